@@ -0,0 +1,548 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package acmetest provides a minimal RFC 8555 ACME server for testing
+// clients.  It verifies signed requests, validates HTTP-01 challenges against
+// a configured address, and issues certificates signed by its own CA.
+package acmetest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/control-center/serviced/acme"
+	xacme "golang.org/x/crypto/acme"
+)
+
+// Server is an ACME server stand-in
+type Server struct {
+	*httptest.Server
+
+	// ChallengeAddr is the host:port that HTTP-01 challenges are fetched from,
+	// instead of port 80 of the domain.
+	ChallengeAddr string
+
+	// ChallengeType is the only type of challenge that is offered
+	ChallengeType string
+
+	// Validity is how long issued certificates are valid for
+	Validity time.Duration
+
+	ca    *x509.Certificate
+	caKey *ecdsa.PrivateKey
+
+	mu       sync.Mutex
+	serial   int64
+	nonces   map[string]bool
+	accounts map[string]*ecdsa.PublicKey
+	orders   map[string]*order
+	authzs   map[string]*authz
+	certs    map[string][]byte
+}
+
+type identifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type problem struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail,omitempty"`
+	Status int    `json:"status,omitempty"`
+}
+
+type challenge struct {
+	Type   string   `json:"type"`
+	URL    string   `json:"url"`
+	Token  string   `json:"token"`
+	Status string   `json:"status"`
+	Error  *problem `json:"error,omitempty"`
+}
+
+type authz struct {
+	Identifier identifier  `json:"identifier"`
+	Status     string      `json:"status"`
+	Challenges []challenge `json:"challenges"`
+	account    string
+}
+
+type order struct {
+	Status         string       `json:"status"`
+	Identifiers    []identifier `json:"identifiers"`
+	Authorizations []string     `json:"authorizations"`
+	Finalize       string       `json:"finalize"`
+	Certificate    string       `json:"certificate,omitempty"`
+	account        string
+	authzs         []*authz
+}
+
+// NewServer starts a server that validates challenges at challengeAddr
+func NewServer(challengeAddr string) *Server {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "acmetest CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		panic(err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		panic(err)
+	}
+	s := &Server{
+		ChallengeAddr: challengeAddr,
+		ChallengeType: "http-01",
+		Validity:      90 * 24 * time.Hour,
+		ca:            ca,
+		caKey:         caKey,
+		serial:        1,
+		nonces:        make(map[string]bool),
+		accounts:      make(map[string]*ecdsa.PublicKey),
+		orders:        make(map[string]*order),
+		authzs:        make(map[string]*authz),
+		certs:         make(map[string][]byte),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// DirectoryURL returns the url of the server's directory
+func (s *Server) DirectoryURL() string {
+	return s.URL + "/directory"
+}
+
+// Roots returns a pool with the CA that signs the issued certificates
+func (s *Server) Roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(s.ca)
+	return pool
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/directory" {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"newNonce":   s.URL + "/new-nonce",
+			"newAccount": s.URL + "/new-account",
+			"newOrder":   s.URL + "/new-order",
+		})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("Replay-Nonce", s.newNonce())
+	if r.URL.Path == "/new-nonce" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != "POST" {
+		writeProblem(w, http.StatusMethodNotAllowed, "malformed", "requests must be signed")
+		return
+	}
+
+	account, pub, payload, ok := s.verify(w, r)
+	if !ok {
+		return
+	}
+	path := r.URL.Path
+	switch {
+	case path == "/new-account":
+		s.newAccount(w, account, pub)
+	case path == "/new-order":
+		s.newOrder(w, account, payload)
+	case strings.HasPrefix(path, "/order/"):
+		s.getOrder(w, account, s.URL+path)
+	case strings.HasPrefix(path, "/authz/"):
+		s.getAuthz(w, account, s.URL+path)
+	case strings.HasPrefix(path, "/challenge/"):
+		s.validate(w, account, strings.TrimPrefix(path, "/challenge/"))
+	case strings.HasPrefix(path, "/finalize/"):
+		s.finalize(w, account, s.URL+"/order/"+strings.TrimPrefix(path, "/finalize/"), payload)
+	case strings.HasPrefix(path, "/cert/"):
+		cert, ok := s.certs[s.URL+path]
+		if !ok {
+			writeProblem(w, http.StatusNotFound, "malformed", "no such certificate")
+			return
+		}
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		w.Write(cert)
+	default:
+		writeProblem(w, http.StatusNotFound, "malformed", "no such resource")
+	}
+}
+
+// verify checks the nonce and signature of a flattened JWS request and
+// returns the account that signed it and its key, along with the payload.
+func (s *Server) verify(w http.ResponseWriter, r *http.Request) (string, *ecdsa.PublicKey, []byte, bool) {
+	var jws struct {
+		Protected string `json:"protected"`
+		Payload   string `json:"payload"`
+		Signature string `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&jws); err != nil {
+		writeProblem(w, http.StatusBadRequest, "malformed", err.Error())
+		return "", nil, nil, false
+	}
+	var header struct {
+		Alg   string          `json:"alg"`
+		JWK   json.RawMessage `json:"jwk"`
+		KID   string          `json:"kid"`
+		Nonce string          `json:"nonce"`
+		URL   string          `json:"url"`
+	}
+	if err := decodeSegment(jws.Protected, &header); err != nil {
+		writeProblem(w, http.StatusBadRequest, "malformed", err.Error())
+		return "", nil, nil, false
+	}
+	if !s.nonces[header.Nonce] {
+		writeProblem(w, http.StatusBadRequest, "badNonce", "unknown nonce")
+		return "", nil, nil, false
+	}
+	delete(s.nonces, header.Nonce)
+	if header.URL != s.URL+r.URL.Path {
+		writeProblem(w, http.StatusUnauthorized, "unauthorized", "url does not match")
+		return "", nil, nil, false
+	}
+	if header.Alg != "ES256" {
+		writeProblem(w, http.StatusBadRequest, "badSignatureAlgorithm", "only ES256 is supported")
+		return "", nil, nil, false
+	}
+
+	var account string
+	var pub *ecdsa.PublicKey
+	if len(header.JWK) > 0 {
+		if r.URL.Path != "/new-account" {
+			writeProblem(w, http.StatusBadRequest, "malformed", "expected kid")
+			return "", nil, nil, false
+		}
+		var err error
+		if pub, err = parseJWK(header.JWK); err != nil {
+			writeProblem(w, http.StatusBadRequest, "badPublicKey", err.Error())
+			return "", nil, nil, false
+		}
+		thumbprint, _ := xacme.JWKThumbprint(pub)
+		account = s.URL + "/account/" + thumbprint
+	} else if pub = s.accounts[header.KID]; pub == nil {
+		writeProblem(w, http.StatusBadRequest, "accountDoesNotExist", "unknown account")
+		return "", nil, nil, false
+	} else {
+		account = header.KID
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(jws.Signature)
+	if err != nil || len(sig) != 64 {
+		writeProblem(w, http.StatusBadRequest, "malformed", "invalid signature")
+		return "", nil, nil, false
+	}
+	hash := sha256.Sum256([]byte(jws.Protected + "." + jws.Payload))
+	r1, s1 := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(pub, hash[:], r1, s1) {
+		writeProblem(w, http.StatusForbidden, "unauthorized", "signature does not verify")
+		return "", nil, nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(jws.Payload)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, "malformed", err.Error())
+		return "", nil, nil, false
+	}
+	return account, pub, payload, true
+}
+
+// newAccount registers the account, or returns it if it is already
+// registered.
+func (s *Server) newAccount(w http.ResponseWriter, account string, pub *ecdsa.PublicKey) {
+	w.Header().Set("Location", account)
+	status := http.StatusOK
+	if s.accounts[account] == nil {
+		s.accounts[account] = pub
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, map[string]string{"status": xacme.StatusValid})
+}
+
+func (s *Server) newOrder(w http.ResponseWriter, account string, payload []byte) {
+	var req struct {
+		Identifiers []identifier `json:"identifiers"`
+	}
+	if err := json.Unmarshal(payload, &req); err != nil || len(req.Identifiers) == 0 {
+		writeProblem(w, http.StatusBadRequest, "malformed", "no identifiers")
+		return
+	}
+	id := s.nextID()
+	o := &order{
+		Status:      xacme.StatusPending,
+		Identifiers: req.Identifiers,
+		Finalize:    fmt.Sprintf("%s/finalize/%s", s.URL, id),
+		account:     account,
+	}
+	for _, ident := range req.Identifiers {
+		authzID := s.nextID()
+		a := &authz{
+			Identifier: ident,
+			Status:     xacme.StatusPending,
+			Challenges: []challenge{{
+				Type:   s.ChallengeType,
+				URL:    fmt.Sprintf("%s/challenge/%s", s.URL, authzID),
+				Token:  s.newToken(),
+				Status: xacme.StatusPending,
+			}},
+			account: account,
+		}
+		authzURL := fmt.Sprintf("%s/authz/%s", s.URL, authzID)
+		s.authzs[authzURL] = a
+		o.authzs = append(o.authzs, a)
+		o.Authorizations = append(o.Authorizations, authzURL)
+	}
+	orderURL := fmt.Sprintf("%s/order/%s", s.URL, id)
+	s.orders[orderURL] = o
+	w.Header().Set("Location", orderURL)
+	writeJSON(w, http.StatusCreated, o)
+}
+
+func (s *Server) getOrder(w http.ResponseWriter, account, orderURL string) {
+	o, ok := s.orders[orderURL]
+	if !ok || o.account != account {
+		writeProblem(w, http.StatusNotFound, "malformed", "no such order")
+		return
+	}
+	if o.Status == xacme.StatusPending {
+		ready := true
+		for _, a := range o.authzs {
+			switch a.Status {
+			case xacme.StatusInvalid:
+				o.Status = xacme.StatusInvalid
+			case xacme.StatusValid:
+			default:
+				ready = false
+			}
+		}
+		if ready && o.Status == xacme.StatusPending {
+			o.Status = xacme.StatusReady
+		}
+	}
+	w.Header().Set("Location", orderURL)
+	writeJSON(w, http.StatusOK, o)
+}
+
+func (s *Server) getAuthz(w http.ResponseWriter, account, authzURL string) {
+	a, ok := s.authzs[authzURL]
+	if !ok || a.account != account {
+		writeProblem(w, http.StatusNotFound, "malformed", "no such authorization")
+		return
+	}
+	writeJSON(w, http.StatusOK, a)
+}
+
+// validate fetches the key authorization from the challenge address, with
+// the domain being validated as the host.  Validation is done before the
+// response, so clients never have to wait for it.
+func (s *Server) validate(w http.ResponseWriter, account, authzID string) {
+	a, ok := s.authzs[s.URL+"/authz/"+authzID]
+	if !ok || a.account != account {
+		writeProblem(w, http.StatusNotFound, "malformed", "no such challenge")
+		return
+	}
+	chal := &a.Challenges[0]
+	thumbprint, _ := xacme.JWKThumbprint(s.accounts[account])
+	expected := chal.Token + "." + thumbprint
+
+	req, err := http.NewRequest("GET", "http://"+s.ChallengeAddr+acme.ChallengePath+chal.Token, nil)
+	if err != nil {
+		writeProblem(w, http.StatusInternalServerError, "serverInternal", err.Error())
+		return
+	}
+	req.Host = a.Identifier.Value
+	chal.Status, a.Status = xacme.StatusValid, xacme.StatusValid
+	if resp, err := http.DefaultClient.Do(req); err != nil {
+		chal.Error = &problem{Type: "urn:ietf:params:acme:error:connection", Detail: err.Error()}
+	} else {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) != expected {
+			chal.Error = &problem{Type: "urn:ietf:params:acme:error:unauthorized", Detail: fmt.Sprintf("got %q from %s", body, req.URL)}
+		}
+	}
+	if chal.Error != nil {
+		chal.Status, a.Status = xacme.StatusInvalid, xacme.StatusInvalid
+	}
+	writeJSON(w, http.StatusOK, chal)
+}
+
+func (s *Server) finalize(w http.ResponseWriter, account, orderURL string, payload []byte) {
+	o, ok := s.orders[orderURL]
+	if !ok || o.account != account {
+		writeProblem(w, http.StatusNotFound, "malformed", "no such order")
+		return
+	}
+	if o.Status != xacme.StatusReady {
+		writeProblem(w, http.StatusForbidden, "orderNotReady", "order is "+o.Status)
+		return
+	}
+	var req struct {
+		CSR string `json:"csr"`
+	}
+	if err := json.Unmarshal(payload, &req); err != nil {
+		writeProblem(w, http.StatusBadRequest, "malformed", err.Error())
+		return
+	}
+	der, err := base64.RawURLEncoding.DecodeString(req.CSR)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, "badCSR", err.Error())
+		return
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err == nil {
+		err = csr.CheckSignature()
+	}
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, "badCSR", err.Error())
+		return
+	}
+	names := make(map[string]bool)
+	for _, ident := range o.Identifiers {
+		names[ident.Value] = true
+	}
+	for _, name := range csr.DNSNames {
+		if !names[name] {
+			writeProblem(w, http.StatusBadRequest, "badCSR", "unauthorized name "+name)
+			return
+		}
+	}
+
+	s.serial++
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(s.serial),
+		Subject:      pkix.Name{CommonName: csr.Subject.CommonName},
+		DNSNames:     csr.DNSNames,
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(s.Validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, tmpl, s.ca, csr.PublicKey, s.caKey)
+	if err != nil {
+		writeProblem(w, http.StatusInternalServerError, "serverInternal", err.Error())
+		return
+	}
+	certURL := fmt.Sprintf("%s/cert/%d", s.URL, s.serial)
+	s.certs[certURL] = append(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.ca.Raw})...,
+	)
+	o.Status = xacme.StatusValid
+	o.Certificate = certURL
+	w.Header().Set("Location", orderURL)
+	writeJSON(w, http.StatusOK, o)
+}
+
+func (s *Server) newNonce() string {
+	nonce := s.newToken()
+	s.nonces[nonce] = true
+	return nonce
+}
+
+func (s *Server) newToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (s *Server) nextID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%x", b)
+}
+
+// parseJWK decodes a P-256 public key
+func parseJWK(data []byte) (*ecdsa.PublicKey, error) {
+	var jwk struct {
+		Kty string `json:"kty"`
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+	if err := json.Unmarshal(data, &jwk); err != nil {
+		return nil, err
+	}
+	if jwk.Kty != "EC" || jwk.Crv != "P-256" {
+		return nil, errors.New("only P-256 keys are supported")
+	}
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+	if err != nil {
+		return nil, err
+	}
+	return &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func writeProblem(w http.ResponseWriter, status int, typ, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	writeJSON(w, status, problem{
+		Type:   "urn:ietf:params:acme:error:" + typ,
+		Detail: detail,
+		Status: status,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
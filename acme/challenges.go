@@ -0,0 +1,82 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+package acme
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ChallengePath is the path under which the responses to HTTP-01 challenges
+// are served.
+const ChallengePath = "/.well-known/acme-challenge/"
+
+// Challenges holds the responses to pending HTTP-01 challenges, for serving
+// at ChallengePath.
+type Challenges struct {
+	mu    sync.RWMutex
+	auths map[string]string
+}
+
+// NewChallenges returns an empty set of challenges
+func NewChallenges() *Challenges {
+	return &Challenges{auths: make(map[string]string)}
+}
+
+// Present implements Solver
+func (c *Challenges) Present(token, keyAuth string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.auths[token] = keyAuth
+	return nil
+}
+
+// CleanUp implements Solver
+func (c *Challenges) CleanUp(token string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.auths, token)
+	return nil
+}
+
+// Get returns the key authorization of the challenge token
+func (c *Challenges) Get(token string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	keyAuth, ok := c.auths[token]
+	return keyAuth, ok
+}
+
+// ServeHTTP serves the response to the challenge in the request path
+func (c *Challenges) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ServeChallenge(w, r, c.Get)
+}
+
+// ServeChallenge answers a request for ChallengePath/<token> with the key
+// authorization returned by lookup.
+func ServeChallenge(w http.ResponseWriter, r *http.Request, lookup func(token string) (string, bool)) {
+	if !strings.HasPrefix(r.URL.Path, ChallengePath) {
+		http.NotFound(w, r)
+		return
+	}
+	keyAuth, ok := lookup(strings.TrimPrefix(r.URL.Path, ChallengePath))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(keyAuth))
+}
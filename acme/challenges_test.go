@@ -0,0 +1,86 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+// +build unit
+
+package acme_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/control-center/serviced/acme"
+	"github.com/control-center/serviced/acme/acmetest"
+	. "gopkg.in/check.v1"
+)
+
+func TestACME(t *testing.T) { TestingT(t) }
+
+type ACMESuite struct {
+	challenges *Challenges
+	web        *httptest.Server
+	server     *acmetest.Server
+	client     *Client
+}
+
+var _ = Suite(&ACMESuite{})
+
+func (s *ACMESuite) SetUpTest(c *C) {
+	s.challenges = NewChallenges()
+	s.web = httptest.NewServer(s.challenges)
+	s.server = acmetest.NewServer(strings.TrimPrefix(s.web.URL, "http://"))
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, IsNil)
+	s.client = NewClient(s.server.DirectoryURL(), key, "admin@example.com")
+}
+
+func (s *ACMESuite) TearDownTest(c *C) {
+	s.server.Close()
+	s.web.Close()
+}
+
+func (s *ACMESuite) get(c *C, path string) (int, string) {
+	resp, err := http.Get(s.web.URL + path)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, IsNil)
+	return resp.StatusCode, string(body)
+}
+
+func (s *ACMESuite) TestServeChallenge(c *C) {
+	c.Assert(s.challenges.Present("token1", "token1.thumbprint"), IsNil)
+
+	code, body := s.get(c, ChallengePath+"token1")
+	c.Check(code, Equals, http.StatusOK)
+	c.Check(body, Equals, "token1.thumbprint")
+
+	code, _ = s.get(c, ChallengePath+"token2")
+	c.Check(code, Equals, http.StatusNotFound)
+	code, _ = s.get(c, "/token1")
+	c.Check(code, Equals, http.StatusNotFound)
+
+	// challenges are no longer served once they are cleaned up
+	c.Assert(s.challenges.CleanUp("token1"), IsNil)
+	_, ok := s.challenges.Get("token1")
+	c.Check(ok, Equals, false)
+	code, _ = s.get(c, ChallengePath+"token1")
+	c.Check(code, Equals, http.StatusNotFound)
+}
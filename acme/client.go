@@ -0,0 +1,193 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package acme obtains certificates from an ACME (RFC 8555) certificate
+// authority with golang.org/x/crypto/acme, answering HTTP-01 challenges.
+package acme
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/logging"
+	xacme "golang.org/x/crypto/acme"
+)
+
+var plog = logging.PackageLogger()
+
+var (
+	// ErrNoChallenge is returned when the server does not offer an HTTP-01
+	// challenge for a domain
+	ErrNoChallenge = errors.New("acme: no http-01 challenge offered")
+	// ErrNoDomains is returned when a certificate is requested for no domains
+	ErrNoDomains = errors.New("acme: no domains requested")
+)
+
+// Solver answers the HTTP-01 challenges of an order
+type Solver interface {
+	Present(token, keyAuth string) error
+	CleanUp(token string) error
+}
+
+// Client obtains certificates from an ACME server.  The account is registered
+// with the server the first time a certificate is requested.
+type Client struct {
+	*xacme.Client
+	Contact []string
+	Timeout time.Duration
+
+	mu         sync.Mutex
+	registered bool
+}
+
+// NewClient returns a client for the server at directoryURL, using key for
+// the account.  The email address, if set, is registered as the account's
+// contact.
+func NewClient(directoryURL string, key crypto.Signer, email string) *Client {
+	c := &Client{
+		Client:  &xacme.Client{DirectoryURL: directoryURL, Key: key},
+		Timeout: 2 * time.Minute,
+	}
+	if email != "" {
+		c.Contact = []string{"mailto:" + email}
+	}
+	return c
+}
+
+// Obtain orders a certificate for the domains, answering the HTTP-01
+// challenges with solver.  It returns the PEM encoded certificate chain and
+// the PEM encoded private key that was generated for it.
+func (c *Client) Obtain(domains []string, solver Solver) (certPEM, keyPEM []byte, err error) {
+	if len(domains) == 0 {
+		return nil, nil, ErrNoDomains
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	logger := plog.WithFields(log.Fields{
+		"directory": c.DirectoryURL,
+		"domains":   domains,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+
+	if err := c.register(ctx); err != nil {
+		logger.WithError(err).Debug("Could not register ACME account")
+		return nil, nil, err
+	}
+
+	order, err := c.AuthorizeOrder(ctx, xacme.DomainIDs(domains...))
+	if err != nil {
+		logger.WithError(err).Debug("Could not create order")
+		return nil, nil, err
+	}
+	logger = logger.WithField("order", order.URI)
+	logger.Debug("Created order")
+
+	for _, authzURL := range order.AuthzURLs {
+		if err := c.authorize(ctx, authzURL, solver); err != nil {
+			logger.WithError(err).WithField("authorization", authzURL).Debug("Could not authorize domain")
+			return nil, nil, err
+		}
+	}
+	if order, err = c.WaitOrder(ctx, order.URI); err != nil {
+		logger.WithError(err).Debug("Order is not ready to finalize")
+		return nil, nil, err
+	}
+
+	// generate a new key for every certificate
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: domains[0]},
+		DNSNames: domains,
+	}, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	chain, _, err := c.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		logger.WithError(err).Debug("Certificate was not issued")
+		return nil, nil, err
+	}
+	for _, der := range chain {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	logger.Info("Obtained certificate")
+	return certPEM, keyPEM, nil
+}
+
+// register registers the account, if that has not already been done.
+// Registering an existing key looks up its account.
+func (c *Client) register(ctx context.Context) error {
+	if c.registered {
+		return nil
+	}
+	_, err := c.Register(ctx, &xacme.Account{Contact: c.Contact}, xacme.AcceptTOS)
+	if err != nil && err != xacme.ErrAccountAlreadyExists {
+		return err
+	}
+	c.registered = true
+	return nil
+}
+
+// authorize answers the HTTP-01 challenge of an authorization and waits for
+// the server to validate it.
+func (c *Client) authorize(ctx context.Context, authzURL string, solver Solver) error {
+	authz, err := c.GetAuthorization(ctx, authzURL)
+	if err != nil {
+		return err
+	}
+	if authz.Status == xacme.StatusValid {
+		return nil
+	}
+	var chal *xacme.Challenge
+	for _, ch := range authz.Challenges {
+		if ch.Type == "http-01" {
+			chal = ch
+			break
+		}
+	}
+	if chal == nil {
+		return ErrNoChallenge
+	}
+	keyAuth, err := c.HTTP01ChallengeResponse(chal.Token)
+	if err != nil {
+		return err
+	}
+	if err := solver.Present(chal.Token, keyAuth); err != nil {
+		return err
+	}
+	defer solver.CleanUp(chal.Token)
+	if _, err := c.Accept(ctx, chal); err != nil {
+		return err
+	}
+	_, err = c.WaitAuthorization(ctx, authz.URI)
+	return err
+}
@@ -0,0 +1,110 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package acme_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+
+	. "github.com/control-center/serviced/acme"
+	xacme "golang.org/x/crypto/acme"
+	. "gopkg.in/check.v1"
+)
+
+// recordingSolver remembers the tokens it was asked to present
+type recordingSolver struct {
+	*Challenges
+	tokens []string
+}
+
+func (r *recordingSolver) Present(token, keyAuth string) error {
+	r.tokens = append(r.tokens, token)
+	return r.Challenges.Present(token, keyAuth)
+}
+
+func (s *ACMESuite) checkCleanedUp(c *C, tokens []string) {
+	for _, token := range tokens {
+		_, ok := s.challenges.Get(token)
+		c.Check(ok, Equals, false)
+	}
+}
+
+func (s *ACMESuite) TestObtain(c *C) {
+	solver := &recordingSolver{Challenges: s.challenges}
+	certPEM, keyPEM, err := s.client.Obtain([]string{"app.example.com", "www.example.com"}, solver)
+	c.Assert(err, IsNil)
+
+	block, rest := pem.Decode(certPEM)
+	c.Assert(block, NotNil)
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	c.Assert(err, IsNil)
+	c.Check(leaf.Subject.CommonName, Equals, "app.example.com")
+	_, err = leaf.Verify(x509.VerifyOptions{DNSName: "www.example.com", Roots: s.server.Roots()})
+	c.Check(err, IsNil)
+
+	// the issuer is included in the chain
+	block, _ = pem.Decode(rest)
+	c.Check(block, NotNil)
+
+	block, _ = pem.Decode(keyPEM)
+	c.Assert(block, NotNil)
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	c.Assert(err, IsNil)
+	c.Check(key.PublicKey.X.Cmp(leaf.PublicKey.(*ecdsa.PublicKey).X), Equals, 0)
+
+	// challenges are cleaned up once validated
+	c.Check(solver.tokens, HasLen, 2)
+	s.checkCleanedUp(c, solver.tokens)
+
+	// the account is reused for renewals, including by a new client
+	_, _, err = s.client.Obtain([]string{"app.example.com"}, s.challenges)
+	c.Check(err, IsNil)
+	client := NewClient(s.server.DirectoryURL(), s.client.Key, "")
+	_, _, err = client.Obtain([]string{"app.example.com"}, s.challenges)
+	c.Check(err, IsNil)
+}
+
+type wrongSolver struct{ *recordingSolver }
+
+func (w wrongSolver) Present(token, keyAuth string) error {
+	return w.recordingSolver.Present(token, "wrong")
+}
+
+func (s *ACMESuite) TestObtainInvalidChallenge(c *C) {
+	solver := wrongSolver{&recordingSolver{Challenges: s.challenges}}
+	_, _, err := s.client.Obtain([]string{"app.example.com"}, solver)
+	authzErr, ok := err.(*xacme.AuthorizationError)
+	c.Assert(ok, Equals, true, Commentf("unexpected error %v", err))
+	c.Assert(authzErr.Errors, HasLen, 1)
+	problem, ok := authzErr.Errors[0].(*xacme.Error)
+	c.Assert(ok, Equals, true)
+	c.Check(problem.ProblemType, Equals, "urn:ietf:params:acme:error:unauthorized")
+	c.Check(solver.tokens, HasLen, 1)
+	s.checkCleanedUp(c, solver.tokens)
+}
+
+func (s *ACMESuite) TestObtainNoChallenge(c *C) {
+	s.server.ChallengeType = "dns-01"
+	_, _, err := s.client.Obtain([]string{"app.example.com"}, s.challenges)
+	c.Check(err, Equals, ErrNoChallenge)
+}
+
+func (s *ACMESuite) TestObtainNoDomains(c *C) {
+	c.Check(s.client.Contact, DeepEquals, []string{"mailto:admin@example.com"})
+	_, _, err := s.client.Obtain(nil, s.challenges)
+	c.Check(err, Equals, ErrNoDomains)
+}
@@ -49,4 +49,13 @@ const (
 
 	// Deploy is the string value for the deploy action when logging.
 	Deploy = "deploy"
+
+	// Shell is the string for opening a shell in a service's container when logging.
+	Shell = "shell"
+
+	// Attach is the string for attaching to a running service instance when logging.
+	Attach = "attach"
+
+	// Run is the string for running a service's predefined command when logging.
+	Run = "run"
 )
@@ -38,11 +38,25 @@
 
 		time="2017-05-11T19:41:10Z" level=warning msg="Adding Resource Pool Swimming" action=add success=false user=system type=resourcepool id=Swimming
 
+	Audit Trail
+
+	Each entry is also appended to the audit trail, which is stored in the "controlplane" index of the datastore
+	passed to the "Message" method (see the domain/auditlog package).  Entries in the trail are numbered in
+	sequence, and each one carries a hash of its contents and of the hash of the entry before it.  Modifying,
+	removing or inserting entries breaks the chain, which "serviced audit verify" reports.  The trail can be
+	queried with "serviced audit list" or the /api/v2/audit endpoint.  A failure to write the trail is logged
+	but does not fail the action being audited.
+
+	Sessions opened with "serviced service shell", "attach" and "run" are audited when they start and when they
+	end, under the "shell", "attach" and "run" actions, with the user that ran the command and its exit code.
+	With --record, the session's output is kept on the master and can be played back with "serviced audit replay".
+
 	API
 
-	The Logger interface provides a fluent API for creating log entries.  A new Logger can be retrieved by calling the NewLogger method.
+	The Logger interface provides a fluent API for creating log entries.  A new Logger can be retrieved by calling the NewLogger
+	method with the store that the audit trail is appended to.
 
-		var auditLogger = audit.NewLogger()
+		var auditLogger = audit.NewLogger(auditlog.NewStore())
 
 	The default implementation that is returned is a wrapped logri Logger.  Logri is a package owned by Zenoss that adds additional
 	functionality to Loggers from the third party package, logrus.
@@ -14,10 +14,13 @@
 package audit
 
 import (
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/control-center/serviced/datastore"
+	"github.com/control-center/serviced/domain/auditlog"
 	"github.com/control-center/serviced/logging"
 	"github.com/zenoss/logri"
 )
@@ -64,16 +67,20 @@ type Logger interface {
 
 // NewLogger returns a default implementation of the audit logger.  The "user" will default
 // to "system" unless otherwise specified in the context.  This wraps a logri Logger,
-// and will write to the location specified in the logger config.
-func NewLogger() Logger {
+// and will write to the location specified in the logger config.  Entries are also
+// appended to the audit trail in store, using the datastore of the context passed to
+// Message.
+func NewLogger(store auditlog.Store) Logger {
 	l := logri.GetLogger("audit")
-	return &logger{loggeri: l}
+	return &logger{loggeri: l, trail: auditlog.NewTrail(store)}
 }
 
 type logger struct {
 	entry   *logrus.Entry
 	message string
 	loggeri *logri.Logger
+	ctx     datastore.Context
+	trail   *auditlog.Trail
 }
 
 func (l *logger) Action(action string) Logger {
@@ -83,6 +90,7 @@ func (l *logger) Action(action string) Logger {
 func (l *logger) Message(ctx datastore.Context, message string) Logger {
 	result := l.newLoggerWith("user", ctx.User())
 	result.message = message
+	result.ctx = ctx
 	return result
 }
 
@@ -114,6 +122,8 @@ func (l *logger) newLoggerWithFields(fields logrus.Fields) *logger {
 		entry:   l.entry,
 		message: l.message,
 		loggeri: l.loggeri,
+		ctx:     l.ctx,
+		trail:   l.trail,
 	}
 	result.addFields(fields)
 	return result
@@ -166,4 +176,38 @@ func (l *logger) log(success bool) {
 	} else {
 		entry.Warn(l.message)
 	}
+	l.record(success)
+}
+
+// record appends the entry to the audit trail.  A failure to write the trail
+// does not fail the action being audited.
+func (l *logger) record(success bool) {
+	if l.trail == nil || l.ctx == nil {
+		return
+	}
+	record := &auditlog.Entry{
+		Timestamp: time.Now().UTC(),
+		Message:   l.message,
+		Success:   success,
+		Fields:    make(map[string]string),
+	}
+	for name, value := range l.entry.Data {
+		v := fmt.Sprintf("%v", value)
+		switch name {
+		case "user":
+			record.User = v
+		case "action":
+			record.Action = v
+		case "type":
+			record.EntityType = v
+		case "id":
+			record.EntityID = v
+		case "success":
+		default:
+			record.Fields[name] = v
+		}
+	}
+	if err := l.trail.Append(l.ctx, record); err != nil {
+		plog.WithFields(l.entry.Data).WithError(err).Warn("Unable to write to the audit trail")
+	}
 }
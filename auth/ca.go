@@ -0,0 +1,377 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+/*
+   The master runs a certificate authority with its own RSA keys.  Each host
+   agent gets a short-lived certificate for its mux, plus one for each tenant
+   whose containers it runs.  The host ID is the common name of a certificate
+   and the tenant ID is its organizational unit, so the mux can tell which
+   tenant a connection comes from.
+*/
+
+const (
+	// MuxCertFileName, MuxKeyFileName and MuxCAFileName are the files in a
+	// directory of mux credentials
+	MuxCertFileName = "mux.crt"
+	MuxKeyFileName  = "mux.key"
+	MuxCAFileName   = "ca.crt"
+
+	caCommonName   = "serviced mux CA"
+	caOrganization = "serviced"
+	caLifetime     = 10 * 365 * 24 * time.Hour
+)
+
+var (
+	// ErrNoMuxCredentials is thrown when no mux certificate has been loaded
+	ErrNoMuxCredentials = errors.New("No mux certificate available")
+	// ErrNotMuxCertificate is thrown when a certificate was not issued by the mux CA
+	ErrNotMuxCertificate = errors.New("Not a mux certificate")
+
+	caLock sync.Mutex
+	caCert *x509.Certificate
+)
+
+// MuxIdentity is who a mux certificate was issued to.  The tenant is empty
+// on the certificate of the host itself.
+type MuxIdentity struct {
+	HostID   string
+	TenantID string
+}
+
+// getCA returns the CA certificate and key, which only the master has.  The
+// subject key id comes from the key, so certificates issued before the
+// master restarted still verify against the new CA certificate.
+func getCA() (*x509.Certificate, *rsa.PrivateKey, error) {
+	key, err := getMasterPrivateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	private, err := verifyRSAPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	caLock.Lock()
+	defer caLock.Unlock()
+	if caCert != nil {
+		if public, ok := caCert.PublicKey.(*rsa.PublicKey); ok && public.N.Cmp(private.N) == 0 {
+			return caCert, private, nil
+		}
+	}
+
+	keyID, err := subjectKeyID(&private.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			CommonName:   caCommonName,
+			Organization: []string{caOrganization},
+		},
+		NotBefore:             now.Add(-ClockDriftDelta),
+		NotAfter:              now.Add(caLifetime),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		SubjectKeyId:          keyID,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &private.PublicKey, private)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	caCert = cert
+	return caCert, private, nil
+}
+
+func subjectKeyID(public *rsa.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum(der)
+	return sum[:], nil
+}
+
+// MuxCACertificate returns the PEM encoded certificate of the mux CA.  This
+// will return an error if we are not the master.
+func MuxCACertificate() ([]byte, error) {
+	ca, _, err := getCA()
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), nil
+}
+
+// CreateMuxCertificate issues a PEM encoded certificate for the public key,
+// which identifies the host and tenant to a mux.  Host certificates also
+// serve the mux, so they are valid for the ip addresses of the host.  This
+// will return an error if we are not the master.
+func CreateMuxCertificate(identity MuxIdentity, ips []string, publicKeyPEM []byte, expiration time.Duration) ([]byte, int64, error) {
+	ca, private, err := getCA()
+	if err != nil {
+		return nil, 0, err
+	}
+	public, err := RSAPublicKeyFromPEM(publicKeyPEM)
+	if err != nil {
+		return nil, 0, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, 0, err
+	}
+	keyID, err := subjectKeyID(public)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	expires := now.Add(expiration)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   identity.HostID,
+			Organization: []string{caOrganization},
+		},
+		NotBefore:    now.Add(-ClockDriftDelta),
+		NotAfter:     expires,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		SubjectKeyId: keyID,
+	}
+	if identity.TenantID != "" {
+		template.Subject.OrganizationalUnit = []string{identity.TenantID}
+	} else {
+		template.ExtKeyUsage = append(template.ExtKeyUsage, x509.ExtKeyUsageServerAuth)
+		for _, ip := range ips {
+			if parsed := net.ParseIP(ip); parsed != nil {
+				template.IPAddresses = append(template.IPAddresses, parsed)
+			}
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, public, private)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), expires.Unix(), nil
+}
+
+// GetMuxIdentity returns who a verified mux certificate was issued to.
+func GetMuxIdentity(cert *x509.Certificate) (MuxIdentity, error) {
+	if cert.Subject.CommonName == "" || len(cert.Subject.Organization) != 1 || cert.Subject.Organization[0] != caOrganization {
+		return MuxIdentity{}, ErrNotMuxCertificate
+	}
+	identity := MuxIdentity{HostID: cert.Subject.CommonName}
+	if len(cert.Subject.OrganizationalUnit) > 0 {
+		identity.TenantID = cert.Subject.OrganizationalUnit[0]
+	}
+	return identity, nil
+}
+
+// MuxCredentials hold the certificate, private key and CA that authenticate
+// connections to and from the mux.  They are reloaded whenever a new
+// certificate is issued.
+type MuxCredentials struct {
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	expires time.Time
+}
+
+// Update loads PEM encoded credentials.
+func (c *MuxCredentials) Update(certPEM, keyPEM, caPEM []byte) error {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return err
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return ErrNotMuxCertificate
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cert, c.pool, c.expires = &cert, pool, leaf.NotAfter
+	return nil
+}
+
+// Load reads the credentials from a directory.
+func (c *MuxCredentials) Load(dir string) error {
+	certPEM, err := ioutil.ReadFile(filepath.Join(dir, MuxCertFileName))
+	if err != nil {
+		return err
+	}
+	keyPEM, err := ioutil.ReadFile(filepath.Join(dir, MuxKeyFileName))
+	if err != nil {
+		return err
+	}
+	caPEM, err := ioutil.ReadFile(filepath.Join(dir, MuxCAFileName))
+	if err != nil {
+		return err
+	}
+	return c.Update(certPEM, keyPEM, caPEM)
+}
+
+// Expires returns when the current certificate expires, or the zero time if
+// there isn't one.
+func (c *MuxCredentials) Expires() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.expires
+}
+
+// ServerConfig returns the TLS configuration of a mux that requires clients
+// to present a certificate from the CA.  The rest of the settings are copied
+// from base.
+func (c *MuxCredentials) ServerConfig(base *tls.Config) (*tls.Config, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.cert == nil {
+		return nil, ErrNoMuxCredentials
+	}
+	return &tls.Config{
+		Certificates:             []tls.Certificate{*c.cert},
+		ClientAuth:               tls.RequireAndVerifyClientCert,
+		ClientCAs:                c.pool,
+		MinVersion:               base.MinVersion,
+		CipherSuites:             base.CipherSuites,
+		PreferServerCipherSuites: base.PreferServerCipherSuites,
+	}, nil
+}
+
+// ClientConfig returns the TLS configuration for connecting to the mux at
+// the host ip, which must present a host certificate from the CA.
+func (c *MuxCredentials) ClientConfig(hostIP string) (*tls.Config, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.cert == nil {
+		return nil, ErrNoMuxCredentials
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{*c.cert},
+		RootCAs:      c.pool,
+		ServerName:   hostIP,
+	}, nil
+}
+
+// Listener returns a listener that requires every connection accepted by
+// inner to authenticate with a certificate from the CA.
+func (c *MuxCredentials) Listener(inner net.Listener, base *tls.Config) net.Listener {
+	return &muxTLSListener{Listener: inner, creds: c, base: base}
+}
+
+type muxTLSListener struct {
+	net.Listener
+	creds *MuxCredentials
+	base  *tls.Config
+}
+
+// Accept waits for the next connection, turning it away if no certificate
+// has been loaded yet.
+func (l *muxTLSListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		config, err := l.creds.ServerConfig(l.base)
+		if err != nil {
+			log.WithError(err).Debug("Turning away mux connection")
+			conn.Close()
+			continue
+		}
+		return tls.Server(conn, config), nil
+	}
+}
+
+// WriteMuxCredentials saves PEM encoded credentials to a directory that only
+// the owner can read.
+func WriteMuxCredentials(dir string, certPEM, keyPEM, caPEM []byte) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{MuxKeyFileName, keyPEM},
+		{MuxCAFileName, caPEM},
+		{MuxCertFileName, certPEM},
+	}
+	for _, f := range files {
+		tmp := filepath.Join(dir, "."+f.name)
+		if err := ioutil.WriteFile(tmp, f.data, 0600); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, filepath.Join(dir, f.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WatchMuxCredentials loads the credentials in a directory and reloads them
+// whenever the certificate is replaced.
+func WatchMuxCredentials(dir string, creds *MuxCredentials, cancel <-chan interface{}) error {
+	log := log.WithField("directory", dir)
+
+	loadCredentials := func() {
+		if err := creds.Load(dir); err != nil {
+			log.WithError(err).Warn("Unable to load mux credentials. Continuing to watch for changes")
+		} else {
+			log.WithField("expires", creds.Expires()).Info("Loaded mux credentials")
+		}
+	}
+
+	// Try an initial load without any file changes
+	loadCredentials()
+
+	filechanges, err := NotifyOnChange(filepath.Join(dir, MuxCertFileName), fsnotify.Write|fsnotify.Create, cancel)
+	if err != nil {
+		return err
+	}
+	for _ = range filechanges {
+		loadCredentials()
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package auth_test
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"os"
+	"time"
+
+	"github.com/control-center/serviced/auth"
+	. "gopkg.in/check.v1"
+)
+
+// muxCredentials issues a certificate to the identity and loads it
+func (s *TestAuthSuite) muxCredentials(c *C, identity auth.MuxIdentity) *auth.MuxCredentials {
+	public, private, err := auth.GenerateRSAKeyPairPEM(nil)
+	c.Assert(err, IsNil)
+	cert, expires, err := auth.CreateMuxCertificate(identity, []string{"127.0.0.1"}, public, time.Hour)
+	c.Assert(err, IsNil)
+	c.Assert(expires > time.Now().Unix(), Equals, true)
+	ca, err := auth.MuxCACertificate()
+	c.Assert(err, IsNil)
+
+	creds := &auth.MuxCredentials{}
+	c.Assert(creds.Update(cert, private, ca), IsNil)
+	return creds
+}
+
+func (s *TestAuthSuite) TestMuxCredentials_Identity(c *C) {
+	host := s.muxCredentials(c, auth.MuxIdentity{HostID: s.hostId})
+	tenant := s.muxCredentials(c, auth.MuxIdentity{HostID: s.hostId, TenantID: "tenant"})
+
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	listener = host.Listener(listener, &tls.Config{})
+	defer listener.Close()
+
+	identities := make(chan auth.MuxIdentity, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			tlsConn := conn.(*tls.Conn)
+			if err := tlsConn.Handshake(); err == nil {
+				identity, _ := auth.GetMuxIdentity(tlsConn.ConnectionState().PeerCertificates[0])
+				identities <- identity
+			}
+			conn.Close()
+		}
+	}()
+
+	// a tenant certificate identifies the tenant
+	config, err := tenant.ClientConfig("127.0.0.1")
+	c.Assert(err, IsNil)
+	conn, err := tls.Dial("tcp4", listener.Addr().String(), config)
+	c.Assert(err, IsNil)
+	conn.Close()
+	select {
+	case identity := <-identities:
+		c.Assert(identity, Equals, auth.MuxIdentity{HostID: s.hostId, TenantID: "tenant"})
+	case <-time.After(5 * time.Second):
+		c.Fatalf("Mux did not accept the connection")
+	}
+
+	// the mux has to be the host it claims to be
+	config, err = tenant.ClientConfig("127.0.0.2")
+	c.Assert(err, IsNil)
+	_, err = tls.Dial("tcp4", listener.Addr().String(), config)
+	c.Assert(err, NotNil)
+
+	// clients need a certificate
+	config, err = tenant.ClientConfig("127.0.0.1")
+	c.Assert(err, IsNil)
+	config.Certificates = nil
+	if conn, err := tls.Dial("tcp4", listener.Addr().String(), config); err == nil {
+		// the server may only hang up after the client finished its side
+		_, err = conn.Read(make([]byte, 1))
+		c.Assert(err, NotNil)
+	}
+}
+
+func (s *TestAuthSuite) TestMuxCredentials_NotLoaded(c *C) {
+	creds := &auth.MuxCredentials{}
+	_, err := creds.ClientConfig("127.0.0.1")
+	c.Assert(err, Equals, auth.ErrNoMuxCredentials)
+	_, err = creds.ServerConfig(&tls.Config{})
+	c.Assert(err, Equals, auth.ErrNoMuxCredentials)
+	c.Assert(creds.Expires().IsZero(), Equals, true)
+}
+
+func (s *TestAuthSuite) TestMuxCredentials_Files(c *C) {
+	public, private, err := auth.GenerateRSAKeyPairPEM(nil)
+	c.Assert(err, IsNil)
+	cert, expires, err := auth.CreateMuxCertificate(auth.MuxIdentity{HostID: s.hostId}, nil, public, time.Hour)
+	c.Assert(err, IsNil)
+	ca, err := auth.MuxCACertificate()
+	c.Assert(err, IsNil)
+
+	dir, err := ioutil.TempDir("", "mux-credentials")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	c.Assert(auth.WriteMuxCredentials(dir, cert, private, ca), IsNil)
+
+	creds := &auth.MuxCredentials{}
+	c.Assert(creds.Load(dir), IsNil)
+	c.Assert(creds.Expires().Unix(), Equals, expires)
+}
+
+func (s *TestAuthSuite) TestCreateMuxCertificate_NotMaster(c *C) {
+	auth.ClearKeys()
+	_, _, err := auth.CreateMuxCertificate(auth.MuxIdentity{HostID: s.hostId}, nil, s.delegatePubPEM, time.Hour)
+	c.Assert(err, Equals, auth.ErrNoPrivateKey)
+}
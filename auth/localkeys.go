@@ -16,6 +16,9 @@ package auth
 import (
 	"bytes"
 	"crypto"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -177,6 +180,23 @@ func getMasterPrivateKey() (crypto.PrivateKey, error) {
 	return masterKeys.private, nil
 }
 
+// MasterSecret derives a 256-bit secret from the master private key, for
+// encrypting data that only the master can read.  Each purpose gets its own
+// secret.
+func MasterSecret(purpose string) ([]byte, error) {
+	key, err := getMasterPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, err := verifyRSAPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, x509.MarshalPKCS1PrivateKey(rsaKey))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil), nil
+}
+
 // LoadKeysFromFile loads keys from a file on disk.
 func LoadDelegateKeysFromFile(filename string) error {
 	pub, priv, err := LoadKeyPairFromFile(filename)
@@ -27,10 +27,19 @@ import (
    ---------------------------------------------------------------------------------------------------------
    | Auth Token length (4 bytes)  |     Auth Token (N bytes)  | Address (6 bytes) |  Signature (256 bytes) |
    ---------------------------------------------------------------------------------------------------------
+
+   A sender that wants the receiver to relay udp datagrams instead of a tcp stream appends a
+   protocol byte to the address.  A sender that wants to keep the connection open and multiplex
+   many streams over it sends an empty address with the session protocol byte instead; the
+   address of each stream is then sent when the stream is opened.
 */
 
 const (
-	ADDRESS_BYTES = 6
+	ADDRESS_BYTES     = 6
+	UDP_ADDRESS_BYTES = ADDRESS_BYTES + 1
+
+	udpProtocol     byte = 'u'
+	sessionProtocol byte = 's'
 )
 
 var (
@@ -48,7 +57,59 @@ func AddSignedMuxHeader(w io.Writer, address []byte, token string) error {
 	return err
 }
 
+// AddSignedUDPMuxHeader writes a mux header that asks the receiver to relay
+// udp datagrams to the address.
+func AddSignedUDPMuxHeader(w io.Writer, address []byte, token string) error {
+	if len(address) != ADDRESS_BYTES {
+		return ErrBadMuxAddress
+	}
+	payload, _ := MuxAddressPayload(address, true)
+	header := NewAuthHeaderWriterTo([]byte(token), payload, &delegateKeys)
+	_, err := header.WriteTo(w)
+	return err
+}
+
+// AddSignedSessionMuxHeader writes a mux header that asks the receiver to
+// keep the connection open for a session of multiplexed streams.
+func AddSignedSessionMuxHeader(w io.Writer, token string) error {
+	payload := append(make([]byte, ADDRESS_BYTES), sessionProtocol)
+	header := NewAuthHeaderWriterTo([]byte(token), payload, &delegateKeys)
+	_, err := header.WriteTo(w)
+	return err
+}
+
+// MuxAddressPayload returns the payload that asks the receiver to connect to
+// the address, as it is read back by SplitMuxAddress.
+func MuxAddressPayload(address []byte, udp bool) ([]byte, error) {
+	if len(address) != ADDRESS_BYTES {
+		return nil, ErrBadMuxAddress
+	}
+	payload := append([]byte{}, address...)
+	if udp {
+		payload = append(payload, udpProtocol)
+	}
+	return payload, nil
+}
+
+// IsSessionMuxHeader returns true if the payload read from a mux header asks
+// for a session of multiplexed streams.
+func IsSessionMuxHeader(payload []byte) bool {
+	return len(payload) == UDP_ADDRESS_BYTES && payload[ADDRESS_BYTES] == sessionProtocol
+}
+
 func ReadMuxHeader(r io.Reader) ([]byte, Identity, error) {
 	sender, _, address, err := ReadAuthHeader(r)
 	return address, sender, err
 }
+
+// SplitMuxAddress separates the address read from a mux header from its
+// protocol, and returns true if the sender asked for udp.
+func SplitMuxAddress(payload []byte) ([]byte, bool, error) {
+	switch {
+	case len(payload) == ADDRESS_BYTES:
+		return payload, false, nil
+	case len(payload) == UDP_ADDRESS_BYTES && payload[ADDRESS_BYTES] == udpProtocol:
+		return payload[:ADDRESS_BYTES], true, nil
+	}
+	return nil, false, ErrBadMuxAddress
+}
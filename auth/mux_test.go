@@ -58,3 +58,65 @@ func (s *TestAuthSuite) TestBuildAndExtractHeader(c *C) {
 	c.Assert(s.admin, Equals, ident.HasAdminAccess())
 	c.Assert(s.dfs, Equals, ident.HasDFSAccess())
 }
+
+func (s *TestAuthSuite) TestBuildAndExtractUDPHeader(c *C) {
+	token, _, _ := auth.CreateJWTIdentity(s.hostId, s.poolId, s.admin, s.dfs, s.delegatePubPEM, time.Hour)
+	addr := "zenoss"
+	var b bytes.Buffer
+
+	err := auth.AddSignedUDPMuxHeader(&b, []byte(addr), token)
+	c.Assert(err, IsNil)
+
+	payload, _, err := auth.ReadMuxHeader(&b)
+	c.Assert(err, IsNil)
+	extractedAddr, udp, err := auth.SplitMuxAddress(payload)
+	c.Assert(err, IsNil)
+	c.Assert(string(extractedAddr), Equals, addr)
+	c.Assert(udp, Equals, true)
+}
+
+func (s *TestAuthSuite) TestSplitMuxAddress(c *C) {
+	addr, udp, err := auth.SplitMuxAddress([]byte("zenoss"))
+	c.Assert(err, IsNil)
+	c.Assert(string(addr), Equals, "zenoss")
+	c.Assert(udp, Equals, false)
+
+	_, _, err = auth.SplitMuxAddress([]byte("zenoss!"))
+	c.Assert(err, Equals, auth.ErrBadMuxAddress)
+
+	_, _, err = auth.SplitMuxAddress([]byte("zen"))
+	c.Assert(err, Equals, auth.ErrBadMuxAddress)
+}
+
+func (s *TestAuthSuite) TestBuildAndExtractSessionHeader(c *C) {
+	token, _, _ := auth.CreateJWTIdentity(s.hostId, s.poolId, s.admin, s.dfs, s.delegatePubPEM, time.Hour)
+	var b bytes.Buffer
+
+	err := auth.AddSignedSessionMuxHeader(&b, token)
+	c.Assert(err, IsNil)
+
+	payload, _, err := auth.ReadMuxHeader(&b)
+	c.Assert(err, IsNil)
+	c.Assert(auth.IsSessionMuxHeader(payload), Equals, true)
+
+	// a session header is not a valid address
+	_, _, err = auth.SplitMuxAddress(payload)
+	c.Assert(err, Equals, auth.ErrBadMuxAddress)
+}
+
+func (s *TestAuthSuite) TestMuxAddressPayload(c *C) {
+	payload, err := auth.MuxAddressPayload([]byte("zenoss"), true)
+	c.Assert(err, IsNil)
+	c.Assert(auth.IsSessionMuxHeader(payload), Equals, false)
+	addr, udp, err := auth.SplitMuxAddress(payload)
+	c.Assert(err, IsNil)
+	c.Assert(string(addr), Equals, "zenoss")
+	c.Assert(udp, Equals, true)
+
+	payload, err = auth.MuxAddressPayload([]byte("zenoss"), false)
+	c.Assert(err, IsNil)
+	c.Assert(string(payload), Equals, "zenoss")
+
+	_, err = auth.MuxAddressPayload([]byte("zen"), false)
+	c.Assert(err, Equals, auth.ErrBadMuxAddress)
+}
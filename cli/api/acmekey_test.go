@@ -0,0 +1,37 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package api
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestAPISuite) TestLoadOrCreateACMEKey(c *C) {
+	filename := filepath.Join(c.MkDir(), "keys", "acme.key")
+
+	key, err := loadOrCreateACMEKey(filename)
+	c.Assert(err, IsNil)
+	loaded, err := loadOrCreateACMEKey(filename)
+	c.Assert(err, IsNil)
+	c.Check(loaded.D.Cmp(key.D), Equals, 0)
+
+	c.Assert(ioutil.WriteFile(filename, []byte("garbage"), 0600), IsNil)
+	_, err = loadOrCreateACMEKey(filename)
+	c.Check(err, Equals, ErrACMEKeyFile)
+}
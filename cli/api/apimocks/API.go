@@ -2,14 +2,21 @@ package mocks
 
 import api "github.com/control-center/serviced/cli/api"
 import applicationendpoint "github.com/control-center/serviced/domain/applicationendpoint"
+import auditlog "github.com/control-center/serviced/domain/auditlog"
+import certificate "github.com/control-center/serviced/domain/certificate"
 import dao "github.com/control-center/serviced/dao"
+import dfs "github.com/control-center/serviced/dfs"
+import registry "github.com/control-center/serviced/dfs/registry"
+import zkdocker "github.com/control-center/serviced/zzk/docker"
 import host "github.com/control-center/serviced/domain/host"
 import io "io"
+import time "time"
 import isvcs "github.com/control-center/serviced/isvcs"
 import metrics "github.com/control-center/serviced/metrics"
 import mock "github.com/stretchr/testify/mock"
 import pool "github.com/control-center/serviced/domain/pool"
 import script "github.com/control-center/serviced/script"
+import slo "github.com/control-center/serviced/slo"
 import "github.com/control-center/serviced/utils"
 import service "github.com/control-center/serviced/domain/service"
 import servicedefinition "github.com/control-center/serviced/domain/servicedefinition"
@@ -85,13 +92,13 @@ func (_m *API) AddHostPrivate(_a0 api.HostConfig) (*host.Host, []byte, error) {
 	return r0, r1, r2
 }
 
-// AddPublicEndpointPort provides a mock function with given fields: serviceid, endpointName, portAddr, usetls, protocol, isEnabled, restart
-func (_m *API) AddPublicEndpointPort(serviceid string, endpointName string, portAddr string, usetls bool, protocol string, isEnabled bool, restart bool) (*servicedefinition.Port, error) {
-	ret := _m.Called(serviceid, endpointName, portAddr, usetls, protocol, isEnabled, restart)
+// AddPublicEndpointPort provides a mock function with given fields: serviceid, endpointName, portAddr, usetls, protocol, isEnabled, access, restart
+func (_m *API) AddPublicEndpointPort(serviceid string, endpointName string, portAddr string, usetls bool, protocol string, isEnabled bool, access servicedefinition.AccessRules, restart bool) (*servicedefinition.Port, error) {
+	ret := _m.Called(serviceid, endpointName, portAddr, usetls, protocol, isEnabled, access, restart)
 
 	var r0 *servicedefinition.Port
-	if rf, ok := ret.Get(0).(func(string, string, string, bool, string, bool, bool) *servicedefinition.Port); ok {
-		r0 = rf(serviceid, endpointName, portAddr, usetls, protocol, isEnabled, restart)
+	if rf, ok := ret.Get(0).(func(string, string, string, bool, string, bool, servicedefinition.AccessRules, bool) *servicedefinition.Port); ok {
+		r0 = rf(serviceid, endpointName, portAddr, usetls, protocol, isEnabled, access, restart)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*servicedefinition.Port)
@@ -99,8 +106,8 @@ func (_m *API) AddPublicEndpointPort(serviceid string, endpointName string, port
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string, string, bool, string, bool, bool) error); ok {
-		r1 = rf(serviceid, endpointName, portAddr, usetls, protocol, isEnabled, restart)
+	if rf, ok := ret.Get(1).(func(string, string, string, bool, string, bool, servicedefinition.AccessRules, bool) error); ok {
+		r1 = rf(serviceid, endpointName, portAddr, usetls, protocol, isEnabled, access, restart)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -108,13 +115,13 @@ func (_m *API) AddPublicEndpointPort(serviceid string, endpointName string, port
 	return r0, r1
 }
 
-// AddPublicEndpointVHost provides a mock function with given fields: serviceid, endpointName, vhost, isEnabled, restart
-func (_m *API) AddPublicEndpointVHost(serviceid string, endpointName string, vhost string, isEnabled bool, restart bool) (*servicedefinition.VHost, error) {
-	ret := _m.Called(serviceid, endpointName, vhost, isEnabled, restart)
+// AddPublicEndpointVHost provides a mock function with given fields: serviceid, endpointName, vhost, isEnabled, access, restart
+func (_m *API) AddPublicEndpointVHost(serviceid string, endpointName string, vhost string, isEnabled bool, access servicedefinition.AccessRules, restart bool) (*servicedefinition.VHost, error) {
+	ret := _m.Called(serviceid, endpointName, vhost, isEnabled, access, restart)
 
 	var r0 *servicedefinition.VHost
-	if rf, ok := ret.Get(0).(func(string, string, string, bool, bool) *servicedefinition.VHost); ok {
-		r0 = rf(serviceid, endpointName, vhost, isEnabled, restart)
+	if rf, ok := ret.Get(0).(func(string, string, string, bool, servicedefinition.AccessRules, bool) *servicedefinition.VHost); ok {
+		r0 = rf(serviceid, endpointName, vhost, isEnabled, access, restart)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*servicedefinition.VHost)
@@ -122,8 +129,8 @@ func (_m *API) AddPublicEndpointVHost(serviceid string, endpointName string, vho
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string, string, bool, bool) error); ok {
-		r1 = rf(serviceid, endpointName, vhost, isEnabled, restart)
+	if rf, ok := ret.Get(1).(func(string, string, string, bool, servicedefinition.AccessRules, bool) error); ok {
+		r1 = rf(serviceid, endpointName, vhost, isEnabled, access, restart)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -249,6 +256,98 @@ func (_m *API) AssignIP(_a0 api.IPConfig) error {
 	return r0
 }
 
+// CollectRegistryGarbage provides a mock function with given fields: dryRun
+func (_m *API) CollectRegistryGarbage(dryRun bool) (*dfs.RegistryGCReport, error) {
+	ret := _m.Called(dryRun)
+
+	var r0 *dfs.RegistryGCReport
+	if rf, ok := ret.Get(0).(func(bool) *dfs.RegistryGCReport); ok {
+		r0 = rf(dryRun)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dfs.RegistryGCReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(bool) error); ok {
+		r1 = rf(dryRun)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPublicEndpointCertificates provides a mock function with given fields:
+func (_m *API) GetPublicEndpointCertificates() ([]certificate.Certificate, error) {
+	ret := _m.Called()
+
+	var r0 []certificate.Certificate
+	if rf, ok := ret.Get(0).(func() []certificate.Certificate); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]certificate.Certificate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRegistryReplicationStatus provides a mock function with given fields:
+func (_m *API) GetRegistryReplicationStatus() ([]registry.ReplicaStatus, error) {
+	ret := _m.Called()
+
+	var r0 []registry.ReplicaStatus
+	if rf, ok := ret.Get(0).(func() []registry.ReplicaStatus); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]registry.ReplicaStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStorageQuotaStatus provides a mock function with given fields:
+func (_m *API) GetStorageQuotaStatus() ([]volume.TenantQuotaStatus, error) {
+	ret := _m.Called()
+
+	var r0 []volume.TenantQuotaStatus
+	if rf, ok := ret.Get(0).(func() []volume.TenantQuotaStatus); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]volume.TenantQuotaStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RemoveIP provides a mock function with given fields: args
 func (_m *API) RemoveIP(args []string) error {
 	ret := _m.Called(args)
@@ -263,6 +362,34 @@ func (_m *API) RemoveIP(args []string) error {
 	return r0
 }
 
+// RemovePublicEndpointCertificate provides a mock function with given fields: serviceid, endpointName, kind, name
+func (_m *API) RemovePublicEndpointCertificate(serviceid string, endpointName string, kind certificate.Kind, name string) error {
+	ret := _m.Called(serviceid, endpointName, kind, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, certificate.Kind, string) error); ok {
+		r0 = rf(serviceid, endpointName, kind, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplicateRegistry provides a mock function with given fields:
+func (_m *API) ReplicateRegistry() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetIP provides a mock function with given fields: _a0
 func (_m *API) SetIP(_a0 api.IPConfig) error {
 	ret := _m.Called(_a0)
@@ -277,13 +404,13 @@ func (_m *API) SetIP(_a0 api.IPConfig) error {
 	return r0
 }
 
-// AttachServiceInstance provides a mock function with given fields: serviceID, instanceID, command, args
-func (_m *API) AttachServiceInstance(serviceID string, instanceID int, command string, args []string) error {
-	ret := _m.Called(serviceID, instanceID, command, args)
+// AttachServiceInstance provides a mock function with given fields: serviceID, instanceID, command, args, record
+func (_m *API) AttachServiceInstance(serviceID string, instanceID int, command string, args []string, record bool) error {
+	ret := _m.Called(serviceID, instanceID, command, args, record)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, int, string, []string) error); ok {
-		r0 = rf(serviceID, instanceID, command, args)
+	if rf, ok := ret.Get(0).(func(string, int, string, []string, bool) error); ok {
+		r0 = rf(serviceID, instanceID, command, args, record)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -507,6 +634,135 @@ func (_m *API) ExportLogs(config api.ExportLogsConfig) error {
 	return r0
 }
 
+// SearchLogs provides a mock function with given fields: config
+func (_m *API) SearchLogs(config api.LogQueryConfig) ([]api.LogMessage, error) {
+	ret := _m.Called(config)
+
+	var r0 []api.LogMessage
+	if rf, ok := ret.Get(0).(func(api.LogQueryConfig) []api.LogMessage); ok {
+		r0 = rf(config)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]api.LogMessage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(api.LogQueryConfig) error); ok {
+		r1 = rf(config)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TailLogs provides a mock function with given fields: config, output, cancel
+func (_m *API) TailLogs(config api.LogQueryConfig, output func(api.LogMessage), cancel <-chan struct{}) error {
+	ret := _m.Called(config, output, cancel)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(api.LogQueryConfig, func(api.LogMessage), <-chan struct{}) error); ok {
+		r0 = rf(config, output, cancel)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnforceLogRetention provides a mock function with given fields: dryRun
+func (_m *API) EnforceLogRetention(dryRun bool) (*isvcs.LogRetentionReport, error) {
+	ret := _m.Called(dryRun)
+
+	var r0 *isvcs.LogRetentionReport
+	if rf, ok := ret.Get(0).(func(bool) *isvcs.LogRetentionReport); ok {
+		r0 = rf(dryRun)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*isvcs.LogRetentionReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(bool) error); ok {
+		r1 = rf(dryRun)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAuditEntries provides a mock function with given fields: query
+func (_m *API) GetAuditEntries(query auditlog.Query) ([]auditlog.Entry, error) {
+	ret := _m.Called(query)
+
+	var r0 []auditlog.Entry
+	if rf, ok := ret.Get(0).(func(auditlog.Query) []auditlog.Entry); ok {
+		r0 = rf(query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]auditlog.Entry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(auditlog.Query) error); ok {
+		r1 = rf(query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetShellSessionRecording provides a mock function with given fields: sessionID
+func (_m *API) GetShellSessionRecording(sessionID string) (*auditlog.Recording, error) {
+	ret := _m.Called(sessionID)
+
+	var r0 *auditlog.Recording
+	if rf, ok := ret.Get(0).(func(string) *auditlog.Recording); ok {
+		r0 = rf(sessionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*auditlog.Recording)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(sessionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifyAuditTrail provides a mock function with given fields:
+func (_m *API) VerifyAuditTrail() (*auditlog.Verification, error) {
+	ret := _m.Called()
+
+	var r0 *auditlog.Verification
+	if rf, ok := ret.Get(0).(func() *auditlog.Verification); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*auditlog.Verification)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetAllPublicEndpoints provides a mock function with given fields:
 func (_m *API) GetAllPublicEndpoints() ([]service.PublicEndpoint, error) {
 	ret := _m.Called()
@@ -1474,6 +1730,43 @@ func (_m *API) SetHostMemory(_a0 api.HostUpdateConfig) error {
 	return r0
 }
 
+// SetPublicEndpointCertificate provides a mock function with given fields: serviceid, endpointName, kind, name, certPEM, keyPEM
+func (_m *API) SetPublicEndpointCertificate(serviceid string, endpointName string, kind certificate.Kind, name string, certPEM []byte, keyPEM []byte) (*certificate.Certificate, error) {
+	ret := _m.Called(serviceid, endpointName, kind, name, certPEM, keyPEM)
+
+	var r0 *certificate.Certificate
+	if rf, ok := ret.Get(0).(func(string, string, certificate.Kind, string, []byte, []byte) *certificate.Certificate); ok {
+		r0 = rf(serviceid, endpointName, kind, name, certPEM, keyPEM)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*certificate.Certificate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, certificate.Kind, string, []byte, []byte) error); ok {
+		r1 = rf(serviceid, endpointName, kind, name, certPEM, keyPEM)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetStorageQuota provides a mock function with given fields: tenantID, quota
+func (_m *API) SetStorageQuota(tenantID string, quota volume.StorageQuota) error {
+	ret := _m.Called(tenantID, quota)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, volume.StorageQuota) error); ok {
+		r0 = rf(tenantID, quota)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // StartServer provides a mock function with given fields:
 func (_m *API) StartServer() error {
 	ret := _m.Called()
@@ -1679,6 +1972,52 @@ func (_m *API) ClearEmergency(_a0 string) (int, error) {
 	return r0, r1
 }
 
+// GetServiceSLOs provides a mock function with given fields: _a0
+func (_m *API) GetServiceSLOs(_a0 string) ([]slo.Status, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []slo.Status
+	if rf, ok := ret.Get(0).(func(string) []slo.Status); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]slo.Status)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetResourceRecommendations provides a mock function with given fields: _a0, _a1
+func (_m *API) GetResourceRecommendations(_a0 string, _a1 time.Time) ([]service.ResourceRecommendation, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []service.ResourceRecommendation
+	if rf, ok := ret.Get(0).(func(string, time.Time) []service.ResourceRecommendation); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]service.ResourceRecommendation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, time.Time) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 func (_m *API) GetBackupEstimate(_a0 string, _a1 []string) (*dao.BackupEstimate, error) {
 	ret := _m.Called(_a0, _a1)
 
@@ -1700,3 +2039,63 @@ func (_m *API) GetBackupEstimate(_a0 string, _a1 []string) (*dao.BackupEstimate,
 }
 
 var _ api.API = (*API)(nil)
+
+// GetPrePullStatus provides a mock function with given fields:
+func (_m *API) GetPrePullStatus() ([]zkdocker.PrePull, error) {
+	ret := _m.Called()
+
+	var r0 []zkdocker.PrePull
+	if rf, ok := ret.Get(0).(func() []zkdocker.PrePull); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]zkdocker.PrePull)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PrePullImages provides a mock function with given fields:
+func (_m *API) PrePullImages() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnablePublicEndpointACME provides a mock function with given fields: serviceid, endpointName, vhost, domains
+func (_m *API) EnablePublicEndpointACME(serviceid string, endpointName string, vhost string, domains []string) (*certificate.Certificate, error) {
+	ret := _m.Called(serviceid, endpointName, vhost, domains)
+
+	var r0 *certificate.Certificate
+	if rf, ok := ret.Get(0).(func(string, string, string, []string) *certificate.Certificate); ok {
+		r0 = rf(serviceid, endpointName, vhost, domains)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*certificate.Certificate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string, []string) error); ok {
+		r1 = rf(serviceid, endpointName, vhost, domains)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,37 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"github.com/control-center/serviced/domain/auditlog"
+)
+
+// GetAuditEntries returns a page of entries from the audit trail, newest
+// first
+func (a *api) GetAuditEntries(query auditlog.Query) ([]auditlog.Entry, error) {
+	client, err := a.connectMaster()
+	if err != nil {
+		return nil, err
+	}
+	return client.GetAuditEntries(query)
+}
+
+// VerifyAuditTrail checks that the hash chain of the audit trail is unbroken
+func (a *api) VerifyAuditTrail() (*auditlog.Verification, error) {
+	client, err := a.connectMaster()
+	if err != nil {
+		return nil, err
+	}
+	return client.VerifyAuditTrail()
+}
@@ -18,6 +18,7 @@ import (
 	"errors"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/acme"
 	"github.com/control-center/serviced/auth"
 	commonsdocker "github.com/control-center/serviced/commons/docker"
 	"github.com/control-center/serviced/config"
@@ -33,7 +34,9 @@ import (
 	"github.com/control-center/serviced/dfs/nfs"
 	"github.com/control-center/serviced/dfs/registry"
 	"github.com/control-center/serviced/domain/addressassignment"
+	"github.com/control-center/serviced/domain/auditlog"
 	"github.com/control-center/serviced/domain/host"
+	"github.com/control-center/serviced/domain/logfilter"
 	"github.com/control-center/serviced/domain/pool"
 	"github.com/control-center/serviced/domain/properties"
 	"github.com/control-center/serviced/domain/service"
@@ -53,6 +56,7 @@ import (
 	"github.com/control-center/serviced/scheduler"
 	"github.com/control-center/serviced/servicedversion"
 	"github.com/control-center/serviced/shell"
+	"github.com/control-center/serviced/slo"
 	"github.com/control-center/serviced/stats"
 	"github.com/control-center/serviced/utils"
 	"github.com/control-center/serviced/utils/iostat"
@@ -95,7 +99,8 @@ var (
 )
 
 const (
-	localhost = "127.0.0.1"
+	localhost       = "127.0.0.1"
+	acmeKeyFileName = ".keys/acme.key"
 )
 
 type daemon struct {
@@ -113,6 +118,8 @@ type daemon struct {
 	waitGroup        *sync.WaitGroup
 	rpcServer        *rpc.Server
 	tokenExpiration  time.Duration
+	exporter         *stats.Exporter
+	storageStats     *stats.StorageStatsReporter
 
 	facade *facade.Facade
 	ssm    servicestatemanager.ServiceStateManager
@@ -136,6 +143,7 @@ func newDaemon(servicedEndpoint string, staticIPs []string, masterPoolID string,
 		waitGroup:        &sync.WaitGroup{},
 		rpcServer:        rpc.NewServer(),
 		tokenExpiration:  tokenExpiration,
+		exporter:         stats.NewExporter(),
 	}
 	return d, nil
 }
@@ -189,7 +197,6 @@ func (d *daemon) startISVCS() {
 		log.WithError(err).Fatal("Unable to start internal services")
 	}
 	log.Info("Started internal services")
-	go d.startLogstashPurger(10*time.Minute, time.Duration(options.LogstashCycleTime)*time.Hour)
 }
 
 func (d *daemon) startAgentISVCS(serviceNames []string) {
@@ -317,6 +324,9 @@ func (d *daemon) run() (err error) {
 	// Start the RPC server
 	d.startRPC()
 
+	// Serve the stats of this host for scraping
+	d.startMetrics()
+
 	//Start the zookeeper client
 	localClient, err := d.initZK(options.Zookeepers)
 	if err != nil {
@@ -489,10 +499,17 @@ func (d *daemon) startMaster() (err error) {
 	// Initialize service state manager
 	d.initServiceStateManager(time.Duration(options.ServiceRunLevelTimeout) * time.Second)
 	d.facade.SetServiceStateManager(d.ssm)
+	healthSamples := &healthSampler{get: func() (map[string]map[int]map[string]health.HealthStatus, error) {
+		return d.facade.GetServicesHealth(d.dsContext)
+	}}
+	go healthSamples.Watch(healthSamplesInterval, d.shutdown)
+	d.exporter.AddSource(healthSamples.Samples)
 
 	// Update current states
 	d.facade.SyncCurrentStates(d.dsContext)
 
+	go d.startLogstashPurger(10*time.Minute, time.Duration(options.LogstashCycleTime)*time.Hour)
+
 	if err = d.checkVersion(); err != nil {
 		log.WithError(err).Fatal("Unable to initialize version")
 	}
@@ -556,6 +573,10 @@ func (d *daemon) startMaster() (err error) {
 	d.addTemplates()
 	d.startScheduler()
 	d.startPoolListener()
+	if options.ACMEDirectory != "" {
+		go d.startCertificateRenewer(time.Minute, 12*time.Hour)
+	}
+	go d.startSLOMonitor()
 
 	log.Info("Started serviced master")
 
@@ -682,7 +703,7 @@ func getTLSConfig(connectionType string) (*tls.Config, error) {
 
 }
 
-func createMuxListener() net.Listener {
+func createMuxListener(creds *auth.MuxCredentials) net.Listener {
 	options := config.GetOptions()
 	var (
 		listener net.Listener
@@ -701,8 +722,18 @@ func createMuxListener() net.Listener {
 		if err != nil {
 			log.WithError(err).Fatal("Invalid TLS configuration")
 		}
-		listener, err = tls.Listen("tcp", fmt.Sprintf(":%d", options.MuxPort), tlsConfig)
+		if creds != nil {
+			// the certificate of the connection is only known after
+			// the handshake, so the credentials do the handshaking
+			listener, err = net.Listen("tcp", fmt.Sprintf(":%d", options.MuxPort))
+			if err == nil {
+				listener = creds.Listener(listener, tlsConfig)
+			}
+		} else {
+			listener, err = tls.Listen("tcp", fmt.Sprintf(":%d", options.MuxPort), tlsConfig)
+		}
 		log = log.WithFields(logrus.Fields{
+			"mutualtls":   creds != nil,
 			"ciphersuite": strings.Join(utils.CipherSuitesByName(tlsConfig), ","),
 		})
 	} else {
@@ -717,11 +748,27 @@ func createMuxListener() net.Listener {
 
 func (d *daemon) startAgent() error {
 	options := config.GetOptions()
-	muxListener := createMuxListener()
+
+	// With mutual TLS, the mux only accepts connections that present a
+	// certificate issued by the master, which the agent keeps renewed.
+	var muxCredentials *auth.MuxCredentials
+	if config.MuxMutualTLSIsEnabled() {
+		muxCredentials = &auth.MuxCredentials{}
+		if err := muxCredentials.Load(options.MuxCredentialsPath); err != nil {
+			log.WithError(err).Info("No mux credentials found. Requesting them from the master")
+		}
+		web.SetMuxCredentials(muxCredentials)
+	}
+
+	muxListener := createMuxListener(muxCredentials)
 	mux, err := proxy.NewTCPMux(muxListener)
 	if err != nil {
 		log.WithError(err).Fatal("Could not start TCP multiplexer")
 	}
+	if muxCredentials != nil {
+		// keep tenants out until the agent knows which containers are theirs
+		mux.SetAuthorizer(func(string, string) error { return node.ErrWrongTenant })
+	}
 
 	// Determine the delegate's IP address
 	agentIP := options.OutboundIP
@@ -995,6 +1042,9 @@ func (d *daemon) startAgent() error {
 			Mux:                   mux,
 			MuxPort:               fmt.Sprintf("%d", options.MuxPort),
 			UseTLS:                !muxDisableTLS,
+			MuxSessions:           options.MuxSessions,
+			MuxCredentials:        muxCredentials,
+			MuxCredentialsPath:    options.MuxCredentialsPath,
 			DockerRegistry:        options.DockerRegistry,
 			MaxContainerAge:       time.Duration(int(time.Second) * options.MaxContainerAge),
 			VirtualAddressSubnet:  options.VirtualAddressSubnet,
@@ -1014,6 +1064,9 @@ func (d *daemon) startAgent() error {
 		// creates a zClient that is not pool based!
 		hostAgent, err := node.NewHostAgent(agentOptions, d.reg)
 		d.hostAgent = hostAgent
+		if muxCredentials != nil {
+			mux.SetAuthorizer(hostAgent.AuthorizeMuxConnection)
+		}
 
 		d.waitGroup.Add(1)
 		go func() {
@@ -1033,6 +1086,9 @@ func (d *daemon) startAgent() error {
 			log.Debug("Registered local ControlCenterAgent RPC service")
 		}
 
+		// external metric sinks of the pool
+		metricSinks := d.startMetricSinks(poolID)
+
 		// serviced stats (cpu, ram, etc)
 		if options.ReportStats {
 			statsdest := fmt.Sprintf("http://%s/api/metrics/store", options.HostStats)
@@ -1046,6 +1102,10 @@ func (d *daemon) startAgent() error {
 			if err != nil {
 				log.WithError(err).Error("Unable to start reporting stats")
 			} else {
+				servicedStatsReporter.AddRegistrySource(web.PublicEndpointRegistries)
+				servicedStatsReporter.AddRegistrySource(metricSinks.Registries)
+				servicedStatsReporter.SetSinks(metricSinks)
+				d.exporter.AddSource(servicedStatsReporter.Samples)
 				go func() {
 					defer servicedStatsReporter.Close()
 					<-d.shutdown
@@ -1067,6 +1127,9 @@ func (d *daemon) startAgent() error {
 			if err != nil {
 				log.WithError(err).Error("Unable to start reporting stats")
 			} else {
+				storageStatsReporter.SetSinks(metricSinks)
+				d.exporter.AddSource(storageStatsReporter.Samples)
+				d.storageStats = storageStatsReporter
 				go func() {
 					defer storageStatsReporter.Close()
 					<-d.shutdown
@@ -1205,6 +1268,7 @@ func (d *daemon) initDriver() datastore.Driver {
 	eDriver.AddMapping(addressassignment.MAPPING)
 	eDriver.AddMapping(serviceconfigfile.MAPPING)
 	eDriver.AddMapping(user.MAPPING)
+	eDriver.AddMapping(auditlog.MAPPING)
 	err := eDriver.Initialize(10 * time.Second)
 	if err != nil {
 		log.WithError(err).Fatal("Unable to establish connection to Elastic database")
@@ -1232,13 +1296,24 @@ func (d *daemon) initFacade() *facade.Facade {
 	index := registry.NewRegistryIndexClient(f)
 	dfs := dfs.NewDistributedFilesystem(d.docker, index, d.reg, d.disk, d.net, time.Duration(options.MaxDFSTimeout)*time.Second)
 	dfs.SetTmp(os.Getenv("TMP"))
+	dfs.SetManifestClient(registry.NewManifestClient(options.DockerRegistry))
 	f.SetDFS(dfs)
 	f.SetIsvcsPath(options.IsvcsPath)
+	f.SetRegistryBlobCollector(isvcs.CollectRegistryGarbage)
+	if options.ACMEDirectory != "" {
+		keyFile := filepath.Join(options.IsvcsPath, acmeKeyFileName)
+		key, err := loadOrCreateACMEKey(keyFile)
+		if err != nil {
+			log.WithError(err).WithField("keyfile", keyFile).Fatal("Unable to load or create ACME account key")
+		}
+		f.SetACMEClient(acme.NewClient(options.ACMEDirectory, key, options.ACMEEmail))
+	}
 	d.hcache = health.New()
 	d.hcache.SetPurgeFrequency(5 * time.Second)
 	f.SetHealthCache(d.hcache)
 	client := initMetricsClient()
 	f.SetMetricsClient(client)
+	f.SetSLOTracker(slo.NewTracker(filepath.Join(options.IsvcsPath, slo.FileName)))
 	if err := f.CreateSystemUser(d.dsContext); err != nil {
 		log.WithError(err).Fatal("Unable to create system user")
 	}
@@ -1249,7 +1324,71 @@ func (d *daemon) initFacade() *facade.Facade {
 	return f
 }
 
-// startLogstashPurger purges logstash based on days and size
+// startCertificateRenewer obtains pending ACME certificates and renews the
+// ones that expire soon
+func (d *daemon) startCertificateRenewer(initialStart, cycleTime time.Duration) {
+	select {
+	case <-d.shutdown:
+		return
+	case <-time.After(initialStart):
+	}
+	for {
+		if err := d.facade.RenewCertificates(d.dsContext); err != nil {
+			log.WithError(err).Warn("Unable to renew ACME certificates")
+		}
+		select {
+		case <-d.shutdown:
+			return
+		case <-time.After(cycleTime):
+		}
+	}
+}
+
+// startSLOMonitor measures the service level objectives of the running
+// services every minute, and posts their compliance, error budgets and burn
+// rates as metrics, on which the burn rate thresholds raise events.
+func (d *daemon) startSLOMonitor() {
+	options := config.GetOptions()
+	statsURL := fmt.Sprintf("http://%s/api/metrics/store", options.HostStats)
+	ticker := time.NewTicker(slo.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.shutdown:
+			return
+		case now := <-ticker.C:
+			statuses, err := d.facade.MeasureSLOs(d.dsContext, now)
+			if err != nil {
+				log.WithError(err).Warn("Unable to measure service level objectives")
+				continue
+			}
+			samples := []stats.Sample{}
+			for _, status := range statuses {
+				samples = append(samples, status.Samples(now)...)
+				for _, rate := range status.BurnRates {
+					if rate.Exceeded {
+						log.WithFields(logrus.Fields{
+							"serviceid": status.ServiceID,
+							"slo":       status.Name,
+							"hours":     rate.Hours,
+							"burnrate":  rate.Rate,
+							"threshold": rate.Threshold,
+						}).Warn("Service level objective is burning its error budget too quickly")
+					}
+				}
+			}
+			if len(samples) == 0 {
+				continue
+			}
+			if err := stats.Post(statsURL, samples); err != nil {
+				log.WithError(err).WithField("statsurl", statsURL).Debug("Unable to post service level objective metrics")
+			}
+		}
+	}
+}
+
+// startLogstashPurger purges logstash based on the retention of each
+// tenant's log types, and on days and size
 func (d *daemon) startLogstashPurger(initialStart, cycleTime time.Duration) {
 	options := config.GetOptions()
 	// Run the first time after 10 minutes
@@ -1259,7 +1398,15 @@ func (d *daemon) startLogstashPurger(initialStart, cycleTime time.Duration) {
 	case <-time.After(initialStart):
 	}
 	for {
-		isvcs.PurgeLogstashIndices(options.LogstashMaxDays, options.LogstashMaxSize)
+		policy, err := d.facade.GetLogRetentionPolicy(d.dsContext)
+		if err != nil {
+			log.WithError(err).Warn("Unable to look up log retention rules, purging by days and size only")
+			policy = &logfilter.RetentionPolicy{MaxDays: options.LogstashMaxDays, MaxSize: options.LogstashMaxSize}
+		}
+		if _, err := isvcs.EnforceLogRetention(*policy, false); err != nil {
+			log.WithError(err).Warn("Unable to enforce log retention rules, purging by days and size only")
+			isvcs.PurgeLogstashIndices(policy.IndexDays(), policy.MaxSize)
+		}
 		select {
 		case <-d.shutdown:
 			return
@@ -1365,17 +1512,10 @@ func (d *daemon) startStorageMonitor() {
 					"prediction": avail[tenant],
 					"period":     lookahead,
 				}).Error("Application storage is predicted to be full within the configured period")
-				if n, err := d.facade.EmergencyStopService(d.dsContext, dao.ScheduleServiceRequest{
-					ServiceIDs:  []string{tenant},
-					AutoLaunch:  true,
-					Synchronous: false,
-				}); err != nil {
-					log.WithError(err).Error("Unable to perform emergency stop of application")
-				} else {
-					log.WithField("numservices", n).Info("Emergency stop initiated")
-				}
+				d.emergencyStopTenant(tenant)
 			}
 		}
+		d.checkStorageQuotas()
 		// Now wait to check again, some duration smaller than that at which
 		// storage metrics are reported, to avoid races
 		select {
@@ -1386,6 +1526,54 @@ func (d *daemon) startStorageMonitor() {
 	}
 }
 
+// checkStorageQuotas measures the storage used by each tenant with a quota,
+// reports it for the quota thresholds, and emergency stops any tenant that
+// has reached its hard limit.
+func (d *daemon) checkStorageQuotas() {
+	statuses, err := d.facade.GetStorageQuotaStatus(d.dsContext)
+	if err != nil {
+		log.WithError(err).Warn("Unable to check application storage quotas")
+		return
+	}
+	for _, status := range statuses {
+		if d.storageStats != nil {
+			d.storageStats.UpdateTenantUsage(status.TenantID, status.Used)
+		}
+		log := log.WithFields(logrus.Fields{
+			"service":   status.TenantID,
+			"used":      status.Used,
+			"softlimit": status.Quota.SoftLimit,
+			"hardlimit": status.Quota.HardLimit,
+		})
+		switch status.State {
+		case volume.QuotaStateWarning:
+			log.Warn("Application storage has reached its soft quota")
+		case volume.QuotaStateExceeded:
+			svc, _ := d.facade.GetService(d.dsContext, status.TenantID)
+			if svc != nil && svc.EmergencyShutdown {
+				log.Debug("Skipping emergency stop of already stopped service")
+				continue
+			}
+			log.Error("Application storage has reached its hard quota")
+			d.emergencyStopTenant(status.TenantID)
+		}
+	}
+}
+
+// emergencyStopTenant stops an application in EmergencyShutdownLevel order.
+func (d *daemon) emergencyStopTenant(tenantID string) {
+	log := log.WithField("service", tenantID)
+	if n, err := d.facade.EmergencyStopService(d.dsContext, dao.ScheduleServiceRequest{
+		ServiceIDs:  []string{tenantID},
+		AutoLaunch:  true,
+		Synchronous: false,
+	}); err != nil {
+		log.WithError(err).Error("Unable to perform emergency stop of application")
+	} else {
+		log.WithField("numservices", n).Info("Emergency stop initiated")
+	}
+}
+
 // FIXME: The dao package is deprecated and should be removed.
 func (d *daemon) initDAO() dao.ControlPlane {
 	options := config.GetOptions()
@@ -1522,12 +1710,39 @@ func (d *daemon) startPoolListener() {
 	}()
 }
 
+// initRegistryReplicator returns the replicator for the secondary docker
+// registry, or nil if replication is not configured.
+func (d *daemon) initRegistryReplicator() *registry.RegistryReplicator {
+	options := config.GetOptions()
+	if options.DockerRegistryReplica == "" {
+		return nil
+	}
+	log := log.WithFields(logrus.Fields{
+		"replica": options.DockerRegistryReplica,
+	})
+	var connect registry.ReplicaConnector
+	if len(options.DockerRegistryReplicaZK) > 0 {
+		replicaClient, err := d.initZK(options.DockerRegistryReplicaZK)
+		if err != nil {
+			log.WithError(err).WithFields(logrus.Fields{
+				"ensemble": options.DockerRegistryReplicaZK,
+			}).Fatal("Unable to create a ZooKeeper client for the secondary master")
+		}
+		connect = replicaClient.GetConnection
+	} else {
+		log.Warn("No ZooKeeper ensemble set for the secondary master; only images will be replicated")
+	}
+	log.Info("Replicating the Docker registry")
+	return registry.NewRegistryReplicator(d.docker, options.DockerRegistry, options.DockerRegistryReplica, connect)
+}
+
 func (d *daemon) runScheduler() {
 	log.Debug("Starting service scheduler")
 	options := config.GetOptions()
+	replicator := d.initRegistryReplicator()
 	// Run the first time after 10 minutes
 	for {
-		sched, err := scheduler.NewScheduler(d.masterPoolID, d.hostID, d.storageHandler, d.cpDao, d.facade, d.reg, options.SnapshotTTL)
+		sched, err := scheduler.NewScheduler(d.masterPoolID, d.hostID, d.storageHandler, d.cpDao, d.facade, d.reg, replicator, options.SnapshotTTL)
 		if err != nil {
 			log.WithError(err).Fatal("Unable to start service scheduler")
 			return
@@ -1548,6 +1763,7 @@ func (d *daemon) runScheduler() {
 func (d *daemon) initServiceStateManager(runLevelTimeout time.Duration) {
 	bssm := servicestatemanager.NewBatchServiceStateManager(d.facade, d.dsContext, runLevelTimeout)
 	d.ssm = bssm
+	d.exporter.AddSource(queueSamples(bssm))
 	go func() {
 		bssm.Start()
 		log.WithField("leveltimeout", runLevelTimeout).Info("Started service state manager")
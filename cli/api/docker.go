@@ -13,6 +13,12 @@
 
 package api
 
+import (
+	"github.com/control-center/serviced/dfs"
+	"github.com/control-center/serviced/dfs/registry"
+	zkdocker "github.com/control-center/serviced/zzk/docker"
+)
+
 // ResetRegistry moves all relevant images into the new docker registry
 func (a *api) ResetRegistry() error {
 	client, err := a.connectMaster()
@@ -49,3 +55,52 @@ func (a *api) DockerOverride(newImage string, oldImage string) error {
 	}
 	return client.DockerOverride(newImage, oldImage)
 }
+
+// CollectRegistryGarbage removes images from the docker registry that are not
+// used by any service or snapshot.
+func (a *api) CollectRegistryGarbage(dryRun bool) (*dfs.RegistryGCReport, error) {
+	client, err := a.connectMaster()
+	if err != nil {
+		return nil, err
+	}
+	return client.CollectRegistryGarbage(dryRun)
+}
+
+// GetRegistryReplicationStatus returns the replication status of every image
+// in the docker registry index.
+func (a *api) GetRegistryReplicationStatus() ([]registry.ReplicaStatus, error) {
+	client, err := a.connectMaster()
+	if err != nil {
+		return nil, err
+	}
+	return client.GetRegistryReplicationStatus()
+}
+
+// ReplicateRegistry pushes every image in the docker registry index to the
+// secondary registry.
+func (a *api) ReplicateRegistry() error {
+	client, err := a.connectMaster()
+	if err != nil {
+		return err
+	}
+	return client.ReplicateRegistry()
+}
+
+// GetPrePullStatus returns the progress of image pre-pulls on every host.
+func (a *api) GetPrePullStatus() ([]zkdocker.PrePull, error) {
+	client, err := a.connectMaster()
+	if err != nil {
+		return nil, err
+	}
+	return client.GetPrePullStatus()
+}
+
+// PrePullImages asks every host to pull the images of the services in its
+// pool.
+func (a *api) PrePullImages() error {
+	client, err := a.connectMaster()
+	if err != nil {
+		return err
+	}
+	return client.PrePullImages()
+}
@@ -15,10 +15,12 @@ package api
 
 import (
 	"os"
+	"os/exec"
 	"syscall"
 
 	dockerclient "github.com/control-center/serviced/commons/docker"
 	"github.com/control-center/serviced/config"
+	"github.com/control-center/serviced/domain/auditlog"
 	"github.com/control-center/serviced/domain/service"
 	"github.com/control-center/serviced/utils"
 )
@@ -43,8 +45,10 @@ func (a *api) StopServiceInstance(serviceID string, instanceID int) error {
 	return client.StopServiceInstance(serviceID, instanceID)
 }
 
-// AttachServiceInstance locates and attaches to a running instance of a service
-func (a *api) AttachServiceInstance(serviceID string, instanceID int, command string, args []string) error {
+// AttachServiceInstance locates and attaches to a running instance of a
+// service.  The session is written to the audit log, and recorded if record
+// is set.
+func (a *api) AttachServiceInstance(serviceID string, instanceID int, command string, args []string, record bool) error {
 	var (
 		targetHost      string
 		targetContainer string
@@ -74,21 +78,31 @@ func (a *api) AttachServiceInstance(serviceID string, instanceID int, command st
 	}
 
 	// attach to the container
+	var cmd *exec.Cmd
 	if targetHost != hostID {
-		cmd, err := a.getSSHCommand(location)
+		argv, err := a.getSSHCommand(location)
 		if err != nil {
 			return err
 		}
-		cmd = append(cmd, []string{"/usr/bin/docker", "exec", "-it", targetContainer}...)
+		argv = append(argv, []string{"/usr/bin/docker", "exec", "-it", targetContainer}...)
 
-		cmd = append(cmd, command)
-		cmd = append(cmd, args...)
-		return syscall.Exec(cmd[0], cmd[0:], os.Environ())
+		argv = append(argv, command)
+		argv = append(argv, args...)
+		cmd = exec.Command(argv[0], argv[1:]...)
 	} else {
-		cmd := []string{command}
-		cmd = append(cmd, args...)
-		return utils.AttachAndExec(targetContainer, cmd)
+		argv := []string{command}
+		argv = append(argv, args...)
+		if cmd, err = utils.DockerExecCommand(targetContainer, argv); err != nil {
+			return err
+		}
+	}
+
+	session, err := a.beginSession(auditlog.SessionAttach, serviceID, targetHost, instanceID, append([]string{command}, args...), record)
+	if err != nil {
+		return err
 	}
+	_, err = session.run(cmd)
+	return err
 }
 
 // LogsForServiceInstance returns the logs for the service instance
@@ -15,9 +15,14 @@ package api
 
 import (
 	"io"
+	"time"
 
 	"github.com/control-center/serviced/dao"
+	"github.com/control-center/serviced/dfs"
+	"github.com/control-center/serviced/dfs/registry"
 	"github.com/control-center/serviced/domain/applicationendpoint"
+	"github.com/control-center/serviced/domain/auditlog"
+	"github.com/control-center/serviced/domain/certificate"
 	"github.com/control-center/serviced/domain/host"
 	"github.com/control-center/serviced/domain/pool"
 	"github.com/control-center/serviced/domain/service"
@@ -26,8 +31,10 @@ import (
 	"github.com/control-center/serviced/isvcs"
 	"github.com/control-center/serviced/metrics"
 	"github.com/control-center/serviced/script"
+	"github.com/control-center/serviced/slo"
 	"github.com/control-center/serviced/utils"
 	"github.com/control-center/serviced/volume"
+	zkdocker "github.com/control-center/serviced/zzk/docker"
 )
 
 // API is the intermediary between the command-line interface and the dao layer
@@ -83,6 +90,8 @@ type API interface {
 	GetEndpoints(serviceID string, reportImports, reportExports, validate bool) ([]applicationendpoint.EndpointReport, error)
 	ResolveServicePath(path string) ([]service.ServiceDetails, error)
 	ClearEmergency(serviceID string) (int, error)
+	GetServiceSLOs(serviceID string) ([]slo.Status, error)
+	GetResourceRecommendations(tenantID string, since time.Time) ([]service.ResourceRecommendation, error)
 	RemoveIP(args []string) error
 	SetIP(IPConfig) error
 
@@ -118,9 +127,22 @@ type API interface {
 	RegistrySync() error
 	UpgradeRegistry(endpoint string, override bool) error
 	DockerOverride(newImage string, oldImage string) error
+	CollectRegistryGarbage(dryRun bool) (*dfs.RegistryGCReport, error)
+	GetRegistryReplicationStatus() ([]registry.ReplicaStatus, error)
+	ReplicateRegistry() error
+	GetPrePullStatus() ([]zkdocker.PrePull, error)
+	PrePullImages() error
 
 	// Logs
 	ExportLogs(config ExportLogsConfig) error
+	SearchLogs(config LogQueryConfig) ([]LogMessage, error)
+	TailLogs(config LogQueryConfig, output func(LogMessage), cancel <-chan struct{}) error
+	EnforceLogRetention(dryRun bool) (*isvcs.LogRetentionReport, error)
+
+	// Audit
+	GetAuditEntries(query auditlog.Query) ([]auditlog.Entry, error)
+	VerifyAuditTrail() (*auditlog.Verification, error)
+	GetShellSessionRecording(sessionID string) (*auditlog.Recording, error)
 
 	// Metric
 	PostMetric(metricName string, metricValue string) (string, error)
@@ -131,20 +153,26 @@ type API interface {
 
 	// Volumes
 	GetVolumeStatus() (*volume.Statuses, error)
+	SetStorageQuota(tenantID string, quota volume.StorageQuota) error
+	GetStorageQuotaStatus() ([]volume.TenantQuotaStatus, error)
 
 	// Public endpoints
-	AddPublicEndpointPort(serviceid, endpointName, portAddr string, usetls bool, protocol string, isEnabled, restart bool) (*servicedefinition.Port, error)
+	AddPublicEndpointPort(serviceid, endpointName, portAddr string, usetls bool, protocol string, isEnabled bool, access servicedefinition.AccessRules, restart bool) (*servicedefinition.Port, error)
 	RemovePublicEndpointPort(serviceid, endpointName, portAddr string) error
 	EnablePublicEndpointPort(serviceid, endpointName, portAddr string, isEnabled bool) error
-	AddPublicEndpointVHost(serviceid, endpointName, vhost string, isEnabled, restart bool) (*servicedefinition.VHost, error)
+	AddPublicEndpointVHost(serviceid, endpointName, vhost string, isEnabled bool, access servicedefinition.AccessRules, restart bool) (*servicedefinition.VHost, error)
 	RemovePublicEndpointVHost(serviceid, endpointName, vhost string) error
 	EnablePublicEndpointVHost(serviceid, endpointName, vhost string, isEnabled bool) error
 	GetAllPublicEndpoints() ([]service.PublicEndpoint, error)
+	SetPublicEndpointCertificate(serviceid, endpointName string, kind certificate.Kind, name string, certPEM, keyPEM []byte) (*certificate.Certificate, error)
+	RemovePublicEndpointCertificate(serviceid, endpointName string, kind certificate.Kind, name string) error
+	GetPublicEndpointCertificates() ([]certificate.Certificate, error)
+	EnablePublicEndpointACME(serviceid, endpointName, vhost string, domains []string) (*certificate.Certificate, error)
 
 	// Service Instances
 	GetServiceInstances(serviceID string) ([]service.Instance, error)
 	StopServiceInstance(serviceID string, instanceID int) error
-	AttachServiceInstance(serviceID string, instanceID int, command string, args []string) error
+	AttachServiceInstance(serviceID string, instanceID int, command string, args []string, record bool) error
 	LogsForServiceInstance(serviceID string, instanceID int, command string, args []string) error
 	SendDockerAction(serviceID string, instanceID int, action string, args []string) error
 
@@ -0,0 +1,425 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/config"
+	"github.com/control-center/serviced/domain/host"
+	"github.com/control-center/serviced/domain/service"
+)
+
+const (
+	// logTailInterval is how often new messages are looked up while
+	// following logs
+	logTailInterval = 2 * time.Second
+
+	// logTailOverlap is how far back each lookup goes to catch messages that
+	// reached logstash late
+	logTailOverlap = 30 * time.Second
+)
+
+// LogQueryConfig selects the log messages shown by serviced log tail and
+// serviced log search
+type LogQueryConfig struct {
+	// A list of service IDs to show logs for (includes all child services
+	// unless ExcludeChildren is true); empty for all services
+	ServiceIDs []string
+
+	// Set to true to exclude child services
+	ExcludeChildren bool
+
+	// The instance of the services to show logs for, -1 for all instances
+	Instance int
+
+	// A lucene query that messages must match, "" for all messages
+	Query string
+
+	// A regular expression that messages must match, "" for all messages
+	Grep string
+
+	// Only messages logged at or after Since are shown
+	Since time.Time
+
+	// Only messages logged before Until are shown, zero for no bound
+	Until time.Time
+
+	// Only the most recent Limit messages are shown, 0 for all of them
+	Limit int
+
+	// Set to true to keep showing messages as they are logged (tail only)
+	Follow bool
+
+	// Driver to work with logstash ES instance; if nil a default driver will be used. Primarily used for testing.
+	Driver ExportLogDriver
+}
+
+// LogMessage is a line from the log of a service instance
+type LogMessage struct {
+	Timestamp   time.Time
+	HostID      string
+	HostName    string
+	ServiceID   string
+	ServiceName string
+	InstanceID  string
+	ContainerID string
+	File        string
+	Offset      uint64
+	Message     string
+}
+
+// logMessageKey identifies a log message across searches
+type logMessageKey struct {
+	ContainerID string
+	File        string
+	Offset      uint64
+	Timestamp   int64
+	Message     string
+}
+
+func (m LogMessage) key() logMessageKey {
+	return logMessageKey{m.ContainerID, m.File, m.Offset, m.Timestamp.UnixNano(), m.Message}
+}
+
+// logMessages sorts log messages by time, keeping the lines of each file in
+// order
+type logMessages []LogMessage
+
+func (m logMessages) Len() int      { return len(m) }
+func (m logMessages) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m logMessages) Less(i, j int) bool {
+	a, b := m[i], m[j]
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return a.Timestamp.Before(b.Timestamp)
+	} else if a.ContainerID != b.ContainerID {
+		return a.ContainerID < b.ContainerID
+	} else if a.File != b.File {
+		return a.File < b.File
+	}
+	return a.Offset < b.Offset
+}
+
+// lastLogMessages returns the most recent limit messages, or all of them if
+// limit is 0.
+func lastLogMessages(messages []LogMessage, limit int) []LogMessage {
+	if limit > 0 && len(messages) > limit {
+		return messages[len(messages)-limit:]
+	}
+	return messages
+}
+
+// logSearcher looks up the log messages selected by a LogQueryConfig
+type logSearcher struct {
+	LogQueryConfig
+
+	// The ES-logstash query string
+	query string
+
+	// Compiled from Grep, nil if not set
+	grep *regexp.Regexp
+
+	// Used to name the hosts and services of the messages
+	hostMap    map[string]host.Host
+	serviceMap map[string]service.ServiceDetails
+}
+
+// SearchLogs returns the log messages that match the query, in the order
+// they were logged.
+func (a *api) SearchLogs(cfg LogQueryConfig) ([]LogMessage, error) {
+	searcher, err := newLogSearcher(cfg, a.GetAllServiceDetails, a.GetHostMap)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := searcher.search(cfg.Since, cfg.Until)
+	if err != nil {
+		return nil, err
+	}
+	return lastLogMessages(messages, cfg.Limit), nil
+}
+
+// TailLogs sends the most recent log messages of the services to output in
+// the order they were logged.  If cfg.Follow is set, it keeps sending new
+// messages until cancel is closed.
+func (a *api) TailLogs(cfg LogQueryConfig, output func(LogMessage), cancel <-chan struct{}) error {
+	searcher, err := newLogSearcher(cfg, a.GetAllServiceDetails, a.GetHostMap)
+	if err != nil {
+		return err
+	}
+	return searcher.tail(output, cancel)
+}
+
+func newLogSearcher(cfg LogQueryConfig, getServices func() ([]service.ServiceDetails, error), getHostMap func() (map[string]host.Host, error)) (*logSearcher, error) {
+	if cfg.Driver == nil {
+		cfg.Driver = &elastigoLogDriver{}
+	}
+	if err := cfg.Driver.SetLogstashInfo(config.GetOptions().LogstashES); err != nil {
+		return nil, err
+	}
+
+	searcher := &logSearcher{LogQueryConfig: cfg}
+	if cfg.Grep != "" {
+		grep, err := regexp.Compile(cfg.Grep)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %s: %s", cfg.Grep, err)
+		}
+		searcher.grep = grep
+	}
+
+	// services are selected the same way as for serviced log export
+	exporter := &logExporter{ExportLogsConfig: ExportLogsConfig{
+		ServiceIDs:      cfg.ServiceIDs,
+		ExcludeChildren: cfg.ExcludeChildren,
+	}}
+	query, err := exporter.buildQuery(getServices)
+	if err != nil {
+		return nil, fmt.Errorf("Could not build query: %s", err)
+	}
+	searcher.query = buildLogQuery(query, cfg.Instance, cfg.Query)
+
+	searcher.hostMap, err = getHostMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list of host: %s", err)
+	}
+	searcher.serviceMap, err = buildServiceMap(getServices)
+	if err != nil {
+		return nil, fmt.Errorf("could not build service map: %s", err)
+	}
+	return searcher, nil
+}
+
+// buildLogQuery narrows down the query of the services to an instance and
+// the query of the user.
+func buildLogQuery(serviceQuery string, instance int, userQuery string) string {
+	parts := []string{}
+	if serviceQuery != "*" {
+		parts = append(parts, serviceQuery)
+	}
+	if instance >= 0 {
+		parts = append(parts, fmt.Sprintf("fields.instance:%d", instance))
+	}
+	if userQuery != "" {
+		parts = append(parts, fmt.Sprintf("(%s)", userQuery))
+	}
+	if len(parts) == 0 {
+		return "*"
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// search returns the application log messages logged between since and
+// until, in the order they were logged.
+func (s *logSearcher) search(since, until time.Time) ([]LogMessage, error) {
+	days, err := s.Driver.LogstashDays()
+	if err != nil {
+		return nil, fmt.Errorf("could not determine range of days in the logstash repo: %s", err)
+	}
+
+	// logstash keeps an index for each day
+	fromDate := since.UTC().Format("2006.01.02")
+	toDate := ""
+	timeQuery := fmt.Sprintf("@timestamp:[%d TO *]", toMillis(since))
+	if !until.IsZero() {
+		toDate = until.UTC().Format("2006.01.02")
+		timeQuery = fmt.Sprintf("@timestamp:[%d TO %d]", toMillis(since), toMillis(until))
+	}
+	query := timeQuery
+	if s.query != "*" {
+		query = fmt.Sprintf("%s AND %s", s.query, timeQuery)
+	}
+
+	messages := []LogMessage{}
+	for _, yyyymmdd := range days {
+		if yyyymmdd < fromDate || (toDate != "" && yyyymmdd > toDate) {
+			continue
+		}
+		log.WithFields(logrus.Fields{
+			"date":  yyyymmdd,
+			"query": query,
+		}).Debug("Searching logstash")
+
+		result, err := s.Driver.StartSearch(yyyymmdd, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search elasticsearch for day %s: %s", yyyymmdd, err)
+		}
+		remaining := result.Hits.Total > 0
+		for remaining {
+			result, err = s.Driver.ScrollSearch(result.ScrollId)
+			if err != nil {
+				return nil, err
+			}
+			for _, hit := range result.Hits.Hits {
+				found, err := parseLogMessages(hit.Source)
+				if err != nil {
+					return nil, err
+				}
+				for _, message := range found {
+					if message.Timestamp.Before(since) || (!until.IsZero() && !message.Timestamp.Before(until)) {
+						continue
+					} else if s.grep != nil && !s.grep.MatchString(message.Message) {
+						continue
+					}
+					if h, ok := s.hostMap[message.HostID]; ok {
+						message.HostName = h.Name
+					}
+					if svc, ok := s.serviceMap[message.ServiceID]; ok {
+						message.ServiceName = svc.Name
+					}
+					messages = append(messages, message)
+				}
+			}
+			remaining = len(result.Hits.Hits) > 0
+		}
+	}
+	sort.Stable(logMessages(messages))
+	return messages, nil
+}
+
+// tail sends the most recent messages to output and then, if following,
+// polls logstash for new messages until cancel is closed.
+func (s *logSearcher) tail(output func(LogMessage), cancel <-chan struct{}) error {
+	messages, err := s.search(s.Since, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	// remember the messages that were shown, so the lookups that overlap
+	// don't show them again
+	seen := make(map[logMessageKey]time.Time)
+	last := s.Since
+	for _, message := range messages {
+		seen[message.key()] = message.Timestamp
+		if message.Timestamp.After(last) {
+			last = message.Timestamp
+		}
+	}
+	for _, message := range lastLogMessages(messages, s.Limit) {
+		output(message)
+	}
+
+	for s.Follow {
+		select {
+		case <-cancel:
+			return nil
+		case <-time.After(logTailInterval):
+		}
+
+		messages, err := s.search(last.Add(-logTailOverlap), time.Time{})
+		if err != nil {
+			return err
+		}
+		for _, message := range messages {
+			key := message.key()
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = message.Timestamp
+			if message.Timestamp.After(last) {
+				last = message.Timestamp
+			}
+			output(message)
+		}
+		for key, timestamp := range seen {
+			if timestamp.Before(last.Add(-logTailOverlap)) {
+				delete(seen, key)
+			}
+		}
+	}
+	return nil
+}
+
+// parseLogMessages returns the lines of an application log message from
+// logstash, keeping their exact time.  Messages that serviced logged itself
+// are skipped.
+func parseLogMessages(source []byte) ([]LogMessage, error) {
+	var (
+		logType     string
+		timestamp   time.Time
+		fields      fieldProps
+		containerID string
+		file        string
+		offsets     []uint64
+		lines       []string
+	)
+
+	var line logSingleLine
+	if err := json.Unmarshal(source, &line); err == nil {
+		offset := uint64(0)
+		if len(line.Offset) != 0 {
+			if offset, err = strconv.ParseUint(string(line.Offset), 10, 64); err != nil {
+				return nil, fmt.Errorf("failed to parse offset \"%s\" in \"%s\": %s", line.Offset, source, err)
+			}
+		}
+		logType, timestamp, fields = line.Type, line.Timestamp, line.Fields
+		containerID, file = line.FileBeat.Hostname, line.File
+		offsets, lines = []uint64{offset}, []string{line.Message}
+	} else {
+		multiLine, err := convertMultiLineSource(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse multiLine log from JSON \"%s\": %s", source, err)
+		}
+		logType, timestamp, fields = multiLine.Type, multiLine.Timestamp, multiLine.Fields
+		containerID, file = multiLine.FileBeat.Hostname, multiLine.File
+		offsets, lines = multiLine.Offsets, multiLine.Messages
+		if len(offsets) != len(lines) || !uint64sAreSorted(offsets) {
+			offsets = generateOffsets(lines, offsets)
+		}
+	}
+
+	// Only filebeat, which forwards the application logs, sets the type to "log"
+	if logType != "log" {
+		return nil, nil
+	}
+
+	hostID, err := convertWorkerID(fields.CCWorkerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ccWorkerID from %v in \"%s\": %s", fields.CCWorkerID, source, err)
+	}
+	instanceID := ""
+	switch instance := fields.Instance.(type) {
+	case nil:
+	case float64:
+		instanceID = strconv.FormatFloat(instance, 'f', -1, 64)
+	default:
+		instanceID = fmt.Sprintf("%v", instance)
+	}
+
+	messages := make([]LogMessage, len(lines))
+	for i, message := range lines {
+		messages[i] = LogMessage{
+			Timestamp:   timestamp,
+			HostID:      hostID,
+			ServiceID:   fields.Service,
+			InstanceID:  instanceID,
+			ContainerID: containerID,
+			File:        file,
+			Offset:      offsets[i],
+			Message:     message,
+		}
+	}
+	return messages, nil
+}
+
+// toMillis returns the time in milliseconds since the epoch, which is how
+// elasticsearch compares dates.
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package api
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/control-center/serviced/cli/api/mocks"
+	"github.com/control-center/serviced/domain/host"
+	"github.com/control-center/serviced/domain/service"
+	"github.com/stretchr/testify/mock"
+	"github.com/zenoss/elastigo/core"
+	. "gopkg.in/check.v1"
+)
+
+func setupLogSearchTest(c *C, cfg LogQueryConfig, day string, sources ...interface{}) (*logSearcher, *mocks.ExportLogDriver) {
+	hits := make([]core.Hit, len(sources))
+	for i, source := range sources {
+		data, err := json.Marshal(source)
+		c.Assert(err, IsNil)
+		hits[i] = core.Hit{Source: data}
+	}
+
+	mockLogDriver := &mocks.ExportLogDriver{}
+	mockLogDriver.On("SetLogstashInfo", mock.AnythingOfType("string")).Return(nil)
+	mockLogDriver.On("LogstashDays").Return([]string{day}, nil)
+	searchStart := core.SearchResult{ScrollId: "scroll1", Hits: core.Hits{Total: len(hits)}}
+	mockLogDriver.On("StartSearch", day, mock.AnythingOfType("string")).Return(searchStart, nil)
+	mockLogDriver.On("ScrollSearch", "scroll1").Return(core.SearchResult{ScrollId: "scroll2", Hits: core.Hits{Hits: hits}}, nil)
+	mockLogDriver.On("ScrollSearch", "scroll2").Return(core.SearchResult{}, nil)
+	cfg.Driver = mockLogDriver
+
+	getServices := func() ([]service.ServiceDetails, error) {
+		return []service.ServiceDetails{{ID: "svc1", Name: "zope"}}, nil
+	}
+	getHostMap := func() (map[string]host.Host, error) {
+		return map[string]host.Host{"host1": {ID: "host1", Name: "worker1"}}, nil
+	}
+	searcher, err := newLogSearcher(cfg, getServices, getHostMap)
+	c.Assert(err, IsNil)
+	return searcher, mockLogDriver
+}
+
+func logSource(timestamp time.Time, instance int, offset, message string) logSingleLine {
+	return logSingleLine{
+		Type:      "log",
+		File:      "/var/log/app.log",
+		Timestamp: timestamp,
+		Offset:    json.Number(offset),
+		Message:   message,
+		FileBeat:  beatProps{Hostname: "container1"},
+		Fields:    fieldProps{CCWorkerID: "host1", Service: "svc1", Instance: instance},
+	}
+}
+
+func (s *TestAPISuite) TestLogs_BuildLogQuery(c *C) {
+	c.Assert(buildLogQuery("*", -1, ""), Equals, "*")
+	c.Assert(buildLogQuery("*", 2, ""), Equals, "fields.instance:2")
+	c.Assert(buildLogQuery(`fields.service:("svc1")`, 0, "error OR warn"), Equals, `fields.service:("svc1") AND fields.instance:0 AND (error OR warn)`)
+}
+
+func (s *TestAPISuite) TestLogs_Search(c *C) {
+	now := time.Date(2112, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := LogQueryConfig{
+		ServiceIDs: []string{"svc1"},
+		Instance:   -1,
+		Grep:       "^keep",
+		Since:      now.Add(-time.Hour),
+	}
+	skipped := logSource(now, 0, "1", "keep serviced")
+	skipped.Type = "serviced-host1"
+	searcher, _ := setupLogSearchTest(c, cfg, "2112.01.01",
+		logSource(now.Add(time.Second), 1, "20", "keep second"),
+		logSource(now, 0, "10", "keep first"),
+		logSource(now, 0, "11", "drop first"),
+		logSource(now.Add(-2*time.Hour), 0, "5", "keep too old"),
+		skipped,
+	)
+
+	messages, err := searcher.search(cfg.Since, time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(messages, HasLen, 2)
+	c.Assert(messages[0].Message, Equals, "keep first")
+	c.Assert(messages[0].HostName, Equals, "worker1")
+	c.Assert(messages[0].ServiceName, Equals, "zope")
+	c.Assert(messages[0].InstanceID, Equals, "0")
+	c.Assert(messages[1].Message, Equals, "keep second")
+	c.Assert(messages[1].InstanceID, Equals, "1")
+
+	c.Assert(lastLogMessages(messages, 1)[0].Message, Equals, "keep second")
+	c.Assert(lastLogMessages(messages, 0), HasLen, 2)
+}
+
+func (s *TestAPISuite) TestLogs_SearchSkipsOtherDays(c *C) {
+	now := time.Date(2112, 1, 2, 12, 0, 0, 0, time.UTC)
+	cfg := LogQueryConfig{Instance: -1, Since: now.Add(-time.Hour)}
+	searcher, mockLogDriver := setupLogSearchTest(c, cfg, "2112.01.01", logSource(now, 0, "1", "message"))
+
+	messages, err := searcher.search(cfg.Since, time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(messages, HasLen, 0)
+	mockLogDriver.AssertNotCalled(c, "StartSearch", mock.Anything, mock.Anything)
+}
+
+func (s *TestAPISuite) TestLogs_Tail(c *C) {
+	now := time.Date(2112, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := LogQueryConfig{
+		Instance: -1,
+		Since:    now.Add(-time.Hour),
+		Limit:    2,
+	}
+	searcher, _ := setupLogSearchTest(c, cfg, "2112.01.01",
+		logSource(now, 0, "1", "one"),
+		logSource(now, 0, "2", "two"),
+		logSource(now, 0, "3", "three"),
+	)
+
+	var shown []string
+	err := searcher.tail(func(message LogMessage) {
+		shown = append(shown, message.Message)
+	}, nil)
+	c.Assert(err, IsNil)
+	c.Assert(shown, DeepEquals, []string{"two", "three"})
+}
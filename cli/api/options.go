@@ -135,8 +135,12 @@ func GetDefaultOptions(cfg utils.ConfigReader) config.Options {
 		Master:                     cfg.BoolVal("MASTER", false),
 		MuxPort:                    cfg.IntVal("MUX_PORT", 22250),
 		MuxDisableTLS:              strconv.FormatBool(cfg.BoolVal("MUX_DISABLE_TLS", false)),
+		MuxSessions:                cfg.IntVal("MUX_SESSIONS", 0),
+		MuxMutualTLS:               strconv.FormatBool(cfg.BoolVal("MUX_MUTUAL_TLS", false)),
 		KeyPEMFile:                 cfg.StringVal("KEY_FILE", ""),
 		CertPEMFile:                cfg.StringVal("CERT_FILE", ""),
+		ACMEDirectory:              cfg.StringVal("ACME_DIRECTORY", ""),
+		ACMEEmail:                  cfg.StringVal("ACME_EMAIL", ""),
 		Zookeepers:                 cfg.StringSlice("ZK", []string{}),
 		HostStats:                  cfg.StringVal("STATS_PORT", fmt.Sprintf("%s:8443", masterIP)),
 		StatsPeriod:                cfg.IntVal("STATS_PERIOD", 10),
@@ -149,6 +153,8 @@ func GetDefaultOptions(cfg utils.ConfigReader) config.Options {
 		Verbosity:                  cfg.IntVal("LOG_LEVEL", 0),
 		StaticIPs:                  cfg.StringSlice("STATIC_IPS", []string{}),
 		DockerRegistry:             cfg.StringVal("DOCKER_REGISTRY", "localhost:5000"),
+		DockerRegistryReplica:      cfg.StringVal("DOCKER_REGISTRY_REPLICA", ""),
+		DockerRegistryReplicaZK:    cfg.StringSlice("DOCKER_REGISTRY_REPLICA_ZK", []string{}),
 		MaxContainerAge:            cfg.IntVal("MAX_CONTAINER_AGE", 60*60*24),
 		MaxDFSTimeout:              cfg.IntVal("MAX_DFS_TIMEOUT", 60*5),
 		VirtualAddressSubnet:       cfg.StringVal("VIRTUAL_ADDRESS_SUBNET", "10.3.0.0/16"),
@@ -159,6 +165,7 @@ func GetDefaultOptions(cfg utils.ConfigReader) config.Options {
 		LogstashMaxSize:            cfg.IntVal("LOGSTASH_MAX_SIZE", 10),
 		LogstashCycleTime:          cfg.IntVal("LOGSTASH_CYCLE_TIME", 6),
 		DebugPort:                  cfg.IntVal("DEBUG_PORT", 6006),
+		MetricsPort:                cfg.StringVal("METRICS_PORT", "127.0.0.1:4982"),
 		AdminGroup:                 cfg.StringVal("ADMIN_GROUP", getDefaultAdminGroup()),
 		MaxRPCClients:              cfg.IntVal("MAX_RPC_CLIENTS", 3),
 		MUXTLSCiphers:              cfg.StringSlice("MUX_TLS_CIPHERS", utils.GetDefaultCiphers("mux")),
@@ -224,6 +231,8 @@ func GetDefaultOptions(cfg utils.ConfigReader) config.Options {
 	options.LogPath = cfg.StringVal("LOG_PATH", "/var/log/serviced")
 	options.VolumesPath = cfg.StringVal("VOLUMES_PATH", filepath.Join(varpath, "volumes"))
 	options.BackupsPath = cfg.StringVal("BACKUPS_PATH", filepath.Join(varpath, "backups"))
+	options.MuxCredentialsPath = cfg.StringVal("MUX_CREDENTIALS_PATH", filepath.Join(varpath, "mux"))
+	options.SessionsPath = cfg.StringVal("SESSIONS_PATH", filepath.Join(varpath, "sessions"))
 	options.EtcPath = cfg.StringVal("ETC_PATH", filepath.Join(options.HomePath, "etc"))
 	options.StorageArgs = getDefaultStorageOptions(options.FSType, cfg)
 
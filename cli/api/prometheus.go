@@ -0,0 +1,187 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/config"
+	coordzk "github.com/control-center/serviced/coordinator/client/zookeeper"
+	"github.com/control-center/serviced/domain/pool"
+	"github.com/control-center/serviced/health"
+	"github.com/control-center/serviced/rpc/master"
+	"github.com/control-center/serviced/rpc/rpcutils"
+	"github.com/control-center/serviced/scheduler/servicestatemanager"
+	"github.com/control-center/serviced/stats"
+)
+
+// startMetrics serves the stats of this process at /metrics in the
+// Prometheus format.  The master and agent add their own sources as they
+// start.  The metrics are not authenticated, so the default address only
+// accepts local connections.
+func (d *daemon) startMetrics() {
+	options := config.GetOptions()
+	d.exporter.AddRegistrySource(rpcutils.CallRegistries)
+	d.exporter.AddSource(zookeeperSamples)
+	if options.MetricsPort == "" {
+		return
+	}
+
+	logger := log.WithFields(logrus.Fields{
+		"server":  "metrics",
+		"address": options.MetricsPort,
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", d.exporter)
+	go func() {
+		logger.Info("Listening for metrics scrapes")
+		if err := http.ListenAndServe(options.MetricsPort, mux); err != nil {
+			logger.WithError(err).Warn("Unable to bind to metrics port")
+		}
+	}()
+}
+
+// startMetricSinks sends the stats of this host to the metric sinks of its
+// pool, and keeps them up to date with the pool.
+func (d *daemon) startMetricSinks(poolID string) *stats.Sinks {
+	sinks := stats.NewSinks()
+	getSinks := func() (pool.MetricSinks, error) {
+		masterClient, err := master.NewClient(d.servicedEndpoint)
+		if err != nil {
+			return nil, err
+		}
+		defer masterClient.Close()
+		return masterClient.GetPoolMetricSinks(poolID)
+	}
+	go sinks.Watch(time.Minute, getSinks, d.shutdown)
+	d.exporter.AddRegistrySource(sinks.Registries)
+	return sinks
+}
+
+// boolValue formats a gauge that is 1 if b is true
+func boolValue(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// zookeeperSamples reports whether each ZooKeeper connection of this process
+// has a session.
+func zookeeperSamples() []stats.Sample {
+	now := time.Now().Unix()
+	samples := []stats.Sample{}
+	for _, session := range coordzk.SessionStates() {
+		samples = append(samples, stats.Sample{
+			Metric:    "zookeeper.session",
+			Value:     boolValue(session.HasSession),
+			Timestamp: now,
+			Tags: map[string]string{
+				"zookeeper_servers": session.Servers,
+				"zookeeper_path":    session.BasePath,
+				"state":             session.State,
+			},
+		})
+	}
+	return samples
+}
+
+// healthSamplesInterval is how often the health check samples are refreshed
+const healthSamplesInterval = 30 * time.Second
+
+// healthSampler reports whether each health check of each running instance
+// is passing.  Looking up the health of every service is too slow to do on
+// each scrape, so the samples are refreshed on an interval and scrapes get
+// the last snapshot.
+type healthSampler struct {
+	mu      sync.Mutex
+	samples []stats.Sample
+	get     func() (map[string]map[int]map[string]health.HealthStatus, error)
+}
+
+// Watch refreshes the samples every interval until cancel is closed
+func (h *healthSampler) Watch(interval time.Duration, cancel <-chan interface{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.refresh()
+		select {
+		case <-ticker.C:
+		case <-cancel:
+			return
+		}
+	}
+}
+
+// refresh takes a new snapshot of the health checks.  The last snapshot is
+// kept if the health checks could not be looked up.
+func (h *healthSampler) refresh() {
+	results, err := h.get()
+	if err != nil {
+		log.WithError(err).Debug("Could not get health checks for metrics")
+		return
+	}
+	now := time.Now().Unix()
+	samples := []stats.Sample{}
+	for serviceID, instances := range results {
+		for instanceID, checks := range instances {
+			for name, status := range checks {
+				samples = append(samples, stats.Sample{
+					Metric:    "healthcheck.passed",
+					Value:     boolValue(status.Status == health.OK),
+					Timestamp: now,
+					Tags: map[string]string{
+						"controlplane_service_id":  serviceID,
+						"controlplane_instance_id": strconv.Itoa(instanceID),
+						"healthcheck":              name,
+					},
+				})
+			}
+		}
+	}
+	h.mu.Lock()
+	h.samples = samples
+	h.mu.Unlock()
+}
+
+// Samples returns the last snapshot of the health checks
+func (h *healthSampler) Samples() []stats.Sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.samples
+}
+
+// queueSamples reports the number of batches and services waiting in the
+// service state manager's queues.
+func queueSamples(ssm *servicestatemanager.BatchServiceStateManager) func() []stats.Sample {
+	return func() []stats.Sample {
+		now := time.Now().Unix()
+		samples := []stats.Sample{}
+		for _, depth := range ssm.QueueDepths() {
+			tags := map[string]string{
+				"controlplane_tenant_id": depth.TenantID,
+				"desiredstate":           depth.DesiredState.String(),
+			}
+			samples = append(samples,
+				stats.Sample{Metric: "scheduler.queue.batches", Value: strconv.Itoa(depth.Batches), Timestamp: now, Tags: tags},
+				stats.Sample{Metric: "scheduler.queue.services", Value: strconv.Itoa(depth.Services), Timestamp: now, Tags: tags},
+			)
+		}
+		return samples
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package api
+
+import (
+	"errors"
+
+	"github.com/control-center/serviced/health"
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestAPISuite) TestHealthSamplerSnapshot(c *C) {
+	lookups := 0
+	var err error
+	sampler := &healthSampler{get: func() (map[string]map[int]map[string]health.HealthStatus, error) {
+		lookups++
+		return map[string]map[int]map[string]health.HealthStatus{
+			"svc": {0: {"answering": {Status: health.OK}, "ready": {Status: health.Failed}}},
+		}, err
+	}}
+	c.Assert(sampler.Samples(), HasLen, 0)
+
+	// scrapes read the snapshot without looking up the health checks
+	sampler.refresh()
+	for i := 0; i < 3; i++ {
+		samples := sampler.Samples()
+		c.Assert(samples, HasLen, 2)
+		passed := map[string]string{}
+		for _, sample := range samples {
+			c.Check(sample.Metric, Equals, "healthcheck.passed")
+			c.Check(sample.Tags["controlplane_service_id"], Equals, "svc")
+			c.Check(sample.Tags["controlplane_instance_id"], Equals, "0")
+			passed[sample.Tags["healthcheck"]] = sample.Value
+		}
+		c.Check(passed, DeepEquals, map[string]string{"answering": "1", "ready": "0"})
+	}
+	c.Assert(lookups, Equals, 1)
+
+	// the last snapshot is kept if the lookup fails
+	err = errors.New("lookup failed")
+	sampler.refresh()
+	c.Assert(lookups, Equals, 2)
+	c.Assert(sampler.Samples(), HasLen, 2)
+}
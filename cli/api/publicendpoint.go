@@ -14,19 +14,20 @@
 package api
 
 import (
+	"github.com/control-center/serviced/domain/certificate"
 	"github.com/control-center/serviced/domain/service"
 	"github.com/control-center/serviced/domain/servicedefinition"
 )
 
 // Add a new port public endpoint.
 func (a *api) AddPublicEndpointPort(serviceid, endpointName, portAddr string, usetls bool,
-	protocol string, isEnabled bool, restart bool) (*servicedefinition.Port, error) {
+	protocol string, isEnabled bool, access servicedefinition.AccessRules, restart bool) (*servicedefinition.Port, error) {
 	client, err := a.connectMaster()
 	if err != nil {
 		return nil, err
 	}
 
-	return client.AddPublicEndpointPort(serviceid, endpointName, portAddr, usetls, protocol, isEnabled, restart)
+	return client.AddPublicEndpointPort(serviceid, endpointName, portAddr, usetls, protocol, isEnabled, access, restart)
 }
 
 // Remove a port public endpoint.
@@ -49,13 +50,13 @@ func (a *api) EnablePublicEndpointPort(serviceid, endpointName, portAddr string,
 	return client.EnablePublicEndpointPort(serviceid, endpointName, portAddr, isEnabled)
 }
 
-func (a *api) AddPublicEndpointVHost(serviceid, endpointName, vhost string, isEnabled, restart bool) (*servicedefinition.VHost, error) {
+func (a *api) AddPublicEndpointVHost(serviceid, endpointName, vhost string, isEnabled bool, access servicedefinition.AccessRules, restart bool) (*servicedefinition.VHost, error) {
 	client, err := a.connectMaster()
 	if err != nil {
 		return nil, err
 	}
 
-	return client.AddPublicEndpointVHost(serviceid, endpointName, vhost, isEnabled, restart)
+	return client.AddPublicEndpointVHost(serviceid, endpointName, vhost, isEnabled, access, restart)
 }
 
 func (a *api) RemovePublicEndpointVHost(serviceid, endpointName, vhost string) error {
@@ -84,3 +85,39 @@ func (a *api) GetAllPublicEndpoints() ([]service.PublicEndpoint, error) {
 
 	return client.GetAllPublicEndpoints()
 }
+
+func (a *api) SetPublicEndpointCertificate(serviceid, endpointName string, kind certificate.Kind, name string, certPEM, keyPEM []byte) (*certificate.Certificate, error) {
+	client, err := a.connectMaster()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.SetPublicEndpointCertificate(serviceid, endpointName, kind, name, certPEM, keyPEM)
+}
+
+func (a *api) RemovePublicEndpointCertificate(serviceid, endpointName string, kind certificate.Kind, name string) error {
+	client, err := a.connectMaster()
+	if err != nil {
+		return err
+	}
+
+	return client.RemovePublicEndpointCertificate(serviceid, endpointName, kind, name)
+}
+
+func (a *api) GetPublicEndpointCertificates() ([]certificate.Certificate, error) {
+	client, err := a.connectMaster()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetPublicEndpointCertificates()
+}
+
+func (a *api) EnablePublicEndpointACME(serviceid, endpointName, vhost string, domains []string) (*certificate.Certificate, error) {
+	client, err := a.connectMaster()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.EnablePublicEndpointACME(serviceid, endpointName, vhost, domains)
+}
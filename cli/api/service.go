@@ -28,6 +28,7 @@ import (
 	"github.com/control-center/serviced/domain/service"
 	"github.com/control-center/serviced/domain/servicedefinition"
 	"github.com/control-center/serviced/health"
+	"github.com/control-center/serviced/slo"
 
 	"github.com/control-center/serviced/domain/host"
 	"github.com/pivotal-golang/bytefmt"
@@ -479,3 +480,26 @@ func (a *api) ClearEmergency(serviceID string) (int, error) {
 
 	return client.ClearEmergency(serviceID)
 }
+
+// GetServiceSLOs reports each service level objective of a service against
+// its target
+func (a *api) GetServiceSLOs(serviceID string) ([]slo.Status, error) {
+	client, err := a.connectMaster()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetServiceSLOs(serviceID)
+}
+
+// GetResourceRecommendations recommends new commitments and limits for the
+// services of a tenant, or of all tenants, from their usage since the given
+// time
+func (a *api) GetResourceRecommendations(tenantID string, since time.Time) ([]service.ResourceRecommendation, error) {
+	client, err := a.connectMaster()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetResourceRecommendations(tenantID, since)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/Sirupsen/logrus"
 	ccconfig "github.com/control-center/serviced/config"
 	"github.com/control-center/serviced/dao"
+	"github.com/control-center/serviced/domain/auditlog"
 	"github.com/control-center/serviced/domain/service"
 	"github.com/control-center/serviced/node"
 	"github.com/control-center/serviced/shell"
@@ -40,6 +41,7 @@ type ShellConfig struct {
 	Mounts           []string
 	ServicedEndpoint string
 	LogToStderr      bool
+	Record           bool // keep a recording of the session on the master
 	LogStash         struct {
 		Enable        bool
 		SettleTime    string
@@ -126,10 +128,16 @@ func (a *api) StartShell(config ShellConfig) error {
 		return fmt.Errorf("failed to connect to service: %s", err)
 	}
 
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	hostID, err := utils.HostID()
+	if err != nil {
+		return err
+	}
+	session, err := a.beginSession(auditlog.SessionShell, config.ServiceID, hostID, -1, command, config.Record)
+	if err != nil {
+		return fmt.Errorf("failed to audit shell session: %s", err)
+	}
+	_, err = session.run(cmd)
+	return err
 }
 
 // RunShell runs a predefined service shell command via the service definition
@@ -197,9 +205,20 @@ func (a *api) RunShell(config ShellConfig, stopChan chan struct{}) (int, error)
 		return 1, fmt.Errorf("failed to connect to service: %s", err)
 	}
 
+	hostID, err := utils.HostID()
+	if err != nil {
+		return 1, err
+	}
+	session, err := a.beginSession(auditlog.SessionRun, config.ServiceID, hostID, -1, append([]string{config.Command}, config.Args...), config.Record)
+	if err != nil {
+		return 1, fmt.Errorf("failed to audit run session: %s", err)
+	}
+	exitcode, snapshot := 1, ""
+	defer func() { session.end(exitcode, snapshot) }()
+
 	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = session.output(os.Stdout)
+	cmd.Stderr = session.output(os.Stderr)
 
 	dockercli, err := a.connectDocker()
 	if err != nil {
@@ -242,7 +261,7 @@ func (a *api) RunShell(config ShellConfig, stopChan chan struct{}) (int, error)
 		log.WithError(err).Fatal("Abnormal termination from shell command")
 	}
 
-	exitcode, err := dockercli.WaitContainer(config.SaveAs)
+	exitcode, err = dockercli.WaitContainer(config.SaveAs)
 	if err != nil {
 		log.WithError(err).Fatal("Failure waiting for container")
 	}
@@ -263,6 +282,7 @@ func (a *api) RunShell(config ShellConfig, stopChan chan struct{}) (int, error)
 			if err := client.Snapshot(dao.SnapshotRequest{ContainerID: container.ID, SnapshotSpacePercent: options.SnapshotSpacePercent}, &label); err != nil {
 				log.WithError(err).Fatal("Unable to commit container")
 			}
+			snapshot = label
 		}
 	} else {
 		// Delete the container
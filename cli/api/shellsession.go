@@ -0,0 +1,168 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"os/user"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/domain/auditlog"
+	"github.com/control-center/serviced/rpc/master"
+	"github.com/control-center/serviced/utils"
+)
+
+// shellSession audits a command run in a service's container, and records
+// its output if asked to
+type shellSession struct {
+	client   master.ClientInterface
+	session  auditlog.Session
+	recorder *auditlog.Recorder
+}
+
+// beginSession writes the start of a session to the audit log on the master.
+// The session is refused if it cannot be audited.
+func (a *api) beginSession(kind auditlog.SessionKind, serviceID, hostID string, instanceID int, command []string, record bool) (*shellSession, error) {
+	client, err := a.connectMaster()
+	if err != nil {
+		return nil, err
+	}
+	id, err := utils.NewUUID62()
+	if err != nil {
+		return nil, err
+	}
+	s := &shellSession{
+		client: client,
+		session: auditlog.Session{
+			ID:         id,
+			Kind:       kind,
+			User:       sessionUser(),
+			HostID:     hostID,
+			ServiceID:  serviceID,
+			InstanceID: instanceID,
+			Command:    utils.ShellQuoteArgs(command),
+			Start:      time.Now().UTC(),
+		},
+	}
+	if err := client.RecordShellSession(s.session, nil); err != nil {
+		log.WithError(err).Error("Unable to audit session")
+		return nil, err
+	}
+	if record {
+		s.recorder = auditlog.NewRecorder(string(kind)+" "+serviceID, s.session.Command)
+		log.WithField("session", id).Info("Recording session")
+	}
+	return s, nil
+}
+
+// output returns where the session's output should be written
+func (s *shellSession) output(w io.Writer) io.Writer {
+	if s.recorder == nil {
+		return w
+	}
+	return io.MultiWriter(w, s.recorder)
+}
+
+// sessionHangupGrace is how long a command gets to exit after the terminal
+// hangs up or the CLI is terminated, before it is killed.
+const sessionHangupGrace = 10 * time.Second
+
+// run runs the command attached to the terminal, ends the session and returns
+// the command's exit code.  Interrupts are left to the command.  If the
+// terminal hangs up or the CLI is terminated, the signal is passed on to the
+// command, so that the end of the session is still written.
+func (s *shellSession) run(cmd *exec.Cmd) (int, error) {
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = s.output(os.Stdout)
+	cmd.Stderr = s.output(os.Stderr)
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGHUP, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+
+	exitcode := 1
+	defer func() { s.end(exitcode, "") }()
+	if err := cmd.Start(); err != nil {
+		return exitcode, err
+	}
+	waitc := make(chan error, 1)
+	go func() { waitc <- cmd.Wait() }()
+
+	var killc <-chan time.Time
+	for {
+		select {
+		case sig := <-sigc:
+			if sig != syscall.SIGHUP && sig != syscall.SIGTERM {
+				continue
+			}
+			log.WithField("signal", sig).Debug("Passing signal to the session command")
+			cmd.Process.Signal(sig)
+			if killc == nil {
+				killc = time.After(sessionHangupGrace)
+			}
+		case <-killc:
+			log.Warn("Session command did not exit, killing it")
+			cmd.Process.Kill()
+		case err := <-waitc:
+			if code, ok := utils.GetExitStatus(err); ok {
+				exitcode = code
+			}
+			return exitcode, err
+		}
+	}
+}
+
+// end writes the end of the session to the audit log, along with its
+// recording
+func (s *shellSession) end(exitcode int, snapshot string) {
+	s.session.End = time.Now().UTC()
+	s.session.ExitCode = exitcode
+	s.session.Snapshot = snapshot
+	var recording *auditlog.Recording
+	if s.recorder != nil {
+		recording = s.recorder.Recording()
+		s.session.Recorded = true
+	}
+	if err := s.client.RecordShellSession(s.session, recording); err != nil {
+		log.WithFields(logrus.Fields{
+			"session": s.session.ID,
+		}).WithError(err).Warn("Unable to audit the end of the session")
+	}
+}
+
+// sessionUser returns the local user running the command, looking through
+// sudo
+func sessionUser() string {
+	if name := os.Getenv("SUDO_USER"); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+// GetShellSessionRecording returns the recording of a session
+func (a *api) GetShellSessionRecording(sessionID string) (*auditlog.Recording, error) {
+	client, err := a.connectMaster()
+	if err != nil {
+		return nil, err
+	}
+	return client.GetShellSessionRecording(sessionID)
+}
@@ -0,0 +1,54 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package api
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/control-center/serviced/domain/auditlog"
+	"github.com/stretchr/testify/mock"
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestAPISuite) TestShellSessionTerminated(c *C) {
+	var ended auditlog.Session
+	s.mockMasterClient.On("RecordShellSession", mock.AnythingOfType("auditlog.Session"), (*auditlog.Recording)(nil)).Return(nil).Run(func(a mock.Arguments) {
+		ended = a.Get(0).(auditlog.Session)
+	}).Once()
+	session := &shellSession{
+		client:  s.mockMasterClient,
+		session: auditlog.Session{ID: "abc123", Kind: auditlog.SessionShell, Start: time.Now().UTC()},
+	}
+
+	// the CLI is terminated while the command is running
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	}()
+	start := time.Now()
+	exitcode, err := session.run(exec.Command("sleep", "30"))
+	c.Assert(err, NotNil)
+	c.Check(exitcode, Not(Equals), 0)
+	c.Check(time.Since(start) < 10*time.Second, Equals, true)
+
+	// the end of the session is still written
+	s.mockMasterClient.AssertExpectations(c)
+	c.Check(ended.End.IsZero(), Equals, false)
+	c.Check(ended.ExitCode, Equals, exitcode)
+}
@@ -27,3 +27,22 @@ func (a *api) GetVolumeStatus() (*volume.Statuses, error) {
 	}
 	return response, nil
 }
+
+// SetStorageQuota sets the storage quota for a tenant
+func (a *api) SetStorageQuota(tenantID string, quota volume.StorageQuota) error {
+	client, err := a.connectMaster()
+	if err != nil {
+		return err
+	}
+	return client.SetStorageQuota(tenantID, quota)
+}
+
+// GetStorageQuotaStatus reports the storage used by each tenant against its
+// quota
+func (a *api) GetStorageQuotaStatus() ([]volume.TenantQuotaStatus, error) {
+	client, err := a.connectMaster()
+	if err != nil {
+		return nil, err
+	}
+	return client.GetStorageQuotaStatus()
+}
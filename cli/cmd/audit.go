@@ -0,0 +1,209 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/control-center/serviced/domain/auditlog"
+)
+
+// auditTimeFormat is how the time of each audit entry is shown
+const auditTimeFormat = "2006-01-02T15:04:05Z07:00"
+
+// Initializer for serviced audit
+func (c *ServicedCli) initAudit() {
+	c.app.Commands = append(c.app.Commands, cli.Command{
+		Name:        "audit",
+		Usage:       "Administers the audit trail",
+		Description: "",
+		Subcommands: []cli.Command{
+			{
+				Name:        "list",
+				Usage:       "Lists entries in the audit trail, newest first",
+				Description: "serviced audit list",
+				Action:      c.cmdAuditList,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "user",
+						Usage: "only show actions performed by this user",
+					},
+					cli.StringFlag{
+						Name:  "entity",
+						Usage: "only show actions on this entity type (e.g. service) or entity ID",
+					},
+					cli.StringFlag{
+						Name:  "since",
+						Usage: "only show actions performed in this duration before now (e.g. 24h)",
+					},
+					cli.IntFlag{
+						Name:  "offset",
+						Value: 0,
+						Usage: "number of matching entries to skip",
+					},
+					cli.IntFlag{
+						Name:  "limit",
+						Value: 100,
+						Usage: "maximum number of entries to show",
+					},
+					cli.BoolFlag{
+						Name:  "verbose, v",
+						Usage: "Show JSON format, including the hash chain",
+					},
+				},
+			}, {
+				Name:        "verify",
+				Usage:       "Verifies that the audit trail has not been tampered with",
+				Description: "serviced audit verify",
+				Action:      c.cmdAuditVerify,
+			}, {
+				Name:        "replay",
+				Usage:       "Replays the recording of a shell, attach or run session",
+				Description: "serviced audit replay SESSIONID",
+				Action:      c.cmdAuditReplay,
+				Flags: []cli.Flag{
+					cli.Float64Flag{
+						Name:  "speed",
+						Value: 1,
+						Usage: "how many times faster than the session to replay",
+					},
+					cli.StringFlag{
+						Name:  "max-wait",
+						Value: "",
+						Usage: "longest pause between output, as a duration (e.g. 2s)",
+					},
+				},
+			},
+		},
+	})
+}
+
+// serviced audit list [--user USER] [--entity ENTITY] [--since DURATION]
+func (c *ServicedCli) cmdAuditList(ctx *cli.Context) {
+	query := auditlog.Query{
+		User:   ctx.String("user"),
+		Entity: ctx.String("entity"),
+		Offset: ctx.Int("offset"),
+		Limit:  ctx.Int("limit"),
+	}
+	if since := ctx.String("since"); since != "" {
+		d, err := time.ParseDuration(since)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid duration %s: %s\n", since, err)
+			c.exit(1)
+			return
+		}
+		query.Since = time.Now().Add(-d)
+	}
+
+	entries, err := c.driver.GetAuditEntries(query)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	} else if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "no audit entries found")
+		return
+	}
+
+	if ctx.Bool("verbose") {
+		if data, err := json.MarshalIndent(entries, " ", "  "); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to marshal audit entries: %s", err)
+		} else {
+			fmt.Println(string(data))
+		}
+		return
+	}
+
+	t := NewTable("Seq,Time,User,Action,Entity,Result,Message")
+	for _, e := range entries {
+		entity := e.EntityType
+		if e.EntityID != "" {
+			entity += "/" + e.EntityID
+		}
+		result := "ok"
+		if !e.Success {
+			result = "failed"
+		}
+		t.AddRow(map[string]interface{}{
+			"Seq":     e.Seq,
+			"Time":    e.Timestamp.Format(auditTimeFormat),
+			"User":    e.User,
+			"Action":  e.Action,
+			"Entity":  entity,
+			"Result":  result,
+			"Message": e.Message,
+		})
+	}
+	t.Print()
+}
+
+// serviced audit verify
+func (c *ServicedCli) cmdAuditVerify(ctx *cli.Context) {
+	result, err := c.driver.VerifyAuditTrail()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+	if !result.Valid {
+		fmt.Fprintf(os.Stderr, "audit trail failed verification after %d entries: %s\n", result.Count, result.Error)
+		c.exit(1)
+		return
+	}
+	fmt.Printf("Verified %d audit entries\n", result.Count)
+	if result.Count > 0 {
+		fmt.Printf("Last entry %d has hash %s\n", result.LastSeq, result.LastHash)
+	}
+}
+
+// serviced audit replay [--speed SPEED] [--max-wait DURATION] SESSIONID
+func (c *ServicedCli) cmdAuditReplay(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 1 {
+		fmt.Printf("Incorrect Usage.\n\n")
+		cli.ShowCommandHelp(ctx, "replay")
+		return
+	}
+
+	var maxWait time.Duration
+	if wait := ctx.String("max-wait"); wait != "" {
+		d, err := time.ParseDuration(wait)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid duration %s: %s\n", wait, err)
+			c.exit(1)
+			return
+		}
+		maxWait = d
+	}
+
+	recording, err := c.driver.GetShellSessionRecording(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+	if err := auditlog.Replay(os.Stdout, recording, ctx.Float64("speed"), maxWait); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+	if recording.Truncated {
+		fmt.Fprintln(os.Stderr, "\nthe recording was truncated")
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package cmd
+
+import (
+	"time"
+
+	mocks "github.com/control-center/serviced/cli/api/apimocks"
+	"github.com/control-center/serviced/domain/auditlog"
+	"github.com/control-center/serviced/utils"
+	"github.com/stretchr/testify/mock"
+)
+
+func runAuditAPITest(driver *mocks.API, args ...string) {
+	c := New(driver, utils.TestConfigReader(make(map[string]string)), MockLogControl{})
+	c.exitDisabled = true
+	c.Run(args)
+}
+
+func ExampleServicedCli_auditList() {
+	entries := []auditlog.Entry{
+		{
+			Seq:        2,
+			Timestamp:  time.Date(2018, 3, 1, 12, 30, 0, 0, time.UTC),
+			User:       "admin",
+			Action:     "stop",
+			EntityType: "service",
+			EntityID:   "svc1",
+			Message:    "Stopping Service",
+			Success:    false,
+		}, {
+			Seq:        1,
+			Timestamp:  time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC),
+			User:       "admin",
+			Action:     "add",
+			EntityType: "resourcepool",
+			EntityID:   "pool1",
+			Message:    "Adding Resource Pool",
+			Success:    true,
+		},
+	}
+	matcher := func(query auditlog.Query) bool {
+		return query.User == "admin" && query.Entity == "" && query.Limit == 10 &&
+			time.Since(query.Since) >= 24*time.Hour
+	}
+	mockAPI := &mocks.API{}
+	mockAPI.On("GetAuditEntries", mock.MatchedBy(matcher)).Return(entries, nil)
+	runAuditAPITest(mockAPI, "serviced", "audit", "list", "--user", "admin", "--since", "24h", "--limit", "10")
+
+	// Output:
+	// Seq Time                 User  Action Entity             Result Message
+	// 2   2018-03-01T12:30:00Z admin stop   service/svc1       failed Stopping Service
+	// 1   2018-03-01T12:00:00Z admin add    resourcepool/pool1 ok     Adding Resource Pool
+}
+
+func ExampleServicedCli_auditVerify() {
+	mockAPI := &mocks.API{}
+	mockAPI.On("VerifyAuditTrail").Return(&auditlog.Verification{Count: 42, LastSeq: 42, LastHash: "c0ffee", Valid: true}, nil)
+	runAuditAPITest(mockAPI, "serviced", "audit", "verify")
+
+	// Output:
+	// Verified 42 audit entries
+	// Last entry 42 has hash c0ffee
+}
+
+func ExampleServicedCli_auditVerifyTampered() {
+	mockAPI := &mocks.API{}
+	mockAPI.On("VerifyAuditTrail").Return(&auditlog.Verification{Count: 6, LastSeq: 6, Error: "entry 7: hash does not match its contents"}, nil)
+	runAuditAPITest(mockAPI, "serviced", "audit", "verify")
+
+	// Output:
+}
+
+func ExampleServicedCli_auditReplay() {
+	recording := &auditlog.Recording{
+		Version: 1,
+		Stdout:  []auditlog.Frame{{Delay: 5, Data: "$ ls\n"}, {Delay: 5, Data: "etc  opt\n"}},
+	}
+	mockAPI := &mocks.API{}
+	mockAPI.On("GetShellSessionRecording", "abc123").Return(recording, nil)
+	runAuditAPITest(mockAPI, "serviced", "audit", "replay", "--max-wait", "1ms", "abc123")
+
+	// Output:
+	// $ ls
+	// etc  opt
+}
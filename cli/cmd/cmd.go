@@ -77,11 +77,15 @@ func New(driver api.API, config utils.ConfigReader, logControl logging.LogContro
 		cli.BoolFlag{"agent", "deprecated"},
 		cli.IntFlag{"mux", defaultOps.MuxPort, "multiplexing port"},
 		cli.StringFlag{"mux-disable-tls", defaultOps.MuxDisableTLS, "disable TLS for mux connections"},
+		cli.IntFlag{"mux-sessions", defaultOps.MuxSessions, "number of connections to each remote mux that are shared by multiplexed streams, 0 for a connection per stream"},
+		cli.StringFlag{"mux-mutual-tls", defaultOps.MuxMutualTLS, "require per-host and per-tenant certificates issued by the master for mux connections"},
 		cli.StringSliceFlag{"mux-tls-ciphers", convertToStringSlice(defaultOps.MUXTLSCiphers), "list of supported TLS ciphers for MUX"},
 		cli.StringFlag{"mux-tls-min-version", string(defaultOps.MUXTLSMinVersion), "mininum TLS version for MUX"},
 		cli.StringFlag{"volumes-path", defaultOps.VolumesPath, "path where application data is stored"},
 		cli.StringFlag{"isvcs-path", defaultOps.IsvcsPath, "path where internal application data is stored"},
 		cli.StringFlag{"backups-path", defaultOps.BackupsPath, "default path where backups are stored"},
+		cli.StringFlag{"mux-credentials-path", defaultOps.MuxCredentialsPath, "path where the mux certificates of this host and its tenants are stored"},
+		cli.StringFlag{"sessions-path", defaultOps.SessionsPath, "path where the master stores recordings of shell sessions"},
 		cli.StringFlag{"etc-path", defaultOps.EtcPath, "default path for configuration files"},
 		cli.StringFlag{"log-path", defaultOps.LogPath, "path where serviced logs are located"},
 		cli.StringFlag{"keyfile", defaultOps.KeyPEMFile, "path to private key file (defaults to compiled in private key)"},
@@ -111,6 +115,7 @@ func New(driver api.API, config utils.ConfigReader, logControl logging.LogContro
 		cli.StringFlag{"mc-password", defaultOps.MCPasswd, "Password for the Zenoss metric consumer"},
 		cli.StringFlag{"cpuprofile", defaultOps.CPUProfile, "write cpu profile to file"},
 		cli.IntFlag{"debug-port", defaultOps.DebugPort, "Port on which to listen for profiler connections"},
+		cli.StringFlag{"metrics-port", defaultOps.MetricsPort, "Address on which to serve metrics in the Prometheus format (local connections only by default)"},
 		cli.IntFlag{"max-rpc-clients", defaultOps.MaxRPCClients, "max number of rpc clients to an endpoint"},
 		cli.IntFlag{"rpc-dial-timeout", defaultOps.RPCDialTimeout, "timeout for creating rpc connections"},
 		cli.StringFlag{"rpc-cert-verify", defaultOps.RPCCertVerify, "enable verification of rpc server certificate"},
@@ -173,6 +178,7 @@ func New(driver api.API, config utils.ConfigReader, logControl logging.LogContro
 	c.initService()
 	c.initSnapshot()
 	c.initLog()
+	c.initAudit()
 	c.initBackup()
 	c.initMetric()
 	c.initDocker()
@@ -232,6 +238,8 @@ func getRuntimeOptions(cfg utils.ConfigReader, ctx *cli.Context) config.Options
 		StartAPIKeyProxy:           cfg.BoolVal("START_API_KEY_PROXY", false),
 		BigTableMetrics:            cfg.BoolVal("BIGTABLE_METRICS", false),
 		DockerRegistry:             ctx.GlobalString("docker-registry"),
+		DockerRegistryReplica:      cfg.StringVal("DOCKER_REGISTRY_REPLICA", ""),
+		DockerRegistryReplicaZK:    cfg.StringSlice("DOCKER_REGISTRY_REPLICA_ZK", []string{}),
 		NFSClient:                  ctx.GlobalString("nfs-client"),
 		Endpoint:                   ctx.GlobalString("endpoint"),
 		StaticIPs:                  ctx.GlobalStringSlice("static-ip"),
@@ -242,16 +250,22 @@ func getRuntimeOptions(cfg utils.ConfigReader, ctx *cli.Context) config.Options
 		Master:                     ctx.GlobalBool("master"),
 		MuxPort:                    ctx.GlobalInt("mux"),
 		MuxDisableTLS:              ctx.GlobalString("mux-disable-tls"),
+		MuxSessions:                ctx.GlobalInt("mux-sessions"),
+		MuxMutualTLS:               ctx.GlobalString("mux-mutual-tls"),
 		MUXTLSCiphers:              ctx.GlobalStringSlice("mux-tls-ciphers"),
 		MUXTLSMinVersion:           ctx.GlobalString("mux-tls-min-version"),
 		HomePath:                   api.GetDefaultOptions(cfg).HomePath,
 		VolumesPath:                ctx.GlobalString("volumes-path"),
 		IsvcsPath:                  ctx.GlobalString("isvcs-path"),
 		BackupsPath:                ctx.GlobalString("backups-path"),
+		MuxCredentialsPath:         ctx.GlobalString("mux-credentials-path"),
+		SessionsPath:               ctx.GlobalString("sessions-path"),
 		EtcPath:                    ctx.GlobalString("etc-path"),
 		LogPath:                    ctx.GlobalString("log-path"),
 		KeyPEMFile:                 ctx.GlobalString("keyfile"),
 		CertPEMFile:                ctx.GlobalString("certfile"),
+		ACMEDirectory:              cfg.StringVal("ACME_DIRECTORY", ""),
+		ACMEEmail:                  cfg.StringVal("ACME_EMAIL", ""),
 		Zookeepers:                 ctx.GlobalStringSlice("zk"),
 		Mount:                      ctx.GlobalStringSlice("mount"),
 		HostAliases:                ctx.GlobalStringSlice("alias"),
@@ -274,6 +288,7 @@ func getRuntimeOptions(cfg utils.ConfigReader, ctx *cli.Context) config.Options
 		LogstashCycleTime:          ctx.GlobalInt("logstash-cycle-time"),
 		LogstashURL:                ctx.GlobalString("logstashurl"),
 		DebugPort:                  ctx.GlobalInt("debug-port"),
+		MetricsPort:                ctx.GlobalString("metrics-port"),
 		AdminGroup:                 ctx.GlobalString("admin-group"),
 		MaxRPCClients:              ctx.GlobalInt("max-rpc-clients"),
 		RPCDialTimeout:             ctx.GlobalInt("rpc-dial-timeout"),
@@ -16,9 +16,11 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/codegangsta/cli"
+	"github.com/control-center/serviced/volume"
 )
 
 // initDocker is the initializer for serviced docker
@@ -59,6 +61,39 @@ func (c *ServicedCli) initDocker() {
 				Usage:       "Replace an image in the registry with a new image",
 				Description: "serviced docker override OLDIMAGE NEWIMAGE",
 				Action:      c.cmdDockerOverride,
+			}, {
+				Name:        "gc",
+				Usage:       "serviced docker gc",
+				Description: "Removes images from the docker registry that are not used by any service or snapshot",
+				Action:      c.cmdRegistryGC,
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "reports what would be removed without removing anything",
+					},
+				},
+			}, {
+				Name:        "replicate",
+				Usage:       "serviced docker replicate",
+				Description: "Pushes all images in the docker registry to the secondary registry",
+				Action:      c.cmdRegistryReplicate,
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "status",
+						Usage: "shows the replication status of each image instead",
+					},
+				},
+			}, {
+				Name:        "prepull",
+				Usage:       "serviced docker prepull",
+				Description: "Pulls the images of all services onto the hosts in their resource pools",
+				Action:      c.cmdDockerPrePull,
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "status",
+						Usage: "shows the pre-pull progress on each host instead",
+					},
+				},
 			},
 		},
 	})
@@ -108,3 +143,115 @@ func (c *ServicedCli) cmdDockerOverride(ctx *cli.Context) {
 		fmt.Fprintln(os.Stderr, err)
 	}
 }
+
+// serviced docker gc [--dry-run]
+func (c *ServicedCli) cmdRegistryGC(ctx *cli.Context) {
+	dryRun := ctx.Bool("dry-run")
+	report, err := c.driver.CollectRegistryGarbage(dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+
+	verb := "Removed"
+	if report.DryRun {
+		verb = "Would remove"
+	}
+	if len(report.Images) == 0 {
+		fmt.Println("No unreferenced images found")
+		return
+	}
+	for _, image := range report.Images {
+		fmt.Printf("%s image %s\n", verb, image)
+	}
+	for _, manifest := range report.Manifests {
+		fmt.Printf("%s manifest %s\n", verb, manifest)
+	}
+	if report.DryRun {
+		fmt.Printf("Reclaimable space: %s\n", volume.ToBytes(report.Bytes))
+	} else {
+		fmt.Printf("Reclaimed space: %s\n", volume.ToBytes(report.Bytes))
+	}
+}
+
+// serviced docker replicate [--status]
+func (c *ServicedCli) cmdRegistryReplicate(ctx *cli.Context) {
+	if !ctx.Bool("status") {
+		if err := c.driver.ReplicateRegistry(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			c.exit(1)
+		}
+		return
+	}
+
+	statuses, err := c.driver.GetRegistryReplicationStatus()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+	if len(statuses) == 0 {
+		fmt.Println("No images found")
+		return
+	}
+	t := NewTable("Image,Status,ReplicatedAt")
+	for _, status := range statuses {
+		state := "pending"
+		if status.Replicated {
+			state = "replicated"
+		} else if status.Error != "" {
+			state = "failed: " + status.Error
+		}
+		replicatedAt := ""
+		if !status.ReplicatedAt.IsZero() {
+			replicatedAt = status.ReplicatedAt.Format(time.RFC3339)
+		}
+		t.AddRow(map[string]interface{}{
+			"Image":        status.Image,
+			"Status":       state,
+			"ReplicatedAt": replicatedAt,
+		})
+	}
+	t.Print()
+}
+
+// serviced docker prepull [--status]
+func (c *ServicedCli) cmdDockerPrePull(ctx *cli.Context) {
+	if !ctx.Bool("status") {
+		if err := c.driver.PrePullImages(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			c.exit(1)
+		}
+		return
+	}
+
+	reqs, err := c.driver.GetPrePullStatus()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+	if len(reqs) == 0 {
+		fmt.Println("No image pre-pulls found")
+		return
+	}
+	t := NewTable("Host,Image,Status,Finished")
+	for _, req := range reqs {
+		state := string(req.State)
+		if req.Error != "" {
+			state += ": " + req.Error
+		}
+		finished := ""
+		if !req.Finished.IsZero() {
+			finished = req.Finished.Format(time.RFC3339)
+		}
+		t.AddRow(map[string]interface{}{
+			"Host":     req.HostID,
+			"Image":    req.Image,
+			"Status":   state,
+			"Finished": finished,
+		})
+	}
+	t.Print()
+}
@@ -17,9 +17,13 @@ package cmd
 
 import (
 	"errors"
+	"time"
 
 	"github.com/control-center/serviced/cli/api"
+	"github.com/control-center/serviced/dfs"
+	"github.com/control-center/serviced/dfs/registry"
 	"github.com/control-center/serviced/utils"
+	zkdocker "github.com/control-center/serviced/zzk/docker"
 )
 
 const (
@@ -49,6 +53,31 @@ func (t DockerAPITest) DockerOverride(newImage, oldImage string) error {
 	}
 }
 
+func (t DockerAPITest) CollectRegistryGarbage(dryRun bool) (*dfs.RegistryGCReport, error) {
+	return &dfs.RegistryGCReport{
+		DryRun:    dryRun,
+		Images:    []string{"tenant/repo:snap0"},
+		Manifests: []string{"tenant/repo@sha256:abc"},
+		Bytes:     2048,
+	}, nil
+}
+
+func (t DockerAPITest) GetRegistryReplicationStatus() ([]registry.ReplicaStatus, error) {
+	return []registry.ReplicaStatus{
+		{Image: "tenant/repo:latest", UUID: "abc", Replicated: true, ReplicatedAt: time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{Image: "tenant/repo:snap1", UUID: "def", Error: "push failed", ReplicatedAt: time.Date(2018, 2, 1, 12, 0, 0, 0, time.UTC)},
+		{Image: "tenant/repo:snap2", UUID: "ghi"},
+	}, nil
+}
+
+func (t DockerAPITest) GetPrePullStatus() ([]zkdocker.PrePull, error) {
+	return []zkdocker.PrePull{
+		{HostID: "host1", Image: "tenant/repo:latest", State: zkdocker.PrePullDone, Finished: time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{HostID: "host2", Image: "tenant/repo:latest", State: zkdocker.PrePullFailed, Error: "timeout", Finished: time.Date(2018, 3, 1, 12, 5, 0, 0, time.UTC)},
+		{HostID: "host3", Image: "tenant/repo:latest", State: zkdocker.PrePullPulling},
+	}, nil
+}
+
 func ExampleServicedCLI_CmdDockerOverride_usage() {
 	InitDockerAPITest("serviced", "docker", "override")
 
@@ -79,3 +108,41 @@ func ExampleServicedCli_cmdDockerOverride_success() {
 
 	// Output:
 }
+
+func ExampleServicedCli_cmdRegistryGC() {
+	InitDockerAPITest("serviced", "docker", "gc")
+
+	// Output:
+	// Removed image tenant/repo:snap0
+	// Removed manifest tenant/repo@sha256:abc
+	// Reclaimed space: 2 KiB
+}
+
+func ExampleServicedCli_cmdRegistryGC_dryRun() {
+	InitDockerAPITest("serviced", "docker", "gc", "--dry-run")
+
+	// Output:
+	// Would remove image tenant/repo:snap0
+	// Would remove manifest tenant/repo@sha256:abc
+	// Reclaimable space: 2 KiB
+}
+
+func ExampleServicedCli_cmdRegistryReplicate_status() {
+	InitDockerAPITest("serviced", "docker", "replicate", "--status")
+
+	// Output:
+	// Image              Status              ReplicatedAt
+	// tenant/repo:latest replicated          2018-03-01T12:00:00Z
+	// tenant/repo:snap1  failed: push failed 2018-02-01T12:00:00Z
+	// tenant/repo:snap2  pending
+}
+
+func ExampleServicedCli_cmdDockerPrePull_status() {
+	InitDockerAPITest("serviced", "docker", "prepull", "--status")
+
+	// Output:
+	// Host  Image              Status          Finished
+	// host1 tenant/repo:latest done            2018-03-01T12:00:00Z
+	// host2 tenant/repo:latest failed: timeout 2018-03-01T12:05:00Z
+	// host3 tenant/repo:latest pulling
+}
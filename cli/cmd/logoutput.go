@@ -0,0 +1,204 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/control-center/serviced/domain/logfilter"
+)
+
+// serviced log output list
+func (c *ServicedCli) cmdLogOutputList(ctx *cli.Context) {
+	t := NewTable("Scope,Name,Type,Address,LogTypes")
+	count := 0
+	addRows := func(scope string, outputs logfilter.LogOutputs) {
+		for _, output := range outputs {
+			address := output.Address
+			if output.Type == logfilter.OutputSyslog && output.Protocol != "" {
+				address = output.Protocol + "://" + address
+			} else if output.Type == logfilter.OutputKafka {
+				address = fmt.Sprintf("%s (%s)", address, output.Topic)
+			}
+			logTypes := "all"
+			if len(output.LogTypes) > 0 {
+				logTypes = strings.Join(output.LogTypes, ",")
+			}
+			if output.AuditOnly {
+				logTypes += " (audit only)"
+			}
+			t.AddRow(map[string]interface{}{
+				"Scope":    scope,
+				"Name":     output.Name,
+				"Type":     output.Type,
+				"Address":  address,
+				"LogTypes": logTypes,
+			})
+			count++
+		}
+	}
+
+	pools, err := c.driver.GetResourcePools()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+	for _, p := range pools {
+		addRows("pool/"+p.ID, p.LogOutputs)
+	}
+
+	services, err := c.driver.GetAllServiceDetails()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+	for _, details := range services {
+		if details.ParentServiceID != "" {
+			continue
+		}
+		svc, err := c.driver.GetService(details.ID)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			c.exit(1)
+			return
+		}
+		addRows("tenant/"+details.Name, svc.LogOutputs)
+	}
+
+	if count == 0 {
+		fmt.Println("No log outputs found")
+		return
+	}
+	t.Print()
+}
+
+// serviced log output add (--pool POOLID | --tenant TENANT) --type TYPE --address ADDRESS NAME
+func (c *ServicedCli) cmdLogOutputAdd(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 1 {
+		fmt.Printf("Incorrect Usage.\n\n")
+		cli.ShowCommandHelp(ctx, "add")
+		return
+	}
+
+	output := logfilter.LogOutput{
+		Name:      args[0],
+		Type:      logfilter.LogOutputType(ctx.String("type")),
+		Address:   ctx.String("address"),
+		LogTypes:  ctx.StringSlice("log-type"),
+		AuditOnly: ctx.Bool("audit-only"),
+	}
+	switch output.Type {
+	case logfilter.OutputSyslog:
+		output.Protocol = ctx.String("protocol")
+	case logfilter.OutputKafka:
+		output.Topic = ctx.String("topic")
+	}
+	if err := output.ValidEntity(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+
+	err := c.updateLogOutputs(ctx, func(outputs logfilter.LogOutputs) (logfilter.LogOutputs, error) {
+		for _, o := range outputs {
+			if o.Name == output.Name {
+				return nil, fmt.Errorf("log output %s already exists", output.Name)
+			}
+		}
+		return append(outputs, output), nil
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+	fmt.Println(output.Name)
+}
+
+// serviced log output remove (--pool POOLID | --tenant TENANT) NAME
+func (c *ServicedCli) cmdLogOutputRemove(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 1 {
+		fmt.Printf("Incorrect Usage.\n\n")
+		cli.ShowCommandHelp(ctx, "remove")
+		return
+	}
+
+	name := args[0]
+	err := c.updateLogOutputs(ctx, func(outputs logfilter.LogOutputs) (logfilter.LogOutputs, error) {
+		for i, o := range outputs {
+			if o.Name == name {
+				return append(outputs[:i], outputs[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("log output %s not found", name)
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+	fmt.Println(name)
+}
+
+// updateLogOutputs changes the log outputs of the pool or tenant set on the
+// command line.
+func (c *ServicedCli) updateLogOutputs(ctx *cli.Context, update func(logfilter.LogOutputs) (logfilter.LogOutputs, error)) error {
+	poolID, tenant := ctx.String("pool"), ctx.String("tenant")
+	if (poolID == "") == (tenant == "") {
+		return errors.New("either --pool or --tenant must be set")
+	}
+
+	if poolID != "" {
+		p, err := c.driver.GetResourcePool(poolID)
+		if err != nil {
+			return err
+		} else if p == nil {
+			return errors.New("pool not found")
+		}
+		if p.LogOutputs, err = update(p.LogOutputs); err != nil {
+			return err
+		}
+		return c.driver.UpdateResourcePool(*p)
+	}
+
+	details, _, err := c.searchForService(tenant)
+	if err != nil {
+		return err
+	} else if details.ParentServiceID != "" {
+		return fmt.Errorf("%s is not a tenant", tenant)
+	}
+	svc, err := c.driver.GetService(details.ID)
+	if err != nil {
+		return err
+	}
+	if svc.LogOutputs, err = update(svc.LogOutputs); err != nil {
+		return err
+	}
+	data, err := json.Marshal(svc)
+	if err != nil {
+		return err
+	}
+	_, err = c.driver.UpdateService(bytes.NewReader(data))
+	return err
+}
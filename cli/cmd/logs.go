@@ -15,12 +15,20 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"regexp"
+	"syscall"
+	"time"
 
 	"github.com/codegangsta/cli"
 	"github.com/control-center/serviced/cli/api"
 )
 
+// logTimeFormat is how the time of each log message is shown
+const logTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
 // Initializer for serviced log
 func (c *ServicedCli) initLog() {
 	c.app.Commands = append(c.app.Commands, cli.Command{
@@ -74,11 +82,318 @@ func (c *ServicedCli) initLog() {
 						Usage: "Do not export child services",
 					},
 				},
+			}, {
+				Name:        "tail",
+				Usage:       "Shows the most recent log messages of a service",
+				Description: "serviced log tail SERVICEID",
+				Action:      c.cmdTailLogs,
+				Flags: []cli.Flag{
+					cli.IntFlag{
+						Name:  "instance",
+						Value: -1,
+						Usage: "only show the logs of this instance",
+					},
+					cli.BoolFlag{
+						Name:  "follow, f",
+						Usage: "keep showing new log messages",
+					},
+					cli.StringFlag{
+						Name:  "grep",
+						Value: "",
+						Usage: "only show messages that match this regular expression",
+					},
+					cli.StringFlag{
+						Name:  "since",
+						Value: "10m",
+						Usage: "show messages logged within this duration",
+					},
+					cli.IntFlag{
+						Name:  "lines, l",
+						Value: 100,
+						Usage: "number of recent messages to show, 0 for all",
+					},
+					cli.BoolFlag{
+						Name:  "no-children, n",
+						Usage: "Do not show the logs of child services",
+					},
+				},
+			}, {
+				Name:        "search",
+				Usage:       "Searches application log data",
+				Description: "serviced log search QUERY",
+				Action:      c.cmdSearchLogs,
+				Flags: []cli.Flag{
+					cli.StringSliceFlag{
+						Name:  "service",
+						Value: &cli.StringSlice{},
+						Usage: "service ID or name (includes all child services)",
+					},
+					cli.IntFlag{
+						Name:  "instance",
+						Value: -1,
+						Usage: "only search the logs of this instance",
+					},
+					cli.StringFlag{
+						Name:  "since",
+						Value: "24h",
+						Usage: "search messages logged within this duration",
+					},
+					cli.IntFlag{
+						Name:  "limit",
+						Value: 1000,
+						Usage: "number of recent messages to show, 0 for all",
+					},
+					cli.BoolFlag{
+						Name:  "no-children, n",
+						Usage: "Do not search child services",
+					},
+				},
+			}, {
+				Name:        "retention",
+				Usage:       "Removes application log data that has outlived its retention",
+				Description: "serviced log retention",
+				Action:      c.cmdLogRetention,
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "reports what would be removed without removing anything",
+					},
+				},
+			}, {
+				Name:        "output",
+				Usage:       "Manages the destinations that application logs are shipped to",
+				Description: "serviced log output",
+				Subcommands: []cli.Command{
+					{
+						Name:        "list",
+						Usage:       "Lists the log outputs of every pool and tenant",
+						Description: "serviced log output list",
+						Action:      c.cmdLogOutputList,
+					}, {
+						Name:        "add",
+						Usage:       "Ships the logs of a pool or tenant to a destination",
+						Description: "serviced log output add (--pool POOLID | --tenant TENANT) --type TYPE --address ADDRESS NAME",
+						Action:      c.cmdLogOutputAdd,
+						Flags: []cli.Flag{
+							cli.StringFlag{
+								Name:  "pool",
+								Usage: "ship the logs of the services in this pool",
+							},
+							cli.StringFlag{
+								Name:  "tenant",
+								Usage: "ship the logs of the services of this tenant",
+							},
+							cli.StringFlag{
+								Name:  "type",
+								Usage: "syslog, http or kafka",
+							},
+							cli.StringFlag{
+								Name:  "address",
+								Usage: "HOST:PORT for syslog, the URL for http, or a comma-separated list of kafka brokers",
+							},
+							cli.StringFlag{
+								Name:  "protocol",
+								Value: "tcp",
+								Usage: "tcp or udp, for syslog",
+							},
+							cli.StringFlag{
+								Name:  "topic",
+								Usage: "the topic, for kafka",
+							},
+							cli.StringSliceFlag{
+								Name:  "log-type",
+								Value: &cli.StringSlice{},
+								Usage: "only ship logs of this type",
+							},
+							cli.BoolFlag{
+								Name:  "audit-only",
+								Usage: "only ship the logs of auditable types",
+							},
+						},
+					}, {
+						Name:        "remove",
+						ShortName:   "rm",
+						Usage:       "Stops shipping the logs of a pool or tenant to a destination",
+						Description: "serviced log output remove (--pool POOLID | --tenant TENANT) NAME",
+						Action:      c.cmdLogOutputRemove,
+						Flags: []cli.Flag{
+							cli.StringFlag{
+								Name:  "pool",
+								Usage: "the pool of the log output",
+							},
+							cli.StringFlag{
+								Name:  "tenant",
+								Usage: "the tenant of the log output",
+							},
+						},
+					},
+				},
 			},
 		},
 	})
 }
 
+// serviced log retention [--dry-run]
+func (c *ServicedCli) cmdLogRetention(ctx *cli.Context) {
+	if len(ctx.Args()) > 0 {
+		fmt.Printf("Incorrect Usage.\n\n")
+		cli.ShowCommandHelp(ctx, "retention")
+		return
+	}
+
+	report, err := c.driver.EnforceLogRetention(ctx.Bool("dry-run"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+
+	verb := "Removed"
+	if report.DryRun {
+		verb = "Would remove"
+	}
+	var total int64
+	t := NewTable("Tenant,Type,Days,Messages")
+	for _, result := range report.Results {
+		tenant, logType := result.Rule.TenantName, result.Rule.Type
+		if tenant == "" {
+			tenant = result.Rule.TenantID
+		}
+		if logType == "" {
+			tenant, logType = "*", "*"
+		}
+		t.AddRow(map[string]interface{}{
+			"Tenant":   tenant,
+			"Type":     logType,
+			"Days":     result.Days,
+			"Messages": result.Count,
+		})
+		total += result.Count
+	}
+	if len(report.Results) > 0 {
+		t.Print()
+	}
+	for _, index := range report.Indices {
+		fmt.Printf("%s index %s (older than %d days)\n", verb, index, report.IndexDays)
+	}
+	fmt.Printf("%s %d log messages and %d indices\n", verb, total, len(report.Indices))
+}
+
+// serviced log tail SERVICEID
+func (c *ServicedCli) cmdTailLogs(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 1 {
+		fmt.Printf("Incorrect Usage.\n\n")
+		cli.ShowCommandHelp(ctx, "tail")
+		return
+	}
+
+	svc, instanceID, err := c.searchForService(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	if ctx.IsSet("instance") {
+		instanceID = ctx.Int("instance")
+	}
+
+	since, err := time.ParseDuration(ctx.String("since"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid duration %s: %s\n", ctx.String("since"), err)
+		return
+	}
+	if grep := ctx.String("grep"); grep != "" {
+		if _, err := regexp.Compile(grep); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid regular expression %s: %s\n", grep, err)
+			return
+		}
+	}
+
+	cfg := api.LogQueryConfig{
+		ServiceIDs:      []string{svc.ID},
+		ExcludeChildren: ctx.Bool("no-children"),
+		Instance:        instanceID,
+		Grep:            ctx.String("grep"),
+		Since:           time.Now().Add(-since),
+		Limit:           ctx.Int("lines"),
+		Follow:          ctx.Bool("follow"),
+	}
+
+	// stop following on ctrl-c
+	cancel := make(chan struct{})
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		<-sigChan
+		close(cancel)
+	}()
+
+	output := func(message api.LogMessage) {
+		printLogMessage(os.Stdout, message)
+	}
+	if err := c.driver.TailLogs(cfg, output, cancel); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+// serviced log search QUERY
+func (c *ServicedCli) cmdSearchLogs(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 1 {
+		fmt.Printf("Incorrect Usage.\n\n")
+		cli.ShowCommandHelp(ctx, "search")
+		return
+	}
+
+	var serviceIDs []string
+	for _, service := range ctx.StringSlice("service") {
+		svc, _, err := c.searchForService(service)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+		serviceIDs = append(serviceIDs, svc.ID)
+	}
+
+	since, err := time.ParseDuration(ctx.String("since"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid duration %s: %s\n", ctx.String("since"), err)
+		return
+	}
+
+	cfg := api.LogQueryConfig{
+		ServiceIDs:      serviceIDs,
+		ExcludeChildren: ctx.Bool("no-children"),
+		Instance:        ctx.Int("instance"),
+		Query:           args[0],
+		Since:           time.Now().Add(-since),
+		Limit:           ctx.Int("limit"),
+	}
+
+	messages, err := c.driver.SearchLogs(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	for _, message := range messages {
+		printLogMessage(os.Stdout, message)
+	}
+}
+
+// printLogMessage shows a log message prefixed with where it was logged
+func printLogMessage(w io.Writer, message api.LogMessage) {
+	hostName := message.HostName
+	if hostName == "" {
+		hostName = message.HostID
+	}
+	serviceName := message.ServiceName
+	if serviceName == "" {
+		serviceName = message.ServiceID
+	}
+	fmt.Fprintf(w, "%s %s %s/%s: %s\n", message.Timestamp.Local().Format(logTimeFormat), hostName, serviceName, message.InstanceID, message.Message)
+}
+
 // serviced log export
 func (c *ServicedCli) cmdExportLogs(ctx *cli.Context) {
 	if len(ctx.Args()) > 0 {
@@ -16,9 +16,15 @@
 package cmd
 
 import (
+	"bytes"
 	"testing"
+	"time"
 
 	"github.com/control-center/serviced/cli/api"
+	"github.com/control-center/serviced/domain/logfilter"
+	"github.com/control-center/serviced/domain/pool"
+	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/isvcs"
 	mocks "github.com/control-center/serviced/cli/api/apimocks"
 	"github.com/control-center/serviced/utils"
 	"github.com/stretchr/testify/mock"
@@ -234,3 +240,143 @@ func runLogsAPITest(driver api.API, args ...string) {
 	c.exitDisabled = true
 	c.Run(args)
 }
+
+func TestLogsCLI_CmdLogTail(t *testing.T) {
+	mockAPI := mocks.API{}
+	mockAPI.On("ResolveServicePath", "zencommand").Return(serviceDetailsByName("zencommand"), nil)
+	matcher := func(cfg api.LogQueryConfig) bool {
+		return compareStringSlices(cfg.ServiceIDs, []string{"test-service-3"}) &&
+			cfg.Instance == 2 && cfg.Grep == "error" && cfg.Limit == 100 && cfg.Follow &&
+			time.Since(cfg.Since) >= time.Hour
+	}
+	mockAPI.On("TailLogs", mock.MatchedBy(matcher), mock.Anything, mock.Anything).Once().Return(nil)
+	runLogsAPITest(&mockAPI, "serviced", "log", "tail", "--instance", "2", "--follow", "--grep", "error", "--since", "1h", "zencommand")
+	mockAPI.AssertExpectations(t)
+}
+
+func TestLogsCLI_CmdLogSearch(t *testing.T) {
+	mockAPI := mocks.API{}
+	mockAPI.On("ResolveServicePath", "zencommand").Return(serviceDetailsByName("zencommand"), nil)
+	matcher := func(cfg api.LogQueryConfig) bool {
+		return compareStringSlices(cfg.ServiceIDs, []string{"test-service-3"}) &&
+			cfg.Instance == -1 && cfg.Query == "message:timeout" && cfg.Limit == 1000
+	}
+	mockAPI.On("SearchLogs", mock.MatchedBy(matcher)).Once().Return([]api.LogMessage{}, nil)
+	runLogsAPITest(&mockAPI, "serviced", "log", "search", "--service", "zencommand", "message:timeout")
+	mockAPI.AssertExpectations(t)
+}
+
+func ExampleServicedCli_logTailUsage() {
+	runLogsAPITest(&mocks.API{}, "serviced", "log", "tail")
+
+	// Output:
+	// Incorrect Usage.
+	//
+	// NAME:
+	//    tail - Shows the most recent log messages of a service
+	//
+	// USAGE:
+	//    command tail [command options] [arguments...]
+	//
+	// DESCRIPTION:
+	//    serviced log tail SERVICEID
+	//
+	// OPTIONS:
+	//    --instance '-1'	only show the logs of this instance
+	//    --follow, -f		keep showing new log messages
+	//    --grep 		only show messages that match this regular expression
+	//    --since '10m'	show messages logged within this duration
+	//    --lines, -l '100'	number of recent messages to show, 0 for all
+	//    --no-children, -n	Do not show the logs of child services
+}
+
+func TestLogsCLI_PrintLogMessage(t *testing.T) {
+	timestamp := time.Date(2018, 3, 4, 5, 6, 7, 8000000, time.UTC)
+	message := api.LogMessage{
+		Timestamp:  timestamp,
+		HostID:     "host1",
+		HostName:   "worker1",
+		ServiceID:  "svc1",
+		InstanceID: "0",
+		Message:    "started",
+	}
+	buffer := &bytes.Buffer{}
+	printLogMessage(buffer, message)
+	expected := timestamp.Local().Format(logTimeFormat) + " worker1 svc1/0: started\n"
+	if buffer.String() != expected {
+		t.Fatalf("got %q expected %q", buffer.String(), expected)
+	}
+}
+
+func ExampleServicedCli_logRetentionDryRun() {
+	report := &isvcs.LogRetentionReport{
+		DryRun:    true,
+		IndexDays: 30,
+		Indices:   []string{"logstash-2018.01.01"},
+		Results: []isvcs.LogRetentionResult{
+			{
+				Rule:  logfilter.RetentionRule{TenantID: "tenant1", TenantName: "Zenoss.core", Type: "debug", Days: 3},
+				Days:  3,
+				Count: 120,
+			}, {
+				Days:  14,
+				Count: 30,
+			},
+		},
+	}
+	mockAPI := &mocks.API{}
+	mockAPI.On("EnforceLogRetention", true).Return(report, nil)
+	runLogsAPITest(mockAPI, "serviced", "log", "retention", "--dry-run")
+
+	// Output:
+	// Tenant      Type  Days Messages
+	// Zenoss.core debug 3    120
+	// *           *     14   30
+	// Would remove index logstash-2018.01.01 (older than 30 days)
+	// Would remove 150 log messages and 1 indices
+}
+
+func TestLogsCLI_CmdLogOutputAdd(t *testing.T) {
+	mockAPI := mocks.API{}
+	mockAPI.On("GetResourcePool", "default").Return(&pool.ResourcePool{ID: "default"}, nil)
+	matcher := func(p pool.ResourcePool) bool {
+		return p.ID == "default" && len(p.LogOutputs) == 1 &&
+			p.LogOutputs[0].Name == "siem" && p.LogOutputs[0].Type == logfilter.OutputSyslog &&
+			p.LogOutputs[0].Address == "siem:514" && p.LogOutputs[0].Protocol == "udp" &&
+			p.LogOutputs[0].AuditOnly
+	}
+	mockAPI.On("UpdateResourcePool", mock.MatchedBy(matcher)).Once().Return(nil)
+	runLogsAPITest(&mockAPI, "serviced", "log", "output", "add", "--pool", "default", "--type", "syslog",
+		"--address", "siem:514", "--protocol", "udp", "--audit-only", "siem")
+	mockAPI.AssertExpectations(t)
+}
+
+func ExampleServicedCli_logOutputList() {
+	pools := []pool.ResourcePool{
+		{
+			ID: "default",
+			LogOutputs: logfilter.LogOutputs{
+				{Name: "siem", Type: logfilter.OutputSyslog, Address: "siem:514", Protocol: "tcp", AuditOnly: true},
+			},
+		},
+	}
+	tenant := &service.Service{
+		ID: "tenant1",
+		LogOutputs: logfilter.LogOutputs{
+			{Name: "stream", Type: logfilter.OutputKafka, Address: "kafka:9092", Topic: "logs", LogTypes: []string{"app", "audit"}},
+		},
+	}
+	mockAPI := &mocks.API{}
+	mockAPI.On("GetResourcePools").Return(pools, nil)
+	mockAPI.On("GetAllServiceDetails").Return([]service.ServiceDetails{
+		{ID: "tenant1", Name: "Zenoss.core"},
+		{ID: "child1", Name: "zope", ParentServiceID: "tenant1"},
+	}, nil)
+	mockAPI.On("GetService", "tenant1").Return(tenant, nil)
+	runLogsAPITest(mockAPI, "serviced", "log", "output", "list")
+
+	// Output:
+	// Scope              Name   Type   Address           LogTypes
+	// pool/default       siem   syslog tcp://siem:514    all (audit only)
+	// tenant/Zenoss.core stream kafka  kafka:9092 (logs) app,audit
+}
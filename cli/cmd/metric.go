@@ -16,6 +16,8 @@ package cmd
 import (
 	"github.com/codegangsta/cli"
 	"fmt"
+
+	"github.com/control-center/serviced/domain/pool"
 )
 
 // Initializer for serviced metric
@@ -30,6 +32,57 @@ func (c *ServicedCli) initMetric() {
 				Usage:        "Push a metric value",
 				Description:  "serviced metric push METRICNAME VALUE",
 				Before:       c.cmdMetric,
+			}, {
+				Name:        "sink",
+				Usage:       "Administers the external metric sinks of pools",
+				Description: "serviced metric sink",
+				Subcommands: []cli.Command{
+					{
+						Name:        "list",
+						Usage:       "Lists the metric sinks of every pool",
+						Description: "serviced metric sink list",
+						Action:      c.cmdMetricSinkList,
+					}, {
+						Name:        "add",
+						Usage:       "Sends the application metrics and serviced stats of a pool to a metric sink",
+						Description: "serviced metric sink add --pool POOLID --type TYPE --address ADDRESS NAME",
+						Action:      c.cmdMetricSinkAdd,
+						Flags: []cli.Flag{
+							cli.StringFlag{
+								Name:  "pool",
+								Usage: "the pool of the metric sink",
+							},
+							cli.StringFlag{
+								Name:  "type",
+								Usage: "statsd, influxdb or opentsdb",
+							},
+							cli.StringFlag{
+								Name:  "address",
+								Usage: "host:port for statsd, or the write url for influxdb and opentsdb",
+							},
+							cli.StringFlag{
+								Name:  "prefix",
+								Usage: "prepended to the name of each metric",
+							},
+							cli.IntFlag{
+								Name:  "buffer-size",
+								Usage: fmt.Sprintf("samples to keep while the sink is down (default %d)", pool.DefaultMetricSinkBuffer),
+							},
+						},
+					}, {
+						Name:        "remove",
+						ShortName:   "rm",
+						Usage:       "Stops sending metrics to a metric sink",
+						Description: "serviced metric sink remove --pool POOLID NAME",
+						Action:      c.cmdMetricSinkRemove,
+						Flags: []cli.Flag{
+							cli.StringFlag{
+								Name:  "pool",
+								Usage: "the pool of the metric sink",
+							},
+						},
+					},
+				},
 			},
 		},
 	})
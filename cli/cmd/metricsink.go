@@ -0,0 +1,138 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/codegangsta/cli"
+	"github.com/control-center/serviced/domain/pool"
+)
+
+// serviced metric sink list
+func (c *ServicedCli) cmdMetricSinkList(ctx *cli.Context) {
+	pools, err := c.driver.GetResourcePools()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+
+	t := NewTable("Pool,Name,Type,Address,Prefix,Buffer")
+	count := 0
+	for _, p := range pools {
+		for _, sink := range p.MetricSinks {
+			t.AddRow(map[string]interface{}{
+				"Pool":    p.ID,
+				"Name":    sink.Name,
+				"Type":    sink.Type,
+				"Address": sink.Address,
+				"Prefix":  sink.Prefix,
+				"Buffer":  strconv.Itoa(sink.GetBufferSize()),
+			})
+			count++
+		}
+	}
+	if count == 0 {
+		fmt.Println("No metric sinks found")
+		return
+	}
+	t.Print()
+}
+
+// serviced metric sink add --pool POOLID --type TYPE --address ADDRESS NAME
+func (c *ServicedCli) cmdMetricSinkAdd(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 1 {
+		fmt.Printf("Incorrect Usage.\n\n")
+		cli.ShowCommandHelp(ctx, "add")
+		return
+	}
+
+	sink := pool.MetricSink{
+		Name:       args[0],
+		Type:       pool.MetricSinkType(ctx.String("type")),
+		Address:    ctx.String("address"),
+		Prefix:     ctx.String("prefix"),
+		BufferSize: ctx.Int("buffer-size"),
+	}
+	if err := sink.ValidEntity(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+
+	err := c.updateMetricSinks(ctx, func(sinks pool.MetricSinks) (pool.MetricSinks, error) {
+		for _, s := range sinks {
+			if s.Name == sink.Name {
+				return nil, fmt.Errorf("metric sink %s already exists", sink.Name)
+			}
+		}
+		return append(sinks, sink), nil
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+	fmt.Println(sink.Name)
+}
+
+// serviced metric sink remove --pool POOLID NAME
+func (c *ServicedCli) cmdMetricSinkRemove(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 1 {
+		fmt.Printf("Incorrect Usage.\n\n")
+		cli.ShowCommandHelp(ctx, "remove")
+		return
+	}
+
+	name := args[0]
+	err := c.updateMetricSinks(ctx, func(sinks pool.MetricSinks) (pool.MetricSinks, error) {
+		for i, s := range sinks {
+			if s.Name == name {
+				return append(sinks[:i], sinks[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("metric sink %s not found", name)
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+	fmt.Println(name)
+}
+
+// updateMetricSinks changes the metric sinks of the pool set on the command
+// line.
+func (c *ServicedCli) updateMetricSinks(ctx *cli.Context, update func(pool.MetricSinks) (pool.MetricSinks, error)) error {
+	poolID := ctx.String("pool")
+	if poolID == "" {
+		return errors.New("--pool must be set")
+	}
+	p, err := c.driver.GetResourcePool(poolID)
+	if err != nil {
+		return err
+	} else if p == nil {
+		return errors.New("pool not found")
+	}
+	if p.MetricSinks, err = update(p.MetricSinks); err != nil {
+		return err
+	}
+	return c.driver.UpdateResourcePool(*p)
+}
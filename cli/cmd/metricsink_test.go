@@ -0,0 +1,93 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package cmd
+
+import (
+	"testing"
+
+	mocks "github.com/control-center/serviced/cli/api/apimocks"
+	"github.com/control-center/serviced/domain/pool"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMetricSinkCLI_CmdMetricSinkAdd(t *testing.T) {
+	mockAPI := mocks.API{}
+	mockAPI.On("GetResourcePool", "default").Return(&pool.ResourcePool{ID: "default"}, nil)
+	matcher := func(p pool.ResourcePool) bool {
+		return p.ID == "default" && len(p.MetricSinks) == 1 &&
+			p.MetricSinks[0].Name == "graphs" && p.MetricSinks[0].Type == pool.SinkInfluxDB &&
+			p.MetricSinks[0].Address == "http://influx:8086/write?db=serviced" &&
+			p.MetricSinks[0].Prefix == "cc." && p.MetricSinks[0].BufferSize == 500
+	}
+	mockAPI.On("UpdateResourcePool", mock.MatchedBy(matcher)).Once().Return(nil)
+	runLogsAPITest(&mockAPI, "serviced", "metric", "sink", "add", "--pool", "default", "--type", "influxdb",
+		"--address", "http://influx:8086/write?db=serviced", "--prefix", "cc.", "--buffer-size", "500", "graphs")
+	mockAPI.AssertExpectations(t)
+}
+
+func TestMetricSinkCLI_CmdMetricSinkAddInvalid(t *testing.T) {
+	mockAPI := mocks.API{}
+	runLogsAPITest(&mockAPI, "serviced", "metric", "sink", "add", "--pool", "default", "--type", "statsd",
+		"--address", "http://statsd", "graphs")
+	mockAPI.AssertNotCalled(t, "UpdateResourcePool", mock.Anything)
+}
+
+func TestMetricSinkCLI_CmdMetricSinkRemove(t *testing.T) {
+	mockAPI := mocks.API{}
+	mockAPI.On("GetResourcePool", "default").Return(&pool.ResourcePool{
+		ID: "default",
+		MetricSinks: pool.MetricSinks{
+			{Name: "graphs", Type: pool.SinkStatsD, Address: "statsd:8125"},
+			{Name: "tsdb", Type: pool.SinkOpenTSDB, Address: "http://tsdb:4242/api/put"},
+		},
+	}, nil)
+	matcher := func(p pool.ResourcePool) bool {
+		return len(p.MetricSinks) == 1 && p.MetricSinks[0].Name == "tsdb"
+	}
+	mockAPI.On("UpdateResourcePool", mock.MatchedBy(matcher)).Once().Return(nil)
+	runLogsAPITest(&mockAPI, "serviced", "metric", "sink", "rm", "--pool", "default", "graphs")
+	mockAPI.AssertExpectations(t)
+}
+
+func ExampleServicedCli_metricSinkList() {
+	pools := []pool.ResourcePool{
+		{
+			ID: "default",
+			MetricSinks: pool.MetricSinks{
+				{Name: "graphs", Type: pool.SinkStatsD, Address: "statsd:8125", Prefix: "cc."},
+				{Name: "tsdb", Type: pool.SinkOpenTSDB, Address: "http://tsdb:4242/api/put", BufferSize: 500},
+			},
+		},
+		{ID: "empty"},
+	}
+	mockAPI := &mocks.API{}
+	mockAPI.On("GetResourcePools").Return(pools, nil)
+	runLogsAPITest(mockAPI, "serviced", "metric", "sink", "list")
+
+	// Output:
+	// Pool    Name   Type     Address                  Prefix Buffer
+	// default graphs statsd   statsd:8125              cc.    10000
+	// default tsdb   opentsdb http://tsdb:4242/api/put        500
+}
+
+func ExampleServicedCli_metricSinkListEmpty() {
+	mockAPI := &mocks.API{}
+	mockAPI.On("GetResourcePools").Return([]pool.ResourcePool{{ID: "default"}}, nil)
+	runLogsAPITest(mockAPI, "serviced", "metric", "sink", "list")
+
+	// Output:
+	// No metric sinks found
+}
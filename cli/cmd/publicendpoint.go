@@ -16,13 +16,22 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/codegangsta/cli"
+	"github.com/control-center/serviced/domain/certificate"
 	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/domain/servicedefinition"
 )
 
+// certExpiryWarning is how long before a certificate expires that it is
+// flagged as expiring
+const certExpiryWarning = 30 * 24 * time.Hour
+
 // The vhost and port public endpoint structures are different, so we'll
 // make a unified structure for output (both text and json) that matches
 // the UI table.  This is only needed for output, not for api commands.
@@ -279,8 +288,10 @@ func (c *ServicedCli) cmdPublicEndpointsPortAdd(ctx *cli.Context) {
 		protocol = "" // Stored as an empty string.
 		usetls = true
 		break
+	case "udp":
+		break
 	default:
-		fmt.Fprintln(os.Stderr, "The protocol must be one of: https, http, other-tls, other")
+		fmt.Fprintln(os.Stderr, "The protocol must be one of: https, http, other-tls, other, udp")
 		return
 	}
 
@@ -291,7 +302,7 @@ func (c *ServicedCli) cmdPublicEndpointsPortAdd(ctx *cli.Context) {
 		return
 	}
 
-	port, err := c.driver.AddPublicEndpointPort(svc.ID, endpointName, portAddr, usetls, protocol, isEnabled, restart)
+	port, err := c.driver.AddPublicEndpointPort(svc.ID, endpointName, portAddr, usetls, protocol, isEnabled, getAccessRules(ctx), restart)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s\n", err)
 	} else {
@@ -300,6 +311,17 @@ func (c *ServicedCli) cmdPublicEndpointsPortAdd(ctx *cli.Context) {
 	return
 }
 
+// getAccessRules returns the access rules set by the flags of a public
+// endpoint add command
+func getAccessRules(ctx *cli.Context) servicedefinition.AccessRules {
+	return servicedefinition.AccessRules{
+		Allow:     ctx.StringSlice("allow"),
+		Deny:      ctx.StringSlice("deny"),
+		RateLimit: ctx.Float64("rate-limit"),
+		Burst:     ctx.Int("burst"),
+	}
+}
+
 // Remove a port public endpoint
 // serviced service public-endpoints port remove <SERVICEID> <ENDPOINTNAME> <PORTADDR>
 func (c *ServicedCli) cmdPublicEndpointsPortRemove(ctx *cli.Context) {
@@ -395,7 +417,7 @@ func (c *ServicedCli) cmdPublicEndpointsVHostAdd(ctx *cli.Context) {
 		return
 	}
 
-	vhost, err := c.driver.AddPublicEndpointVHost(svc.ID, endpointName, vhostName, isEnabled, restart)
+	vhost, err := c.driver.AddPublicEndpointVHost(svc.ID, endpointName, vhostName, isEnabled, getAccessRules(ctx), restart)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s\n", err)
 	} else {
@@ -467,3 +489,164 @@ func (c *ServicedCli) cmdPublicEndpointsVHostEnable(ctx *cli.Context) {
 	}
 	return
 }
+
+// Set the certificate of a port public endpoint
+// serviced service public-endpoints port cert set <SERVICEID> <ENDPOINTNAME> <PORTADDR> --cert=FILE --key=FILE
+func (c *ServicedCli) cmdPublicEndpointsPortCertSet(ctx *cli.Context) {
+	c.setPublicEndpointCertificate(ctx, certificate.Port)
+}
+
+// Remove the certificate of a port public endpoint
+// serviced service public-endpoints port cert remove <SERVICEID> <ENDPOINTNAME> <PORTADDR>
+func (c *ServicedCli) cmdPublicEndpointsPortCertRemove(ctx *cli.Context) {
+	c.removePublicEndpointCertificate(ctx, certificate.Port)
+}
+
+// Set the certificate of a vhost public endpoint
+// serviced service public-endpoints vhost cert set <SERVICEID> <ENDPOINTNAME> <VHOST> --cert=FILE --key=FILE
+func (c *ServicedCli) cmdPublicEndpointsVHostCertSet(ctx *cli.Context) {
+	c.setPublicEndpointCertificate(ctx, certificate.VHost)
+}
+
+// Remove the certificate of a vhost public endpoint
+// serviced service public-endpoints vhost cert remove <SERVICEID> <ENDPOINTNAME> <VHOST>
+func (c *ServicedCli) cmdPublicEndpointsVHostCertRemove(ctx *cli.Context) {
+	c.removePublicEndpointCertificate(ctx, certificate.VHost)
+}
+
+// Have the certificate of a vhost public endpoint obtained and renewed by ACME
+// serviced service public-endpoints vhost cert acme <SERVICEID> <ENDPOINTNAME> <VHOST> [--domain=DOMAIN ...]
+func (c *ServicedCli) cmdPublicEndpointsVHostCertACME(ctx *cli.Context) {
+	// Make sure we have each argument.
+	if len(ctx.Args()) != 3 {
+		cli.ShowCommandHelp(ctx, "acme")
+		return
+	}
+
+	serviceid := ctx.Args()[0]
+	endpointName := ctx.Args()[1]
+	vhost := ctx.Args()[2]
+
+	// We need the serviceid, but they may have provided the service id or name.
+	svc, _, err := c.searchForService(serviceid)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+
+	cert, err := c.driver.EnablePublicEndpointACME(svc.ID, endpointName, vhost, ctx.StringSlice("domain"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+	fmt.Printf("%s (expires %s)\n", vhost, cert.NotAfter.Format(time.RFC3339))
+}
+
+// List the certificates of the public endpoints
+// serviced service public-endpoints certs
+func (c *ServicedCli) cmdPublicEndpointsCerts(ctx *cli.Context) {
+	certs, err := c.driver.GetPublicEndpointCertificates()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+	if len(certs) == 0 {
+		fmt.Println("No certificates found")
+		return
+	}
+	t := NewTable("Type,Name,Subjects,Expires,Status,ACME")
+	for _, cert := range certs {
+		status := "ok"
+		expires := cert.NotAfter.Format(time.RFC3339)
+		if cert.IsPending() {
+			status, expires = "pending", ""
+		} else if cert.ExpiresWithin(0) {
+			status = "expired"
+		} else if cert.ExpiresWithin(certExpiryWarning) {
+			status = "expiring"
+		}
+		t.AddRow(map[string]interface{}{
+			"Type":     cert.Kind,
+			"Name":     cert.Name,
+			"Subjects": strings.Join(cert.Subjects, ","),
+			"Expires":  expires,
+			"Status":   status,
+			"ACME":     strings.Join(cert.ACMEDomains, ","),
+		})
+	}
+	t.Print()
+}
+
+func (c *ServicedCli) setPublicEndpointCertificate(ctx *cli.Context, kind certificate.Kind) {
+	// Make sure we have each argument.
+	if len(ctx.Args()) != 3 || ctx.String("cert") == "" || ctx.String("key") == "" {
+		cli.ShowCommandHelp(ctx, "set")
+		return
+	}
+
+	serviceid := ctx.Args()[0]
+	endpointName := ctx.Args()[1]
+	name := ctx.Args()[2]
+
+	certPEM, err := ioutil.ReadFile(ctx.String("cert"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not read certificate: %s\n", err)
+		c.exit(1)
+		return
+	}
+	keyPEM, err := ioutil.ReadFile(ctx.String("key"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not read key: %s\n", err)
+		c.exit(1)
+		return
+	}
+
+	// We need the serviceid, but they may have provided the service id or name.
+	svc, _, err := c.searchForService(serviceid)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+
+	cert, err := c.driver.SetPublicEndpointCertificate(svc.ID, endpointName, kind, name, certPEM, keyPEM)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+	fmt.Printf("%s (expires %s)\n", name, cert.NotAfter.Format(time.RFC3339))
+	if cert.ExpiresWithin(certExpiryWarning) {
+		fmt.Fprintf(os.Stderr, "Warning: certificate for %s expires within %d days\n", name, int(certExpiryWarning.Hours()/24))
+	}
+}
+
+func (c *ServicedCli) removePublicEndpointCertificate(ctx *cli.Context, kind certificate.Kind) {
+	// Make sure we have each argument.
+	if len(ctx.Args()) != 3 {
+		cli.ShowCommandHelp(ctx, "remove")
+		return
+	}
+
+	serviceid := ctx.Args()[0]
+	endpointName := ctx.Args()[1]
+	name := ctx.Args()[2]
+
+	// We need the serviceid, but they may have provided the service id or name.
+	svc, _, err := c.searchForService(serviceid)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+
+	if err := c.driver.RemovePublicEndpointCertificate(svc.ID, endpointName, kind, name); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.exit(1)
+		return
+	}
+	fmt.Println(name)
+}
@@ -18,8 +18,10 @@ package cmd
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/control-center/serviced/cli/api"
+	"github.com/control-center/serviced/domain/certificate"
 	"github.com/control-center/serviced/domain/service"
 	"github.com/control-center/serviced/domain/servicedefinition"
 	"github.com/control-center/serviced/utils"
@@ -54,11 +56,11 @@ func (t ServiceAPITest) GetAllPublicEndpoints() ([]service.PublicEndpoint, error
 }
 
 func (t ServiceAPITest) AddPublicEndpointPort(serviceID, endpointName, portAddr string,
-	usetls bool, protocol string, isEnabled, restart bool) (*servicedefinition.Port, error) {
+	usetls bool, protocol string, isEnabled bool, access servicedefinition.AccessRules, restart bool) (*servicedefinition.Port, error) {
 	if t.errs["AddPublicEndpointPort"] != nil {
 		return nil, t.errs["AddPublicEndpointPort"]
 	}
-	return &servicedefinition.Port{PortAddr: portAddr, Enabled: isEnabled, UseTLS: usetls, Protocol: protocol}, nil
+	return &servicedefinition.Port{PortAddr: portAddr, Enabled: isEnabled, UseTLS: usetls, Protocol: protocol, AccessRules: access}, nil
 }
 
 func (t ServiceAPITest) RemovePublicEndpointPort(serviceID, endpointName, portAddr string) error {
@@ -75,11 +77,11 @@ func (t ServiceAPITest) EnablePublicEndpointPort(serviceID, endpointName, portAd
 	return nil
 }
 
-func (t ServiceAPITest) AddPublicEndpointVHost(serviceid, endpointName, vhost string, isEnabled, restart bool) (*servicedefinition.VHost, error) {
+func (t ServiceAPITest) AddPublicEndpointVHost(serviceid, endpointName, vhost string, isEnabled bool, access servicedefinition.AccessRules, restart bool) (*servicedefinition.VHost, error) {
 	if t.errs["AddPublicEndpointVHost"] != nil {
 		return nil, t.errs["AddPublicEndpointVHost"]
 	}
-	return &servicedefinition.VHost{Name: vhost, Enabled: isEnabled}, nil
+	return &servicedefinition.VHost{Name: vhost, Enabled: isEnabled, AccessRules: access}, nil
 }
 
 func (t ServiceAPITest) RemovePublicEndpointVHost(serviceID, endpointName, vhost string) error {
@@ -96,6 +98,39 @@ func (t ServiceAPITest) EnablePublicEndpointVHost(serviceID, endpointName, vhost
 	return nil
 }
 
+func (t ServiceAPITest) SetPublicEndpointCertificate(serviceID, endpointName string, kind certificate.Kind, name string, certPEM, keyPEM []byte) (*certificate.Certificate, error) {
+	if t.errs["SetPublicEndpointCertificate"] != nil {
+		return nil, t.errs["SetPublicEndpointCertificate"]
+	}
+	return &certificate.Certificate{Kind: kind, Name: name}, nil
+}
+
+func (t ServiceAPITest) RemovePublicEndpointCertificate(serviceID, endpointName string, kind certificate.Kind, name string) error {
+	if t.errs["RemovePublicEndpointCertificate"] != nil {
+		return t.errs["RemovePublicEndpointCertificate"]
+	}
+	return nil
+}
+
+func (t ServiceAPITest) GetPublicEndpointCertificates() ([]certificate.Certificate, error) {
+	if t.errs["GetPublicEndpointCertificates"] != nil {
+		return nil, t.errs["GetPublicEndpointCertificates"]
+	}
+	return nil, nil
+}
+
+func (t ServiceAPITest) EnablePublicEndpointACME(serviceID, endpointName, vhost string, domains []string) (*certificate.Certificate, error) {
+	if t.errs["EnablePublicEndpointACME"] != nil {
+		return nil, t.errs["EnablePublicEndpointACME"]
+	}
+	return &certificate.Certificate{
+		Kind:        certificate.VHost,
+		Name:        vhost,
+		NotAfter:    time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		ACMEDomains: domains,
+	}, nil
+}
+
 func InitPublicEndpointPortTest(args ...string) {
 	c := New(DefaultServiceAPITest, utils.TestConfigReader(make(map[string]string)), MockLogControl{})
 	c.exitDisabled = true
@@ -297,7 +332,7 @@ func ExampleServicedCLI_CmdPublicEndpointsPortAdd_InvalidProtocol() {
 	})
 
 	// Output:
-	// The protocol must be one of: https, http, other-tls, other
+	// The protocol must be one of: https, http, other-tls, other, udp
 }
 
 func ExampleServicedCLI_CmdPublicEndpointsPortAdd_ValidProtocol() {
@@ -305,12 +340,21 @@ func ExampleServicedCLI_CmdPublicEndpointsPortAdd_ValidProtocol() {
 	InitPublicEndpointPortTest("serviced", "service", "public-endpoints", "port", "add", "Zenoss", "zproxy", ":22222", "https", "true")
 	InitPublicEndpointPortTest("serviced", "service", "public-endpoints", "port", "add", "Zenoss", "zproxy", ":22222", "other", "true")
 	InitPublicEndpointPortTest("serviced", "service", "public-endpoints", "port", "add", "Zenoss", "zproxy", ":22222", "other-tls", "true")
+	InitPublicEndpointPortTest("serviced", "service", "public-endpoints", "port", "add", "Zenoss", "zproxy", ":22222", "udp", "true")
 
 	// Output:
 	// :22222
 	// :22222
 	// :22222
 	// :22222
+	// :22222
+}
+
+func ExampleServicedCLI_CmdPublicEndpointsPortAdd_AccessRules() {
+	InitPublicEndpointPortTest("serviced", "service", "public-endpoints", "port", "add", "--allow", "10.0.0.0/8", "--deny", "10.1.2.3", "--rate-limit", "20", "--burst", "40", "Zenoss", "zproxy", ":22222", "other", "true")
+
+	// Output:
+	// :22222
 }
 
 func ExampleServicedCLI_CmdPublicEndpointsPortRemove() {
@@ -372,6 +416,13 @@ func ExampleServicedCLI_cmdPublicEndpointsVHostAdd() {
 	// zproxy2
 }
 
+func ExampleServicedCLI_cmdPublicEndpointsVHostAdd_AccessRules() {
+	InitPublicEndpointPortTest("serviced", "service", "public-endpoints", "vhost", "add", "--allow", "192.168.0.0/16", "--rate-limit", "100", "Zenoss", "zproxy", "zproxy2", "true")
+
+	// Output:
+	// zproxy2
+}
+
 func ExampleServicedCLI_cmdPublicEndpointsVHostAdd_InvalidArgCount() {
 	InitPublicEndpointPortTest("serviced", "service", "public-endpoints", "vhost", "add", "Zenoss", "zproxy", "zproxy2", "true", "invalid")
 
@@ -386,6 +437,10 @@ func ExampleServicedCLI_cmdPublicEndpointsVHostAdd_InvalidArgCount() {
 	//    serviced service public-endpoints vhost add <SERVICEID> <ENDPOINTNAME> <VHOST> <ENABLED>
 	//
 	// OPTIONS:
+	//    --allow '--allow option --allow option'	Only accept requests from this address or CIDR (repeatable)
+	//    --deny '--deny option --deny option'		Reject requests from this address or CIDR (repeatable)
+	//    --rate-limit '0'				Maximum requests per second, 0 is unlimited
+	//    --burst '0'					Maximum requests accepted at once when rate limited, defaults to the rate limit
 }
 
 func ExampleServicedCLI_cmdPublicEndpointsVHostAdd_InvalidService() {
@@ -457,3 +512,71 @@ func ExampleServicedCLI_CmdPublicEndpointsVHostEnable_ValidFlags() {
 	// zproxy
 	// zproxy
 }
+
+func ExampleServicedCLI_CmdPublicEndpointsVHostCertRemove_InvalidArgCount() {
+	pipeStderr(func() {
+		InitPublicEndpointPortTest("serviced", "service", "public-endpoints", "vhost", "cert", "remove", "Zenoss", "zproxy")
+	})
+
+	// Output:
+	// NAME:
+	//    remove - Remove the TLS certificate of a vhost public endpoint
+	//
+	// USAGE:
+	//    command remove [command options] [arguments...]
+	//
+	// DESCRIPTION:
+	//    serviced service public-endpoints vhost cert remove <SERVICEID> <ENDPOINTNAME> <VHOST>
+	//
+	// OPTIONS:
+}
+
+func ExampleServicedCLI_CmdPublicEndpointsVHostCertRemove_InvalidService() {
+	pipeStderr(func() {
+		InitPublicEndpointPortTest("serviced", "service", "public-endpoints", "vhost", "cert", "remove", "invalid", "zproxy", "zproxy")
+	})
+
+	// Output:
+	// service not found
+}
+
+func ExampleServicedCLI_CmdPublicEndpointsVHostCertRemove() {
+	InitPublicEndpointPortTest("serviced", "service", "public-endpoints", "vhost", "cert", "remove", "Zenoss", "zproxy", "zproxy")
+
+	// Output:
+	// zproxy
+}
+
+func ExampleServicedCLI_CmdPublicEndpointsPortCertSet_MissingFiles() {
+	pipeStderr(func() {
+		InitPublicEndpointPortTest("serviced", "service", "public-endpoints", "port", "cert", "set", "Zenoss", "zproxy", ":22222",
+			"--cert", "/nonexistent/cert.pem", "--key", "/nonexistent/key.pem")
+	})
+
+	// Output:
+	// Could not read certificate: open /nonexistent/cert.pem: no such file or directory
+}
+
+func ExampleServicedCLI_CmdPublicEndpointsCerts() {
+	InitPublicEndpointPortTest("serviced", "service", "public-endpoints", "certs")
+
+	// Output:
+	// No certificates found
+}
+
+func ExampleServicedCLI_CmdPublicEndpointsVHostCertACME() {
+	InitPublicEndpointPortTest("serviced", "service", "public-endpoints", "vhost", "cert", "acme", "Zenoss", "zproxy", "zproxy",
+		"--domain", "zproxy.example.com")
+
+	// Output:
+	// zproxy (expires 2019-01-01T00:00:00Z)
+}
+
+func ExampleServicedCLI_CmdPublicEndpointsVHostCertACME_InvalidService() {
+	pipeStderr(func() {
+		InitPublicEndpointPortTest("serviced", "service", "public-endpoints", "vhost", "cert", "acme", "invalid", "zproxy", "zproxy")
+	})
+
+	// Output:
+	// service not found
+}
@@ -14,6 +14,7 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -33,6 +34,7 @@ import (
 	"github.com/control-center/serviced/cli/api"
 	"github.com/control-center/serviced/domain/service"
 	"github.com/control-center/serviced/utils"
+	"github.com/pivotal-golang/bytefmt"
 )
 
 var unstartedTime = time.Date(1999, 12, 31, 23, 59, 0, 0, time.UTC)
@@ -236,6 +238,10 @@ func (c *ServicedCli) initService() {
 						Value: &cli.StringSlice{},
 						Usage: "bind mount: HOST_PATH[,CONTAINER_PATH]",
 					},
+					cli.BoolFlag{
+						Name:  "record",
+						Usage: "keeps a recording of the session on the master for replay",
+					},
 				},
 			}, {
 				Name:         "run",
@@ -271,6 +277,10 @@ func (c *ServicedCli) initService() {
 						Value: "",
 						Usage: "container username used to run command",
 					},
+					cli.BoolFlag{
+						Name:  "record",
+						Usage: "keeps a recording of the session on the master for replay",
+					},
 				},
 			}, {
 				Name:         "attach",
@@ -278,6 +288,12 @@ func (c *ServicedCli) initService() {
 				Description:  "serviced service attach { SERVICEID | SERVICENAME | DEPLOYMENTID/...PARENTNAME.../SERVICENAME/INSTANCE } [COMMAND]",
 				BashComplete: c.printServicesFirst,
 				Before:       c.cmdServiceAttach,
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "record",
+						Usage: "keeps a recording of the session on the master for replay",
+					},
+				},
 			}, {
 				Name:         "action",
 				Usage:        "Run a predefined action in a running service container",
@@ -374,6 +390,12 @@ func (c *ServicedCli) initService() {
 							},
 						},
 					},
+					{
+						Name:        "certs",
+						Usage:       "Lists the TLS certificates of public endpoints",
+						Description: "serviced service public-endpoints certs",
+						Action:      c.cmdPublicEndpointsCerts,
+					},
 					{
 						Name:        "port",
 						Usage:       "Manages port public endpoints for a service",
@@ -410,6 +432,24 @@ func (c *ServicedCli) initService() {
 										Name:  "restart, r",
 										Usage: "Restart the service after adding the port if the service is currently running",
 									},
+									cli.StringSliceFlag{
+										Name:  "allow",
+										Value: &cli.StringSlice{},
+										Usage: "Only accept connections from this address or CIDR (repeatable)",
+									},
+									cli.StringSliceFlag{
+										Name:  "deny",
+										Value: &cli.StringSlice{},
+										Usage: "Reject connections from this address or CIDR (repeatable)",
+									},
+									cli.Float64Flag{
+										Name:  "rate-limit",
+										Usage: "Maximum connections per second, 0 is unlimited",
+									},
+									cli.IntFlag{
+										Name:  "burst",
+										Usage: "Maximum connections accepted at once when rate limited, defaults to the rate limit",
+									},
 								},
 							},
 							{
@@ -425,6 +465,36 @@ func (c *ServicedCli) initService() {
 								Description: "serviced service public-endpoints port enable <SERVICEID> <ENDPOINTNAME> <PORTADDR> true|false",
 								Action:      c.cmdPublicEndpointsPortEnable,
 							},
+							{
+								Name:        "cert",
+								Usage:       "Manages the TLS certificate of a port public endpoint",
+								Description: "serviced service public-endpoints port cert",
+								Subcommands: []cli.Command{
+									{
+										Name:        "set",
+										Usage:       "Set the TLS certificate of a port public endpoint",
+										Description: "serviced service public-endpoints port cert set <SERVICEID> <ENDPOINTNAME> <PORTADDR> --cert=FILE --key=FILE",
+										Action:      c.cmdPublicEndpointsPortCertSet,
+										Flags: []cli.Flag{
+											cli.StringFlag{
+												Name:  "cert",
+												Usage: "PEM file with the certificate (and any intermediate certificates)",
+											},
+											cli.StringFlag{
+												Name:  "key",
+												Usage: "PEM file with the private key of the certificate",
+											},
+										},
+									},
+									{
+										Name:        "remove",
+										ShortName:   "rm",
+										Usage:       "Remove the TLS certificate of a port public endpoint",
+										Description: "serviced service public-endpoints port cert remove <SERVICEID> <ENDPOINTNAME> <PORTADDR>",
+										Action:      c.cmdPublicEndpointsPortCertRemove,
+									},
+								},
+							},
 						},
 					},
 					{
@@ -458,6 +528,26 @@ func (c *ServicedCli) initService() {
 								Usage:       "Add a vhost public endpoint to a service",
 								Description: "serviced service public-endpoints vhost add <SERVICEID> <ENDPOINTNAME> <VHOST> <ENABLED>",
 								Action:      c.cmdPublicEndpointsVHostAdd,
+								Flags: []cli.Flag{
+									cli.StringSliceFlag{
+										Name:  "allow",
+										Value: &cli.StringSlice{},
+										Usage: "Only accept requests from this address or CIDR (repeatable)",
+									},
+									cli.StringSliceFlag{
+										Name:  "deny",
+										Value: &cli.StringSlice{},
+										Usage: "Reject requests from this address or CIDR (repeatable)",
+									},
+									cli.Float64Flag{
+										Name:  "rate-limit",
+										Usage: "Maximum requests per second, 0 is unlimited",
+									},
+									cli.IntFlag{
+										Name:  "burst",
+										Usage: "Maximum requests accepted at once when rate limited, defaults to the rate limit",
+									},
+								},
 							},
 							{
 								Name:        "remove",
@@ -472,6 +562,49 @@ func (c *ServicedCli) initService() {
 								Description: "serviced service public-endpoints vhost enable <SERVICEID> <ENDPOINTNAME> <VHOST> true|false",
 								Action:      c.cmdPublicEndpointsVHostEnable,
 							},
+							{
+								Name:        "cert",
+								Usage:       "Manages the TLS certificate of a vhost public endpoint",
+								Description: "serviced service public-endpoints vhost cert",
+								Subcommands: []cli.Command{
+									{
+										Name:        "set",
+										Usage:       "Set the TLS certificate of a vhost public endpoint",
+										Description: "serviced service public-endpoints vhost cert set <SERVICEID> <ENDPOINTNAME> <VHOST> --cert=FILE --key=FILE",
+										Action:      c.cmdPublicEndpointsVHostCertSet,
+										Flags: []cli.Flag{
+											cli.StringFlag{
+												Name:  "cert",
+												Usage: "PEM file with the certificate (and any intermediate certificates)",
+											},
+											cli.StringFlag{
+												Name:  "key",
+												Usage: "PEM file with the private key of the certificate",
+											},
+										},
+									},
+									{
+										Name:        "remove",
+										ShortName:   "rm",
+										Usage:       "Remove the TLS certificate of a vhost public endpoint",
+										Description: "serviced service public-endpoints vhost cert remove <SERVICEID> <ENDPOINTNAME> <VHOST>",
+										Action:      c.cmdPublicEndpointsVHostCertRemove,
+									},
+									{
+										Name:        "acme",
+										Usage:       "Obtain and renew the TLS certificate of a vhost public endpoint with ACME",
+										Description: "serviced service public-endpoints vhost cert acme <SERVICEID> <ENDPOINTNAME> <VHOST> [--domain=DOMAIN ...]",
+										Action:      c.cmdPublicEndpointsVHostCertACME,
+										Flags: []cli.Flag{
+											cli.StringSliceFlag{
+												Name:  "domain",
+												Value: &cli.StringSlice{},
+												Usage: "Domain name of the certificate (default is the vhost name)",
+											},
+										},
+									},
+								},
+							},
 						},
 					},
 				},
@@ -483,6 +616,48 @@ func (c *ServicedCli) initService() {
 				BashComplete: c.printServicesFirst,
 				Action:       c.cmdServiceClearEmergency,
 			},
+			{
+				Name:         "slo",
+				Usage:        "Reports a service's service level objectives against their targets",
+				Description:  "serviced service slo { SERVICEID | SERVICENAME | DEPLOYMENTID/...PARENTNAME.../SERVICENAME }",
+				BashComplete: c.printServicesFirst,
+				Action:       c.cmdServiceSLO,
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "history",
+						Usage: "Show the daily compliance of each objective over its window",
+					},
+					cli.BoolFlag{
+						Name:  "verbose, v",
+						Usage: "Show JSON format",
+					},
+				},
+			},
+			{
+				Name:        "recommend",
+				Usage:       "Recommends RAM commitments, CPU commitments and memory limits from the actual usage of services",
+				Description: "serviced service recommend [--tenant TENANT] [--window DURATION] [--apply]",
+				Action:      c.cmdServiceRecommend,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "tenant",
+						Usage: "Only report the services of this tenant",
+					},
+					cli.StringFlag{
+						Name:  "window",
+						Value: "168h",
+						Usage: "Compare the usage of the services within this duration",
+					},
+					cli.BoolFlag{
+						Name:  "apply",
+						Usage: "Update the services with the recommended values",
+					},
+					cli.BoolFlag{
+						Name:  "verbose, v",
+						Usage: "Show JSON format",
+					},
+				},
+			},
 			{
 				Name:         "remove-ip",
 				Usage:        "Remove the IP assignment of a service's endpoints",
@@ -1371,6 +1546,7 @@ func (c *ServicedCli) cmdServiceShell(ctx *cli.Context) error {
 		IsTTY:            isTTY,
 		Mounts:           ctx.GlobalStringSlice("mount"),
 		ServicedEndpoint: fmt.Sprintf("localhost:%s", api.GetOptionsRPCPort()),
+		Record:           ctx.GlobalBool("record"),
 	}
 
 	if err := c.driver.StartShell(config); err != nil {
@@ -1393,7 +1569,7 @@ func (c *ServicedCli) cmdServiceRun(ctx *cli.Context) error {
 	// set up signal handler to stop the run
 	stopChan := make(chan struct{})
 	sigChan := make(chan os.Signal)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	go func() {
 		<-sigChan
 		log.Debug("Received stop signal")
@@ -1472,6 +1648,7 @@ func (c *ServicedCli) cmdServiceRun(ctx *cli.Context) error {
 		Mounts:           ctx.GlobalStringSlice("mount"),
 		ServicedEndpoint: fmt.Sprintf("localhost:%s", api.GetOptionsRPCPort()),
 		LogToStderr:      ctx.GlobalBool("logtostderr"),
+		Record:           ctx.GlobalBool("record"),
 	}
 
 	config.LogStash.Enable = ctx.GlobalBool("logstash")
@@ -1520,7 +1697,11 @@ func (c *ServicedCli) cmdServiceAttach(ctx *cli.Context) error {
 		argv = args[2:]
 	}
 
-	if err := c.driver.AttachServiceInstance(svc.ID, instanceID, command, argv); err != nil {
+	if err := c.driver.AttachServiceInstance(svc.ID, instanceID, command, argv, ctx.GlobalBool("record")); err != nil {
+		// pass on the exit code of the command, as when attach exec'd it
+		if exitcode, ok := utils.GetExitStatus(err); ok {
+			return c.exit(exitcode)
+		}
 		fmt.Fprintln(os.Stderr, err)
 		return err
 	}
@@ -1818,3 +1999,210 @@ func (c *ServicedCli) cmdServiceClearEmergency(ctx *cli.Context) {
 
 	fmt.Printf("Cleared emergency status for %d services\n", count)
 }
+
+// serviced service slo { SERVICEID | SERVICENAME | DEPLOYMENTID/...PARENTNAME.../SERVICENAME } [--history] [--verbose]
+func (c *ServicedCli) cmdServiceSLO(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) < 1 {
+		fmt.Printf("Incorrect Usage.\n\n")
+		cli.ShowCommandHelp(ctx, "slo")
+		return
+	}
+
+	svc, _, err := c.searchForService(args.First())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+
+	statuses, err := c.driver.GetServiceSLOs(svc.ID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	} else if len(statuses) == 0 {
+		fmt.Fprintf(os.Stderr, "%s - no service level objectives defined\n", svc.Name)
+		return
+	}
+
+	if ctx.Bool("verbose") {
+		if data, err := json.MarshalIndent(statuses, " ", "  "); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to marshal service level objectives: %s", err)
+		} else {
+			fmt.Println(string(data))
+		}
+		return
+	}
+
+	t := NewTable("Name,Type,Target,Window,Compliance,ErrorBudget,BurnRates,Status")
+	for _, status := range statuses {
+		rates := []string{}
+		burning := false
+		for _, rate := range status.BurnRates {
+			rates = append(rates, fmt.Sprintf("%dh:%.2f", rate.Hours, rate.Rate))
+			burning = burning || rate.Exceeded
+		}
+		state := "met"
+		if !status.Met() {
+			state = "missed"
+		}
+		if burning {
+			state += ", burning"
+		}
+		t.AddRow(map[string]interface{}{
+			"Name":        status.Name,
+			"Type":        status.Type,
+			"Target":      fmt.Sprintf("%g%%", status.Target),
+			"Window":      fmt.Sprintf("%dd", status.WindowDays),
+			"Compliance":  fmt.Sprintf("%.3f%%", status.Compliance),
+			"ErrorBudget": fmt.Sprintf("%.1f%%", status.ErrorBudget),
+			"BurnRates":   strings.Join(rates, " "),
+			"Status":      state,
+		})
+	}
+	t.Print()
+
+	if ctx.Bool("history") {
+		for _, status := range statuses {
+			fmt.Printf("\n%s\n", status.Name)
+			h := NewTable("Date,Good,Total,Compliance")
+			for _, day := range status.History {
+				h.AddRow(map[string]interface{}{
+					"Date":       day.Date,
+					"Good":       day.Good,
+					"Total":      day.Total,
+					"Compliance": fmt.Sprintf("%.3f%%", day.Compliance),
+				})
+			}
+			h.Print()
+		}
+	}
+}
+
+// serviced service recommend [--tenant TENANT] [--window DURATION] [--apply] [--verbose]
+func (c *ServicedCli) cmdServiceRecommend(ctx *cli.Context) {
+	window, err := time.ParseDuration(ctx.String("window"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid duration %s: %s\n", ctx.String("window"), err)
+		return
+	}
+
+	tenantID := ""
+	if tenant := ctx.String("tenant"); tenant != "" {
+		details, _, err := c.searchForService(tenant)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		} else if details.ParentServiceID != "" {
+			fmt.Fprintf(os.Stderr, "%s is not a tenant\n", tenant)
+			return
+		}
+		tenantID = details.ID
+	}
+
+	recs, err := c.driver.GetResourceRecommendations(tenantID, time.Now().Add(-window))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	} else if len(recs) == 0 {
+		fmt.Fprintln(os.Stderr, "no resource usage found")
+		return
+	}
+
+	if ctx.Bool("verbose") {
+		if data, err := json.MarshalIndent(recs, " ", "  "); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to marshal recommendations: %s", err)
+		} else {
+			fmt.Println(string(data))
+		}
+	} else {
+		printResourceRecommendations(recs)
+	}
+
+	if ctx.Bool("apply") {
+		for _, rec := range recs {
+			if !rec.Changed() {
+				continue
+			}
+			if err := c.applyResourceRecommendation(rec); err != nil {
+				fmt.Fprintf(os.Stderr, "could not update %s: %s\n", rec.Name, err)
+				continue
+			}
+			fmt.Printf("Updated %s\n", rec.Name)
+		}
+	}
+}
+
+// printResourceRecommendations prints the usage and recommended values of
+// each service, and the capacity that the recommendations free in each pool.
+func printResourceRecommendations(recs []service.ResourceRecommendation) {
+	t := NewTable("Name,ServiceID,Inst,RAM p95/max,RAMCommitment,CPU p95/max,CPUCommitment,MemoryLimit")
+	pools := []string{}
+	freedRAM := make(map[string]int64)
+	freedCPU := make(map[string]int64)
+	for _, rec := range recs {
+		memoryLimit := "-"
+		if rec.MemoryLimit > 0 {
+			memoryLimit = recommendedValue(bytefmt.ByteSize(uint64(rec.MemoryLimit)), bytefmt.ByteSize(uint64(rec.RecommendedMemoryLimit)))
+		}
+		t.AddRow(map[string]interface{}{
+			"Name":          rec.Name,
+			"ServiceID":     rec.ServiceID,
+			"Inst":          rec.Instances,
+			"RAM p95/max":   fmt.Sprintf("%s/%s", bytefmt.ByteSize(uint64(rec.Usage.MemoryP95)), bytefmt.ByteSize(uint64(rec.Usage.MemoryMax))),
+			"RAMCommitment": recommendedValue(bytefmt.ByteSize(rec.RAMCommitment), bytefmt.ByteSize(rec.RecommendedRAMCommitment)),
+			"CPU p95/max":   fmt.Sprintf("%.0f%%/%.0f%%", rec.Usage.CPUP95, rec.Usage.CPUMax),
+			"CPUCommitment": recommendedValue(strconv.FormatUint(rec.CPUCommitment, 10), strconv.FormatUint(rec.RecommendedCPUCommitment, 10)),
+			"MemoryLimit":   memoryLimit,
+		})
+		if _, ok := freedRAM[rec.PoolID]; !ok {
+			pools = append(pools, rec.PoolID)
+		}
+		freedRAM[rec.PoolID] += rec.FreedRAM()
+		freedCPU[rec.PoolID] += rec.FreedCPU()
+	}
+	t.Print()
+
+	fmt.Println()
+	p := NewTable("Pool,FreedRAM,FreedCPU")
+	sort.Strings(pools)
+	for _, poolID := range pools {
+		ram := freedRAM[poolID]
+		sign := ""
+		if ram < 0 {
+			sign, ram = "-", -ram
+		}
+		p.AddRow(map[string]interface{}{
+			"Pool":     poolID,
+			"FreedRAM": sign + bytefmt.ByteSize(uint64(ram)),
+			"FreedCPU": freedCPU[poolID],
+		})
+	}
+	p.Print()
+}
+
+// recommendedValue shows the change from the current to the recommended
+// value, or the current value if it does not change.
+func recommendedValue(current, recommended string) string {
+	if current == recommended {
+		return current
+	}
+	return current + " -> " + recommended
+}
+
+// applyResourceRecommendation updates the service with the recommended
+// values.
+func (c *ServicedCli) applyResourceRecommendation(rec service.ResourceRecommendation) error {
+	svc, err := c.driver.GetService(rec.ServiceID)
+	if err != nil {
+		return err
+	} else if svc == nil {
+		return errors.New("service not found")
+	}
+	rec.Apply(svc)
+	data, err := json.Marshal(svc)
+	if err != nil {
+		return err
+	}
+	_, err = c.driver.UpdateService(bytes.NewReader(data))
+	return err
+}
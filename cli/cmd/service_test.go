@@ -23,6 +23,7 @@ import (
 	//	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/control-center/serviced/cli/api"
 	"github.com/control-center/serviced/dao"
@@ -31,6 +32,8 @@ import (
 	"github.com/control-center/serviced/domain/host"
 	"github.com/control-center/serviced/domain/pool"
 	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/domain/servicedefinition"
+	"github.com/control-center/serviced/slo"
 	"github.com/control-center/serviced/utils"
 )
 
@@ -451,6 +454,56 @@ func (t ServiceAPITest) ClearEmergency(serviceID string) (int, error) {
 	return 1, nil
 }
 
+func (t ServiceAPITest) GetServiceSLOs(serviceID string) ([]slo.Status, error) {
+	if t.errs["GetServiceSLOs"] != nil {
+		return nil, t.errs["GetServiceSLOs"]
+	} else if serviceID != "test-service-1" {
+		return []slo.Status{}, nil
+	}
+	return []slo.Status{
+		{
+			ServiceID:   serviceID,
+			Name:        "availability",
+			Type:        servicedefinition.SLOAvailability,
+			Target:      99.9,
+			WindowDays:  30,
+			Good:        43170,
+			Total:       43200,
+			Compliance:  99.93055,
+			ErrorBudget: 30.6,
+			BurnRates: []slo.BurnRate{
+				{Hours: 1, Rate: 0, Threshold: 14.4},
+				{Hours: 6, Rate: 7.5, Threshold: 6, Exceeded: true},
+			},
+			History: []slo.DailyCompliance{
+				{Date: "2018-03-01", Good: 1440, Total: 1440, Compliance: 100},
+				{Date: "2018-03-02", Good: 690, Total: 720, Compliance: 95.83333},
+			},
+		},
+	}, nil
+}
+
+func (t ServiceAPITest) GetResourceRecommendations(tenantID string, since time.Time) ([]service.ResourceRecommendation, error) {
+	if t.errs["GetResourceRecommendations"] != nil {
+		return nil, t.errs["GetResourceRecommendations"]
+	} else if tenantID != "" && tenantID != "test-service-1" {
+		return []service.ResourceRecommendation{}, nil
+	}
+	return []service.ResourceRecommendation{
+		{
+			ServiceID:                "test-service-2",
+			Name:                     "Zope",
+			PoolID:                   "default",
+			Instances:                1,
+			Usage:                    service.ResourceUsage{MemoryP95: 300 * 1024 * 1024, MemoryMax: 400 * 1024 * 1024, CPUP95: 50, CPUMax: 120},
+			RAMCommitment:            1024 * 1024 * 1024,
+			RecommendedRAMCommitment: 384 * 1024 * 1024,
+			CPUCommitment:            2,
+			RecommendedCPUCommitment: 1,
+		},
+	}, nil
+}
+
 func TestServicedCLI_CmdServiceList_one(t *testing.T) {
 	serviceID := "test-service-1"
 
@@ -1361,6 +1414,45 @@ func ExampleServicedCLI_CmdServiceClearEmergency_err() {
 	// stub for facade failed
 }
 
+func ExampleServicedCLI_CmdServiceSLO() {
+	InitServiceAPITest("serviced", "service", "slo", "--history", "test-service-1")
+
+	// Output:
+	// Name         Type         Target Window Compliance ErrorBudget BurnRates       Status
+	// availability availability 99.9%  30d    99.931%    30.6%       1h:0.00 6h:7.50 met, burning
+	//
+	// availability
+	// Date       Good Total Compliance
+	// 2018-03-01 1440 1440  100.000%
+	// 2018-03-02 690  720   95.833%
+}
+
+func ExampleServicedCLI_CmdServiceSLO_none() {
+	pipeStderr(func() { InitServiceAPITest("serviced", "service", "slo", "test-service-2") })
+
+	// Output:
+	// Zope - no service level objectives defined
+}
+
+func ExampleServicedCLI_CmdServiceRecommend() {
+	InitServiceAPITest("serviced", "service", "recommend", "--tenant", "test-service-1", "--apply")
+
+	// Output:
+	// Name ServiceID      Inst RAM p95/max RAMCommitment CPU p95/max CPUCommitment MemoryLimit
+	// Zope test-service-2 1    300M/400M   1G -> 384M    50%/120%    2 -> 1        -
+	//
+	// Pool    FreedRAM FreedCPU
+	// default 640M     1
+	// Updated Zope
+}
+
+func ExampleServicedCLI_CmdServiceRecommend_none() {
+	pipeStderr(func() { InitServiceAPITest("serviced", "service", "recommend", "--tenant", "test-service-2") })
+
+	// Output:
+	// no resource usage found
+}
+
 func ExampleServicedCLI_CmdServiceClearEmergency_usage() {
 	pipeStderr(func() { InitServiceAPITest("serviced", "service", "clear-emergency") })
 
@@ -41,6 +41,10 @@ func (c *ServicedCli) initSnapshot() {
 						Name:  "show-tags, t",
 						Usage: "shows tags associated with each snapshot",
 					},
+					cli.BoolFlag{
+						Name:  "show-hooks",
+						Usage: "shows the quiesce hooks that ran for each snapshot",
+					},
 				},
 			}, {
 				Name:         "add",
@@ -176,9 +180,10 @@ func (c *ServicedCli) printSnapshotsFirstThenTags(ctx *cli.Context) {
 	}
 }
 
-// serviced snapshot list [SERVICEID] [--show-tags]
+// serviced snapshot list [SERVICEID] [--show-tags] [--show-hooks]
 func (c *ServicedCli) cmdSnapshotList(ctx *cli.Context) {
 	showTags := ctx.Bool("show-tags")
+	showHooks := ctx.Bool("show-hooks")
 	var (
 		snapshots []dao.SnapshotInfo
 		err       error
@@ -199,7 +204,9 @@ func (c *ServicedCli) cmdSnapshotList(ctx *cli.Context) {
 	if snapshots == nil || len(snapshots) == 0 {
 		fmt.Fprintln(os.Stderr, "no snapshots found")
 	} else {
-		if showTags { //print a table of snapshot, description, tag list
+		if showHooks {
+			printSnapshotHooks(snapshots)
+		} else if showTags { //print a table of snapshot, description, tag list
 			t := NewTable("Snapshot,Description,Tags")
 			for _, s := range snapshots {
 				//build a comma-delimited list of the tags
@@ -228,6 +235,37 @@ func (c *ServicedCli) cmdSnapshotList(ctx *cli.Context) {
 	return
 }
 
+// printSnapshotHooks prints a table of the hooks that ran for each snapshot
+func printSnapshotHooks(snapshots []dao.SnapshotInfo) {
+	t := NewTable("Snapshot,Freeze Window,Service,Instance,Hook,Phase,Duration,Result")
+	for _, s := range snapshots {
+		for _, hook := range s.Hooks {
+			result := "ok"
+			if hook.Aborted {
+				result = "aborted: " + hook.Error
+			} else if hook.Error != "" {
+				result = "failed: " + hook.Error
+			}
+			t.AddRow(map[string]interface{}{
+				"Snapshot":      s.SnapshotID,
+				"Freeze Window": s.FreezeWindow,
+				"Service":       hook.ServiceID,
+				"Instance":      hook.InstanceID,
+				"Hook":          hook.Name,
+				"Phase":         hook.Phase,
+				"Duration":      hook.Duration,
+				"Result":        result,
+			})
+		}
+	}
+	if len(t.rows) == 0 {
+		fmt.Fprintln(os.Stderr, "no snapshot hooks found")
+		return
+	}
+	t.Padding = 4
+	t.Print()
+}
+
 // serviced snapshot add SERVICEID [--tags=<tag1>,<tag2>...]
 func (c *ServicedCli) cmdSnapshotAdd(ctx *cli.Context) {
 	nArgs := len(ctx.Args())
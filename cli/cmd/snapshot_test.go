@@ -20,9 +20,11 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/control-center/serviced/cli/api"
 	"github.com/control-center/serviced/dao"
+	"github.com/control-center/serviced/domain/servicedefinition"
 	"github.com/control-center/serviced/utils"
 	"github.com/control-center/serviced/volume/btrfs"
 )
@@ -35,7 +37,12 @@ var DefaultSnapshotAPITest = SnapshotAPITest{snapshots: DefaultTestSnapshots}
 
 var DefaultTestSnapshots = []dao.SnapshotInfo{
 	dao.SnapshotInfo{SnapshotID: "test-service-1-snapshot-1", TenantID: "test-service-1", Description: "description 1", Tags: []string{"tag-1"}},
-	dao.SnapshotInfo{SnapshotID: "test-service-1-snapshot-2", TenantID: "test-service-1", Description: "description 2", Tags: []string{"tag-2", "tag-3"}},
+	dao.SnapshotInfo{SnapshotID: "test-service-1-snapshot-2", TenantID: "test-service-1", Description: "description 2", Tags: []string{"tag-2", "tag-3"},
+		FreezeWindow: 5 * time.Second,
+		Hooks: []servicedefinition.SnapshotHookResult{
+			{ServiceID: "test-service-1", InstanceID: 0, Name: "flush", Phase: servicedefinition.HookPreFreeze, Duration: time.Second},
+			{ServiceID: "test-service-1", InstanceID: 0, Name: "check", Phase: servicedefinition.HookPostThaw, Duration: 2 * time.Second, Error: "exit status 1"},
+		}},
 	dao.SnapshotInfo{SnapshotID: "test-service-1-invalid", Invalid: true},
 	dao.SnapshotInfo{SnapshotID: "test-service-2-snapshot-1", TenantID: "test-service-2", Description: "", Tags: []string{""}},
 	dao.SnapshotInfo{SnapshotID: "test-service-2-invalid", Invalid: true},
@@ -228,6 +235,21 @@ func TestServicedCLI_CmdSnapshotList_ShowTagsLong(t *testing.T) {
 	}
 }
 
+func TestServicedCLI_CmdSnapshotList_ShowHooks(t *testing.T) {
+	output := captureStdout(func() { InitSnapshotAPITest("serviced", "snapshot", "list", "--show-hooks") })
+	expected :=
+		"Snapshot                     Freeze Window    Service           Instance    Hook     Phase         Duration    Result" +
+			"\ntest-service-1-snapshot-2    5s               test-service-1    0           flush    pre-freeze    1s          ok" +
+			"\ntest-service-1-snapshot-2    5s               test-service-1    0           check    post-thaw     2s          failed: exit status 1"
+
+	outStr := TrimLines(fmt.Sprintf("%s", output))
+	expected = TrimLines(expected)
+
+	if expected != outStr {
+		t.Fatalf("\ngot:\n%s\nwant:\n%s", outStr, expected)
+	}
+}
+
 func ExampleServicedCLI_CmdSnapshotList_byServiceID() {
 	InitSnapshotAPITest("serviced", "snapshot", "list", "test-service-1")
 
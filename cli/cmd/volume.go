@@ -20,6 +20,7 @@ import (
 
 	"github.com/codegangsta/cli"
 	"github.com/control-center/serviced/volume"
+	"github.com/docker/go-units"
 )
 
 // Initializer for serviced pool subcommands
@@ -40,6 +41,23 @@ func (c *ServicedCli) initVolume() {
 						Usage: "Show JSON format",
 					},
 				},
+			}, {
+				Name:        "quota",
+				Usage:       "Sets the storage quota for an application",
+				Description: "serviced volume quota TENANT [--soft SIZE] [--hard SIZE]",
+				Action:      c.cmdVolumeQuota,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "soft",
+						Value: "",
+						Usage: "Storage used at which a warning is raised (e.g. 50G); omit for no limit",
+					},
+					cli.StringFlag{
+						Name:  "hard",
+						Value: "",
+						Usage: "Storage used at which the application is emergency stopped (e.g. 60G); omit for no limit",
+					},
+				},
 			},
 		},
 	})
@@ -52,14 +70,94 @@ func (c *ServicedCli) cmdVolumeStatus(ctx *cli.Context) {
 		log.WithError(err).Error("Unable to get volume status")
 		return
 	}
+	quotas, err := c.driver.GetStorageQuotaStatus()
+	if err != nil {
+		log.WithError(err).Warn("Unable to get storage quota status")
+	}
 	if ctx.Bool("verbose") {
 		printStatusesJson(response)
 	} else {
 		printStatuses(response)
+		printQuotaStatuses(quotas)
 	}
 	return
 }
 
+// serviced volume quota TENANT [--soft SIZE] [--hard SIZE]
+func (c *ServicedCli) cmdVolumeQuota(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) < 1 {
+		fmt.Printf("Incorrect Usage.\n\n")
+		cli.ShowCommandHelp(ctx, "quota")
+		return
+	}
+
+	svc, _, err := c.searchForService(args.First())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+
+	var quota volume.StorageQuota
+	if quota.SoftLimit, err = parseQuotaLimit(ctx.String("soft")); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid soft limit: %s\n", err)
+		return
+	}
+	if quota.HardLimit, err = parseQuotaLimit(ctx.String("hard")); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid hard limit: %s\n", err)
+		return
+	}
+
+	if err := c.driver.SetStorageQuota(svc.ID, quota); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	if quota.IsSet() {
+		fmt.Printf("Set storage quota for %s\n", svc.Name)
+	} else {
+		fmt.Printf("Removed storage quota for %s\n", svc.Name)
+	}
+}
+
+func parseQuotaLimit(value string) (uint64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	size, err := units.RAMInBytes(value)
+	if err != nil {
+		return 0, err
+	}
+	if size < 0 {
+		return 0, fmt.Errorf("size must not be negative")
+	}
+	return uint64(size), nil
+}
+
+func printQuotaStatuses(quotas []volume.TenantQuotaStatus) {
+	if len(quotas) == 0 {
+		return
+	}
+	fmt.Println("Application Storage Quotas:")
+	t := NewTable("TenantID,Used,SoftLimit,HardLimit,State")
+	for _, q := range quotas {
+		t.AddRow(map[string]interface{}{
+			"TenantID":  q.TenantID,
+			"Used":      volume.ToBytes(q.Used),
+			"SoftLimit": quotaLimitString(q.Quota.SoftLimit),
+			"HardLimit": quotaLimitString(q.Quota.HardLimit),
+			"State":     q.State,
+		})
+	}
+	t.Print()
+}
+
+func quotaLimitString(limit uint64) string {
+	if limit == 0 {
+		return "none"
+	}
+	return volume.ToBytes(limit)
+}
+
 func printStatuses(statuses *volume.Statuses) {
 	for path, status := range statuses.GetAllStatuses() {
 		fmt.Printf("Status for volume %s:\n", path)
@@ -52,13 +52,19 @@ type Options struct {
 	Agent                      bool
 	MuxPort                    int
 	MuxDisableTLS              string //  Disable TLS for MUX connections, string val of bool
+	MuxSessions                int    //  Number of multiplexed connections kept open to each remote mux, 0 disables
+	MuxMutualTLS               string //  Require certificates signed by the master on MUX connections, string val of bool
 	KeyPEMFile                 string
 	CertPEMFile                string
+	ACMEDirectory              string // url of the ACME server's directory
+	ACMEEmail                  string
 	HomePath                   string // serviced's root directory; e.g. /opt/serviced
 	VolumesPath                string
 	EtcPath                    string
 	IsvcsPath                  string
 	BackupsPath                string
+	MuxCredentialsPath         string // where the mux certificates of the host and its tenants are kept
+	SessionsPath               string // where the master keeps recordings of shell sessions
 	ResourcePath               string
 	LogPath                    string // Serviced logs directory
 	Zookeepers                 []string
@@ -76,6 +82,8 @@ type Options struct {
 	Verbosity                  int
 	StaticIPs                  []string
 	DockerRegistry             string
+	DockerRegistryReplica      string
+	DockerRegistryReplicaZK    []string
 	CPUProfile                 string // write cpu profile to file
 	MaxContainerAge            int    // max container age in seconds
 	MaxDFSTimeout              int    // max timeout for snapshot
@@ -87,6 +95,7 @@ type Options struct {
 	LogstashCycleTime          int    // Logstash purging cycle time in hours
 	LogstashURL                string
 	DebugPort                  int      // Port to listen for profile clients
+	MetricsPort                string   // Address to serve metrics in the Prometheus format
 	AdminGroup                 string   // user group that can log in to control center
 	MaxRPCClients              int      // the max number of rpc clients to an endpoint
 	MUXTLSCiphers              []string // List of tls ciphers supported for mux
@@ -182,3 +191,10 @@ func MuxTLSIsEnabled() bool {
 	disabled, _ := strconv.ParseBool(options.MuxDisableTLS)
 	return !disabled && options.MuxPort > 0
 }
+
+// MuxMutualTLSIsEnabled returns true if mux connections must present a
+// certificate issued by the master.
+func MuxMutualTLSIsEnabled() bool {
+	enabled, _ := strconv.ParseBool(options.MuxMutualTLS)
+	return enabled && MuxTLSIsEnabled()
+}
@@ -20,6 +20,7 @@ import (
 	coordclient "github.com/control-center/serviced/coordinator/client"
 	"github.com/control-center/serviced/domain"
 	"github.com/control-center/serviced/domain/applicationendpoint"
+	"github.com/control-center/serviced/domain/pool"
 	"github.com/control-center/serviced/domain/service"
 	"github.com/control-center/serviced/domain/servicedefinition"
 	"github.com/control-center/serviced/health"
@@ -91,11 +92,13 @@ type ControllerOptions struct {
 		Command     []string // The command to launch
 	}
 	Mux struct { // TCPMUX configuration: RFC 1078
-		Enabled     bool   // True if muxing is used
-		Port        int    // the TCP port to use
-		DisableTLS  bool   // True if TLS is disabled
-		KeyPEMFile  string // Path to the key file when TLS is used
-		CertPEMFile string // Path to the cert file when TLS is used
+		Enabled         bool   // True if muxing is used
+		Port            int    // the TCP port to use
+		DisableTLS      bool   // True if TLS is disabled
+		Sessions        int    // Number of multiplexed connections to each remote mux, 0 disables
+		CredentialsPath string // Directory of the certificate presented to remote muxes, empty if not used
+		KeyPEMFile      string // Path to the key file when TLS is used
+		CertPEMFile     string // Path to the cert file when TLS is used
 	}
 	Logforwarder LogforwarderOptions
 	Metric struct {
@@ -127,6 +130,7 @@ type Controller struct {
 	endpoints          *ContainerEndpoints
 	healthChecks       map[string]health.HealthCheck
 	ccApiProxy         *servicedApiProxy
+	drainPeriod        time.Duration
 }
 
 // Close shuts down the controller
@@ -197,6 +201,23 @@ func getAgentZkInfo(lbClientPort string) (node.ZkInfo, error) {
 	return zkInfo, nil
 }
 
+// getAgentMetricSinks returns a function that gets the metric sinks of the
+// pool from the agent
+func getAgentMetricSinks(lbClientPort string) func() (pool.MetricSinks, error) {
+	return func() (pool.MetricSinks, error) {
+		client, err := node.NewLBClient(lbClientPort)
+		if err != nil {
+			return nil, err
+		}
+		defer client.Close()
+		var sinks pool.MetricSinks
+		if err := client.GetMetricSinks(&sinks); err != nil {
+			return nil, err
+		}
+		return sinks, nil
+	}
+}
+
 // chownConfFile sets the owner and permissions for a file
 func chownConfFile(filename, owner, permissions string) error {
 
@@ -259,10 +280,10 @@ func setupConfigFiles(svc *service.Service) error {
 }
 
 // setupLogstashFiles sets up logstash files
-func setupLogstashFiles(hostID string, hostIPs string, svcPath string, service *service.Service, instanceID string, logforwarderOptions LogforwarderOptions) error {
+func setupLogstashFiles(hostID string, hostIPs string, svcPath string, tenantID string, service *service.Service, instanceID string, logforwarderOptions LogforwarderOptions) error {
 	// write out logstash files
 	if len(service.LogConfigs) != 0 {
-		err := writeLogstashAgentConfig(hostID, hostIPs, svcPath, service, instanceID, logforwarderOptions)
+		err := writeLogstashAgentConfig(hostID, hostIPs, svcPath, tenantID, service, instanceID, logforwarderOptions)
 		if err != nil {
 			return err
 		}
@@ -300,6 +321,13 @@ func NewController(options ControllerOptions) (*Controller, error) {
 	<-auth.WaitForDelegateKeys(nil)
 	<-auth.WaitForAuthToken(nil)
 
+	// The agent renews the certificate of the tenant while the container runs
+	var muxCredentials *auth.MuxCredentials
+	if options.Mux.CredentialsPath != "" {
+		muxCredentials = &auth.MuxCredentials{}
+		go auth.WatchMuxCredentials(options.Mux.CredentialsPath, muxCredentials, keyshutdown)
+	}
+
 	// get service
 	instanceID, err := strconv.Atoi(options.Service.InstanceID)
 	if err != nil {
@@ -344,7 +372,7 @@ func NewController(options ControllerOptions) (*Controller, error) {
 	}
 
 	if options.Logforwarder.Enabled && len(service.LogConfigs) > 0 {
-		if err := setupLogstashFiles(c.hostID, options.HostIPs, options.ServiceNamePath, service,
+		if err := setupLogstashFiles(c.hostID, options.HostIPs, options.ServiceNamePath, c.tenantID, service,
 				options.Service.InstanceID, options.Logforwarder); err != nil {
 			glog.Errorf("Could not setup logstash files error:%s", err)
 			return c, fmt.Errorf("container: invalid LogStashFiles error:%s", err)
@@ -385,7 +413,7 @@ func NewController(options ControllerOptions) (*Controller, error) {
 		metricRedirect += "&controlplane_instance_id=" + options.Service.InstanceID
 
 		//build and serve the container metric forwarder
-		forwarder, err := NewMetricForwarder(options.Metric.Address, metricRedirect)
+		forwarder, err := NewMetricForwarder(options.Metric.Address, metricRedirect, getAgentMetricSinks(options.ServicedEndpoint))
 		if err != nil {
 			return c, err
 		}
@@ -399,6 +427,7 @@ func NewController(options ControllerOptions) (*Controller, error) {
 
 	// Keep a copy of the service prerequisites in the Controller object.
 	c.prereqs = service.Prereqs
+	c.drainPeriod = service.GetDrainPeriod()
 
 	// set up the zookeeper client
 	c.zkInfo, err = getAgentZkInfo(options.ServicedEndpoint)
@@ -426,6 +455,8 @@ func NewController(options ControllerOptions) (*Controller, error) {
 		IsShell:              os.Getenv("SERVICED_IS_SERVICE_SHELL") == "true",
 		TCPMuxPort:           uint16(options.Mux.Port),
 		UseTLS:               !options.Mux.DisableTLS,
+		MuxSessions:          options.Mux.Sessions,
+		MuxCredentials:       muxCredentials,
 		VirtualAddressSubnet: options.VirtualAddressSubnet,
 	}
 	c.endpoints, err = NewContainerEndpoints(service, opts)
@@ -644,6 +675,8 @@ func (c *Controller) Run() (err error) {
 	prereqsPassed := make(chan bool)
 	var startAfter <-chan time.Time
 	var exitAfter <-chan time.Time
+	var drainAfter <-chan time.Time
+	var drainSignal os.Signal
 	var service *subprocess.Instance = nil
 	serviceExited := make(chan error, 1)
 	endpointExit := make(chan struct{})
@@ -681,6 +714,7 @@ func (c *Controller) Run() (err error) {
 			sigc = nil
 			prereqsPassed = nil
 			startAfter = nil
+			drainAfter = nil
 			rpcDead = nil
 			storageDead = nil
 
@@ -700,10 +734,25 @@ func (c *Controller) Run() (err error) {
 	for !exited {
 		select {
 		case sig := <-sigc:
+			// unregister the exports and give the open connections time to
+			// finish before stopping the service, unless it was already
+			// draining.
+			if c.drainPeriod > 0 && drainAfter == nil && service != nil {
+				glog.Infof("Draining connections to service %s for %s before signaling %v", c.options.Service.ID, c.drainPeriod, sig)
+				c.endpoints.UnregisterExports()
+				drainSignal = sig
+				drainAfter = time.After(c.drainPeriod)
+				continue
+			}
 			glog.Infof("Notifying subprocess of signal %v for service %s", sig, c.options.Service.ID)
 			shutdownService(service, sig)
 			glog.Infof("Notification complete for signal %v for service %s", sig, c.options.Service.ID)
 
+		case <-drainAfter:
+			glog.Infof("Drained connections; notifying subprocess of signal %v for service %s", drainSignal, c.options.Service.ID)
+			shutdownService(service, drainSignal)
+			glog.Infof("Notification complete for signal %v for service %s", drainSignal, c.options.Service.ID)
+
 		case <-exitAfter:
 			glog.Infof("Killing unresponsive subprocess for service %s", c.options.Service.ID)
 			sendSignal(service, syscall.SIGKILL)
@@ -907,6 +956,7 @@ func (c *Controller) handleControlCenterImports(rpcdead chan struct{}) error {
 		bind := zkservice.ImportBinding{
 			Application:    eps[0].Application,
 			Purpose:        "import", // Punting on control center dynamic imports for now
+			Protocol:       eps[0].Protocol,
 			PortNumber:     eps[0].ProxyPort,
 			VirtualAddress: eps[0].VirtualAddress,
 		}
@@ -19,11 +19,15 @@ import (
 	"net"
 	"os"
 	"regexp"
+	"strings"
 	"sync"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/auth"
+	"github.com/control-center/serviced/commons"
 	"github.com/control-center/serviced/domain/service"
+	svcproxy "github.com/control-center/serviced/proxy"
 	"github.com/control-center/serviced/zzk"
 	"github.com/control-center/serviced/zzk/registry"
 	zkservice "github.com/control-center/serviced/zzk/service"
@@ -37,16 +41,20 @@ type ContainerEndpointsOptions struct {
 	IsShell              bool
 	TCPMuxPort           uint16
 	UseTLS               bool
+	MuxSessions          int
+	MuxCredentials       *auth.MuxCredentials
 	VirtualAddressSubnet string
 }
 
 // ContainerEndpoints manages import and export bindings for the instance.
 type ContainerEndpoints struct {
-	opts  ContainerEndpointsOptions
-	state *zkservice.State
-	cache *proxyCache
-	ports map[uint16]struct{}
-	vifs  *VIFRegistry
+	opts     ContainerEndpointsOptions
+	state    *zkservice.State
+	cache    *proxyCache
+	ports    map[uint16]struct{}
+	vifs     *VIFRegistry
+	unexport chan struct{}
+	once     sync.Once
 }
 
 // NewContainerEndpoints loads the service state and manages port bindings
@@ -54,9 +62,10 @@ type ContainerEndpoints struct {
 func NewContainerEndpoints(svc *service.Service, opts ContainerEndpointsOptions) (*ContainerEndpoints, error) {
 
 	ce := &ContainerEndpoints{
-		opts:  opts,
-		ports: make(map[uint16]struct{}),
-		vifs:  NewVIFRegistry(),
+		opts:     opts,
+		ports:    make(map[uint16]struct{}),
+		vifs:     NewVIFRegistry(),
+		unexport: make(chan struct{}),
 	}
 
 	// load the state object
@@ -66,7 +75,7 @@ func NewContainerEndpoints(svc *service.Service, opts ContainerEndpointsOptions)
 	}
 
 	// set up the proxy cache
-	ce.cache = newProxyCache(opts.TenantID, opts.TCPMuxPort, opts.UseTLS, allowDirect)
+	ce.cache = newProxyCache(opts.TenantID, opts.TCPMuxPort, opts.UseTLS, opts.MuxSessions, opts.MuxCredentials, allowDirect)
 
 	// set up virtual interface registry
 	if err := ce.vifs.SetSubnet(opts.VirtualAddressSubnet); err != nil {
@@ -116,6 +125,7 @@ func (ce *ContainerEndpoints) loadState(svc *service.Service) (bool, error) {
 				binds = append(binds, zkservice.ImportBinding{
 					Application:    ep.Application,
 					Purpose:        ep.Purpose,
+					Protocol:       ep.Protocol,
 					PortNumber:     ep.PortNumber,
 					VirtualAddress: ep.VirtualAddress,
 				})
@@ -186,10 +196,19 @@ func (ce *ContainerEndpoints) loadState(svc *service.Service) (bool, error) {
 // Run manages the container endpoints
 func (ce *ContainerEndpoints) Run(cancel <-chan struct{}) {
 
-	// register all of the exports
+	// register all of the exports, until they are unregistered to drain the
+	// instance
+	exportCancel := make(chan struct{})
+	go func() {
+		select {
+		case <-cancel:
+		case <-ce.unexport:
+		}
+		close(exportCancel)
+	}()
 	for _, bind := range ce.state.Exports {
 		ce.ports[bind.PortNumber] = struct{}{}
-		go ce.AddExport(cancel, bind)
+		go ce.AddExport(exportCancel, bind)
 	}
 
 	// track all of the imports
@@ -197,6 +216,12 @@ func (ce *ContainerEndpoints) Run(cancel <-chan struct{}) {
 	go ce.RunImportListener(cancel, ce.opts.TenantID, ce.state.Imports...)
 }
 
+// UnregisterExports removes the exports of the instance, so that other
+// services stop opening new connections to it.
+func (ce *ContainerEndpoints) UnregisterExports() {
+	ce.once.Do(func() { close(ce.unexport) })
+}
+
 // AddExport ensures that an export is registered for other services to bind
 func (ce *ContainerEndpoints) AddExport(cancel <-chan struct{}, bind zkservice.ExportBinding) {
 	logger := plog.WithFields(log.Fields{
@@ -377,7 +402,8 @@ func (ce *ContainerEndpoints) UpdateRemoteExports(bind zkservice.ImportBinding,
 			}
 
 			// update the proxy; returns a boolean if a new proxy was created.
-			isNew, err := ce.cache.Set(bind.Application, port, export)
+			protocol := importProtocol(bind, export)
+			isNew, err := ce.cache.Set(bind.Application, port, protocol, export)
 			if err != nil {
 				exLogger.WithError(err).Error("Could not update proxy")
 				return
@@ -396,7 +422,7 @@ func (ce *ContainerEndpoints) UpdateRemoteExports(bind zkservice.ImportBinding,
 				if virtualAddress != "" {
 
 					exLogger = exLogger.WithField("virtualaddress", virtualAddress)
-					if err := ce.vifs.RegisterVirtualAddress(virtualAddress, fmt.Sprintf("%d", port), export.Protocol); err != nil {
+					if err := ce.vifs.RegisterVirtualAddress(virtualAddress, fmt.Sprintf("%d", port), protocol); err != nil {
 						exLogger.WithError(err).Warn("Could not register virtual address")
 						continue
 					}
@@ -433,7 +459,8 @@ func (ce *ContainerEndpoints) UpdateRemoteExports(bind zkservice.ImportBinding,
 		}
 
 		// update the proxy
-		isNew, err := ce.cache.Set(bind.Application, port, exports...)
+		protocol := importProtocol(bind, exports...)
+		isNew, err := ce.cache.Set(bind.Application, port, protocol, exports...)
 		if err != nil {
 			exLogger.WithError(err).Error("Could not update proxy")
 			return
@@ -452,7 +479,7 @@ func (ce *ContainerEndpoints) UpdateRemoteExports(bind zkservice.ImportBinding,
 			if virtualAddress != "" {
 
 				exLogger = exLogger.WithField("virtualaddress", virtualAddress)
-				if err := ce.vifs.RegisterVirtualAddress(virtualAddress, fmt.Sprintf(":%d", port), "tcp"); err != nil {
+				if err := ce.vifs.RegisterVirtualAddress(virtualAddress, fmt.Sprintf(":%d", port), protocol); err != nil {
 					exLogger.WithError(err).Warn("Could not register virtual address")
 					return
 				}
@@ -463,9 +490,23 @@ func (ce *ContainerEndpoints) UpdateRemoteExports(bind zkservice.ImportBinding,
 	}
 }
 
+// importProtocol returns the protocol of an import binding, which falls back
+// to the protocol of the exports it is bound to.
+func importProtocol(bind zkservice.ImportBinding, exports ...registry.ExportDetails) string {
+	protocol := bind.Protocol
+	if protocol == "" && len(exports) > 0 {
+		protocol = exports[0].Protocol
+	}
+	if strings.ToLower(protocol) == commons.UDP {
+		return commons.UDP
+	}
+	return commons.TCP
+}
+
 type proxyKey struct {
 	Application string
 	PortNumber  uint16
+	Protocol    string
 }
 
 type proxyCache struct {
@@ -475,25 +516,34 @@ type proxyCache struct {
 	tenantID    string
 	tcpMuxPort  uint16
 	useTLS      bool
+	sessions    *svcproxy.SessionPool
+	credentials *auth.MuxCredentials
 	allowDirect bool
 }
 
-func newProxyCache(tenantID string, tcpMuxPort uint16, useTLS, allowDirect bool) *proxyCache {
+func newProxyCache(tenantID string, tcpMuxPort uint16, useTLS bool, muxSessions int, credentials *auth.MuxCredentials, allowDirect bool) *proxyCache {
+	var sessions *svcproxy.SessionPool
+	if muxSessions > 0 {
+		sessions = svcproxy.NewSessionPool(muxSessions, dialMux(useTLS, credentials))
+	}
 	return &proxyCache{
 		mu:          &sync.Mutex{},
 		cache:       make(map[proxyKey]*proxy),
 		tenantID:    tenantID,
 		tcpMuxPort:  tcpMuxPort,
 		useTLS:      useTLS,
+		sessions:    sessions,
+		credentials: credentials,
 		allowDirect: allowDirect,
 	}
 }
 
 // Set returns true if the key was created and an error
-func (c *proxyCache) Set(application string, portNumber uint16, exports ...registry.ExportDetails) (bool, error) {
+func (c *proxyCache) Set(application string, portNumber uint16, protocol string, exports ...registry.ExportDetails) (bool, error) {
 	logger := plog.WithFields(log.Fields{
 		"application": application,
 		"portnumber":  portNumber,
+		"protocol":    protocol,
 	})
 
 	c.mu.Lock()
@@ -502,6 +552,7 @@ func (c *proxyCache) Set(application string, portNumber uint16, exports ...regis
 	key := proxyKey{
 		Application: application,
 		PortNumber:  portNumber,
+		Protocol:    protocol,
 	}
 
 	// check if the key exists
@@ -510,24 +561,54 @@ func (c *proxyCache) Set(application string, portNumber uint16, exports ...regis
 
 		logger.Debug("Setting up new proxy")
 
-		// start the listener on the provided port
-		listener, err := net.Listen("tcp4", fmt.Sprintf(":%d", portNumber))
-		if err != nil {
-			logger.WithError(err).Debug("Could not open port")
-			return false, err
-		}
+		var err error
+		if protocol == commons.UDP {
+
+			// start the packet listener on the provided port
+			var conn net.PacketConn
+			conn, err = net.ListenPacket("udp4", fmt.Sprintf(":%d", portNumber))
+			if err != nil {
+				logger.WithError(err).Debug("Could not open port")
+				return false, err
+			}
 
-		logger.Debug("Started port listener")
-
-		// create the proxy
-		prxy, err = newProxy(
-			fmt.Sprintf("%s-%d", application, portNumber),
-			fmt.Sprintf("%s-%s-%d", c.tenantID, application, portNumber),
-			c.tcpMuxPort,
-			c.useTLS,
-			listener,
-			c.allowDirect,
-		)
+			logger.Debug("Started port listener")
+
+			// create the proxy
+			prxy, err = newUDPProxy(
+				fmt.Sprintf("%s-%d", application, portNumber),
+				fmt.Sprintf("%s-%s-%d", c.tenantID, application, portNumber),
+				c.tcpMuxPort,
+				c.useTLS,
+				c.sessions,
+				c.credentials,
+				conn,
+				c.allowDirect,
+			)
+		} else {
+
+			// start the listener on the provided port
+			var listener net.Listener
+			listener, err = net.Listen("tcp4", fmt.Sprintf(":%d", portNumber))
+			if err != nil {
+				logger.WithError(err).Debug("Could not open port")
+				return false, err
+			}
+
+			logger.Debug("Started port listener")
+
+			// create the proxy
+			prxy, err = newProxy(
+				fmt.Sprintf("%s-%d", application, portNumber),
+				fmt.Sprintf("%s-%s-%d", c.tenantID, application, portNumber),
+				c.tcpMuxPort,
+				c.useTLS,
+				c.sessions,
+				c.credentials,
+				listener,
+				c.allowDirect,
+			)
+		}
 		if err != nil {
 			logger.WithError(err).Debug("Could not start proxy")
 			return false, err
@@ -544,8 +625,13 @@ func (c *proxyCache) Set(application string, portNumber uint16, exports ...regis
 		addresses[i] = addressTuple{
 			host:          export.HostIP,
 			containerAddr: fmt.Sprintf("%s:%d", export.PrivateIP, export.PortNumber),
+			instanceID:    export.InstanceID,
+			drainPeriod:   export.GetDrainPeriod(),
 		}
 	}
+	if len(exports) > 0 {
+		prxy.SetLoadBalancer(exports[0].LoadBalancer)
+	}
 	prxy.SetNewAddresses(addresses)
 
 	logger.Debug("Set exports for proxy")
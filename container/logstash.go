@@ -26,10 +26,11 @@ import (
 )
 
 //createFields makes the map of tags for the logstash config including the type
-func createFields(hostID string, hostIPs string, svcPath string, service *service.Service, instanceID string, logConfig *servicedefinition.LogConfig) map[string]string {
+func createFields(hostID string, hostIPs string, svcPath string, tenantID string, service *service.Service, instanceID string, logConfig *servicedefinition.LogConfig) map[string]string {
 	fields := make(map[string]string)
 	fields["type"] = logConfig.Type
 	fields["service"] = service.ID
+	fields["tenantid"] = tenantID
 	fields["instance"] = instanceID
 	fields["hostips"] = hostIPs
 	fields["poolid"] = service.PoolID
@@ -60,8 +61,24 @@ func formatTagsForConfFile(tags map[string]string) string {
 	return buffer.String()
 }
 
+// formatMultilineForConfFile returns the prospector settings that join the
+// lines of a log entry that do not match the parser's multiline start pattern
+// onto the previous line
+func formatMultilineForConfFile(parser *servicedefinition.LogParser) string {
+	if parser == nil || parser.MultilineStart == "" {
+		return ""
+	}
+	// single quoted yaml strings escape quotes by doubling them
+	pattern := strings.Replace(parser.MultilineStart, "'", "''", -1)
+	return fmt.Sprintf(`
+      multiline:
+        pattern: '%s'
+        negate: true
+        match: after`, pattern)
+}
+
 // writeLogstashAgentConfig creates the logstash forwarder config file
-func writeLogstashAgentConfig(hostID string, hostIPs string, svcPath string, service *service.Service,
+func writeLogstashAgentConfig(hostID string, hostIPs string, svcPath string, tenantID string, service *service.Service,
 	instanceID string, logforwarderOptions LogforwarderOptions) error {
 
 	// generate a prospector configuration for each service log file
@@ -75,7 +92,8 @@ func writeLogstashAgentConfig(hostID string, hostIPs string, svcPath string, ser
         - %s
       fields: %s`
 		prospectorsConf = fmt.Sprintf(prospectorsConf, logConfig.Path,
-			formatTagsForConfFile(createFields(hostID, hostIPs, svcPath, service, instanceID, &logConfig)))
+			formatTagsForConfFile(createFields(hostID, hostIPs, svcPath, tenantID, service, instanceID, &logConfig)))
+		prospectorsConf += formatMultilineForConfFile(logConfig.Parser)
 	}
 
 	resourcePath := filepath.Dir(logforwarderOptions.Path)
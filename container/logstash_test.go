@@ -92,7 +92,7 @@ func TestMakeSureTagsMakeItIntoTheJson(t *testing.T) {
 
 	fileBeatBinary := filepath.Join(utils.ResourcesDir(), "logstash/filebeat")
 	logforwarderOptions := LogforwarderOptions{Enabled: true, Path: fileBeatBinary, ConfigFile: confFileLocation}
-	if err := writeLogstashAgentConfig("host1", "192.168.1.1", "service/service/", &service, "0", logforwarderOptions); err != nil {
+	if err := writeLogstashAgentConfig("host1", "192.168.1.1", "service/service/", "tenant1", &service, "0", logforwarderOptions); err != nil {
 		t.Errorf("Error writing config file %s", err)
 		return
 	}
@@ -147,8 +147,27 @@ func TestDontWriteToNilMap(t *testing.T) {
 
 	fileBeatBinary := filepath.Join(utils.ResourcesDir(), "logstash/filebeat")
 	logforwarderOptions := LogforwarderOptions{Enabled: true, Path: fileBeatBinary, ConfigFile: confFileLocation}
-	if err := writeLogstashAgentConfig("host1", "192.168.1.1", "service/service/", &service, "0", logforwarderOptions); err != nil {
+	if err := writeLogstashAgentConfig("host1", "192.168.1.1", "service/service/", "tenant1", &service, "0", logforwarderOptions); err != nil {
 		t.Errorf("Writing with empty tags produced an error %s", err)
 		return
 	}
 }
+
+func TestFormatMultilineForConfFile(t *testing.T) {
+	if conf := formatMultilineForConfFile(nil); conf != "" {
+		t.Errorf("Expected no multiline settings without a parser, got %q", conf)
+	}
+	parser := &servicedefinition.LogParser{Format: servicedefinition.ParseJSON}
+	if conf := formatMultilineForConfFile(parser); conf != "" {
+		t.Errorf("Expected no multiline settings without a start pattern, got %q", conf)
+	}
+	parser.MultilineStart = `^\d{4}-'`
+	expected := `
+      multiline:
+        pattern: '^\d{4}-'''
+        negate: true
+        match: after`
+	if conf := formatMultilineForConfFile(parser); conf != expected {
+		t.Errorf("Expected %q, got %q", expected, conf)
+	}
+}
@@ -14,40 +14,58 @@
 package container
 
 import (
+	"github.com/control-center/serviced/domain/pool"
+	"github.com/control-center/serviced/stats"
 	"github.com/zenoss/glog"
 	rest "github.com/zenoss/go-json-rest"
 
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
 	"time"
 )
 
+// metricSinksInterval is how often the forwarder checks for changes to the
+// metric sinks of its pool
+const metricSinksInterval = time.Minute
+
 // MetricForwarder contains all configuration parameters required to provide a
 // forward metrics inside a docker container.
 type MetricForwarder struct {
 	port               string
 	metricsRedirectURL string
 	listener           *net.Listener
+	sinks              *stats.Sinks
+	done               chan interface{}
 }
 
 var client = &http.Client{Timeout:time.Duration(5 * time.Second)}
 
-// NewMetricForwarder creates a new metric forwarder at port, all metrics are forwarded to metricsRedirectURL
-func NewMetricForwarder(port, metricsRedirectURL string) (config *MetricForwarder, err error) {
+// NewMetricForwarder creates a new metric forwarder at port, all metrics are forwarded to metricsRedirectURL.
+// If getSinks is set, metrics are also sent to the metric sinks that it returns.
+func NewMetricForwarder(port, metricsRedirectURL string, getSinks func() (pool.MetricSinks, error)) (config *MetricForwarder, err error) {
 	if len(port) < 4 {
 		return nil, fmt.Errorf("invalid port specification: '%s'", port)
 	}
 	config = &MetricForwarder{
 		port:               port,
 		metricsRedirectURL: metricsRedirectURL,
+		sinks:              stats.NewSinks(),
+		done:               make(chan interface{}),
 	}
 	listener, err := net.Listen("tcp", port)
 	if err != nil {
 		return nil, err
 	}
 	config.listener = &listener
+	if getSinks != nil {
+		go config.sinks.Watch(metricSinksInterval, getSinks, config.done)
+	}
 	go config.loop()
 	return config, err
 }
@@ -59,7 +77,7 @@ func (forwarder *MetricForwarder) loop() {
 		rest.Route{
 			HttpMethod: "POST",
 			PathExp:    "/api/metrics/store",
-			Func:       postAPIMetricsStore(forwarder.metricsRedirectURL),
+			Func:       postAPIMetricsStore(forwarder.metricsRedirectURL, forwarder.sinks),
 		},
 	}
 
@@ -73,16 +91,33 @@ func (forwarder *MetricForwarder) Close() error {
 	if forwarder != nil && forwarder.listener != nil {
 		(*forwarder.listener).Close()
 		forwarder.listener = nil
+		close(forwarder.done)
 	}
 	return nil
 }
 
 // postAPIMetricsStore redirects the post request to the configured address
 // Any additional parameters should be encoded in the redirect url.  For
-// example, encode the containers tenant and service id.
-func postAPIMetricsStore(redirectURL string) func(*rest.ResponseWriter, *rest.Request) {
+// example, encode the containers tenant and service id.  The metrics are
+// also sent to the sinks, tagged with the parameters of the redirect url.
+func postAPIMetricsStore(redirectURL string, sinks *stats.Sinks) func(*rest.ResponseWriter, *rest.Request) {
+	tags := make(map[string]string)
+	if u, err := url.Parse(redirectURL); err == nil {
+		for k, v := range u.Query() {
+			if len(v) > 0 {
+				tags[k] = v[0]
+			}
+		}
+	}
 	return func(w *rest.ResponseWriter, request *rest.Request) {
-		proxyRequest, _ := http.NewRequest(request.Method, redirectURL, request.Body)
+		body, err := ioutil.ReadAll(request.Body)
+		if err != nil {
+			glog.Errorf("Failed to read metrics: %s", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		sendToSinks(sinks, body, tags)
+		proxyRequest, _ := http.NewRequest(request.Method, redirectURL, bytes.NewReader(body))
 		for k, v := range request.Header {
 			proxyRequest.Header[k] = v
 		}
@@ -97,3 +132,29 @@ func postAPIMetricsStore(redirectURL string) func(*rest.ResponseWriter, *rest.Re
 		}
 	}
 }
+
+// sendToSinks sends the metrics posted to the forwarder to the metric sinks,
+// with the tags of the container.
+func sendToSinks(sinks *stats.Sinks, body []byte, tags map[string]string) {
+	if sinks == nil {
+		return
+	}
+	var payload struct {
+		Metrics []stats.Sample `json:"metrics"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		glog.V(2).Infof("Could not parse metrics for the metric sinks: %s", err)
+		return
+	}
+	for i := range payload.Metrics {
+		sampleTags := make(map[string]string, len(payload.Metrics[i].Tags)+len(tags))
+		for k, v := range payload.Metrics[i].Tags {
+			sampleTags[k] = v
+		}
+		for k, v := range tags {
+			sampleTags[k] = v
+		}
+		payload.Metrics[i].Tags = sampleTags
+	}
+	sinks.Send(payload.Metrics)
+}
@@ -21,9 +21,12 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/control-center/serviced/auth"
+	"github.com/control-center/serviced/domain/servicedefinition"
+	svcproxy "github.com/control-center/serviced/proxy"
 	"github.com/control-center/serviced/utils"
 	"github.com/zenoss/glog"
 )
@@ -72,24 +75,39 @@ The netcat (nc) command is particularly useful for this:
 */
 
 type addressTuple struct {
-	host          string // IP of the host on which the container is running
-	containerAddr string // Container IP:port of the remote service
+	host          string        // IP of the host on which the container is running
+	containerAddr string        // Container IP:port of the remote service
+	instanceID    int           // Instance of the remote service
+	drainPeriod   time.Duration // time the connections get to finish when it goes away
+}
+
+// key uniquely identifies the address for load balancing
+func (a addressTuple) key() string {
+	return a.host + "/" + a.containerAddr
 }
 
 type proxy struct {
-	name             string              // Name of the remote service
-	tenantEndpointID string              // Tenant endpoint ID
-	addresses        []addressTuple      // Public/container IP:Port of the remote service
-	tcpMuxPort       uint16              // the port to use for TCP Muxing, 0 is disabled
-	useTLS           bool                // use encryption over mux port
-	closing          chan chan error     // internal shutdown signal
-	newAddresses     chan []addressTuple // a stream of updates to the addresses
-	listener         net.Listener        // handle on the listening socket
-	allowDirectConn  bool                // allow container to container connections
+	name             string                // Name of the remote service
+	tenantEndpointID string                // Tenant endpoint ID
+	addresses        []addressTuple        // Public/container IP:Port of the remote service
+	tcpMuxPort       uint16                // the port to use for TCP Muxing, 0 is disabled
+	useTLS           bool                  // use encryption over mux port
+	sessions         *svcproxy.SessionPool // multiplexed connections to remote muxes, nil if disabled
+	credentials      *auth.MuxCredentials  // certificate presented to remote muxes, nil if not used
+	closing          chan chan error       // internal shutdown signal
+	newAddresses     chan []addressTuple   // a stream of updates to the addresses
+	listener         net.Listener          // handle on the listening socket
+	udp              *svcproxy.UDPProxy    // forwards the datagrams of a udp port
+	allowDirectConn  bool                  // allow container to container connections
+	balancer         *svcproxy.Balancer    // picks the address for each connection
+	health           *svcproxy.HealthTracker
+	drainer          *svcproxy.Drainer // closes the connections to removed addresses
+	mu               sync.Mutex
+	byKey            map[string]addressTuple // addresses by load balancing key
 }
 
 // Newproxy create a new proxy object. It starts listening on the prxy port asynchronously.
-func newProxy(name, tenantEndpointID string, tcpMuxPort uint16, useTLS bool, listener net.Listener, allowDirectConn bool) (p *proxy, err error) {
+func newProxy(name, tenantEndpointID string, tcpMuxPort uint16, useTLS bool, sessions *svcproxy.SessionPool, credentials *auth.MuxCredentials, listener net.Listener, allowDirectConn bool) (p *proxy, err error) {
 	if len(name) == 0 {
 		return nil, fmt.Errorf("prxy: name can not be empty")
 	}
@@ -99,14 +117,47 @@ func newProxy(name, tenantEndpointID string, tcpMuxPort uint16, useTLS bool, lis
 		addresses:        make([]addressTuple, 0),
 		tcpMuxPort:       tcpMuxPort,
 		useTLS:           useTLS,
+		sessions:         sessions,
+		credentials:      credentials,
 		listener:         listener,
 		allowDirectConn:  allowDirectConn,
+		health:           svcproxy.NewHealthTracker(),
+		drainer:          svcproxy.NewDrainer(),
+		byKey:            make(map[string]addressTuple),
 	}
+	p.balancer = svcproxy.NewBalancer(p.health)
 	p.newAddresses = make(chan []addressTuple, 2)
 	go p.listenAndproxy()
 	return p, nil
 }
 
+// newUDPProxy creates a proxy that forwards the datagrams received on conn,
+// with a session for each client address.
+func newUDPProxy(name, tenantEndpointID string, tcpMuxPort uint16, useTLS bool, sessions *svcproxy.SessionPool, credentials *auth.MuxCredentials, conn net.PacketConn, allowDirectConn bool) (p *proxy, err error) {
+	if len(name) == 0 {
+		return nil, fmt.Errorf("prxy: name can not be empty")
+	}
+	p = &proxy{
+		name:             name,
+		tenantEndpointID: tenantEndpointID,
+		addresses:        make([]addressTuple, 0),
+		tcpMuxPort:       tcpMuxPort,
+		useTLS:           useTLS,
+		sessions:         sessions,
+		credentials:      credentials,
+		allowDirectConn:  allowDirectConn,
+		health:           svcproxy.NewHealthTracker(),
+		drainer:          svcproxy.NewDrainer(),
+		byKey:            make(map[string]addressTuple),
+	}
+	p.balancer = svcproxy.NewBalancer(p.health)
+	p.newAddresses = make(chan []addressTuple, 2)
+	p.udp = svcproxy.NewUDPProxy(conn, p.dialDatagrams, svcproxy.DefaultUDPTimeout)
+	go p.udp.Serve()
+	go p.listenAndproxy()
+	return p, nil
+}
+
 // Name() returns the application name associated with the prxy
 func (p *proxy) Name() string {
 	return p.name
@@ -138,45 +189,89 @@ func (p *proxy) SetNewAddresses(addresses []addressTuple) {
 	p.newAddresses <- dest
 }
 
+// SetLoadBalancer sets the load balancing policy of the prxy
+func (p *proxy) SetLoadBalancer(config servicedefinition.LoadBalancer) {
+	p.balancer.Configure(config)
+}
+
 // Close() terminates the prxy; it can not be restarted.
 func (p *proxy) Close() error {
-	p.listener.Close()
+	p.closeListener()
 	errc := make(chan error)
 	p.closing <- errc
 	return <-errc
 }
 
+func (p *proxy) closeListener() {
+	if p.listener != nil {
+		p.listener.Close()
+	}
+	if p.udp != nil {
+		p.udp.Close()
+	}
+}
+
+// lookup returns the address with the given load balancing key
+func (p *proxy) lookup(key string) addressTuple {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.byKey[key]
+}
+
 // listenAndproxy listens, locally, on the prxy's specified Port. For each
 // incoming connection a goroutine running the prxy method is created.
 func (p *proxy) listenAndproxy() {
 
+	// udp proxies pick their addresses as new flows arrive
 	connections := make(chan net.Conn)
-	go func(lsocket net.Listener, conns chan net.Conn) {
-		for {
-			conn, err := lsocket.Accept()
-			if err != nil {
-				glog.Fatal("Error (net.Accept): ", err)
+	if p.listener != nil {
+		go func(lsocket net.Listener, conns chan net.Conn) {
+			for {
+				conn, err := lsocket.Accept()
+				if err != nil {
+					glog.Fatal("Error (net.Accept): ", err)
+				}
+				conns <- conn
 			}
-			conns <- conn
-		}
-	}(p.listener, connections)
+		}(p.listener, connections)
+	}
 
-	i := 0
 	for {
 		select {
 		case conn := <-connections:
-			if len(p.addresses) == 0 {
+			glog.V(1).Infof("choosing address from %v", p.addresses)
+			backend, ok := p.balancer.Pick(conn.RemoteAddr().String())
+			if !ok {
 				glog.Warningf("No remote services available for prxying %v", p)
 				conn.Close()
 				continue
 			}
-			i++
-			// round robin connections to list of addresses
-			glog.V(1).Infof("choosing address from %v", p.addresses)
-			go p.prxy(conn, p.addresses[i%len(p.addresses)])
+			go p.prxy(conn, p.lookup(backend.Key))
 		case p.addresses = <-p.newAddresses:
+			addresses := make(map[string]addressTuple)
+			backends := make([]svcproxy.Backend, len(p.addresses))
+			for i, address := range p.addresses {
+				addresses[address.key()] = address
+				backends[i] = svcproxy.Backend{Key: address.key(), InstanceID: address.instanceID}
+			}
+			p.mu.Lock()
+			removed := p.byKey
+			p.byKey = addresses
+			p.mu.Unlock()
+			p.balancer.Set(backends)
+
+			// give the open connections to the addresses that went away
+			// time to finish
+			for key, address := range removed {
+				if _, ok := addresses[key]; !ok {
+					p.drainer.Drain(key, address.drainPeriod)
+				}
+			}
+			for key := range addresses {
+				p.drainer.Restore(key)
+			}
 		case errc := <-p.closing:
-			p.listener.Close()
+			p.closeListener()
 			errc <- nil
 			return
 		}
@@ -194,13 +289,67 @@ func getPort(addr string) (int, error) {
 
 // prxy takes an established local connection, Dials the remote address specified
 // by the proxy structure and then copies data to and from the resulting pair
-// of endpoints.
+// of endpoints.  It returns once the connection is closed.
 func (p *proxy) prxy(local net.Conn, address addressTuple) {
+	defer p.balancer.Release(address.key())
+
+	remote, err := p.dial(address, false)
+	if err != nil {
+		return
+	}
+	remote = p.drainer.Track(address.key(), remote)
+
+	glog.V(2).Infof("Using hostAgent:%v to prxy %v<->%v<->%v<->%v",
+		remote.RemoteAddr(), local.LocalAddr(), local.RemoteAddr(), remote.LocalAddr(), address)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func(address string) {
+		defer wg.Done()
+		defer local.Close()
+		defer remote.Close()
+		io.Copy(local, remote)
+		glog.V(2).Infof("Closing hostAgent:%v to prxy %v<->%v<->%v<->%v",
+			remote.RemoteAddr(), local.LocalAddr(), local.RemoteAddr(), remote.LocalAddr(), address)
+	}(address.containerAddr)
+	go func(address string) {
+		defer wg.Done()
+		defer local.Close()
+		defer remote.Close()
+		io.Copy(remote, local)
+		glog.V(2).Infof("closing hostAgent:%v to prxy %v<->%v<->%v<->%v",
+			remote.RemoteAddr(), local.LocalAddr(), local.RemoteAddr(), remote.LocalAddr(), address)
+	}(address.containerAddr)
+	wg.Wait()
+}
 
+// dialDatagrams picks the address for a new udp flow and dials it.
+func (p *proxy) dialDatagrams(client net.Addr) (net.Conn, func(), error) {
+	backend, ok := p.balancer.Pick(client.String())
+	if !ok {
+		glog.Warningf("No remote services available for prxying %v", p)
+		return nil, nil, fmt.Errorf("no remote services available for %s", p.name)
+	}
+	release := func() { p.balancer.Release(backend.Key) }
+	remote, err := p.dial(p.lookup(backend.Key), true)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	return p.drainer.Track(backend.Key, remote), release, nil
+}
+
+// dial connects to the address, either directly if the container is local or
+// through the mux of its host.  If udp is set, the connection sends and
+// receives datagrams.
+func (p *proxy) dial(address addressTuple, udp bool) (net.Conn, error) {
 	var (
 		remote net.Conn
 		err    error
 	)
+	network := "tcp4"
+	if udp {
+		network = "udp4"
+	}
 	glog.V(2).Infof("Setting up proxy for %#v", address)
 	isLocalContainer := false
 	localAddr := address.containerAddr
@@ -248,13 +397,30 @@ func (p *proxy) prxy(local net.Conn, address addressTuple) {
 		muxAddrPacked, err = utils.PackTCPAddressString(address.containerAddr)
 		if err != nil {
 			glog.Errorf("Container address is invalid. Can't create proxy: %s", address.containerAddr)
-			return
+			return nil, err
 		}
 		select {
 		case token = <-auth.AuthToken(nil):
 		case <-time.After(tokenTimeout):
 			glog.Error("Unable to retrieve authentication token with 30 seconds")
-			return
+			return nil, fmt.Errorf("timed out waiting for an authentication token")
+		}
+	}
+
+	// Streams share the connections to the mux when it supports sessions
+	if !isLocalContainer && p.sessions != nil {
+		glog.V(2).Infof("opening stream to remote => %s", muxAddr)
+		remote, err = p.sessions.Dial(muxAddr, muxAddrPacked, udp, token)
+		if err == nil {
+			p.health.Succeeded(address.key())
+			if udp {
+				remote = svcproxy.NewDatagramConn(remote)
+			}
+			return remote, nil
+		} else if err != svcproxy.ErrSessionUnsupported {
+			glog.Errorf("Error opening mux stream: %s", err)
+			p.health.Failed(address.key())
+			return nil, err
 		}
 	}
 
@@ -263,18 +429,24 @@ func (p *proxy) prxy(local net.Conn, address addressTuple) {
 	switch {
 	case isLocalContainer:
 		glog.V(2).Infof("dialing local addr=> %s", localAddr)
-		remote, err = net.Dial("tcp4", localAddr)
+		remote, err = net.Dial(network, localAddr)
 		if err != nil {
 			glog.Errorf("Error Local (net.Dial): %s", err)
-			return
+			p.health.Failed(address.key())
+			return nil, err
 		}
 	case p.useTLS:
 		glog.V(2).Infof("dialing remote tls => %s", muxAddr)
-		config := tls.Config{InsecureSkipVerify: true}
-		tlsConn, err := tls.Dial("tcp4", muxAddr, &config)
+		config, err := muxTLSConfig(p.credentials, muxAddr)
+		if err != nil {
+			glog.Errorf("Error TLS configuration: %s", err)
+			return nil, err
+		}
+		tlsConn, err := tls.Dial("tcp4", muxAddr, config)
 		if err != nil {
 			glog.Errorf("Error TLS (net.Dial): %s", err)
-			return
+			p.health.Failed(address.key())
+			return nil, err
 		}
 		remote = tlsConn // cast it to the net.Conn interface
 		cipher := tlsConn.ConnectionState().CipherSuite
@@ -284,29 +456,53 @@ func (p *proxy) prxy(local net.Conn, address addressTuple) {
 		remote, err = net.Dial("tcp4", muxAddr)
 		if err != nil {
 			glog.Errorf("Error Remote (net.Dial): %s", err)
-			return
+			p.health.Failed(address.key())
+			return nil, err
 		}
 	}
+	p.health.Succeeded(address.key())
 
 	// If this is not a local container, write the mux header
 	if token != "" && len(muxAddrPacked) > 0 {
-		auth.AddSignedMuxHeader(remote, muxAddrPacked, token)
+		if !udp {
+			auth.AddSignedMuxHeader(remote, muxAddrPacked, token)
+		} else if err := auth.AddSignedUDPMuxHeader(remote, muxAddrPacked, token); err != nil {
+			glog.Errorf("Unable to send mux header: %s", err)
+			remote.Close()
+			return nil, err
+		} else {
+			// datagrams are framed to cross the mux
+			remote = svcproxy.NewDatagramConn(remote)
+		}
 	}
+	return remote, nil
+}
 
-	glog.V(2).Infof("Using hostAgent:%v to prxy %v<->%v<->%v<->%v",
-		remote.RemoteAddr(), local.LocalAddr(), local.RemoteAddr(), remote.LocalAddr(), address)
-	go func(address string) {
-		defer local.Close()
-		defer remote.Close()
-		io.Copy(local, remote)
-		glog.V(2).Infof("Closing hostAgent:%v to prxy %v<->%v<->%v<->%v",
-			remote.RemoteAddr(), local.LocalAddr(), local.RemoteAddr(), remote.LocalAddr(), address)
-	}(address.containerAddr)
-	go func(address string) {
-		defer local.Close()
-		defer remote.Close()
-		io.Copy(remote, local)
-		glog.V(2).Infof("closing hostAgent:%v to prxy %v<->%v<->%v<->%v",
-			remote.RemoteAddr(), local.LocalAddr(), local.RemoteAddr(), remote.LocalAddr(), address)
-	}(address.containerAddr)
+// dialMux returns the function that session pools use to connect to a remote
+// mux.
+func dialMux(useTLS bool, credentials *auth.MuxCredentials) func(address string) (net.Conn, error) {
+	return func(address string) (net.Conn, error) {
+		if useTLS {
+			config, err := muxTLSConfig(credentials, address)
+			if err != nil {
+				return nil, err
+			}
+			return tls.Dial("tcp4", address, config)
+		}
+		return net.Dial("tcp4", address)
+	}
+}
+
+// muxTLSConfig returns the TLS configuration for connecting to a remote mux,
+// which verifies the mux and presents the certificate of the tenant when
+// there are credentials.
+func muxTLSConfig(credentials *auth.MuxCredentials, muxAddr string) (*tls.Config, error) {
+	if credentials == nil {
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+	host, _, err := net.SplitHostPort(muxAddr)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.ClientConfig(host)
 }
@@ -18,6 +18,8 @@ package container
 import (
 	"strings"
 
+	"github.com/control-center/serviced/zzk/registry"
+	zkservice "github.com/control-center/serviced/zzk/service"
 	"github.com/zenoss/glog"
 
 	"net"
@@ -57,12 +59,12 @@ func TestNoMux(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Could not bind to a port for test")
 	}
-	prxy, err := newProxy("foo", "endpointfoo", 0, false, local, false)
+	prxy, err := newProxy("foo", "endpointfoo", 0, false, nil, nil, local, false)
 	if err != nil {
 		t.Fatalf("Could not create a prxy: %s", err)
 	}
 	host := strings.Split(remote.Addr().String(), ":")[0]
-	addresses := []addressTuple{addressTuple{host, remote.Addr().String()}}
+	addresses := []addressTuple{{host: host, containerAddr: remote.Addr().String()}}
 	prxy.SetNewAddresses(addresses)
 	stringChan := stringAcceptor(remote)
 	conn, err := net.Dial("tcp4", local.Addr().String())
@@ -92,3 +94,20 @@ func TestNoMux(t *testing.T) {
 		t.Fatalf("Timed out reading response from test port")
 	}
 }
+
+func TestImportProtocol(t *testing.T) {
+	udpExport := registry.ExportDetails{ExportBinding: zkservice.ExportBinding{Protocol: "udp"}}
+
+	if protocol := importProtocol(zkservice.ImportBinding{}); protocol != "tcp" {
+		t.Errorf("Expected tcp by default, got %s", protocol)
+	}
+	if protocol := importProtocol(zkservice.ImportBinding{}, udpExport); protocol != "udp" {
+		t.Errorf("Expected the protocol of the export, got %s", protocol)
+	}
+	if protocol := importProtocol(zkservice.ImportBinding{Protocol: "UDP"}); protocol != "udp" {
+		t.Errorf("Expected the protocol of the import, got %s", protocol)
+	}
+	if protocol := importProtocol(zkservice.ImportBinding{Protocol: "tcp"}, udpExport); protocol != "tcp" {
+		t.Errorf("Expected the import to override the export, got %s", protocol)
+	}
+}
@@ -85,6 +85,37 @@ func (_m *Connection) CreateEphemeral(path string, node client.Node) (string, er
 
 	return r0, r1
 }
+func (_m *Connection) CreateIfExists(path string, node client.Node) error {
+	ret := _m.Called(path, node)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, client.Node) error); ok {
+		r0 = rf(path, node)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+func (_m *Connection) CreateEphemeralIfExists(path string, node client.Node) (string, error) {
+	ret := _m.Called(path, node)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, client.Node) string); ok {
+		r0 = rf(path, node)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, client.Node) error); ok {
+		r1 = rf(path, node)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
 func (_m *Connection) EnsurePath(path string) error {
 	ret := _m.Called(path)
 
@@ -116,6 +147,34 @@ func (_m *Connection) Exists(path string) (bool, error) {
 
 	return r0, r1
 }
+func (_m *Connection) ExistsW(path string, done <-chan struct{}) (bool, <-chan client.Event, error) {
+	ret := _m.Called(path, done)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, <-chan struct{}) bool); ok {
+		r0 = rf(path, done)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 <-chan client.Event
+	if rf, ok := ret.Get(1).(func(string, <-chan struct{}) <-chan client.Event); ok {
+		r1 = rf(path, done)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(<-chan client.Event)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, <-chan struct{}) error); ok {
+		r2 = rf(path, done)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
 func (_m *Connection) Delete(path string) error {
 	ret := _m.Called(path)
 
@@ -205,29 +264,47 @@ func (_m *Connection) Set(path string, node client.Node) error {
 
 	return r0
 }
-func (_m *Connection) NewLock(path string) client.Lock {
+func (_m *Connection) NewLock(path string) (client.Lock, error) {
 	ret := _m.Called(path)
 
 	var r0 client.Lock
 	if rf, ok := ret.Get(0).(func(string) client.Lock); ok {
 		r0 = rf(path)
 	} else {
-		r0 = ret.Get(0).(client.Lock)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(client.Lock)
+		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(path)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
-func (_m *Connection) NewLeader(path string, data client.Node) client.Leader {
-	ret := _m.Called(path, data)
+func (_m *Connection) NewLeader(path string) (client.Leader, error) {
+	ret := _m.Called(path)
 
 	var r0 client.Leader
-	if rf, ok := ret.Get(0).(func(string, client.Node) client.Leader); ok {
-		r0 = rf(path, data)
+	if rf, ok := ret.Get(0).(func(string) client.Leader); ok {
+		r0 = rf(path)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(client.Leader)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(path)
 	} else {
-		r0 = ret.Get(0).(client.Leader)
+		r1 = ret.Error(1)
 	}
 
-	return r0
+	return r0, r1
 }
 
 func (_m *Connection) GetW(path string, node client.Node, done <-chan struct{}) (<-chan client.Event, error) {
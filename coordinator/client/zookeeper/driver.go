@@ -118,12 +118,14 @@ func (driver *Driver) GetConnection(dsn, basePath string) (client.Connection, er
 			}
 		}
 	}
+	trackSession(conn, dsnVal.Servers, basePath)
 	go func() {
 		for {
 			select {
 			case e, ok := <-event:
 				if !ok {
 					plog.WithField("event", e).Debug("zk event channel closed")
+					untrackSession(conn)
 					return
 				} else {
 					plog.WithField("event", e).Debug("zk state change event received")
@@ -0,0 +1,71 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zookeeper
+
+import (
+	"strings"
+	"sync"
+
+	zklib "github.com/control-center/go-zookeeper/zk"
+)
+
+// SessionState is the state of a connection to ZooKeeper
+type SessionState struct {
+	Servers    string
+	BasePath   string
+	State      string
+	HasSession bool
+}
+
+type session struct {
+	servers  string
+	basePath string
+}
+
+var (
+	sessionMu = &sync.Mutex{}
+	sessions  = make(map[*zklib.Conn]session)
+)
+
+// trackSession adds a connection to those returned by SessionStates.
+func trackSession(conn *zklib.Conn, servers []string, basePath string) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	sessions[conn] = session{servers: strings.Join(servers, ","), basePath: basePath}
+}
+
+// untrackSession removes a connection after it has been closed.
+func untrackSession(conn *zklib.Conn) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	delete(sessions, conn)
+}
+
+// SessionStates returns the state of each ZooKeeper connection that is open
+// in this process.
+func SessionStates() []SessionState {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	result := make([]SessionState, 0, len(sessions))
+	for conn, s := range sessions {
+		state := conn.State()
+		result = append(result, SessionState{
+			Servers:    s.servers,
+			BasePath:   s.basePath,
+			State:      state.String(),
+			HasSession: state == zklib.StateHasSession,
+		})
+	}
+	return result
+}
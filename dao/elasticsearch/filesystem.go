@@ -410,6 +410,10 @@ func (dao *ControlPlaneDao) ListSnapshots(serviceID string, snapshots *[]model.S
 				Created:     info.Created,
 				Invalid:     false,
 			}
+			if info.Hooks != nil {
+				newInfo.FreezeWindow = info.Hooks.FreezeWindow()
+				newInfo.Hooks = info.Hooks.Results
+			}
 		}
 		*snapshots = append(*snapshots, newInfo)
 	}
@@ -470,5 +474,9 @@ func (dao *ControlPlaneDao) GetSnapshotByServiceIDAndTag(request model.SnapshotB
 		Tags:        info.Tags,
 		Created:     info.Created,
 	}
+	if info.Hooks != nil {
+		snapshot.FreezeWindow = info.Hooks.FreezeWindow()
+		snapshot.Hooks = info.Hooks.Results
+	}
 	return nil
 }
@@ -75,12 +75,14 @@ type BackupFile struct {
 }
 
 type SnapshotInfo struct {
-	SnapshotID  string
-	TenantID    string
-	Description string
-	Tags        []string
-	Created     time.Time
-	Invalid     bool
+	SnapshotID   string
+	TenantID     string
+	Description  string
+	Tags         []string
+	Created      time.Time
+	Invalid      bool
+	FreezeWindow time.Duration                          // how long services were paused, if recorded
+	Hooks        []servicedefinition.SnapshotHookResult // quiesce hooks that ran for the snapshot
 }
 
 func (s SnapshotInfo) String() string {
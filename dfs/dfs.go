@@ -23,6 +23,7 @@ import (
 	"github.com/control-center/serviced/dfs/registry"
 	"github.com/control-center/serviced/domain/pool"
 	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/domain/servicedefinition"
 	"github.com/control-center/serviced/domain/servicetemplate"
 	"github.com/control-center/serviced/logging"
 	"github.com/control-center/serviced/volume"
@@ -80,6 +81,15 @@ type DFS interface {
 	DfPath(path string, excludes []string) (uint64, error)
 	// Verifies that the mount points are correct. Returns nil if there are no problems.
 	VerifyTenantMounts(tenantID string) (err error)
+	// Usage returns the number of bytes of application data used by a tenant
+	Usage(tenantID string) (uint64, error)
+	// EnforceQuota caps the size of an application's volume, if supported by
+	// the storage driver
+	EnforceQuota(tenantID string, limit uint64) error
+	// SaveSnapshotHooks records the hooks that ran for a snapshot
+	SaveSnapshotHooks(snapshotID string, record SnapshotHookRecord) error
+	// CollectRegistryGarbage removes unreferenced images from the registry
+	CollectRegistryGarbage(images, keep []string, dryRun bool) (*RegistryGCReport, error)
 }
 
 var _ = DFS(&DistributedFilesystem{})
@@ -100,6 +110,23 @@ type SnapshotInfo struct {
 	*volume.SnapshotInfo
 	Images   []string
 	Services []service.Service
+	Hooks    *SnapshotHookRecord // nil if the snapshot did not record any hooks
+}
+
+// SnapshotHookRecord describes the quiesce window of a snapshot and the hooks
+// that ran on each service instance.
+type SnapshotHookRecord struct {
+	Frozen  time.Time // when all services reported as paused
+	Thawed  time.Time // when the services were asked to resume
+	Results []servicedefinition.SnapshotHookResult
+}
+
+// FreezeWindow returns how long the services were paused for the snapshot.
+func (r SnapshotHookRecord) FreezeWindow() time.Duration {
+	if r.Frozen.IsZero() || r.Thawed.Before(r.Frozen) {
+		return 0
+	}
+	return r.Thawed.Sub(r.Frozen)
 }
 
 // DistributedFilesystem manages disk and registry data for all system
@@ -112,9 +139,10 @@ type DistributedFilesystem struct {
 	// FIXME: replace this with a NFS server, instead of restarting the
 	// daemon
 	net     storage.StorageDriver
-	timeout time.Duration
-	locker  *csync.TimedMutex
-	tmp     string // tmp directory where backups are temporarily spooled
+	timeout   time.Duration
+	locker    *csync.TimedMutex
+	tmp       string                  // tmp directory where backups are temporarily spooled
+	manifests registry.ManifestClient // client to the registry's manifest api
 }
 
 // ImageInfo provides meta info about a Docker image
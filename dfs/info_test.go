@@ -22,6 +22,7 @@ import (
 
 	. "github.com/control-center/serviced/dfs"
 	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/domain/servicedefinition"
 	"github.com/control-center/serviced/volume"
 	volumemocks "github.com/control-center/serviced/volume/mocks"
 	. "gopkg.in/check.v1"
@@ -117,7 +118,24 @@ func (s *DFSTestSuite) TestInfo_Success(c *C) {
 	err = json.NewEncoder(imgsbuffer).Encode(imgs)
 	c.Assert(err, IsNil)
 	vol.On("ReadMetadata", "snapshot-label", ImagesMetadataFile).Return(&NopCloser{imgsbuffer}, nil)
+	hooks := &SnapshotHookRecord{
+		Frozen: time.Now().UTC(),
+		Thawed: time.Now().UTC(),
+		Results: []servicedefinition.SnapshotHookResult{
+			{
+				ServiceID: "test-service-1",
+				Name:      "flush",
+				Phase:     servicedefinition.HookPreFreeze,
+				Started:   time.Now().UTC(),
+				Duration:  time.Second,
+			},
+		},
+	}
+	hooksbuffer := bytes.NewBufferString("")
+	err = json.NewEncoder(hooksbuffer).Encode(hooks)
+	c.Assert(err, IsNil)
+	vol.On("ReadMetadata", "snapshot-label", HooksMetadataFile).Return(&NopCloser{hooksbuffer}, nil)
 	info, err := s.dfs.Info("test-snapshot-label")
 	c.Assert(err, IsNil)
-	c.Assert(info, DeepEquals, &SnapshotInfo{vinfo, imgs, svcs})
+	c.Assert(info, DeepEquals, &SnapshotInfo{vinfo, imgs, svcs, hooks})
 }
@@ -424,3 +424,75 @@ func (_m *DFS) VerifyTenantMounts(tenantid string) error {
 
 	return r0
 }
+
+// Usage provides a mock function with given fields: tenantID
+func (_m *DFS) Usage(tenantID string) (uint64, error) {
+	ret := _m.Called(tenantID)
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(string) uint64); ok {
+		r0 = rf(tenantID)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(tenantID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EnforceQuota provides a mock function with given fields: tenantID, limit
+func (_m *DFS) EnforceQuota(tenantID string, limit uint64) error {
+	ret := _m.Called(tenantID, limit)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, uint64) error); ok {
+		r0 = rf(tenantID, limit)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveSnapshotHooks provides a mock function with given fields: snapshotID, record
+func (_m *DFS) SaveSnapshotHooks(snapshotID string, record dfs.SnapshotHookRecord) error {
+	ret := _m.Called(snapshotID, record)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, dfs.SnapshotHookRecord) error); ok {
+		r0 = rf(snapshotID, record)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CollectRegistryGarbage provides a mock function with given fields: images, keep, dryRun
+func (_m *DFS) CollectRegistryGarbage(images []string, keep []string, dryRun bool) (*dfs.RegistryGCReport, error) {
+	ret := _m.Called(images, keep, dryRun)
+
+	var r0 *dfs.RegistryGCReport
+	if rf, ok := ret.Get(0).(func([]string, []string, bool) *dfs.RegistryGCReport); ok {
+		r0 = rf(images, keep, dryRun)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dfs.RegistryGCReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]string, []string, bool) error); ok {
+		r1 = rf(images, keep, dryRun)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
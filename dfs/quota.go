@@ -0,0 +1,49 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dfs
+
+import (
+	"github.com/control-center/serviced/volume"
+	"github.com/zenoss/glog"
+)
+
+// Usage returns the number of bytes of application data used by a tenant
+func (dfs *DistributedFilesystem) Usage(tenantID string) (uint64, error) {
+	vol, err := dfs.disk.Get(tenantID)
+	if err != nil {
+		glog.Errorf("Could not get volume for tenant %s: %s", tenantID, err)
+		return 0, err
+	}
+	used, err := volume.BytesUsed(vol)
+	if err != nil {
+		glog.Errorf("Could not compute storage used by tenant %s: %s", tenantID, err)
+		return 0, err
+	}
+	return used, nil
+}
+
+// EnforceQuota caps the size of an application's volume, if supported by the
+// storage driver.  Returns volume.ErrQuotaUnsupported if the driver cannot
+// enforce the limit, or volume.ErrNoShrinkage if the volume is already larger
+// than the limit.
+func (dfs *DistributedFilesystem) EnforceQuota(tenantID string, limit uint64) error {
+	if err := volume.EnforceQuota(dfs.disk, tenantID, limit); err != nil {
+		if err != volume.ErrQuotaUnsupported && err != volume.ErrNoShrinkage {
+			glog.Errorf("Could not enforce storage quota of %d bytes for tenant %s: %s", limit, tenantID, err)
+		}
+		return err
+	}
+	glog.Infof("Enforcing storage quota of %d bytes for tenant %s", limit, tenantID)
+	return nil
+}
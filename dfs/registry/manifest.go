@@ -0,0 +1,117 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/control-center/serviced/domain/registry"
+)
+
+const manifestV2MediaType = "application/vnd.docker.distribution.manifest.v2+json"
+
+var (
+	// ErrManifestNotFound is returned when the registry has no manifest for
+	// the requested tag or digest.
+	ErrManifestNotFound = errors.New("registry: manifest not found")
+)
+
+// ManifestClient looks up and removes image manifests on a v2 docker registry.
+type ManifestClient interface {
+	// GetManifest returns the manifest of repo:tag, where repo includes the
+	// library (e.g. tenantid/reponame)
+	GetManifest(repo, tag string) (*registry.Manifest, error)
+	// DeleteManifest removes a manifest, and every tag that points to it,
+	// from the registry
+	DeleteManifest(repo, digest string) error
+}
+
+// HTTPManifestClient talks to the registry's HTTP API.
+type HTTPManifestClient struct {
+	address string
+	client  *http.Client
+}
+
+var _ = ManifestClient(&HTTPManifestClient{})
+
+// NewManifestClient returns a client for the registry at address (e.g.
+// localhost:5000).
+func NewManifestClient(address string) *HTTPManifestClient {
+	return &HTTPManifestClient{
+		address: address,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// GetManifest implements ManifestClient
+func (c *HTTPManifestClient) GetManifest(repo, tag string) (*registry.Manifest, error) {
+	req, err := http.NewRequest("GET", c.url(repo, tag), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", manifestV2MediaType)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrManifestNotFound
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("registry: could not get manifest for %s:%s: %s", repo, tag, resp.Status)
+	}
+
+	var body struct {
+		Config registry.Blob
+		Layers []registry.Blob
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	manifest := &registry.Manifest{Digest: resp.Header.Get("Docker-Content-Digest")}
+	if body.Config.Digest != "" {
+		manifest.Blobs = append(manifest.Blobs, body.Config)
+	}
+	manifest.Blobs = append(manifest.Blobs, body.Layers...)
+	return manifest, nil
+}
+
+// DeleteManifest implements ManifestClient
+func (c *HTTPManifestClient) DeleteManifest(repo, digest string) error {
+	req, err := http.NewRequest("DELETE", c.url(repo, digest), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusAccepted, http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return ErrManifestNotFound
+	default:
+		return fmt.Errorf("registry: could not delete manifest %s from %s: %s", digest, repo, resp.Status)
+	}
+}
+
+func (c *HTTPManifestClient) url(repo, reference string) string {
+	return fmt.Sprintf("http://%s/v2/%s/manifests/%s", c.address, repo, reference)
+}
@@ -0,0 +1,86 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/control-center/serviced/domain/registry"
+	. "gopkg.in/check.v1"
+)
+
+type ManifestClientSuite struct {
+	server  *httptest.Server
+	client  *HTTPManifestClient
+	deleted []string
+}
+
+var _ = Suite(&ManifestClientSuite{})
+
+func (s *ManifestClientSuite) SetUpTest(c *C) {
+	s.deleted = []string{}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v2/tenant/repo/manifests/latest":
+			c.Check(r.Header.Get("Accept"), Equals, manifestV2MediaType)
+			w.Header().Set("Docker-Content-Digest", "sha256:aaa")
+			fmt.Fprint(w, `{
+				"schemaVersion": 2,
+				"config": {"digest": "sha256:cfg", "size": 7},
+				"layers": [{"digest": "sha256:l1", "size": 100}, {"digest": "sha256:l2", "size": 20}]
+			}`)
+		case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/v2/tenant/repo/manifests/"):
+			s.deleted = append(s.deleted, strings.TrimPrefix(r.URL.Path, "/v2/tenant/repo/manifests/"))
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	s.client = NewManifestClient(strings.TrimPrefix(s.server.URL, "http://"))
+}
+
+func (s *ManifestClientSuite) TearDownTest(c *C) {
+	s.server.Close()
+}
+
+func (s *ManifestClientSuite) TestGetManifest(c *C) {
+	manifest, err := s.client.GetManifest("tenant/repo", "latest")
+	c.Assert(err, IsNil)
+	c.Assert(manifest, DeepEquals, &registry.Manifest{
+		Digest: "sha256:aaa",
+		Blobs: []registry.Blob{
+			{Digest: "sha256:cfg", Size: 7},
+			{Digest: "sha256:l1", Size: 100},
+			{Digest: "sha256:l2", Size: 20},
+		},
+	})
+
+	manifest, err = s.client.GetManifest("tenant/repo", "missing")
+	c.Assert(manifest, IsNil)
+	c.Assert(err, Equals, ErrManifestNotFound)
+}
+
+func (s *ManifestClientSuite) TestDeleteManifest(c *C) {
+	err := s.client.DeleteManifest("tenant/repo", "sha256:aaa")
+	c.Assert(err, IsNil)
+	c.Assert(s.deleted, DeepEquals, []string{"sha256:aaa"})
+
+	err = s.client.DeleteManifest("other/repo", "sha256:aaa")
+	c.Assert(err, Equals, ErrManifestNotFound)
+}
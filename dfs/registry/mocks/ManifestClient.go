@@ -0,0 +1,57 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocks
+
+import "github.com/stretchr/testify/mock"
+
+import "github.com/control-center/serviced/domain/registry"
+
+type ManifestClient struct {
+	mock.Mock
+}
+
+func (_m *ManifestClient) DeleteManifest(repo string, digest string) error {
+	ret := _m.Called(repo, digest)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(repo, digest)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *ManifestClient) GetManifest(repo string, tag string) (*registry.Manifest, error) {
+	ret := _m.Called(repo, tag)
+
+	var r0 *registry.Manifest
+	if rf, ok := ret.Get(0).(func(string, string) *registry.Manifest); ok {
+		r0 = rf(repo, tag)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*registry.Manifest)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(repo, tag)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,343 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"path"
+	"sort"
+	"time"
+
+	"github.com/control-center/serviced/coordinator/client"
+	"github.com/control-center/serviced/dfs/docker"
+	"github.com/control-center/serviced/domain/registry"
+	"github.com/zenoss/glog"
+)
+
+const (
+	zkregistryreplica = "/docker/registry/replica"
+
+	// replicaRetryInterval is how long to wait before retrying a failed
+	// replication.
+	replicaRetryInterval = time.Minute
+)
+
+// ReplicaNode records the last time an image in the registry index was
+// replicated to the secondary registry.
+type ReplicaNode struct {
+	Image        registry.Image
+	ReplicatedAt time.Time
+	Error        string
+	version      interface{}
+}
+
+// Version implements client.Node
+func (node *ReplicaNode) Version() interface{} {
+	return node.version
+}
+
+// SetVersion implements client.Node
+func (node *ReplicaNode) SetVersion(version interface{}) {
+	node.version = version
+}
+
+// ReplicaStatus describes the replication of an image in the registry index.
+type ReplicaStatus struct {
+	Image        string
+	UUID         string
+	Replicated   bool // the secondary has the current version of the image
+	ReplicatedAt time.Time
+	Error        string
+}
+
+type replicaStatusByImage []ReplicaStatus
+
+func (s replicaStatusByImage) Len() int           { return len(s) }
+func (s replicaStatusByImage) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s replicaStatusByImage) Less(i, j int) bool { return s[i].Image < s[j].Image }
+
+// ReplicaConnector returns a connection to the coordinator of the secondary
+// master, where the registry index is replicated.
+type ReplicaConnector func() (client.Connection, error)
+
+// RegistryReplicator pushes the images in the primary docker registry to a
+// secondary registry, and copies their registry index nodes to the secondary
+// master's coordinator, so that a standby master can take over without
+// pulling every image from upstream.
+type RegistryReplicator struct {
+	// connection to the coordinator
+	conn client.Connection
+	// docker client
+	docker docker.Docker
+	// address to the primary registry (e.g. localhost:5000)
+	address string
+	// address to the secondary registry (e.g. standby:5000)
+	replica string
+	// connects to the secondary coordinator; nil if the index is not
+	// replicated
+	connect ReplicaConnector
+}
+
+// NewRegistryReplicator instantiates a new registry replicator.  If connect is
+// nil, only the images are replicated.
+func NewRegistryReplicator(docker docker.Docker, address, replica string, connect ReplicaConnector) *RegistryReplicator {
+	return &RegistryReplicator{docker: docker, address: address, replica: replica, connect: connect}
+}
+
+// SetConnection implements zzk.Listener
+func (r *RegistryReplicator) SetConnection(conn client.Connection) {
+	r.conn = conn
+}
+
+// GetPath implements zzk.Listener
+func (r *RegistryReplicator) GetPath(nodes ...string) string {
+	return path.Join(append([]string{zkregistrytags}, nodes...)...)
+}
+
+// Ready implements zzk.Listener
+func (r *RegistryReplicator) Ready() error {
+	if err := r.conn.CreateDir(zkregistryreplica); err != nil && err != client.ErrNodeExists {
+		glog.Errorf("Could not create replica path %s: %s", zkregistryreplica, err)
+		return err
+	}
+	return nil
+}
+
+// Done implements zzk.Listener
+func (r *RegistryReplicator) Done() {}
+
+// PostProcess removes the replica records (and replicated index nodes) of
+// images that are no longer in the registry index.
+func (r *RegistryReplicator) PostProcess(p map[string]struct{}) {
+	ids, err := r.conn.Children(zkregistryreplica)
+	if err != nil {
+		glog.Warningf("Could not look up registry replicas: %s", err)
+		return
+	}
+	var replicaConn client.Connection
+	for _, id := range ids {
+		if _, ok := p[id]; ok {
+			continue
+		}
+		// the image may still be in the index if its listener exited early
+		if ok, err := r.conn.Exists(r.GetPath(id)); err != nil || ok {
+			continue
+		}
+		if r.connect != nil {
+			if replicaConn == nil {
+				if replicaConn, err = r.connect(); err != nil {
+					glog.Warningf("Could not connect to the secondary coordinator: %s", err)
+					return
+				}
+				defer replicaConn.Close()
+			}
+			if err := DeleteRegistryImage(replicaConn, id); err != nil && err != client.ErrNoNode {
+				glog.Warningf("Could not remove image %s from the secondary registry index: %s", id, err)
+				continue
+			}
+		}
+		if err := r.conn.Delete(path.Join(zkregistryreplica, id)); err != nil && err != client.ErrNoNode {
+			glog.Warningf("Could not remove replica record for image %s: %s", id, err)
+		}
+	}
+}
+
+// Spawn watches a registry image and replicates it to the secondary registry
+// once it has been pushed to the primary.
+func (r *RegistryReplicator) Spawn(shutdown <-chan interface{}, id string) {
+	imagepath := r.GetPath(id)
+	replicapath := path.Join(zkregistryreplica, id)
+	done := make(chan struct{})
+	defer func(channel *chan struct{}) { close(*channel) }(&done)
+	for {
+		var node RegistryImageNode
+		evt, err := r.conn.GetW(imagepath, &node, done)
+		if err != nil {
+			glog.Errorf("Could not look up node at %s: %s", imagepath, err)
+			return
+		}
+
+		var retry <-chan time.Time
+		if node.PushedAt.Unix() > 0 {
+			var rnode ReplicaNode
+			if err := r.conn.Get(replicapath, &rnode); err != nil && err != client.ErrNoNode {
+				glog.Errorf("Could not look up replica at %s: %s", replicapath, err)
+				return
+			}
+			if !isReplicated(&node, &rnode) {
+				rnode.Image = node.Image
+				if err := r.replicate(&node); err != nil {
+					glog.Warningf("Could not replicate image %s: %s", node.Image.String(), err)
+					rnode.Error = err.Error()
+					retry = time.After(replicaRetryInterval)
+				} else {
+					glog.Infof("Replicated image %s to %s", node.Image.String(), r.replica)
+					rnode.ReplicatedAt = time.Now().UTC()
+					rnode.Error = ""
+				}
+				if err := setReplicaNode(r.conn, replicapath, &rnode); err != nil {
+					glog.Errorf("Could not update replica at %s: %s", replicapath, err)
+					return
+				}
+			}
+		}
+
+		// a removed replica record means that a resync was requested
+		_, revt, err := r.conn.ExistsW(replicapath, done)
+		if err != nil {
+			glog.Errorf("Could not watch replica at %s: %s", replicapath, err)
+			return
+		}
+
+		select {
+		case <-evt:
+		case <-revt:
+		case <-retry:
+		case <-shutdown:
+			return
+		}
+
+		close(done)
+		done = make(chan struct{})
+	}
+}
+
+// replicate pushes the image to the secondary registry and copies its index
+// node to the secondary coordinator.
+func (r *RegistryReplicator) replicate(node *RegistryImageNode) error {
+	img, err := r.docker.FindImage(node.Image.UUID)
+	if err != nil {
+		registrypath := path.Join(r.address, node.Image.String())
+		if err := r.docker.PullImage(registrypath); err != nil {
+			return err
+		}
+		if img, err = r.docker.FindImage(registrypath); err != nil {
+			return err
+		}
+	}
+	replicapath := path.Join(r.replica, node.Image.String())
+	if err := r.docker.TagImage(img.ID, replicapath); err != nil {
+		return err
+	}
+	// only the replica tag is removed; the image is still tagged for the
+	// primary registry
+	defer r.docker.RemoveImage(replicapath)
+	if err := r.docker.PushImage(replicapath); err != nil {
+		return err
+	}
+	if r.connect != nil {
+		conn, err := r.connect()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if err := SetReplicaRegistryImage(conn, *node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isReplicated returns true if the replica record is current with the image
+// node.
+func isReplicated(node *RegistryImageNode, rnode *ReplicaNode) bool {
+	return rnode.Error == "" &&
+		rnode.ReplicatedAt.Unix() > 0 &&
+		rnode.Image.UUID == node.Image.UUID &&
+		rnode.Image.Hash == node.Image.Hash
+}
+
+// setReplicaNode creates or updates a replica record.
+func setReplicaNode(conn client.Connection, replicapath string, node *ReplicaNode) error {
+	var current ReplicaNode
+	if err := conn.Get(replicapath, &current); err == client.ErrNoNode {
+		return conn.Create(replicapath, node)
+	} else if err != nil {
+		return err
+	}
+	node.SetVersion(current.Version())
+	return conn.Set(replicapath, node)
+}
+
+// SetReplicaRegistryImage writes a registry index node, as it is on the
+// primary, into a secondary coordinator.  Unlike SetRegistryImage, the push
+// time is kept, since the image is already in the secondary's registry.
+func SetReplicaRegistryImage(conn client.Connection, node RegistryImageNode) error {
+	leaderpath := path.Join(zkregistryrepos, node.Image.Library, node.Image.Repo)
+	if err := conn.CreateDir(leaderpath); err != nil && err != client.ErrNodeExists {
+		glog.Errorf("Could not create repo path %s: %s", leaderpath, err)
+		return err
+	}
+	imagepath := path.Join(zkregistrytags, node.Image.ID())
+	replica := &RegistryImageNode{Image: node.Image, PushedAt: node.PushedAt}
+	var current RegistryImageNode
+	if err := conn.Get(imagepath, &current); err == client.ErrNoNode {
+		return conn.Create(imagepath, replica)
+	} else if err != nil {
+		return err
+	}
+	replica.SetVersion(current.Version())
+	return conn.Set(imagepath, replica)
+}
+
+// GetReplicationStatus returns the replication status of every image in the
+// registry index, sorted by image name.
+func GetReplicationStatus(conn client.Connection) ([]ReplicaStatus, error) {
+	ids, err := conn.Children(zkregistrytags)
+	if err == client.ErrNoNode {
+		return []ReplicaStatus{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	statuses := make([]ReplicaStatus, 0, len(ids))
+	for _, id := range ids {
+		var node RegistryImageNode
+		if err := conn.Get(path.Join(zkregistrytags, id), &node); err == client.ErrNoNode {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		status := ReplicaStatus{
+			Image: node.Image.String(),
+			UUID:  node.Image.UUID,
+		}
+		var rnode ReplicaNode
+		if err := conn.Get(path.Join(zkregistryreplica, id), &rnode); err == nil {
+			status.Replicated = isReplicated(&node, &rnode)
+			status.ReplicatedAt = rnode.ReplicatedAt
+			status.Error = rnode.Error
+		} else if err != client.ErrNoNode {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Sort(replicaStatusByImage(statuses))
+	return statuses, nil
+}
+
+// ResetReplication discards the replica records of every image, so that each
+// image is replicated again.
+func ResetReplication(conn client.Connection) error {
+	ids, err := conn.Children(zkregistryreplica)
+	if err == client.ErrNoNode {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := conn.Delete(path.Join(zkregistryreplica, id)); err != nil && err != client.ErrNoNode {
+			return err
+		}
+	}
+	return nil
+}
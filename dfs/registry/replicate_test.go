@@ -0,0 +1,84 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package registry
+
+import (
+	"errors"
+	"time"
+
+	"github.com/control-center/serviced/dfs/docker/mocks"
+	"github.com/control-center/serviced/domain/registry"
+	dockerclient "github.com/fsouza/go-dockerclient"
+	. "gopkg.in/check.v1"
+)
+
+var ErrTestPushFailed = errors.New("push failed")
+
+type RegistryReplicatorSuite struct {
+	docker     *mocks.Docker
+	replicator *RegistryReplicator
+	node       *RegistryImageNode
+}
+
+var _ = Suite(&RegistryReplicatorSuite{})
+
+func (s *RegistryReplicatorSuite) SetUpTest(c *C) {
+	s.docker = &mocks.Docker{}
+	s.replicator = NewRegistryReplicator(s.docker, "localhost:5000", "standby:5000", nil)
+	s.node = &RegistryImageNode{
+		Image: registry.Image{
+			Library: "tenant",
+			Repo:    "repo",
+			Tag:     "latest",
+			UUID:    "uuidvalue",
+			Hash:    "hashvalue",
+		},
+		PushedAt: time.Now(),
+	}
+}
+
+func (s *RegistryReplicatorSuite) TestReplicate_LocalImage(c *C) {
+	s.docker.On("FindImage", "uuidvalue").Return(&dockerclient.Image{ID: "uuidvalue"}, nil)
+	s.docker.On("TagImage", "uuidvalue", "standby:5000/tenant/repo:latest").Return(nil)
+	s.docker.On("PushImage", "standby:5000/tenant/repo:latest").Return(nil)
+	s.docker.On("RemoveImage", "standby:5000/tenant/repo:latest").Return(nil)
+	c.Assert(s.replicator.replicate(s.node), IsNil)
+	s.docker.AssertExpectations(c)
+	s.docker.AssertNotCalled(c, "PullImage", "localhost:5000/tenant/repo:latest")
+}
+
+func (s *RegistryReplicatorSuite) TestReplicate_PullImage(c *C) {
+	s.docker.On("FindImage", "uuidvalue").Return(nil, dockerclient.ErrNoSuchImage)
+	s.docker.On("PullImage", "localhost:5000/tenant/repo:latest").Return(nil)
+	s.docker.On("FindImage", "localhost:5000/tenant/repo:latest").Return(&dockerclient.Image{ID: "uuidvalue"}, nil)
+	s.docker.On("TagImage", "uuidvalue", "standby:5000/tenant/repo:latest").Return(nil)
+	s.docker.On("PushImage", "standby:5000/tenant/repo:latest").Return(ErrTestPushFailed)
+	s.docker.On("RemoveImage", "standby:5000/tenant/repo:latest").Return(nil)
+	c.Assert(s.replicator.replicate(s.node), Equals, ErrTestPushFailed)
+	s.docker.AssertExpectations(c)
+}
+
+func (s *RegistryReplicatorSuite) TestIsReplicated(c *C) {
+	rnode := &ReplicaNode{Image: s.node.Image}
+	c.Check(isReplicated(s.node, rnode), Equals, false)
+	rnode.ReplicatedAt = time.Now()
+	c.Check(isReplicated(s.node, rnode), Equals, true)
+	rnode.Error = "push failed"
+	c.Check(isReplicated(s.node, rnode), Equals, false)
+	rnode.Error = ""
+	rnode.Image.Hash = "oldhash"
+	c.Check(isReplicated(s.node, rnode), Equals, false)
+}
@@ -0,0 +1,143 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dfs
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/commons"
+	"github.com/control-center/serviced/dfs/docker"
+	index "github.com/control-center/serviced/dfs/registry"
+	"github.com/control-center/serviced/domain/registry"
+)
+
+// ErrNoManifestClient is returned when registry garbage collection is
+// requested but the dfs has no way to reach the registry.
+var ErrNoManifestClient = errors.New("no registry manifest client configured")
+
+// RegistryGCReport describes the result of a registry garbage collection.
+type RegistryGCReport struct {
+	DryRun    bool
+	Images    []string // index entries that were (or would be) removed
+	Manifests []string // registry manifests that were (or would be) removed
+	Bytes     uint64   // size of the blobs that are no longer referenced
+}
+
+// SetManifestClient sets the client used to look up and remove manifests on
+// the docker registry.
+func (dfs *DistributedFilesystem) SetManifestClient(client index.ManifestClient) {
+	dfs.manifests = client
+}
+
+// CollectRegistryGarbage removes the images in the registry index that are not
+// in keep, along with their manifests.  Images are named library/repo:tag.  A
+// manifest is left in place if any kept image shares it.  If dryRun is set,
+// nothing is removed.
+func (dfs *DistributedFilesystem) CollectRegistryGarbage(images, keep []string, dryRun bool) (*RegistryGCReport, error) {
+	if dfs.manifests == nil {
+		return nil, ErrNoManifestClient
+	}
+	keepSet := make(map[string]struct{})
+	for _, image := range keep {
+		keepSet[image] = struct{}{}
+	}
+
+	// look up the manifest of every image in the index, so that manifests
+	// and blobs shared with kept images are not removed
+	type orphan struct {
+		image    string
+		repo     string
+		manifest *registry.Manifest
+	}
+	orphans := []orphan{}
+	keptManifests := make(map[string]struct{})
+	keptBlobs := make(map[string]struct{})
+	for _, image := range images {
+		logger := plog.WithField("image", image)
+		imageID, err := commons.ParseImageID(image)
+		if err != nil {
+			logger.WithError(err).Debug("Could not parse image")
+			return nil, err
+		}
+		if imageID.IsLatest() {
+			imageID.Tag = docker.Latest
+		}
+		repo := fmt.Sprintf("%s/%s", imageID.User, imageID.Repo)
+		manifest, err := dfs.manifests.GetManifest(repo, imageID.Tag)
+		if err == index.ErrManifestNotFound {
+			logger.Debug("Image has no manifest in the registry")
+			manifest = nil
+		} else if err != nil {
+			logger.WithError(err).Debug("Could not look up manifest for image")
+			return nil, err
+		}
+
+		if _, ok := keepSet[image]; ok {
+			if manifest != nil {
+				keptManifests[repo+"@"+manifest.Digest] = struct{}{}
+				for _, blob := range manifest.Blobs {
+					keptBlobs[blob.Digest] = struct{}{}
+				}
+			}
+			continue
+		}
+		orphans = append(orphans, orphan{image, repo, manifest})
+	}
+
+	report := &RegistryGCReport{DryRun: dryRun, Images: []string{}, Manifests: []string{}}
+	removedManifests := make(map[string]struct{})
+	countedBlobs := make(map[string]struct{})
+	for _, o := range orphans {
+		logger := plog.WithFields(logrus.Fields{
+			"image":  o.image,
+			"dryrun": dryRun,
+		})
+		if o.manifest != nil {
+			ref := o.repo + "@" + o.manifest.Digest
+			_, kept := keptManifests[ref]
+			_, removed := removedManifests[ref]
+			if !kept && !removed {
+				if !dryRun {
+					if err := dfs.manifests.DeleteManifest(o.repo, o.manifest.Digest); err != nil && err != index.ErrManifestNotFound {
+						logger.WithError(err).Debug("Could not delete manifest from the registry")
+						return nil, err
+					}
+				}
+				removedManifests[ref] = struct{}{}
+				report.Manifests = append(report.Manifests, ref)
+				for _, blob := range o.manifest.Blobs {
+					if _, ok := keptBlobs[blob.Digest]; ok {
+						continue
+					}
+					if _, ok := countedBlobs[blob.Digest]; ok {
+						continue
+					}
+					countedBlobs[blob.Digest] = struct{}{}
+					report.Bytes += uint64(blob.Size)
+				}
+			}
+		}
+		if !dryRun {
+			if err := dfs.index.RemoveImage(o.image); err != nil {
+				logger.WithError(err).Debug("Could not remove image from the registry index")
+				return nil, err
+			}
+		}
+		report.Images = append(report.Images, o.image)
+		logger.Info("Collected unreferenced registry image")
+	}
+	return report, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package dfs_test
+
+import (
+	. "github.com/control-center/serviced/dfs"
+	index "github.com/control-center/serviced/dfs/registry"
+	registrymocks "github.com/control-center/serviced/dfs/registry/mocks"
+	"github.com/control-center/serviced/domain/registry"
+	. "gopkg.in/check.v1"
+)
+
+func (s *DFSTestSuite) TestCollectRegistryGarbage_NoClient(c *C) {
+	report, err := s.dfs.CollectRegistryGarbage([]string{"tenant/repo:latest"}, nil, false)
+	c.Assert(report, IsNil)
+	c.Assert(err, Equals, ErrNoManifestClient)
+}
+
+func (s *DFSTestSuite) setUpRegistryGC() *registrymocks.ManifestClient {
+	manifests := &registrymocks.ManifestClient{}
+	s.dfs.SetManifestClient(manifests)
+	manifests.On("GetManifest", "tenant/repo", "latest").Return(&registry.Manifest{
+		Digest: "sha256:aaa",
+		Blobs:  []registry.Blob{{"sha256:base", 100}, {"sha256:app", 10}},
+	}, nil)
+	// shares its manifest with latest
+	manifests.On("GetManifest", "tenant/repo", "snap1").Return(&registry.Manifest{
+		Digest: "sha256:aaa",
+		Blobs:  []registry.Blob{{"sha256:base", 100}, {"sha256:app", 10}},
+	}, nil)
+	// shares a base layer with latest
+	manifests.On("GetManifest", "tenant/repo", "snap2").Return(&registry.Manifest{
+		Digest: "sha256:bbb",
+		Blobs:  []registry.Blob{{"sha256:base", 100}, {"sha256:old", 20}},
+	}, nil)
+	manifests.On("GetManifest", "gone/repo", "latest").Return(nil, index.ErrManifestNotFound)
+	return manifests
+}
+
+func (s *DFSTestSuite) TestCollectRegistryGarbage_DryRun(c *C) {
+	manifests := s.setUpRegistryGC()
+	images := []string{"tenant/repo:latest", "tenant/repo:snap1", "tenant/repo:snap2", "gone/repo:latest"}
+	report, err := s.dfs.CollectRegistryGarbage(images, []string{"tenant/repo:latest"}, true)
+	c.Assert(err, IsNil)
+	c.Assert(report.DryRun, Equals, true)
+	c.Assert(report.Images, DeepEquals, []string{"tenant/repo:snap1", "tenant/repo:snap2", "gone/repo:latest"})
+	c.Assert(report.Manifests, DeepEquals, []string{"tenant/repo@sha256:bbb"})
+	c.Assert(report.Bytes, Equals, uint64(20))
+	manifests.AssertNotCalled(c, "DeleteManifest", "tenant/repo", "sha256:bbb")
+	s.index.AssertNotCalled(c, "RemoveImage", "tenant/repo:snap2")
+}
+
+func (s *DFSTestSuite) TestCollectRegistryGarbage(c *C) {
+	manifests := s.setUpRegistryGC()
+	manifests.On("DeleteManifest", "tenant/repo", "sha256:bbb").Return(nil).Once()
+	s.index.On("RemoveImage", "tenant/repo:snap1").Return(nil).Once()
+	s.index.On("RemoveImage", "tenant/repo:snap2").Return(nil).Once()
+	s.index.On("RemoveImage", "gone/repo:latest").Return(nil).Once()
+	images := []string{"tenant/repo:latest", "tenant/repo:snap1", "tenant/repo:snap2", "gone/repo:latest"}
+	report, err := s.dfs.CollectRegistryGarbage(images, []string{"tenant/repo:latest"}, false)
+	c.Assert(err, IsNil)
+	c.Assert(report.DryRun, Equals, false)
+	c.Assert(report.Images, HasLen, 3)
+	c.Assert(report.Bytes, Equals, uint64(20))
+	manifests.AssertExpectations(c)
+	s.index.AssertExpectations(c)
+}
+
+func (s *DFSTestSuite) TestCollectRegistryGarbage_DeleteFailed(c *C) {
+	manifests := s.setUpRegistryGC()
+	manifests.On("DeleteManifest", "tenant/repo", "sha256:bbb").Return(ErrTestGeneric)
+	s.index.On("RemoveImage", "tenant/repo:snap1").Return(nil)
+	images := []string{"tenant/repo:latest", "tenant/repo:snap1", "tenant/repo:snap2"}
+	report, err := s.dfs.CollectRegistryGarbage(images, []string{"tenant/repo:latest"}, false)
+	c.Assert(report, IsNil)
+	c.Assert(err, Equals, ErrTestGeneric)
+	s.index.AssertNotCalled(c, "RemoveImage", "tenant/repo:snap2")
+}
@@ -23,6 +23,7 @@ import (
 const (
 	ServicesMetadataFile = "./.snapshot/services.json"
 	ImagesMetadataFile   = "./.snapshot/images.json"
+	HooksMetadataFile    = "./.snapshot/hooks.json"
 )
 
 var (
@@ -107,6 +108,24 @@ func (dfs *DistributedFilesystem) Snapshot(data SnapshotInfo, spaceFactor int) (
 	return info.Name, nil
 }
 
+// SaveSnapshotHooks records the quiesce window and hook results of a snapshot
+func (dfs *DistributedFilesystem) SaveSnapshotHooks(snapshotID string, record SnapshotHookRecord) error {
+	vol, info, err := dfs.getSnapshotVolumeAndInfo(snapshotID)
+	if err != nil {
+		return err
+	}
+	w, err := vol.WriteMetadata(info.Label, HooksMetadataFile)
+	if err != nil {
+		glog.Errorf("Could not create hooks metadata file for snapshot %s: %s", snapshotID, err)
+		return err
+	}
+	if err := exportJSON(w, record); err != nil {
+		glog.Errorf("Could not write hooks metadata file for snapshot %s: %s", snapshotID, err)
+		return err
+	}
+	return nil
+}
+
 // generateSnapshotLabel creates a label for a snapshot
 func generateSnapshotLabel() string {
 	return time.Now().UTC().Format("20060102-150405.000")
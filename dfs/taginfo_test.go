@@ -78,7 +78,8 @@ func (s *DFSTestSuite) TestTagInfo_Success(c *C) {
 	err = json.NewEncoder(imgsbuffer).Encode(imgs)
 	c.Assert(err, IsNil)
 	vol.On("ReadMetadata", "Snap", ImagesMetadataFile).Return(&NopCloser{imgsbuffer}, nil)
+	vol.On("ReadMetadata", "Snap", HooksMetadataFile).Return(&NopCloser{}, ErrTestInfoNotFound)
 	info, err := s.dfs.TagInfo("Base", "tagA")
 	c.Assert(err, IsNil)
-	c.Assert(info, DeepEquals, &SnapshotInfo{vinfo, imgs, svcs})
+	c.Assert(info, DeepEquals, &SnapshotInfo{vinfo, imgs, svcs, nil})
 }
@@ -55,5 +55,14 @@ func readSnapshotInfo(vol volume.Volume, info *volume.SnapshotInfo) (*SnapshotIn
 		glog.Errorf("Could not interpret services metadata from snapshot %s: %s", info.Label, err)
 		return nil, err
 	}
-	return &SnapshotInfo{info, images, svcs}, nil
+	// Retrieve hooks metadata, which is only present if hooks were recorded
+	var hooks *SnapshotHookRecord
+	if r, err = vol.ReadMetadata(info.Label, HooksMetadataFile); err == nil {
+		hooks = &SnapshotHookRecord{}
+		if err := importJSON(r, hooks); err != nil {
+			glog.Errorf("Could not interpret hooks metadata from snapshot %s: %s", info.Label, err)
+			return nil, err
+		}
+	}
+	return &SnapshotInfo{info, images, svcs, hooks}, nil
 }
@@ -0,0 +1,152 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditlog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/control-center/serviced/datastore"
+)
+
+// Entry is a single record in the audit trail.  Each entry is chained to the
+// one before it by including the previous entry's hash in its own hash, so
+// that modifying, removing or inserting entries can be detected.
+type Entry struct {
+	Seq        int64             // position of the entry in the trail, starting at 1
+	Timestamp  time.Time         // when the action took place
+	User       string            // the user performing the action
+	Action     string            // the action being performed (e.g. add, remove, start)
+	EntityType string            // the type of the entity being changed, if applicable
+	EntityID   string            // the id of the entity being changed, if applicable
+	Message    string            // user friendly description of the action
+	Success    bool              // whether the action succeeded
+	Fields     map[string]string // any additional fields set on the entry
+	PrevHash   string            // hash of the previous entry in the trail
+	Hash       string            // hash of this entry, including PrevHash
+	datastore.VersionedEntity
+}
+
+// hashedEntry holds the fields of an entry that are covered by its hash
+type hashedEntry struct {
+	Seq        int64
+	Timestamp  string
+	User       string
+	Action     string
+	EntityType string
+	EntityID   string
+	Message    string
+	Success    bool
+	Fields     map[string]string
+	PrevHash   string
+}
+
+// ComputeHash returns the hash of the entry's contents and previous hash
+func (e *Entry) ComputeHash() string {
+	data, _ := json.Marshal(hashedEntry{
+		Seq:        e.Seq,
+		Timestamp:  e.Timestamp.UTC().Format(time.RFC3339Nano),
+		User:       e.User,
+		Action:     e.Action,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+		Message:    e.Message,
+		Success:    e.Success,
+		Fields:     e.Fields,
+		PrevHash:   e.PrevHash,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Link chains the entry to prev, the last entry in the trail (nil if the
+// trail is empty), and sets its sequence number and hash.
+func (e *Entry) Link(prev *Entry) {
+	if prev == nil {
+		e.Seq, e.PrevHash = 1, ""
+	} else {
+		e.Seq, e.PrevHash = prev.Seq+1, prev.Hash
+	}
+	e.Hash = e.ComputeHash()
+}
+
+// Verify checks that entries, sorted by sequence number, form an unbroken
+// chain following prev (nil if entries start the trail).  It returns an error
+// describing the first entry that fails verification.
+func Verify(prev *Entry, entries []Entry) error {
+	for i := range entries {
+		e := &entries[i]
+		if prev == nil {
+			if e.Seq != 1 || e.PrevHash != "" {
+				return fmt.Errorf("entry %d: trail does not start at entry 1", e.Seq)
+			}
+		} else if e.Seq != prev.Seq+1 {
+			return fmt.Errorf("entry %d: expected entry %d; entries are missing", e.Seq, prev.Seq+1)
+		} else if e.PrevHash != prev.Hash {
+			return fmt.Errorf("entry %d: previous hash does not match entry %d", e.Seq, prev.Seq)
+		}
+		if e.Hash != e.ComputeHash() {
+			return fmt.Errorf("entry %d: hash does not match its contents", e.Seq)
+		}
+		prev = e
+	}
+	return nil
+}
+
+// ValidEntity used to make sure the audit entry is in a valid state
+func (e *Entry) ValidEntity() error {
+	if e.Seq < 1 {
+		return fmt.Errorf("audit entry: invalid sequence number %d", e.Seq)
+	}
+	if e.Hash == "" {
+		return fmt.Errorf("audit entry %d: missing hash", e.Seq)
+	}
+	return nil
+}
+
+// GetType returns the type of audit entries
+func GetType() string {
+	return kind
+}
+
+// GetType returns the audit entry instance's type
+func (e *Entry) GetType() string {
+	return GetType()
+}
+
+// GetID returns the audit entry instance's ID
+func (e *Entry) GetID() string {
+	return buildID(e.Seq)
+}
+
+// Query selects a page of entries from the audit trail, newest first
+type Query struct {
+	User   string    // only entries performed by this user
+	Entity string    // only entries for this entity type or entity id
+	Since  time.Time // only entries at or after this time
+	Offset int       // number of matching entries to skip
+	Limit  int       // maximum number of entries to return
+}
+
+// Verification is the result of verifying the hash chain of the audit trail
+type Verification struct {
+	Count    int    // number of entries verified
+	LastSeq  int64  // sequence number of the last valid entry
+	LastHash string // hash of the last valid entry
+	Valid    bool   // whether the whole trail verified
+	Error    string // describes the first entry that failed verification
+}
@@ -0,0 +1,89 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package auditlog
+
+import (
+	"testing"
+	"time"
+)
+
+func buildChain(n int) []Entry {
+	entries := make([]Entry, n)
+	var prev *Entry
+	for i := range entries {
+		entries[i] = Entry{
+			Timestamp:  time.Date(2018, 1, 1, 0, i, 0, 0, time.UTC),
+			User:       "admin",
+			Action:     "update",
+			EntityType: "service",
+			EntityID:   "svc1",
+			Message:    "Updating Service",
+			Success:    true,
+			Fields:     map[string]string{"name": "zope"},
+		}
+		entries[i].Link(prev)
+		prev = &entries[i]
+	}
+	return entries
+}
+
+func TestLink(t *testing.T) {
+	entries := buildChain(2)
+	if entries[0].Seq != 1 || entries[0].PrevHash != "" {
+		t.Errorf("unexpected first entry %+v", entries[0])
+	}
+	if entries[1].Seq != 2 || entries[1].PrevHash != entries[0].Hash {
+		t.Errorf("entry 2 is not chained to entry 1: %+v", entries[1])
+	}
+	if entries[0].Hash == entries[1].Hash {
+		t.Errorf("expected distinct hashes")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	if err := Verify(nil, buildChain(5)); err != nil {
+		t.Errorf("unexpected error verifying a valid chain: %s", err)
+	}
+
+	entries := buildChain(5)
+	if err := Verify(&entries[1], entries[2:]); err != nil {
+		t.Errorf("unexpected error verifying a partial chain: %s", err)
+	}
+
+	entries = buildChain(5)
+	entries[2].User = "mallory"
+	if err := Verify(nil, entries); err == nil {
+		t.Errorf("expected error verifying a modified entry")
+	}
+
+	entries = buildChain(5)
+	entries = append(entries[:2], entries[3:]...)
+	if err := Verify(nil, entries); err == nil {
+		t.Errorf("expected error verifying a chain with a removed entry")
+	}
+
+	entries = buildChain(5)
+	entries[2].Fields["name"] = "zeneventd"
+	entries[2].Hash = entries[2].ComputeHash()
+	if err := Verify(nil, entries); err == nil {
+		t.Errorf("expected error verifying a rehashed entry")
+	}
+
+	entries = buildChain(5)
+	if err := Verify(nil, entries[1:]); err == nil {
+		t.Errorf("expected error verifying a chain that does not start at entry 1")
+	}
+}
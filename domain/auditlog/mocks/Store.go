@@ -0,0 +1,103 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocks
+
+import (
+	"github.com/control-center/serviced/datastore"
+	"github.com/control-center/serviced/domain/auditlog"
+	"github.com/stretchr/testify/mock"
+)
+
+type Store struct {
+	mock.Mock
+}
+
+func (_m *Store) Put(ctx datastore.Context, entry *auditlog.Entry) error {
+	ret := _m.Called(ctx, entry)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(datastore.Context, *auditlog.Entry) error); ok {
+		r0 = rf(ctx, entry)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *Store) Last(ctx datastore.Context) (*auditlog.Entry, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *auditlog.Entry
+	if rf, ok := ret.Get(0).(func(datastore.Context) *auditlog.Entry); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*auditlog.Entry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(datastore.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (_m *Store) Search(ctx datastore.Context, query auditlog.Query) ([]auditlog.Entry, error) {
+	ret := _m.Called(ctx, query)
+
+	var r0 []auditlog.Entry
+	if rf, ok := ret.Get(0).(func(datastore.Context, auditlog.Query) []auditlog.Entry); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]auditlog.Entry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(datastore.Context, auditlog.Query) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (_m *Store) Range(ctx datastore.Context, from int64, size int) ([]auditlog.Entry, error) {
+	ret := _m.Called(ctx, from, size)
+
+	var r0 []auditlog.Entry
+	if rf, ok := ret.Get(0).(func(datastore.Context, int64, int) []auditlog.Entry); ok {
+		r0 = rf(ctx, from, size)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]auditlog.Entry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(datastore.Context, int64, int) error); ok {
+		r1 = rf(ctx, from, size)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
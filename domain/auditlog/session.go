@@ -0,0 +1,171 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// SessionKind is the command that opened an interactive session in a
+// service's container
+type SessionKind string
+
+const (
+	// SessionShell is a session opened by serviced service shell
+	SessionShell SessionKind = "shell"
+	// SessionAttach is a session opened by serviced service attach
+	SessionAttach SessionKind = "attach"
+	// SessionRun is a session opened by serviced service run
+	SessionRun SessionKind = "run"
+)
+
+// MaxRecordingSize is the most output that is kept in a session recording;
+// output after that is dropped and the recording is marked truncated.
+const MaxRecordingSize = 16 << 20
+
+var validSessionID = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// Session describes a command run in a service's container from the command
+// line.  A session is reported when it starts, with a zero End, and again
+// when it ends.
+type Session struct {
+	ID         string      // unique id of the session, also the name of its recording
+	Kind       SessionKind // shell, attach or run
+	User       string      // the local user that ran the command
+	HostID     string      // the host the container is running on
+	ServiceID  string
+	InstanceID int    // the instance attached to; -1 for a new container
+	Command    string // the command run in the container
+	Start      time.Time
+	End        time.Time
+	ExitCode   int
+	Snapshot   string // the snapshot committed by a run command with CommitOnSuccess
+	Recorded   bool   // whether a recording of the session was kept
+}
+
+// ValidSessionID returns an error if id cannot be used to name a session
+// recording
+func ValidSessionID(id string) error {
+	if !validSessionID.MatchString(id) {
+		return fmt.Errorf("invalid session id %q", id)
+	}
+	return nil
+}
+
+// Frame is a chunk of session output and the delay in seconds since the
+// previous chunk.  It is encoded as a [delay, data] pair.
+type Frame struct {
+	Delay float64
+	Data  string
+}
+
+// MarshalJSON implements json.Marshaler
+func (f Frame) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{f.Delay, f.Data})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (f *Frame) UnmarshalJSON(data []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	} else if len(pair) != 2 {
+		return fmt.Errorf("invalid frame %s", data)
+	}
+	if err := json.Unmarshal(pair[0], &f.Delay); err != nil {
+		return err
+	}
+	return json.Unmarshal(pair[1], &f.Data)
+}
+
+// Recording is the typescript of a session, in the asciicast v1 format so
+// that it can also be played back by asciinema.
+type Recording struct {
+	Version   int     `json:"version"`
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+	Duration  float64 `json:"duration"`
+	Command   string  `json:"command"`
+	Title     string  `json:"title"`
+	Truncated bool    `json:"truncated,omitempty"`
+	Stdout    []Frame `json:"stdout"`
+}
+
+// Recorder is an io.Writer that records a session's output as it is written
+type Recorder struct {
+	mu        sync.Mutex
+	recording Recording
+	start     time.Time
+	last      time.Time
+	size      int
+}
+
+// NewRecorder starts recording a session that runs command
+func NewRecorder(title, command string) *Recorder {
+	now := time.Now()
+	return &Recorder{
+		recording: Recording{Version: 1, Width: 80, Height: 24, Command: command, Title: title, Stdout: []Frame{}},
+		start:     now,
+		last:      now,
+	}
+}
+
+// Write implements io.Writer
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.size+len(p) > MaxRecordingSize {
+		r.recording.Truncated = true
+		return len(p), nil
+	}
+	now := time.Now()
+	r.recording.Stdout = append(r.recording.Stdout, Frame{Delay: now.Sub(r.last).Seconds(), Data: string(p)})
+	r.last = now
+	r.size += len(p)
+	return len(p), nil
+}
+
+// Recording stops the recording and returns it
+func (r *Recorder) Recording() *Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec := r.recording
+	rec.Duration = r.last.Sub(r.start).Seconds()
+	return &rec
+}
+
+// Replay writes the recording's output to w, waiting between frames as the
+// session did.  Delays are divided by speed, and never longer than maxWait
+// if it is set.
+func Replay(w io.Writer, rec *Recording, speed float64, maxWait time.Duration) error {
+	if speed <= 0 {
+		speed = 1
+	}
+	for _, frame := range rec.Stdout {
+		delay := time.Duration(frame.Delay / speed * float64(time.Second))
+		if maxWait > 0 && delay > maxWait {
+			delay = maxWait
+		}
+		time.Sleep(delay)
+		if _, err := io.WriteString(w, frame.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package auditlog
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFrame_JSON(t *testing.T) {
+	data, err := json.Marshal(Frame{Delay: 0.5, Data: "ls\r\n"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if string(data) != `[0.5,"ls\r\n"]` {
+		t.Errorf("Unexpected encoding: %s", data)
+	}
+
+	var frame Frame
+	if err := json.Unmarshal(data, &frame); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if frame.Delay != 0.5 || frame.Data != "ls\r\n" {
+		t.Errorf("Unexpected frame: %+v", frame)
+	}
+
+	if err := json.Unmarshal([]byte(`["x","y"]`), &frame); err == nil {
+		t.Errorf("Expected an error decoding a malformed frame")
+	}
+}
+
+func TestValidSessionID(t *testing.T) {
+	if err := ValidSessionID("abc123"); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	for _, id := range []string{"", "../etc/passwd", "a b"} {
+		if err := ValidSessionID(id); err == nil {
+			t.Errorf("Expected an error for session id %q", id)
+		}
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder("session", "/bin/bash")
+	r.Write([]byte("hello "))
+	r.Write([]byte("world\n"))
+	rec := r.Recording()
+	if rec.Version != 1 || rec.Command != "/bin/bash" || rec.Title != "session" {
+		t.Errorf("Unexpected header: %+v", rec)
+	}
+	if len(rec.Stdout) != 2 || rec.Truncated {
+		t.Fatalf("Unexpected frames: %+v", rec.Stdout)
+	}
+
+	r.Write(make([]byte, MaxRecordingSize))
+	rec = r.Recording()
+	if !rec.Truncated || len(rec.Stdout) != 2 {
+		t.Errorf("Expected the recording to be truncated")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	rec := &Recording{Stdout: []Frame{{Delay: 10, Data: "hello "}, {Delay: 10, Data: "world"}}}
+	buf := &bytes.Buffer{}
+	start := time.Now()
+	if err := Replay(buf, rec, 1, time.Millisecond); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Replay did not honor max wait")
+	}
+	if buf.String() != "hello world" {
+		t.Errorf("Unexpected output: %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := Replay(buf, &Recording{Stdout: []Frame{{Delay: 0.002, Data: "x"}}}, 0, 0); err != nil || !strings.HasPrefix(buf.String(), "x") {
+		t.Errorf("Unexpected replay result: %v %q", err, buf.String())
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditlog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/control-center/serviced/datastore"
+	"github.com/zenoss/elastigo/search"
+)
+
+// Store is the database for the audit trail
+type Store interface {
+	// Put adds an entry to the audit trail
+	Put(ctx datastore.Context, entry *Entry) error
+
+	// Last returns the most recent entry in the audit trail, or nil if the
+	// trail is empty
+	Last(ctx datastore.Context) (*Entry, error)
+
+	// Search returns the entries matching the query, newest first
+	Search(ctx datastore.Context, query Query) ([]Entry, error)
+
+	// Range returns up to size entries starting at sequence number from,
+	// oldest first
+	Range(ctx datastore.Context, from int64, size int) ([]Entry, error)
+}
+
+type storeImpl struct {
+	ds datastore.DataStore
+}
+
+// NewStore creates a Store for the audit trail
+func NewStore() Store {
+	return &storeImpl{}
+}
+
+// Put adds an entry to the audit trail
+func (s *storeImpl) Put(ctx datastore.Context, entry *Entry) error {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("AuditLogStore.Put"))
+	return s.ds.Put(ctx, Key(entry.Seq), entry)
+}
+
+// Last returns the most recent entry in the audit trail
+func (s *storeImpl) Last(ctx datastore.Context) (*Entry, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("AuditLogStore.Last"))
+	query := search.Query().Search("_exists_:Seq")
+	search := search.Search("controlplane").Type(kind).Size("1").Query(query).Sort(search.Sort("Seq").Desc())
+	entries, err := s.execute(ctx, search)
+	if err != nil {
+		return nil, err
+	} else if len(entries) == 0 {
+		return nil, nil
+	}
+	return &entries[0], nil
+}
+
+// Search returns the entries matching the query, newest first
+func (s *storeImpl) Search(ctx datastore.Context, query Query) ([]Entry, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("AuditLogStore.Search"))
+	terms := []string{"_exists_:Seq"}
+	if query.User != "" {
+		terms = append(terms, "User:"+quote(query.User))
+	}
+	if query.Entity != "" {
+		terms = append(terms, fmt.Sprintf("(EntityType:%s OR EntityID:%s)", quote(query.Entity), quote(query.Entity)))
+	}
+	elasticQuery := search.Query().Search(strings.Join(terms, " AND "))
+	if !query.Since.IsZero() {
+		elasticQuery = elasticQuery.Range(search.Range().Field("Timestamp").From(query.Since.UTC().Format(time.RFC3339)))
+	}
+	search := search.Search("controlplane").Type(kind).
+		From(strconv.Itoa(query.Offset)).Size(strconv.Itoa(query.Limit)).
+		Query(elasticQuery).Sort(search.Sort("Seq").Desc())
+	return s.execute(ctx, search)
+}
+
+// Range returns up to size entries starting at sequence number from
+func (s *storeImpl) Range(ctx datastore.Context, from int64, size int) ([]Entry, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("AuditLogStore.Range"))
+	query := search.Query().Range(search.Range().Field("Seq").From(strconv.FormatInt(from, 10))).Search("_exists_:Seq")
+	search := search.Search("controlplane").Type(kind).Size(strconv.Itoa(size)).Query(query).Sort(search.Sort("Seq").Asc())
+	return s.execute(ctx, search)
+}
+
+func (s *storeImpl) execute(ctx datastore.Context, search *search.SearchDsl) ([]Entry, error) {
+	results, err := datastore.NewQuery(ctx).Execute(search)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, results.Len())
+	for i := range entries {
+		if err := results.Get(i, &entries[i]); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// Key creates a Key suitable for getting and putting audit entries
+func Key(seq int64) datastore.Key {
+	return datastore.NewKey(kind, buildID(seq))
+}
+
+func buildID(seq int64) string {
+	return fmt.Sprintf("%020d", seq)
+}
+
+// quote returns value as a quoted term for an elastic query string
+func quote(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	return `"` + strings.Replace(value, `"`, `\"`, -1) + `"`
+}
@@ -0,0 +1,89 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build integration
+
+package auditlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/control-center/serviced/datastore"
+	"github.com/control-center/serviced/datastore/elastic"
+	. "gopkg.in/check.v1"
+)
+
+// This plumbs gocheck into testing
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&S{
+	ElasticTest: elastic.ElasticTest{
+		Index:    "controlplane",
+		Mappings: []elastic.Mapping{MAPPING},
+	}})
+
+type S struct {
+	elastic.ElasticTest
+	ctx   datastore.Context
+	store Store
+}
+
+func (s *S) SetUpTest(c *C) {
+	s.ElasticTest.SetUpTest(c)
+	datastore.Register(s.Driver())
+	s.ctx = datastore.Get()
+	s.store = NewStore()
+}
+
+func (s *S) Test_AuditTrail(c *C) {
+	last, err := s.store.Last(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(last, IsNil)
+
+	trail := NewTrail(s.store)
+	now := time.Now().UTC()
+	entries := []*Entry{
+		{Timestamp: now.Add(-2 * time.Hour), User: "admin", Action: "add", EntityType: "resourcepool", EntityID: "pool1", Success: true},
+		{Timestamp: now.Add(-time.Hour), User: "system", Action: "start", EntityType: "service", EntityID: "svc1", Success: true},
+		{Timestamp: now, User: "admin", Action: "stop", EntityType: "service", EntityID: "svc1", Success: false},
+	}
+	for _, e := range entries {
+		c.Assert(trail.Append(s.ctx, e), IsNil)
+	}
+
+	last, err = s.store.Last(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(last.Seq, Equals, int64(3))
+
+	all, err := s.store.Range(s.ctx, 1, 10)
+	c.Assert(err, IsNil)
+	c.Assert(all, HasLen, 3)
+	c.Assert(Verify(nil, all), IsNil)
+
+	found, err := s.store.Search(s.ctx, Query{User: "admin", Limit: 10})
+	c.Assert(err, IsNil)
+	c.Assert(found, HasLen, 2)
+	c.Assert(found[0].Seq, Equals, int64(3))
+
+	found, err = s.store.Search(s.ctx, Query{Entity: "service", Since: now.Add(-90 * time.Minute), Limit: 10})
+	c.Assert(err, IsNil)
+	c.Assert(found, HasLen, 2)
+
+	found, err = s.store.Search(s.ctx, Query{Entity: "svc1", Offset: 1, Limit: 10})
+	c.Assert(err, IsNil)
+	c.Assert(found, HasLen, 1)
+	c.Assert(found[0].Seq, Equals, int64(2))
+}
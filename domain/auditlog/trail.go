@@ -0,0 +1,53 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditlog
+
+import (
+	"sync"
+
+	"github.com/control-center/serviced/datastore"
+)
+
+// Trail appends entries to the audit trail, chaining each new entry to the
+// last one written.  Appends are serialized so that the chain has no forks.
+type Trail struct {
+	mu     sync.Mutex
+	store  Store
+	last   *Entry
+	loaded bool
+}
+
+// NewTrail returns a Trail that writes to store
+func NewTrail(store Store) *Trail {
+	return &Trail{store: store}
+}
+
+// Append links the entry to the end of the trail and writes it to the store
+func (t *Trail) Append(ctx datastore.Context, entry *Entry) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.loaded {
+		last, err := t.store.Last(ctx)
+		if err != nil {
+			return err
+		}
+		t.last, t.loaded = last, true
+	}
+	entry.Link(t.last)
+	if err := t.store.Put(ctx, entry); err != nil {
+		return err
+	}
+	t.last = entry
+	return nil
+}
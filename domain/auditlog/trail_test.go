@@ -0,0 +1,74 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package auditlog_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/control-center/serviced/datastore"
+	. "github.com/control-center/serviced/domain/auditlog"
+	"github.com/control-center/serviced/domain/auditlog/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTrailAppend(t *testing.T) {
+	ctx := datastore.Get()
+	last := &Entry{Message: "last"}
+	last.Link(&Entry{Seq: 41, Hash: "abc"})
+
+	store := &mocks.Store{}
+	store.On("Last", ctx).Return(last, nil).Once()
+	store.On("Put", ctx, mock.AnythingOfType("*auditlog.Entry")).Return(nil).Twice()
+	trail := NewTrail(store)
+
+	first := &Entry{Message: "first"}
+	if err := trail.Append(ctx, first); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	second := &Entry{Message: "second"}
+	if err := trail.Append(ctx, second); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := Verify(last, []Entry{*first, *second}); err != nil {
+		t.Errorf("appended entries do not verify: %s", err)
+	}
+	if second.Seq != 44 {
+		t.Errorf("expected seq 44, got %d", second.Seq)
+	}
+	store.AssertExpectations(t)
+}
+
+func TestTrailAppendPutError(t *testing.T) {
+	ctx := datastore.Get()
+	store := &mocks.Store{}
+	store.On("Last", ctx).Return(nil, nil).Once()
+	store.On("Put", ctx, mock.AnythingOfType("*auditlog.Entry")).Return(errors.New("ouch")).Once()
+	store.On("Put", ctx, mock.AnythingOfType("*auditlog.Entry")).Return(nil).Once()
+	trail := NewTrail(store)
+
+	if err := trail.Append(ctx, &Entry{Message: "lost"}); err == nil {
+		t.Fatalf("expected error")
+	}
+	entry := &Entry{Message: "kept"}
+	if err := trail.Append(ctx, entry); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if entry.Seq != 1 {
+		t.Errorf("expected a failed write to leave the trail unchanged, got seq %d", entry.Seq)
+	}
+	store.AssertExpectations(t)
+}
@@ -0,0 +1,139 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certificate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/control-center/serviced/datastore"
+)
+
+// SecretPurpose is the purpose of the master secret that encrypts the keys of
+// certificates
+const SecretPurpose = "certificate"
+
+// ErrNoCertificate is returned when the certificate PEM has no certificate
+var ErrNoCertificate = errors.New("no certificate found in PEM data")
+
+// Kind is the kind of public endpoint that a certificate is for
+type Kind string
+
+const (
+	// VHost certificates are selected by the server name that the client
+	// asks for
+	VHost Kind = "vhost"
+	// Port certificates are served on a public port
+	Port Kind = "port"
+)
+
+// Certificate is a TLS certificate for a vhost or public port.  The private
+// key is encrypted with a secret of the master.  Certificates with ACME
+// domains are obtained and renewed automatically, and are pending until the
+// first one is issued.
+type Certificate struct {
+	Kind         Kind
+	Name         string // vhost name or public port address
+	CertPEM      string
+	EncryptedKey string
+	Subjects     []string
+	NotAfter     time.Time
+	ACMEDomains  []string
+	datastore.VersionedEntity
+}
+
+// GetType returns the datastore type of certificates
+func GetType() string {
+	return kind
+}
+
+// GetType returns the datastore type of the certificate
+func (c *Certificate) GetType() string {
+	return GetType()
+}
+
+// GetID returns the id of the certificate
+func (c *Certificate) GetID() string {
+	return buildID(c.Kind, c.Name)
+}
+
+// IsPending returns true if the certificate has not been issued yet
+func (c *Certificate) IsPending() bool {
+	return c.CertPEM == ""
+}
+
+// ExpiresWithin returns true if the certificate expires in less than d
+func (c *Certificate) ExpiresWithin(d time.Duration) bool {
+	return time.Now().Add(d).After(c.NotAfter)
+}
+
+// Parse checks that the key matches the certificate and returns the leaf
+// certificate.
+func Parse(certPEM, keyPEM []byte) (*x509.Certificate, error) {
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if len(pair.Certificate) == 0 {
+		return nil, ErrNoCertificate
+	}
+	return x509.ParseCertificate(pair.Certificate[0])
+}
+
+// New parses a certificate and key, and returns a certificate with the key
+// encrypted with secret.
+func New(kind Kind, name string, certPEM, keyPEM, secret []byte) (*Certificate, error) {
+	leaf, err := Parse(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := Encrypt(secret, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	subjects := []string{}
+	if leaf.Subject.CommonName != "" {
+		subjects = append(subjects, leaf.Subject.CommonName)
+	}
+	for _, dnsName := range leaf.DNSNames {
+		if dnsName != leaf.Subject.CommonName {
+			subjects = append(subjects, dnsName)
+		}
+	}
+	return &Certificate{
+		Kind:         kind,
+		Name:         name,
+		CertPEM:      string(certPEM),
+		EncryptedKey: encrypted,
+		Subjects:     subjects,
+		NotAfter:     leaf.NotAfter.UTC(),
+	}, nil
+}
+
+// KeyPair decrypts the private key with secret and returns the certificate
+// for a tls config.
+func (c *Certificate) KeyPair(secret []byte) (tls.Certificate, error) {
+	keyPEM, err := Decrypt(secret, c.EncryptedKey)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair([]byte(c.CertPEM), keyPEM)
+}
+
+func buildID(kind Kind, name string) string {
+	return fmt.Sprintf("%s-%s", kind, name)
+}
@@ -0,0 +1,125 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package certificate
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func testKeyPair(t *testing.T, name string, notAfter time.Time) ([]byte, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Could not generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name, "www." + name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Could not create certificate: %s", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return certPEM, keyPEM
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	ciphertext, err := Encrypt(testSecret, []byte("secret key"))
+	if err != nil {
+		t.Fatalf("Could not encrypt: %s", err)
+	}
+	plaintext, err := Decrypt(testSecret, ciphertext)
+	if err != nil {
+		t.Fatalf("Could not decrypt: %s", err)
+	} else if string(plaintext) != "secret key" {
+		t.Errorf("Expected %q, got %q", "secret key", plaintext)
+	}
+
+	other := []byte("fedcba9876543210fedcba9876543210")
+	if _, err := Decrypt(other, ciphertext); err == nil {
+		t.Errorf("Expected an error decrypting with the wrong secret")
+	}
+	if _, err := Decrypt(testSecret, ""); err != ErrCiphertext {
+		t.Errorf("Expected %s, got %v", ErrCiphertext, err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	notAfter := time.Now().Add(10 * 24 * time.Hour).Truncate(time.Second)
+	certPEM, keyPEM := testKeyPair(t, "example.com", notAfter)
+
+	cert, err := New(VHost, "myapp", certPEM, keyPEM, testSecret)
+	if err != nil {
+		t.Fatalf("Could not create certificate: %s", err)
+	}
+	if err := cert.ValidEntity(); err != nil {
+		t.Errorf("Expected a valid certificate, got %s", err)
+	}
+	if cert.GetID() != "vhost-myapp" {
+		t.Errorf("Unexpected id %s", cert.GetID())
+	}
+	if len(cert.Subjects) != 2 || cert.Subjects[0] != "example.com" || cert.Subjects[1] != "www.example.com" {
+		t.Errorf("Unexpected subjects %v", cert.Subjects)
+	}
+	if !cert.NotAfter.Equal(notAfter) {
+		t.Errorf("Expected expiry %s, got %s", notAfter, cert.NotAfter)
+	}
+	if cert.EncryptedKey == string(keyPEM) {
+		t.Errorf("Expected the key to be encrypted")
+	}
+	if !cert.ExpiresWithin(30*24*time.Hour) || cert.ExpiresWithin(24*time.Hour) {
+		t.Errorf("Unexpected expiry check for %s", cert.NotAfter)
+	}
+
+	if _, err := cert.KeyPair(testSecret); err != nil {
+		t.Errorf("Could not load key pair: %s", err)
+	}
+}
+
+func TestNew_Mismatch(t *testing.T) {
+	certPEM, _ := testKeyPair(t, "example.com", time.Now().Add(time.Hour))
+	_, keyPEM := testKeyPair(t, "example.com", time.Now().Add(time.Hour))
+	if _, err := New(Port, ":443", certPEM, keyPEM, testSecret); err == nil {
+		t.Errorf("Expected an error for a key that does not match")
+	}
+}
+
+func TestValidEntity_Pending(t *testing.T) {
+	cert := &Certificate{Kind: VHost, Name: "myapp"}
+	if !cert.IsPending() {
+		t.Errorf("Expected certificate to be pending")
+	}
+	if err := cert.ValidEntity(); err == nil {
+		t.Errorf("Expected an error for a pending certificate without ACME domains")
+	}
+	cert.ACMEDomains = []string{"myapp.example.com"}
+	if err := cert.ValidEntity(); err != nil {
+		t.Errorf("Expected a valid pending ACME certificate, got %s", err)
+	}
+}
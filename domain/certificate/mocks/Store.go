@@ -0,0 +1,80 @@
+package mocks
+
+import "github.com/control-center/serviced/domain/certificate"
+import "github.com/stretchr/testify/mock"
+
+import "github.com/control-center/serviced/datastore"
+
+type Store struct {
+	mock.Mock
+}
+
+func (_m *Store) Get(ctx datastore.Context, kind certificate.Kind, name string) (*certificate.Certificate, error) {
+	ret := _m.Called(ctx, kind, name)
+
+	var r0 *certificate.Certificate
+	if rf, ok := ret.Get(0).(func(datastore.Context, certificate.Kind, string) *certificate.Certificate); ok {
+		r0 = rf(ctx, kind, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*certificate.Certificate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(datastore.Context, certificate.Kind, string) error); ok {
+		r1 = rf(ctx, kind, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (_m *Store) Put(ctx datastore.Context, cert *certificate.Certificate) error {
+	ret := _m.Called(ctx, cert)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(datastore.Context, *certificate.Certificate) error); ok {
+		r0 = rf(ctx, cert)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *Store) Delete(ctx datastore.Context, kind certificate.Kind, name string) error {
+	ret := _m.Called(ctx, kind, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(datastore.Context, certificate.Kind, string) error); ok {
+		r0 = rf(ctx, kind, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *Store) GetCertificates(ctx datastore.Context) ([]certificate.Certificate, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []certificate.Certificate
+	if rf, ok := ret.Get(0).(func(datastore.Context) []certificate.Certificate); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]certificate.Certificate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(datastore.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,91 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certificate
+
+import (
+	"strings"
+
+	"github.com/control-center/serviced/datastore"
+	"github.com/zenoss/elastigo/search"
+)
+
+// Store is the database for certificates
+type Store interface {
+	// Get a certificate by kind and name.  Return ErrNoSuchEntity if not found
+	Get(ctx datastore.Context, kind Kind, name string) (*Certificate, error)
+
+	// Put adds or updates a certificate
+	Put(ctx datastore.Context, cert *Certificate) error
+
+	// Delete removes a certificate if it exists
+	Delete(ctx datastore.Context, kind Kind, name string) error
+
+	// GetCertificates returns all certificates
+	GetCertificates(ctx datastore.Context) ([]Certificate, error)
+}
+
+type storeImpl struct {
+	ds datastore.DataStore
+}
+
+// NewStore creates a Store for certificates
+func NewStore() Store {
+	return &storeImpl{}
+}
+
+// Get a certificate by kind and name.  Return ErrNoSuchEntity if not found
+func (s *storeImpl) Get(ctx datastore.Context, kind Kind, name string) (*Certificate, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("CertificateStore.Get"))
+	val := &Certificate{}
+	if err := s.ds.Get(ctx, Key(kind, name), val); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+// Put adds or updates a certificate
+func (s *storeImpl) Put(ctx datastore.Context, cert *Certificate) error {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("CertificateStore.Put"))
+	return s.ds.Put(ctx, Key(cert.Kind, cert.Name), cert)
+}
+
+// Delete removes a certificate
+func (s *storeImpl) Delete(ctx datastore.Context, kind Kind, name string) error {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("CertificateStore.Delete"))
+	return s.ds.Delete(ctx, Key(kind, name))
+}
+
+// GetCertificates returns all certificates
+func (s *storeImpl) GetCertificates(ctx datastore.Context) ([]Certificate, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("CertificateStore.GetCertificates"))
+	q := datastore.NewQuery(ctx)
+	query := search.Query().Search("_exists_:Kind")
+	search := search.Search("controlplane").Type(kind).Size("50000").Query(query)
+	results, err := q.Execute(search)
+	if err != nil {
+		return nil, err
+	}
+	certs := make([]Certificate, results.Len())
+	for i := range certs {
+		if err := results.Get(i, &certs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return certs, nil
+}
+
+// Key creates a Key suitable for getting, putting and deleting certificates
+func Key(kind Kind, name string) datastore.Key {
+	return datastore.NewKey(GetType(), buildID(kind, strings.TrimSpace(name)))
+}
@@ -0,0 +1,45 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certificate
+
+import (
+	"fmt"
+
+	"github.com/control-center/serviced/validation"
+)
+
+// ValidEntity validates the certificate
+func (c *Certificate) ValidEntity() error {
+	violations := validation.NewValidationError()
+	violations.Add(validation.StringIn(string(c.Kind), string(VHost), string(Port)))
+	violations.Add(validation.NotEmpty("Name", c.Name))
+	if c.IsPending() {
+		// only ACME certificates are stored before they are issued
+		if len(c.ACMEDomains) == 0 {
+			violations.Add(validation.NewViolation(fmt.Sprintf("certificate %s has no certificate or ACME domains", c.GetID())))
+		}
+	} else {
+		violations.Add(validation.NotEmpty("EncryptedKey", c.EncryptedKey))
+		if c.NotAfter.IsZero() {
+			violations.Add(validation.NewViolation(fmt.Sprintf("certificate %s has no expiry", c.GetID())))
+		}
+	}
+	for _, domain := range c.ACMEDomains {
+		violations.Add(validation.NotEmpty("ACMEDomains", domain))
+	}
+	if violations.HasError() {
+		return violations
+	}
+	return nil
+}
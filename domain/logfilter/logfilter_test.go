@@ -72,3 +72,16 @@ func (s *unitTestSuite) Test_Equals(c *C) {
 	filterDiffers.Filter = "something different"
 	c.Assert(a.Equals(&filterDiffers), Equals, false)
 }
+
+func (s *unitTestSuite) Test_RetentionRuleQuery(c *C) {
+	rule := RetentionRule{Type: "audit", Days: 365, ServiceIDs: []string{"svc-2", "svc-1"}}
+	c.Assert(rule.Query(), Equals, `fields.type:"audit" AND fields.service:("svc\-1" OR "svc\-2")`)
+}
+
+func (s *unitTestSuite) Test_RetentionPolicyIndexDays(c *C) {
+	policy := RetentionPolicy{MaxDays: 14}
+	c.Assert(policy.IndexDays(), Equals, 14)
+
+	policy.Rules = []RetentionRule{{Type: "debug", Days: 3}, {Type: "audit", Days: 365}}
+	c.Assert(policy.IndexDays(), Equals, 365)
+}
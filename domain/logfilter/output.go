@@ -0,0 +1,116 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logfilter
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/control-center/serviced/validation"
+)
+
+// LogOutputType is the kind of destination that log messages are shipped to
+type LogOutputType string
+
+const (
+	// OutputSyslog ships messages to a syslog server using RFC 5424
+	OutputSyslog LogOutputType = "syslog"
+	// OutputHTTP posts each message as json to an http endpoint
+	OutputHTTP LogOutputType = "http"
+	// OutputKafka produces each message as json to a kafka topic
+	OutputKafka LogOutputType = "kafka"
+)
+
+// LogOutput ships the application log messages of a pool or tenant to a
+// destination outside of Control Center, in addition to the internal
+// logstash.
+type LogOutput struct {
+	Name      string
+	Type      LogOutputType
+	Address   string   // host:port for syslog, the url for http, and a comma-separated list of brokers for kafka
+	Protocol  string   // tcp (default) or udp, for syslog
+	Topic     string   // the topic, for kafka
+	LogTypes  []string // only ship messages of these LogConfig types; empty ships all types
+	AuditOnly bool     // only ship messages of the auditable LogConfig types
+}
+
+// ValidEntity used to make sure the log output is in a valid state
+func (o LogOutput) ValidEntity() error {
+	violations := validation.NewValidationError()
+	if strings.TrimSpace(o.Name) == "" {
+		violations.Add(fmt.Errorf("log output: missing name"))
+	}
+	if err := validation.StringIn(string(o.Type), string(OutputSyslog), string(OutputHTTP), string(OutputKafka)); err != nil {
+		violations.Add(fmt.Errorf("log output %s: invalid type: %v", o.Name, err))
+	}
+	if strings.ContainsAny(o.Address+o.Topic, "\"\\") {
+		violations.Add(fmt.Errorf("log output %s: address and topic must not contain quotes or backslashes", o.Name))
+	}
+	for _, logType := range o.LogTypes {
+		if strings.ContainsAny(logType, "\"\\") {
+			violations.Add(fmt.Errorf("log output %s: invalid log type %q", o.Name, logType))
+		}
+	}
+	switch o.Type {
+	case OutputSyslog:
+		if _, port, err := net.SplitHostPort(o.Address); err != nil {
+			violations.Add(fmt.Errorf("log output %s: invalid syslog address: %v", o.Name, err))
+		} else if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			violations.Add(fmt.Errorf("log output %s: invalid syslog port %q", o.Name, port))
+		}
+		if o.Protocol != "" {
+			if err := validation.StringIn(o.Protocol, "tcp", "udp"); err != nil {
+				violations.Add(fmt.Errorf("log output %s: invalid syslog protocol: %v", o.Name, err))
+			}
+		}
+	case OutputHTTP:
+		if u, err := url.Parse(o.Address); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			violations.Add(fmt.Errorf("log output %s: invalid http url %q", o.Name, o.Address))
+		}
+	case OutputKafka:
+		if strings.TrimSpace(o.Address) == "" {
+			violations.Add(fmt.Errorf("log output %s: missing kafka brokers", o.Name))
+		}
+		if strings.TrimSpace(o.Topic) == "" {
+			violations.Add(fmt.Errorf("log output %s: missing kafka topic", o.Name))
+		}
+	}
+	if violations.HasError() {
+		return violations
+	}
+	return nil
+}
+
+// LogOutputs are the log outputs of a pool or tenant
+type LogOutputs []LogOutput
+
+// ValidEntity used to make sure the log outputs are valid and uniquely named
+func (outputs LogOutputs) ValidEntity() error {
+	violations := validation.NewValidationError()
+	names := make(map[string]struct{})
+	for _, o := range outputs {
+		violations.Add(o.ValidEntity())
+		if _, ok := names[o.Name]; ok {
+			violations.Add(fmt.Errorf("log output %s: name is not unique", o.Name))
+		}
+		names[o.Name] = struct{}{}
+	}
+	if violations.HasError() {
+		return violations
+	}
+	return nil
+}
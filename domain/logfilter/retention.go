@@ -0,0 +1,77 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logfilter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RetentionRule keeps the log messages of a single LogConfig type, written by
+// the services of a tenant, for a number of days.
+type RetentionRule struct {
+	TenantID   string
+	TenantName string
+	Type       string
+	Days       int
+	ServiceIDs []string // services of the tenant that log messages of this type
+}
+
+// Query returns the elasticsearch query string that matches the messages
+// covered by the rule.
+func (r RetentionRule) Query() string {
+	ids := make([]string, len(r.ServiceIDs))
+	for i, id := range r.ServiceIDs {
+		ids[i] = fmt.Sprintf("\"%s\"", strings.Replace(id, "-", "\\-", -1))
+	}
+	sort.Strings(ids)
+	return fmt.Sprintf("fields.type:\"%s\" AND fields.service:(%s)", r.Type, strings.Join(ids, " OR "))
+}
+
+// RetentionPolicy describes how long logstash keeps application logs.
+// Messages not covered by a rule are kept for MaxDays, and the oldest
+// indices are removed once the logs grow beyond MaxSize gigabytes.
+type RetentionPolicy struct {
+	MaxDays int
+	MaxSize int
+	Rules   []RetentionRule
+}
+
+// IndexDays returns the age in days after which a whole logstash index can
+// be removed, which is the longest time any message is kept.
+func (p RetentionPolicy) IndexDays() int {
+	days := p.MaxDays
+	for _, rule := range p.Rules {
+		if rule.Days > days {
+			days = rule.Days
+		}
+	}
+	return days
+}
+
+// RetentionRules sorts retention rules by tenant and then by type
+type RetentionRules []RetentionRule
+
+func (r RetentionRules) Len() int      { return len(r) }
+func (r RetentionRules) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r RetentionRules) Less(i, j int) bool {
+	if r[i].TenantName != r[j].TenantName {
+		return r[i].TenantName < r[j].TenantName
+	}
+	if r[i].TenantID != r[j].TenantID {
+		return r[i].TenantID < r[j].TenantID
+	}
+	return r[i].Type < r[j].Type
+}
@@ -63,3 +63,38 @@ func (s *validationSuite) TestLogFilter_NonBlankFilter(c *C) {
 	err := filter.ValidEntity()
 	c.Assert(err, NotNil)
 }
+
+func (s *validationSuite) TestLogOutput_Success(c *C) {
+	outputs := LogOutputs{
+		{Name: "siem", Type: OutputSyslog, Address: "siem.example.com:514", Protocol: "udp", AuditOnly: true},
+		{Name: "collector", Type: OutputHTTP, Address: "https://collector.example.com/logs"},
+		{Name: "stream", Type: OutputKafka, Address: "kafka1:9092,kafka2:9092", Topic: "logs", LogTypes: []string{"app"}},
+	}
+	c.Assert(outputs.ValidEntity(), IsNil)
+}
+
+func (s *validationSuite) TestLogOutput_Invalid(c *C) {
+	outputs := []LogOutput{
+		{Type: OutputSyslog, Address: "siem:514"},
+		{Name: "bad", Type: "smoke-signals", Address: "siem:514"},
+		{Name: "bad", Type: OutputSyslog, Address: "siem"},
+		{Name: "bad", Type: OutputSyslog, Address: "siem:port"},
+		{Name: "bad", Type: OutputSyslog, Address: "siem:514", Protocol: "sctp"},
+		{Name: "bad", Type: OutputHTTP, Address: "ftp://collector/logs"},
+		{Name: "bad", Type: OutputHTTP, Address: "https://collector/\"logs"},
+		{Name: "bad", Type: OutputKafka, Address: "kafka:9092"},
+		{Name: "bad", Type: OutputKafka, Topic: "logs"},
+		{Name: "bad", Type: OutputHTTP, Address: "https://collector/logs", LogTypes: []string{"a\"b"}},
+	}
+	for _, output := range outputs {
+		c.Check(output.ValidEntity(), NotNil, Commentf("%+v", output))
+	}
+}
+
+func (s *validationSuite) TestLogOutputs_DuplicateName(c *C) {
+	outputs := LogOutputs{
+		{Name: "siem", Type: OutputSyslog, Address: "siem1:514"},
+		{Name: "siem", Type: OutputSyslog, Address: "siem2:514"},
+	}
+	c.Assert(outputs.ValidEntity(), NotNil)
+}
@@ -0,0 +1,110 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pool
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/control-center/serviced/validation"
+)
+
+// MetricSinkType is the protocol used to send metrics to a sink
+type MetricSinkType string
+
+const (
+	// SinkStatsD sends each sample as a gauge over udp, with its tags in the
+	// DogStatsD format
+	SinkStatsD MetricSinkType = "statsd"
+	// SinkInfluxDB posts samples in the InfluxDB line protocol
+	SinkInfluxDB MetricSinkType = "influxdb"
+	// SinkOpenTSDB posts samples as json to an OpenTSDB compatible put api
+	SinkOpenTSDB MetricSinkType = "opentsdb"
+)
+
+// DefaultMetricSinkBuffer is the number of samples kept for a sink that is
+// down, if the sink does not set its own buffer size
+const DefaultMetricSinkBuffer = 10000
+
+// MetricSink sends the application metrics and serviced stats of a pool to
+// a destination outside of Control Center, in addition to the internal
+// metric consumer.
+type MetricSink struct {
+	Name       string
+	Type       MetricSinkType
+	Address    string // host:port for statsd, and the write url for influxdb and opentsdb
+	Prefix     string // prepended to the name of each metric
+	BufferSize int    // samples kept while the sink is down; 0 uses DefaultMetricSinkBuffer
+}
+
+// GetBufferSize returns the number of samples to keep while the sink is down
+func (s MetricSink) GetBufferSize() int {
+	if s.BufferSize <= 0 {
+		return DefaultMetricSinkBuffer
+	}
+	return s.BufferSize
+}
+
+// ValidEntity used to make sure the metric sink is in a valid state
+func (s MetricSink) ValidEntity() error {
+	violations := validation.NewValidationError()
+	if strings.TrimSpace(s.Name) == "" {
+		violations.Add(fmt.Errorf("metric sink: missing name"))
+	}
+	if err := validation.StringIn(string(s.Type), string(SinkStatsD), string(SinkInfluxDB), string(SinkOpenTSDB)); err != nil {
+		violations.Add(fmt.Errorf("metric sink %s: invalid type: %v", s.Name, err))
+	}
+	if s.BufferSize < 0 {
+		violations.Add(fmt.Errorf("metric sink %s: buffer size cannot be less than 0", s.Name))
+	}
+	switch s.Type {
+	case SinkStatsD:
+		if _, port, err := net.SplitHostPort(s.Address); err != nil {
+			violations.Add(fmt.Errorf("metric sink %s: invalid statsd address: %v", s.Name, err))
+		} else if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			violations.Add(fmt.Errorf("metric sink %s: invalid statsd port %q", s.Name, port))
+		}
+	case SinkInfluxDB, SinkOpenTSDB:
+		if u, err := url.Parse(s.Address); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			violations.Add(fmt.Errorf("metric sink %s: invalid %s url %q", s.Name, s.Type, s.Address))
+		}
+	}
+	if violations.HasError() {
+		return violations
+	}
+	return nil
+}
+
+// MetricSinks are the metric sinks of a pool
+type MetricSinks []MetricSink
+
+// ValidEntity used to make sure the metric sinks are valid and uniquely named
+func (sinks MetricSinks) ValidEntity() error {
+	violations := validation.NewValidationError()
+	names := make(map[string]struct{})
+	for _, s := range sinks {
+		violations.Add(s.ValidEntity())
+		if _, ok := names[s.Name]; ok {
+			violations.Add(fmt.Errorf("metric sink %s: name is not unique", s.Name))
+		}
+		names[s.Name] = struct{}{}
+	}
+	if violations.HasError() {
+		return violations
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package pool
+import (
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+func TestMetricSinks(t *testing.T) { TestingT(t) }
+
+type metricSinkSuite struct{}
+
+var _ = Suite(&metricSinkSuite{})
+
+func (s *metricSinkSuite) TestMetricSinks_Success(c *C) {
+	sinks := MetricSinks{
+		{Name: "statsd", Type: SinkStatsD, Address: "statsd.example.com:8125", Prefix: "cc."},
+		{Name: "influx", Type: SinkInfluxDB, Address: "http://influx:8086/write?db=cc", BufferSize: 500},
+		{Name: "tsdb", Type: SinkOpenTSDB, Address: "https://tsdb.example.com/api/put"},
+	}
+	c.Assert(sinks.ValidEntity(), IsNil)
+	c.Assert(sinks[0].GetBufferSize(), Equals, DefaultMetricSinkBuffer)
+	c.Assert(sinks[1].GetBufferSize(), Equals, 500)
+}
+
+func (s *metricSinkSuite) TestMetricSink_Invalid(c *C) {
+	sinks := []MetricSink{
+		{Type: SinkStatsD, Address: "statsd:8125"},
+		{Name: "bad", Type: "carbon", Address: "carbon:2003"},
+		{Name: "bad", Type: SinkStatsD, Address: "statsd"},
+		{Name: "bad", Type: SinkStatsD, Address: "statsd:port"},
+		{Name: "bad", Type: SinkInfluxDB, Address: "udp://influx:8089"},
+		{Name: "bad", Type: SinkOpenTSDB, Address: "tsdb:4242"},
+		{Name: "bad", Type: SinkStatsD, Address: "statsd:8125", BufferSize: -1},
+	}
+	for _, sink := range sinks {
+		c.Check(sink.ValidEntity(), NotNil, Commentf("%+v", sink))
+	}
+}
+
+func (s *metricSinkSuite) TestMetricSinks_DuplicateName(c *C) {
+	sinks := MetricSinks{
+		{Name: "statsd", Type: SinkStatsD, Address: "statsd1:8125"},
+		{Name: "statsd", Type: SinkStatsD, Address: "statsd2:8125"},
+	}
+	c.Assert(sinks.ValidEntity(), NotNil)
+}
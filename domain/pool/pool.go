@@ -21,6 +21,7 @@ import (
 	"github.com/control-center/serviced/datastore"
 	"github.com/control-center/serviced/domain"
 	"github.com/control-center/serviced/domain/host"
+	"github.com/control-center/serviced/domain/logfilter"
 	"github.com/control-center/serviced/logging"
 )
 
@@ -57,6 +58,8 @@ type ResourcePool struct {
 	UpdatedAt         time.Time
 	MonitoringProfile domain.MonitorProfile
 	Permissions       Permission
+	LogOutputs        logfilter.LogOutputs // Destinations outside of Control Center that the pool's logs are shipped to
+	MetricSinks       MetricSinks          // Destinations outside of Control Center that the pool's metrics are sent to
 	datastore.VersionedEntity
 }
 
@@ -134,6 +137,12 @@ func (a *ResourcePool) Equals(b *ResourcePool) bool {
 	if !a.MonitoringProfile.Equals(&b.MonitoringProfile) {
 		return false
 	}
+	if !reflect.DeepEqual(a.LogOutputs, b.LogOutputs) {
+		return false
+	}
+	if !reflect.DeepEqual(a.MetricSinks, b.MetricSinks) {
+		return false
+	}
 
 	return true
 }
@@ -37,6 +37,9 @@ func (p *ResourcePool) ValidEntity() error {
 		violations.Add(validation.NewViolation(fmt.Sprintf("connection timeout cannot be less than 0")))
 	}
 
+	violations.Add(p.LogOutputs.ValidEntity())
+	violations.Add(p.MetricSinks.ValidEntity())
+
 	if len(violations.Errors) > 0 {
 		return violations
 	}
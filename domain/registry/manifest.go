@@ -0,0 +1,27 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+// Blob is a content-addressed item (layer or image config) referenced by a
+// manifest.
+type Blob struct {
+	Digest string
+	Size   int64
+}
+
+// Manifest describes an image tag in the docker registry.
+type Manifest struct {
+	Digest string // content digest of the manifest itself
+	Blobs  []Blob // image config and layers
+}
@@ -0,0 +1,132 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"math"
+
+	"github.com/control-center/serviced/utils"
+)
+
+const (
+	// RAMHeadroom is the factor applied to the p95 memory usage of an
+	// instance to recommend its RAM commitment.
+	RAMHeadroom = 1.2
+
+	// MemoryLimitHeadroom is the factor applied to the peak memory usage of
+	// an instance to recommend its memory limit.
+	MemoryLimitHeadroom = 1.5
+
+	// CPUHeadroom is the factor applied to the p95 cpu usage of an instance
+	// to recommend its CPU commitment.
+	CPUHeadroom = 1.2
+
+	// RAMGranularity is the multiple that recommended memory values are
+	// rounded up to.
+	RAMGranularity = 64 * 1024 * 1024
+)
+
+// ResourceUsage is the memory and cpu usage of the busiest instance of a
+// service over a window.
+type ResourceUsage struct {
+	MemoryP95 int64   // bytes
+	MemoryMax int64   // bytes
+	CPUP95    float64 // percent of a core
+	CPUMax    float64 // percent of a core
+}
+
+// ResourceRecommendation compares the resource usage of a service to its
+// commitments and limits, and recommends new values.
+type ResourceRecommendation struct {
+	ServiceID                string
+	Name                     string
+	PoolID                   string
+	Instances                int
+	Usage                    ResourceUsage
+	RAMCommitment            uint64
+	RecommendedRAMCommitment uint64
+	CPUCommitment            uint64
+	RecommendedCPUCommitment uint64
+	MemoryLimit              float64
+	RecommendedMemoryLimit   float64
+}
+
+// NewResourceRecommendation recommends the RAM commitment, CPU commitment and
+// memory limit of a service from the usage of its instances.  A memory limit
+// is only recommended for a service that already sets one.
+func NewResourceRecommendation(svc Service, usage ResourceUsage) ResourceRecommendation {
+	rec := ResourceRecommendation{
+		ServiceID:     svc.ID,
+		Name:          svc.Name,
+		PoolID:        svc.PoolID,
+		Instances:     svc.Instances,
+		Usage:         usage,
+		RAMCommitment: svc.RAMCommitment.Value,
+		CPUCommitment: svc.CPUCommitment,
+		MemoryLimit:   svc.MemoryLimit,
+	}
+	rec.RecommendedRAMCommitment = roundUpRAM(float64(usage.MemoryP95) * RAMHeadroom)
+	rec.RecommendedCPUCommitment = uint64(math.Ceil(usage.CPUP95 / 100 * CPUHeadroom))
+	if rec.RecommendedCPUCommitment < 1 {
+		rec.RecommendedCPUCommitment = 1
+	}
+	if svc.MemoryLimit > 0 {
+		limit := roundUpRAM(float64(usage.MemoryMax) * MemoryLimitHeadroom)
+		if limit < rec.RecommendedRAMCommitment {
+			limit = rec.RecommendedRAMCommitment
+		}
+		rec.RecommendedMemoryLimit = float64(limit)
+	}
+	return rec
+}
+
+// roundUpRAM rounds an amount of memory up to the next RAMGranularity, and to
+// at least one.
+func roundUpRAM(bytes float64) uint64 {
+	units := uint64(math.Ceil(bytes / RAMGranularity))
+	if units < 1 {
+		units = 1
+	}
+	return units * RAMGranularity
+}
+
+// Changed returns true if any recommended value differs from the current
+// value.
+func (rec ResourceRecommendation) Changed() bool {
+	return rec.RAMCommitment != rec.RecommendedRAMCommitment ||
+		rec.CPUCommitment != rec.RecommendedCPUCommitment ||
+		rec.MemoryLimit != rec.RecommendedMemoryLimit
+}
+
+// FreedRAM returns the RAM, in bytes, that the recommendation frees in the
+// resource pool across all instances.  It is negative if the service needs
+// more.
+func (rec ResourceRecommendation) FreedRAM() int64 {
+	return (int64(rec.RAMCommitment) - int64(rec.RecommendedRAMCommitment)) * int64(rec.Instances)
+}
+
+// FreedCPU returns the cores that the recommendation frees in the resource
+// pool across all instances.  It is negative if the service needs more.
+func (rec ResourceRecommendation) FreedCPU() int64 {
+	return (int64(rec.CPUCommitment) - int64(rec.RecommendedCPUCommitment)) * int64(rec.Instances)
+}
+
+// Apply sets the recommended values on the service.
+func (rec ResourceRecommendation) Apply(svc *Service) {
+	if svc.RAMCommitment.Value != rec.RecommendedRAMCommitment {
+		svc.RAMCommitment = utils.NewEngNotation(int64(rec.RecommendedRAMCommitment))
+	}
+	svc.CPUCommitment = rec.RecommendedCPUCommitment
+	svc.MemoryLimit = rec.RecommendedMemoryLimit
+}
@@ -0,0 +1,76 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package service_test
+
+import (
+	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/utils"
+	. "gopkg.in/check.v1"
+)
+
+const mib = 1024 * 1024
+
+func (s *ServiceDomainUnitTestSuite) TestNewResourceRecommendation_Overcommitted(t *C) {
+	svc := service.Service{
+		ID:            "svc1",
+		Name:          "svc",
+		PoolID:        "default",
+		Instances:     3,
+		RAMCommitment: utils.NewEngNotation(2048 * mib),
+		CPUCommitment: 4,
+		MemoryLimit:   4096 * mib,
+	}
+	usage := service.ResourceUsage{MemoryP95: 500 * mib, MemoryMax: 700 * mib, CPUP95: 90, CPUMax: 180}
+
+	rec := service.NewResourceRecommendation(svc, usage)
+	t.Check(rec.RecommendedRAMCommitment, Equals, uint64(640*mib))
+	t.Check(rec.RecommendedCPUCommitment, Equals, uint64(2))
+	t.Check(rec.RecommendedMemoryLimit, Equals, float64(1088*mib))
+	t.Check(rec.Changed(), Equals, true)
+	t.Check(rec.FreedRAM(), Equals, int64(3*(2048-640)*mib))
+	t.Check(rec.FreedCPU(), Equals, int64(6))
+
+	rec.Apply(&svc)
+	t.Check(svc.RAMCommitment.Value, Equals, uint64(640*mib))
+	t.Check(svc.CPUCommitment, Equals, uint64(2))
+	t.Check(svc.MemoryLimit, Equals, float64(1088*mib))
+}
+
+func (s *ServiceDomainUnitTestSuite) TestNewResourceRecommendation_Undercommitted(t *C) {
+	svc := service.Service{
+		Instances:     2,
+		RAMCommitment: utils.NewEngNotation(256 * mib),
+		CPUCommitment: 1,
+	}
+	usage := service.ResourceUsage{MemoryP95: 1000 * mib, MemoryMax: 1200 * mib}
+
+	rec := service.NewResourceRecommendation(svc, usage)
+	t.Check(rec.RecommendedRAMCommitment, Equals, uint64(1216*mib))
+	t.Check(rec.RecommendedCPUCommitment, Equals, uint64(1))
+	t.Check(rec.RecommendedMemoryLimit, Equals, float64(0))
+	t.Check(rec.FreedRAM(), Equals, -int64(2*(1216-256)*mib))
+	t.Check(rec.FreedCPU(), Equals, int64(0))
+}
+
+func (s *ServiceDomainUnitTestSuite) TestNewResourceRecommendation_Unchanged(t *C) {
+	svc := service.Service{
+		Instances:     1,
+		RAMCommitment: utils.NewEngNotation(128 * mib),
+		CPUCommitment: 1,
+	}
+	rec := service.NewResourceRecommendation(svc, service.ResourceUsage{MemoryP95: 100 * mib, CPUP95: 20})
+	t.Check(rec.Changed(), Equals, false)
+}
@@ -25,9 +25,11 @@ import (
 	"github.com/control-center/serviced/datastore"
 	"github.com/control-center/serviced/domain"
 	"github.com/control-center/serviced/domain/addressassignment"
+	"github.com/control-center/serviced/domain/logfilter"
 	"github.com/control-center/serviced/domain/servicedefinition"
 	"github.com/control-center/serviced/health"
 	"github.com/control-center/serviced/utils"
+	"github.com/control-center/serviced/volume"
 )
 
 // Desired states of services.
@@ -265,9 +267,24 @@ type Service struct {
 	// are stopped after services with a defined EmergencyShutdownLevel, in the normal order
 	// dictated by their StartLevel.
 	EmergencyShutdownLevel uint
+	// DrainPeriod is the number of seconds that the open connections to an
+	// instance get to finish when it is stopped or its exports change.  The
+	// instance is unregistered first, so that it gets no new connections, and
+	// stopped once the period is over.
+	DrainPeriod uint
 	// EmergencyShutdown is a flag that indicates whether this service has been shutdown due
 	// to an emergency (low-storage) situation.  Services with this flag set can not be started
 	EmergencyShutdown bool
+	// StorageQuota limits the application storage that may be consumed by this
+	// service's tenant.  It may only be set on tenant services.
+	StorageQuota volume.StorageQuota
+	// LogOutputs are destinations outside of Control Center that the logs of
+	// this service's tenant are shipped to.  They may only be set on tenant
+	// services.
+	LogOutputs logfilter.LogOutputs
+	// SLOs are the service level objectives that the master tracks for this
+	// service.
+	SLOs servicedefinition.SLOs
 	datastore.VersionedEntity
 }
 
@@ -287,6 +304,7 @@ type ServiceEndpoint struct {
 	VHostList         []servicedefinition.VHost // VHost is used to request named vhost(s) for this endpoint.
 	AddressAssignment addressassignment.AddressAssignment
 	PortList          []servicedefinition.Port // The list of enabled/disabled ports to assign to this endpoint.
+	LoadBalancer      servicedefinition.LoadBalancer
 }
 
 // IsConfigurable returns true if the endpoint is configurable
@@ -331,6 +349,7 @@ func BuildServiceEndpoint(epd servicedefinition.EndpointDefinition) ServiceEndpo
 	sep.VHosts = epd.VHosts
 	sep.VHostList = epd.VHostList
 	sep.PortList = epd.PortList
+	sep.LoadBalancer = epd.LoadBalancer
 
 	// run public ports through scrubber to allow for "almost correct" port addresses
 	for index, port := range sep.PortList {
@@ -393,6 +412,8 @@ func BuildService(sd servicedefinition.ServiceDefinition, parentServiceID string
 	svc.PIDFile = sd.PIDFile
 	svc.StartLevel = sd.StartLevel
 	svc.EmergencyShutdownLevel = sd.EmergencyShutdownLevel
+	svc.DrainPeriod = sd.DrainPeriod
+	svc.SLOs = sd.SLOs
 
 	svc.Endpoints = make([]ServiceEndpoint, 0)
 	for _, ep := range sd.Endpoints {
@@ -474,6 +495,12 @@ func CloneService(fromSvc *Service, suffix string) (*Service, error) {
 	return &svc, nil
 }
 
+// GetDrainPeriod returns how long the open connections to a stopping instance
+// get to finish.
+func (s *Service) GetDrainPeriod() time.Duration {
+	return time.Duration(s.DrainPeriod) * time.Second
+}
+
 // GetServiceImports retrieves service endpoints whose purpose is "import"
 func (s *Service) GetServiceImports() []ServiceEndpoint {
 	result := []ServiceEndpoint{}
@@ -741,6 +768,37 @@ func (s *Service) EnableVirtualHost(application, vhostName string, enable bool)
 	return nil
 }
 
+// SetPortAccess sets the access rules of a port for given service
+func (s *Service) SetPortAccess(application, portAddr string, access servicedefinition.AccessRules) error {
+	for _, ep := range s.GetServicePorts() {
+		if ep.Application == application {
+			for i, port := range ep.PortList {
+				if port.PortAddr == portAddr {
+					ep.PortList[i].AccessRules = access
+					return nil
+				}
+			}
+		}
+	}
+	return fmt.Errorf("port %s not found in service %s:%s", portAddr, s.ID, s.Name)
+}
+
+// SetVirtualHostAccess sets the access rules of a virtual host for given
+// service
+func (s *Service) SetVirtualHostAccess(application, vhostName string, access servicedefinition.AccessRules) error {
+	for _, ep := range s.GetServiceVHosts() {
+		if ep.Application == application {
+			for i, vhost := range ep.VHostList {
+				if strings.ToLower(vhost.Name) == strings.ToLower(vhostName) {
+					ep.VHostList[i].AccessRules = access
+					return nil
+				}
+			}
+		}
+	}
+	return fmt.Errorf("vhost %s not found in service %s:%s", vhostName, s.ID, s.Name)
+}
+
 // RemoveVirtualHost Remove a virtual host for given service
 func (s *Service) RemoveVirtualHost(application, vhostName string) error {
 	if s.Endpoints != nil {
@@ -465,6 +465,8 @@ func createPublicEndpoints(result EndpointQueryResult) []PublicEndpoint {
 
 			if strings.HasPrefix(port.Protocol, "http") {
 				pub.Protocol = port.Protocol
+			} else if port.Protocol == "udp" {
+				pub.Protocol = "UDP"
 			} else if port.UseTLS {
 				pub.Protocol = "Other, secure (TLS)"
 			} else {
@@ -15,6 +15,7 @@ package service
 
 import (
 	"github.com/control-center/serviced/datastore"
+	"github.com/control-center/serviced/domain/servicedefinition"
 	"github.com/control-center/serviced/health"
 	"github.com/control-center/serviced/utils"
 	"github.com/control-center/serviced/validation"
@@ -31,6 +32,7 @@ type ServiceHealth struct {
 	HealthChecks      map[string]health.HealthCheck
 	EmergencyShutdown bool
 	RAMCommitment     utils.EngNotation
+	SLOs              servicedefinition.SLOs
 	datastore.VersionedEntity
 }
 
@@ -56,6 +58,7 @@ func BuildServiceHealth(svc Service) *ServiceHealth {
 		Instances:    svc.Instances,
 		DesiredState: svc.DesiredState,
 		HealthChecks: make(map[string]health.HealthCheck),
+		SLOs:         svc.SLOs,
 	}
 
 	for key, value := range svc.HealthChecks {
@@ -123,4 +123,5 @@ var serviceHealthFields = []string{
 	"HealthChecks",
 	"EmergencyShutdown",
 	"RAMCommitment",
+	"SLOs",
 }
@@ -46,6 +46,35 @@ func (s *Service) ValidEntity() error {
 	// validate the monitoring profile
 	vErr.Add(s.MonitoringProfile.ValidEntity())
 
+	// validate the snapshot hooks
+	vErr.Add(s.Snapshot.ValidEntity())
+
+	// validate the log parsers
+	for _, logConfig := range s.LogConfigs {
+		if logConfig.Parser != nil {
+			vErr.Add(logConfig.Parser.ValidEntity())
+		}
+	}
+
+	// storage quotas are only honored on tenants
+	if s.StorageQuota.IsSet() {
+		if s.ParentServiceID != "" {
+			vErr.Add(fmt.Errorf("Storage quota may only be set on a tenant service"))
+		}
+		vErr.Add(s.StorageQuota.Validate())
+	}
+
+	// log outputs are only honored on tenants
+	if len(s.LogOutputs) > 0 {
+		if s.ParentServiceID != "" {
+			vErr.Add(fmt.Errorf("Log outputs may only be set on a tenant service"))
+		}
+		vErr.Add(s.LogOutputs.ValidEntity())
+	}
+
+	// validate the service level objectives
+	vErr.Add(s.SLOs.ValidEntity())
+
 	for _, ep := range s.Endpoints {
 		vErr.Add(ep.ValidEntity())
 	}
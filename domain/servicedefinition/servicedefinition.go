@@ -16,7 +16,12 @@ package servicedefinition
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
+	"net"
+	"regexp"
 	"strings"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/control-center/serviced/domain"
@@ -67,12 +72,80 @@ type ServiceDefinition struct {
 	PIDFile                string // An optional path or command to generate a path for a PID file to which signals are relayed.
 	StartLevel             uint   // Services start in the order implied by this field (low to high) and stopped in reverse order
 	EmergencyShutdownLevel uint   // In case of low storage, Services stopped in the order implied by this field (low to high)
+	DrainPeriod            uint   // Seconds that open connections to a stopping instance get to finish before it is stopped
+	SLOs                   SLOs   // Service level objectives tracked by the master
 }
 
 // SnapshotCommands commands to be called during and after a snapshot
 type SnapshotCommands struct {
-	Pause  string // bash command to pause the volume  (quiesce)
-	Resume string // bash command to resume the volume (unquiesce)
+	Pause  string         // bash command to pause the volume  (quiesce)
+	Resume string         // bash command to resume the volume (unquiesce)
+	Hooks  []SnapshotHook // ordered hooks to run around the pause and resume commands
+}
+
+// SnapshotHookPhase describes when a snapshot hook is run
+type SnapshotHookPhase string
+
+const (
+	// HookPreFreeze hooks run before the pause command
+	HookPreFreeze SnapshotHookPhase = "pre-freeze"
+	// HookPostFreeze hooks run after the pause command
+	HookPostFreeze SnapshotHookPhase = "post-freeze"
+	// HookPostThaw hooks run after the resume command
+	HookPostThaw SnapshotHookPhase = "post-thaw"
+)
+
+// SnapshotHookPolicy describes what happens to a snapshot when a hook fails
+type SnapshotHookPolicy string
+
+const (
+	// HookAbort cancels the snapshot if the hook fails
+	HookAbort SnapshotHookPolicy = "abort"
+	// HookContinue records the failure and continues with the snapshot
+	HookContinue SnapshotHookPolicy = "continue"
+)
+
+// DefaultSnapshotHookTimeout is the number of seconds a hook may run if no
+// timeout is set.
+const DefaultSnapshotHookTimeout = 60
+
+// SnapshotHook is a bash command that is run inside each instance of a
+// service while a snapshot is taken.  Hooks of the same phase run in the
+// order they are defined, and services are visited in the same order as the
+// pause and resume commands (by StartLevel).
+type SnapshotHook struct {
+	Name      string
+	Phase     SnapshotHookPhase
+	Command   string
+	Timeout   int                // seconds to wait for the command to finish
+	OnFailure SnapshotHookPolicy // defaults to abort; ignored for post-thaw hooks
+}
+
+// GetTimeout returns the hook timeout, or the default if none is set.
+func (h SnapshotHook) GetTimeout() time.Duration {
+	if h.Timeout <= 0 {
+		return DefaultSnapshotHookTimeout * time.Second
+	}
+	return time.Duration(h.Timeout) * time.Second
+}
+
+// AbortOnFailure returns true if a failure of the hook should cancel the
+// snapshot.
+func (h SnapshotHook) AbortOnFailure() bool {
+	return h.Phase != HookPostThaw && h.OnFailure != HookContinue
+}
+
+// SnapshotHookResult records the outcome of a snapshot hook on a single
+// service instance.
+type SnapshotHookResult struct {
+	ServiceID  string
+	InstanceID int
+	Name       string
+	Phase      SnapshotHookPhase
+	Started    time.Time
+	Duration   time.Duration
+	Error      string // empty if the hook succeeded
+	Aborted    bool   // true if the failure cancelled the snapshot
 }
 
 // EndpointDefinition An endpoint that a Service exposes.
@@ -88,14 +161,128 @@ type EndpointDefinition struct {
 	AddressConfig       AddressResourceConfig
 	VHosts              []string // VHost is used to request named vhost for this endpoint. Should be the name of a
 	// subdomain, i.e "myapplication"  not "myapplication.host.com"
-	VHostList []VHost // VHost is used to request named vhost(s) for this endpoint.
-	PortList  []Port
+	VHostList    []VHost // VHost is used to request named vhost(s) for this endpoint.
+	PortList     []Port
+	LoadBalancer LoadBalancer // How connections are spread across the instances exporting this endpoint.
+}
+
+// LoadBalancerPolicy is how connections to an exported endpoint are spread
+// across the instances of the service.
+type LoadBalancerPolicy string
+
+const (
+	// BalanceRoundRobin sends each connection to the next instance in turn
+	BalanceRoundRobin LoadBalancerPolicy = "roundrobin"
+	// BalanceLeastConn sends each connection to the instance with the fewest
+	// active connections
+	BalanceLeastConn LoadBalancerPolicy = "leastconn"
+	// BalanceWeighted sends connections to instances in proportion to their
+	// weights
+	BalanceWeighted LoadBalancerPolicy = "weighted"
+	// BalanceIPHash sends every connection from a client address to the same
+	// instance, using a consistent hash
+	BalanceIPHash LoadBalancerPolicy = "iphash"
+)
+
+const (
+	// DefaultMaxFails is the number of consecutive failures to connect to an
+	// instance before it is ejected, if not set.
+	DefaultMaxFails = 3
+	// DefaultFailTimeout is the number of seconds an ejected instance is
+	// skipped, if not set.
+	DefaultFailTimeout = 30
+)
+
+// LoadBalancer is the load balancing configuration of an exported endpoint.
+// It is used by the proxies in importing containers as well as the vhost and
+// public port proxies.  An instance that cannot be reached MaxFails times in a
+// row is skipped for FailTimeout seconds.
+type LoadBalancer struct {
+	Policy      LoadBalancerPolicy // defaults to roundrobin
+	Weights     map[int]int        // weight by instance id for the weighted policy; defaults to 1
+	MaxFails    int                // a negative value disables ejection
+	FailTimeout int                // seconds
+}
+
+// GetMaxFails returns the number of failures before an instance is ejected,
+// or 0 if instances are never ejected.
+func (lb LoadBalancer) GetMaxFails() int {
+	if lb.MaxFails < 0 {
+		return 0
+	} else if lb.MaxFails == 0 {
+		return DefaultMaxFails
+	}
+	return lb.MaxFails
+}
+
+// GetFailTimeout returns how long an ejected instance is skipped.
+func (lb LoadBalancer) GetFailTimeout() time.Duration {
+	if lb.FailTimeout <= 0 {
+		return DefaultFailTimeout * time.Second
+	}
+	return time.Duration(lb.FailTimeout) * time.Second
+}
+
+// GetWeight returns the weight of an instance for the weighted policy.
+func (lb LoadBalancer) GetWeight(instanceID int) int {
+	if w := lb.Weights[instanceID]; w > 0 {
+		return w
+	}
+	return 1
 }
 
 // VHost is the configuration for an application endpoint that wants an http VHost endpoint provided by Control Center
 type VHost struct {
 	Name    string // name of the vhost subdomain subdomain, i.e "myapplication"  not "myapplication.host.com
 	Enabled bool   // whether the vhost should be enabled or disabled.
+	VHostRules
+	AccessRules
+}
+
+// VHostRules control how requests to a vhost are proxied.
+type VHostRules struct {
+	StickySessions bool         // whether a cookie pins each client to the instance it first reached
+	Routes         []VHostRoute // requests matching a path prefix go to another endpoint
+	Headers        []HeaderRule // header rewrites, applied in order
+	Timeout        int          // seconds to wait for a response; 0 waits forever
+}
+
+// VHostRoute sends the requests to a vhost whose path starts with PathPrefix
+// to another exported endpoint of the same tenant.  If more than one route
+// matches, the longest prefix wins.
+type VHostRoute struct {
+	PathPrefix  string
+	Application string
+	StripPrefix bool // remove the prefix from the path before proxying
+}
+
+// HeaderAction is what a header rule does to a header.
+type HeaderAction string
+
+const (
+	// HeaderSet replaces the values of the header
+	HeaderSet HeaderAction = "set"
+	// HeaderAdd adds a value to the header
+	HeaderAdd HeaderAction = "add"
+	// HeaderRemove deletes the header
+	HeaderRemove HeaderAction = "remove"
+)
+
+// HeaderRule rewrites a header of the requests (or responses) proxied through
+// a vhost.
+type HeaderRule struct {
+	Action   HeaderAction
+	Name     string
+	Value    string
+	Response bool // rewrite the response header instead of the request header
+}
+
+// GetTimeout returns how long to wait for a response, or 0 to wait forever.
+func (r VHostRules) GetTimeout() time.Duration {
+	if r.Timeout <= 0 {
+		return 0
+	}
+	return time.Duration(r.Timeout) * time.Second
 }
 
 // Port is the configuration for an application endpoint port.
@@ -104,6 +291,46 @@ type Port struct {
 	Enabled  bool   // whether the port should be enabled or disabled.
 	UseTLS   bool   // Does this port endpoint use tls.
 	Protocol string // What protocol (if any) does the endpoind use.
+	AccessRules
+}
+
+// AccessRules control which clients may reach a public endpoint, and how
+// often.  Addresses are CIDRs, or single IP addresses.  A client that matches
+// Deny is rejected; otherwise, if Allow is set, the client must match it.
+type AccessRules struct {
+	Allow     []string
+	Deny      []string
+	RateLimit float64 // requests (vhosts) or connections (ports) per second; 0 is unlimited
+	Burst     int     // how many may arrive at once; defaults to the rate limit
+}
+
+// GetBurst returns the size of the token bucket of the rate limit
+func (r AccessRules) GetBurst() int {
+	if r.Burst > 0 {
+		return r.Burst
+	}
+	if burst := int(math.Ceil(r.RateLimit)); burst > 0 {
+		return burst
+	}
+	return 1
+}
+
+// ParseCIDR parses an address of an access rule, which is a CIDR or a single
+// IP address.
+func ParseCIDR(address string) (*net.IPNet, error) {
+	if !strings.Contains(address, "/") {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", address)
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipnet, err := net.ParseCIDR(address)
+	return ipnet, err
 }
 
 // Volume import defines a file system directory underneath an export directory
@@ -138,11 +365,52 @@ type AddressResourceConfig struct {
 
 // LogConfig represents the configuration for a logfile for a service.
 type LogConfig struct {
-	Path    string   // The location on the container's filesystem of the log, can be a directory
-	Type    string   // Arbitrary string that identifies the "types" of logs that come from this source. This will be
-	Filters []string // A list of filters that must be contained in either the LogFilters or a parent's LogFilter,
-	LogTags []LogTag // Key value pair of tags that are sent to logstash for all entries coming out of this logfile
-	IsAudit bool     // Whether to send log entries to /var/log/serviced/application-audit.log or not for each LogConfig Type
+	Path          string     // The location on the container's filesystem of the log, can be a directory
+	Type          string     // Arbitrary string that identifies the "types" of logs that come from this source. This will be
+	Filters       []string   // A list of filters that must be contained in either the LogFilters or a parent's LogFilter,
+	LogTags       []LogTag   // Key value pair of tags that are sent to logstash for all entries coming out of this logfile
+	IsAudit       bool       // Whether to send log entries to /var/log/serviced/application-audit.log or not for each LogConfig Type
+	RetentionDays int        // Days to keep log entries of this Type in logstash; 0 uses the global default
+	Parser        *LogParser // How to extract searchable fields from each log entry
+}
+
+// LogParserFormat describes how the fields of a log entry are extracted
+type LogParserFormat string
+
+const (
+	// ParseJSON reads each log entry as a json object
+	ParseJSON LogParserFormat = "json"
+	// ParseLogfmt reads each log entry as key=value pairs
+	ParseLogfmt LogParserFormat = "logfmt"
+	// ParseRegex reads the named captures of a regular expression
+	ParseRegex LogParserFormat = "regex"
+)
+
+// LogParser extracts fields from the entries of a log file so that they can
+// be searched in logstash under the "app" field.  Format may be empty if the
+// parser only joins multiline entries.
+type LogParser struct {
+	Format         LogParserFormat
+	Pattern        string // regular expression with named captures, e.g. (?P<level>\w+), for the regex format
+	MultilineStart string // regular expression that matches the first line of each entry
+}
+
+// Captures returns the names of the fields captured by a regex parser
+func (p LogParser) Captures() []string {
+	if p.Format != ParseRegex {
+		return nil
+	}
+	re, err := regexp.Compile(p.Pattern)
+	if err != nil {
+		return nil
+	}
+	names := []string{}
+	for _, name := range re.SubexpNames() {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
 }
 
 // LogTag  no clue what this is. Maybe someone actually reads this
@@ -151,6 +419,84 @@ type LogTag struct {
 	Value string
 }
 
+// SLOType describes what a service level objective measures
+type SLOType string
+
+const (
+	// SLOAvailability measures the minutes in which every instance of the
+	// service passes its health checks
+	SLOAvailability SLOType = "availability"
+	// SLOLatency measures the minutes in which the vhosts and public ports of
+	// the service respond within the latency threshold
+	SLOLatency SLOType = "latency"
+)
+
+const (
+	// DefaultSLOWindowDays is the compliance window of an SLO if none is set
+	DefaultSLOWindowDays = 30
+	// MaxSLOWindowDays is the longest compliance window the master keeps
+	// history for
+	MaxSLOWindowDays = 90
+	// DefaultSLOPercentile is the proxy latency percentile measured by a
+	// latency SLO if none is set
+	DefaultSLOPercentile = "p95"
+	// DefaultBurnRateSeverity is the severity of a burn rate event if none
+	// is set
+	DefaultBurnRateSeverity = 3
+)
+
+// SLO is a service level objective.  The master measures each minute in
+// which the service is supposed to be running as good or bad, and the
+// objective is met while the percentage of good minutes over the window is
+// at least the target.  The bad minutes that the target allows are the error
+// budget.
+type SLO struct {
+	Name       string
+	Type       SLOType
+	Target     float64         // percentage of good minutes, e.g. 99.9
+	WindowDays int             // rolling compliance window; 0 uses the default
+	LatencyMS  float64         // latency only: the slowest response time of a good minute
+	Percentile string          // latency only: p50, p95 or p99 of the proxy response times
+	Alerts     []BurnRateAlert // raise threshold events when the error budget burns too quickly
+}
+
+// GetWindowDays returns the compliance window, or the default if none is set
+func (s SLO) GetWindowDays() int {
+	if s.WindowDays <= 0 {
+		return DefaultSLOWindowDays
+	}
+	return s.WindowDays
+}
+
+// GetPercentile returns the measured latency percentile, or the default if
+// none is set
+func (s SLO) GetPercentile() string {
+	if s.Percentile == "" {
+		return DefaultSLOPercentile
+	}
+	return s.Percentile
+}
+
+// BurnRateAlert raises a threshold event when the error budget of an SLO was
+// spent over the last Hours at more than BurnRate times the rate that would
+// use it up exactly at the end of the window.
+type BurnRateAlert struct {
+	Hours    int
+	BurnRate float64
+	Severity int // 1 (debug) to 5 (critical); 0 uses the default
+}
+
+// GetSeverity returns the event severity, or the default if none is set
+func (a BurnRateAlert) GetSeverity() int {
+	if a.Severity <= 0 {
+		return DefaultBurnRateSeverity
+	}
+	return a.Severity
+}
+
+// SLOs are the service level objectives of a service
+type SLOs []SLO
+
 // HostPolicy represents the optional policy used to determine which hosts on
 // which to run instances of a service. Default is to run on the available
 // host with the most uncommitted RAM.
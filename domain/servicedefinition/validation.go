@@ -60,6 +60,26 @@ func (sd *ServiceDefinition) validate(context *validationContext) error {
 		names[trimName] = struct{}{}
 	}
 	//TODO: validate LogConfigs
+	for _, lc := range sd.LogConfigs {
+		if lc.RetentionDays < 0 {
+			return fmt.Errorf("service definition %v: invalid retention of %d days for log type %s", sd.Name, lc.RetentionDays, lc.Type)
+		}
+		if lc.Parser != nil {
+			if err := lc.Parser.ValidEntity(); err != nil {
+				return fmt.Errorf("service definition %v: log %s: %v", sd.Name, lc.Path, err)
+			}
+		}
+	}
+
+	// validate snapshot hooks
+	if err := sd.Snapshot.ValidEntity(); err != nil {
+		return fmt.Errorf("service definition %v: %v", sd.Name, err)
+	}
+
+	// validate service level objectives
+	if err := sd.SLOs.ValidEntity(); err != nil {
+		return fmt.Errorf("service definition %v: %v", sd.Name, err)
+	}
 
 	// validate Monitoring Profile
 	if err := sd.MonitoringProfile.ValidEntity(); err != nil {
@@ -120,10 +140,239 @@ func (se EndpointDefinition) ValidEntity() error {
 		if err := applicationValidation(se.Application); err != nil {
 			return fmt.Errorf("endpoint '%s': %s", se.Name, err)
 		}
+		if err := se.LoadBalancer.ValidEntity(); err != nil {
+			return fmt.Errorf("endpoint '%s': %s", se.Name, err)
+		}
+		for _, vhost := range se.VHostList {
+			if err := vhost.VHostRules.ValidEntity(); err != nil {
+				return fmt.Errorf("endpoint '%s': vhost '%s': %s", se.Name, vhost.Name, err)
+			}
+			if err := vhost.AccessRules.ValidEntity(); err != nil {
+				return fmt.Errorf("endpoint '%s': vhost '%s': %s", se.Name, vhost.Name, err)
+			}
+		}
+		for _, port := range se.PortList {
+			if err := port.AccessRules.ValidEntity(); err != nil {
+				return fmt.Errorf("endpoint '%s': port '%s': %s", se.Name, port.PortAddr, err)
+			}
+		}
 	}
 	return se.AddressConfig.ValidEntity()
 }
 
+//ValidEntity used to make sure the load balancer is in a valid state
+func (lb LoadBalancer) ValidEntity() error {
+	violations := validation.NewValidationError()
+	if lb.Policy != "" {
+		if err := validation.StringIn(string(lb.Policy), string(BalanceRoundRobin), string(BalanceLeastConn), string(BalanceWeighted), string(BalanceIPHash)); err != nil {
+			violations.Add(fmt.Errorf("invalid load balancer policy: %v", err))
+		}
+	}
+	for instanceID, weight := range lb.Weights {
+		if weight < 0 {
+			violations.Add(fmt.Errorf("load balancer weight of instance %d must not be negative", instanceID))
+		}
+	}
+	if lb.FailTimeout < 0 {
+		violations.Add(fmt.Errorf("load balancer fail timeout must not be negative"))
+	}
+	if violations.HasError() {
+		return violations
+	}
+	return nil
+}
+
+//ValidEntity used to make sure the snapshot hooks are in a valid state
+func (sc SnapshotCommands) ValidEntity() error {
+	violations := validation.NewValidationError()
+	for i, hook := range sc.Hooks {
+		name := hook.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+		if strings.TrimSpace(hook.Command) == "" {
+			violations.Add(fmt.Errorf("snapshot hook %s: missing command", name))
+		}
+		if err := validation.StringIn(string(hook.Phase), string(HookPreFreeze), string(HookPostFreeze), string(HookPostThaw)); err != nil {
+			violations.Add(fmt.Errorf("snapshot hook %s: invalid phase: %v", name, err))
+		}
+		if hook.OnFailure != "" {
+			if err := validation.StringIn(string(hook.OnFailure), string(HookAbort), string(HookContinue)); err != nil {
+				violations.Add(fmt.Errorf("snapshot hook %s: invalid failure policy: %v", name, err))
+			}
+		}
+		if hook.Timeout < 0 {
+			violations.Add(fmt.Errorf("snapshot hook %s: timeout must not be negative", name))
+		}
+	}
+	if violations.HasError() {
+		return violations
+	}
+	return nil
+}
+
+// logParserCapture is the form of the field names a regex parser may capture
+var logParserCapture = regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9_]*$")
+
+//ValidEntity used to make sure the log parser is in a valid state
+func (p LogParser) ValidEntity() error {
+	violations := validation.NewValidationError()
+	if p.Format != "" {
+		if err := validation.StringIn(string(p.Format), string(ParseJSON), string(ParseLogfmt), string(ParseRegex)); err != nil {
+			violations.Add(fmt.Errorf("invalid log parser format: %v", err))
+		}
+	} else if p.MultilineStart == "" {
+		violations.Add(fmt.Errorf("log parser needs a format or a multiline start pattern"))
+	}
+	if p.Format == ParseRegex {
+		if _, err := regexp.Compile(p.Pattern); err != nil {
+			violations.Add(fmt.Errorf("invalid log parser pattern: %v", err))
+		} else if captures := p.Captures(); len(captures) == 0 {
+			violations.Add(fmt.Errorf("log parser pattern must have at least one named capture"))
+		} else {
+			for _, name := range captures {
+				if !logParserCapture.MatchString(name) {
+					violations.Add(fmt.Errorf("invalid log parser capture name %q", name))
+				}
+			}
+		}
+		// the pattern is written into the logstash configuration as a
+		// quoted string
+		if strings.Contains(p.Pattern, "\"") {
+			violations.Add(fmt.Errorf("log parser pattern must not contain a double quote, use \\x22 instead"))
+		}
+	} else if p.Pattern != "" {
+		violations.Add(fmt.Errorf("log parser pattern is only used by the regex format"))
+	}
+	if p.MultilineStart != "" {
+		if _, err := regexp.Compile(p.MultilineStart); err != nil {
+			violations.Add(fmt.Errorf("invalid log parser multiline start pattern: %v", err))
+		}
+	}
+	if violations.HasError() {
+		return violations
+	}
+	return nil
+}
+
+//ValidEntity used to make sure the vhost rules are in a valid state
+func (r VHostRules) ValidEntity() error {
+	violations := validation.NewValidationError()
+	prefixes := make(map[string]struct{})
+	for _, route := range r.Routes {
+		if !strings.HasPrefix(route.PathPrefix, "/") {
+			violations.Add(fmt.Errorf("route path prefix %q must start with /", route.PathPrefix))
+		}
+		if _, ok := prefixes[route.PathPrefix]; ok {
+			violations.Add(fmt.Errorf("route path prefix %q is not unique", route.PathPrefix))
+		}
+		prefixes[route.PathPrefix] = struct{}{}
+		if strings.TrimSpace(route.Application) == "" {
+			violations.Add(fmt.Errorf("route %s: missing application", route.PathPrefix))
+		}
+	}
+	for _, rule := range r.Headers {
+		if strings.TrimSpace(rule.Name) == "" {
+			violations.Add(fmt.Errorf("header rule: missing header name"))
+		}
+		if err := validation.StringIn(string(rule.Action), string(HeaderSet), string(HeaderAdd), string(HeaderRemove)); err != nil {
+			violations.Add(fmt.Errorf("header rule %s: invalid action: %v", rule.Name, err))
+		}
+	}
+	if r.Timeout < 0 {
+		violations.Add(fmt.Errorf("timeout must not be negative"))
+	}
+	if violations.HasError() {
+		return violations
+	}
+	return nil
+}
+
+//ValidEntity used to make sure the access rules are in a valid state
+func (r AccessRules) ValidEntity() error {
+	violations := validation.NewValidationError()
+	for _, address := range append(append([]string{}, r.Allow...), r.Deny...) {
+		if _, err := ParseCIDR(address); err != nil {
+			violations.Add(fmt.Errorf("invalid address %q: %v", address, err))
+		}
+	}
+	if r.RateLimit < 0 {
+		violations.Add(fmt.Errorf("rate limit must not be negative"))
+	}
+	if r.Burst < 0 {
+		violations.Add(fmt.Errorf("burst must not be negative"))
+	}
+	if violations.HasError() {
+		return violations
+	}
+	return nil
+}
+
+// sloName is the form of an SLO name, which is part of its metric names
+var sloName = regexp.MustCompile("^[a-zA-Z0-9][a-zA-Z0-9_-]*$")
+
+//ValidEntity used to make sure the service level objectives are in a valid
+//state
+func (s SLOs) ValidEntity() error {
+	violations := validation.NewValidationError()
+	names := make(map[string]struct{})
+	for _, slo := range s {
+		if _, ok := names[slo.Name]; ok {
+			violations.Add(fmt.Errorf("slo name %q is not unique", slo.Name))
+		}
+		names[slo.Name] = struct{}{}
+		if err := slo.ValidEntity(); err != nil {
+			violations.Add(err)
+		}
+	}
+	if violations.HasError() {
+		return violations
+	}
+	return nil
+}
+
+//ValidEntity used to make sure the service level objective is in a valid state
+func (s SLO) ValidEntity() error {
+	violations := validation.NewValidationError()
+	if !sloName.MatchString(s.Name) {
+		violations.Add(fmt.Errorf("invalid slo name %q", s.Name))
+	}
+	if err := validation.StringIn(string(s.Type), string(SLOAvailability), string(SLOLatency)); err != nil {
+		violations.Add(fmt.Errorf("slo %s: invalid type: %v", s.Name, err))
+	}
+	if s.Target <= 0 || s.Target >= 100 {
+		violations.Add(fmt.Errorf("slo %s: target must be between 0 and 100 percent", s.Name))
+	}
+	if s.WindowDays < 0 || s.WindowDays > MaxSLOWindowDays {
+		violations.Add(fmt.Errorf("slo %s: window must be between 1 and %d days", s.Name, MaxSLOWindowDays))
+	}
+	if s.Type == SLOLatency {
+		if s.LatencyMS <= 0 {
+			violations.Add(fmt.Errorf("slo %s: latency threshold must be positive", s.Name))
+		}
+		if err := validation.StringIn(s.GetPercentile(), "p50", "p95", "p99"); err != nil {
+			violations.Add(fmt.Errorf("slo %s: invalid percentile: %v", s.Name, err))
+		}
+	} else if s.LatencyMS != 0 || s.Percentile != "" {
+		violations.Add(fmt.Errorf("slo %s: latency settings are only used by latency slos", s.Name))
+	}
+	for _, alert := range s.Alerts {
+		if alert.Hours <= 0 || alert.Hours > s.GetWindowDays()*24 {
+			violations.Add(fmt.Errorf("slo %s: burn rate alert must look back between 1 hour and the window", s.Name))
+		}
+		if alert.BurnRate <= 0 {
+			violations.Add(fmt.Errorf("slo %s: burn rate must be positive", s.Name))
+		}
+		if alert.Severity < 0 || alert.Severity > 5 {
+			violations.Add(fmt.Errorf("slo %s: burn rate severity must be between 1 and 5", s.Name))
+		}
+	}
+	if violations.HasError() {
+		return violations
+	}
+	return nil
+}
+
 func applicationValidation(application string) error {
 	_, err := regexp.Compile(application)
 	if err != nil {
@@ -20,8 +20,10 @@ import (
 	. "github.com/control-center/serviced/domain/servicedefinition"
 	. "github.com/control-center/serviced/domain/servicedefinition/testutils"
 
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestServiceDefinitionValidate(t *testing.T) {
@@ -137,3 +139,289 @@ func TestServiceDefinitionEmptyEndpointName(t *testing.T) {
 		t.Errorf("Unexpected Error %v", err)
 	}
 }
+
+func TestServiceDefinitionNegativeLogRetention(t *testing.T) {
+	sd := CreateValidServiceDefinition()
+	sd.Services[0].LogConfigs = []LogConfig{{Path: "/var/log/app.log", Type: "app", RetentionDays: -1}}
+
+	err := sd.ValidEntity()
+	if err == nil {
+		t.Error("Expected error")
+	} else if !strings.Contains(err.Error(), "invalid retention") {
+		t.Errorf("Unexpected Error %v", err)
+	}
+}
+
+func TestValidateLogParser(t *testing.T) {
+	valid := []LogParser{
+		{Format: ParseJSON},
+		{Format: ParseLogfmt, MultilineStart: `^\d{4}-`},
+		{Format: ParseRegex, Pattern: `^(?P<level>\w+) (?P<message>.*)$`},
+		{MultilineStart: `^\S`},
+	}
+	for _, parser := range valid {
+		if err := parser.ValidEntity(); err != nil {
+			t.Errorf("Unexpected error validating log parser %+v: %v", parser, err)
+		}
+	}
+
+	invalid := []LogParser{
+		{},
+		{Format: "xml"},
+		{Format: ParseJSON, Pattern: `(?P<level>\w+)`},
+		{Format: ParseRegex, Pattern: `(\w+`},
+		{Format: ParseRegex, Pattern: `(\w+)`},
+		{Format: ParseRegex, Pattern: `(?P<_level>\w+)`},
+		{Format: ParseRegex, Pattern: `"(?P<level>\w+)"`},
+		{Format: ParseJSON, MultilineStart: `[`},
+	}
+	for _, parser := range invalid {
+		if err := parser.ValidEntity(); err == nil {
+			t.Errorf("Expected error validating log parser %+v", parser)
+		}
+	}
+
+	sd := CreateValidServiceDefinition()
+	sd.Services[0].LogConfigs = []LogConfig{{Path: "/var/log/app.log", Type: "app", Parser: &LogParser{Format: "xml"}}}
+	if err := sd.ValidEntity(); err == nil {
+		t.Error("Expected error")
+	} else if !strings.Contains(err.Error(), "invalid log parser format") {
+		t.Errorf("Unexpected Error %v", err)
+	}
+}
+
+func TestLogParserCaptures(t *testing.T) {
+	parser := LogParser{Format: ParseRegex, Pattern: `^(?P<level>\w+) (\d+) (?P<message>.*)$`}
+	if captures := parser.Captures(); !reflect.DeepEqual(captures, []string{"level", "message"}) {
+		t.Errorf("Unexpected captures %v", captures)
+	}
+	parser.Format = ParseJSON
+	if captures := parser.Captures(); captures != nil {
+		t.Errorf("Expected no captures, got %v", captures)
+	}
+}
+
+func TestValidateSnapshotHooks(t *testing.T) {
+	sc := SnapshotCommands{}
+	if err := sc.ValidEntity(); err != nil {
+		t.Errorf("Unexpected error validating empty snapshot commands: %v", err)
+	}
+
+	sc.Hooks = []SnapshotHook{
+		{Name: "flush", Phase: HookPreFreeze, Command: "sync"},
+		{Name: "check", Phase: HookPostThaw, Command: "true", Timeout: 10, OnFailure: HookContinue},
+	}
+	if err := sc.ValidEntity(); err != nil {
+		t.Errorf("Unexpected error validating snapshot hooks: %v", err)
+	}
+
+	sc.Hooks = []SnapshotHook{{Name: "bad", Phase: "during", Command: "sync"}}
+	if err := sc.ValidEntity(); err == nil {
+		t.Errorf("Expected error for invalid hook phase")
+	}
+
+	sc.Hooks = []SnapshotHook{{Name: "bad", Phase: HookPreFreeze}}
+	if err := sc.ValidEntity(); err == nil {
+		t.Errorf("Expected error for missing hook command")
+	}
+
+	sc.Hooks = []SnapshotHook{{Name: "bad", Phase: HookPreFreeze, Command: "sync", OnFailure: "ignore"}}
+	if err := sc.ValidEntity(); err == nil {
+		t.Errorf("Expected error for invalid failure policy")
+	}
+
+	sc.Hooks = []SnapshotHook{{Name: "bad", Phase: HookPreFreeze, Command: "sync", Timeout: -1}}
+	if err := sc.ValidEntity(); err == nil {
+		t.Errorf("Expected error for negative timeout")
+	}
+}
+
+func TestSnapshotHookAbortOnFailure(t *testing.T) {
+	if !(SnapshotHook{Phase: HookPreFreeze}).AbortOnFailure() {
+		t.Errorf("Expected pre-freeze hook to abort by default")
+	}
+	if (SnapshotHook{Phase: HookPostFreeze, OnFailure: HookContinue}).AbortOnFailure() {
+		t.Errorf("Expected hook with continue policy not to abort")
+	}
+	if (SnapshotHook{Phase: HookPostThaw, OnFailure: HookAbort}).AbortOnFailure() {
+		t.Errorf("Expected post-thaw hook never to abort")
+	}
+}
+
+func TestValidateLoadBalancer(t *testing.T) {
+	lb := LoadBalancer{}
+	if err := lb.ValidEntity(); err != nil {
+		t.Errorf("Unexpected error validating empty load balancer: %v", err)
+	}
+
+	lb = LoadBalancer{Policy: BalanceWeighted, Weights: map[int]int{0: 3, 1: 1}, MaxFails: -1, FailTimeout: 10}
+	if err := lb.ValidEntity(); err != nil {
+		t.Errorf("Unexpected error validating load balancer: %v", err)
+	}
+
+	lb = LoadBalancer{Policy: "random"}
+	if err := lb.ValidEntity(); err == nil {
+		t.Errorf("Expected error for invalid load balancer policy")
+	}
+
+	lb = LoadBalancer{Weights: map[int]int{0: -1}}
+	if err := lb.ValidEntity(); err == nil {
+		t.Errorf("Expected error for negative weight")
+	}
+}
+
+func TestLoadBalancerDefaults(t *testing.T) {
+	lb := LoadBalancer{Weights: map[int]int{1: 5}}
+	if lb.GetMaxFails() != DefaultMaxFails {
+		t.Errorf("Expected default max fails, got %d", lb.GetMaxFails())
+	}
+	if lb.GetFailTimeout() != DefaultFailTimeout*time.Second {
+		t.Errorf("Expected default fail timeout, got %s", lb.GetFailTimeout())
+	}
+	if lb.GetWeight(0) != 1 || lb.GetWeight(1) != 5 {
+		t.Errorf("Unexpected weights %d, %d", lb.GetWeight(0), lb.GetWeight(1))
+	}
+	if (LoadBalancer{MaxFails: -1}).GetMaxFails() != 0 {
+		t.Errorf("Expected ejection to be disabled")
+	}
+}
+
+func TestValidateVHostRules(t *testing.T) {
+	rules := VHostRules{}
+	if err := rules.ValidEntity(); err != nil {
+		t.Errorf("Unexpected error validating empty vhost rules: %v", err)
+	}
+
+	rules = VHostRules{
+		StickySessions: true,
+		Routes:         []VHostRoute{{PathPrefix: "/api", Application: "api", StripPrefix: true}},
+		Headers:        []HeaderRule{{Action: HeaderSet, Name: "X-Frame-Options", Value: "DENY", Response: true}},
+		Timeout:        30,
+	}
+	if err := rules.ValidEntity(); err != nil {
+		t.Errorf("Unexpected error validating vhost rules: %v", err)
+	}
+
+	rules = VHostRules{Routes: []VHostRoute{{PathPrefix: "api", Application: "api"}}}
+	if err := rules.ValidEntity(); err == nil {
+		t.Errorf("Expected error for relative path prefix")
+	}
+
+	rules = VHostRules{Routes: []VHostRoute{{PathPrefix: "/api", Application: "api"}, {PathPrefix: "/api", Application: "other"}}}
+	if err := rules.ValidEntity(); err == nil {
+		t.Errorf("Expected error for duplicate path prefix")
+	}
+
+	rules = VHostRules{Routes: []VHostRoute{{PathPrefix: "/api"}}}
+	if err := rules.ValidEntity(); err == nil {
+		t.Errorf("Expected error for missing application")
+	}
+
+	rules = VHostRules{Headers: []HeaderRule{{Action: "replace", Name: "X-Test"}}}
+	if err := rules.ValidEntity(); err == nil {
+		t.Errorf("Expected error for invalid header action")
+	}
+
+	rules = VHostRules{Timeout: -1}
+	if err := rules.ValidEntity(); err == nil {
+		t.Errorf("Expected error for negative timeout")
+	}
+}
+
+func TestValidateAccessRules(t *testing.T) {
+	rules := AccessRules{}
+	if err := rules.ValidEntity(); err != nil {
+		t.Errorf("Unexpected error validating empty access rules: %v", err)
+	}
+
+	rules = AccessRules{
+		Allow:     []string{"10.0.0.0/8", "192.168.1.10", "fd00::/8"},
+		Deny:      []string{"10.1.0.0/16"},
+		RateLimit: 2.5,
+	}
+	if err := rules.ValidEntity(); err != nil {
+		t.Errorf("Unexpected error validating access rules: %v", err)
+	}
+	if burst := rules.GetBurst(); burst != 3 {
+		t.Errorf("Expected default burst of 3, got %d", burst)
+	}
+
+	rules = AccessRules{Allow: []string{"10.0.0.0/33"}}
+	if err := rules.ValidEntity(); err == nil {
+		t.Errorf("Expected error for invalid CIDR")
+	}
+
+	rules = AccessRules{Deny: []string{"myhost"}}
+	if err := rules.ValidEntity(); err == nil {
+		t.Errorf("Expected error for invalid address")
+	}
+
+	rules = AccessRules{RateLimit: -1}
+	if err := rules.ValidEntity(); err == nil {
+		t.Errorf("Expected error for negative rate limit")
+	}
+
+	rules = AccessRules{RateLimit: 1, Burst: -1}
+	if err := rules.ValidEntity(); err == nil {
+		t.Errorf("Expected error for negative burst")
+	}
+}
+
+func TestParseCIDR(t *testing.T) {
+	ipnet, err := ParseCIDR("192.168.1.10")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ipnet.String() != "192.168.1.10/32" {
+		t.Errorf("Expected a /32 network, got %s", ipnet)
+	}
+	ipnet, err = ParseCIDR("fd00::1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ipnet.String() != "fd00::1/128" {
+		t.Errorf("Expected a /128 network, got %s", ipnet)
+	}
+}
+
+func TestValidateSLOs(t *testing.T) {
+	slos := SLOs{
+		{Name: "availability", Type: SLOAvailability, Target: 99.9, Alerts: []BurnRateAlert{{Hours: 1, BurnRate: 14.4}, {Hours: 72, BurnRate: 1, Severity: 4}}},
+		{Name: "latency", Type: SLOLatency, Target: 99, WindowDays: 7, LatencyMS: 250, Percentile: "p99"},
+	}
+	if err := slos.ValidEntity(); err != nil {
+		t.Errorf("Unexpected error validating slos: %v", err)
+	}
+	if days := slos[0].GetWindowDays(); days != DefaultSLOWindowDays {
+		t.Errorf("Expected default window of %d days, got %d", DefaultSLOWindowDays, days)
+	}
+	if severity := slos[0].Alerts[0].GetSeverity(); severity != DefaultBurnRateSeverity {
+		t.Errorf("Expected default severity of %d, got %d", DefaultBurnRateSeverity, severity)
+	}
+
+	invalid := []SLO{
+		{Name: "bad name", Type: SLOAvailability, Target: 99},
+		{Name: "bad", Type: "throughput", Target: 99},
+		{Name: "bad", Type: SLOAvailability, Target: 100},
+		{Name: "bad", Type: SLOAvailability, Target: 99, WindowDays: MaxSLOWindowDays + 1},
+		{Name: "bad", Type: SLOAvailability, Target: 99, LatencyMS: 100},
+		{Name: "bad", Type: SLOLatency, Target: 99},
+		{Name: "bad", Type: SLOLatency, Target: 99, LatencyMS: 100, Percentile: "p90"},
+		{Name: "bad", Type: SLOAvailability, Target: 99, WindowDays: 1, Alerts: []BurnRateAlert{{Hours: 25, BurnRate: 2}}},
+		{Name: "bad", Type: SLOAvailability, Target: 99, Alerts: []BurnRateAlert{{Hours: 1}}},
+		{Name: "bad", Type: SLOAvailability, Target: 99, Alerts: []BurnRateAlert{{Hours: 1, BurnRate: 2, Severity: 6}}},
+	}
+	for _, obj := range invalid {
+		if err := obj.ValidEntity(); err == nil {
+			t.Errorf("Expected error for slo %+v", obj)
+		}
+	}
+
+	slos = SLOs{
+		{Name: "up", Type: SLOAvailability, Target: 99},
+		{Name: "up", Type: SLOAvailability, Target: 99.9},
+	}
+	if err := slos.ValidEntity(); err == nil {
+		t.Errorf("Expected error for duplicate slo names")
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+package facade
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/audit"
+	"github.com/control-center/serviced/auth"
+	"github.com/control-center/serviced/datastore"
+	"github.com/control-center/serviced/domain/certificate"
+)
+
+// acmeRenewBefore is how long before they expire that ACME certificates are
+// renewed
+const acmeRenewBefore = 30 * 24 * time.Hour
+
+// ErrACMEDisabled is returned when a certificate is requested from ACME, but
+// no ACME server is configured
+var ErrACMEDisabled = errors.New("no ACME server is configured")
+
+// EnablePublicEndpointACME has the certificate of a vhost obtained from, and
+// renewed by, the ACME server.  If no domains are given, the vhost name must
+// be a fully qualified domain name.  The vhost keeps serving its current
+// certificate until the first one is issued.
+func (f *Facade) EnablePublicEndpointACME(ctx datastore.Context, serviceID, endpointName, vhost string, domains []string) (*certificate.Certificate, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.EnablePublicEndpointACME"))
+	alog := f.auditLogger.Message(ctx, "Enabling Public Endpoint ACME Certificate").Action(audit.Update).ID(serviceID).
+		WithFields(logrus.Fields{
+			"endpointname": endpointName,
+			"vhost":        vhost,
+			"domains":      domains,
+		})
+	logger := plog.WithFields(logrus.Fields{
+		"serviceid":    serviceID,
+		"endpointname": endpointName,
+		"vhost":        vhost,
+	})
+
+	if f.acmeClient == nil {
+		return nil, alog.Error(ErrACMEDisabled)
+	}
+
+	name, err := f.getPublicEndpointName(ctx, serviceID, endpointName, certificate.VHost, vhost)
+	if err != nil {
+		return nil, alog.Error(err)
+	}
+	if len(domains) == 0 {
+		if !strings.Contains(name, ".") {
+			return nil, alog.Error(fmt.Errorf("vhost %s is not a fully qualified domain name, so its domains must be given", name))
+		}
+		domains = []string{name}
+	}
+	for i := range domains {
+		domains[i] = strings.ToLower(strings.TrimSpace(domains[i]))
+	}
+
+	cert, err := f.certStore.Get(ctx, certificate.VHost, name)
+	if datastore.IsErrNoSuchEntity(err) {
+		cert = &certificate.Certificate{Kind: certificate.VHost, Name: name}
+	} else if err != nil {
+		logger.WithError(err).Debug("Could not look up certificate")
+		return nil, alog.Error(err)
+	}
+	cert.ACMEDomains = domains
+	if err := cert.ValidEntity(); err != nil {
+		return nil, alog.Error(err)
+	}
+	if err := f.certStore.Put(ctx, cert); err != nil {
+		logger.WithError(err).Debug("Could not save certificate")
+		return nil, alog.Error(err)
+	}
+	alog = alog.Entity(cert)
+
+	// if this fails, the certificate is retried when certificates are renewed
+	cert, err = f.issueACMECertificate(ctx, cert)
+	if err != nil {
+		return nil, alog.Error(err)
+	}
+	alog.Succeeded()
+	return cert, nil
+}
+
+// RenewCertificates obtains the ACME certificates that are pending or expire
+// soon.
+func (f *Facade) RenewCertificates(ctx datastore.Context) error {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.RenewCertificates"))
+	if f.acmeClient == nil {
+		return nil
+	}
+	certs, err := f.certStore.GetCertificates(ctx)
+	if err != nil {
+		plog.WithError(err).Debug("Could not look up certificates")
+		return err
+	}
+	failed := 0
+	for i := range certs {
+		cert := &certs[i]
+		if len(cert.ACMEDomains) == 0 || !(cert.IsPending() || cert.ExpiresWithin(acmeRenewBefore)) {
+			continue
+		}
+		if _, err := f.issueACMECertificate(ctx, cert); err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("could not renew %d certificate(s)", failed)
+	}
+	return nil
+}
+
+// GetACMEChallenge returns the response to a pending HTTP-01 challenge
+func (f *Facade) GetACMEChallenge(token string) (string, bool) {
+	return f.challenges.Get(token)
+}
+
+// issueACMECertificate obtains a new certificate for the domains of cert,
+// and tells the hosts to reload their certificates.
+func (f *Facade) issueACMECertificate(ctx datastore.Context, cert *certificate.Certificate) (*certificate.Certificate, error) {
+	logger := plog.WithFields(logrus.Fields{
+		"kind":    cert.Kind,
+		"name":    cert.Name,
+		"domains": cert.ACMEDomains,
+	})
+	alog := f.auditLogger.Message(ctx, "Issuing ACME Certificate").Action(audit.Update).Entity(cert).
+		WithField("domains", strings.Join(cert.ACMEDomains, ","))
+
+	certPEM, keyPEM, err := f.acmeClient.Obtain(cert.ACMEDomains, f.challenges)
+	if err != nil {
+		logger.WithError(err).Warn("Could not obtain certificate from ACME server")
+		return nil, alog.Error(err)
+	}
+	secret, err := auth.MasterSecret(certificate.SecretPurpose)
+	if err != nil {
+		logger.WithError(err).Debug("Could not get the secret for encrypting certificates")
+		return nil, alog.Error(err)
+	}
+	issued, err := certificate.New(cert.Kind, cert.Name, certPEM, keyPEM, secret)
+	if err != nil {
+		logger.WithError(err).Warn("Could not load certificate from ACME server")
+		return nil, alog.Error(err)
+	}
+	issued.ACMEDomains = cert.ACMEDomains
+	issued.DatabaseVersion = cert.DatabaseVersion
+	if err := f.certStore.Put(ctx, issued); err != nil {
+		logger.WithError(err).Warn("Could not save certificate from ACME server")
+		return nil, alog.Error(err)
+	}
+	if err := f.zzk.NotifyCertificates(); err != nil {
+		logger.WithError(err).Warn("Could not notify hosts of the new certificate")
+	}
+	logger.WithField("notafter", issued.NotAfter).Info("Issued ACME certificate")
+	alog.Succeeded()
+	return issued, nil
+}
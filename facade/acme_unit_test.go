@@ -0,0 +1,196 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+// +build unit
+
+package facade_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/control-center/serviced/acme"
+	"github.com/control-center/serviced/acme/acmetest"
+	"github.com/control-center/serviced/datastore"
+	"github.com/control-center/serviced/domain/certificate"
+	"github.com/control-center/serviced/facade"
+	"github.com/control-center/serviced/facade/mocks"
+	"github.com/stretchr/testify/mock"
+	. "gopkg.in/check.v1"
+)
+
+// setupACME has the facade obtain certificates from a mock ACME client, which
+// issues a self-signed certificate for the first domain of each order, and
+// returns a function that removes it.
+func (ft *FacadeUnitTest) setupACME(c *C, domains ...string) (*mocks.ACMEClient, func()) {
+	client := &mocks.ACMEClient{}
+	for _, domain := range domains {
+		certPEM, keyPEM := testCertificatePEM(c, domain)
+		client.On("Obtain", []string{domain}, mock.AnythingOfType("*acme.Challenges")).Return(certPEM, keyPEM, nil)
+	}
+	ft.Facade.SetACMEClient(client)
+	return client, func() { ft.Facade.SetACMEClient(nil) }
+}
+
+// setupACMEServer has the facade obtain certificates from a test ACME server,
+// which validates the challenges that the facade serves, and returns a
+// function that stops it.
+func (ft *FacadeUnitTest) setupACMEServer(c *C) (*acmetest.Server, func()) {
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acme.ServeChallenge(w, r, ft.Facade.GetACMEChallenge)
+	}))
+	server := acmetest.NewServer(strings.TrimPrefix(web.URL, "http://"))
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, IsNil)
+	ft.Facade.SetACMEClient(acme.NewClient(server.DirectoryURL(), key, ""))
+	return server, func() {
+		ft.Facade.SetACMEClient(nil)
+		server.Close()
+		web.Close()
+	}
+}
+
+func (ft *FacadeUnitTest) Test_EnablePublicEndpointACME_Disabled(c *C) {
+	_, err := ft.Facade.EnablePublicEndpointACME(ft.ctx, "svcid", "web", "myapp", nil)
+	c.Assert(err, Equals, facade.ErrACMEDisabled)
+}
+
+func (ft *FacadeUnitTest) Test_EnablePublicEndpointACME(c *C) {
+	client, cleanup := ft.setupACME(c, "myapp.example.com")
+	defer cleanup()
+	ft.setupCertificateService(c)
+
+	// the vhost name is not a domain
+	_, err := ft.Facade.EnablePublicEndpointACME(ft.ctx, "svcid", "web", "MyApp", nil)
+	c.Assert(err, NotNil)
+
+	ft.certStore.On("Get", ft.ctx, certificate.VHost, "myapp").Return(nil, datastore.ErrNoSuchEntity{})
+	stored := []certificate.Certificate{}
+	ft.certStore.On("Put", ft.ctx, mock.AnythingOfType("*certificate.Certificate")).Return(nil).Run(func(a mock.Arguments) {
+		stored = append(stored, *a.Get(1).(*certificate.Certificate))
+	})
+	ft.zzk.On("NotifyCertificates").Return(nil).Once()
+
+	cert, err := ft.Facade.EnablePublicEndpointACME(ft.ctx, "svcid", "web", "MyApp", []string{"MyApp.example.com"})
+	c.Assert(err, IsNil)
+	c.Assert(stored, HasLen, 2)
+
+	// the certificate is pending until it is issued
+	c.Check(stored[0].IsPending(), Equals, true)
+	c.Check(stored[0].ACMEDomains, DeepEquals, []string{"myapp.example.com"})
+
+	c.Check(stored[1].Name, Equals, "myapp")
+	c.Check(stored[1].IsPending(), Equals, false)
+	c.Check(stored[1].ACMEDomains, DeepEquals, []string{"myapp.example.com"})
+	c.Check(stored[1].Subjects, DeepEquals, []string{"myapp.example.com"})
+	c.Check(cert.NotAfter, Equals, stored[1].NotAfter)
+	ft.zzk.AssertExpectations(c)
+	client.AssertExpectations(c)
+}
+
+func (ft *FacadeUnitTest) Test_GetACMEChallenge(c *C) {
+	client, cleanup := ft.setupACME(c)
+	defer cleanup()
+	pending := certificate.Certificate{Kind: certificate.VHost, Name: "pending", ACMEDomains: []string{"pending.example.com"}}
+	ft.certStore.On("GetCertificates", ft.ctx).Return([]certificate.Certificate{pending}, nil)
+
+	// the facade serves the challenges that the client presents
+	served := ""
+	client.On("Obtain", []string{"pending.example.com"}, mock.Anything).Return(nil, nil, acme.ErrNoChallenge).Run(func(a mock.Arguments) {
+		solver := a.Get(1).(acme.Solver)
+		solver.Present("token1", "token1.thumbprint")
+		served, _ = ft.Facade.GetACMEChallenge("token1")
+		solver.CleanUp("token1")
+	})
+
+	err := ft.Facade.RenewCertificates(ft.ctx)
+	c.Assert(err, ErrorMatches, "could not renew 1 certificate\\(s\\)")
+	c.Check(served, Equals, "token1.thumbprint")
+	_, ok := ft.Facade.GetACMEChallenge("token1")
+	c.Check(ok, Equals, false)
+}
+
+func (ft *FacadeUnitTest) Test_RenewCertificates(c *C) {
+	client, cleanup := ft.setupACME(c, "expiring.example.com", "pending.example.com")
+	defer cleanup()
+
+	uploaded := certificate.Certificate{Kind: certificate.VHost, Name: "uploaded", CertPEM: "x", NotAfter: time.Now()}
+	current := certificate.Certificate{Kind: certificate.VHost, Name: "current", CertPEM: "x", NotAfter: time.Now().Add(60 * 24 * time.Hour),
+		ACMEDomains: []string{"current.example.com"}}
+	expiring := certificate.Certificate{Kind: certificate.VHost, Name: "expiring", CertPEM: "x", NotAfter: time.Now().Add(24 * time.Hour),
+		ACMEDomains: []string{"expiring.example.com"}}
+	pending := certificate.Certificate{Kind: certificate.VHost, Name: "pending", ACMEDomains: []string{"pending.example.com"}}
+	ft.certStore.On("GetCertificates", ft.ctx).Return([]certificate.Certificate{uploaded, current, expiring, pending}, nil)
+	renewed := []string{}
+	ft.certStore.On("Put", ft.ctx, mock.AnythingOfType("*certificate.Certificate")).Return(nil).Run(func(a mock.Arguments) {
+		renewed = append(renewed, a.Get(1).(*certificate.Certificate).Name)
+	})
+	ft.zzk.On("NotifyCertificates").Return(nil)
+
+	err := ft.Facade.RenewCertificates(ft.ctx)
+	c.Assert(err, IsNil)
+	c.Check(renewed, DeepEquals, []string{"expiring", "pending"})
+	client.AssertExpectations(c)
+}
+
+func (ft *FacadeUnitTest) Test_RenewCertificates_ACMEServer(c *C) {
+	server, cleanup := ft.setupACMEServer(c)
+	defer cleanup()
+
+	expiring := certificate.Certificate{Kind: certificate.VHost, Name: "expiring", CertPEM: "x", NotAfter: time.Now().Add(24 * time.Hour),
+		ACMEDomains: []string{"expiring.example.com", "www.example.com"}}
+	pending := certificate.Certificate{Kind: certificate.VHost, Name: "pending", ACMEDomains: []string{"pending.example.com"}}
+	ft.certStore.On("GetCertificates", ft.ctx).Return([]certificate.Certificate{expiring, pending}, nil)
+	renewed := []certificate.Certificate{}
+	ft.certStore.On("Put", ft.ctx, mock.AnythingOfType("*certificate.Certificate")).Return(nil).Run(func(a mock.Arguments) {
+		renewed = append(renewed, *a.Get(1).(*certificate.Certificate))
+	})
+	ft.zzk.On("NotifyCertificates").Return(nil).Twice()
+
+	err := ft.Facade.RenewCertificates(ft.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(renewed, HasLen, 2)
+	for i, cert := range []certificate.Certificate{expiring, pending} {
+		c.Check(renewed[i].Name, Equals, cert.Name)
+		c.Check(renewed[i].ACMEDomains, DeepEquals, cert.ACMEDomains)
+		c.Check(renewed[i].Subjects, DeepEquals, cert.ACMEDomains)
+		c.Check(renewed[i].IsPending(), Equals, false)
+		c.Check(renewed[i].NotAfter.After(time.Now().Add(server.Validity-time.Hour)), Equals, true)
+	}
+	ft.zzk.AssertExpectations(c)
+
+	// the challenges are no longer served once the certificates are issued
+	_, ok := ft.Facade.GetACMEChallenge("anything")
+	c.Check(ok, Equals, false)
+}
+
+func (ft *FacadeUnitTest) Test_RenewCertificates_ACMEServerInvalid(c *C) {
+	server, cleanup := ft.setupACMEServer(c)
+	defer cleanup()
+
+	// the server cannot reach the challenges, so the domain is not validated
+	server.ChallengeAddr = "127.0.0.1:1"
+	pending := certificate.Certificate{Kind: certificate.VHost, Name: "pending", ACMEDomains: []string{"pending.example.com"}}
+	ft.certStore.On("GetCertificates", ft.ctx).Return([]certificate.Certificate{pending}, nil)
+
+	err := ft.Facade.RenewCertificates(ft.ctx)
+	c.Assert(err, ErrorMatches, "could not renew 1 certificate\\(s\\)")
+	ft.certStore.AssertNotCalled(c, "Put", ft.ctx, mock.Anything)
+	ft.zzk.AssertNotCalled(c, "NotifyCertificates")
+}
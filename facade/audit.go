@@ -0,0 +1,74 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package facade
+
+import (
+	"github.com/control-center/serviced/datastore"
+	"github.com/control-center/serviced/domain/auditlog"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 10000
+	auditVerifyBatch  = 1000
+)
+
+// GetAuditEntries returns a page of entries from the audit trail, newest
+// first.
+func (f *Facade) GetAuditEntries(ctx datastore.Context, query auditlog.Query) ([]auditlog.Entry, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.GetAuditEntries"))
+	if query.Limit <= 0 {
+		query.Limit = defaultAuditLimit
+	} else if query.Limit > maxAuditLimit {
+		query.Limit = maxAuditLimit
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+	entries, err := f.auditStore.Search(ctx, query)
+	if err != nil {
+		plog.WithError(err).Error("Could not search the audit trail")
+		return nil, err
+	}
+	return entries, nil
+}
+
+// VerifyAuditTrail walks the audit trail from the first entry and checks
+// that the hash chain is unbroken.
+func (f *Facade) VerifyAuditTrail(ctx datastore.Context) (*auditlog.Verification, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.VerifyAuditTrail"))
+	result := &auditlog.Verification{Valid: true}
+	var prev *auditlog.Entry
+	for {
+		entries, err := f.auditStore.Range(ctx, result.LastSeq+1, auditVerifyBatch)
+		if err != nil {
+			plog.WithError(err).Error("Could not read the audit trail")
+			return nil, err
+		}
+		for i := range entries {
+			if err := auditlog.Verify(prev, entries[i:i+1]); err != nil {
+				plog.WithError(err).Warn("Audit trail failed verification")
+				result.Valid = false
+				result.Error = err.Error()
+				return result, nil
+			}
+			prev = &entries[i]
+			result.Count++
+			result.LastSeq, result.LastHash = prev.Seq, prev.Hash
+		}
+		if len(entries) < auditVerifyBatch {
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package facade_test
+
+import (
+	"time"
+
+	"github.com/control-center/serviced/audit"
+	"github.com/control-center/serviced/domain/auditlog"
+	"github.com/control-center/serviced/volume"
+	"github.com/stretchr/testify/mock"
+	. "gopkg.in/check.v1"
+)
+
+func auditChain(n int) []auditlog.Entry {
+	entries := make([]auditlog.Entry, n)
+	var prev *auditlog.Entry
+	for i := range entries {
+		entries[i] = auditlog.Entry{
+			Timestamp: time.Date(2018, 1, 1, 0, i, 0, 0, time.UTC),
+			User:      "admin",
+			Action:    "start",
+			Success:   true,
+		}
+		entries[i].Link(prev)
+		prev = &entries[i]
+	}
+	return entries
+}
+
+func (ft *FacadeUnitTest) Test_GetAuditEntriesDefaultLimit(c *C) {
+	query := auditlog.Query{User: "admin", Limit: 100}
+	ft.auditStore.On("Search", ft.ctx, query).Return(auditChain(1), nil)
+	entries, err := ft.Facade.GetAuditEntries(ft.ctx, auditlog.Query{User: "admin"})
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 1)
+	ft.auditStore.AssertExpectations(c)
+}
+
+func (ft *FacadeUnitTest) Test_VerifyAuditTrail(c *C) {
+	entries := auditChain(3)
+	ft.auditStore.On("Range", ft.ctx, int64(1), 1000).Return(entries, nil)
+	result, err := ft.Facade.VerifyAuditTrail(ft.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(result.Valid, Equals, true)
+	c.Assert(result.Count, Equals, 3)
+	c.Assert(result.LastSeq, Equals, int64(3))
+	c.Assert(result.LastHash, Equals, entries[2].Hash)
+}
+
+func (ft *FacadeUnitTest) Test_VerifyAuditTrailTampered(c *C) {
+	entries := auditChain(3)
+	entries[1].Success = false
+	ft.auditStore.On("Range", ft.ctx, int64(1), 1000).Return(entries, nil)
+	result, err := ft.Facade.VerifyAuditTrail(ft.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(result.Valid, Equals, false)
+	c.Assert(result.Count, Equals, 1)
+	c.Assert(result.LastSeq, Equals, int64(1))
+	c.Assert(result.Error, Not(Equals), "")
+}
+
+func (ft *FacadeUnitTest) Test_AuditTrailAppend(c *C) {
+	ft.Facade.SetAuditLogger(audit.NewLogger(ft.auditStore))
+	ft.ctx.On("User").Return("admin")
+	last := auditChain(1)[0]
+	ft.auditStore.On("Last", ft.ctx).Return(&last, nil)
+	var appended *auditlog.Entry
+	ft.auditStore.On("Put", ft.ctx, mock.AnythingOfType("*auditlog.Entry")).Return(nil).Run(func(a mock.Arguments) {
+		appended = a.Get(1).(*auditlog.Entry)
+	})
+
+	// audited actions are appended to the same store that is queried
+	err := ft.Facade.SetStorageQuota(ft.ctx, "tenant", volume.StorageQuota{SoftLimit: 20, HardLimit: 10})
+	c.Assert(err, Equals, volume.ErrInvalidQuota)
+	c.Assert(appended, NotNil)
+	c.Check(appended.User, Equals, "admin")
+	c.Check(appended.Action, Equals, audit.Update)
+	c.Check(appended.EntityID, Equals, "tenant")
+	c.Check(appended.Success, Equals, false)
+	c.Check(appended.Seq, Equals, last.Seq+1)
+	ft.auditStore.AssertExpectations(c)
+}
@@ -0,0 +1,180 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package facade
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/audit"
+	"github.com/control-center/serviced/auth"
+	"github.com/control-center/serviced/datastore"
+	"github.com/control-center/serviced/domain/certificate"
+)
+
+type certificatesByName []certificate.Certificate
+
+func (c certificatesByName) Len() int      { return len(c) }
+func (c certificatesByName) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c certificatesByName) Less(i, j int) bool {
+	if c[i].Kind != c[j].Kind {
+		return c[i].Kind < c[j].Kind
+	}
+	return c[i].Name < c[j].Name
+}
+
+// SetPublicEndpointCertificate sets the TLS certificate of a vhost or public
+// port of a service.  The private key is encrypted before it is stored, and
+// the hosts serving the public endpoint are told to reload their
+// certificates.
+func (f *Facade) SetPublicEndpointCertificate(ctx datastore.Context, serviceID, endpointName string, kind certificate.Kind, name string, certPEM, keyPEM []byte) (*certificate.Certificate, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.SetPublicEndpointCertificate"))
+	alog := f.auditLogger.Message(ctx, "Setting Public Endpoint Certificate").Action(audit.Update).ID(serviceID).
+		WithFields(logrus.Fields{
+			"endpointname": endpointName,
+			"kind":         kind,
+			"name":         name,
+		})
+	logger := plog.WithFields(logrus.Fields{
+		"serviceid":    serviceID,
+		"endpointname": endpointName,
+		"kind":         kind,
+		"name":         name,
+	})
+
+	name, err := f.getPublicEndpointName(ctx, serviceID, endpointName, kind, name)
+	if err != nil {
+		return nil, alog.Error(err)
+	}
+
+	secret, err := auth.MasterSecret(certificate.SecretPurpose)
+	if err != nil {
+		logger.WithError(err).Debug("Could not get the secret for encrypting certificates")
+		return nil, alog.Error(err)
+	}
+
+	cert, err := certificate.New(kind, name, certPEM, keyPEM, secret)
+	if err != nil {
+		logger.WithError(err).Debug("Could not load certificate")
+		return nil, alog.Error(err)
+	}
+	if err := cert.ValidEntity(); err != nil {
+		return nil, alog.Error(err)
+	}
+	alog = alog.Entity(cert)
+
+	if current, err := f.certStore.Get(ctx, kind, name); err == nil {
+		cert.DatabaseVersion = current.DatabaseVersion
+	} else if !datastore.IsErrNoSuchEntity(err) {
+		logger.WithError(err).Debug("Could not look up certificate")
+		return nil, alog.Error(err)
+	}
+
+	if err := f.certStore.Put(ctx, cert); err != nil {
+		logger.WithError(err).Debug("Could not save certificate")
+		return nil, alog.Error(err)
+	}
+
+	if err := f.zzk.NotifyCertificates(); err != nil {
+		logger.WithError(err).Warn("Could not notify hosts of the new certificate")
+	}
+
+	logger.WithField("notafter", cert.NotAfter).Info("Set public endpoint certificate")
+	alog.Succeeded()
+	return cert, nil
+}
+
+// RemovePublicEndpointCertificate removes the TLS certificate of a vhost or
+// public port, which falls back to the default certificate.
+func (f *Facade) RemovePublicEndpointCertificate(ctx datastore.Context, serviceID, endpointName string, kind certificate.Kind, name string) error {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.RemovePublicEndpointCertificate"))
+	alog := f.auditLogger.Message(ctx, "Removing Public Endpoint Certificate").Action(audit.Remove).ID(serviceID).
+		WithFields(logrus.Fields{
+			"endpointname": endpointName,
+			"kind":         kind,
+			"name":         name,
+		})
+
+	name, err := f.getPublicEndpointName(ctx, serviceID, endpointName, kind, name)
+	if err != nil {
+		return alog.Error(err)
+	}
+	if err := f.removeCertificate(ctx, kind, name); err != nil {
+		return alog.Error(err)
+	}
+	alog.Succeeded()
+	return nil
+}
+
+// GetCertificates returns the certificates of all vhosts and public ports,
+// sorted by kind and name.
+func (f *Facade) GetCertificates(ctx datastore.Context) ([]certificate.Certificate, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.GetCertificates"))
+	certs, err := f.certStore.GetCertificates(ctx)
+	if err != nil {
+		plog.WithError(err).Debug("Could not look up certificates")
+		return nil, err
+	}
+	sort.Sort(certificatesByName(certs))
+	return certs, nil
+}
+
+// removeCertificate deletes a certificate, if it exists, and tells the hosts
+// to reload their certificates.
+func (f *Facade) removeCertificate(ctx datastore.Context, kind certificate.Kind, name string) error {
+	logger := plog.WithFields(logrus.Fields{
+		"kind": kind,
+		"name": name,
+	})
+	if err := f.certStore.Delete(ctx, kind, name); datastore.IsErrNoSuchEntity(err) {
+		return nil
+	} else if err != nil {
+		logger.WithError(err).Debug("Could not delete certificate")
+		return err
+	}
+	if err := f.zzk.NotifyCertificates(); err != nil {
+		logger.WithError(err).Warn("Could not notify hosts of the removed certificate")
+	}
+	logger.Info("Removed public endpoint certificate")
+	return nil
+}
+
+// getPublicEndpointName returns the name of a vhost or public port of a
+// service, as it is known to the hosts serving it.
+func (f *Facade) getPublicEndpointName(ctx datastore.Context, serviceID, endpointName string, kind certificate.Kind, name string) (string, error) {
+	svc, err := f.GetService(ctx, serviceID)
+	if err != nil {
+		return "", fmt.Errorf("could not find service %s: %s", serviceID, err)
+	}
+	switch kind {
+	case certificate.VHost:
+		vhost := svc.GetVirtualHost(endpointName, name)
+		if vhost == nil {
+			return "", fmt.Errorf("vhost %s not found in service %s", name, svc.Name)
+		}
+		return strings.ToLower(vhost.Name), nil
+	case certificate.Port:
+		port := svc.GetPort(endpointName, name)
+		if port == nil {
+			return "", fmt.Errorf("port %s not found in service %s", name, svc.Name)
+		}
+		if !port.UseTLS {
+			return "", fmt.Errorf("port %s of service %s does not use TLS", name, svc.Name)
+		}
+		return port.PortAddr, nil
+	}
+	return "", fmt.Errorf("unknown certificate kind %s", kind)
+}
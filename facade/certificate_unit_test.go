@@ -0,0 +1,138 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package facade_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	"github.com/control-center/serviced/auth"
+	"github.com/control-center/serviced/datastore"
+	"github.com/control-center/serviced/domain/certificate"
+	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/domain/serviceconfigfile"
+	"github.com/control-center/serviced/domain/servicedefinition"
+	"github.com/stretchr/testify/mock"
+	. "gopkg.in/check.v1"
+)
+
+func testCertificatePEM(c *C, name string) ([]byte, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	c.Assert(err, IsNil)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	c.Assert(err, IsNil)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return certPEM, keyPEM
+}
+
+func (ft *FacadeUnitTest) setupCertificateService(c *C) {
+	svc := service.Service{
+		ID:   "svcid",
+		Name: "svc",
+		Endpoints: []service.ServiceEndpoint{
+			{
+				Application: "web",
+				Purpose:     "export",
+				VHostList:   []servicedefinition.VHost{{Name: "MyApp", Enabled: true}},
+				PortList: []servicedefinition.Port{
+					{PortAddr: ":8443", Enabled: true, UseTLS: true, Protocol: "https"},
+					{PortAddr: ":8080", Enabled: true, Protocol: "http"},
+				},
+			},
+		},
+	}
+	ft.serviceStore.On("GetServiceDetails", ft.ctx, "svcid").Return(&service.ServiceDetails{ID: "svcid"}, nil)
+	ft.serviceStore.On("Get", ft.ctx, "svcid").Return(&svc, nil)
+	ft.configStore.On("GetConfigFiles", ft.ctx, "svcid", "/svcid").Return([]*serviceconfigfile.SvcConfigFile{}, nil)
+}
+
+func (ft *FacadeUnitTest) Test_SetPublicEndpointCertificate_VHost(c *C) {
+	ft.setupCertificateService(c)
+	certPEM, keyPEM := testCertificatePEM(c, "myapp.example.com")
+
+	ft.certStore.On("Get", ft.ctx, certificate.VHost, "myapp").Return(nil, datastore.ErrNoSuchEntity{})
+	var stored *certificate.Certificate
+	ft.certStore.On("Put", ft.ctx, mock.AnythingOfType("*certificate.Certificate")).Return(nil).Run(func(a mock.Arguments) {
+		stored = a.Get(1).(*certificate.Certificate)
+	})
+	ft.zzk.On("NotifyCertificates").Return(nil).Once()
+
+	cert, err := ft.Facade.SetPublicEndpointCertificate(ft.ctx, "svcid", "web", certificate.VHost, "MyApp", certPEM, keyPEM)
+	c.Assert(err, IsNil)
+	c.Assert(stored, Equals, cert)
+	c.Check(cert.Name, Equals, "myapp")
+	c.Check(cert.Subjects, DeepEquals, []string{"myapp.example.com"})
+	c.Check(cert.EncryptedKey, Not(Equals), string(keyPEM))
+
+	// the key can be decrypted with the master secret
+	secret, err := auth.MasterSecret(certificate.SecretPurpose)
+	c.Assert(err, IsNil)
+	_, err = cert.KeyPair(secret)
+	c.Assert(err, IsNil)
+	ft.zzk.AssertExpectations(c)
+}
+
+func (ft *FacadeUnitTest) Test_SetPublicEndpointCertificate_Port(c *C) {
+	ft.setupCertificateService(c)
+	certPEM, keyPEM := testCertificatePEM(c, "example.com")
+
+	// the port does not use tls
+	_, err := ft.Facade.SetPublicEndpointCertificate(ft.ctx, "svcid", "web", certificate.Port, ":8080", certPEM, keyPEM)
+	c.Assert(err, NotNil)
+
+	// the port does not exist
+	_, err = ft.Facade.SetPublicEndpointCertificate(ft.ctx, "svcid", "web", certificate.Port, ":9443", certPEM, keyPEM)
+	c.Assert(err, NotNil)
+
+	// the key does not match
+	_, otherKeyPEM := testCertificatePEM(c, "example.com")
+	_, err = ft.Facade.SetPublicEndpointCertificate(ft.ctx, "svcid", "web", certificate.Port, ":8443", certPEM, otherKeyPEM)
+	c.Assert(err, NotNil)
+
+	// an existing certificate is replaced
+	current := &certificate.Certificate{}
+	current.DatabaseVersion = 3
+	ft.certStore.On("Get", ft.ctx, certificate.Port, ":8443").Return(current, nil)
+	ft.certStore.On("Put", ft.ctx, mock.AnythingOfType("*certificate.Certificate")).Return(nil)
+	ft.zzk.On("NotifyCertificates").Return(nil).Once()
+
+	cert, err := ft.Facade.SetPublicEndpointCertificate(ft.ctx, "svcid", "web", certificate.Port, ":8443", certPEM, keyPEM)
+	c.Assert(err, IsNil)
+	c.Check(cert.DatabaseVersion, Equals, 3)
+	ft.certStore.AssertNumberOfCalls(c, "Put", 1)
+}
+
+func (ft *FacadeUnitTest) Test_RemovePublicEndpointCertificate(c *C) {
+	ft.setupCertificateService(c)
+	ft.certStore.On("Delete", ft.ctx, certificate.VHost, "myapp").Return(nil)
+	ft.zzk.On("NotifyCertificates").Return(nil).Once()
+
+	err := ft.Facade.RemovePublicEndpointCertificate(ft.ctx, "svcid", "web", certificate.VHost, "myapp")
+	c.Assert(err, IsNil)
+	ft.zzk.AssertExpectations(c)
+}
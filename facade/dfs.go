@@ -33,6 +33,7 @@ import (
 	"github.com/control-center/serviced/dfs"
 	"github.com/control-center/serviced/domain/pool"
 	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/domain/servicedefinition"
 	"github.com/control-center/serviced/metrics"
 	"github.com/control-center/serviced/volume"
 	"github.com/dustin/go-humanize"
@@ -253,6 +254,9 @@ func (f *Facade) Commit(ctx datastore.Context, ctrID, message string, tags []str
 		return "", err
 	}
 	logger = logger.WithField("tenantid", tenantID)
+	if err := f.PrePullImages(ctx, tenantID); err != nil {
+		logger.WithError(err).Warn("Could not pre-pull images for tenant")
+	}
 	snapshotID, err := f.Snapshot(ctx, tenantID, message, tags, snapshotSpacePercent)
 	if err != nil {
 		logger.WithError(err).Debug("Could not snapshot tenant")
@@ -778,10 +782,17 @@ func (f *Facade) Snapshot(ctx datastore.Context, serviceID, message string, tags
 		return "", err
 	}
 
+	var snapshotID string
+	hooks := dfs.SnapshotHookRecord{}
+	hasHooks := hasSnapshotHooks(servicesToPause, servicedefinition.HookPreFreeze, servicedefinition.HookPostFreeze, servicedefinition.HookPostThaw)
 	defer func() {
+		hooks.Thawed = time.Now()
 		// Refresh service objects in case something has changed (like current state)
 		servicesToPause = f.GetServicesForScheduling(ctx, pausedServiceIds)
 		scheduleServices(f, servicesToPause, ctx, tenantID, service.SVCRun, false)
+		if hasHooks && snapshotID != "" {
+			f.recordSnapshotHooks(ctx, snapshotID, servicesToPause, pausedServiceIds, hooks)
+		}
 	}()
 
 	// Wait for the paused services to reach the paused state (and other services to reach stopped)
@@ -789,7 +800,17 @@ func (f *Facade) Snapshot(ctx datastore.Context, serviceID, message string, tags
 		logger.WithError(err).Debug("Could not wait for services to pause during snapshot")
 		return "", err
 	}
+	hooks.Frozen = time.Now()
 	logger.Infof("Services are now paused for snapshot")
+
+	// Cancel the snapshot if any of the quiesce hooks failed
+	if hasHooks {
+		hooks.Results = f.getSnapshotHookResults(ctx, servicesToPause, servicedefinition.HookPreFreeze, servicedefinition.HookPostFreeze)
+		if err := checkSnapshotHookResults(hooks.Results); err != nil {
+			logger.WithError(err).Warn("Cancelling snapshot")
+			return "", err
+		}
+	}
 	data := dfs.SnapshotInfo{
 		SnapshotInfo: &volume.SnapshotInfo{
 			TenantID: tenantID,
@@ -799,7 +820,7 @@ func (f *Facade) Snapshot(ctx datastore.Context, serviceID, message string, tags
 		Services: svcs,
 		Images:   images,
 	}
-	snapshotID, err := f.dfs.Snapshot(data, snapshotSpacePercent)
+	snapshotID, err = f.dfs.Snapshot(data, snapshotSpacePercent)
 	if err != nil {
 		logger.WithError(err).Debug("Could not snapshot disk and images for tenant")
 		return "", err
@@ -16,9 +16,12 @@ package facade
 import (
 	"time"
 
+	"github.com/control-center/serviced/acme"
 	"github.com/control-center/serviced/audit"
 	"github.com/control-center/serviced/auth"
 	"github.com/control-center/serviced/dfs"
+	"github.com/control-center/serviced/domain/auditlog"
+	"github.com/control-center/serviced/domain/certificate"
 	"github.com/control-center/serviced/domain/host"
 	"github.com/control-center/serviced/domain/hostkey"
 	"github.com/control-center/serviced/domain/pool"
@@ -31,12 +34,21 @@ import (
 	"github.com/control-center/serviced/logging"
 	"github.com/control-center/serviced/metrics"
 	"github.com/control-center/serviced/scheduler/servicestatemanager"
+	"github.com/control-center/serviced/slo"
 	"github.com/control-center/serviced/domain/logfilter"
 )
 
 type MetricsClient interface {
 	GetInstanceMemoryStats(time.Time, ...metrics.ServiceInstance) ([]metrics.MemoryUsageStats, error)
+	GetInstanceUsageHistory(time.Time, ...metrics.ServiceInstance) ([]metrics.InstanceUsageHistory, error)
 	GetAvailableStorage(time.Duration, string, ...string) (*metrics.StorageMetrics, error)
+	GetPublicEndpointLatency(time.Duration, string, ...string) (float64, bool, error)
+}
+
+// ACMEClient obtains certificates from an ACME server, answering its
+// challenges with solver
+type ACMEClient interface {
+	Obtain(domains []string, solver acme.Solver) (certPEM, keyPEM []byte, err error)
 }
 
 // instantiate the package logger
@@ -47,8 +59,11 @@ var _ FacadeInterface = &Facade{}
 
 // New creates an initialized Facade instance
 func New() *Facade {
+	// the audit trail is appended to and queried from the same store
+	auditStore := auditlog.NewStore()
 	return &Facade{
-		auditLogger:    audit.NewLogger(),
+		auditLogger:    audit.NewLogger(auditStore),
+		auditStore:     auditStore,
 		hostStore:      host.NewStore(),
 		hostkeyStore:   hostkey.NewStore(),
 		registryStore:  registry.NewStore(),
@@ -57,11 +72,13 @@ func New() *Facade {
 		configStore:    serviceconfigfile.NewStore(),
 		templateStore:  servicetemplate.NewStore(),
 		logFilterStore: logfilter.NewStore(),
+		certStore:      certificate.NewStore(),
 		userStore:      user.NewStore(),
 		serviceCache:   NewServiceCache(),
 		poolCache:      NewPoolCache(),
 		hostRegistry:   auth.NewHostExpirationRegistry(),
 		deployments:    NewPendingDeploymentMgr(),
+		challenges:     acme.NewChallenges(),
 		zzk:            getZZK(),
 	}
 }
@@ -74,9 +91,11 @@ type Facade struct {
 	poolStore      pool.Store
 	templateStore  servicetemplate.Store
 	logFilterStore logfilter.Store
+	certStore      certificate.Store
 	serviceStore   service.Store
 	configStore    serviceconfigfile.Store
 	userStore      user.Store
+	auditStore     auditlog.Store
 
 	auditLogger   audit.Logger
 	zzk           ZZK
@@ -89,6 +108,10 @@ type Facade struct {
 	deployments   *PendingDeploymentMgr
 	ssm           servicestatemanager.ServiceStateManager
 	isvcsPath     string
+	acmeClient    ACMEClient
+	challenges    *acme.Challenges
+	sloTracker    *slo.Tracker
+	registryBlobs func() error
 
 	rollingRestartTimeout time.Duration
 }
@@ -128,12 +151,24 @@ func (f *Facade) SetTemplateStore(store servicetemplate.Store) { f.templateStore
 
 func (f *Facade) SetLogFilterStore(store logfilter.Store) { f.logFilterStore = store }
 
+func (f *Facade) SetAuditStore(store auditlog.Store) { f.auditStore = store }
+
+func (f *Facade) SetCertificateStore(store certificate.Store) { f.certStore = store }
+
+func (f *Facade) SetACMEClient(client ACMEClient) { f.acmeClient = client }
+
 func (f *Facade) SetHealthCache(hcache *health.HealthStatusCache) { f.hcache = hcache }
 
 func (f *Facade) SetMetricsClient(client MetricsClient) { f.metricsClient = client }
 
+func (f *Facade) SetSLOTracker(tracker *slo.Tracker) { f.sloTracker = tracker }
+
 func (f *Facade) SetIsvcsPath(path string) { f.isvcsPath = path }
 
+// SetRegistryBlobCollector sets the function that frees the registry storage
+// used by the blobs of removed manifests.
+func (f *Facade) SetRegistryBlobCollector(collect func() error) { f.registryBlobs = collect }
+
 func (f *Facade) SetHostExpirationRegistry(hostRegistry auth.HostExpirationRegistryInterface) {
 	f.hostRegistry = hostRegistry
 }
@@ -23,6 +23,8 @@ import (
 	authmocks "github.com/control-center/serviced/auth/mocks"
 	datastoremocks "github.com/control-center/serviced/datastore/mocks"
 	dfsmocks "github.com/control-center/serviced/dfs/mocks"
+	auditlogmocks "github.com/control-center/serviced/domain/auditlog/mocks"
+	certmocks "github.com/control-center/serviced/domain/certificate/mocks"
 	hostmocks "github.com/control-center/serviced/domain/host/mocks"
 	keymocks "github.com/control-center/serviced/domain/hostkey/mocks"
 	poolmocks "github.com/control-center/serviced/domain/pool/mocks"
@@ -53,6 +55,8 @@ type FacadeUnitTest struct {
 	configStore      *configmocks.Store
 	templateStore    *templatemocks.Store
 	logFilterStore   *logfiltermocks.Store
+	certStore        *certmocks.Store
+	auditStore       *auditlogmocks.Store
 	metricsClient    *zzkmocks.MetricsClient
 	hostauthregistry *authmocks.HostExpirationRegistryInterface
 }
@@ -77,6 +81,7 @@ func (ft *FacadeUnitTest) SetUpTest(c *C) {
 	mockLogger.On("Entity", mock.AnythingOfType("*pool.ResourcePool")).Return(mockLogger)
 	mockLogger.On("Entity", mock.AnythingOfType("*service.Service")).Return(mockLogger)
 	mockLogger.On("Entity", mock.AnythingOfType("*host.Host")).Return(mockLogger)
+	mockLogger.On("Entity", mock.AnythingOfType("*certificate.Certificate")).Return(mockLogger)
 	mockLogger.On("WithField", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(mockLogger)
 	mockLogger.On("WithFields", mock.AnythingOfType("logrus.Fields")).Return(mockLogger)
 	mockLogger.On("Error", mock.Anything)
@@ -112,6 +117,12 @@ func (ft *FacadeUnitTest) SetUpTest(c *C) {
 	ft.logFilterStore = &logfiltermocks.Store{}
 	ft.Facade.SetLogFilterStore(ft.logFilterStore)
 
+	ft.certStore = &certmocks.Store{}
+	ft.Facade.SetCertificateStore(ft.certStore)
+
+	ft.auditStore = &auditlogmocks.Store{}
+	ft.Facade.SetAuditStore(ft.auditStore)
+
 	ft.zzk = &zzkmocks.ZZK{}
 	ft.Facade.SetZZK(ft.zzk)
 
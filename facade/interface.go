@@ -22,13 +22,18 @@ import (
 	"github.com/control-center/serviced/health"
 
 	"github.com/control-center/serviced/domain/addressassignment"
+	"github.com/control-center/serviced/domain/auditlog"
+	"github.com/control-center/serviced/domain/certificate"
 	"github.com/control-center/serviced/domain/host"
+	"github.com/control-center/serviced/domain/logfilter"
 	"github.com/control-center/serviced/domain/pool"
 	"github.com/control-center/serviced/domain/service"
 	"github.com/control-center/serviced/domain/servicedefinition"
 	"github.com/control-center/serviced/domain/servicetemplate"
 	"github.com/control-center/serviced/domain/user"
+	"github.com/control-center/serviced/slo"
 	"github.com/control-center/serviced/utils"
+	"github.com/control-center/serviced/volume"
 )
 
 // The FacadeInterface is the API for a Facade
@@ -122,13 +127,13 @@ type FacadeInterface interface {
 
 	GetHealthChecksForService(ctx datastore.Context, id string) (map[string]health.HealthCheck, error)
 
-	AddPublicEndpointPort(ctx datastore.Context, serviceid, endpointName, portAddr string, usetls bool, protocol string, isEnabled bool, restart bool) (*servicedefinition.Port, error)
+	AddPublicEndpointPort(ctx datastore.Context, serviceid, endpointName, portAddr string, usetls bool, protocol string, isEnabled bool, access servicedefinition.AccessRules, restart bool) (*servicedefinition.Port, error)
 
 	RemovePublicEndpointPort(ctx datastore.Context, serviceid, endpointName, portAddr string) error
 
 	EnablePublicEndpointPort(ctx datastore.Context, serviceid, endpointName, portAddr string, isEnabled bool) error
 
-	AddPublicEndpointVHost(ctx datastore.Context, serviceid, endpointName, vhost string, isEnabled, restart bool) (*servicedefinition.VHost, error)
+	AddPublicEndpointVHost(ctx datastore.Context, serviceid, endpointName, vhost string, isEnabled bool, access servicedefinition.AccessRules, restart bool) (*servicedefinition.VHost, error)
 
 	RemovePublicEndpointVHost(ctx datastore.Context, serviceid, endpointName, vhost string) error
 
@@ -162,6 +167,18 @@ type FacadeInterface interface {
 
 	GetAllPublicEndpoints(ctx datastore.Context) ([]service.PublicEndpoint, error)
 
+	SetPublicEndpointCertificate(ctx datastore.Context, serviceID, endpointName string, kind certificate.Kind, name string, certPEM, keyPEM []byte) (*certificate.Certificate, error)
+
+	RemovePublicEndpointCertificate(ctx datastore.Context, serviceID, endpointName string, kind certificate.Kind, name string) error
+
+	GetCertificates(ctx datastore.Context) ([]certificate.Certificate, error)
+
+	EnablePublicEndpointACME(ctx datastore.Context, serviceID, endpointName, vhost string, domains []string) (*certificate.Certificate, error)
+
+	RenewCertificates(ctx datastore.Context) error
+
+	GetACMEChallenge(token string) (string, bool)
+
 	GetServiceAddressAssignmentDetails(ctx datastore.Context, serviceID string, children bool) ([]service.IPAssignment, error)
 
 	GetServiceExportedEndpoints(ctx datastore.Context, serviceID string, children bool) ([]service.ExportedEndpoint, error)
@@ -202,12 +219,34 @@ type FacadeInterface interface {
 
 	ReloadLogstashConfig(ctx datastore.Context) error
 
+	GetLogRetentionPolicy(ctx datastore.Context) (*logfilter.RetentionPolicy, error)
+
+	GetAuditEntries(ctx datastore.Context, query auditlog.Query) ([]auditlog.Entry, error)
+
+	VerifyAuditTrail(ctx datastore.Context) (*auditlog.Verification, error)
+
+	RecordShellSession(ctx datastore.Context, session auditlog.Session, recording *auditlog.Recording) error
+
+	GetShellSessionRecording(ctx datastore.Context, sessionID string) (*auditlog.Recording, error)
+
 	EmergencyStopService(ctx datastore.Context, request dao.ScheduleServiceRequest) (int, error)
 
 	ClearEmergencyStopFlag(ctx datastore.Context, serviceID string) (int, error)
 
 	PredictStorageAvailability(ctx datastore.Context, lookahead time.Duration) (map[string]float64, error)
 
+	SetStorageQuota(ctx datastore.Context, tenantID string, quota volume.StorageQuota) error
+
+	GetStorageQuotas(ctx datastore.Context) (map[string]volume.StorageQuota, error)
+
+	GetStorageQuotaStatus(ctx datastore.Context) ([]volume.TenantQuotaStatus, error)
+
+	MeasureSLOs(ctx datastore.Context, now time.Time) ([]slo.Status, error)
+
+	GetServiceSLOs(ctx datastore.Context, serviceID string) ([]slo.Status, error)
+
+	GetResourceRecommendations(ctx datastore.Context, tenantID string, since time.Time) ([]service.ResourceRecommendation, error)
+
 	QueryServiceDetails(ctx datastore.Context, query service.Query) ([]service.ServiceDetails, error)
 
 	GetServiceNamePath(ctx datastore.Context, serviceID string) (tenantID string, servicePath string, err error)
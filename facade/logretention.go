@@ -0,0 +1,87 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package facade
+
+import (
+	"sort"
+
+	"github.com/control-center/serviced/config"
+	"github.com/control-center/serviced/dao"
+	"github.com/control-center/serviced/datastore"
+	"github.com/control-center/serviced/domain/logfilter"
+	"github.com/control-center/serviced/domain/service"
+)
+
+// GetLogRetentionPolicy returns how long logstash keeps application logs,
+// with a rule for each tenant's LogConfig type that sets its own retention.
+func (f *Facade) GetLogRetentionPolicy(ctx datastore.Context) (*logfilter.RetentionPolicy, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.GetLogRetentionPolicy"))
+	tenants, err := f.GetServiceDetailsByParentID(ctx, "", 0)
+	if err != nil {
+		plog.WithError(err).Error("Could not get tenants")
+		return nil, err
+	}
+	rules := []logfilter.RetentionRule{}
+	for _, tenant := range tenants {
+		svcs, err := f.GetServices(ctx, dao.ServiceRequest{TenantID: tenant.ID})
+		if err != nil {
+			plog.WithError(err).WithField("tenantid", tenant.ID).Error("Could not retrieve services for tenant")
+			return nil, err
+		}
+		rules = append(rules, getRetentionRules(tenant.ID, tenant.Name, svcs)...)
+	}
+	sort.Sort(logfilter.RetentionRules(rules))
+	options := config.GetOptions()
+	return &logfilter.RetentionPolicy{
+		MaxDays: options.LogstashMaxDays,
+		MaxSize: options.LogstashMaxSize,
+		Rules:   rules,
+	}, nil
+}
+
+// getRetentionRules collects the retention rules of a tenant's services.  If
+// services disagree on how long to keep a type, the longest retention wins.
+func getRetentionRules(tenantID, tenantName string, svcs []service.Service) []logfilter.RetentionRule {
+	byType := make(map[string]*logfilter.RetentionRule)
+	types := []string{}
+	for _, svc := range svcs {
+		for _, logConfig := range svc.LogConfigs {
+			if logConfig.RetentionDays <= 0 {
+				continue
+			}
+			rule, ok := byType[logConfig.Type]
+			if !ok {
+				rule = &logfilter.RetentionRule{
+					TenantID:   tenantID,
+					TenantName: tenantName,
+					Type:       logConfig.Type,
+				}
+				byType[logConfig.Type] = rule
+				types = append(types, logConfig.Type)
+			}
+			if logConfig.RetentionDays > rule.Days {
+				rule.Days = logConfig.RetentionDays
+			}
+			if n := len(rule.ServiceIDs); n == 0 || rule.ServiceIDs[n-1] != svc.ID {
+				rule.ServiceIDs = append(rule.ServiceIDs, svc.ID)
+			}
+		}
+	}
+	sort.Strings(types)
+	rules := make([]logfilter.RetentionRule, len(types))
+	for i, t := range types {
+		rules[i] = *byType[t]
+	}
+	return rules
+}
@@ -18,8 +18,10 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sync"
 	"strings"
 
@@ -82,6 +84,18 @@ func (f *Facade) ReloadLogstashConfig(ctx datastore.Context) error {
 		return err
 	}
 
+	pools, err := f.GetResourcePools(ctx)
+	if err != nil {
+		plog.WithError(err).Error("Could not retrieve resource pools")
+		return err
+	}
+	logOutputs := []scopedLogOutput{}
+	for _, p := range pools {
+		for _, output := range p.LogOutputs {
+			logOutputs = append(logOutputs, scopedLogOutput{"poolid", p.ID, output})
+		}
+	}
+
 	// serviceLogs is a unique list of services with log files, such that if there are two or more
 	// copies of the same service, then only the most recent is kept in the list. In other words,
 	// in cases where two or more versions of a particular service are deployed, we only use
@@ -99,6 +113,9 @@ func (f *Facade) ReloadLogstashConfig(ctx datastore.Context) error {
 		for _, svc := range svcs {
 			if svc.ID == tenantID {
 				tenantVersion = svc.Version
+				for _, output := range svc.LogOutputs {
+					logOutputs = append(logOutputs, scopedLogOutput{"tenantid", tenantID, output})
+				}
 				break
 			}
 		}
@@ -110,15 +127,18 @@ func (f *Facade) ReloadLogstashConfig(ctx datastore.Context) error {
 	logFiles := []string{} 	// a list of unique application log file names
 	auditLogSection := ""
 	auditableTypes := []string{} // a list of unique log types where IsAudit=true
+	parsedFiles := []string{}    // a list of unique log file names with a parser
 
 	plog.Debugf("Checking %d services", len(serviceLogs))
 	for _, logInfo := range serviceLogs {
+		filterSection += getParserSection(logInfo, &parsedFiles)
 		filterSection += getFilterSection(logInfo, logFilters, &logFiles)
 		auditLogSection += getAuditLogSection(logInfo.LogConfigs, &auditableTypes)
 	}
 	plog.Debugf("after checking services, auditLogSection=%s", auditLogSection)
+	outputSection := getOutputSection(logOutputs, auditableTypes)
 
-	err = writeLogstashConfiguration(filterSection, auditLogSection)
+	err = writeLogstashConfiguration(filterSection, auditLogSection, outputSection)
 	if err == ErrLogstashUnchanged {
 		return nil
 	} else if err != nil {
@@ -161,7 +181,7 @@ func addServiceLogs(tenantVersion string, svcs []service.Service, serviceLogs ma
 //
 // This method returns nil of logstash configuration was replaced,
 // ErrLogstashUnchanged if the configuration is unchanged, or other errors if there was an I/O problem
-func writeLogstashConfiguration(filterSection, auditLogSection string) error {
+func writeLogstashConfiguration(filterSection, auditLogSection, outputSection string) error {
 
 	logstashDir := getLogstashConfigDirectory()
 	newConfigFile := filepath.Join(logstashDir, "logstash.conf.new")
@@ -171,7 +191,7 @@ func writeLogstashConfiguration(filterSection, auditLogSection string) error {
 		"currentconfigfile": originalFile,
 	})
 
-	err := writeLogStashConfigFile(filterSection, auditLogSection, newConfigFile)
+	err := writeLogStashConfigFile(filterSection, auditLogSection, outputSection, newConfigFile)
 	if err != nil {
 		logger.WithError(err).Error("Unable to create new logstash config file")
 		return err
@@ -230,6 +250,51 @@ func getFilterSection(logInfo serviceLogInfo, logFilters []*logfilter.LogFilter,
 	return filterSection
 }
 
+// logParserCapture matches the named captures of a log parser pattern
+var logParserCapture = regexp.MustCompile(`\(\?P<([a-zA-Z][a-zA-Z0-9_]*)>`)
+
+// getParserSection returns the logstash filters that extract the fields of
+// each parsed log file of a service into the "app" field.
+func getParserSection(logInfo serviceLogInfo, parsedFiles *[]string) string {
+	parserSection := ""
+	for _, config := range logInfo.LogConfigs {
+		if config.Parser == nil || config.Parser.Format == "" {
+			continue
+		}
+		if utils.StringInSlice(config.Path, *parsedFiles) {
+			continue
+		}
+		var filter string
+		switch config.Parser.Format {
+		case servicedefinition.ParseJSON:
+			filter = "json {\n  source => \"message\"\n  target => \"app\"\n}"
+		case servicedefinition.ParseLogfmt:
+			filter = "kv {\n  source => \"message\"\n  target => \"app\"\n}"
+		case servicedefinition.ParseRegex:
+			// prefix the captures so that they cannot clobber the fields
+			// of the message, then move them under the app field
+			pattern := logParserCapture.ReplaceAllString(config.Parser.Pattern, "(?<app_$1>")
+			renames := ""
+			for _, name := range config.Parser.Captures() {
+				renames += fmt.Sprintf("\n    \"app_%s\" => \"[app][%s]\"", name, name)
+			}
+			filter = fmt.Sprintf("grok {\n  match => { \"message\" => \"%s\" }\n}\nmutate {\n  rename => {%s\n  }\n}", pattern, renames)
+		default:
+			plog.WithFields(log.Fields{
+				"serviceid":   logInfo.ID,
+				"servicename": logInfo.Name,
+				"format":      config.Parser.Format,
+			}).Warn("Unknown log parser format")
+			continue
+		}
+		path := strings.Replace(config.Path, "/", "\\/", -1)
+		parserSection += fmt.Sprintf("\n  # Parse the fields of %s\n  if [file] =~ \"%s\" {\n%s\n  }\n",
+			config.Path, path, indent(filter, "    "))
+		*parsedFiles = append(*parsedFiles, config.Path)
+	}
+	return parserSection
+}
+
 // Finds the newest match for the named filter by version.
 // If an exact match is found, use it. Otherwise, return the newest version of the named filter
 func findNewestFilter(filterName string, logInfo serviceLogInfo, logFilters []*logfilter.LogFilter) (string, bool) {
@@ -288,9 +353,93 @@ func getAuditLogSection(configs []servicedefinition.LogConfig, auditTypes *[]str
 	return auditSection
 }
 
+// scopedLogOutput is a log output of the pool or tenant whose messages have
+// the value in the named field
+type scopedLogOutput struct {
+	Field  string
+	Value  string
+	Output logfilter.LogOutput
+}
+
+// getOutputSection returns the logstash outputs that ship the messages of
+// each pool and tenant to their log outputs.
+func getOutputSection(outputs []scopedLogOutput, auditableTypes []string) string {
+	outputSection := ""
+	for _, scoped := range outputs {
+		output := scoped.Output
+		logger := plog.WithFields(log.Fields{
+			scoped.Field: scoped.Value,
+			"output":     output.Name,
+		})
+
+		condition := fmt.Sprintf("[fields][%s] == \"%s\"", scoped.Field, scoped.Value)
+		types := output.LogTypes
+		if output.AuditOnly {
+			if len(types) == 0 {
+				types = auditableTypes
+			} else {
+				auditable := []string{}
+				for _, t := range types {
+					if utils.StringInSlice(t, auditableTypes) {
+						auditable = append(auditable, t)
+					}
+				}
+				types = auditable
+			}
+			if len(types) == 0 {
+				logger.Debug("No auditable log types to ship")
+				continue
+			}
+		}
+		if len(types) == 1 {
+			// logstash treats a single item list as a substring match
+			condition += fmt.Sprintf(" and [fields][type] == \"%s\"", types[0])
+		} else if len(types) > 1 {
+			condition += fmt.Sprintf(" and [fields][type] in [\"%s\"]", strings.Join(types, "\", \""))
+		}
+
+		var plugin string
+		switch output.Type {
+		case logfilter.OutputSyslog:
+			host, port, _ := net.SplitHostPort(output.Address)
+			protocol := output.Protocol
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			plugin = fmt.Sprintf(`syslog {
+  host => "%s"
+  port => %s
+  protocol => "%s"
+  rfc => "rfc5424"
+  appname => "%%{[fields][type]}"
+  procid => "%%{[fields][instance]}"
+  sourcehost => "%%{[fields][ccWorkerID]}"
+}`, host, port, protocol)
+		case logfilter.OutputHTTP:
+			plugin = fmt.Sprintf(`http {
+  url => "%s"
+  http_method => "post"
+  format => "json"
+}`, output.Address)
+		case logfilter.OutputKafka:
+			plugin = fmt.Sprintf(`kafka {
+  bootstrap_servers => "%s"
+  topic_id => "%s"
+  codec => json
+}`, output.Address, output.Topic)
+		default:
+			logger.WithField("type", output.Type).Warn("Unknown log output type")
+			continue
+		}
+		outputSection += fmt.Sprintf("\n        # %s %s: %s\n        if %s {\n%s        }",
+			strings.TrimSuffix(scoped.Field, "id"), scoped.Value, output.Name, condition, indent(plugin, "            "))
+	}
+	return outputSection
+}
+
 // This method writes out the config file for logstash. It uses
 // the logstash.conf.template and does a variable replacement.
-func writeLogStashConfigFile(filterSection string, auditLogSection string, outputPath string) error {
+func writeLogStashConfigFile(filterSection string, auditLogSection string, outputSection string, outputPath string) error {
 	// read the log configuration template
 	templatePath := filepath.Join(getLogstashConfigDirectory(), "logstash.conf.template")
 
@@ -325,6 +474,9 @@ filter {
 	if len(auditLogSection) > 0 {
 		newContents = strings.Replace(string(newContents),"${AUDITLOG_SECTION}", auditLogSection, 1)
 	}
+	if len(outputSection) > 0 {
+		newContents = strings.Replace(newContents, "${OUTPUT_SECTION}", outputSection, 1)
+	}
 	newBytes := []byte(newContents)
 	// generate the filters section
 	// write the log file
@@ -641,7 +641,7 @@ func  (t *LogStashTest) TestWritingConfigFile(c *C) {
 	err = tmpfile.Sync()
 	c.Assert(err, IsNil)
 
-	err = writeLogStashConfigFile(filters, auditLogSection, tmpfile.Name())
+	err = writeLogStashConfigFile(filters, auditLogSection, "", tmpfile.Name())
 	c.Assert(err, IsNil)
 
 	// read the contents
@@ -657,6 +657,143 @@ func  (t *LogStashTest) TestWritingConfigFile(c *C) {
 	}
 }
 
+func (t *LogStashTest) Test_getParserSection(c *C) {
+	logInfo := serviceLogInfo{
+		ID:   "id1",
+		Name: "service1",
+		LogConfigs: []servicedefinition.LogConfig{
+			{Path: "/var/log/plain.log"},
+			{Path: "/var/log/app.json", Parser: &servicedefinition.LogParser{Format: servicedefinition.ParseJSON}},
+			{Path: "/var/log/app.log", Parser: &servicedefinition.LogParser{
+				Format:  servicedefinition.ParseRegex,
+				Pattern: `^(?P<level>\w+) (?P<msg>.*)$`,
+			}},
+			{Path: "/var/log/trace.log", Parser: &servicedefinition.LogParser{MultilineStart: `^\d`}},
+		},
+	}
+	parsedFiles := []string{}
+
+	section := getParserSection(logInfo, &parsedFiles)
+
+	c.Assert(parsedFiles, DeepEquals, []string{"/var/log/app.json", "/var/log/app.log"})
+	c.Assert(section, Equals, `
+  # Parse the fields of /var/log/app.json
+  if [file] =~ "\/var\/log\/app.json" {
+    json {
+      source => "message"
+      target => "app"
+    }
+
+  }
+
+  # Parse the fields of /var/log/app.log
+  if [file] =~ "\/var\/log\/app.log" {
+    grok {
+      match => { "message" => "^(?<app_level>\w+) (?<app_msg>.*)$" }
+    }
+    mutate {
+      rename => {
+        "app_level" => "[app][level]"
+        "app_msg" => "[app][msg]"
+      }
+    }
+
+  }
+`)
+
+	// parsers are only written once per file
+	c.Assert(getParserSection(logInfo, &parsedFiles), Equals, "")
+}
+
+func (t *LogStashTest) Test_getOutputSection(c *C) {
+	outputs := []scopedLogOutput{
+		{"poolid", "default", logfilter.LogOutput{
+			Name:     "siem",
+			Type:     logfilter.OutputSyslog,
+			Address:  "siem.example.com:514",
+			Protocol: "udp",
+			LogTypes: []string{"app"},
+		}},
+		{"tenantid", "tenant1", logfilter.LogOutput{
+			Name:      "audit",
+			Type:      logfilter.OutputKafka,
+			Address:   "kafka1:9092,kafka2:9092",
+			Topic:     "audit",
+			AuditOnly: true,
+		}},
+		{"tenantid", "tenant1", logfilter.LogOutput{
+			Name:      "nothing audited",
+			Type:      logfilter.OutputHTTP,
+			Address:   "https://logs.example.com/ingest",
+			LogTypes:  []string{"app"},
+			AuditOnly: true,
+		}},
+		{"tenantid", "tenant2", logfilter.LogOutput{
+			Name:    "collector",
+			Type:    logfilter.OutputHTTP,
+			Address: "https://logs.example.com/ingest",
+		}},
+	}
+
+	section := getOutputSection(outputs, []string{"audit", "zenaudit"})
+
+	c.Assert(section, Equals, `
+        # pool default: siem
+        if [fields][poolid] == "default" and [fields][type] == "app" {
+            syslog {
+              host => "siem.example.com"
+              port => 514
+              protocol => "udp"
+              rfc => "rfc5424"
+              appname => "%{[fields][type]}"
+              procid => "%{[fields][instance]}"
+              sourcehost => "%{[fields][ccWorkerID]}"
+            }
+        }
+        # tenant tenant1: audit
+        if [fields][tenantid] == "tenant1" and [fields][type] in ["audit", "zenaudit"] {
+            kafka {
+              bootstrap_servers => "kafka1:9092,kafka2:9092"
+              topic_id => "audit"
+              codec => json
+            }
+        }
+        # tenant tenant2: collector
+        if [fields][tenantid] == "tenant2" {
+            http {
+              url => "https://logs.example.com/ingest"
+              http_method => "post"
+              format => "json"
+            }
+        }`)
+}
+
+func (t *LogStashTest) Test_getRetentionRules(c *C) {
+	svcs := []service.Service{
+		{
+			ID: "id1",
+			LogConfigs: []servicedefinition.LogConfig{
+				{Path: "/var/log/audit.log", Type: "audit", RetentionDays: 365},
+				{Path: "/var/log/debug.log", Type: "debug", RetentionDays: 3},
+				{Path: "/var/log/app.log", Type: "app"},
+			},
+		}, {
+			ID: "id2",
+			LogConfigs: []servicedefinition.LogConfig{
+				{Path: "/var/log/debug.log", Type: "debug", RetentionDays: 5},
+				{Path: "/var/log/trace.log", Type: "debug", RetentionDays: 1},
+			},
+		},
+	}
+
+	rules := getRetentionRules("tenant", "Tenant", svcs)
+
+	c.Assert(rules, DeepEquals, []logfilter.RetentionRule{
+		{TenantID: "tenant", TenantName: "Tenant", Type: "audit", Days: 365, ServiceIDs: []string{"id1"}},
+		{TenantID: "tenant", TenantName: "Tenant", Type: "debug", Days: 5, ServiceIDs: []string{"id1", "id2"}},
+	})
+}
+
 func getTestServices(version string) []service.Service {
 	return []service.Service{
 		service.Service{
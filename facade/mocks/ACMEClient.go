@@ -0,0 +1,41 @@
+package mocks
+
+import acme "github.com/control-center/serviced/acme"
+import mock "github.com/stretchr/testify/mock"
+
+// ACMEClient is an autogenerated mock type for the ACMEClient type
+type ACMEClient struct {
+	mock.Mock
+}
+
+// Obtain provides a mock function with given fields: domains, solver
+func (_m *ACMEClient) Obtain(domains []string, solver acme.Solver) ([]byte, []byte, error) {
+	ret := _m.Called(domains, solver)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func([]string, acme.Solver) []byte); ok {
+		r0 = rf(domains, solver)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 []byte
+	if rf, ok := ret.Get(1).(func([]string, acme.Solver) []byte); ok {
+		r1 = rf(domains, solver)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]byte)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func([]string, acme.Solver) error); ok {
+		r2 = rf(domains, solver)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
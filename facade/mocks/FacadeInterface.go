@@ -1,20 +1,25 @@
 package mocks
 
 import addressassignment "github.com/control-center/serviced/domain/addressassignment"
+import auditlog "github.com/control-center/serviced/domain/auditlog"
+import certificate "github.com/control-center/serviced/domain/certificate"
 import dao "github.com/control-center/serviced/dao"
 import datastore "github.com/control-center/serviced/datastore"
 import domain "github.com/control-center/serviced/domain"
 
 import health "github.com/control-center/serviced/health"
 import host "github.com/control-center/serviced/domain/host"
+import logfilter "github.com/control-center/serviced/domain/logfilter"
 import mock "github.com/stretchr/testify/mock"
 import pool "github.com/control-center/serviced/domain/pool"
 import service "github.com/control-center/serviced/domain/service"
 import servicedefinition "github.com/control-center/serviced/domain/servicedefinition"
 import servicetemplate "github.com/control-center/serviced/domain/servicetemplate"
+import slo "github.com/control-center/serviced/slo"
 import time "time"
 import user "github.com/control-center/serviced/domain/user"
 import "github.com/control-center/serviced/utils"
+import volume "github.com/control-center/serviced/volume"
 
 // FacadeInterface is an autogenerated mock type for the FacadeInterface type
 type FacadeInterface struct {
@@ -67,13 +72,13 @@ func (_m *FacadeInterface) AddHostPrivate(ctx datastore.Context, entity *host.Ho
 	return r0, r1
 }
 
-// AddPublicEndpointPort provides a mock function with given fields: ctx, serviceid, endpointName, portAddr, usetls, protocol, isEnabled, restart
-func (_m *FacadeInterface) AddPublicEndpointPort(ctx datastore.Context, serviceid string, endpointName string, portAddr string, usetls bool, protocol string, isEnabled bool, restart bool) (*servicedefinition.Port, error) {
-	ret := _m.Called(ctx, serviceid, endpointName, portAddr, usetls, protocol, isEnabled, restart)
+// AddPublicEndpointPort provides a mock function with given fields: ctx, serviceid, endpointName, portAddr, usetls, protocol, isEnabled, access, restart
+func (_m *FacadeInterface) AddPublicEndpointPort(ctx datastore.Context, serviceid string, endpointName string, portAddr string, usetls bool, protocol string, isEnabled bool, access servicedefinition.AccessRules, restart bool) (*servicedefinition.Port, error) {
+	ret := _m.Called(ctx, serviceid, endpointName, portAddr, usetls, protocol, isEnabled, access, restart)
 
 	var r0 *servicedefinition.Port
-	if rf, ok := ret.Get(0).(func(datastore.Context, string, string, string, bool, string, bool, bool) *servicedefinition.Port); ok {
-		r0 = rf(ctx, serviceid, endpointName, portAddr, usetls, protocol, isEnabled, restart)
+	if rf, ok := ret.Get(0).(func(datastore.Context, string, string, string, bool, string, bool, servicedefinition.AccessRules, bool) *servicedefinition.Port); ok {
+		r0 = rf(ctx, serviceid, endpointName, portAddr, usetls, protocol, isEnabled, access, restart)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*servicedefinition.Port)
@@ -81,8 +86,8 @@ func (_m *FacadeInterface) AddPublicEndpointPort(ctx datastore.Context, servicei
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(datastore.Context, string, string, string, bool, string, bool, bool) error); ok {
-		r1 = rf(ctx, serviceid, endpointName, portAddr, usetls, protocol, isEnabled, restart)
+	if rf, ok := ret.Get(1).(func(datastore.Context, string, string, string, bool, string, bool, servicedefinition.AccessRules, bool) error); ok {
+		r1 = rf(ctx, serviceid, endpointName, portAddr, usetls, protocol, isEnabled, access, restart)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -90,13 +95,13 @@ func (_m *FacadeInterface) AddPublicEndpointPort(ctx datastore.Context, servicei
 	return r0, r1
 }
 
-// AddPublicEndpointVHost provides a mock function with given fields: ctx, serviceid, endpointName, vhost, isEnabled, restart
-func (_m *FacadeInterface) AddPublicEndpointVHost(ctx datastore.Context, serviceid string, endpointName string, vhost string, isEnabled bool, restart bool) (*servicedefinition.VHost, error) {
-	ret := _m.Called(ctx, serviceid, endpointName, vhost, isEnabled, restart)
+// AddPublicEndpointVHost provides a mock function with given fields: ctx, serviceid, endpointName, vhost, isEnabled, access, restart
+func (_m *FacadeInterface) AddPublicEndpointVHost(ctx datastore.Context, serviceid string, endpointName string, vhost string, isEnabled bool, access servicedefinition.AccessRules, restart bool) (*servicedefinition.VHost, error) {
+	ret := _m.Called(ctx, serviceid, endpointName, vhost, isEnabled, access, restart)
 
 	var r0 *servicedefinition.VHost
-	if rf, ok := ret.Get(0).(func(datastore.Context, string, string, string, bool, bool) *servicedefinition.VHost); ok {
-		r0 = rf(ctx, serviceid, endpointName, vhost, isEnabled, restart)
+	if rf, ok := ret.Get(0).(func(datastore.Context, string, string, string, bool, servicedefinition.AccessRules, bool) *servicedefinition.VHost); ok {
+		r0 = rf(ctx, serviceid, endpointName, vhost, isEnabled, access, restart)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*servicedefinition.VHost)
@@ -104,8 +109,8 @@ func (_m *FacadeInterface) AddPublicEndpointVHost(ctx datastore.Context, service
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(datastore.Context, string, string, string, bool, bool) error); ok {
-		r1 = rf(ctx, serviceid, endpointName, vhost, isEnabled, restart)
+	if rf, ok := ret.Get(1).(func(datastore.Context, string, string, string, bool, servicedefinition.AccessRules, bool) error); ok {
+		r1 = rf(ctx, serviceid, endpointName, vhost, isEnabled, access, restart)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -204,6 +209,144 @@ func (_m *FacadeInterface) AssignIPs(ctx datastore.Context, assignmentRequest ad
 	return r0
 }
 
+// GetCertificates provides a mock function with given fields: ctx
+func (_m *FacadeInterface) GetCertificates(ctx datastore.Context) ([]certificate.Certificate, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []certificate.Certificate
+	if rf, ok := ret.Get(0).(func(datastore.Context) []certificate.Certificate); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]certificate.Certificate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(datastore.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStorageQuotaStatus provides a mock function with given fields: ctx
+func (_m *FacadeInterface) GetStorageQuotaStatus(ctx datastore.Context) ([]volume.TenantQuotaStatus, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []volume.TenantQuotaStatus
+	if rf, ok := ret.Get(0).(func(datastore.Context) []volume.TenantQuotaStatus); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]volume.TenantQuotaStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(datastore.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStorageQuotas provides a mock function with given fields: ctx
+func (_m *FacadeInterface) GetStorageQuotas(ctx datastore.Context) (map[string]volume.StorageQuota, error) {
+	ret := _m.Called(ctx)
+
+	var r0 map[string]volume.StorageQuota
+	if rf, ok := ret.Get(0).(func(datastore.Context) map[string]volume.StorageQuota); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]volume.StorageQuota)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(datastore.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MeasureSLOs provides a mock function with given fields: ctx, now
+func (_m *FacadeInterface) MeasureSLOs(ctx datastore.Context, now time.Time) ([]slo.Status, error) {
+	ret := _m.Called(ctx, now)
+
+	var r0 []slo.Status
+	if rf, ok := ret.Get(0).(func(datastore.Context, time.Time) []slo.Status); ok {
+		r0 = rf(ctx, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]slo.Status)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(datastore.Context, time.Time) error); ok {
+		r1 = rf(ctx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetServiceSLOs provides a mock function with given fields: ctx, serviceID
+func (_m *FacadeInterface) GetServiceSLOs(ctx datastore.Context, serviceID string) ([]slo.Status, error) {
+	ret := _m.Called(ctx, serviceID)
+
+	var r0 []slo.Status
+	if rf, ok := ret.Get(0).(func(datastore.Context, string) []slo.Status); ok {
+		r0 = rf(ctx, serviceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]slo.Status)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(datastore.Context, string) error); ok {
+		r1 = rf(ctx, serviceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetResourceRecommendations provides a mock function with given fields: ctx, tenantID, since
+func (_m *FacadeInterface) GetResourceRecommendations(ctx datastore.Context, tenantID string, since time.Time) ([]service.ResourceRecommendation, error) {
+	ret := _m.Called(ctx, tenantID, since)
+
+	var r0 []service.ResourceRecommendation
+	if rf, ok := ret.Get(0).(func(datastore.Context, string, time.Time) []service.ResourceRecommendation); ok {
+		r0 = rf(ctx, tenantID, since)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]service.ResourceRecommendation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(datastore.Context, string, time.Time) error); ok {
+		r1 = rf(ctx, tenantID, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RemoveIPs provides a mock function with given fields: ctx, []string
 func (_m *FacadeInterface) RemoveIPs(ctx datastore.Context, args []string) error {
 	ret := _m.Called(ctx, args)
@@ -682,6 +825,112 @@ func (_m *FacadeInterface) GetHosts(ctx datastore.Context) ([]host.Host, error)
 	return r0, r1
 }
 
+// GetLogRetentionPolicy provides a mock function with given fields: ctx
+func (_m *FacadeInterface) GetLogRetentionPolicy(ctx datastore.Context) (*logfilter.RetentionPolicy, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *logfilter.RetentionPolicy
+	if rf, ok := ret.Get(0).(func(datastore.Context) *logfilter.RetentionPolicy); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*logfilter.RetentionPolicy)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(datastore.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAuditEntries provides a mock function with given fields: ctx, query
+func (_m *FacadeInterface) GetAuditEntries(ctx datastore.Context, query auditlog.Query) ([]auditlog.Entry, error) {
+	ret := _m.Called(ctx, query)
+
+	var r0 []auditlog.Entry
+	if rf, ok := ret.Get(0).(func(datastore.Context, auditlog.Query) []auditlog.Entry); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]auditlog.Entry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(datastore.Context, auditlog.Query) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifyAuditTrail provides a mock function with given fields: ctx
+func (_m *FacadeInterface) VerifyAuditTrail(ctx datastore.Context) (*auditlog.Verification, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *auditlog.Verification
+	if rf, ok := ret.Get(0).(func(datastore.Context) *auditlog.Verification); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*auditlog.Verification)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(datastore.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordShellSession provides a mock function with given fields: ctx, session, recording
+func (_m *FacadeInterface) RecordShellSession(ctx datastore.Context, session auditlog.Session, recording *auditlog.Recording) error {
+	ret := _m.Called(ctx, session, recording)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(datastore.Context, auditlog.Session, *auditlog.Recording) error); ok {
+		r0 = rf(ctx, session, recording)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetShellSessionRecording provides a mock function with given fields: ctx, sessionID
+func (_m *FacadeInterface) GetShellSessionRecording(ctx datastore.Context, sessionID string) (*auditlog.Recording, error) {
+	ret := _m.Called(ctx, sessionID)
+
+	var r0 *auditlog.Recording
+	if rf, ok := ret.Get(0).(func(datastore.Context, string) *auditlog.Recording); ok {
+		r0 = rf(ctx, sessionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*auditlog.Recording)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(datastore.Context, string) error); ok {
+		r1 = rf(ctx, sessionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetPoolIPs provides a mock function with given fields: ctx, poolID
 func (_m *FacadeInterface) GetPoolIPs(ctx datastore.Context, poolID string) (*pool.PoolIPs, error) {
 	ret := _m.Called(ctx, poolID)
@@ -1377,6 +1626,20 @@ func (_m *FacadeInterface) RemoveHostExpiration(ctx datastore.Context, hostID st
 	_m.Called(ctx, hostID)
 }
 
+// RemovePublicEndpointCertificate provides a mock function with given fields: ctx, serviceID, endpointName, kind, name
+func (_m *FacadeInterface) RemovePublicEndpointCertificate(ctx datastore.Context, serviceID string, endpointName string, kind certificate.Kind, name string) error {
+	ret := _m.Called(ctx, serviceID, endpointName, kind, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(datastore.Context, string, string, certificate.Kind, string) error); ok {
+		r0 = rf(ctx, serviceID, endpointName, kind, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // RemovePublicEndpointPort provides a mock function with given fields: ctx, serviceid, endpointName, portAddr
 func (_m *FacadeInterface) RemovePublicEndpointPort(ctx datastore.Context, serviceid string, endpointName string, portAddr string) error {
 	ret := _m.Called(ctx, serviceid, endpointName, portAddr)
@@ -1608,6 +1871,43 @@ func (_m *FacadeInterface) RestartService(ctx datastore.Context, request dao.Sch
 	return r0, r1
 }
 
+// SetPublicEndpointCertificate provides a mock function with given fields: ctx, serviceID, endpointName, kind, name, certPEM, keyPEM
+func (_m *FacadeInterface) SetPublicEndpointCertificate(ctx datastore.Context, serviceID string, endpointName string, kind certificate.Kind, name string, certPEM []byte, keyPEM []byte) (*certificate.Certificate, error) {
+	ret := _m.Called(ctx, serviceID, endpointName, kind, name, certPEM, keyPEM)
+
+	var r0 *certificate.Certificate
+	if rf, ok := ret.Get(0).(func(datastore.Context, string, string, certificate.Kind, string, []byte, []byte) *certificate.Certificate); ok {
+		r0 = rf(ctx, serviceID, endpointName, kind, name, certPEM, keyPEM)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*certificate.Certificate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(datastore.Context, string, string, certificate.Kind, string, []byte, []byte) error); ok {
+		r1 = rf(ctx, serviceID, endpointName, kind, name, certPEM, keyPEM)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetStorageQuota provides a mock function with given fields: ctx, tenantID, quota
+func (_m *FacadeInterface) SetStorageQuota(ctx datastore.Context, tenantID string, quota volume.StorageQuota) error {
+	ret := _m.Called(ctx, tenantID, quota)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(datastore.Context, string, volume.StorageQuota) error); ok {
+		r0 = rf(ctx, tenantID, quota)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SyncServiceRegistry provides a mock function with given fields: ctx, svc
 func (_m *FacadeInterface) SyncServiceRegistry(ctx datastore.Context, svc *service.Service) error {
 	ret := _m.Called(ctx, svc)
@@ -1807,3 +2107,61 @@ func (_m *FacadeInterface) GetServiceNamePath(ctx datastore.Context, serviceID s
 
 	return r0, r1, r2
 }
+
+// EnablePublicEndpointACME provides a mock function with given fields: ctx, serviceID, endpointName, vhost, domains
+func (_m *FacadeInterface) EnablePublicEndpointACME(ctx datastore.Context, serviceID string, endpointName string, vhost string, domains []string) (*certificate.Certificate, error) {
+	ret := _m.Called(ctx, serviceID, endpointName, vhost, domains)
+
+	var r0 *certificate.Certificate
+	if rf, ok := ret.Get(0).(func(datastore.Context, string, string, string, []string) *certificate.Certificate); ok {
+		r0 = rf(ctx, serviceID, endpointName, vhost, domains)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*certificate.Certificate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(datastore.Context, string, string, string, []string) error); ok {
+		r1 = rf(ctx, serviceID, endpointName, vhost, domains)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RenewCertificates provides a mock function with given fields: ctx
+func (_m *FacadeInterface) RenewCertificates(ctx datastore.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(datastore.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetACMEChallenge provides a mock function with given fields: token
+func (_m *FacadeInterface) GetACMEChallenge(token string) (string, bool) {
+	ret := _m.Called(token)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(token)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(string) bool); ok {
+		r1 = rf(token)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
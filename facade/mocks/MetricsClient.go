@@ -54,3 +54,54 @@ func (_m *MetricsClient) GetInstanceMemoryStats(_a0 time.Time, _a1 ...metrics.Se
 
 	return r0, r1
 }
+
+// GetInstanceUsageHistory provides a mock function with given fields: _a0, _a1
+func (_m *MetricsClient) GetInstanceUsageHistory(_a0 time.Time, _a1 ...metrics.ServiceInstance) ([]metrics.InstanceUsageHistory, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []metrics.InstanceUsageHistory
+	if rf, ok := ret.Get(0).(func(time.Time, ...metrics.ServiceInstance) []metrics.InstanceUsageHistory); ok {
+		r0 = rf(_a0, _a1...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]metrics.InstanceUsageHistory)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time, ...metrics.ServiceInstance) error); ok {
+		r1 = rf(_a0, _a1...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPublicEndpointLatency provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MetricsClient) GetPublicEndpointLatency(_a0 time.Duration, _a1 string, _a2 ...string) (float64, bool, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 float64
+	if rf, ok := ret.Get(0).(func(time.Duration, string, ...string) float64); ok {
+		r0 = rf(_a0, _a1, _a2...)
+	} else {
+		r0 = ret.Get(0).(float64)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(time.Duration, string, ...string) bool); ok {
+		r1 = rf(_a0, _a1, _a2...)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(time.Duration, string, ...string) error); ok {
+		r2 = rf(_a0, _a1, _a2...)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
@@ -3,10 +3,12 @@ package mocks
 import "github.com/stretchr/testify/mock"
 
 import "github.com/control-center/serviced/datastore"
+import zkimgregistry "github.com/control-center/serviced/dfs/registry"
 import "github.com/control-center/serviced/domain/host"
 import "github.com/control-center/serviced/domain/pool"
 import "github.com/control-center/serviced/domain/registry"
 import "github.com/control-center/serviced/domain/service"
+import zkdocker "github.com/control-center/serviced/zzk/docker"
 import zkservice "github.com/control-center/serviced/zzk/service"
 
 type ZZK struct {
@@ -565,3 +567,86 @@ func (_m *ZZK) UpdateInstanceCurrentState(ctx datastore.Context, poolID, service
 
 	return r0
 }
+
+func (_m *ZZK) GetRegistryReplicationStatus() ([]zkimgregistry.ReplicaStatus, error) {
+	ret := _m.Called()
+
+	var r0 []zkimgregistry.ReplicaStatus
+	if rf, ok := ret.Get(0).(func() []zkimgregistry.ReplicaStatus); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]zkimgregistry.ReplicaStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (_m *ZZK) ResetRegistryReplication() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *ZZK) RequestPrePull(poolID string, hostID string, image string, uuid string) error {
+	ret := _m.Called(poolID, hostID, image, uuid)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, string) error); ok {
+		r0 = rf(poolID, hostID, image, uuid)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (_m *ZZK) GetPrePulls(poolID string) ([]zkdocker.PrePull, error) {
+	ret := _m.Called(poolID)
+
+	var r0 []zkdocker.PrePull
+	if rf, ok := ret.Get(0).(func(string) []zkdocker.PrePull); ok {
+		r0 = rf(poolID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]zkdocker.PrePull)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(poolID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (_m *ZZK) NotifyCertificates() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
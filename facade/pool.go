@@ -24,6 +24,7 @@ import (
 
 	"errors"
 	"fmt"
+	"reflect"
 	"time"
 )
 
@@ -190,6 +191,11 @@ func (f *Facade) updateResourcePool(ctx datastore.Context, entity *pool.Resource
 
 	f.poolCache.SetDirty()
 
+	// rebuild the logstash config if the pool's log outputs have changed
+	if !reflect.DeepEqual(entity.LogOutputs, current.LogOutputs) {
+		go LogstashContainerReloader(ctx, f)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,132 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package facade
+
+import (
+	"sort"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/datastore"
+	zkdocker "github.com/control-center/serviced/zzk/docker"
+)
+
+type prePullsByHost []zkdocker.PrePull
+
+func (p prePullsByHost) Len() int      { return len(p) }
+func (p prePullsByHost) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p prePullsByHost) Less(i, j int) bool {
+	if p[i].HostID != p[j].HostID {
+		return p[i].HostID < p[j].HostID
+	}
+	return p[i].Image < p[j].Image
+}
+
+// PrePullImages asks every host in the pools of a tenant's services to pull
+// the images of those services, so that instances do not wait on a pull when
+// they are scheduled.
+func (f *Facade) PrePullImages(ctx datastore.Context, tenantID string) error {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.PrePullImages"))
+	logger := plog.WithField("tenantid", tenantID)
+
+	svcs, err := f.GetServiceDetailsByTenantID(ctx, tenantID)
+	if err != nil {
+		logger.WithError(err).Debug("Could not look up services for tenant")
+		return err
+	}
+
+	requested := make(map[[2]string]struct{})
+	for _, svc := range svcs {
+		if svc.ImageID == "" {
+			continue
+		}
+		key := [2]string{svc.PoolID, svc.ImageID}
+		if _, ok := requested[key]; ok {
+			continue
+		}
+		requested[key] = struct{}{}
+		if err := f.prePullImage(ctx, svc.PoolID, svc.ImageID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PrePullAllImages asks every host to pull the images of the services in its
+// pool.
+func (f *Facade) PrePullAllImages(ctx datastore.Context) error {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.PrePullAllImages"))
+	tenantIDs, err := f.GetTenantIDs(ctx)
+	if err != nil {
+		plog.WithError(err).Debug("Could not get tenants")
+		return err
+	}
+	for _, tenantID := range tenantIDs {
+		if err := f.PrePullImages(ctx, tenantID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prePullImage asks every host in a pool to pull an image.
+func (f *Facade) prePullImage(ctx datastore.Context, poolID, image string) error {
+	logger := plog.WithFields(logrus.Fields{
+		"poolid":  poolID,
+		"imageid": image,
+	})
+
+	uuid, err := f.getImageUUID(ctx, image)
+	if err != nil {
+		logger.WithError(err).Debug("Could not look up image in the registry")
+		return err
+	}
+
+	hosts, err := f.FindHostsInPool(ctx, poolID)
+	if err != nil {
+		logger.WithError(err).Debug("Could not look up hosts in pool")
+		return err
+	}
+
+	for _, h := range hosts {
+		if err := f.zzk.RequestPrePull(poolID, h.ID, image, uuid); err != nil {
+			logger.WithField("hostid", h.ID).WithError(err).Debug("Could not request image pre-pull")
+			return err
+		}
+	}
+	logger.WithField("hosts", len(hosts)).Debug("Requested image pre-pull")
+	return nil
+}
+
+// GetPrePullStatus returns the progress of image pre-pulls on every host,
+// sorted by host.
+func (f *Facade) GetPrePullStatus(ctx datastore.Context) ([]zkdocker.PrePull, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.GetPrePullStatus"))
+	pools, err := f.GetResourcePools(ctx)
+	if err != nil {
+		plog.WithError(err).Debug("Could not look up resource pools")
+		return nil, err
+	}
+
+	reqs := []zkdocker.PrePull{}
+	for _, p := range pools {
+		preqs, err := f.zzk.GetPrePulls(p.ID)
+		if err != nil {
+			plog.WithField("poolid", p.ID).WithError(err).Debug("Could not look up image pre-pulls")
+			return nil, err
+		}
+		reqs = append(reqs, preqs...)
+	}
+	sort.Sort(prePullsByHost(reqs))
+	return reqs, nil
+}
@@ -15,13 +15,16 @@ package facade
 
 import (
 	"fmt"
+	"io"
 	"net"
 	"regexp"
 	"strings"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/control-center/serviced/audit"
+	"github.com/control-center/serviced/commons"
 	"github.com/control-center/serviced/datastore"
+	"github.com/control-center/serviced/domain/certificate"
 	"github.com/control-center/serviced/domain/service"
 	"github.com/control-center/serviced/domain/servicedefinition"
 	"github.com/zenoss/glog"
@@ -31,7 +34,7 @@ var vhostNameRegex = regexp.MustCompile("^(([a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*
 
 // Adds a port public endpoint to a service
 func (f *Facade) AddPublicEndpointPort(ctx datastore.Context, serviceID, endpointName, portAddr string,
-	usetls bool, protocol string, isEnabled bool, restart bool) (*servicedefinition.Port, error) {
+	usetls bool, protocol string, isEnabled bool, access servicedefinition.AccessRules, restart bool) (*servicedefinition.Port, error) {
 	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.AddPublicEndpointPort"))
 	alog := f.auditLogger.Message(ctx, "Adding Public Endpoint Port").Action(audit.Update).ID(serviceID).
 		WithFields(logrus.Fields{
@@ -40,10 +43,19 @@ func (f *Facade) AddPublicEndpointPort(ctx datastore.Context, serviceID, endpoin
 			"usetls":       usetls,
 			"protocol":     protocol,
 			"isenabled":    isEnabled,
+			"allow":        strings.Join(access.Allow, ","),
+			"deny":         strings.Join(access.Deny, ","),
+			"ratelimit":    access.RateLimit,
 		})
 	// Scrub the port for all checks, as this is what gets stored against the service.
 	portAddr = service.ScrubPortString(portAddr)
 
+	// Validate the access rules
+	if err := access.ValidEntity(); err != nil {
+		glog.Error(err)
+		return nil, alog.Error(err)
+	}
+
 	// Validate the port number
 	portParts := strings.Split(portAddr, ":")
 	if len(portParts) < 2 {
@@ -61,7 +73,13 @@ func (f *Facade) AddPublicEndpointPort(ctx datastore.Context, serviceID, endpoin
 	// Check to make sure the port is available.  Don't allow adding a port if it's already being used.
 	// This has the added benefit of validating the port address before it gets added to the service
 	// definition.
-	if err := checkPort("tcp", fmt.Sprintf("%s", portAddr)); err != nil {
+	if protocol == commons.UDP && usetls {
+		err := fmt.Errorf("TLS is not supported on udp ports")
+		glog.Error(err)
+		return nil, alog.Error(err)
+	}
+
+	if err := checkPort(portNetwork(protocol), fmt.Sprintf("%s", portAddr)); err != nil {
 		glog.Error(err)
 		return nil, alog.Error(err)
 	}
@@ -107,6 +125,11 @@ func (f *Facade) AddPublicEndpointPort(ctx datastore.Context, serviceID, endpoin
 		glog.Error(err)
 		return nil, alog.Error(err)
 	}
+	if err := svc.SetPortAccess(endpointName, port.PortAddr, access); err != nil {
+		glog.Error(err)
+		return nil, alog.Error(err)
+	}
+	port.AccessRules = access
 
 	// Make sure no other service currently has zzk data for this port -- this would result
 	// in the service getting turned off during restart but not being able to start again.
@@ -129,9 +152,25 @@ func (f *Facade) AddPublicEndpointPort(ctx datastore.Context, serviceID, endpoin
 }
 
 // Try to open the port.  If the port opens, we're good. Otherwise return the error.
+// portNetwork returns the network a public port with the protocol listens on
+func portNetwork(protocol string) string {
+	if protocol == commons.UDP {
+		return commons.UDP
+	}
+	return commons.TCP
+}
+
 func checkPort(network string, laddr string) error {
 	glog.V(2).Infof("Checking %s port %s", network, laddr)
-	listener, err := net.Listen(network, laddr)
+	var (
+		listener io.Closer
+		err      error
+	)
+	if network == commons.UDP {
+		listener, err = net.ListenPacket(network, laddr)
+	} else {
+		listener, err = net.Listen(network, laddr)
+	}
 	if err != nil {
 		// Port isn't available.
 		glog.V(2).Infof("Port Listen failed; something else is using this port.")
@@ -178,6 +217,11 @@ func (f *Facade) RemovePublicEndpointPort(ctx datastore.Context, serviceid, endp
 		return alog.Error(err)
 	}
 
+	// The certificate of the port goes with it
+	if err = f.removeCertificate(ctx, certificate.Port, portAddr); err != nil {
+		glog.Warningf("Could not remove the certificate of port %s: %s", portAddr, err)
+	}
+
 	glog.V(2).Infof("Service (%s) updated", svc.Name)
 	alog.Succeeded()
 	return nil
@@ -270,7 +314,7 @@ func (f *Facade) EnablePublicEndpointPort(ctx datastore.Context, serviceid, endp
 			return alog.Error(err)
 		}
 
-		if err = checkPort("tcp", fmt.Sprintf("%s", portAddr)); err != nil {
+		if err = checkPort(portNetwork(port.Protocol), fmt.Sprintf("%s", portAddr)); err != nil {
 			glog.Error(err)
 			return alog.Error(err)
 		}
@@ -295,13 +339,17 @@ func (f *Facade) EnablePublicEndpointPort(ctx datastore.Context, serviceid, endp
 }
 
 // Adds a vhost public endpoint to a service
-func (f *Facade) AddPublicEndpointVHost(ctx datastore.Context, serviceid, endpointName, vhostName string, isEnabled, restart bool) (*servicedefinition.VHost, error) {
+func (f *Facade) AddPublicEndpointVHost(ctx datastore.Context, serviceid, endpointName, vhostName string, isEnabled bool,
+	access servicedefinition.AccessRules, restart bool) (*servicedefinition.VHost, error) {
 	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.AddPublicEndpointVHost"))
 	alog := f.auditLogger.Message(ctx, "Adding Public Endpoint VHost").Action(audit.Update).ID(serviceid).
 		WithFields(logrus.Fields{
 			"endpointname": endpointName,
 			"vhostname":    vhostName,
 			"isenabled":    isEnabled,
+			"allow":        strings.Join(access.Allow, ","),
+			"deny":         strings.Join(access.Deny, ","),
+			"ratelimit":    access.RateLimit,
 		})
 	// Get the service for this service id.
 	svc, err := f.GetService(ctx, serviceid)
@@ -319,6 +367,12 @@ func (f *Facade) AddPublicEndpointVHost(ctx datastore.Context, serviceid, endpoi
 		return nil, alog.Error(err)
 	}
 
+	// Validate the access rules
+	if err := access.ValidEntity(); err != nil {
+		glog.Error(err)
+		return nil, alog.Error(err)
+	}
+
 	// check other virtual hosts for redundancy
 	vhostLowerName := strings.ToLower(vhostName)
 
@@ -352,6 +406,11 @@ func (f *Facade) AddPublicEndpointVHost(ctx datastore.Context, serviceid, endpoi
 		glog.Error(err)
 		return nil, alog.Error(err)
 	}
+	if err := svc.SetVirtualHostAccess(endpointName, vhost.Name, access); err != nil {
+		glog.Error(err)
+		return nil, alog.Error(err)
+	}
+	vhost.AccessRules = access
 
 	// Make sure no other service currently has zzk data for this vhost -- this would result
 	// in the service getting turned off during restart but not being able to start again.
@@ -404,6 +463,11 @@ func (f *Facade) RemovePublicEndpointVHost(ctx datastore.Context, serviceid, end
 		return alog.Error(err)
 	}
 
+	// The certificate of the vhost goes with it
+	if err = f.removeCertificate(ctx, certificate.VHost, strings.ToLower(vhost)); err != nil {
+		glog.Warningf("Could not remove the certificate of vhost %s: %s", vhost, err)
+	}
+
 	glog.V(2).Infof("Service (%s) updated", svc.Name)
 	alog.Succeeded()
 	return nil
@@ -107,7 +107,7 @@ func (ft *FacadeIntegrationTest) Test_PublicEndpoint_PortAdd(c *C) {
 
 	// Add a valid port.
 	port, err := ft.Facade.AddPublicEndpointPort(ft.CTX, svcA.ID, "zproxy", ":33333",
-		true, "http", true, false)
+		true, "http", true, servicedefinition.AccessRules{}, false)
 	c.Assert(err, IsNil)
 	if port == nil {
 		c.Errorf("Adding a valid public endpoint port returned a nil port")
@@ -126,7 +126,7 @@ func (ft *FacadeIntegrationTest) Test_PublicEndpoint_PortAdd_VerifyEnabledFlag(c
 	ft.zzk.On("GetPublicPort", ":12345").Return("", "", nil)
 
 	// Add a new vhost with enabled=false.
-	_, err := ft.Facade.AddPublicEndpointPort(ft.CTX, svcB.ID, "service2", ":12345", true, "http", false, false)
+	_, err := ft.Facade.AddPublicEndpointPort(ft.CTX, svcB.ID, "service2", ":12345", true, "http", false, servicedefinition.AccessRules{}, false)
 	c.Assert(err, IsNil)
 
 	// Check to make sure the new vhost is *not* enabled.
@@ -139,6 +139,54 @@ func (ft *FacadeIntegrationTest) Test_PublicEndpoint_PortAdd_VerifyEnabledFlag(c
 	fmt.Println(" ##### Test_PublicEndpoint_PortAdd_VerifyEnabledFlag: PASSED")
 }
 
+func (ft *FacadeIntegrationTest) Test_PublicEndpoint_PortAdd_AccessRules(c *C) {
+	fmt.Println(" ##### Test_PublicEndpoint_PortAdd_AccessRules: STARTED")
+
+	// Add a service so we can test our public endpoint.
+	_, svcB := ft.setupServiceWithPublicEndpoints(c)
+
+	// Add mock calls.
+	ft.zzk.On("GetPublicPort", ":12346").Return("", "", nil)
+
+	// Add a port with access rules.
+	access := servicedefinition.AccessRules{Allow: []string{"10.0.0.0/8"}, Deny: []string{"10.1.2.3"}, RateLimit: 10}
+	port, err := ft.Facade.AddPublicEndpointPort(ft.CTX, svcB.ID, "service2", ":12346", false, "", true, access, false)
+	c.Assert(err, IsNil)
+	c.Assert(port.AccessRules, DeepEquals, access)
+
+	// Check to make sure the rules were saved with the port.
+	svc, err := ft.Facade.GetService(ft.CTX, svcB.ID)
+	c.Assert(err, IsNil)
+	c.Assert(svc.Endpoints[0].PortList[0].AccessRules, DeepEquals, access)
+
+	// Invalid rules are rejected.
+	access = servicedefinition.AccessRules{Allow: []string{"10.0.0.0/33"}}
+	_, err = ft.Facade.AddPublicEndpointPort(ft.CTX, svcB.ID, "service2", ":12347", false, "", true, access, false)
+	c.Assert(err, NotNil)
+
+	fmt.Println(" ##### Test_PublicEndpoint_PortAdd_AccessRules: PASSED")
+}
+
+func (ft *FacadeIntegrationTest) Test_PublicEndpoint_PortAdd_UDP(c *C) {
+	fmt.Println(" ##### Test_PublicEndpoint_PortAdd_UDP: STARTED")
+
+	// Add a service so we can test our public endpoint.
+	_, svcB := ft.setupServiceWithPublicEndpoints(c)
+
+	// Add mock calls.
+	ft.zzk.On("GetPublicPort", ":12348").Return("", "", nil)
+
+	// TLS can't be used on a udp port.
+	_, err := ft.Facade.AddPublicEndpointPort(ft.CTX, svcB.ID, "service2", ":12348", true, "udp", true, servicedefinition.AccessRules{}, false)
+	c.Assert(err, NotNil)
+
+	port, err := ft.Facade.AddPublicEndpointPort(ft.CTX, svcB.ID, "service2", ":12348", false, "udp", true, servicedefinition.AccessRules{}, false)
+	c.Assert(err, IsNil)
+	c.Assert(port.Protocol, Equals, "udp")
+
+	fmt.Println(" ##### Test_PublicEndpoint_PortAdd_UDP: PASSED")
+}
+
 func (ft *FacadeIntegrationTest) Test_PublicEndpoint_PortAdd_DuplicatePort(c *C) {
 	fmt.Println(" ##### Test_PublicEndpoint_PortAdd_DuplicatePort: starting")
 
@@ -147,7 +195,7 @@ func (ft *FacadeIntegrationTest) Test_PublicEndpoint_PortAdd_DuplicatePort(c *C)
 
 	// Add a duplicate port.
 	_, err := ft.Facade.AddPublicEndpointPort(ft.CTX, svcA.ID, "zproxy", ":22222",
-		true, "http", true, false)
+		true, "http", true, servicedefinition.AccessRules{}, false)
 	if err == nil {
 		c.Errorf("Expected failure adding a duplicate port")
 	}
@@ -163,7 +211,7 @@ func (ft *FacadeIntegrationTest) Test_PublicEndpoint_PortAdd_OutOfRangePort(c *C
 
 	// Add a port with an invalid port range.
 	_, err := ft.Facade.AddPublicEndpointPort(ft.CTX, svcA.ID, "zproxy", ":70000",
-		true, "http", true, false)
+		true, "http", true, servicedefinition.AccessRules{}, false)
 	if err == nil {
 		c.Errorf("Expected failure adding an out of range port address :70000")
 	}
@@ -178,7 +226,7 @@ func (ft *FacadeIntegrationTest) Test_PublicEndpoint_PortAdd_PortZero(c *C) {
 	svcA, _ := ft.setupServiceWithPublicEndpoints(c)
 
 	_, err := ft.Facade.AddPublicEndpointPort(ft.CTX, svcA.ID, "zproxy", ":0",
-		true, "http", true, false)
+		true, "http", true, servicedefinition.AccessRules{}, false)
 	if err == nil {
 		c.Errorf("Expected failure adding an invalid port address :0")
 	}
@@ -193,7 +241,7 @@ func (ft *FacadeIntegrationTest) Test_PublicEndpoint_PortAdd_NegativePort(c *C)
 	svcA, _ := ft.setupServiceWithPublicEndpoints(c)
 
 	_, err := ft.Facade.AddPublicEndpointPort(ft.CTX, svcA.ID, "zproxy", ":-1",
-		true, "http", true, false)
+		true, "http", true, servicedefinition.AccessRules{}, false)
 	if err == nil {
 		c.Errorf("Expected failure adding a negative port address :-1")
 	}
@@ -209,7 +257,7 @@ func (ft *FacadeIntegrationTest) Test_PublicEndpoint_PortAdd_InvalidService(c *C
 
 	// Add a port for an invalid service.
 	_, err := ft.Facade.AddPublicEndpointPort(ft.CTX, "invalid", "zproxy", ":22223",
-		true, "http", true, false)
+		true, "http", true, servicedefinition.AccessRules{}, false)
 	if err == nil {
 		c.Errorf("Expected failure adding a port to an invalid service")
 	}
@@ -225,7 +273,7 @@ func (ft *FacadeIntegrationTest) Test_PublicEndpoint_PortAdd_PortInAnotherServic
 
 	// Add a port to a service that's defined in another service.
 	_, err := ft.Facade.AddPublicEndpointPort(ft.CTX, svcB.ID, "service2", ":22222",
-		true, "http", true, false)
+		true, "http", true, servicedefinition.AccessRules{}, false)
 	if err == nil {
 		c.Errorf("Expected failure adding a port that already exists in another service")
 	}
@@ -450,7 +498,7 @@ func (ft *FacadeIntegrationTest) Test_PublicEndpoint_VHostAdd_VerifyEnabledFlag(
 	ft.zzk.On("GetVHost", "service2").Return("", "", nil)
 
 	// Add a new vhost with enabled=false.
-	_, err := ft.Facade.AddPublicEndpointVHost(ft.CTX, svcB.ID, "service2", "service2", false, false)
+	_, err := ft.Facade.AddPublicEndpointVHost(ft.CTX, svcB.ID, "service2", "service2", false, servicedefinition.AccessRules{}, false)
 	c.Assert(err, IsNil)
 
 	// Check to make sure the new vhost is *not* enabled.
@@ -467,7 +515,7 @@ func (ft *FacadeIntegrationTest) Test_PublicEndpoint_VHostAdd_InvalidService(c *
 	fmt.Println(" ##### Test_PublicEndpoint_VHostAdd_InvalidService: STARTED")
 
 	// Add a vhost to an invalid service.
-	_, err := ft.Facade.AddPublicEndpointVHost(ft.CTX, "invalid", "zproxy", "zproxy", true, true)
+	_, err := ft.Facade.AddPublicEndpointVHost(ft.CTX, "invalid", "zproxy", "zproxy", true, servicedefinition.AccessRules{}, true)
 	if err == nil {
 		c.Errorf("Expected failure adding a vhost with an invalid service id")
 	}
@@ -481,7 +529,7 @@ func (ft *FacadeIntegrationTest) Test_PublicEndpoint_VHostAdd_InvalidEndpoint(c
 	svcA, _ := ft.setupServiceWithPublicEndpoints(c)
 
 	// Add a vhost to a service with an invalid endpoint.
-	_, err := ft.Facade.AddPublicEndpointVHost(ft.CTX, svcA.ID, "invalid", "zproxy", true, true)
+	_, err := ft.Facade.AddPublicEndpointVHost(ft.CTX, svcA.ID, "invalid", "zproxy", true, servicedefinition.AccessRules{}, true)
 	if err == nil {
 		c.Errorf("Expected failure adding a vhost with an invalid endpoint")
 	}
@@ -494,7 +542,7 @@ func (ft *FacadeIntegrationTest) Test_PublicEndpoint_VHostAdd_DuplicateVHost(c *
 	_, svcB := ft.setupServiceWithPublicEndpoints(c)
 
 	// Add a vhost to a service, but another service already has this vhost.
-	_, err := ft.Facade.AddPublicEndpointVHost(ft.CTX, svcB.ID, "service2", "zproxy", true, true)
+	_, err := ft.Facade.AddPublicEndpointVHost(ft.CTX, svcB.ID, "service2", "zproxy", true, servicedefinition.AccessRules{}, true)
 	if err == nil {
 		c.Errorf("Expected failure adding a duplicate vhost name")
 	}
@@ -511,7 +559,7 @@ func (ft *FacadeIntegrationTest) Test_PublicEndpoint_VHostAdd_InvalidVHostName(c
 	ft.zzk.On("GetVHost", "test#$%").Return("", "", nil)
 
 	// Add a vhost to a service with a vhost name that contains invalid characters.
-	_, err := ft.Facade.AddPublicEndpointVHost(ft.CTX, svcB.ID, "service2", "test#$%", true, true)
+	_, err := ft.Facade.AddPublicEndpointVHost(ft.CTX, svcB.ID, "service2", "test#$%", true, servicedefinition.AccessRules{}, true)
 	if err == nil {
 		c.Errorf("Expected failure adding a vhost with invalid characters")
 	}
@@ -530,7 +578,7 @@ func (ft *FacadeIntegrationTest) Test_PublicEndpoint_VHostAdd(c *C) {
 	ft.zzk.On("GetVHost", "zproxy2").Return("", "", nil)
 
 	// Add a valid vhost entry.
-	_, err := ft.Facade.AddPublicEndpointVHost(ft.CTX, svcA.ID, "zproxy", "zproxy2", true, true)
+	_, err := ft.Facade.AddPublicEndpointVHost(ft.CTX, svcA.ID, "zproxy", "zproxy2", true, servicedefinition.AccessRules{}, true)
 	if err != nil {
 		c.Errorf("Unexpected failure adding a valid vhost")
 	}
@@ -538,6 +586,34 @@ func (ft *FacadeIntegrationTest) Test_PublicEndpoint_VHostAdd(c *C) {
 	fmt.Println(" ##### Test_PublicEndpoint_VHostAdd: PASSED")
 }
 
+func (ft *FacadeIntegrationTest) Test_PublicEndpoint_VHostAdd_AccessRules(c *C) {
+	fmt.Println(" ##### Test_PublicEndpoint_VHostAdd_AccessRules: STARTED")
+
+	svcA, _ := ft.setupServiceWithPublicEndpoints(c)
+
+	// Mock call expectations:
+	ft.zzk.On("GetPublicPort", ":22222").Return("", "", nil)
+	ft.zzk.On("GetVHost", "zproxy").Return("", "", nil)
+	ft.zzk.On("GetVHost", "zproxy3").Return("", "", nil)
+
+	// Add a vhost with a rate limit.
+	access := servicedefinition.AccessRules{RateLimit: 50, Burst: 100}
+	vhost, err := ft.Facade.AddPublicEndpointVHost(ft.CTX, svcA.ID, "zproxy", "zproxy3", true, access, false)
+	c.Assert(err, IsNil)
+	c.Assert(vhost.AccessRules, DeepEquals, access)
+
+	svc, err := ft.Facade.GetService(ft.CTX, svcA.ID)
+	c.Assert(err, IsNil)
+	c.Assert(svc.GetVirtualHost("zproxy", "zproxy3").AccessRules, DeepEquals, access)
+
+	// Invalid rules are rejected.
+	access = servicedefinition.AccessRules{RateLimit: -1}
+	_, err = ft.Facade.AddPublicEndpointVHost(ft.CTX, svcA.ID, "zproxy", "zproxy4", true, access, false)
+	c.Assert(err, NotNil)
+
+	fmt.Println(" ##### Test_PublicEndpoint_VHostAdd_AccessRules: PASSED")
+}
+
 func (ft *FacadeIntegrationTest) Test_PublicEndpointVHost_Remove(c *C) {
 	fmt.Println(" ##### Test_PublicEndpointVHost_Remove: STARTED")
 
@@ -14,12 +14,20 @@
 package facade
 
 import (
+	"errors"
+
+	"github.com/control-center/serviced/config"
 	"github.com/control-center/serviced/coordinator/client"
 	"github.com/control-center/serviced/datastore"
+	zkimgregistry "github.com/control-center/serviced/dfs/registry"
 	"github.com/control-center/serviced/domain/registry"
 	"github.com/zenoss/glog"
 )
 
+// ErrReplicationNotConfigured is returned when registry replication is
+// requested but no secondary registry is set.
+var ErrReplicationNotConfigured = errors.New("docker registry replication is not configured")
+
 // GetRegistryImage returns information about an image that is stored in the
 // docker registry index.
 // e.g. GetRegistryImage(ctx, "library/reponame:tagname")
@@ -115,3 +123,23 @@ func (f *Facade) SyncRegistryImages(ctx datastore.Context, force bool) error {
 	}
 	return nil
 }
+
+// GetRegistryReplicationStatus returns the replication status of every image
+// in the docker registry index.
+func (f *Facade) GetRegistryReplicationStatus(ctx datastore.Context) ([]zkimgregistry.ReplicaStatus, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.GetRegistryReplicationStatus"))
+	if config.GetOptions().DockerRegistryReplica == "" {
+		return nil, ErrReplicationNotConfigured
+	}
+	return f.zzk.GetRegistryReplicationStatus()
+}
+
+// ReplicateRegistry pushes every image in the docker registry index to the
+// secondary registry again, including images that are already replicated.
+func (f *Facade) ReplicateRegistry(ctx datastore.Context) error {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.ReplicateRegistry"))
+	if config.GetOptions().DockerRegistryReplica == "" {
+		return ErrReplicationNotConfigured
+	}
+	return f.zzk.ResetRegistryReplication()
+}
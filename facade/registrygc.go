@@ -0,0 +1,145 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package facade
+
+import (
+	"fmt"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/audit"
+	"github.com/control-center/serviced/commons"
+	"github.com/control-center/serviced/datastore"
+	"github.com/control-center/serviced/dfs"
+	"github.com/control-center/serviced/dfs/docker"
+)
+
+// CollectRegistryGarbage removes images from the docker registry that are not
+// used by any service or referenced by any snapshot, and frees the storage of
+// their blobs.  If dryRun is set, the report describes what would be removed
+// without removing anything.
+func (f *Facade) CollectRegistryGarbage(ctx datastore.Context, dryRun bool) (*dfs.RegistryGCReport, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.CollectRegistryGarbage"))
+	logger := plog.WithField("dryrun", dryRun)
+	if err := f.DFSLock(ctx).LockWithTimeout("collect registry garbage", userLockTimeout); err != nil {
+		logger.WithError(err).Debug("Cannot collect registry garbage")
+		return nil, err
+	}
+	defer f.DFSLock(ctx).Unlock()
+
+	keep, err := f.getReferencedImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rImages, err := f.GetRegistryImages(ctx)
+	if err != nil {
+		logger.WithError(err).Debug("Could not get images from the registry index")
+		return nil, err
+	}
+	images := make([]string, len(rImages))
+	for i, rImage := range rImages {
+		images[i] = rImage.String()
+	}
+
+	if dryRun {
+		return f.dfs.CollectRegistryGarbage(images, keep, true)
+	}
+	alog := f.auditLogger.Message(ctx, "Collecting Registry Garbage").Action(audit.Remove)
+	report, err := f.dfs.CollectRegistryGarbage(images, keep, false)
+	if err != nil {
+		logger.WithError(err).Debug("Could not collect registry garbage")
+		return nil, alog.Error(err)
+	}
+
+	// pushes are held by the dfs lock, so the registry can safely free the
+	// blobs of the removed manifests
+	if len(report.Manifests) > 0 && f.registryBlobs != nil {
+		if err := f.registryBlobs(); err != nil {
+			// the manifests are already gone, so their blobs will be picked
+			// up by the next collection
+			logger.WithError(err).Warn("Could not free registry storage")
+		}
+	}
+	alog.WithFields(logrus.Fields{
+		"images": len(report.Images),
+		"bytes":  report.Bytes,
+	}).Succeeded()
+	logger.WithField("images", len(report.Images)).Info("Collected registry garbage")
+	return report, nil
+}
+
+// getReferencedImages returns the registry index names (library/repo:tag) of
+// every image that is used by a service or referenced by a snapshot.
+func (f *Facade) getReferencedImages(ctx datastore.Context) ([]string, error) {
+	imagesMap := make(map[string]struct{})
+	images := []string{}
+	addImage := func(image string) error {
+		imageID, err := commons.ParseImageID(image)
+		if err != nil {
+			return err
+		}
+		if imageID.IsLatest() {
+			imageID.Tag = docker.Latest
+		}
+		name := fmt.Sprintf("%s/%s:%s", imageID.User, imageID.Repo, imageID.Tag)
+		if _, ok := imagesMap[name]; !ok {
+			imagesMap[name] = struct{}{}
+			images = append(images, name)
+		}
+		return nil
+	}
+
+	tenantIDs, err := f.GetTenantIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, tenantID := range tenantIDs {
+		logger := plog.WithField("tenantid", tenantID)
+		svcs, err := f.GetServiceDetailsByTenantID(ctx, tenantID)
+		if err != nil {
+			logger.WithError(err).Debug("Could not get services for tenant")
+			return nil, err
+		}
+		for _, svc := range svcs {
+			if svc.ImageID == "" {
+				continue
+			}
+			if err := addImage(svc.ImageID); err != nil {
+				logger.WithField("imageid", svc.ImageID).WithError(err).Debug("Could not parse service image")
+				return nil, err
+			}
+		}
+
+		snapshots, err := f.dfs.List(tenantID)
+		if err != nil {
+			logger.WithError(err).Debug("Could not list snapshots for tenant")
+			return nil, err
+		}
+		for _, snapshotID := range snapshots {
+			info, err := f.dfs.Info(snapshotID)
+			if err != nil {
+				// without the snapshot's metadata there is no way to know
+				// which images are safe to remove
+				logger.WithField("snapshotid", snapshotID).WithError(err).Debug("Could not get snapshot info")
+				return nil, err
+			}
+			for _, image := range info.Images {
+				if err := addImage(image); err != nil {
+					logger.WithField("imageid", image).WithError(err).Debug("Could not parse snapshot image")
+					return nil, err
+				}
+			}
+		}
+	}
+	return images, nil
+}
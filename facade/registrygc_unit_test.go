@@ -0,0 +1,110 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package facade_test
+
+import (
+	"time"
+
+	"github.com/control-center/serviced/dfs"
+	dfsmocks "github.com/control-center/serviced/dfs/mocks"
+	"github.com/control-center/serviced/domain/registry"
+	"github.com/control-center/serviced/domain/service"
+	. "gopkg.in/check.v1"
+)
+
+// setupRegistryGarbage sets up a tenant whose service and snapshot keep two
+// of the four images in the registry
+func (ft *FacadeUnitTest) setupRegistryGarbage() {
+	tenant := service.ServiceDetails{ID: "tenant", ImageID: "localhost:5000/tenant/repo"}
+	child := service.ServiceDetails{ID: "child", ParentServiceID: "tenant", ImageID: "localhost:5000/tenant/repo:latest"}
+	ft.serviceStore.On("GetServiceDetailsByParentID", ft.ctx, "", time.Duration(0)).Return([]service.ServiceDetails{tenant}, nil)
+	ft.serviceStore.On("Query", ft.ctx, service.Query{}).Return([]service.ServiceDetails{tenant, child}, nil)
+	ft.serviceStore.On("GetServiceDetails", ft.ctx, "tenant").Return(&tenant, nil)
+	ft.serviceStore.On("GetServiceDetails", ft.ctx, "child").Return(&child, nil)
+	ft.dfs.On("List", "tenant").Return([]string{"tenant_snap1"}, nil)
+	ft.dfs.On("Info", "tenant_snap1").Return(&dfs.SnapshotInfo{
+		Images: []string{"localhost:5000/tenant/repo:snap1"},
+	}, nil)
+	ft.registryStore.On("GetImages", ft.ctx).Return([]registry.Image{
+		{Library: "tenant", Repo: "repo", Tag: "latest"},
+		{Library: "tenant", Repo: "repo", Tag: "snap1"},
+		{Library: "tenant", Repo: "repo", Tag: "snap0"},
+		{Library: "removed", Repo: "repo", Tag: "latest"},
+	}, nil)
+}
+
+func (ft *FacadeUnitTest) Test_CollectRegistryGarbage(c *C) {
+	ft.setupMockDFSLocking()
+	ft.setupRegistryGarbage()
+	collected := false
+	ft.Facade.SetRegistryBlobCollector(func() error {
+		collected = true
+		return nil
+	})
+	report := &dfs.RegistryGCReport{DryRun: true, Images: []string{"tenant/repo:snap0", "removed/repo:latest"}}
+	ft.dfs.On("CollectRegistryGarbage",
+		[]string{"tenant/repo:latest", "tenant/repo:snap1", "tenant/repo:snap0", "removed/repo:latest"},
+		[]string{"tenant/repo:latest", "tenant/repo:snap1"},
+		true,
+	).Return(report, nil)
+
+	actual, err := ft.Facade.CollectRegistryGarbage(ft.ctx, true)
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, report)
+	c.Assert(collected, Equals, false)
+}
+
+// lockedDFS records whether the dfs lock is held
+type lockedDFS struct {
+	*dfsmocks.DFS
+	locked bool
+}
+
+func (d *lockedDFS) LockWithTimeout(opName string, timeout time.Duration) error {
+	d.locked = true
+	return nil
+}
+
+func (d *lockedDFS) Unlock() { d.locked = false }
+
+func (ft *FacadeUnitTest) Test_CollectRegistryGarbageBlobs(c *C) {
+	lock := &lockedDFS{DFS: ft.dfs}
+	ft.Facade.SetDFS(lock)
+	ft.setupRegistryGarbage()
+
+	// the blobs are freed while pushes are still held by the dfs lock
+	collected := 0
+	ft.Facade.SetRegistryBlobCollector(func() error {
+		c.Check(lock.locked, Equals, true)
+		collected++
+		return nil
+	})
+	report := &dfs.RegistryGCReport{
+		Images:    []string{"tenant/repo:snap0", "removed/repo:latest"},
+		Manifests: []string{"sha256:0", "sha256:1"},
+	}
+	ft.dfs.On("CollectRegistryGarbage",
+		[]string{"tenant/repo:latest", "tenant/repo:snap1", "tenant/repo:snap0", "removed/repo:latest"},
+		[]string{"tenant/repo:latest", "tenant/repo:snap1"},
+		false,
+	).Return(report, nil)
+
+	actual, err := ft.Facade.CollectRegistryGarbage(ft.ctx, false)
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, report)
+	c.Assert(collected, Equals, 1)
+	c.Assert(lock.locked, Equals, false)
+}
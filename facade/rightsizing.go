@@ -0,0 +1,117 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package facade
+
+import (
+	"time"
+
+	"github.com/control-center/serviced/datastore"
+	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/metrics"
+)
+
+// GetResourceRecommendations compares the memory and cpu usage of the
+// instances of each service since the given time to the commitments and
+// limits of the service, and recommends new values.  Only the services of
+// the tenant are reported if a tenant is given.  Services that reported no
+// usage are skipped.
+func (f *Facade) GetResourceRecommendations(ctx datastore.Context, tenantID string, since time.Time) ([]service.ResourceRecommendation, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.GetResourceRecommendations"))
+	logger := plog.WithField("tenantid", tenantID)
+
+	tenantIDs := []string{tenantID}
+	if tenantID == "" {
+		var err error
+		if tenantIDs, err = f.GetTenantIDs(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	svcs := []service.Service{}
+	instances := []metrics.ServiceInstance{}
+	for _, tid := range tenantIDs {
+		err := f.walkServices(ctx, tid, true, func(svc *service.Service) error {
+			if svc.Instances == 0 {
+				return nil
+			}
+			svcs = append(svcs, *svc)
+			for i := 0; i < svc.Instances; i++ {
+				instances = append(instances, metrics.ServiceInstance{ServiceID: svc.ID, InstanceID: i})
+			}
+			return nil
+		}, "GetResourceRecommendations")
+		if err != nil {
+			logger.WithError(err).Debug("Could not look up the services of the tenant")
+			return nil, err
+		}
+	}
+	result := []service.ResourceRecommendation{}
+	if len(instances) == 0 {
+		return result, nil
+	}
+
+	history, err := f.metricsClient.GetInstanceUsageHistory(since, instances...)
+	if err != nil {
+		logger.WithError(err).Debug("Could not look up the usage history of the services")
+		return nil, err
+	}
+	usage := make(map[string]*service.ResourceUsage)
+	for _, h := range history {
+		if len(h.Memory) == 0 && len(h.CPU) == 0 {
+			continue
+		}
+		u, ok := usage[h.ServiceID]
+		if !ok {
+			u = &service.ResourceUsage{}
+			usage[h.ServiceID] = u
+		}
+		u.MemoryP95 = maxInt64(u.MemoryP95, int64(metrics.Percentile(h.Memory, 95)))
+		u.MemoryMax = maxInt64(u.MemoryMax, int64(metrics.Percentile(h.Memory, 100)))
+		u.CPUP95 = maxFloat64(u.CPUP95, metrics.Percentile(h.CPU, 95))
+		u.CPUMax = maxFloat64(u.CPUMax, metrics.Percentile(h.CPU, 100))
+	}
+
+	// the usage history is averaged, so look up the actual memory peaks
+	memStats, err := f.metricsClient.GetInstanceMemoryStats(since, instances...)
+	if err != nil {
+		logger.WithError(err).Debug("Could not look up the peak memory usage of the services")
+	} else {
+		for _, stat := range memStats {
+			if u, ok := usage[stat.ServiceID]; ok {
+				u.MemoryMax = maxInt64(u.MemoryMax, stat.Max)
+			}
+		}
+	}
+
+	for _, svc := range svcs {
+		if u, ok := usage[svc.ID]; ok {
+			result = append(result, service.NewResourceRecommendation(svc, *u))
+		}
+	}
+	return result, nil
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func maxFloat64(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
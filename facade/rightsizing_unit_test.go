@@ -0,0 +1,87 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package facade_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/metrics"
+	"github.com/control-center/serviced/utils"
+	. "gopkg.in/check.v1"
+)
+
+func (ft *FacadeUnitTest) Test_GetResourceRecommendations(c *C) {
+	const mib = 1024 * 1024
+	since := time.Now().Add(-7 * 24 * time.Hour)
+	tenant := service.Service{ID: "tenant", Name: "tenant", PoolID: "default"}
+	child := service.Service{
+		ID:              "svc1",
+		Name:            "svc",
+		ParentServiceID: "tenant",
+		PoolID:          "default",
+		Instances:       2,
+		RAMCommitment:   utils.NewEngNotation(1024 * mib),
+		CPUCommitment:   2,
+	}
+	idle := service.Service{ID: "svc2", Name: "idle", ParentServiceID: "tenant", PoolID: "default", Instances: 1}
+	ft.serviceStore.On("Get", ft.ctx, "tenant").Return(&tenant, nil)
+	ft.serviceStore.On("GetChildServices", ft.ctx, "tenant").Return([]service.Service{child, idle}, nil)
+	ft.serviceStore.On("Get", ft.ctx, "svc1").Return(&child, nil)
+	ft.serviceStore.On("Get", ft.ctx, "svc2").Return(&idle, nil)
+	ft.serviceStore.On("GetChildServices", ft.ctx, "svc1").Return([]service.Service{}, nil)
+	ft.serviceStore.On("GetChildServices", ft.ctx, "svc2").Return([]service.Service{}, nil)
+
+	instances := []metrics.ServiceInstance{
+		{ServiceID: "svc1", InstanceID: 0},
+		{ServiceID: "svc1", InstanceID: 1},
+		{ServiceID: "svc2", InstanceID: 0},
+	}
+	ft.metricsClient.On("GetInstanceUsageHistory", since, instances).Return([]metrics.InstanceUsageHistory{
+		{ServiceID: "svc1", InstanceID: "0", Memory: []float64{100 * mib, 200 * mib}, CPU: []float64{10, 20}},
+		{ServiceID: "svc1", InstanceID: "1", Memory: []float64{300 * mib}, CPU: []float64{50}},
+		{ServiceID: "svc2", InstanceID: "0"},
+	}, nil)
+	ft.metricsClient.On("GetInstanceMemoryStats", since, instances).Return([]metrics.MemoryUsageStats{
+		{ServiceID: "svc1", InstanceID: "1", Max: 400 * mib},
+	}, nil)
+
+	recs, err := ft.Facade.GetResourceRecommendations(ft.ctx, "tenant", since)
+	c.Assert(err, IsNil)
+	c.Assert(recs, HasLen, 1)
+	c.Assert(recs[0].ServiceID, Equals, "svc1")
+	c.Assert(recs[0].Usage, DeepEquals, service.ResourceUsage{
+		MemoryP95: 300 * mib,
+		MemoryMax: 400 * mib,
+		CPUP95:    50,
+		CPUMax:    50,
+	})
+	c.Assert(recs[0].RecommendedRAMCommitment, Equals, uint64(384*mib))
+	c.Assert(recs[0].RecommendedCPUCommitment, Equals, uint64(1))
+	c.Assert(recs[0].FreedRAM(), Equals, int64(2*(1024-384)*mib))
+}
+
+func (ft *FacadeUnitTest) Test_GetResourceRecommendationsMetricsError(c *C) {
+	since := time.Now().Add(-time.Hour)
+	svc := service.Service{ID: "tenant", PoolID: "default", Instances: 1}
+	ft.serviceStore.On("Get", ft.ctx, "tenant").Return(&svc, nil)
+	ft.serviceStore.On("GetChildServices", ft.ctx, "tenant").Return([]service.Service{}, nil)
+	ft.metricsClient.On("GetInstanceUsageHistory", since, []metrics.ServiceInstance{{ServiceID: "tenant"}}).Return(nil, errors.New("metrics down"))
+
+	_, err := ft.Facade.GetResourceRecommendations(ft.ctx, "tenant", since)
+	c.Assert(err, ErrorMatches, "metrics down")
+}
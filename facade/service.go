@@ -383,6 +383,13 @@ func (f *Facade) updateService(ctx datastore.Context, tenantID string, svc servi
 		return err
 	}
 	glog.Infof("Synced service %s (%s) to the coordinator", svc.Name, svc.ID)
+
+	// have the hosts pull a new image before the instances are rescheduled
+	if svc.ImageID != "" && (svc.ImageID != cursvc.ImageID || svc.PoolID != cursvc.PoolID) {
+		if err := f.prePullImage(ctx, svc.PoolID, svc.ImageID); err != nil {
+			glog.Warningf("Could not pre-pull image %s for service %s (%s): %s", svc.ImageID, svc.Name, svc.ID, err)
+		}
+	}
 	return nil
 }
 
@@ -2409,6 +2416,12 @@ func (f *Facade) ServiceUse(ctx datastore.Context, serviceID, imageName, registr
 			}
 		}
 	}
+
+	// the hosts need the new image, even if the services still refer to the
+	// same tag
+	if err := f.PrePullImages(ctx, serviceID); err != nil {
+		glog.Warningf("Could not pre-pull images for tenant %s: %s", serviceID, err)
+	}
 	return nil
 }
 
@@ -2938,6 +2951,8 @@ func (f *Facade) getServicePublicEndpoints(svc service.Service) []service.Public
 
 			if strings.HasPrefix(port.Protocol, "http") {
 				pub.Protocol = port.Protocol
+			} else if port.Protocol == "udp" {
+				pub.Protocol = "UDP"
 			} else if port.UseTLS {
 				pub.Protocol = "Other, secure (TLS)"
 			} else {
@@ -91,6 +91,7 @@ func (sc *serviceRegistryCache) BuildSyncRequest(tenantID string, svc *service.S
 					ServiceID:   svc.ID,
 					Protocol:    p.Protocol,
 					UseTLS:      p.UseTLS,
+					Access:      p.AccessRules,
 				}
 				request.PortsToPublish[key] = pub
 			}
@@ -107,6 +108,8 @@ func (sc *serviceRegistryCache) BuildSyncRequest(tenantID string, svc *service.S
 					TenantID:    tenantID,
 					Application: ep.Application,
 					ServiceID:   svc.ID,
+					Rules:       v.VHostRules,
+					Access:      v.AccessRules,
 				}
 				request.VHostsToPublish[key] = vh
 			}
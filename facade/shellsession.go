@@ -0,0 +1,121 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package facade
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/audit"
+	"github.com/control-center/serviced/config"
+	"github.com/control-center/serviced/datastore"
+	"github.com/control-center/serviced/domain/auditlog"
+	"github.com/control-center/serviced/domain/service"
+)
+
+// RecordShellSession writes the start or end of a shell, attach or run
+// session to the audit log.  If the session has ended and a recording is
+// passed, the recording is kept on the master for replay.
+func (f *Facade) RecordShellSession(ctx datastore.Context, session auditlog.Session, recording *auditlog.Recording) error {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.RecordShellSession"))
+	if err := auditlog.ValidSessionID(session.ID); err != nil {
+		return err
+	}
+
+	var action string
+	switch session.Kind {
+	case auditlog.SessionShell:
+		action = audit.Shell
+	case auditlog.SessionAttach:
+		action = audit.Attach
+	case auditlog.SessionRun:
+		action = audit.Run
+	default:
+		return fmt.Errorf("invalid session kind %q", session.Kind)
+	}
+
+	fields := logrus.Fields{
+		"session":  session.ID,
+		"host":     session.HostID,
+		"instance": strconv.Itoa(session.InstanceID),
+		"command":  session.Command,
+		"start":    session.Start.UTC().Format(time.RFC3339),
+	}
+	message := fmt.Sprintf("Started %s session", session.Kind)
+	ended := !session.End.IsZero()
+	if ended {
+		message = fmt.Sprintf("Ended %s session", session.Kind)
+		fields["end"] = session.End.UTC().Format(time.RFC3339)
+		fields["exitcode"] = strconv.Itoa(session.ExitCode)
+		fields["snapshot"] = session.Snapshot
+		fields["recorded"] = strconv.FormatBool(session.Recorded && recording != nil)
+	}
+
+	// the local user that ran the command is reported by the client, so it
+	// is kept apart from the user that authenticated with the master
+	if session.User != "" {
+		fields["localuser"] = session.User
+	}
+	alog := f.auditLogger.Message(ctx, message).Action(action).
+		Type(service.GetType()).ID(session.ServiceID).WithFields(fields)
+
+	if ended && recording != nil {
+		if err := saveSessionRecording(session.ID, recording); err != nil {
+			plog.WithError(err).WithField("session", session.ID).Warn("Could not save session recording")
+			alog = alog.WithField("recorded", "false")
+		}
+	}
+	alog.SucceededIf(!ended || session.ExitCode == 0)
+	return nil
+}
+
+// GetShellSessionRecording returns the recording of a session
+func (f *Facade) GetShellSessionRecording(ctx datastore.Context, sessionID string) (*auditlog.Recording, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.GetShellSessionRecording"))
+	if err := auditlog.ValidSessionID(sessionID); err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(sessionRecordingPath(sessionID))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no recording of session %s", sessionID)
+	} else if err != nil {
+		return nil, err
+	}
+	recording := &auditlog.Recording{}
+	if err := json.Unmarshal(data, recording); err != nil {
+		return nil, err
+	}
+	return recording, nil
+}
+
+func sessionRecordingPath(sessionID string) string {
+	return filepath.Join(config.GetOptions().SessionsPath, sessionID+".json")
+}
+
+func saveSessionRecording(sessionID string, recording *auditlog.Recording) error {
+	data, err := json.Marshal(recording)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.GetOptions().SessionsPath, 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(sessionRecordingPath(sessionID), data, 0600)
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package facade_test
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/control-center/serviced/audit"
+	"github.com/control-center/serviced/config"
+	"github.com/control-center/serviced/domain/auditlog"
+	"github.com/stretchr/testify/mock"
+	. "gopkg.in/check.v1"
+)
+
+func (ft *FacadeUnitTest) Test_RecordShellSessionInvalid(c *C) {
+	session := auditlog.Session{ID: "../secret", Kind: auditlog.SessionShell}
+	c.Assert(ft.Facade.RecordShellSession(ft.ctx, session, nil), NotNil)
+
+	session = auditlog.Session{ID: "abc123", Kind: "telnet"}
+	c.Assert(ft.Facade.RecordShellSession(ft.ctx, session, nil), NotNil)
+
+	_, err := ft.Facade.GetShellSessionRecording(ft.ctx, "../secret")
+	c.Assert(err, NotNil)
+}
+
+func (ft *FacadeUnitTest) Test_RecordShellSessionRecording(c *C) {
+	dir, err := ioutil.TempDir("", "serviced-sessions-")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	opts := config.GetOptions()
+	defer config.LoadOptions(opts)
+	newOpts := opts
+	newOpts.SessionsPath = dir
+	config.LoadOptions(newOpts)
+
+	session := auditlog.Session{
+		ID:        "abc123",
+		Kind:      auditlog.SessionAttach,
+		User:      "admin",
+		ServiceID: "svc1",
+		Command:   "/bin/bash",
+		Start:     time.Now(),
+	}
+	_, err = ft.Facade.GetShellSessionRecording(ft.ctx, session.ID)
+	c.Assert(err, ErrorMatches, "no recording of session abc123")
+
+	c.Assert(ft.Facade.RecordShellSession(ft.ctx, session, nil), IsNil)
+
+	recording := &auditlog.Recording{Version: 1, Command: "/bin/bash", Stdout: []auditlog.Frame{{Delay: 0.1, Data: "hello"}}}
+	session.End = time.Now()
+	session.Recorded = true
+	c.Assert(ft.Facade.RecordShellSession(ft.ctx, session, recording), IsNil)
+
+	actual, err := ft.Facade.GetShellSessionRecording(ft.ctx, session.ID)
+	c.Assert(err, IsNil)
+	c.Assert(actual, DeepEquals, recording)
+}
+
+func (ft *FacadeUnitTest) Test_RecordShellSessionUser(c *C) {
+	ft.Facade.SetAuditLogger(audit.NewLogger(ft.auditStore))
+	ft.ctx.On("User").Return("admin")
+	last := auditChain(1)[0]
+	ft.auditStore.On("Last", ft.ctx).Return(&last, nil)
+	var appended *auditlog.Entry
+	ft.auditStore.On("Put", ft.ctx, mock.AnythingOfType("*auditlog.Entry")).Return(nil).Run(func(a mock.Arguments) {
+		appended = a.Get(1).(*auditlog.Entry)
+	})
+
+	// the local user is reported by the client, so it does not replace the
+	// user that authenticated with the master
+	session := auditlog.Session{
+		ID:        "abc123",
+		Kind:      auditlog.SessionShell,
+		User:      "mallory",
+		ServiceID: "svc1",
+		Command:   "/bin/bash",
+		Start:     time.Now(),
+	}
+	c.Assert(ft.Facade.RecordShellSession(ft.ctx, session, nil), IsNil)
+	c.Assert(appended, NotNil)
+	c.Check(appended.User, Equals, "admin")
+	c.Check(appended.Action, Equals, audit.Shell)
+	c.Check(appended.Fields["localuser"], Equals, "mallory")
+}
@@ -0,0 +1,179 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package facade
+
+import (
+	"errors"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/datastore"
+	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/domain/servicedefinition"
+	"github.com/control-center/serviced/health"
+	"github.com/control-center/serviced/slo"
+	"github.com/control-center/serviced/stats"
+)
+
+// ErrNoSLOTracker is returned when service level objectives are requested
+// from a facade that does not track them.
+var ErrNoSLOTracker = errors.New("facade: service level objectives are not tracked")
+
+// MeasureSLOs measures the service level objectives of every service that is
+// supposed to be running, and reports each objective of every service against
+// its target.
+func (f *Facade) MeasureSLOs(ctx datastore.Context, now time.Time) ([]slo.Status, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.MeasureSLOs"))
+	if f.sloTracker == nil {
+		return nil, ErrNoSLOTracker
+	}
+	shs, err := f.serviceStore.GetAllServiceHealth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defined := make(map[string]servicedefinition.SLOs)
+	result := []slo.Status{}
+	for _, sh := range shs {
+		if len(sh.SLOs) == 0 {
+			continue
+		}
+		defined[sh.ID] = sh.SLOs
+		if sh.DesiredState == int(service.SVCRun) && sh.Instances > 0 {
+			f.measureSLOs(ctx, sh, now)
+		}
+		for _, obj := range sh.SLOs {
+			result = append(result, f.sloTracker.Status(sh.ID, obj, now))
+		}
+	}
+	f.sloTracker.Retain(defined, now)
+	if err := f.sloTracker.Save(); err != nil {
+		plog.WithError(err).Warn("Could not save the SLO history")
+	}
+	return result, nil
+}
+
+// measureSLOs records the last minute of each service level objective of a
+// running service as good or bad.  An objective is not recorded for a minute
+// that could not be measured.
+func (f *Facade) measureSLOs(ctx datastore.Context, sh service.ServiceHealth, now time.Time) {
+	logger := plog.WithFields(log.Fields{
+		"serviceid":   sh.ID,
+		"servicename": sh.Name,
+	})
+	var available, measured bool
+	var names []string
+	for _, obj := range sh.SLOs {
+		var good bool
+		switch obj.Type {
+		case servicedefinition.SLOAvailability:
+			if !measured {
+				var err error
+				if available, err = f.isServiceAvailable(ctx, sh); err != nil {
+					logger.WithError(err).Debug("Could not measure the availability of the service")
+					continue
+				}
+				measured = true
+			}
+			good = available
+		case servicedefinition.SLOLatency:
+			if f.metricsClient == nil {
+				continue
+			}
+			if names == nil {
+				svc, err := f.serviceStore.Get(ctx, sh.ID)
+				if err != nil {
+					logger.WithError(err).Debug("Could not look up the public endpoints of the service")
+					continue
+				}
+				names = publicEndpointTags(svc)
+			}
+			latency, ok, err := f.metricsClient.GetPublicEndpointLatency(slo.Interval, obj.GetPercentile(), names...)
+			if err != nil {
+				logger.WithError(err).Debug("Could not measure the latency of the service")
+				continue
+			} else if !ok {
+				// no requests were served, so the minute does not count
+				continue
+			}
+			good = latency <= obj.LatencyMS
+		default:
+			continue
+		}
+		f.sloTracker.Record(sh.ID, obj.Name, now, good)
+	}
+}
+
+// isServiceAvailable returns true if every instance of the service is running
+// and passing all of its health checks.
+func (f *Facade) isServiceAvailable(ctx datastore.Context, sh service.ServiceHealth) (bool, error) {
+	if len(sh.HealthChecks) == 0 {
+		states, err := f.zzk.GetServiceStates(ctx, sh.PoolID, sh.ID)
+		if err != nil {
+			return false, err
+		}
+		running := 0
+		for _, state := range states {
+			if state.Started.After(state.Terminated) {
+				running++
+			}
+		}
+		return running >= sh.Instances, nil
+	}
+	instances, err := f.getServiceHealth(ctx, sh)
+	if err != nil {
+		return false, err
+	}
+	for _, checks := range instances {
+		for _, status := range checks {
+			if status.Status != health.OK {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// publicEndpointTags returns the metric tags of the vhosts and public ports of
+// a service.
+func publicEndpointTags(svc *service.Service) []string {
+	names := []string{}
+	for _, ep := range svc.Endpoints {
+		for _, vhost := range ep.VHostList {
+			names = append(names, stats.TagValue(vhost.Name))
+		}
+		for _, port := range ep.PortList {
+			names = append(names, stats.TagValue(port.PortAddr))
+		}
+	}
+	return names
+}
+
+// GetServiceSLOs reports each service level objective of a service against
+// its target.
+func (f *Facade) GetServiceSLOs(ctx datastore.Context, serviceID string) ([]slo.Status, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.GetServiceSLOs"))
+	if f.sloTracker == nil {
+		return nil, ErrNoSLOTracker
+	}
+	sh, err := f.serviceStore.GetServiceHealth(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	result := make([]slo.Status, len(sh.SLOs))
+	for i, obj := range sh.SLOs {
+		result[i] = f.sloTracker.Status(serviceID, obj, now)
+	}
+	return result, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package facade_test
+
+import (
+	"time"
+
+	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/domain/servicedefinition"
+	"github.com/control-center/serviced/facade"
+	"github.com/control-center/serviced/slo"
+	zkservice "github.com/control-center/serviced/zzk/service"
+	. "gopkg.in/check.v1"
+)
+
+func (ft *FacadeUnitTest) Test_GetServiceSLOsNotTracked(c *C) {
+	ft.Facade.SetSLOTracker(nil)
+	_, err := ft.Facade.GetServiceSLOs(ft.ctx, "svc1")
+	c.Assert(err, Equals, facade.ErrNoSLOTracker)
+}
+
+func (ft *FacadeUnitTest) Test_MeasureSLOs(c *C) {
+	ft.Facade.SetSLOTracker(slo.NewTracker(""))
+	defer ft.Facade.SetSLOTracker(nil)
+
+	slos := servicedefinition.SLOs{
+		{Name: "up", Type: servicedefinition.SLOAvailability, Target: 99},
+		{Name: "fast", Type: servicedefinition.SLOLatency, Target: 99, LatencyMS: 250},
+	}
+	shs := []service.ServiceHealth{
+		{ID: "svc1", PoolID: "default", Instances: 2, DesiredState: int(service.SVCRun), SLOs: slos},
+		{ID: "svc2", PoolID: "default", Instances: 1, DesiredState: int(service.SVCStop), SLOs: slos[:1]},
+		{ID: "svc3", PoolID: "default", Instances: 1, DesiredState: int(service.SVCRun)},
+	}
+	ft.serviceStore.On("GetAllServiceHealth", ft.ctx).Return(shs, nil)
+	started := time.Now().Add(-time.Hour)
+	ft.zzk.On("GetServiceStates", ft.ctx, "default", "svc1").Return([]zkservice.State{
+		{InstanceID: 0, ServiceState: zkservice.ServiceState{Started: started}},
+		{InstanceID: 1, ServiceState: zkservice.ServiceState{Started: started}},
+	}, nil)
+	ft.serviceStore.On("Get", ft.ctx, "svc1").Return(&service.Service{
+		ID: "svc1",
+		Endpoints: []service.ServiceEndpoint{
+			{VHostList: []servicedefinition.VHost{{Name: "app"}}},
+		},
+	}, nil)
+	ft.metricsClient.On("GetPublicEndpointLatency", slo.Interval, "p95", []string{"app"}).Return(300.0, true, nil)
+
+	statuses, err := ft.Facade.MeasureSLOs(ft.ctx, time.Now())
+	c.Assert(err, IsNil)
+	c.Assert(statuses, HasLen, 3)
+	c.Assert(statuses[0].Name, Equals, "up")
+	c.Assert(statuses[0].Good, Equals, 1)
+	c.Assert(statuses[0].Total, Equals, 1)
+	c.Assert(statuses[1].Name, Equals, "fast")
+	c.Assert(statuses[1].Good, Equals, 0)
+	c.Assert(statuses[1].Total, Equals, 1)
+	c.Assert(statuses[2].ServiceID, Equals, "svc2")
+	c.Assert(statuses[2].Total, Equals, 0)
+	ft.zzk.AssertNotCalled(c, "GetServiceStates", ft.ctx, "default", "svc2")
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package facade
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/control-center/serviced/datastore"
+	"github.com/control-center/serviced/dfs"
+	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/domain/servicedefinition"
+)
+
+// ErrSnapshotHookFailed is returned when a snapshot hook fails and its policy
+// is to abort the snapshot.
+var ErrSnapshotHookFailed = errors.New("snapshot hook failed")
+
+// hookResultsByStart sorts hook results in the order they were run
+type hookResultsByStart []servicedefinition.SnapshotHookResult
+
+func (r hookResultsByStart) Len() int           { return len(r) }
+func (r hookResultsByStart) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r hookResultsByStart) Less(i, j int) bool { return r[i].Started.Before(r[j].Started) }
+
+// hasSnapshotHooks returns true if any of the services define a hook for one
+// of the given phases.
+func hasSnapshotHooks(svcs []*service.Service, phases ...servicedefinition.SnapshotHookPhase) bool {
+	for _, svc := range svcs {
+		for _, hook := range svc.Snapshot.Hooks {
+			for _, phase := range phases {
+				if hook.Phase == phase {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// getSnapshotHookResults returns the results of the hooks of the given phases
+// that were recorded on the instances of each service, in the order they ran.
+func (f *Facade) getSnapshotHookResults(ctx datastore.Context, svcs []*service.Service, phases ...servicedefinition.SnapshotHookPhase) []servicedefinition.SnapshotHookResult {
+	results := []servicedefinition.SnapshotHookResult{}
+	for _, svc := range svcs {
+		if len(svc.Snapshot.Hooks) == 0 {
+			continue
+		}
+		states, err := f.zzk.GetServiceStates(ctx, svc.PoolID, svc.ID)
+		if err != nil {
+			plog.WithField("serviceid", svc.ID).WithError(err).Warn("Could not look up snapshot hook results for service")
+			continue
+		}
+		for _, state := range states {
+			for _, result := range state.SnapshotHooks {
+				for _, phase := range phases {
+					if result.Phase == phase {
+						results = append(results, result)
+						break
+					}
+				}
+			}
+		}
+	}
+	sort.Stable(hookResultsByStart(results))
+	return results
+}
+
+// recordSnapshotHooks saves the hook results and freeze window of a snapshot.
+// If any service defines post-thaw hooks, this waits for the services to
+// resume so that those results can be recorded as well.
+func (f *Facade) recordSnapshotHooks(ctx datastore.Context, snapshotID string, svcs []*service.Service, serviceIDs []string, record dfs.SnapshotHookRecord) {
+	logger := plog.WithField("snapshotid", snapshotID)
+	if hasSnapshotHooks(svcs, servicedefinition.HookPostThaw) {
+		if err := f.WaitService(ctx, service.SVCRun, f.dfs.Timeout(), false, serviceIDs...); err != nil {
+			logger.WithError(err).Warn("Could not wait for services to resume; post-thaw hook results may be incomplete")
+		}
+		record.Results = append(record.Results, f.getSnapshotHookResults(ctx, svcs, servicedefinition.HookPostThaw)...)
+	}
+	if err := f.dfs.SaveSnapshotHooks(snapshotID, record); err != nil {
+		logger.WithError(err).Warn("Could not record snapshot hook results")
+		return
+	}
+	logger.WithField("freezewindow", record.FreezeWindow()).Info("Recorded snapshot hook results")
+}
+
+// checkSnapshotHookResults returns an error describing the hooks that
+// cancelled the snapshot, if any.
+func checkSnapshotHookResults(results []servicedefinition.SnapshotHookResult) error {
+	failed := []string{}
+	for _, result := range results {
+		if result.Aborted {
+			failed = append(failed, fmt.Sprintf("%s (%s) on %s/%d: %s", result.Name, result.Phase, result.ServiceID, result.InstanceID, result.Error))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s: %s", ErrSnapshotHookFailed, strings.Join(failed, "; "))
+	}
+	return nil
+}
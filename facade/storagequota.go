@@ -0,0 +1,124 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package facade
+
+import (
+	"errors"
+	"strconv"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/audit"
+	"github.com/control-center/serviced/datastore"
+	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/volume"
+)
+
+// ErrNotATenant is returned when a tenant-only operation is attempted on a
+// child service.
+var ErrNotATenant = errors.New("facade: service is not a tenant")
+
+// SetStorageQuota sets the storage quota for a tenant.  If a hard limit is
+// set and the storage driver supports it, the limit is also enforced on the
+// tenant volume.  Otherwise, or if the volume is already larger than the
+// limit, it is held by the storage monitor.
+func (f *Facade) SetStorageQuota(ctx datastore.Context, tenantID string, quota volume.StorageQuota) error {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.SetStorageQuota"))
+	alog := f.auditLogger.Message(ctx, "Setting Storage Quota").Action(audit.Update).
+		ID(tenantID).Type(service.GetType()).WithFields(log.Fields{
+		"softlimit": strconv.FormatUint(quota.SoftLimit, 10),
+		"hardlimit": strconv.FormatUint(quota.HardLimit, 10),
+	})
+	if err := quota.Validate(); err != nil {
+		return alog.Error(err)
+	}
+
+	mutex := getTenantLock(tenantID)
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	svc, err := f.GetService(ctx, tenantID)
+	if err != nil {
+		return alog.Error(err)
+	}
+	if svc.ParentServiceID != "" {
+		return alog.Error(ErrNotATenant)
+	}
+	svc.StorageQuota = quota
+	if err := f.updateService(ctx, tenantID, *svc, false, false); err != nil {
+		return alog.Error(err)
+	}
+
+	logger := plog.WithFields(log.Fields{
+		"tenantid":  tenantID,
+		"softlimit": quota.SoftLimit,
+		"hardlimit": quota.HardLimit,
+	})
+	if quota.HardLimit > 0 {
+		if err := f.dfs.EnforceQuota(tenantID, quota.HardLimit); err == volume.ErrQuotaUnsupported {
+			logger.Info("Storage driver cannot enforce quota; usage will be checked periodically")
+		} else if err == volume.ErrNoShrinkage {
+			logger.Info("Tenant volume is larger than the quota and cannot be shrunk; usage will be checked periodically")
+		} else if err != nil {
+			logger.WithError(err).Warn("Could not enforce quota on the tenant volume; usage will be checked periodically")
+		}
+	}
+	logger.Info("Updated storage quota")
+	alog.Succeeded()
+	return nil
+}
+
+// GetStorageQuotas returns the storage quota for each tenant that has one.
+func (f *Facade) GetStorageQuotas(ctx datastore.Context) (map[string]volume.StorageQuota, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.GetStorageQuotas"))
+	tenantIDs, err := f.ListTenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	quotas := make(map[string]volume.StorageQuota)
+	for _, tenantID := range tenantIDs {
+		svc, err := f.serviceStore.Get(ctx, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		if svc.StorageQuota.IsSet() {
+			quotas[tenantID] = svc.StorageQuota
+		}
+	}
+	return quotas, nil
+}
+
+// GetStorageQuotaStatus measures the storage used by each tenant that has a
+// quota and reports it against the quota.
+func (f *Facade) GetStorageQuotaStatus(ctx datastore.Context) ([]volume.TenantQuotaStatus, error) {
+	defer ctx.Metrics().Stop(ctx.Metrics().Start("Facade.GetStorageQuotaStatus"))
+	quotas, err := f.GetStorageQuotas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := []volume.TenantQuotaStatus{}
+	for tenantID, quota := range quotas {
+		used, err := f.dfs.Usage(tenantID)
+		if err != nil {
+			plog.WithField("tenantid", tenantID).WithError(err).Warn("Could not measure storage used by tenant")
+			continue
+		}
+		result = append(result, volume.TenantQuotaStatus{
+			TenantID: tenantID,
+			Quota:    quota,
+			Used:     used,
+			State:    quota.Check(used),
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package facade_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/facade"
+	"github.com/control-center/serviced/volume"
+	. "gopkg.in/check.v1"
+)
+
+func (ft *FacadeUnitTest) Test_SetStorageQuotaInvalid(c *C) {
+	err := ft.Facade.SetStorageQuota(ft.ctx, "tenant", volume.StorageQuota{SoftLimit: 20, HardLimit: 10})
+	c.Assert(err, Equals, volume.ErrInvalidQuota)
+}
+
+func (ft *FacadeUnitTest) Test_SetStorageQuotaNotTenant(c *C) {
+	svc := service.Service{ID: "child", ParentServiceID: "tenant"}
+	ft.serviceStore.On("Get", ft.ctx, "child").Return(&svc, nil)
+	ft.serviceStore.On("GetServiceDetails", ft.ctx, "child").Return(&service.ServiceDetails{ID: "child", ParentServiceID: "tenant"}, nil)
+	ft.serviceStore.On("GetServiceDetails", ft.ctx, "tenant").Return(&service.ServiceDetails{ID: "tenant"}, nil)
+	ft.configStore.On("GetConfigFiles", ft.ctx, "tenant", "/tenant/child").Return(nil, nil)
+
+	err := ft.Facade.SetStorageQuota(ft.ctx, "child", volume.StorageQuota{HardLimit: 10})
+	c.Assert(err, Equals, facade.ErrNotATenant)
+}
+
+func (ft *FacadeUnitTest) Test_GetStorageQuotaStatus(c *C) {
+	tenants := []service.ServiceDetails{{ID: "tenant1"}, {ID: "tenant2"}, {ID: "tenant3"}}
+	ft.serviceStore.On("GetServiceDetailsByParentID", ft.ctx, "", time.Duration(0)).Return(tenants, nil)
+	ft.serviceStore.On("Get", ft.ctx, "tenant1").Return(&service.Service{
+		ID:           "tenant1",
+		StorageQuota: volume.StorageQuota{SoftLimit: 50, HardLimit: 100},
+	}, nil)
+	ft.serviceStore.On("Get", ft.ctx, "tenant2").Return(&service.Service{ID: "tenant2"}, nil)
+	ft.serviceStore.On("Get", ft.ctx, "tenant3").Return(&service.Service{
+		ID:           "tenant3",
+		StorageQuota: volume.StorageQuota{HardLimit: 100},
+	}, nil)
+	ft.dfs.On("Usage", "tenant1").Return(uint64(120), nil)
+	ft.dfs.On("Usage", "tenant3").Return(uint64(0), errors.New("no volume"))
+
+	statuses, err := ft.Facade.GetStorageQuotaStatus(ft.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(statuses, DeepEquals, []volume.TenantQuotaStatus{
+		{
+			TenantID: "tenant1",
+			Quota:    volume.StorageQuota{SoftLimit: 50, HardLimit: 100},
+			Used:     120,
+			State:    volume.QuotaStateExceeded,
+		},
+	})
+	ft.dfs.AssertNotCalled(c, "Usage", "tenant2")
+}
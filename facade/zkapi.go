@@ -489,6 +489,48 @@ func (z *zkf) DeleteRegistryLibrary(tenantID string) error {
 	return zkimgregistry.DeleteRegistryImage(conn, tenantID)
 }
 
+func (z *zkf) GetRegistryReplicationStatus() ([]zkimgregistry.ReplicaStatus, error) {
+	conn, err := zzk.GetLocalConnection("/")
+	if err != nil {
+		return nil, err
+	}
+	return zkimgregistry.GetReplicationStatus(conn)
+}
+
+func (z *zkf) ResetRegistryReplication() error {
+	conn, err := zzk.GetLocalConnection("/")
+	if err != nil {
+		return err
+	}
+	return zkimgregistry.ResetReplication(conn)
+}
+
+// RequestPrePull asks a host in a pool to pull an image before it is needed
+func (z *zkf) RequestPrePull(poolID, hostID, image, uuid string) error {
+	conn, err := zzk.GetLocalConnection(zzk.GeneratePoolPath(poolID))
+	if err != nil {
+		return err
+	}
+	return zkd.RequestPrePull(conn, hostID, image, uuid)
+}
+
+// GetPrePulls returns the image pre-pull requests of the hosts in a pool
+func (z *zkf) GetPrePulls(poolID string) ([]zkd.PrePull, error) {
+	conn, err := zzk.GetLocalConnection(zzk.GeneratePoolPath(poolID))
+	if err != nil {
+		return nil, err
+	}
+	return zkd.GetPrePulls(conn)
+}
+
+func (z *zkf) NotifyCertificates() error {
+	conn, err := zzk.GetLocalConnection("/")
+	if err != nil {
+		return err
+	}
+	return zkr.NotifyCertificates(conn)
+}
+
 func (z *zkf) LockServices(ctx datastore.Context, svcs []service.ServiceDetails) error {
 	defer ctx.Metrics().Stop(ctx.Metrics().Start("zzk.LockServices"))
 	conn, err := zzk.GetLocalConnection("/")
@@ -15,10 +15,12 @@ package facade
 
 import (
 	"github.com/control-center/serviced/datastore"
+	zkimgregistry "github.com/control-center/serviced/dfs/registry"
 	"github.com/control-center/serviced/domain/host"
 	"github.com/control-center/serviced/domain/pool"
 	"github.com/control-center/serviced/domain/registry"
 	"github.com/control-center/serviced/domain/service"
+	zkdocker "github.com/control-center/serviced/zzk/docker"
 	zkservice "github.com/control-center/serviced/zzk/service"
 )
 
@@ -44,6 +46,11 @@ type ZZK interface {
 	SetRegistryImage(rImage *registry.Image) error
 	DeleteRegistryImage(id string) error
 	DeleteRegistryLibrary(tenantID string) error
+	GetRegistryReplicationStatus() ([]zkimgregistry.ReplicaStatus, error)
+	ResetRegistryReplication() error
+	RequestPrePull(poolID, hostID, image, uuid string) error
+	GetPrePulls(poolID string) ([]zkdocker.PrePull, error)
+	NotifyCertificates() error
 	LockServices(ctx datastore.Context, svcs []service.ServiceDetails) error
 	UnlockServices(ctx datastore.Context, svcs []service.ServiceDetails) error
 	GetServiceStates(ctx datastore.Context, poolID, serviceID string) ([]zkservice.State, error)
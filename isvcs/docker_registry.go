@@ -50,7 +50,9 @@ func initDockerRegistry() {
 		HostIpOverride: "", // docker registry should always be open
 		HostPort:       registryPort,
 	}
-	command := `SETTINGS_FLAVOR=serviced exec /opt/registry/registry /opt/registry/registry-config.yml`
+	// deletes must be enabled so that unreferenced manifests can be removed
+	// (see CollectRegistryGarbage)
+	command := `SETTINGS_FLAVOR=serviced REGISTRY_STORAGE_DELETE_ENABLED=true exec /opt/registry/registry /opt/registry/registry-config.yml`
 
 	dockerRegistry, err = NewIService(
 		IServiceDefinition{
@@ -113,6 +115,19 @@ func checkDockerRegistryPath(svc *IService) error {
 	return nil
 }
 
+// CollectRegistryGarbage removes the blobs in the docker registry's storage
+// that are no longer referenced by any manifest.  The registry must not accept
+// pushes while this is running, so callers should hold the dfs lock.
+func CollectRegistryGarbage() error {
+	output, err := dockerRegistry.Exec([]string{"/opt/registry/registry", "garbage-collect", "/opt/registry/registry-config.yml"})
+	if err != nil {
+		log.WithError(err).WithField("output", string(output)).Warn("Unable to collect garbage in the Docker registry")
+		return err
+	}
+	log.Info("Collected garbage in the Docker registry")
+	return nil
+}
+
 func registryHealthCheck(halt <-chan struct{}) error {
 	url := fmt.Sprintf("http://localhost:%d/", registryPort)
 	log := log.WithFields(logrus.Fields{
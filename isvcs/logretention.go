@@ -0,0 +1,235 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package isvcs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/domain/logfilter"
+)
+
+const logstashIndexPrefix = "logstash-"
+
+// ErrLogstashNotConfigured is returned when the logstash elasticsearch
+// service has not been initialized on this host.
+var ErrLogstashNotConfigured = errors.New("elasticsearch-logstash is not configured on this host")
+
+// LogRetentionResult describes the log messages that were (or would be)
+// removed because they outlived their retention.  A result with an empty
+// rule type describes the messages that are kept for the global default.
+type LogRetentionResult struct {
+	Rule  logfilter.RetentionRule
+	Days  int
+	Query string
+	Count int64
+}
+
+// LogRetentionReport describes the work done to enforce a retention policy
+type LogRetentionReport struct {
+	DryRun    bool
+	IndexDays int      // age in days after which whole indices are removed
+	Indices   []string // indices that are older than IndexDays
+	Results   []LogRetentionResult
+}
+
+// EnforceLogRetention removes the log messages in logstash that are older
+// than the retention of their tenant and type, then removes the indices that
+// are older than the longest retention or that push logstash beyond its
+// maximum size.  If dryRun is set, the report describes what would be
+// removed without removing anything.
+func EnforceLogRetention(policy logfilter.RetentionPolicy, dryRun bool) (*LogRetentionReport, error) {
+	if elasticsearch_logstash == nil {
+		return nil, ErrLogstashNotConfigured
+	}
+	binding := elasticsearch_logstash.PortBindings[0]
+	address := fmt.Sprintf("http://%s:%d", getHostIp(binding), binding.HostPort)
+	report, err := enforceLogRetention(address, policy, time.Now(), dryRun)
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		PurgeLogstashIndices(report.IndexDays, policy.MaxSize)
+	}
+	return report, nil
+}
+
+func enforceLogRetention(address string, policy logfilter.RetentionPolicy, now time.Time, dryRun bool) (*LogRetentionReport, error) {
+	log := log.WithFields(logrus.Fields{
+		"maxagedays": policy.MaxDays,
+		"rules":      len(policy.Rules),
+		"dryrun":     dryRun,
+	})
+
+	indices, err := getLogstashIndices(address)
+	if err != nil {
+		log.WithError(err).Warn("Unable to list logstash indices")
+		return nil, err
+	}
+
+	report := &LogRetentionReport{
+		DryRun:    dryRun,
+		IndexDays: policy.IndexDays(),
+		Indices:   expiredIndices(indices, now.AddDate(0, 0, -policy.IndexDays())),
+		Results:   []LogRetentionResult{},
+	}
+
+	// each rule that expires before the indices do needs its own cleanup
+	ruleQueries := []string{}
+	for _, rule := range policy.Rules {
+		ruleQueries = append(ruleQueries, fmt.Sprintf("(%s)", rule.Query()))
+		if rule.Days >= report.IndexDays {
+			continue
+		}
+		result, err := expireLogMessages(address, indices, rule.Query(), rule.Days, now, dryRun)
+		if err != nil {
+			log.WithError(err).WithFields(logrus.Fields{
+				"tenantid": rule.TenantID,
+				"type":     rule.Type,
+			}).Warn("Unable to enforce log retention rule")
+			return nil, err
+		}
+		result.Rule = rule
+		report.Results = append(report.Results, *result)
+	}
+
+	// messages without a rule are kept for the default, which is shorter
+	// than the life of the indices if any rule keeps messages for longer
+	if policy.MaxDays < report.IndexDays {
+		query := fmt.Sprintf("NOT (%s)", strings.Join(ruleQueries, " OR "))
+		result, err := expireLogMessages(address, indices, query, policy.MaxDays, now, dryRun)
+		if err != nil {
+			log.WithError(err).Warn("Unable to enforce default log retention")
+			return nil, err
+		}
+		report.Results = append(report.Results, *result)
+	}
+
+	log.Info("Enforced log retention policy")
+	return report, nil
+}
+
+// expireLogMessages removes the messages matching the query that are older
+// than the given number of days.
+func expireLogMessages(address string, indices map[string]time.Time, query string, days int, now time.Time, dryRun bool) (*LogRetentionResult, error) {
+	cutoff := now.AddDate(0, 0, -days)
+	query = fmt.Sprintf("%s AND @timestamp:[* TO %d}", query, cutoff.UnixNano()/int64(time.Millisecond))
+	result := &LogRetentionResult{Days: days, Query: query}
+
+	// only the indices started before the cutoff can hold expired messages
+	names := []string{}
+	for name, date := range indices {
+		if !date.After(cutoff) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return result, nil
+	}
+	sort.Strings(names)
+	path := strings.Join(names, ",")
+
+	body := map[string]interface{}{
+		"query": map[string]interface{}{
+			"query_string": map[string]interface{}{"query": query},
+		},
+	}
+	var count struct {
+		Count int64 `json:"count"`
+	}
+	if err := doElasticRequest("POST", fmt.Sprintf("%s/%s/_count", address, path), body, &count); err != nil {
+		return nil, err
+	}
+	result.Count = count.Count
+	if dryRun || count.Count == 0 {
+		return result, nil
+	}
+	// delete by query is part of the core api as of elasticsearch 5
+	if err := doElasticRequest("POST", fmt.Sprintf("%s/%s/_delete_by_query", address, path), body, nil); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getLogstashIndices returns the logstash indices and the day each started
+func getLogstashIndices(address string) (map[string]time.Time, error) {
+	aliases := make(map[string]interface{})
+	if err := doElasticRequest("GET", address+"/_aliases", nil, &aliases); err != nil {
+		return nil, err
+	}
+	indices := make(map[string]time.Time)
+	for name := range aliases {
+		if !strings.HasPrefix(name, logstashIndexPrefix) {
+			continue
+		}
+		date, err := time.Parse("2006.01.02", strings.TrimPrefix(name, logstashIndexPrefix))
+		if err != nil {
+			log.WithField("index", name).Debug("Skipping logstash index without a date")
+			continue
+		}
+		indices[name] = date
+	}
+	return indices, nil
+}
+
+// expiredIndices returns the sorted names of the indices whose whole day is
+// before the cutoff.
+func expiredIndices(indices map[string]time.Time, cutoff time.Time) []string {
+	names := []string{}
+	for name, date := range indices {
+		if !date.AddDate(0, 0, 1).After(cutoff) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// doElasticRequest sends a json request to elastic and decodes the response
+// into result, if it is set.
+func doElasticRequest(method, url string, body, result interface{}) error {
+	data := []byte{}
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s returned %d: %s", method, url, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
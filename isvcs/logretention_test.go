@@ -0,0 +1,150 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package isvcs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/control-center/serviced/domain/logfilter"
+)
+
+type fakeLogstash struct {
+	sync.Mutex
+	counts  map[string]int64 // count returned for queries on a type
+	deletes []string         // methods, paths and queries that were deleted
+}
+
+func (f *fakeLogstash) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	if r.URL.Path == "/_aliases" {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"logstash-2018.03.01": map[string]interface{}{},
+			"logstash-2018.03.25": map[string]interface{}{},
+			"logstash-2018.03.31": map[string]interface{}{},
+			"kibana-int":          map[string]interface{}{},
+		})
+		return
+	}
+	var body struct {
+		Query struct {
+			QueryString struct {
+				Query string `json:"query"`
+			} `json:"query_string"`
+		} `json:"query"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	query := body.Query.QueryString.Query
+	switch {
+	case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/_count"):
+		var count int64
+		for logType, n := range f.counts {
+			if strings.HasPrefix(query, "fields.type:\""+logType+"\"") {
+				count = n
+			}
+		}
+		if strings.HasPrefix(query, "NOT") {
+			count = f.counts[""]
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"count": count})
+	case strings.HasSuffix(r.URL.Path, "/_delete_by_query"):
+		f.deletes = append(f.deletes, r.Method+" "+r.URL.Path+" "+query)
+		w.Write([]byte("{}"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestEnforceLogRetention(t *testing.T) {
+	es := &fakeLogstash{counts: map[string]int64{"debug": 5, "audit": 7, "": 3}}
+	server := httptest.NewServer(es)
+	defer server.Close()
+
+	policy := logfilter.RetentionPolicy{
+		MaxDays: 14,
+		MaxSize: 10,
+		Rules: []logfilter.RetentionRule{
+			{TenantID: "t1", Type: "audit", Days: 365, ServiceIDs: []string{"s1"}},
+			{TenantID: "t1", Type: "debug", Days: 3, ServiceIDs: []string{"s1", "s2"}},
+		},
+	}
+	now := time.Date(2018, 4, 1, 12, 0, 0, 0, time.UTC)
+	debugCutoff := now.AddDate(0, 0, -3).UnixNano() / int64(time.Millisecond)
+	defaultCutoff := now.AddDate(0, 0, -14).UnixNano() / int64(time.Millisecond)
+
+	report, err := enforceLogRetention(server.URL, policy, now, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if report.DryRun || report.IndexDays != 365 || len(report.Indices) != 0 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if len(report.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %+v", report.Results)
+	}
+	if result := report.Results[0]; result.Rule.Type != "debug" || result.Days != 3 || result.Count != 5 {
+		t.Errorf("Unexpected result for the debug rule: %+v", result)
+	}
+	if result := report.Results[1]; result.Rule.Type != "" || result.Days != 14 || result.Count != 3 {
+		t.Errorf("Unexpected result for the default: %+v", result)
+	}
+
+	expected := []string{
+		"POST /logstash-2018.03.01,logstash-2018.03.25/_delete_by_query fields.type:\"debug\" AND fields.service:(\"s1\" OR \"s2\") AND @timestamp:[* TO " + strconv.FormatInt(debugCutoff, 10) + "}",
+		"POST /logstash-2018.03.01/_delete_by_query NOT ((fields.type:\"audit\" AND fields.service:(\"s1\")) OR (fields.type:\"debug\" AND fields.service:(\"s1\" OR \"s2\"))) AND @timestamp:[* TO " + strconv.FormatInt(defaultCutoff, 10) + "}",
+	}
+	if !reflect.DeepEqual(es.deletes, expected) {
+		t.Errorf("Expected deletes %v, got %v", expected, es.deletes)
+	}
+}
+
+func TestEnforceLogRetention_DryRun(t *testing.T) {
+	es := &fakeLogstash{counts: map[string]int64{"debug": 5}}
+	server := httptest.NewServer(es)
+	defer server.Close()
+
+	policy := logfilter.RetentionPolicy{
+		MaxDays: 14,
+		Rules: []logfilter.RetentionRule{
+			{TenantID: "t1", Type: "debug", Days: 3, ServiceIDs: []string{"s1"}},
+		},
+	}
+	now := time.Date(2018, 4, 1, 12, 0, 0, 0, time.UTC)
+	report, err := enforceLogRetention(server.URL, policy, now, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !report.DryRun || report.IndexDays != 14 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if !reflect.DeepEqual(report.Indices, []string{"logstash-2018.03.01"}) {
+		t.Errorf("Unexpected expired indices: %v", report.Indices)
+	}
+	if len(report.Results) != 1 || report.Results[0].Count != 5 {
+		t.Errorf("Unexpected results: %+v", report.Results)
+	}
+	if len(es.deletes) != 0 {
+		t.Errorf("Expected no deletes on a dry run, got %v", es.deletes)
+	}
+}
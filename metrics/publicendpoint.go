@@ -0,0 +1,61 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"time"
+)
+
+// GetPublicEndpointLatency returns the highest latency percentile (p50, p95
+// or p99), in milliseconds, that the named vhosts and public ports reported
+// from any host over the window.  ok is false if none of them served a
+// request within the window.
+func (c *Client) GetPublicEndpointLatency(window time.Duration, percentile string, names ...string) (latency float64, ok bool, err error) {
+	log.WithField("names", names).Debug("Requesting public endpoint latency")
+	if len(names) == 0 {
+		return 0, false, nil
+	}
+	options := PerformanceOptions{
+		Start:     time.Now().UTC().Add(-window).Format(timeFormat),
+		End:       "now",
+		Returnset: "exact",
+		Tags: map[string][]string{
+			"publicendpoint_name": names,
+		},
+		Metrics: []MetricOptions{
+			{
+				Metric:     "publicendpoint.latency." + percentile,
+				Name:       "latency",
+				Aggregator: "max",
+			},
+		},
+	}
+	data, err := c.performanceQuery(options)
+	if err != nil {
+		log.WithError(err).WithField("options", options).Debug("Public endpoint latency query failed")
+		return 0, false, err
+	}
+	for _, result := range data.Results {
+		for _, dp := range result.Datapoints {
+			if dp.Value.IsNaN {
+				continue
+			}
+			if !ok || dp.Value.Value > latency {
+				latency = dp.Value.Value
+			}
+			ok = true
+		}
+	}
+	return latency, ok, nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// UsageHistoryInterval is the downsampling interval of the usage history of
+// a service instance.
+const UsageHistoryInterval = 5 * time.Minute
+
+// InstanceUsageHistory is the memory and cpu usage of a service instance over
+// a window, averaged over each UsageHistoryInterval.
+type InstanceUsageHistory struct {
+	ServiceID  string
+	InstanceID string
+	Memory     []float64 // resident memory in bytes
+	CPU        []float64 // cpu usage in percent of a core
+}
+
+// cpuUsageMetrics are the container metrics that add up to the cpu usage of
+// a service instance.
+var cpuUsageMetrics = []string{"docker.usageinusermode", "docker.usageinkernelmode"}
+
+// GetInstanceUsageHistory returns the memory and cpu usage history of the
+// service instances since the start date.
+func (c *Client) GetInstanceUsageHistory(startDate time.Time, instances ...ServiceInstance) ([]InstanceUsageHistory, error) {
+	logger := log.WithField("instancecount", len(instances))
+	logger.Debug("Requesting usage history of service instances")
+	if len(instances) == 0 {
+		return []InstanceUsageHistory{}, nil
+	}
+
+	serviceInstanceFilterMap := make(map[string][]string)
+	serviceIDTags := []string{}
+	for _, instance := range instances {
+		ids, ok := serviceInstanceFilterMap[instance.ServiceID]
+		if !ok {
+			serviceIDTags = append(serviceIDTags, instance.ServiceID)
+		}
+		serviceInstanceFilterMap[instance.ServiceID] = append(ids, strconv.Itoa(instance.InstanceID))
+	}
+	tags := map[string][]string{
+		"controlplane_service_id":  serviceIDTags,
+		"controlplane_instance_id": []string{"*"},
+	}
+	downsample := fmt.Sprintf("%ds-avg", int(UsageHistoryInterval.Seconds()))
+
+	queries := []V2MetricOptions{{Metric: "cgroup.memory.totalrss", Tags: tags, Downsample: downsample}}
+	for _, metric := range cpuUsageMetrics {
+		queries = append(queries, V2MetricOptions{Metric: metric, Tags: tags, Downsample: downsample})
+	}
+	options := V2PerformanceOptions{
+		Start:     fmt.Sprintf("%ds-ago", int(time.Since(startDate).Seconds())),
+		End:       "now",
+		Returnset: "exact",
+		Metrics:   queries,
+	}
+	result, err := c.v2performanceQuery(options)
+	if err != nil {
+		logger.WithError(err).Debug("Usage history query failed")
+		return nil, err
+	}
+	return convertUsageHistory(result, serviceInstanceFilterMap), nil
+}
+
+// convertUsageHistory adds up the cpu metrics of each interval and collects
+// the series of each requested service instance.
+func convertUsageHistory(data *V2PerformanceData, svcToInstances map[string][]string) []InstanceUsageHistory {
+	type usage struct {
+		serviceID  string
+		instanceID string
+		memory     []float64
+		cpu        map[float64]float64
+	}
+	usageMap := make(map[string]*usage)
+	keys := []string{}
+	for _, result := range data.Series {
+		if !filterV2ResultsInstance(result, svcToInstances) {
+			continue
+		}
+		key := result.Tags["controlplane_service_id"] + "." + result.Tags["controlplane_instance_id"]
+		u, ok := usageMap[key]
+		if !ok {
+			u = &usage{
+				serviceID:  result.Tags["controlplane_service_id"],
+				instanceID: result.Tags["controlplane_instance_id"],
+				cpu:        make(map[float64]float64),
+			}
+			usageMap[key] = u
+			keys = append(keys, key)
+		}
+		for _, dp := range result.Datapoints {
+			if len(dp) < 2 {
+				continue
+			}
+			if result.Metric == "cgroup.memory.totalrss" {
+				u.memory = append(u.memory, dp.Value())
+			} else {
+				u.cpu[dp.Timestamp()] += dp.Value()
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	history := make([]InstanceUsageHistory, 0, len(keys))
+	for _, key := range keys {
+		u := usageMap[key]
+		timestamps := make([]float64, 0, len(u.cpu))
+		for ts := range u.cpu {
+			timestamps = append(timestamps, ts)
+		}
+		sort.Float64s(timestamps)
+		cpu := make([]float64, len(timestamps))
+		for i, ts := range timestamps {
+			cpu[i] = u.cpu[ts]
+		}
+		history = append(history, InstanceUsageHistory{
+			ServiceID:  u.serviceID,
+			InstanceID: u.instanceID,
+			Memory:     u.memory,
+			CPU:        cpu,
+		})
+	}
+	return history
+}
+
+// Percentile returns the pth percentile of the values, using the nearest
+// rank.  It returns 0 if there are no values.
+func Percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	} else if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
@@ -0,0 +1,74 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package metrics
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestConvertUsageHistory(t *testing.T) {
+	testData := []byte(`
+	{"series":[
+		{"metric":"cgroup.memory.totalrss","tags":{"controlplane_service_id":"svc1","controlplane_instance_id":"0"},"datapoints":[[1520000000,100],[1520000300,300]]},
+		{"metric":"docker.usageinusermode","tags":{"controlplane_service_id":"svc1","controlplane_instance_id":"0"},"datapoints":[[1520000000,40],[1520000300,60]]},
+		{"metric":"docker.usageinkernelmode","tags":{"controlplane_service_id":"svc1","controlplane_instance_id":"0"},"datapoints":[[1520000000,10],[1520000300,5]]},
+		{"metric":"cgroup.memory.totalrss","tags":{"controlplane_service_id":"svc1","controlplane_instance_id":"7"},"datapoints":[[1520000000,900]]},
+		{"metric":"cgroup.memory.totalrss","tags":{"controlplane_service_id":"svc2","controlplane_instance_id":"1"},"datapoints":[[1520000000,200]]}
+	]}
+	`)
+
+	var perfdata V2PerformanceData
+	if err := json.Unmarshal(testData, &perfdata); err != nil {
+		t.Fatalf("Could not unmarshal testData: %s", err)
+	}
+
+	actual := convertUsageHistory(&perfdata, map[string][]string{"svc1": {"0"}, "svc2": {"1"}})
+	expected := []InstanceUsageHistory{
+		{ServiceID: "svc1", InstanceID: "0", Memory: []float64{100, 300}, CPU: []float64{50, 65}},
+		{ServiceID: "svc2", InstanceID: "1", Memory: []float64{200}, CPU: []float64{}},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{}
+	for i := 100; i > 0; i-- {
+		values = append(values, float64(i))
+	}
+	for _, tc := range []struct {
+		p        float64
+		expected float64
+	}{
+		{95, 95},
+		{100, 100},
+		{50, 50},
+		{0, 1},
+	} {
+		if actual := Percentile(values, tc.p); actual != tc.expected {
+			t.Errorf("Expected p%g to be %g, got %g", tc.p, tc.expected, actual)
+		}
+	}
+	if actual := Percentile(nil, 95); actual != 0 {
+		t.Errorf("Expected 0 without values, got %g", actual)
+	}
+	if values[0] != 100 {
+		t.Errorf("Expected the values to be left unsorted")
+	}
+}
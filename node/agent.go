@@ -32,6 +32,7 @@ import (
 
 	"github.com/zenoss/glog"
 
+	"github.com/control-center/serviced/auth"
 	"github.com/control-center/serviced/commons/docker"
 	"github.com/control-center/serviced/commons/iptables"
 	coordclient "github.com/control-center/serviced/coordinator/client"
@@ -59,6 +60,7 @@ import (
 const (
 	dockerEndpoint     = "unix:///var/run/docker.sock"
 	circularBufferSize = 1000
+	prePullTimeout     = 30 * time.Minute
 )
 
 // HostAgent is an instance of the control center Agent.
@@ -78,6 +80,10 @@ type HostAgent struct {
 	mux                  *proxy.TCPMux
 	muxport              string // the mux port to serviced (default is 22250)
 	useTLS               bool   // true if TLS should be enabled for MUX
+	muxSessions          int    // number of multiplexed connections to each remote mux, 0 disables
+	muxCredentials       *auth.MuxCredentials // certificate of the host for mutual TLS on the mux, nil if disabled
+	muxCredentialsPath   string               // where the certificates of the host and its tenants are kept
+	containerTenants     containerTenants     // tenants of the containers on this host
 	proxyRegistry        proxy.ProxyRegistry
 	zkClient             *coordclient.Client
 	maxContainerAge      time.Duration   // maximum age for a stopped container before it is removed
@@ -131,6 +137,9 @@ type AgentOptions struct {
 	Mux                  *proxy.TCPMux
 	MuxPort              string
 	UseTLS               bool
+	MuxSessions          int // number of multiplexed connections to each remote mux, 0 disables
+	MuxCredentials       *auth.MuxCredentials // certificate of the host for mutual TLS on the mux, nil if disabled
+	MuxCredentialsPath   string
 	DockerRegistry       string
 	MaxContainerAge      time.Duration // Maximum container age for a stopped container before being removed
 	VirtualAddressSubnet string
@@ -163,6 +172,9 @@ func NewHostAgent(options AgentOptions, reg registry.Registry) (*HostAgent, erro
 	agent.mux = options.Mux
 	agent.muxport = options.MuxPort
 	agent.useTLS = options.UseTLS
+	agent.muxSessions = options.MuxSessions
+	agent.muxCredentials = options.MuxCredentials
+	agent.muxCredentialsPath = options.MuxCredentialsPath
 	agent.maxContainerAge = options.MaxContainerAge
 	agent.virtualAddressSubnet = options.VirtualAddressSubnet
 	agent.servicedChain = iptables.NewChain("SERVICED")
@@ -352,6 +364,17 @@ func (a *HostAgent) Start(shutdown <-chan interface{}) {
 		wg.Done()
 	}()
 
+	// keep the mux certificates of the host and its tenants fresh
+	if a.muxCredentials != nil {
+		wg.Add(1)
+		go func() {
+			glog.Infof("Starting mux certificate renewal")
+			a.muxCredentialsLoop(shutdown)
+			glog.Infof("Mux certificate renewal done")
+			wg.Done()
+		}()
+	}
+
 	// run the virtual ip listener
 	wg.Add(1)
 	go func() {
@@ -430,6 +453,9 @@ func (a *HostAgent) Start(shutdown <-chan interface{}) {
 		// watch docker action nodes
 		actionListener := zkdocker.NewActionListener(a, a.hostID)
 
+		// watch image pre-pull requests
+		prepullListener := zkdocker.NewPrePullListener(a, a.hostID, prePullTimeout)
+
 		// watch the host state nodes
 		// this blocks until
 		// 1) has a connection
@@ -439,7 +465,7 @@ func (a *HostAgent) Start(shutdown <-chan interface{}) {
 		go func() {
 			defer close(startExit)
 			glog.Infof("Host Agent successfully started")
-			zzk.Start(stop, conn, hsListener, actionListener)
+			zzk.Start(stop, conn, hsListener, actionListener, prepullListener)
 		}()
 
 		select {
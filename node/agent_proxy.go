@@ -27,6 +27,7 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/control-center/serviced/domain/applicationendpoint"
+	"github.com/control-center/serviced/domain/pool"
 	"github.com/control-center/serviced/rpc/master"
 	"github.com/zenoss/glog"
 )
@@ -240,6 +241,22 @@ func (a *HostAgent) GetZkInfo(_ string, zkInfo *ZkInfo) error {
 	return nil
 }
 
+// GetMetricSinks returns the metric sinks of the agent's pool
+func (a *HostAgent) GetMetricSinks(_ string, sinks *pool.MetricSinks) error {
+	masterClient, err := master.NewClient(a.master)
+	if err != nil {
+		glog.Errorf("Could not start Control Center client: %s", err)
+		return err
+	}
+	defer masterClient.Close()
+	response, err := masterClient.GetPoolMetricSinks(a.poolID)
+	if err != nil {
+		return err
+	}
+	*sinks = response
+	return nil
+}
+
 // GetServiceBindMounts returns the service bindmounts
 func (a *HostAgent) GetServiceBindMounts(serviceID string, bindmounts *map[string]string) error {
 	glog.V(4).Infof("ControlCenterAgent.GetServiceBindMounts(serviceID:%s)", serviceID)
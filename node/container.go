@@ -29,6 +29,7 @@ import (
 	"github.com/control-center/serviced/commons/iptables"
 	"github.com/control-center/serviced/dfs/registry"
 	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/domain/servicedefinition"
 	"github.com/control-center/serviced/rpc/master"
 	"github.com/control-center/serviced/servicedversion"
 	"github.com/control-center/serviced/utils"
@@ -59,6 +60,19 @@ func (a *HostAgent) setInstanceState(serviceID string, instanceID int, state ser
 	})
 }
 
+const (
+	// stopTimeout is how long a container gets to stop before it is killed
+	stopTimeout = 45 * time.Second
+
+	// drainPeriodEnv records the drain period of the service in the
+	// container's environment
+	drainPeriodEnv = "SERVICED_DRAIN_PERIOD"
+
+	// tenantIDEnv records the tenant of the service in the container's
+	// environment
+	tenantIDEnv = "SERVICED_TENANT_ID"
+)
+
 // StopContainer stops running container or returns nil if the container does
 // not exist or has already stopped.
 func (a *HostAgent) StopContainer(serviceID string, instanceID int) error {
@@ -79,7 +93,9 @@ func (a *HostAgent) StopContainer(serviceID string, instanceID int) error {
 	}
 
 	a.setInstanceState(serviceID, instanceID, service.StateStopping)
-	err = ctr.Stop(45 * time.Second)
+
+	// the container drains its connections before it stops its service
+	err = ctr.Stop(getDrainPeriod(ctr) + stopTimeout)
 	if _, ok := err.(*dockerclient.ContainerNotRunning); ok {
 		logger.Debug("Container already stopped")
 		return nil
@@ -90,6 +106,30 @@ func (a *HostAgent) StopContainer(serviceID string, instanceID int) error {
 	return nil
 }
 
+// getDrainPeriod returns the drain period of the service, as it was recorded
+// in the container's environment.
+func getDrainPeriod(ctr *docker.Container) time.Duration {
+	if seconds, err := strconv.Atoi(getContainerEnv(ctr, drainPeriodEnv)); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return 0
+}
+
+// getContainerEnv returns the value of an environment variable that was set
+// when the container was created.
+func getContainerEnv(ctr *docker.Container, name string) string {
+	if ctr.Container == nil || ctr.Config == nil {
+		return ""
+	}
+	prefix := name + "="
+	for _, env := range ctr.Config.Env {
+		if strings.HasPrefix(env, prefix) {
+			return strings.TrimPrefix(env, prefix)
+		}
+	}
+	return ""
+}
+
 // AttachContainer returns a channel that monitors the run state of a given
 // container.
 func (a *HostAgent) AttachContainer(state *zkservice.ServiceState, serviceID string, instanceID int) (<-chan time.Time, error) {
@@ -130,6 +170,7 @@ func (a *HostAgent) AttachContainer(state *zkservice.ServiceState, serviceID str
 		ctr.CancelOnEvent(docker.Die)
 		return nil, nil
 	}
+	a.setContainerTenant(ctr.NetworkSettings.IPAddress, ctr.ID, getContainerEnv(ctr, tenantIDEnv))
 	go a.exposeAssignedIPs(state, ctr)
 	a.setInstanceState(serviceID, instanceID, service.StateRunning)
 	return ev, nil
@@ -189,6 +230,7 @@ func (a *HostAgent) StartContainer(cancel <-chan interface{}, serviceID string,
 	state.HostIP = a.ipaddress
 	state.PrivateIP = ctr.NetworkSettings.IPAddress
 	state.Started = dctr.State.StartedAt
+	a.setContainerTenant(state.PrivateIP, ctr.ID, tenantID)
 
 	go a.exposeAssignedIPs(state, ctr)
 	a.setInstanceState(serviceID, instanceID, service.StateRunning)
@@ -250,7 +292,7 @@ func (a *HostAgent) RestartContainer(cancel <-chan interface{}, serviceID string
 		// deleted before the pull is successful, then this will just be a
 		// no-op.  The restart of the container is handled by the delegate once
 		// it is notified that the container has stopped.
-		if err := ctr.Stop(45 * time.Second); err != nil {
+		if err := ctr.Stop(getDrainPeriod(ctr) + stopTimeout); err != nil {
 			logger.WithError(err).Debug("Could not stop container")
 		}
 	}()
@@ -297,6 +339,12 @@ func (a *HostAgent) ResumeContainer(serviceID string, instanceID int) error {
 		return err
 	}
 	logger.Debug("Resumed paused container")
+
+	// post-thaw hooks cannot cancel the snapshot, so they are only recorded
+	results, _ := runSnapshotHooks(logger, containerHookRunner(ctrName), serviceID, instanceID, svc.Snapshot.Hooks, servicedefinition.HookPostThaw)
+	if err := a.setInstanceSnapshotHooks(serviceID, instanceID, results, false); err != nil {
+		logger.WithError(err).Warn("Could not record snapshot hook results")
+	}
 	a.setInstanceState(serviceID, instanceID, service.StateRunning)
 
 	return nil
@@ -334,13 +382,23 @@ func (a *HostAgent) PauseContainer(serviceID string, instanceID int) error {
 		return nil
 	}
 
-	// pause the running container
+	// pause the running container.  A failed hook only cancels the snapshot;
+	// the container is still paused so that the master can resume it.
 	a.setInstanceState(serviceID, instanceID, service.StatePausing)
+	run := containerHookRunner(ctrName)
+	results, aborted := runSnapshotHooks(logger, run, serviceID, instanceID, svc.Snapshot.Hooks, servicedefinition.HookPreFreeze)
 	if err := attachAndRun(ctrName, svc.Snapshot.Pause); err != nil {
 		logger.WithError(err).Debug("Could not pause running container")
 		return err
 	}
 	logger.Debug("Paused running container")
+	if !aborted {
+		postResults, _ := runSnapshotHooks(logger, run, serviceID, instanceID, svc.Snapshot.Hooks, servicedefinition.HookPostFreeze)
+		results = append(results, postResults...)
+	}
+	if err := a.setInstanceSnapshotHooks(serviceID, instanceID, results, true); err != nil {
+		logger.WithError(err).Warn("Could not record snapshot hook results")
+	}
 	a.setInstanceState(serviceID, instanceID, service.StatePaused)
 	return nil
 }
@@ -399,6 +457,26 @@ func (a *HostAgent) pullImage(logger *log.Entry, cancel <-chan interface{}, imag
 	return uuid, name, nil
 }
 
+// PullImage implements zkdocker.PrePullHandler, pulling a service image onto
+// the host before any of its instances are scheduled here.
+func (a *HostAgent) PullImage(cancel <-chan time.Time, imageID string) error {
+	logger := plog.WithField("imageid", imageID)
+
+	stop := make(chan interface{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-cancel:
+			close(stop)
+		case <-done:
+		}
+	}()
+
+	_, _, err := a.pullImage(logger, stop, imageID)
+	return err
+}
+
 // monitorContainer tracks the running state of the container.
 // runs when the container dies
 func (a *HostAgent) monitorContainer(logger *log.Entry, ctr *docker.Container) <-chan time.Time {
@@ -408,6 +486,9 @@ func (a *HostAgent) monitorContainer(logger *log.Entry, ctr *docker.Container) <
 		serviceID := someSlice[0]
 		instanceID, err := strconv.ParseInt(someSlice[1], 10, 0)
 		a.setInstanceState(serviceID, int(instanceID), service.StateStopped)
+		if ctr.NetworkSettings != nil {
+			a.removeContainerTenant(ctr.NetworkSettings.IPAddress, ctr.ID)
+		}
 		defer close(ev)
 		dctr, err := ctr.Inspect()
 		if err != nil {
@@ -599,11 +680,14 @@ func (a *HostAgent) createContainerConfig(tenantID string, svc *service.Service,
 					Protocol:           endpoint.Protocol,
 					PortNumber:         endpoint.PortNumber,
 					AssignedPortNumber: assignedPortNumber,
+					LoadBalancer:       endpoint.LoadBalancer,
+					DrainPeriod:        svc.DrainPeriod,
 				})
 			} else {
 				state.Imports = append(state.Imports, zkservice.ImportBinding{
 					Application:    endpoint.Application,
 					Purpose:        endpoint.Purpose,
+					Protocol:       endpoint.Protocol,
 					PortNumber:     endpoint.PortNumber,
 					PortTemplate:   endpoint.PortTemplate,
 					VirtualAddress: endpoint.VirtualAddress,
@@ -650,6 +734,16 @@ func (a *HostAgent) createContainerConfig(tenantID string, svc *service.Service,
 	// Note that /etc/serviced also contains logconfig-controller.yaml
 	addBindingToMap(bindsMap, "/etc/serviced", filepath.Dir(a.delegateKeyFile))
 
+	// Bind mount the certificate of the tenant, so the container can only
+	// reach the endpoints of its own tenant through the mux
+	if a.muxCredentials != nil {
+		if err := a.ensureTenantMuxCredentials(tenantID); err != nil {
+			logger.WithError(err).Error("Could not get the mux certificate of the tenant")
+			return nil, nil, nil, err
+		}
+		addBindingToMap(bindsMap, containerMuxCredentialsDir, a.muxCredentialsDir(tenantID))
+	}
+
 	// specify temporary volume paths for docker to create
 	tmpVolumes := []string{"/tmp"}
 	for _, volume := range svc.Volumes {
@@ -749,6 +843,8 @@ func (a *HostAgent) createContainerConfig(tenantID string, svc *service.Service,
 		fmt.Sprintf("SERVICED_MUX_PORT=%s", a.muxport),
 		fmt.Sprintf("SERVICED_RPC_PORT=%s", a.rpcport),
 		fmt.Sprintf("SERVICED_LOG_ADDRESS=%s", a.logstashURL),
+		fmt.Sprintf("%s=%d", drainPeriodEnv, svc.DrainPeriod),
+		fmt.Sprintf("%s=%s", tenantIDEnv, tenantID),
 		//The SERVICED_UI_PORT environment variable is deprecated and services should always use port 443 to contact serviced from inside a container
 		"SERVICED_UI_PORT=443",
 		fmt.Sprintf("SERVICED_MASTER_IP=%s", strings.Split(a.master, ":")[0]),
@@ -777,6 +873,12 @@ func (a *HostAgent) createContainerConfig(tenantID string, svc *service.Service,
 	if !a.useTLS {
 		cmd = append(cmd, "--mux-disable-tls")
 	}
+	if a.muxSessions > 0 {
+		cmd = append(cmd, fmt.Sprintf("--mux-sessions=%d", a.muxSessions))
+	}
+	if a.muxCredentials != nil {
+		cmd = append(cmd, fmt.Sprintf("--mux-credentials=%s", containerMuxCredentialsDir))
+	}
 	if a.rpcDisableTLS {
 		cmd = append(cmd, "--rpc-disable-tls")
 	}
@@ -17,11 +17,14 @@ package node
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	regmocks "github.com/control-center/serviced/dfs/registry/mocks"
+	"github.com/control-center/serviced/commons/docker"
 	"github.com/control-center/serviced/domain/service"
+	dockerclient "github.com/fsouza/go-dockerclient"
 )
 
 func TestSetupContainer_DockerLog(t *testing.T) {
@@ -61,3 +64,30 @@ func TestSetupContainer_DockerLog(t *testing.T) {
 	assert.Equal(hcfg.LogConfig.Config["bravo"], "two")
 	assert.Equal(hcfg.LogConfig.Config["charlie"], "three")
 }
+
+func TestGetDrainPeriod(t *testing.T) {
+	assert := assert.New(t)
+
+	fakeHostAgent := &HostAgent{
+		uiport:               ":443",
+		virtualAddressSubnet: "0.0.0.0",
+		pullreg:              &regmocks.Registry{},
+	}
+	fakeService := &service.Service{
+		ImageID:     "busybox:latest",
+		ID:          "faketestService",
+		Name:        "fakeTestServiceName",
+		DrainPeriod: 20,
+	}
+
+	// the drain period is recorded in the container's environment
+	cfg, _, _, err := fakeHostAgent.createContainerConfig("unused", fakeService, 0, "unused")
+	assert.Nil(err)
+	ctr := &docker.Container{Container: &dockerclient.Container{Config: cfg}}
+	assert.Equal(20*time.Second, getDrainPeriod(ctr))
+
+	// containers without it don't drain
+	ctr = &docker.Container{Container: &dockerclient.Container{Config: &dockerclient.Config{Env: []string{"TZ=UTC"}}}}
+	assert.Equal(time.Duration(0), getDrainPeriod(ctr))
+	assert.Equal(time.Duration(0), getDrainPeriod(&docker.Container{Container: &dockerclient.Container{}}))
+}
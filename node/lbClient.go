@@ -15,6 +15,7 @@ package node
 
 import (
 	"github.com/control-center/serviced/domain/applicationendpoint"
+	"github.com/control-center/serviced/domain/pool"
 	"github.com/control-center/serviced/rpc/master"
 	"github.com/control-center/serviced/rpc/rpcutils"
 	"github.com/zenoss/glog"
@@ -108,6 +109,12 @@ func (a *LBClient) GetZkInfo(zkInfo *ZkInfo) error {
 	return a.rpcClient.Call("ControlCenterAgent.GetZkInfo", "na", zkInfo, 0)
 }
 
+// GetMetricSinks returns the metric sinks of the agent's pool
+func (a *LBClient) GetMetricSinks(sinks *pool.MetricSinks) error {
+	glog.V(4).Infof("ControlCenterAgent.GetMetricSinks()")
+	return a.rpcClient.Call("ControlCenterAgent.GetMetricSinks", "na", sinks, 0)
+}
+
 // GetServiceBindMounts returns the service
 func (a *LBClient) GetServiceBindMounts(serviceID string, bindmounts *map[string]string) error {
 	glog.V(4).Infof("ControlCenterAgent.GetServiceBindMounts(serviceID:%s)", serviceID)
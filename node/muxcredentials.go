@@ -0,0 +1,189 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/auth"
+	"github.com/control-center/serviced/rpc/master"
+)
+
+const (
+	// containerMuxCredentialsDir is where the certificate of the tenant is
+	// mounted in its containers
+	containerMuxCredentialsDir = "/etc/serviced-mux"
+
+	// muxCredentialsRetry is how long to wait to try again after failing to
+	// get a certificate
+	muxCredentialsRetry = 10 * time.Second
+)
+
+// ErrWrongTenant is returned when a tenant asks the mux for a container that
+// belongs to another tenant.
+var ErrWrongTenant = errors.New("container does not belong to the tenant")
+
+// containerTenants keeps track of the tenants of the containers on this host
+// by ip address, so the mux can keep tenants apart.
+type containerTenants struct {
+	mu      sync.RWMutex
+	tenants map[string]containerTenant
+}
+
+// containerTenant is the container at an ip address, and its tenant
+type containerTenant struct {
+	containerID string
+	tenantID    string
+}
+
+func (a *HostAgent) setContainerTenant(ip, containerID, tenantID string) {
+	if ip == "" {
+		return
+	}
+	a.containerTenants.mu.Lock()
+	defer a.containerTenants.mu.Unlock()
+	if a.containerTenants.tenants == nil {
+		a.containerTenants.tenants = make(map[string]containerTenant)
+	}
+	a.containerTenants.tenants[ip] = containerTenant{containerID: containerID, tenantID: tenantID}
+}
+
+// removeContainerTenant forgets the tenant of a container that exited, unless
+// a new container has already taken over its address.
+func (a *HostAgent) removeContainerTenant(ip, containerID string) {
+	a.containerTenants.mu.Lock()
+	defer a.containerTenants.mu.Unlock()
+	if owner, ok := a.containerTenants.tenants[ip]; ok && owner.containerID == containerID {
+		delete(a.containerTenants.tenants, ip)
+	}
+}
+
+// AuthorizeMuxConnection returns an error unless the container at the
+// address belongs to the tenant.
+func (a *HostAgent) AuthorizeMuxConnection(tenantID, address string) error {
+	ip, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	a.containerTenants.mu.RLock()
+	defer a.containerTenants.mu.RUnlock()
+	if owner, ok := a.containerTenants.tenants[ip]; !ok || owner.tenantID != tenantID {
+		return ErrWrongTenant
+	}
+	return nil
+}
+
+// muxCredentialsDir is where the certificate of the host, or of one of its
+// tenants, is kept.
+func (a *HostAgent) muxCredentialsDir(tenantID string) string {
+	if tenantID == "" {
+		return a.muxCredentialsPath
+	}
+	return filepath.Join(a.muxCredentialsPath, "tenants", tenantID)
+}
+
+// refreshMuxCredentials gets a new certificate for the host, or one of its
+// tenants, from the master.  The private key never leaves the host.
+func (a *HostAgent) refreshMuxCredentials(tenantID string) error {
+	logger := plog.WithFields(log.Fields{
+		"tenantid": tenantID,
+	})
+	dir := a.muxCredentialsDir(tenantID)
+
+	// reuse the key if there is one
+	keyPEM, err := ioutil.ReadFile(filepath.Join(dir, auth.MuxKeyFileName))
+	var publicPEM []byte
+	if err == nil {
+		key, err := auth.RSAPrivateKeyFromPEM(keyPEM)
+		if err != nil {
+			return err
+		}
+		if publicPEM, err = auth.PEMFromRSAPublicKey(key.Public(), nil); err != nil {
+			return err
+		}
+	} else if os.IsNotExist(err) {
+		if publicPEM, keyPEM, err = auth.GenerateRSAKeyPairPEM(nil); err != nil {
+			return err
+		}
+	} else {
+		return err
+	}
+
+	masterClient, err := master.NewClient(a.master)
+	if err != nil {
+		logger.WithField("master", a.master).WithError(err).Debug("Could not connect to the master")
+		return err
+	}
+	defer masterClient.Close()
+
+	certPEM, caPEM, expires, err := masterClient.GetMuxCertificate(a.hostID, tenantID, publicPEM)
+	if err != nil {
+		logger.WithError(err).Debug("Could not get a mux certificate")
+		return err
+	}
+	if err := auth.WriteMuxCredentials(dir, certPEM, keyPEM, caPEM); err != nil {
+		logger.WithError(err).Debug("Could not save the mux certificate")
+		return err
+	}
+	if tenantID == "" {
+		if err := a.muxCredentials.Update(certPEM, keyPEM, caPEM); err != nil {
+			return err
+		}
+	}
+	logger.WithField("expires", time.Unix(expires, 0)).Debug("Received mux certificate")
+	return nil
+}
+
+// ensureTenantMuxCredentials makes sure the tenant has a certificate that
+// isn't about to expire.
+func (a *HostAgent) ensureTenantMuxCredentials(tenantID string) error {
+	creds := &auth.MuxCredentials{}
+	if err := creds.Load(a.muxCredentialsDir(tenantID)); err == nil && creds.Expires().Sub(time.Now()) > auth.RefreshAhead {
+		return nil
+	}
+	return a.refreshMuxCredentials(tenantID)
+}
+
+// muxCredentialsLoop renews the certificates of the host and its tenants
+// halfway through their lifetime, until shutdown.
+func (a *HostAgent) muxCredentialsLoop(shutdown <-chan interface{}) {
+	for {
+		wait := muxCredentialsRetry
+		if err := a.refreshMuxCredentials(""); err != nil {
+			plog.WithError(err).Warn("Could not renew the mux certificate of the host")
+		} else {
+			tenants, _ := ioutil.ReadDir(filepath.Join(a.muxCredentialsPath, "tenants"))
+			for _, tenant := range tenants {
+				if err := a.refreshMuxCredentials(tenant.Name()); err != nil {
+					plog.WithError(err).WithField("tenantid", tenant.Name()).Warn("Could not renew the mux certificate of the tenant")
+				}
+			}
+			if half := a.muxCredentials.Expires().Sub(time.Now()) / 2; half > wait {
+				wait = half
+			}
+		}
+		select {
+		case <-time.After(wait):
+		case <-shutdown:
+			return
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizeMuxConnection(t *testing.T) {
+	a := &HostAgent{}
+	a.setContainerTenant("172.17.0.2", "ctr1", "tenant-a")
+	a.setContainerTenant("172.17.0.3", "ctr2", "tenant-b")
+
+	assert.Nil(t, a.AuthorizeMuxConnection("tenant-a", "172.17.0.2:8080"))
+	assert.Equal(t, ErrWrongTenant, a.AuthorizeMuxConnection("tenant-a", "172.17.0.3:8080"))
+	assert.Equal(t, ErrWrongTenant, a.AuthorizeMuxConnection("tenant-a", "172.17.0.4:8080"))
+	assert.NotNil(t, a.AuthorizeMuxConnection("tenant-a", "172.17.0.2"))
+
+	// a new container may take over the address of an old one
+	a.setContainerTenant("172.17.0.3", "ctr3", "tenant-a")
+	assert.Nil(t, a.AuthorizeMuxConnection("tenant-a", "172.17.0.3:8080"))
+
+	// the address is forgotten when its container exits, but not when the
+	// container that it was taken over from exits
+	a.removeContainerTenant("172.17.0.3", "ctr2")
+	assert.Nil(t, a.AuthorizeMuxConnection("tenant-a", "172.17.0.3:8080"))
+	a.removeContainerTenant("172.17.0.3", "ctr3")
+	assert.Equal(t, ErrWrongTenant, a.AuthorizeMuxConnection("tenant-a", "172.17.0.3:8080"))
+	a.removeContainerTenant("172.17.0.2", "ctr1")
+	assert.Empty(t, a.containerTenants.tenants)
+}
@@ -0,0 +1,161 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/domain/servicedefinition"
+	"github.com/control-center/serviced/utils"
+	"github.com/control-center/serviced/zzk"
+	zkservice "github.com/control-center/serviced/zzk/service"
+)
+
+// hookKillGrace is how long a hook may take to exit after it is asked to stop
+var hookKillGrace = 5 * time.Second
+
+// hookRunner runs a bash command inside a container, and waits for it to exit
+// or to be stopped after the timeout.
+type hookRunner func(command string, timeout time.Duration) error
+
+// containerHookRunner returns a hookRunner that attaches to the named
+// container.
+func containerHookRunner(ctrName string) hookRunner {
+	return func(command string, timeout time.Duration) error {
+		cmd, err := utils.DockerExecCommand(ctrName, hookCommand(command, timeout))
+		if err != nil {
+			return err
+		}
+		return runWithTimeout(cmd, timeout)
+	}
+}
+
+// hookCommand wraps the hook in timeout, because killing docker exec does not
+// stop the process that it started inside the container.
+func hookCommand(command string, timeout time.Duration) []string {
+	return []string{
+		"timeout",
+		"-k", strconv.Itoa(int(math.Ceil(hookKillGrace.Seconds()))),
+		strconv.Itoa(int(math.Ceil(timeout.Seconds()))),
+		"/bin/bash", "-c", command,
+	}
+}
+
+// runSnapshotHooks runs the hooks of the given phase in the order they are
+// defined.  If a hook fails and its policy is to abort, the remaining hooks
+// are skipped and aborted is returned as true.
+func runSnapshotHooks(logger *log.Entry, run hookRunner, serviceID string, instanceID int, hooks []servicedefinition.SnapshotHook, phase servicedefinition.SnapshotHookPhase) (results []servicedefinition.SnapshotHookResult, aborted bool) {
+	for _, hook := range hooks {
+		if hook.Phase != phase {
+			continue
+		}
+		hlog := logger.WithFields(log.Fields{
+			"hook":  hook.Name,
+			"phase": hook.Phase,
+		})
+		result := servicedefinition.SnapshotHookResult{
+			ServiceID:  serviceID,
+			InstanceID: instanceID,
+			Name:       hook.Name,
+			Phase:      hook.Phase,
+			Started:    time.Now(),
+		}
+		err := run(hook.Command, hook.GetTimeout())
+		result.Duration = time.Since(result.Started)
+		if err != nil {
+			result.Error = err.Error()
+			result.Aborted = hook.AbortOnFailure()
+			hlog.WithError(err).Warn("Snapshot hook failed")
+		} else {
+			hlog.WithField("duration", result.Duration).Debug("Ran snapshot hook")
+		}
+		results = append(results, result)
+		if result.Aborted {
+			return results, true
+		}
+	}
+	return results, false
+}
+
+// runWithTimeout runs the command and waits for it to exit.  The hook is
+// stopped inside the container at the timeout; if the command still has not
+// exited once the hook has been killed, it is killed as well.
+func runWithTimeout(cmd *exec.Cmd, timeout time.Duration) error {
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	errC := make(chan error, 1)
+	go func() {
+		errC <- cmd.Wait()
+	}()
+
+	timer := time.NewTimer(timeout + 2*hookKillGrace)
+	defer timer.Stop()
+	var err error
+	select {
+	case err = <-errC:
+	case <-timer.C:
+		cmd.Process.Kill()
+		<-errC
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		// timeout exits with 124 when it stops the hook, or 137 when it has
+		// to kill it
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && (status.ExitStatus() == 124 || status.ExitStatus() == 137) {
+			return fmt.Errorf("timed out after %s", timeout)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s (%s)", strings.TrimSpace(output.String()), err)
+	}
+	return nil
+}
+
+// setInstanceSnapshotHooks records the results of the snapshot hooks on the
+// instance's current state.  If reset is true, results from a previous
+// snapshot are discarded.
+func (a *HostAgent) setInstanceSnapshotHooks(serviceID string, instanceID int, results []servicedefinition.SnapshotHookResult, reset bool) error {
+	conn, err := zzk.GetLocalConnection(zzk.GeneratePoolPath(a.poolID))
+	if err != nil {
+		return err
+	}
+	req := zkservice.StateRequest{
+		HostID:     a.hostID,
+		ServiceID:  serviceID,
+		InstanceID: instanceID,
+	}
+	return zkservice.UpdateState(conn, req, func(s *zkservice.State) bool {
+		if reset {
+			s.SnapshotHooks = results
+		} else if len(results) > 0 {
+			s.SnapshotHooks = append(s.SnapshotHooks, results...)
+		} else {
+			return false
+		}
+		return true
+	})
+}
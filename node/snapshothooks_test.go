@@ -0,0 +1,109 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package node
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/control-center/serviced/domain/servicedefinition"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunSnapshotHooks_Order(t *testing.T) {
+	assert := assert.New(t)
+
+	ran := []string{}
+	run := func(command string, timeout time.Duration) error {
+		ran = append(ran, command)
+		return nil
+	}
+	hooks := []servicedefinition.SnapshotHook{
+		{Name: "a", Phase: servicedefinition.HookPreFreeze, Command: "one"},
+		{Name: "b", Phase: servicedefinition.HookPostFreeze, Command: "two"},
+		{Name: "c", Phase: servicedefinition.HookPreFreeze, Command: "three"},
+	}
+
+	results, aborted := runSnapshotHooks(plog.WithField("test", t.Name()), run, "svc", 1, hooks, servicedefinition.HookPreFreeze)
+	assert.False(aborted)
+	assert.Equal([]string{"one", "three"}, ran)
+	assert.Len(results, 2)
+	assert.Equal("a", results[0].Name)
+	assert.Equal("svc", results[0].ServiceID)
+	assert.Equal(1, results[0].InstanceID)
+	assert.Empty(results[0].Error)
+	assert.Equal("c", results[1].Name)
+}
+
+func TestRunSnapshotHooks_Abort(t *testing.T) {
+	assert := assert.New(t)
+
+	ran := []string{}
+	run := func(command string, timeout time.Duration) error {
+		ran = append(ran, command)
+		if command == "fail" {
+			return errors.New("exit status 1")
+		}
+		return nil
+	}
+	hooks := []servicedefinition.SnapshotHook{
+		{Name: "a", Phase: servicedefinition.HookPreFreeze, Command: "fail", OnFailure: servicedefinition.HookContinue},
+		{Name: "b", Phase: servicedefinition.HookPreFreeze, Command: "fail"},
+		{Name: "c", Phase: servicedefinition.HookPreFreeze, Command: "skipped"},
+	}
+
+	results, aborted := runSnapshotHooks(plog.WithField("test", t.Name()), run, "svc", 0, hooks, servicedefinition.HookPreFreeze)
+	assert.True(aborted)
+	assert.Equal([]string{"fail", "fail"}, ran)
+	assert.Len(results, 2)
+	assert.Equal("exit status 1", results[0].Error)
+	assert.False(results[0].Aborted)
+	assert.True(results[1].Aborted)
+}
+
+func TestHookCommand(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"timeout", "-k", "5", "2", "/bin/bash", "-c", "echo hi"}, hookCommand("echo hi", 1500*time.Millisecond))
+}
+
+func TestRunWithTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.NoError(runWithTimeout(exec.Command("true"), time.Second))
+	assert.EqualError(runWithTimeout(exec.Command("sh", "-c", "echo oops; exit 1"), time.Second), "oops (exit status 1)")
+
+	// the hook was stopped inside the container
+	assert.EqualError(runWithTimeout(exec.Command("sh", "-c", "exit 124"), time.Second), "timed out after 1s")
+}
+
+func TestRunWithTimeout_Kill(t *testing.T) {
+	assert := assert.New(t)
+
+	defer func(grace time.Duration) { hookKillGrace = grace }(hookKillGrace)
+	hookKillGrace = 10 * time.Millisecond
+
+	cmd := exec.Command("sleep", "10")
+	start := time.Now()
+	err := runWithTimeout(cmd, 10*time.Millisecond)
+	assert.EqualError(err, "timed out after 10ms")
+	assert.True(time.Since(start) < 5*time.Second)
+
+	// the command has exited by the time the hook times out
+	assert.NotNil(cmd.ProcessState)
+}
@@ -0,0 +1,384 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/control-center/serviced/domain/servicedefinition"
+)
+
+// ringReplicas is the number of points each backend has on the hash ring
+const ringReplicas = 64
+
+// Backend is a destination that a Balancer spreads connections across.
+type Backend struct {
+	Key        string // uniquely identifies the backend (e.g. its address)
+	InstanceID int    // instance of the service, for looking up its weight
+}
+
+// HealthTracker counts the consecutive failures to connect to each backend.
+// It may be shared by balancers that have backends in common.
+type HealthTracker struct {
+	mu       sync.Mutex
+	fails    map[string]int
+	lastFail map[string]time.Time
+}
+
+// NewHealthTracker instantiates a new health tracker
+func NewHealthTracker() *HealthTracker {
+	return &HealthTracker{
+		fails:    make(map[string]int),
+		lastFail: make(map[string]time.Time),
+	}
+}
+
+// Failed records a failure to connect to a backend
+func (t *HealthTracker) Failed(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fails[key]++
+	t.lastFail[key] = time.Now()
+}
+
+// Succeeded resets the failure count of a backend
+func (t *HealthTracker) Succeeded(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.fails, key)
+	delete(t.lastFail, key)
+}
+
+// Ejected returns true if a backend has failed at least maxFails times in a
+// row, the last time less than timeout ago.  Once the timeout passes, the
+// backend gets another chance.
+func (t *HealthTracker) Ejected(key string, maxFails int, timeout time.Duration) bool {
+	if maxFails <= 0 {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.fails[key] >= maxFails && time.Since(t.lastFail[key]) < timeout
+}
+
+type ringPoint struct {
+	hash uint32
+	key  string
+}
+
+type ringPoints []ringPoint
+
+func (r ringPoints) Len() int           { return len(r) }
+func (r ringPoints) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r ringPoints) Less(i, j int) bool { return r[i].hash < r[j].hash }
+
+// Balancer picks a backend for each connection according to a load balancing
+// policy, skipping backends that have been ejected for failing to connect.
+type Balancer struct {
+	mu       sync.Mutex
+	config   servicedefinition.LoadBalancer
+	health   *HealthTracker
+	backends []Backend
+	active   map[string]int // open connections by backend
+	current  map[string]int // running weights for the weighted policy
+	ring     ringPoints     // hash ring for the iphash policy
+	next     int
+}
+
+// NewBalancer instantiates a new balancer.  If health is nil, backends are
+// never ejected.
+func NewBalancer(health *HealthTracker) *Balancer {
+	return &Balancer{
+		health:  health,
+		active:  make(map[string]int),
+		current: make(map[string]int),
+	}
+}
+
+// Configure sets the load balancing policy
+func (b *Balancer) Configure(config servicedefinition.LoadBalancer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.config = config
+	b.current = make(map[string]int)
+}
+
+// Set updates the list of backends.  Connection counts are kept for backends
+// that are still in the list.
+func (b *Balancer) Set(backends []Backend) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.backends = make([]Backend, len(backends))
+	copy(b.backends, backends)
+	b.next = 0
+	b.current = make(map[string]int)
+
+	keys := make(map[string]struct{})
+	b.ring = make(ringPoints, 0, len(backends)*ringReplicas)
+	for _, backend := range backends {
+		keys[backend.Key] = struct{}{}
+		for i := 0; i < ringReplicas; i++ {
+			b.ring = append(b.ring, ringPoint{hash: hashString(fmt.Sprintf("%s-%d", backend.Key, i)), key: backend.Key})
+		}
+	}
+	sort.Sort(b.ring)
+	for key := range b.active {
+		if _, ok := keys[key]; !ok {
+			delete(b.active, key)
+		}
+	}
+}
+
+// Pick chooses a backend for a connection from clientAddr (which is only
+// needed for the iphash policy).  The connection should be released when it
+// closes.  Returns false if there are no backends.
+func (b *Balancer) Pick(clientAddr string) (Backend, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.backends) == 0 {
+		return Backend{}, false
+	}
+
+	// skip ejected backends, unless all of them are ejected
+	candidates := []Backend{}
+	if b.health != nil {
+		maxFails, timeout := b.config.GetMaxFails(), b.config.GetFailTimeout()
+		for _, backend := range b.backends {
+			if !b.health.Ejected(backend.Key, maxFails, timeout) {
+				candidates = append(candidates, backend)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = b.backends
+	}
+
+	var backend Backend
+	switch b.config.Policy {
+	case servicedefinition.BalanceLeastConn:
+		backend = b.leastConn(candidates)
+	case servicedefinition.BalanceWeighted:
+		backend = b.weighted(candidates)
+	case servicedefinition.BalanceIPHash:
+		backend = b.ipHash(candidates, clientAddr)
+	default:
+		backend = b.roundRobin(candidates)
+	}
+	b.active[backend.Key]++
+	return backend, true
+}
+
+// Acquire counts a connection to a specific backend, bypassing the policy (for
+// clients that are pinned to a backend).  Returns false if the backend is gone
+// or has been ejected, in which case a backend should be picked instead.
+func (b *Balancer) Acquire(key string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, backend := range b.backends {
+		if backend.Key != key {
+			continue
+		}
+		if b.health != nil && b.health.Ejected(key, b.config.GetMaxFails(), b.config.GetFailTimeout()) {
+			return false
+		}
+		b.active[key]++
+		return true
+	}
+	return false
+}
+
+// Release is called when a connection to a backend that was picked closes
+func (b *Balancer) Release(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.active[key] > 1 {
+		b.active[key]--
+	} else {
+		delete(b.active, key)
+	}
+}
+
+func (b *Balancer) roundRobin(candidates []Backend) Backend {
+	backend := candidates[b.next%len(candidates)]
+	b.next++
+	return backend
+}
+
+// leastConn returns the backend with the fewest open connections, taking
+// turns between backends that are tied.
+func (b *Balancer) leastConn(candidates []Backend) Backend {
+	var backend Backend
+	least := -1
+	for i := range candidates {
+		c := candidates[(b.next+i)%len(candidates)]
+		if n := b.active[c.Key]; least < 0 || n < least {
+			backend, least = c, n
+		}
+	}
+	b.next++
+	return backend
+}
+
+// weighted implements smooth weighted round robin, which spreads the picks
+// of heavier backends out instead of sending them in bursts.
+func (b *Balancer) weighted(candidates []Backend) Backend {
+	total := 0
+	best := -1
+	for i, c := range candidates {
+		weight := b.config.GetWeight(c.InstanceID)
+		total += weight
+		b.current[c.Key] += weight
+		if best < 0 || b.current[c.Key] > b.current[candidates[best].Key] {
+			best = i
+		}
+	}
+	backend := candidates[best]
+	b.current[backend.Key] -= total
+	return backend
+}
+
+// ipHash maps the client address onto the hash ring, so that a client keeps
+// reaching the same backend, and only the clients of a backend that goes
+// away are moved.
+func (b *Balancer) ipHash(candidates []Backend, clientAddr string) Backend {
+	if clientAddr == "" || len(b.ring) == 0 {
+		return b.roundRobin(candidates)
+	}
+	if host, _, err := net.SplitHostPort(clientAddr); err == nil {
+		clientAddr = host
+	}
+
+	available := make(map[string]Backend)
+	for _, c := range candidates {
+		available[c.Key] = c
+	}
+
+	hash := hashString(clientAddr)
+	start := sort.Search(len(b.ring), func(i int) bool { return b.ring[i].hash >= hash })
+	for i := 0; i < len(b.ring); i++ {
+		if backend, ok := available[b.ring[(start+i)%len(b.ring)].key]; ok {
+			return backend
+		}
+	}
+	return b.roundRobin(candidates)
+}
+
+func hashString(s string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return h.Sum32()
+}
+
+// Drainer keeps track of the open connections to each backend, so that the
+// connections to a backend that goes away get some time to finish before they
+// are closed.
+type Drainer struct {
+	mu     sync.Mutex
+	conns  map[string]map[*drainConn]struct{}
+	timers map[string]*time.Timer
+}
+
+// NewDrainer instantiates a new drainer
+func NewDrainer() *Drainer {
+	return &Drainer{
+		conns:  make(map[string]map[*drainConn]struct{}),
+		timers: make(map[string]*time.Timer),
+	}
+}
+
+// Track returns a connection to a backend that is closed if the backend is
+// drained.
+func (d *Drainer) Track(key string, conn net.Conn) net.Conn {
+	c := &drainConn{Conn: conn, key: key, drainer: d}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.conns[key] == nil {
+		d.conns[key] = make(map[*drainConn]struct{})
+	}
+	d.conns[key][c] = struct{}{}
+	return c
+}
+
+// Drain closes the connections to a backend that are still open once the
+// period is over.  The connections are left alone if the period is 0.
+func (d *Drainer) Drain(key string, period time.Duration) {
+	if period <= 0 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.timers[key]; ok || len(d.conns[key]) == 0 {
+		return
+	}
+	d.timers[key] = time.AfterFunc(period, func() { d.closeAll(key) })
+}
+
+// Restore stops draining a backend that has come back
+func (d *Drainer) Restore(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if timer, ok := d.timers[key]; ok {
+		timer.Stop()
+		delete(d.timers, key)
+	}
+}
+
+// Open returns the number of open connections to a backend
+func (d *Drainer) Open(key string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.conns[key])
+}
+
+func (d *Drainer) closeAll(key string) {
+	d.mu.Lock()
+	conns := d.conns[key]
+	delete(d.conns, key)
+	delete(d.timers, key)
+	d.mu.Unlock()
+
+	for c := range conns {
+		c.Conn.Close()
+	}
+}
+
+func (d *Drainer) untrack(c *drainConn) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.conns[c.key], c)
+	if len(d.conns[c.key]) == 0 {
+		delete(d.conns, c.key)
+	}
+}
+
+// drainConn is a connection tracked by a drainer
+type drainConn struct {
+	net.Conn
+	key     string
+	drainer *Drainer
+	once    sync.Once
+}
+
+func (c *drainConn) Close() error {
+	c.once.Do(func() { c.drainer.untrack(c) })
+	return c.Conn.Close()
+}
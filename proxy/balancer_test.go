@@ -0,0 +1,236 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package proxy
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/control-center/serviced/domain/servicedefinition"
+)
+
+var testBackends = []Backend{
+	{Key: "a", InstanceID: 0},
+	{Key: "b", InstanceID: 1},
+	{Key: "c", InstanceID: 2},
+}
+
+func pick(t *testing.T, b *Balancer, clientAddr string) string {
+	backend, ok := b.Pick(clientAddr)
+	if !ok {
+		t.Fatalf("Expected a backend")
+	}
+	return backend.Key
+}
+
+func TestBalancer_Empty(t *testing.T) {
+	b := NewBalancer(nil)
+	if _, ok := b.Pick(""); ok {
+		t.Errorf("Expected no backend")
+	}
+}
+
+func TestBalancer_RoundRobin(t *testing.T) {
+	b := NewBalancer(nil)
+	b.Set(testBackends)
+	for i := 0; i < 6; i++ {
+		if key, expected := pick(t, b, ""), testBackends[i%3].Key; key != expected {
+			t.Errorf("Pick %d: expected %s, got %s", i, expected, key)
+		}
+	}
+}
+
+func TestBalancer_LeastConn(t *testing.T) {
+	b := NewBalancer(nil)
+	b.Configure(servicedefinition.LoadBalancer{Policy: servicedefinition.BalanceLeastConn})
+	b.Set(testBackends)
+
+	// one connection to each backend
+	picked := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		picked[pick(t, b, "")] = true
+	}
+	if len(picked) != 3 {
+		t.Fatalf("Expected each backend to be picked once, got %v", picked)
+	}
+
+	// b is the only backend without a connection
+	b.Release("b")
+	for i := 0; i < 3; i++ {
+		if key := pick(t, b, ""); key != "b" {
+			t.Fatalf("Expected b, got %s", key)
+		}
+		b.Release("b")
+	}
+}
+
+func TestBalancer_Weighted(t *testing.T) {
+	b := NewBalancer(nil)
+	b.Configure(servicedefinition.LoadBalancer{
+		Policy:  servicedefinition.BalanceWeighted,
+		Weights: map[int]int{0: 3},
+	})
+	b.Set(testBackends)
+
+	counts := map[string]int{}
+	for i := 0; i < 50; i++ {
+		counts[pick(t, b, "")]++
+	}
+	if counts["a"] != 30 || counts["b"] != 10 || counts["c"] != 10 {
+		t.Errorf("Unexpected distribution %v", counts)
+	}
+}
+
+func TestBalancer_IPHash(t *testing.T) {
+	b := NewBalancer(nil)
+	b.Configure(servicedefinition.LoadBalancer{Policy: servicedefinition.BalanceIPHash})
+	b.Set(testBackends)
+
+	// the same client always gets the same backend, regardless of its port
+	first := pick(t, b, "10.0.0.1:1234")
+	for i := 0; i < 5; i++ {
+		if key := pick(t, b, "10.0.0.1:5678"); key != first {
+			t.Fatalf("Expected %s, got %s", first, key)
+		}
+	}
+
+	// clients of the backends that remain are not moved
+	clients := map[string]string{}
+	for _, addr := range []string{"10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6", "10.0.0.7"} {
+		clients[addr] = pick(t, b, addr)
+	}
+	b.Set(testBackends[:2])
+	for addr, key := range clients {
+		if key == "c" {
+			continue
+		}
+		if moved := pick(t, b, addr); moved != key {
+			t.Errorf("Client %s moved from %s to %s", addr, key, moved)
+		}
+	}
+}
+
+func TestBalancer_Ejection(t *testing.T) {
+	health := NewHealthTracker()
+	b := NewBalancer(health)
+	b.Configure(servicedefinition.LoadBalancer{MaxFails: 2})
+	b.Set(testBackends)
+
+	// a single failure is not enough
+	health.Failed("a")
+	picked := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		picked[pick(t, b, "")] = true
+	}
+	if !picked["a"] {
+		t.Errorf("Expected a to be picked after one failure")
+	}
+
+	health.Failed("a")
+	for i := 0; i < 4; i++ {
+		if key := pick(t, b, ""); key == "a" {
+			t.Fatalf("Expected a to be ejected")
+		}
+	}
+
+	// a success brings it back
+	health.Succeeded("a")
+	picked = map[string]bool{}
+	for i := 0; i < 3; i++ {
+		picked[pick(t, b, "")] = true
+	}
+	if !picked["a"] {
+		t.Errorf("Expected a to be picked after a success")
+	}
+
+	// if every backend is ejected, they are all used
+	for _, backend := range testBackends {
+		health.Failed(backend.Key)
+		health.Failed(backend.Key)
+	}
+	if _, ok := b.Pick(""); !ok {
+		t.Errorf("Expected a backend when all are ejected")
+	}
+}
+
+func TestHealthTracker_Ejected(t *testing.T) {
+	health := NewHealthTracker()
+	health.Failed("a")
+	if health.Ejected("a", 0, 0) {
+		t.Errorf("Expected ejection to be disabled")
+	}
+	if !health.Ejected("a", 1, time.Minute) {
+		t.Errorf("Expected a to be ejected")
+	}
+	if health.Ejected("a", 1, 0) {
+		t.Errorf("Expected a to be back after the timeout")
+	}
+}
+
+// pipeConn returns a connection whose peer reports when it is closed
+func pipeConn() (net.Conn, <-chan struct{}) {
+	conn, peer := net.Pipe()
+	closed := make(chan struct{})
+	go func() {
+		peer.Read(make([]byte, 1))
+		close(closed)
+	}()
+	return conn, closed
+}
+
+func TestDrainer(t *testing.T) {
+	d := NewDrainer()
+
+	connA, closedA := pipeConn()
+	connB, closedB := pipeConn()
+	connC, _ := pipeConn()
+	a := d.Track("a", connA)
+	d.Track("b", connB)
+	c := d.Track("c", connC)
+	if n := d.Open("a"); n != 1 {
+		t.Errorf("Expected 1 open connection, got %d", n)
+	}
+
+	// closing a connection stops tracking it
+	c.Close()
+	if n := d.Open("c"); n != 0 {
+		t.Errorf("Expected no open connections, got %d", n)
+	}
+
+	// without a period, connections are left alone
+	d.Drain("a", 0)
+	// a restored backend is not drained
+	d.Drain("b", 50*time.Millisecond)
+	d.Restore("b")
+
+	d.Drain("a", 50*time.Millisecond)
+	select {
+	case <-closedA:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Connection was not drained")
+	}
+	if n := d.Open("a"); n != 0 {
+		t.Errorf("Expected no open connections, got %d", n)
+	}
+	a.Close()
+
+	select {
+	case <-closedB:
+		t.Errorf("Restored connection was closed")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -19,24 +19,37 @@ import (
 	"github.com/control-center/serviced/logging"
 	"github.com/control-center/serviced/utils"
 
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
 
 var (
 	log = logging.PackageLogger()
+
+	// ErrNoClientCertificate is returned when a connection to a mux that
+	// requires mutual TLS doesn't present a certificate.
+	ErrNoClientCertificate = errors.New("no client certificate")
 )
 
+// TenantAuthorizer returns an error unless the tenant may connect to the
+// container address.
+type TenantAuthorizer func(tenantID, address string) error
+
 // TCPMux is an implementation of tcp muxing RFC 1078.
 type TCPMux struct {
 	listener    net.Listener    // the connection this mux listens on
 	connections chan net.Conn   // stream of accepted connections
 	closing     chan chan error // shutdown noticiation
 	log         *logrus.Entry
+	mu          sync.RWMutex
+	authorize   TenantAuthorizer // keeps tenants apart, nil unless mutual TLS is used
 }
 
 // NewTCPMux creates a new tcp mux with the given listener. If it succees, it
@@ -60,6 +73,21 @@ func NewTCPMux(listener net.Listener) (mux *TCPMux, err error) {
 	return mux, nil
 }
 
+// SetAuthorizer requires every connection to present a certificate, and
+// only lets the tenant on the certificate connect to the container addresses
+// allowed by authorize.  Certificates of hosts may connect anywhere.
+func (mux *TCPMux) SetAuthorizer(authorize TenantAuthorizer) {
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+	mux.authorize = authorize
+}
+
+func (mux *TCPMux) getAuthorizer() TenantAuthorizer {
+	mux.mu.RLock()
+	defer mux.mu.RUnlock()
+	return mux.authorize
+}
+
 func (mux *TCPMux) Close() {
 	mux.log.Debug("Closing TCP multiplexer")
 	close(mux.closing)
@@ -133,14 +161,24 @@ func (mux *TCPMux) loop() {
 // then attempts to set up a connection to the service specified by the
 // line. The service is specified in the form "IP:PORT\n". If the connection
 // to the service is sucessful, all traffic continues to be proxied between
-// two connections.
+// two connections.  If the header asks for udp, the traffic is relayed as
+// datagrams instead.  If the header asks for a session, the connection is
+// kept open and every stream opened on it is proxied the same way.
 func (mux *TCPMux) muxConnection(conn net.Conn) {
 
 	log := mux.log.WithFields(logrus.Fields{
 		"remoteaddr": conn.RemoteAddr(),
 	})
 	// make sure that we don't block indefinitely
-	conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	conn.SetDeadline(time.Now().Add(time.Second * 5))
+
+	identity, err := mux.peerIdentity(conn)
+	if err != nil {
+		log.WithError(err).Warn("Unable to authenticate mux connection. Closing connection")
+		conn.Close()
+		return
+	}
+	conn.SetWriteDeadline(time.Time{})
 
 	// TODO retrieve and validate the identity of the sender
 	addrPacked, _, err := auth.ReadMuxHeader(conn)
@@ -150,16 +188,112 @@ func (mux *TCPMux) muxConnection(conn net.Conn) {
 		return
 	}
 
-	address := utils.UnpackTCPAddressToString(addrPacked)
-
 	// Restore the read deadline
 	conn.SetReadDeadline(time.Time{})
 
+	if auth.IsSessionMuxHeader(addrPacked) {
+		if _, err := conn.Write(sessionAck); err != nil {
+			log.WithError(err).Debug("Unable to accept mux session")
+			conn.Close()
+			return
+		}
+		log.Debug("Accepted mux session")
+		go mux.serveSession(NewSession(conn, false), identity, log)
+		return
+	}
+	mux.proxyTo(conn, addrPacked, identity, log)
+}
+
+// peerIdentity returns who the certificate of the connection was issued to,
+// or nil if it didn't present one and the mux doesn't require it.
+func (mux *TCPMux) peerIdentity(conn net.Conn) (*auth.MuxIdentity, error) {
+	required := mux.getAuthorizer() != nil
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		if required {
+			return nil, ErrNoClientCertificate
+		}
+		return nil, nil
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, err
+	}
+	state := tlsConn.ConnectionState()
+	if len(state.VerifiedChains) == 0 {
+		if required {
+			return nil, ErrNoClientCertificate
+		}
+		return nil, nil
+	}
+	identity, err := auth.GetMuxIdentity(state.PeerCertificates[0])
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// authorized returns an error unless the identity may connect to the
+// container address.
+func (mux *TCPMux) authorized(identity *auth.MuxIdentity, address string) error {
+	authorize := mux.getAuthorizer()
+	if authorize == nil {
+		return nil
+	} else if identity == nil {
+		return ErrNoClientCertificate
+	} else if identity.TenantID == "" {
+		return nil
+	}
+	return authorize(identity.TenantID, address)
+}
+
+// serveSession proxies every stream opened on the session until the session
+// goes away.
+func (mux *TCPMux) serveSession(session *Session, identity *auth.MuxIdentity, log *logrus.Entry) {
+	defer session.Close()
+	for {
+		stream, err := session.Accept()
+		if err != nil {
+			log.Debug("Mux session closed")
+			return
+		}
+		go mux.proxyTo(stream, stream.Address(), identity, log)
+	}
+}
+
+// proxyTo dials the container address requested by the sender and wires the
+// connection up to it.
+func (mux *TCPMux) proxyTo(conn net.Conn, addrPacked []byte, identity *auth.MuxIdentity, log *logrus.Entry) {
+	addrPacked, udp, err := auth.SplitMuxAddress(addrPacked)
+	if err != nil {
+		log.WithError(err).Warn("Unable to read valid mux address. Closing connection")
+		conn.Close()
+		return
+	}
+
+	address := utils.UnpackTCPAddressToString(addrPacked)
+
 	// Dial the requested address
 	log = log.WithFields(logrus.Fields{
-		"remoteaddr":    conn.RemoteAddr(),
 		"containeraddr": address,
+		"udp":           udp,
 	})
+	if err := mux.authorized(identity, address); err != nil {
+		log.WithError(err).Warn("Connection is not allowed to reach the container address. Closing connection")
+		conn.Close()
+		return
+	}
+	if udp {
+		svc, err := net.Dial("udp4", address)
+		if err != nil {
+			log.WithError(err).Debug("Unable to dial container address")
+			conn.Close()
+			return
+		}
+
+		// Relay the framed datagrams until the flow goes idle
+		go RelayDatagrams(NewDatagramConn(conn), svc, DefaultUDPTimeout)
+		return
+	}
 	svc, err := net.Dial("tcp4", address)
 	if err != nil {
 		log.Debug("Unable to dial container address. Perhaps the container is still starting?")
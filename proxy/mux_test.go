@@ -16,6 +16,7 @@
 package proxy
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io"
 	"net"
@@ -161,3 +162,276 @@ func TestTCPMux(t *testing.T) {
 	conn.Close()
 
 }
+
+func TestTCPMux_UDP(t *testing.T) {
+	pub, priv, _ := auth.GenerateRSAKeyPairPEM(nil)
+	auth.LoadMasterKeysFromPEM(pub, priv)
+
+	dpub, priv, _ := auth.GenerateRSAKeyPairPEM(nil)
+	auth.LoadDelegateKeysFromPEM(pub, priv)
+
+	auth.RefreshToken(func() (string, int64, error) {
+		return auth.CreateJWTIdentity("host", "pool", true, true, dpub, time.Duration(365*24*60*60)*time.Second)
+	}, "")
+
+	target, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %s", err)
+	}
+	defer target.Close()
+	go func() {
+		buffer := make([]byte, 4096)
+		for {
+			n, addr, err := target.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			target.WriteTo(buffer[:n], addr)
+		}
+	}()
+
+	muxEndpoint, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("could not create tcpmux endpoint: %s", err)
+	}
+	mux, err := NewTCPMux(muxEndpoint)
+	if err != nil {
+		t.Fatalf("did not expect failure creating TCPMux: %s", err)
+	}
+	defer mux.Close()
+
+	conn := mux.testConnect(t)
+	defer conn.Close()
+	addr, err := utils.PackTCPAddressString(target.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("could not pack address: %s", err)
+	}
+	token, err := auth.AuthTokenNonBlocking()
+	if err != nil {
+		t.Fatalf("could not get token: %s", err)
+	}
+	if err := auth.AddSignedUDPMuxHeader(conn, addr, token); err != nil {
+		t.Fatalf("could not write header: %s", err)
+	}
+
+	datagrams := NewDatagramConn(conn)
+	for _, msg := range []string{"trap", "syslog"} {
+		if _, err := datagrams.Write([]byte(msg)); err != nil {
+			t.Fatalf("could not write: %s", err)
+		}
+		buffer := make([]byte, 4096)
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, err := datagrams.Read(buffer)
+		if err != nil {
+			t.Fatalf("could not read: %s", err)
+		}
+		if returnedValue := string(buffer[:n]); returnedValue != msg {
+			t.Fatalf("got back %+v expected %+v", returnedValue, msg)
+		}
+	}
+}
+
+func TestTCPMux_Session(t *testing.T) {
+	pub, priv, _ := auth.GenerateRSAKeyPairPEM(nil)
+	auth.LoadMasterKeysFromPEM(pub, priv)
+
+	dpub, priv, _ := auth.GenerateRSAKeyPairPEM(nil)
+	auth.LoadDelegateKeysFromPEM(pub, priv)
+
+	auth.RefreshToken(func() (string, int64, error) {
+		return auth.CreateJWTIdentity("host", "pool", true, true, dpub, time.Duration(365*24*60*60)*time.Second)
+	}, "")
+
+	target := newEchoListener(t)
+	defer target.Close()
+
+	muxEndpoint, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("could not create tcpmux endpoint: %s", err)
+	}
+	mux, err := NewTCPMux(muxEndpoint)
+	if err != nil {
+		t.Fatalf("did not expect failure creating TCPMux: %s", err)
+	}
+	defer mux.Close()
+
+	dials := 0
+	pool := NewSessionPool(1, func(address string) (net.Conn, error) {
+		dials++
+		return net.Dial("tcp4", address)
+	})
+	defer pool.Close()
+
+	muxAddr := fmt.Sprintf("127.0.0.1:%s", listenerToPort(muxEndpoint))
+	addr, err := utils.PackTCPAddressString(fmt.Sprintf("127.0.0.1:%s", listenerToPort(target.listener)))
+	if err != nil {
+		t.Fatalf("could not pack address: %s", err)
+	}
+	token, err := auth.AuthTokenNonBlocking()
+	if err != nil {
+		t.Fatalf("could not get token: %s", err)
+	}
+
+	for _, msg := range []string{"hello", "again"} {
+		conn, err := pool.Dial(muxAddr, addr, false, token)
+		if err != nil {
+			t.Fatalf("could not open stream: %s", err)
+		}
+		conn.Write([]byte(msg))
+		buffer := make([]byte, len(msg))
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if _, err := io.ReadFull(conn, buffer); err != nil {
+			t.Fatalf("could not read: %s", err)
+		}
+		if returnedValue := string(buffer); returnedValue != msg {
+			t.Fatalf("got back %+v expected %+v", returnedValue, msg)
+		}
+		conn.Close()
+	}
+	if dials != 1 {
+		t.Fatalf("expected the streams to share a connection, got %d connections", dials)
+	}
+}
+
+func TestTCPMux_SessionFallback(t *testing.T) {
+	pub, priv, _ := auth.GenerateRSAKeyPairPEM(nil)
+	auth.LoadMasterKeysFromPEM(pub, priv)
+
+	dpub, priv, _ := auth.GenerateRSAKeyPairPEM(nil)
+	auth.LoadDelegateKeysFromPEM(pub, priv)
+
+	auth.RefreshToken(func() (string, int64, error) {
+		return auth.CreateJWTIdentity("host", "pool", true, true, dpub, time.Duration(365*24*60*60)*time.Second)
+	}, "")
+
+	// an older mux reads the header and hangs up on an address it can't use
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("could not listen: %s", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			auth.ReadMuxHeader(conn)
+			conn.Close()
+		}
+	}()
+
+	dials := 0
+	pool := NewSessionPool(1, func(address string) (net.Conn, error) {
+		dials++
+		return net.Dial("tcp4", address)
+	})
+	defer pool.Close()
+
+	muxAddr := fmt.Sprintf("127.0.0.1:%s", listenerToPort(listener))
+	token, err := auth.AuthTokenNonBlocking()
+	if err != nil {
+		t.Fatalf("could not get token: %s", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := pool.Dial(muxAddr, []byte("zenoss"), false, token); err != ErrSessionUnsupported {
+			t.Fatalf("expected %s, got %v", ErrSessionUnsupported, err)
+		}
+	}
+	if dials != 1 {
+		t.Fatalf("expected the fallback to be remembered, got %d connections", dials)
+	}
+}
+
+// newMuxCredentials issues a certificate for the identity
+func newMuxCredentials(t *testing.T, identity auth.MuxIdentity) *auth.MuxCredentials {
+	public, private, err := auth.GenerateRSAKeyPairPEM(nil)
+	if err != nil {
+		t.Fatalf("could not create key: %s", err)
+	}
+	cert, _, err := auth.CreateMuxCertificate(identity, []string{"127.0.0.1"}, public, time.Hour)
+	if err != nil {
+		t.Fatalf("could not create certificate: %s", err)
+	}
+	ca, err := auth.MuxCACertificate()
+	if err != nil {
+		t.Fatalf("could not get ca: %s", err)
+	}
+	creds := &auth.MuxCredentials{}
+	if err := creds.Update(cert, private, ca); err != nil {
+		t.Fatalf("could not load credentials: %s", err)
+	}
+	return creds
+}
+
+func TestTCPMux_MutualTLS(t *testing.T) {
+	pub, priv, _ := auth.GenerateRSAKeyPairPEM(nil)
+	auth.LoadMasterKeysFromPEM(pub, priv)
+
+	dpub, priv, _ := auth.GenerateRSAKeyPairPEM(nil)
+	auth.LoadDelegateKeysFromPEM(pub, priv)
+
+	auth.RefreshToken(func() (string, int64, error) {
+		return auth.CreateJWTIdentity("host", "pool", true, true, dpub, time.Duration(365*24*60*60)*time.Second)
+	}, "")
+
+	target := newEchoListener(t)
+	defer target.Close()
+	targetAddr := fmt.Sprintf("127.0.0.1:%s", listenerToPort(target.listener))
+
+	hostCreds := newMuxCredentials(t, auth.MuxIdentity{HostID: "host"})
+	muxEndpoint, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not create tcpmux endpoint: %s", err)
+	}
+	mux, err := NewTCPMux(hostCreds.Listener(muxEndpoint, &tls.Config{}))
+	if err != nil {
+		t.Fatalf("did not expect failure creating TCPMux: %s", err)
+	}
+	defer mux.Close()
+
+	// only tenant a owns the target
+	mux.SetAuthorizer(func(tenantID, address string) error {
+		if tenantID != "a" || address != targetAddr {
+			return fmt.Errorf("tenant %s does not own %s", tenantID, address)
+		}
+		return nil
+	})
+
+	addr, err := utils.PackTCPAddressString(targetAddr)
+	if err != nil {
+		t.Fatalf("could not pack address: %s", err)
+	}
+	token, err := auth.AuthTokenNonBlocking()
+	if err != nil {
+		t.Fatalf("could not get token: %s", err)
+	}
+
+	echoes := func(creds *auth.MuxCredentials) bool {
+		config, err := creds.ClientConfig("127.0.0.1")
+		if err != nil {
+			t.Fatalf("could not get client config: %s", err)
+		}
+		conn, err := tls.Dial("tcp4", muxEndpoint.Addr().String(), config)
+		if err != nil {
+			t.Fatalf("could not connect to mux: %s", err)
+		}
+		defer conn.Close()
+		auth.AddSignedMuxHeader(conn, addr, token)
+		conn.Write([]byte("hello"))
+		buffer := make([]byte, 5)
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, err = io.ReadFull(conn, buffer)
+		return err == nil && string(buffer) == "hello"
+	}
+
+	if !echoes(newMuxCredentials(t, auth.MuxIdentity{HostID: "host", TenantID: "a"})) {
+		t.Fatalf("expected tenant a to reach its container")
+	}
+	if echoes(newMuxCredentials(t, auth.MuxIdentity{HostID: "host", TenantID: "b"})) {
+		t.Fatalf("expected tenant b to be turned away")
+	}
+	if !echoes(newMuxCredentials(t, auth.MuxIdentity{HostID: "other"})) {
+		t.Fatalf("expected a host to reach any container")
+	}
+}
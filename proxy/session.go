@@ -0,0 +1,519 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+/*
+   A session multiplexes many streams over a single authenticated connection
+   to the mux.  Every frame starts with a 9 byte header:
+
+   -------------------------------------------------------
+   | Type (1 byte) | Stream ID (4 bytes) | Length (4 bytes) |
+   -------------------------------------------------------
+
+   open:   the client opens a stream; the payload is the mux address.
+   data:   the payload is stream data.
+   window: the receiver read Length more bytes of the stream; there is no payload.
+   close:  the sender will neither read nor write the stream again.
+
+   Each side may only have initialWindow bytes of a stream in flight before
+   the receiver acknowledges them with a window frame, so a slow reader does
+   not hold up the other streams on the connection.
+*/
+
+const (
+	frameOpen byte = iota
+	frameData
+	frameWindow
+	frameClose
+
+	frameHeaderBytes = 9
+	maxFrameSize     = 32 * 1024
+	maxOpenSize      = 64
+	initialWindow    = 256 * 1024
+	acceptBacklog    = 128
+)
+
+var (
+	// ErrSessionClosed is returned when using a session or a stream whose
+	// connection has gone away.
+	ErrSessionClosed = errors.New("mux session closed")
+
+	// ErrStreamClosed is returned when writing to a stream that was closed.
+	ErrStreamClosed = errors.New("mux stream closed")
+
+	// ErrSessionProtocol is returned when the remote side of a session sends
+	// a frame that doesn't make sense.
+	ErrSessionProtocol = errors.New("mux session protocol error")
+
+	// sessionAck is sent by the mux to accept a session.
+	sessionAck = []byte("MUX2")
+)
+
+// timeoutError is returned when a stream deadline passes.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// Session multiplexes streams over a connection to the mux.  Only the client
+// side of a session opens streams; the mux side accepts them.
+type Session struct {
+	conn    net.Conn
+	client  bool
+	created time.Time
+
+	wmu sync.Mutex // serializes frames written to conn
+
+	mu      sync.Mutex
+	streams map[uint32]*Stream
+	nextID  uint32
+
+	accept chan *Stream
+	closed chan struct{}
+	once   sync.Once
+}
+
+// NewSession starts a session on an established connection.  The client
+// side opens streams, the other side accepts them.
+func NewSession(conn net.Conn, client bool) *Session {
+	s := &Session{
+		conn:    conn,
+		client:  client,
+		created: time.Now(),
+		streams: make(map[uint32]*Stream),
+		nextID:  1,
+		accept:  make(chan *Stream, acceptBacklog),
+		closed:  make(chan struct{}),
+	}
+	go s.recvLoop()
+	return s
+}
+
+// Open starts a new stream to the mux address.
+func (s *Session) Open(address []byte) (*Stream, error) {
+	if !s.client {
+		return nil, ErrSessionProtocol
+	}
+	s.mu.Lock()
+	if s.IsClosed() {
+		s.mu.Unlock()
+		return nil, ErrSessionClosed
+	}
+	stream := newStream(s, s.nextID, address)
+	s.streams[stream.id] = stream
+	s.nextID += 2
+	s.mu.Unlock()
+
+	if err := s.writeFrame(frameOpen, stream.id, uint32(len(address)), address); err != nil {
+		s.remove(stream.id)
+		return nil, err
+	}
+	return stream, nil
+}
+
+// Accept waits for the next stream opened by the client.
+func (s *Session) Accept() (*Stream, error) {
+	select {
+	case stream := <-s.accept:
+		return stream, nil
+	case <-s.closed:
+		return nil, ErrSessionClosed
+	}
+}
+
+// NumStreams returns the number of open streams.
+func (s *Session) NumStreams() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.streams)
+}
+
+// Age returns how long ago the session was started.
+func (s *Session) Age() time.Duration {
+	return time.Since(s.created)
+}
+
+// IsClosed returns true if the session's connection has gone away.
+func (s *Session) IsClosed() bool {
+	select {
+	case <-s.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close closes the connection and every stream on it.
+func (s *Session) Close() error {
+	var err error
+	s.once.Do(func() {
+		err = s.conn.Close()
+		s.mu.Lock()
+		close(s.closed)
+		streams := s.streams
+		s.streams = make(map[uint32]*Stream)
+		s.mu.Unlock()
+		for _, stream := range streams {
+			stream.notify()
+		}
+	})
+	return err
+}
+
+// writeFrame sends a single frame.  Failing to write closes the session,
+// since the other side can no longer make sense of the connection.
+func (s *Session) writeFrame(typ byte, id, length uint32, payload []byte) error {
+	buf := make([]byte, frameHeaderBytes+len(payload))
+	buf[0] = typ
+	binary.BigEndian.PutUint32(buf[1:5], id)
+	binary.BigEndian.PutUint32(buf[5:9], length)
+	copy(buf[frameHeaderBytes:], payload)
+
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	if s.IsClosed() {
+		return ErrSessionClosed
+	}
+	if _, err := s.conn.Write(buf); err != nil {
+		s.Close()
+		return err
+	}
+	return nil
+}
+
+// recvLoop reads frames off the connection until it breaks.
+func (s *Session) recvLoop() {
+	defer s.Close()
+	header := make([]byte, frameHeaderBytes)
+	for {
+		if _, err := io.ReadFull(s.conn, header); err != nil {
+			return
+		}
+		typ := header[0]
+		id := binary.BigEndian.Uint32(header[1:5])
+		length := binary.BigEndian.Uint32(header[5:9])
+		if err := s.handle(typ, id, length); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Session) handle(typ byte, id, length uint32) error {
+	switch typ {
+	case frameOpen:
+		if s.client || length > maxOpenSize {
+			return ErrSessionProtocol
+		}
+		address := make([]byte, length)
+		if _, err := io.ReadFull(s.conn, address); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		if _, ok := s.streams[id]; ok {
+			s.mu.Unlock()
+			return ErrSessionProtocol
+		}
+		stream := newStream(s, id, address)
+		s.streams[id] = stream
+		s.mu.Unlock()
+		select {
+		case s.accept <- stream:
+		default:
+			// nobody is keeping up with the streams, so turn this one away
+			stream.Close()
+		}
+	case frameData:
+		if length > maxFrameSize {
+			return ErrSessionProtocol
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(s.conn, data); err != nil {
+			return err
+		}
+		if stream := s.lookup(id); stream != nil {
+			return stream.receive(data)
+		}
+	case frameWindow:
+		if stream := s.lookup(id); stream != nil {
+			stream.grow(length)
+		}
+	case frameClose:
+		if stream := s.lookup(id); stream != nil {
+			stream.remoteClose()
+		}
+	default:
+		return ErrSessionProtocol
+	}
+	return nil
+}
+
+func (s *Session) lookup(id uint32) *Stream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.streams[id]
+}
+
+func (s *Session) remove(id uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.streams, id)
+}
+
+// Stream is a logical connection carried by a session.
+type Stream struct {
+	id      uint32
+	session *Session
+	address []byte
+
+	mu            sync.Mutex
+	buf           bytes.Buffer
+	window        uint32 // bytes we may still send
+	consumed      uint32 // bytes read since the last window update
+	localClosed   bool
+	remoteClosed  bool
+	readDeadline  time.Time
+	writeDeadline time.Time
+
+	readable chan struct{}
+	writable chan struct{}
+}
+
+func newStream(session *Session, id uint32, address []byte) *Stream {
+	return &Stream{
+		id:       id,
+		session:  session,
+		address:  address,
+		window:   initialWindow,
+		readable: make(chan struct{}, 1),
+		writable: make(chan struct{}, 1),
+	}
+}
+
+// Address returns the mux address the stream was opened to.
+func (s *Stream) Address() []byte {
+	return s.address
+}
+
+// Read reads data sent by the other side of the stream, returning io.EOF
+// once it has closed the stream and everything it sent has been read.
+func (s *Stream) Read(b []byte) (int, error) {
+	for {
+		s.mu.Lock()
+		if s.localClosed {
+			s.mu.Unlock()
+			return 0, ErrStreamClosed
+		}
+		if s.buf.Len() > 0 {
+			n, _ := s.buf.Read(b)
+			s.consumed += uint32(n)
+			var update uint32
+			if s.consumed >= initialWindow/2 && !s.remoteClosed {
+				update, s.consumed = s.consumed, 0
+			}
+			s.mu.Unlock()
+			if update > 0 {
+				s.session.writeFrame(frameWindow, s.id, update, nil)
+			}
+			return n, nil
+		}
+		remoteClosed, deadline := s.remoteClosed, s.readDeadline
+		s.mu.Unlock()
+
+		if remoteClosed {
+			return 0, io.EOF
+		} else if s.session.IsClosed() {
+			return 0, ErrSessionClosed
+		} else if err := s.wait(s.readable, deadline); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Write sends data to the other side of the stream, waiting whenever the
+// other side hasn't caught up with reading.
+func (s *Stream) Write(b []byte) (int, error) {
+	written := 0
+	for len(b) > 0 {
+		s.mu.Lock()
+		if s.localClosed || s.remoteClosed {
+			s.mu.Unlock()
+			return written, ErrStreamClosed
+		}
+		n := uint32(len(b))
+		if n > maxFrameSize {
+			n = maxFrameSize
+		}
+		if n > s.window {
+			n = s.window
+		}
+		s.window -= n
+		deadline := s.writeDeadline
+		s.mu.Unlock()
+
+		if s.session.IsClosed() {
+			return written, ErrSessionClosed
+		} else if n == 0 {
+			if err := s.wait(s.writable, deadline); err != nil {
+				return written, err
+			}
+			continue
+		}
+		if err := s.session.writeFrame(frameData, s.id, n, b[:n]); err != nil {
+			return written, err
+		}
+		written += int(n)
+		b = b[n:]
+	}
+	return written, nil
+}
+
+// Close closes the stream in both directions.  Anything the other side
+// sends afterwards is dropped.
+func (s *Stream) Close() error {
+	s.mu.Lock()
+	if s.localClosed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.localClosed = true
+	s.buf.Reset()
+	remoteClosed := s.remoteClosed
+	s.mu.Unlock()
+
+	s.notify()
+	if remoteClosed {
+		s.session.remove(s.id)
+	}
+	if err := s.session.writeFrame(frameClose, s.id, 0, nil); err != ErrSessionClosed {
+		return err
+	}
+	return nil
+}
+
+// LocalAddr returns the local address of the session's connection.
+func (s *Stream) LocalAddr() net.Addr {
+	return s.session.conn.LocalAddr()
+}
+
+// RemoteAddr returns the remote address of the session's connection.
+func (s *Stream) RemoteAddr() net.Addr {
+	return s.session.conn.RemoteAddr()
+}
+
+// SetDeadline sets the read and write deadlines of the stream.
+func (s *Stream) SetDeadline(t time.Time) error {
+	s.SetReadDeadline(t)
+	return s.SetWriteDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline of the stream.
+func (s *Stream) SetReadDeadline(t time.Time) error {
+	s.mu.Lock()
+	s.readDeadline = t
+	s.mu.Unlock()
+	s.notify()
+	return nil
+}
+
+// SetWriteDeadline sets the write deadline of the stream.
+func (s *Stream) SetWriteDeadline(t time.Time) error {
+	s.mu.Lock()
+	s.writeDeadline = t
+	s.mu.Unlock()
+	s.notify()
+	return nil
+}
+
+// receive buffers data sent by the other side.
+func (s *Stream) receive(data []byte) error {
+	s.mu.Lock()
+	if s.localClosed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.buf.Write(data)
+	overflow := s.buf.Len() > initialWindow
+	s.mu.Unlock()
+	if overflow {
+		return ErrSessionProtocol
+	}
+	s.signal(s.readable)
+	return nil
+}
+
+// grow lets the stream send more data.
+func (s *Stream) grow(n uint32) {
+	s.mu.Lock()
+	s.window += n
+	s.mu.Unlock()
+	s.signal(s.writable)
+}
+
+// remoteClose records that the other side has closed the stream.
+func (s *Stream) remoteClose() {
+	s.mu.Lock()
+	s.remoteClosed = true
+	localClosed := s.localClosed
+	s.mu.Unlock()
+	if localClosed {
+		s.session.remove(s.id)
+	}
+	s.notify()
+}
+
+// notify wakes up anything waiting to read or write the stream.
+func (s *Stream) notify() {
+	s.signal(s.readable)
+	s.signal(s.writable)
+}
+
+func (s *Stream) signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// wait blocks until the stream is signalled, the session closes or the
+// deadline passes.
+func (s *Stream) wait(ch chan struct{}, deadline time.Time) error {
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		d := deadline.Sub(time.Now())
+		if d <= 0 {
+			return timeoutError{}
+		}
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-ch:
+	case <-s.session.closed:
+	case <-timeout:
+		return timeoutError{}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+)
+
+// sessionPair returns both sides of a session over an in-memory connection
+func sessionPair() (*Session, *Session) {
+	a, b := net.Pipe()
+	return NewSession(a, true), NewSession(b, false)
+}
+
+func TestSession_Streams(t *testing.T) {
+	client, server := sessionPair()
+	defer client.Close()
+	defer server.Close()
+
+	// echo every stream back to its sender
+	go func() {
+		for {
+			stream, err := server.Accept()
+			if err != nil {
+				return
+			}
+			go func(s *Stream) {
+				s.Write(s.Address())
+				io.Copy(s, s)
+				s.Close()
+			}(stream)
+		}
+	}()
+
+	for _, address := range []string{"first!", "second"} {
+		stream, err := client.Open([]byte(address))
+		if err != nil {
+			t.Fatalf("Could not open stream: %s", err)
+		}
+		if _, err := stream.Write([]byte("hello")); err != nil {
+			t.Fatalf("Could not write: %s", err)
+		}
+		buf := make([]byte, 11)
+		stream.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if _, err := io.ReadFull(stream, buf); err != nil {
+			t.Fatalf("Could not read: %s", err)
+		}
+		if string(buf) != address+"hello" {
+			t.Errorf("Got %q, expected %q", buf, address+"hello")
+		}
+	}
+	if n := client.NumStreams(); n != 2 {
+		t.Errorf("Expected 2 streams, got %d", n)
+	}
+}
+
+func TestSession_FlowControl(t *testing.T) {
+	client, server := sessionPair()
+	defer client.Close()
+	defer server.Close()
+
+	// more than fits in a window, so the writer has to wait for the reader
+	data := bytes.Repeat([]byte("0123456789abcdef"), 4*initialWindow/16)
+	stream, err := client.Open([]byte("target"))
+	if err != nil {
+		t.Fatalf("Could not open stream: %s", err)
+	}
+	go func() {
+		stream.Write(data)
+		stream.Close()
+	}()
+
+	remote, err := server.Accept()
+	if err != nil {
+		t.Fatalf("Could not accept stream: %s", err)
+	}
+
+	// a stalled stream doesn't hold up the others
+	other, err := client.Open([]byte("other!"))
+	if err != nil {
+		t.Fatalf("Could not open stream: %s", err)
+	}
+	if _, err := other.Write([]byte("ping")); err != nil {
+		t.Fatalf("Could not write: %s", err)
+	}
+	otherRemote, err := server.Accept()
+	if err != nil {
+		t.Fatalf("Could not accept stream: %s", err)
+	}
+	buf := make([]byte, 4)
+	otherRemote.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.ReadFull(otherRemote, buf); err != nil || string(buf) != "ping" {
+		t.Fatalf("Got %q (%v), expected ping", buf, err)
+	}
+
+	received, err := ioutil.ReadAll(remote)
+	if err != nil {
+		t.Fatalf("Could not read: %s", err)
+	}
+	if !bytes.Equal(received, data) {
+		t.Errorf("Got %d bytes, expected %d", len(received), len(data))
+	}
+	remote.Close()
+}
+
+func TestSession_Close(t *testing.T) {
+	client, server := sessionPair()
+	defer server.Close()
+
+	stream, err := client.Open([]byte("target"))
+	if err != nil {
+		t.Fatalf("Could not open stream: %s", err)
+	}
+	remote, err := server.Accept()
+	if err != nil {
+		t.Fatalf("Could not accept stream: %s", err)
+	}
+
+	// reads time out
+	remote.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	if _, err := remote.Read(make([]byte, 1)); err == nil {
+		t.Fatalf("Expected a timeout")
+	} else if nerr, ok := err.(net.Error); !ok || !nerr.Timeout() {
+		t.Fatalf("Expected a timeout, got %s", err)
+	}
+	remote.SetReadDeadline(time.Time{})
+
+	// closing the stream ends the other side
+	stream.Write([]byte("bye"))
+	stream.Close()
+	received, err := ioutil.ReadAll(remote)
+	if err != nil || string(received) != "bye" {
+		t.Fatalf("Got %q (%v), expected bye", received, err)
+	}
+	if _, err := remote.Write([]byte("hello?")); err != ErrStreamClosed {
+		t.Errorf("Expected %s, got %v", ErrStreamClosed, err)
+	}
+	remote.Close()
+
+	// closing the session ends everything on it
+	stream, err = client.Open([]byte("target"))
+	if err != nil {
+		t.Fatalf("Could not open stream: %s", err)
+	}
+	client.Close()
+	if _, err := stream.Read(make([]byte, 1)); err != ErrSessionClosed {
+		t.Errorf("Expected %s, got %v", ErrSessionClosed, err)
+	}
+	if _, err := client.Open([]byte("target")); err != ErrSessionClosed {
+		t.Errorf("Expected %s, got %v", ErrSessionClosed, err)
+	}
+	select {
+	case <-server.closed:
+	case <-time.After(5 * time.Second):
+		t.Errorf("Expected the server side to close")
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/control-center/serviced/auth"
+)
+
+const (
+	// sessionAckTimeout is how long to wait for the mux to accept a session.
+	sessionAckTimeout = 5 * time.Second
+
+	// sessionFallbackPeriod is how long to remember that a mux doesn't
+	// accept sessions before asking it again.
+	sessionFallbackPeriod = time.Minute
+
+	// sessionMaxAge is how long a session takes new streams.  The session
+	// was authenticated with the token that was current when it started, so
+	// it is retired once that token is no longer fresh.
+	sessionMaxAge = 30 * time.Minute
+
+	// streamsPerSession is how many streams share a session before another
+	// one is started, as long as the pool has room.
+	streamsPerSession = 64
+)
+
+// ErrSessionUnsupported is returned when the mux doesn't accept sessions, so
+// the caller should fall back to a connection per stream.
+var ErrSessionUnsupported = errors.New("mux does not support sessions")
+
+// SessionPool keeps long-lived sessions to remote muxes and opens streams on
+// them.
+type SessionPool struct {
+	size     int
+	dial     func(address string) (net.Conn, error)
+	mu       sync.Mutex
+	sessions map[string][]*Session
+	fallback map[string]time.Time
+}
+
+// NewSessionPool keeps up to size sessions per remote mux, dialing each one
+// with dial.
+func NewSessionPool(size int, dial func(address string) (net.Conn, error)) *SessionPool {
+	if size < 1 {
+		size = 1
+	}
+	return &SessionPool{
+		size:     size,
+		dial:     dial,
+		sessions: make(map[string][]*Session),
+		fallback: make(map[string]time.Time),
+	}
+}
+
+// Dial opens a stream through the mux at muxAddr to the packed container
+// address.  It returns ErrSessionUnsupported if the mux only takes a
+// connection per stream.
+func (p *SessionPool) Dial(muxAddr string, address []byte, udp bool, token string) (net.Conn, error) {
+	payload, err := auth.MuxAddressPayload(address, udp)
+	if err != nil {
+		return nil, err
+	}
+	session, err := p.get(muxAddr, token)
+	if err != nil {
+		return nil, err
+	}
+	return session.Open(payload)
+}
+
+// Close closes all of the sessions in the pool.
+func (p *SessionPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for muxAddr, sessions := range p.sessions {
+		for _, session := range sessions {
+			session.Close()
+		}
+		delete(p.sessions, muxAddr)
+	}
+}
+
+// get returns the least busy session to the mux, starting a new one if they
+// are all busy and there is room.
+func (p *SessionPool) get(muxAddr, token string) (*Session, error) {
+	p.mu.Lock()
+	if until, ok := p.fallback[muxAddr]; ok {
+		if time.Now().Before(until) {
+			p.mu.Unlock()
+			return nil, ErrSessionUnsupported
+		}
+		delete(p.fallback, muxAddr)
+	}
+	var (
+		best  *Session
+		count int
+	)
+	sessions := p.sessions[muxAddr][:0]
+	for _, session := range p.sessions[muxAddr] {
+		if session.IsClosed() {
+			continue
+		}
+		if session.Age() > sessionMaxAge {
+			// retired sessions are closed once they are idle
+			if session.NumStreams() == 0 {
+				session.Close()
+				continue
+			}
+		} else if n := session.NumStreams(); best == nil || n < count {
+			best, count = session, n
+		}
+		sessions = append(sessions, session)
+	}
+	p.sessions[muxAddr] = sessions
+	if best != nil && (count < streamsPerSession || len(sessions) >= p.size) {
+		p.mu.Unlock()
+		return best, nil
+	}
+	p.mu.Unlock()
+
+	session, err := p.connect(muxAddr, token)
+	if err == ErrSessionUnsupported {
+		p.mu.Lock()
+		p.fallback[muxAddr] = time.Now().Add(sessionFallbackPeriod)
+		p.mu.Unlock()
+		log.WithField("muxaddress", muxAddr).Debug("Mux does not accept sessions; using a connection per stream")
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.sessions[muxAddr] = append(p.sessions[muxAddr], session)
+	p.mu.Unlock()
+	return session, nil
+}
+
+// connect dials the mux and asks it for a session.
+func (p *SessionPool) connect(muxAddr, token string) (*Session, error) {
+	conn, err := p.dial(muxAddr)
+	if err != nil {
+		return nil, err
+	}
+	if err := auth.AddSignedSessionMuxHeader(conn, token); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// a mux that doesn't know about sessions hangs up
+	conn.SetReadDeadline(time.Now().Add(sessionAckTimeout))
+	ack := make([]byte, len(sessionAck))
+	if _, err := io.ReadFull(conn, ack); err != nil || !bytes.Equal(ack, sessionAck) {
+		conn.Close()
+		return nil, ErrSessionUnsupported
+	}
+	conn.SetReadDeadline(time.Time{})
+	return NewSession(conn, true), nil
+}
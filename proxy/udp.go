@@ -0,0 +1,345 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+const (
+	// DefaultUDPTimeout is how long a udp flow may be idle before its
+	// session is closed.
+	DefaultUDPTimeout = 60 * time.Second
+
+	// maxDatagramSize is the largest datagram that fits in a frame
+	maxDatagramSize = 65535
+
+	// sessionQueue is the number of datagrams that may wait for a session's
+	// backend before they are dropped
+	sessionQueue = 64
+)
+
+// ErrDatagramTooLarge is returned when a datagram does not fit in a frame
+var ErrDatagramTooLarge = errors.New("datagram is too large")
+
+// WriteDatagram writes a datagram to a stream, prefixed by its length.
+func WriteDatagram(w io.Writer, b []byte) error {
+	if len(b) > maxDatagramSize {
+		return ErrDatagramTooLarge
+	}
+	frame := make([]byte, 2+len(b))
+	binary.BigEndian.PutUint16(frame, uint16(len(b)))
+	copy(frame[2:], b)
+	_, err := w.Write(frame)
+	return err
+}
+
+// ReadDatagram reads a datagram written by WriteDatagram into buf, truncating
+// it if buf is too small.
+func ReadDatagram(r io.Reader, buf []byte) (int, error) {
+	var size uint16
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return 0, err
+	}
+	n := int(size)
+	if n > len(buf) {
+		n = len(buf)
+	}
+	if _, err := io.ReadFull(r, buf[:n]); err != nil {
+		return 0, err
+	}
+	if _, err := io.CopyN(ioutil.Discard, r, int64(size)-int64(n)); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// datagramConn carries datagrams over a stream connection, which is how udp
+// flows cross the mux.
+type datagramConn struct {
+	net.Conn
+	rmu sync.Mutex
+	wmu sync.Mutex
+}
+
+// NewDatagramConn wraps a stream connection, so that every Write sends one
+// datagram and every Read receives one.
+func NewDatagramConn(stream net.Conn) net.Conn {
+	return &datagramConn{Conn: stream}
+}
+
+func (c *datagramConn) Read(b []byte) (int, error) {
+	c.rmu.Lock()
+	defer c.rmu.Unlock()
+	return ReadDatagram(c.Conn, b)
+}
+
+func (c *datagramConn) Write(b []byte) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if err := WriteDatagram(c.Conn, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// RelayDatagrams copies datagrams between a datagram connection to a peer and
+// a udp connection to a backend, until either side fails or no datagram has
+// crossed in either direction for the timeout.  Both connections are closed
+// when it returns.
+func RelayDatagrams(peer, backend net.Conn, timeout time.Duration) {
+	last := time.Now().UnixNano()
+	touch := func() { atomic.StoreInt64(&last, time.Now().UnixNano()) }
+	idle := func() time.Duration { return time.Since(time.Unix(0, atomic.LoadInt64(&last))) }
+
+	done := make(chan struct{}, 2)
+	go func() {
+		defer func() { done <- struct{}{} }()
+		buf := make([]byte, maxDatagramSize)
+		for {
+			n, err := peer.Read(buf)
+			if err != nil {
+				return
+			}
+			touch()
+			if _, err := backend.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		defer func() { done <- struct{}{} }()
+		buf := make([]byte, maxDatagramSize)
+		for {
+			// only the udp side gets a deadline, since a timeout in the
+			// middle of a frame would break the stream.
+			backend.SetReadDeadline(time.Now().Add(timeout))
+			n, err := backend.Read(buf)
+			if err != nil {
+				if err, ok := err.(net.Error); ok && err.Timeout() && idle() < timeout {
+					continue
+				}
+				return
+			}
+			touch()
+			if _, err := peer.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+	}()
+	<-done
+	peer.Close()
+	backend.Close()
+	<-done
+}
+
+// UDPDialer opens the backend connection for a new flow from a client.
+// release, if it is not nil, is called when the flow's session closes.
+type UDPDialer func(client net.Addr) (backend net.Conn, release func(), err error)
+
+// udpSession is a flow from a client address to its backend
+type udpSession struct {
+	client net.Addr
+	queue  chan []byte
+	last   int64 // unix nanoseconds of the last datagram in either direction
+}
+
+func (s *udpSession) touch() {
+	atomic.StoreInt64(&s.last, time.Now().UnixNano())
+}
+
+func (s *udpSession) idle() time.Duration {
+	return time.Since(time.Unix(0, atomic.LoadInt64(&s.last)))
+}
+
+// UDPProxy forwards the datagrams received on a packet connection to
+// backends.  Each client address gets a session with its own backend
+// connection, which carries the replies back to the client, and which is
+// closed once the flow has been idle for the timeout.
+type UDPProxy struct {
+	conn     net.PacketConn
+	dial     UDPDialer
+	timeout  time.Duration
+	mu       sync.Mutex
+	sessions map[string]*udpSession
+	closing  chan struct{}
+	once     sync.Once
+	wg       sync.WaitGroup
+	log      *logrus.Entry
+}
+
+// NewUDPProxy creates a new udp proxy that owns the packet connection.  Call
+// Serve to start forwarding.
+func NewUDPProxy(conn net.PacketConn, dial UDPDialer, timeout time.Duration) *UDPProxy {
+	if timeout <= 0 {
+		timeout = DefaultUDPTimeout
+	}
+	return &UDPProxy{
+		conn:     conn,
+		dial:     dial,
+		timeout:  timeout,
+		sessions: make(map[string]*udpSession),
+		closing:  make(chan struct{}),
+		log: log.WithFields(logrus.Fields{
+			"address": conn.LocalAddr(),
+		}),
+	}
+}
+
+// Serve reads datagrams from the packet connection until it is closed
+func (p *UDPProxy) Serve() {
+	p.log.Debug("Started UDP proxy")
+	defer p.log.Debug("Stopped UDP proxy")
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, client, err := p.conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-p.closing:
+			default:
+				p.log.WithError(err).Error("Could not read from UDP port")
+			}
+			return
+		}
+		datagram := make([]byte, n)
+		copy(datagram, buf[:n])
+		p.forward(client, datagram)
+	}
+}
+
+// forward queues a datagram on the client's session, starting a session if
+// the client doesn't have one.  The datagram is dropped if the session is
+// backed up.
+func (p *UDPProxy) forward(client net.Addr, datagram []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, ok := p.sessions[client.String()]
+	if !ok {
+		select {
+		case <-p.closing:
+			return
+		default:
+		}
+		s = &udpSession{client: client, queue: make(chan []byte, sessionQueue)}
+		s.touch()
+		p.sessions[client.String()] = s
+		p.wg.Add(1)
+		go p.runSession(s)
+	}
+
+	select {
+	case s.queue <- datagram:
+	default:
+		p.log.WithField("client", client).Debug("Dropped datagram for a busy UDP session")
+	}
+}
+
+func (p *UDPProxy) runSession(s *udpSession) {
+	var release func()
+	defer p.wg.Done()
+	defer func() {
+		if release != nil {
+			release()
+		}
+	}()
+	defer p.remove(s)
+
+	logger := p.log.WithField("client", s.client)
+	backend, release, err := p.dial(s.client)
+	if err != nil {
+		logger.WithError(err).Warn("Could not open UDP session")
+		return
+	}
+	defer backend.Close()
+	logger.WithField("backend", backend.RemoteAddr()).Debug("Opened UDP session")
+	defer logger.Debug("Closed UDP session")
+
+	// send the replies back to the client
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, maxDatagramSize)
+		for {
+			n, err := backend.Read(buf)
+			if err != nil {
+				return
+			}
+			s.touch()
+			if _, err := p.conn.WriteTo(buf[:n], s.client); err != nil {
+				return
+			}
+		}
+	}()
+
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case datagram := <-s.queue:
+			s.touch()
+			if _, err := backend.Write(datagram); err != nil {
+				logger.WithError(err).Debug("Could not write to UDP backend")
+				return
+			}
+		case <-timer.C:
+			if idle := s.idle(); idle < p.timeout {
+				timer.Reset(p.timeout - idle)
+				continue
+			}
+			return
+		case <-done:
+			return
+		case <-p.closing:
+			return
+		}
+	}
+}
+
+func (p *UDPProxy) remove(s *udpSession) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.sessions[s.client.String()] == s {
+		delete(p.sessions, s.client.String())
+	}
+}
+
+// Sessions returns the number of open sessions
+func (p *UDPProxy) Sessions() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.sessions)
+}
+
+// Close stops the proxy and closes all of its sessions
+func (p *UDPProxy) Close() error {
+	var err error
+	p.once.Do(func() {
+		p.mu.Lock()
+		close(p.closing)
+		p.mu.Unlock()
+		err = p.conn.Close()
+		p.wg.Wait()
+	})
+	return err
+}
@@ -0,0 +1,160 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package proxy
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+// udpEcho starts a udp server that echoes datagrams back to their sender
+func udpEcho(t *testing.T) net.PacketConn {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen: %s", err)
+	}
+	go func() {
+		buf := make([]byte, maxDatagramSize)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			conn.WriteTo(buf[:n], addr)
+		}
+	}()
+	return conn
+}
+
+// roundTrip sends a datagram over conn and waits for the reply
+func roundTrip(t *testing.T, conn net.Conn, msg string) string {
+	if _, err := conn.Write([]byte(msg)); err != nil {
+		t.Fatalf("Could not write: %s", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Could not read: %s", err)
+	}
+	return string(buf[:n])
+}
+
+func TestDatagramFraming(t *testing.T) {
+	var stream bytes.Buffer
+	for _, msg := range []string{"first", "", "third"} {
+		if err := WriteDatagram(&stream, []byte(msg)); err != nil {
+			t.Fatalf("Could not write %q: %s", msg, err)
+		}
+	}
+	if err := WriteDatagram(&stream, make([]byte, maxDatagramSize+1)); err != ErrDatagramTooLarge {
+		t.Errorf("Expected %s, got %v", ErrDatagramTooLarge, err)
+	}
+
+	buf := make([]byte, 3)
+	for _, expected := range []string{"fir", "", "thi"} {
+		n, err := ReadDatagram(&stream, buf)
+		if err != nil {
+			t.Fatalf("Could not read: %s", err)
+		}
+		if actual := string(buf[:n]); actual != expected {
+			t.Errorf("Expected %q, got %q", expected, actual)
+		}
+	}
+	if stream.Len() != 0 {
+		t.Errorf("Expected the stream to be drained, %d bytes left", stream.Len())
+	}
+}
+
+func TestRelayDatagrams(t *testing.T) {
+	echo := udpEcho(t)
+	defer echo.Close()
+
+	backend, err := net.Dial("udp4", echo.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Could not dial the echo server: %s", err)
+	}
+	peer, remote := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		RelayDatagrams(NewDatagramConn(remote), backend, 100*time.Millisecond)
+		close(done)
+	}()
+
+	conn := NewDatagramConn(peer)
+	if reply := roundTrip(t, conn, "hello"); reply != "hello" {
+		t.Errorf("Expected hello, got %q", reply)
+	}
+
+	// the relay closes once the flow is idle
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Relay did not time out")
+	}
+}
+
+func TestUDPProxy(t *testing.T) {
+	echo := udpEcho(t)
+	defer echo.Close()
+
+	listener, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen: %s", err)
+	}
+	released := make(chan string, 2)
+	p := NewUDPProxy(listener, func(client net.Addr) (net.Conn, func(), error) {
+		backend, err := net.Dial("udp4", echo.LocalAddr().String())
+		return backend, func() { released <- client.String() }, err
+	}, 200*time.Millisecond)
+	go p.Serve()
+	defer p.Close()
+
+	clients := make([]net.Conn, 2)
+	for i := range clients {
+		clients[i], err = net.Dial("udp4", listener.LocalAddr().String())
+		if err != nil {
+			t.Fatalf("Could not dial the proxy: %s", err)
+		}
+		defer clients[i].Close()
+	}
+	if reply := roundTrip(t, clients[0], "one"); reply != "one" {
+		t.Errorf("Expected one, got %q", reply)
+	}
+	if reply := roundTrip(t, clients[1], "two"); reply != "two" {
+		t.Errorf("Expected two, got %q", reply)
+	}
+	if reply := roundTrip(t, clients[0], "three"); reply != "three" {
+		t.Errorf("Expected three, got %q", reply)
+	}
+	if n := p.Sessions(); n != 2 {
+		t.Errorf("Expected 2 sessions, got %d", n)
+	}
+
+	// idle sessions are closed and released
+	for i := 0; i < 2; i++ {
+		select {
+		case <-released:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Session was not released")
+		}
+	}
+	if n := p.Sessions(); n != 0 {
+		t.Errorf("Expected no sessions, got %d", n)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package master
+
+import (
+	"github.com/control-center/serviced/domain/auditlog"
+)
+
+// GetAuditEntries returns a page of entries from the audit trail, newest
+// first
+func (c *Client) GetAuditEntries(query auditlog.Query) ([]auditlog.Entry, error) {
+	entries := []auditlog.Entry{}
+	if err := c.call("GetAuditEntries", query, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// VerifyAuditTrail checks that the hash chain of the audit trail is unbroken
+func (c *Client) VerifyAuditTrail() (*auditlog.Verification, error) {
+	verification := &auditlog.Verification{}
+	if err := c.call("VerifyAuditTrail", empty, verification); err != nil {
+		return nil, err
+	}
+	return verification, nil
+}
+
+// RecordShellSession writes the start or end of a shell, attach or run
+// session to the audit log.  A recording of an ended session is kept on the
+// master for replay.
+func (c *Client) RecordShellSession(session auditlog.Session, recording *auditlog.Recording) error {
+	return c.call("RecordShellSession", ShellSessionRequest{Session: session, Recording: recording}, nil)
+}
+
+// GetShellSessionRecording returns the recording of a session
+func (c *Client) GetShellSessionRecording(sessionID string) (*auditlog.Recording, error) {
+	recording := &auditlog.Recording{}
+	if err := c.call("GetShellSessionRecording", sessionID, recording); err != nil {
+		return nil, err
+	}
+	return recording, nil
+}
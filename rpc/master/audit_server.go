@@ -0,0 +1,60 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package master
+
+import (
+	"github.com/control-center/serviced/domain/auditlog"
+)
+
+// GetAuditEntries returns a page of entries from the audit trail
+func (s *Server) GetAuditEntries(query auditlog.Query, entries *[]auditlog.Entry) error {
+	result, err := s.f.GetAuditEntries(s.context(), query)
+	if err != nil {
+		return err
+	}
+	*entries = result
+	return nil
+}
+
+// VerifyAuditTrail checks the hash chain of the audit trail
+func (s *Server) VerifyAuditTrail(empty struct{}, verification *auditlog.Verification) error {
+	result, err := s.f.VerifyAuditTrail(s.context())
+	if err != nil {
+		return err
+	}
+	*verification = *result
+	return nil
+}
+
+// ShellSessionRequest is the start or end of a shell, attach or run session,
+// with its recording if one was kept
+type ShellSessionRequest struct {
+	Session   auditlog.Session
+	Recording *auditlog.Recording
+}
+
+// RecordShellSession writes the start or end of a session to the audit log
+func (s *Server) RecordShellSession(request ShellSessionRequest, _ *struct{}) error {
+	return s.f.RecordShellSession(s.context(), request.Session, request.Recording)
+}
+
+// GetShellSessionRecording returns the recording of a session
+func (s *Server) GetShellSessionRecording(sessionID string, recording *auditlog.Recording) error {
+	result, err := s.f.GetShellSessionRecording(s.context(), sessionID)
+	if err != nil {
+		return err
+	}
+	*recording = *result
+	return nil
+}
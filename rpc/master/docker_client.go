@@ -13,6 +13,12 @@
 
 package master
 
+import (
+	"github.com/control-center/serviced/dfs"
+	"github.com/control-center/serviced/dfs/registry"
+	zkdocker "github.com/control-center/serviced/zzk/docker"
+)
+
 // ResetRegistry pulls latest from the running docker registry and updates the
 // index.
 func (c *Client) ResetRegistry() error {
@@ -40,3 +46,44 @@ func (c *Client) DockerOverride(newImage, oldImage string) error {
 	}
 	return c.call("DockerOverride", req, new(int))
 }
+
+// CollectRegistryGarbage removes images from the docker registry that are not
+// used by any service or snapshot.  If dryRun is set, nothing is removed.
+func (c *Client) CollectRegistryGarbage(dryRun bool) (*dfs.RegistryGCReport, error) {
+	report := &dfs.RegistryGCReport{}
+	if err := c.call("CollectRegistryGarbage", dryRun, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetRegistryReplicationStatus returns the replication status of every image
+// in the docker registry index.
+func (c *Client) GetRegistryReplicationStatus() ([]registry.ReplicaStatus, error) {
+	statuses := []registry.ReplicaStatus{}
+	if err := c.call("GetRegistryReplicationStatus", struct{}{}, &statuses); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// ReplicateRegistry pushes every image in the docker registry index to the
+// secondary registry.
+func (c *Client) ReplicateRegistry() error {
+	return c.call("ReplicateRegistry", struct{}{}, new(int))
+}
+
+// GetPrePullStatus returns the progress of image pre-pulls on every host.
+func (c *Client) GetPrePullStatus() ([]zkdocker.PrePull, error) {
+	reqs := []zkdocker.PrePull{}
+	if err := c.call("GetPrePullStatus", struct{}{}, &reqs); err != nil {
+		return nil, err
+	}
+	return reqs, nil
+}
+
+// PrePullImages asks every host to pull the images of the services in its
+// pool.
+func (c *Client) PrePullImages() error {
+	return c.call("PrePullImages", struct{}{}, new(int))
+}
@@ -13,6 +13,12 @@
 
 package master
 
+import (
+	"github.com/control-center/serviced/dfs"
+	"github.com/control-center/serviced/dfs/registry"
+	zkdocker "github.com/control-center/serviced/zzk/docker"
+)
+
 // UpgradeDockerRequest are options for upgrading/migrating the docker registry.
 type UpgradeDockerRequest struct {
 	Endpoint string
@@ -47,3 +53,48 @@ func (s *Server) UpgradeRegistry(req UpgradeDockerRequest, reply *int) error {
 func (s *Server) DockerOverride(overrideReq DockerOverrideRequest, _ *int) error {
 	return s.f.DockerOverride(s.context(), overrideReq.NewImage, overrideReq.OldImage)
 }
+
+// CollectRegistryGarbage removes images from the docker registry that are not
+// used by any service or snapshot, and then frees the registry storage used
+// by their layers.
+func (s *Server) CollectRegistryGarbage(dryRun bool, report *dfs.RegistryGCReport) error {
+	result, err := s.f.CollectRegistryGarbage(s.context(), dryRun)
+	if err != nil {
+		return err
+	}
+	*report = *result
+	return nil
+}
+
+// GetRegistryReplicationStatus returns the replication status of every image
+// in the docker registry index.
+func (s *Server) GetRegistryReplicationStatus(req struct{}, reply *[]registry.ReplicaStatus) error {
+	statuses, err := s.f.GetRegistryReplicationStatus(s.context())
+	if err != nil {
+		return err
+	}
+	*reply = statuses
+	return nil
+}
+
+// ReplicateRegistry pushes every image in the docker registry index to the
+// secondary registry.
+func (s *Server) ReplicateRegistry(req struct{}, reply *int) error {
+	return s.f.ReplicateRegistry(s.context())
+}
+
+// GetPrePullStatus returns the progress of image pre-pulls on every host.
+func (s *Server) GetPrePullStatus(req struct{}, reply *[]zkdocker.PrePull) error {
+	reqs, err := s.f.GetPrePullStatus(s.context())
+	if err != nil {
+		return err
+	}
+	*reply = reqs
+	return nil
+}
+
+// PrePullImages asks every host to pull the images of the services in its
+// pool.
+func (s *Server) PrePullImages(req struct{}, reply *int) error {
+	return s.f.PrePullAllImages(s.context())
+}
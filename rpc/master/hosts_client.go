@@ -102,6 +102,27 @@ func (c *Client) AuthenticateHost(hostID string) (string, int64, error) {
 	return response.Token, response.Expires, nil
 }
 
+// GetMuxCertificate gets a certificate for the public key that authenticates
+// the host, or one of its tenants if tenantID is set, to the mux.
+func (c *Client) GetMuxCertificate(hostID, tenantID string, publicKeyPEM []byte) ([]byte, []byte, int64, error) {
+	req := MuxCertificateRequest{
+		HostID:    hostID,
+		TenantID:  tenantID,
+		PublicKey: publicKeyPEM,
+		Timestamp: time.Now().UTC().Unix(),
+	}
+	sig, err := auth.SignAsDelegate(req.toMessage())
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	req.Signature = sig
+	var response MuxCertificateResponse
+	if err := c.call("GetMuxCertificate", req, &response); err != nil {
+		return nil, nil, 0, err
+	}
+	return response.Certificate, response.CA, response.Expires, nil
+}
+
 func (c *Client) GetHostPublicKey(hostID string) ([]byte, error) {
 	response := []byte{}
 	err := c.call("GetHostPublicKey", hostID, &response)
@@ -119,11 +119,16 @@ func (req HostAuthenticationRequest) toMessage() []byte {
 }
 
 func (req HostAuthenticationRequest) valid(publicKeyPEM []byte) error {
+	return req.validMessage(publicKeyPEM, req.toMessage())
+}
+
+// validMessage checks that the host signed the message recently.
+func (req HostAuthenticationRequest) validMessage(publicKeyPEM, message []byte) error {
 	verifier, err := auth.RSAVerifierFromPEM(publicKeyPEM)
 	if err != nil {
 		return err
 	}
-	if err := verifier.Verify(req.toMessage(), req.Signature); err != nil {
+	if err := verifier.Verify(message, req.Signature); err != nil {
 		return err
 	}
 	logger := plog.WithField("hostid", req.HostID)
@@ -176,6 +181,68 @@ func (s *Server) AuthenticateHost(req HostAuthenticationRequest, resp *HostAuthe
 	return nil
 }
 
+type MuxCertificateRequest struct {
+	HostID    string
+	TenantID  string
+	PublicKey []byte
+	Timestamp int64
+	Signature []byte
+}
+
+type MuxCertificateResponse struct {
+	Certificate []byte
+	CA          []byte
+	Expires     int64
+}
+
+func (req MuxCertificateRequest) toMessage() []byte {
+	return []byte(fmt.Sprintf("%s:%s:%d:%s", req.HostID, req.TenantID, req.Timestamp, req.PublicKey))
+}
+
+// GetMuxCertificate issues a certificate that authenticates the host, or one
+// of the tenants whose containers it runs, to the mux of other hosts.
+func (s *Server) GetMuxCertificate(req MuxCertificateRequest, resp *MuxCertificateResponse) error {
+	keypem, err := s.f.GetHostKey(s.context(), req.HostID)
+	if err != nil {
+		return err
+	}
+	authReq := HostAuthenticationRequest{req.HostID, req.Timestamp, req.Signature}
+	if err := authReq.validMessage(keypem, req.toMessage()); err != nil {
+		return err
+	}
+
+	host, err := s.f.GetHost(s.context(), req.HostID)
+	if err != nil {
+		return err
+	}
+	if host == nil {
+		return facade.ErrHostDoesNotExist
+	}
+	if req.TenantID != "" {
+		if tenantID, err := s.f.GetTenantID(s.context(), req.TenantID); err != nil {
+			return err
+		} else if tenantID != req.TenantID {
+			return fmt.Errorf("service %s is not a tenant", req.TenantID)
+		}
+	}
+
+	identity := auth.MuxIdentity{HostID: host.ID, TenantID: req.TenantID}
+	ips := []string{host.IPAddr}
+	for _, ip := range host.IPs {
+		ips = append(ips, ip.IPAddress)
+	}
+	cert, expires, err := auth.CreateMuxCertificate(identity, ips, req.PublicKey, s.expiration)
+	if err != nil {
+		return err
+	}
+	ca, err := auth.MuxCACertificate()
+	if err != nil {
+		return err
+	}
+	*resp = MuxCertificateResponse{cert, ca, expires}
+	return nil
+}
+
 // Return host's public key
 func (s *Server) GetHostPublicKey(hostID string, key *[]byte) error {
 	publicKey, err := s.f.GetHostKey(s.context(), hostID)
@@ -16,7 +16,11 @@ package master
 import (
 	"time"
 
+	"github.com/control-center/serviced/dfs"
+	"github.com/control-center/serviced/dfs/registry"
 	"github.com/control-center/serviced/domain/addressassignment"
+	"github.com/control-center/serviced/domain/auditlog"
+	"github.com/control-center/serviced/domain/certificate"
 	"github.com/control-center/serviced/domain/applicationendpoint"
 	"github.com/control-center/serviced/domain/host"
 	"github.com/control-center/serviced/domain/pool"
@@ -26,7 +30,9 @@ import (
 	"github.com/control-center/serviced/domain/user"
 	"github.com/control-center/serviced/health"
 	"github.com/control-center/serviced/isvcs"
+	"github.com/control-center/serviced/slo"
 	"github.com/control-center/serviced/volume"
+	zkdocker "github.com/control-center/serviced/zzk/docker"
 )
 
 // The RPC interface is the API for a serviced master.
@@ -66,6 +72,10 @@ type ClientInterface interface {
 	// Authenticate a host and receive an identity token and expiration
 	AuthenticateHost(hostID string) (string, int64, error)
 
+	// GetMuxCertificate gets a certificate that authenticates the host, or
+	// one of its tenants, to the mux
+	GetMuxCertificate(hostID, tenantID string, publicKeyPEM []byte) ([]byte, []byte, int64, error)
+
 	// Get hostID's public key
 	GetHostPublicKey(hostID string) ([]byte, error)
 
@@ -96,6 +106,9 @@ type ClientInterface interface {
 	// GetPoolIPs returns a all IPs in a ResourcePool.
 	GetPoolIPs(poolID string) (*pool.PoolIPs, error)
 
+	// GetPoolMetricSinks returns the metric sinks of a ResourcePool
+	GetPoolMetricSinks(poolID string) (pool.MetricSinks, error)
+
 	// AddVirtualIP adds a VirtualIP to a specific pool
 	AddVirtualIP(requestVirtualIP pool.VirtualIP) error
 
@@ -126,6 +139,15 @@ type ClientInterface interface {
 	// ClearEmergency will set EmergencyShutdown to false on the service and all child services
 	ClearEmergency(serviceID string) (int, error)
 
+	// GetServiceSLOs reports each service level objective of a service
+	// against its target
+	GetServiceSLOs(serviceID string) ([]slo.Status, error)
+
+	// GetResourceRecommendations recommends new commitments and limits for
+	// the services of a tenant, or of all tenants, from their usage since the
+	// given time
+	GetResourceRecommendations(tenantID string, since time.Time) ([]service.ResourceRecommendation, error)
+
 	//--------------------------------------------------------------------------
 	// Service Instance Management Functions
 
@@ -169,6 +191,13 @@ type ClientInterface interface {
 	// GetVolumeStatus gets status information for the given volume or nil
 	GetVolumeStatus() (*volume.Statuses, error)
 
+	// SetStorageQuota sets the storage quota for a tenant
+	SetStorageQuota(tenantID string, quota volume.StorageQuota) error
+
+	// GetStorageQuotaStatus reports the storage used by each tenant against
+	// its quota
+	GetStorageQuotaStatus() ([]volume.TenantQuotaStatus, error)
+
 	//--------------------------------------------------------------------------
 	// Endpoint Management Functions
 
@@ -192,15 +221,34 @@ type ClientInterface interface {
 	// DockerOverride replaces an image in the docker registry with a new image
 	DockerOverride(newImage, oldImage string) error
 
+	// CollectRegistryGarbage removes images from the docker registry that
+	// are not used by any service or snapshot.
+	CollectRegistryGarbage(dryRun bool) (*dfs.RegistryGCReport, error)
+
+	// GetRegistryReplicationStatus returns the replication status of every
+	// image in the docker registry index.
+	GetRegistryReplicationStatus() ([]registry.ReplicaStatus, error)
+
+	// ReplicateRegistry pushes every image in the docker registry index to
+	// the secondary registry.
+	ReplicateRegistry() error
+
+	// GetPrePullStatus returns the progress of image pre-pulls on every host.
+	GetPrePullStatus() ([]zkdocker.PrePull, error)
+
+	// PrePullImages asks every host to pull the images of the services in
+	// its pool.
+	PrePullImages() error
+
 	//--------------------------------------------------------------------------
 	// Public Endpoint Management Functions
-	AddPublicEndpointPort(serviceid, endpointName, portAddr string, usetls bool, protocol string, isEnabled bool, restart bool) (*servicedefinition.Port, error)
+	AddPublicEndpointPort(serviceid, endpointName, portAddr string, usetls bool, protocol string, isEnabled bool, access servicedefinition.AccessRules, restart bool) (*servicedefinition.Port, error)
 
 	RemovePublicEndpointPort(serviceid, endpointName, portAddr string) error
 
 	EnablePublicEndpointPort(serviceid, endpointName, portAddr string, isEnabled bool) error
 
-	AddPublicEndpointVHost(serviceid, endpointName, vhost string, isEnabled, restart bool) (*servicedefinition.VHost, error)
+	AddPublicEndpointVHost(serviceid, endpointName, vhost string, isEnabled bool, access servicedefinition.AccessRules, restart bool) (*servicedefinition.VHost, error)
 
 	RemovePublicEndpointVHost(serviceid, endpointName, vhost string) error
 
@@ -208,6 +256,22 @@ type ClientInterface interface {
 
 	GetAllPublicEndpoints() ([]service.PublicEndpoint, error)
 
+	// SetPublicEndpointCertificate sets the TLS certificate of a vhost or
+	// port public endpoint.
+	SetPublicEndpointCertificate(serviceid, endpointName string, kind certificate.Kind, name string, certPEM, keyPEM []byte) (*certificate.Certificate, error)
+
+	// RemovePublicEndpointCertificate removes the TLS certificate of a vhost
+	// or port public endpoint.
+	RemovePublicEndpointCertificate(serviceid, endpointName string, kind certificate.Kind, name string) error
+
+	// GetPublicEndpointCertificates returns the certificates of all public
+	// endpoints.
+	GetPublicEndpointCertificates() ([]certificate.Certificate, error)
+
+	// EnablePublicEndpointACME has the TLS certificate of a vhost public
+	// endpoint obtained and renewed by ACME.
+	EnablePublicEndpointACME(serviceid, endpointName, vhost string, domains []string) (*certificate.Certificate, error)
+
 	//--------------------------------------------------------------------------
 	// User Management Functions
 
@@ -217,6 +281,32 @@ type ClientInterface interface {
 	// Validate the credentials of the specified user
 	ValidateCredentials(user user.User) (bool, error)
 
+	//--------------------------------------------------------------------------
+	// Log Management Functions
+
+	// EnforceLogRetention removes the log messages in logstash that have
+	// outlived the retention of their tenant and type.
+	EnforceLogRetention(dryRun bool) (*isvcs.LogRetentionReport, error)
+
+	//--------------------------------------------------------------------------
+	// Audit Functions
+
+	// GetAuditEntries returns a page of entries from the audit trail, newest
+	// first
+	GetAuditEntries(query auditlog.Query) ([]auditlog.Entry, error)
+
+	// VerifyAuditTrail checks that the hash chain of the audit trail is
+	// unbroken
+	VerifyAuditTrail() (*auditlog.Verification, error)
+
+	// RecordShellSession writes the start or end of a shell, attach or run
+	// session to the audit log.  A recording of an ended session is kept on
+	// the master for replay.
+	RecordShellSession(session auditlog.Session, recording *auditlog.Recording) error
+
+	// GetShellSessionRecording returns the recording of a session
+	GetShellSessionRecording(sessionID string) (*auditlog.Recording, error)
+
 	//--------------------------------------------------------------------------
 	// Healthcheck Management Functions
 
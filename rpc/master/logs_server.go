@@ -0,0 +1,33 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package master
+
+import (
+	"github.com/control-center/serviced/isvcs"
+)
+
+// EnforceLogRetention removes the log messages in logstash that have
+// outlived the retention of their tenant and type.
+func (s *Server) EnforceLogRetention(dryRun bool, report *isvcs.LogRetentionReport) error {
+	policy, err := s.f.GetLogRetentionPolicy(s.context())
+	if err != nil {
+		return err
+	}
+	result, err := isvcs.EnforceLogRetention(*policy, dryRun)
+	if err != nil {
+		return err
+	}
+	*report = *result
+	return nil
+}
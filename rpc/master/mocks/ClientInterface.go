@@ -1,6 +1,8 @@
 package mocks
 
 import applicationendpoint "github.com/control-center/serviced/domain/applicationendpoint"
+import auditlog "github.com/control-center/serviced/domain/auditlog"
+import certificate "github.com/control-center/serviced/domain/certificate"
 import health "github.com/control-center/serviced/health"
 import host "github.com/control-center/serviced/domain/host"
 import isvcs "github.com/control-center/serviced/isvcs"
@@ -10,10 +12,14 @@ import pool "github.com/control-center/serviced/domain/pool"
 import service "github.com/control-center/serviced/domain/service"
 import servicedefinition "github.com/control-center/serviced/domain/servicedefinition"
 import servicetemplate "github.com/control-center/serviced/domain/servicetemplate"
+import slo "github.com/control-center/serviced/slo"
 import time "time"
 import user "github.com/control-center/serviced/domain/user"
 import volume "github.com/control-center/serviced/volume"
 import addressassignment "github.com/control-center/serviced/domain/addressassignment"
+import dfs "github.com/control-center/serviced/dfs"
+import registry "github.com/control-center/serviced/dfs/registry"
+import zkdocker "github.com/control-center/serviced/zzk/docker"
 
 // ClientInterface is an autogenerated mock type for the ClientInterface type
 type ClientInterface struct {
@@ -66,13 +72,13 @@ func (_m *ClientInterface) AddHostPrivate(h host.Host) ([]byte, error) {
 	return r0, r1
 }
 
-// AddPublicEndpointPort provides a mock function with given fields: serviceid, endpointName, portAddr, usetls, protocol, isEnabled, restart
-func (_m *ClientInterface) AddPublicEndpointPort(serviceid string, endpointName string, portAddr string, usetls bool, protocol string, isEnabled bool, restart bool) (*servicedefinition.Port, error) {
-	ret := _m.Called(serviceid, endpointName, portAddr, usetls, protocol, isEnabled, restart)
+// AddPublicEndpointPort provides a mock function with given fields: serviceid, endpointName, portAddr, usetls, protocol, isEnabled, access, restart
+func (_m *ClientInterface) AddPublicEndpointPort(serviceid string, endpointName string, portAddr string, usetls bool, protocol string, isEnabled bool, access servicedefinition.AccessRules, restart bool) (*servicedefinition.Port, error) {
+	ret := _m.Called(serviceid, endpointName, portAddr, usetls, protocol, isEnabled, access, restart)
 
 	var r0 *servicedefinition.Port
-	if rf, ok := ret.Get(0).(func(string, string, string, bool, string, bool, bool) *servicedefinition.Port); ok {
-		r0 = rf(serviceid, endpointName, portAddr, usetls, protocol, isEnabled, restart)
+	if rf, ok := ret.Get(0).(func(string, string, string, bool, string, bool, servicedefinition.AccessRules, bool) *servicedefinition.Port); ok {
+		r0 = rf(serviceid, endpointName, portAddr, usetls, protocol, isEnabled, access, restart)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*servicedefinition.Port)
@@ -80,8 +86,8 @@ func (_m *ClientInterface) AddPublicEndpointPort(serviceid string, endpointName
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string, string, bool, string, bool, bool) error); ok {
-		r1 = rf(serviceid, endpointName, portAddr, usetls, protocol, isEnabled, restart)
+	if rf, ok := ret.Get(1).(func(string, string, string, bool, string, bool, servicedefinition.AccessRules, bool) error); ok {
+		r1 = rf(serviceid, endpointName, portAddr, usetls, protocol, isEnabled, access, restart)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -89,13 +95,13 @@ func (_m *ClientInterface) AddPublicEndpointPort(serviceid string, endpointName
 	return r0, r1
 }
 
-// AddPublicEndpointVHost provides a mock function with given fields: serviceid, endpointName, vhost, isEnabled, restart
-func (_m *ClientInterface) AddPublicEndpointVHost(serviceid string, endpointName string, vhost string, isEnabled bool, restart bool) (*servicedefinition.VHost, error) {
-	ret := _m.Called(serviceid, endpointName, vhost, isEnabled, restart)
+// AddPublicEndpointVHost provides a mock function with given fields: serviceid, endpointName, vhost, isEnabled, access, restart
+func (_m *ClientInterface) AddPublicEndpointVHost(serviceid string, endpointName string, vhost string, isEnabled bool, access servicedefinition.AccessRules, restart bool) (*servicedefinition.VHost, error) {
+	ret := _m.Called(serviceid, endpointName, vhost, isEnabled, access, restart)
 
 	var r0 *servicedefinition.VHost
-	if rf, ok := ret.Get(0).(func(string, string, string, bool, bool) *servicedefinition.VHost); ok {
-		r0 = rf(serviceid, endpointName, vhost, isEnabled, restart)
+	if rf, ok := ret.Get(0).(func(string, string, string, bool, servicedefinition.AccessRules, bool) *servicedefinition.VHost); ok {
+		r0 = rf(serviceid, endpointName, vhost, isEnabled, access, restart)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*servicedefinition.VHost)
@@ -103,8 +109,8 @@ func (_m *ClientInterface) AddPublicEndpointVHost(serviceid string, endpointName
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string, string, bool, bool) error); ok {
-		r1 = rf(serviceid, endpointName, vhost, isEnabled, restart)
+	if rf, ok := ret.Get(1).(func(string, string, string, bool, servicedefinition.AccessRules, bool) error); ok {
+		r1 = rf(serviceid, endpointName, vhost, isEnabled, access, restart)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -210,6 +216,52 @@ func (_m *ClientInterface) ClearEmergency(serviceID string) (int, error) {
 	return r0, r1
 }
 
+// GetServiceSLOs provides a mock function with given fields: serviceID
+func (_m *ClientInterface) GetServiceSLOs(serviceID string) ([]slo.Status, error) {
+	ret := _m.Called(serviceID)
+
+	var r0 []slo.Status
+	if rf, ok := ret.Get(0).(func(string) []slo.Status); ok {
+		r0 = rf(serviceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]slo.Status)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(serviceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetResourceRecommendations provides a mock function with given fields: tenantID, since
+func (_m *ClientInterface) GetResourceRecommendations(tenantID string, since time.Time) ([]service.ResourceRecommendation, error) {
+	ret := _m.Called(tenantID, since)
+
+	var r0 []service.ResourceRecommendation
+	if rf, ok := ret.Get(0).(func(string, time.Time) []service.ResourceRecommendation); ok {
+		r0 = rf(tenantID, since)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]service.ResourceRecommendation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, time.Time) error); ok {
+		r1 = rf(tenantID, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Close provides a mock function with given fields:
 func (_m *ClientInterface) Close() error {
 	ret := _m.Called()
@@ -224,6 +276,29 @@ func (_m *ClientInterface) Close() error {
 	return r0
 }
 
+// CollectRegistryGarbage provides a mock function with given fields: dryRun
+func (_m *ClientInterface) CollectRegistryGarbage(dryRun bool) (*dfs.RegistryGCReport, error) {
+	ret := _m.Called(dryRun)
+
+	var r0 *dfs.RegistryGCReport
+	if rf, ok := ret.Get(0).(func(bool) *dfs.RegistryGCReport); ok {
+		r0 = rf(dryRun)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dfs.RegistryGCReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(bool) error); ok {
+		r1 = rf(dryRun)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DebugDisableMetrics provides a mock function with given fields:
 func (_m *ClientInterface) DebugDisableMetrics() (string, error) {
 	ret := _m.Called()
@@ -331,6 +406,112 @@ func (_m *ClientInterface) EnablePublicEndpointVHost(serviceid string, endpointN
 	return r0
 }
 
+// EnforceLogRetention provides a mock function with given fields: dryRun
+func (_m *ClientInterface) EnforceLogRetention(dryRun bool) (*isvcs.LogRetentionReport, error) {
+	ret := _m.Called(dryRun)
+
+	var r0 *isvcs.LogRetentionReport
+	if rf, ok := ret.Get(0).(func(bool) *isvcs.LogRetentionReport); ok {
+		r0 = rf(dryRun)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*isvcs.LogRetentionReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(bool) error); ok {
+		r1 = rf(dryRun)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAuditEntries provides a mock function with given fields: query
+func (_m *ClientInterface) GetAuditEntries(query auditlog.Query) ([]auditlog.Entry, error) {
+	ret := _m.Called(query)
+
+	var r0 []auditlog.Entry
+	if rf, ok := ret.Get(0).(func(auditlog.Query) []auditlog.Entry); ok {
+		r0 = rf(query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]auditlog.Entry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(auditlog.Query) error); ok {
+		r1 = rf(query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifyAuditTrail provides a mock function with given fields:
+func (_m *ClientInterface) VerifyAuditTrail() (*auditlog.Verification, error) {
+	ret := _m.Called()
+
+	var r0 *auditlog.Verification
+	if rf, ok := ret.Get(0).(func() *auditlog.Verification); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*auditlog.Verification)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordShellSession provides a mock function with given fields: session, recording
+func (_m *ClientInterface) RecordShellSession(session auditlog.Session, recording *auditlog.Recording) error {
+	ret := _m.Called(session, recording)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(auditlog.Session, *auditlog.Recording) error); ok {
+		r0 = rf(session, recording)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetShellSessionRecording provides a mock function with given fields: sessionID
+func (_m *ClientInterface) GetShellSessionRecording(sessionID string) (*auditlog.Recording, error) {
+	ret := _m.Called(sessionID)
+
+	var r0 *auditlog.Recording
+	if rf, ok := ret.Get(0).(func(string) *auditlog.Recording); ok {
+		r0 = rf(sessionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*auditlog.Recording)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(sessionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindHostsInPool provides a mock function with given fields: poolID
 func (_m *ClientInterface) FindHostsInPool(poolID string) ([]host.Host, error) {
 	ret := _m.Called(poolID)
@@ -483,6 +664,45 @@ func (_m *ClientInterface) GetHost(hostID string) (*host.Host, error) {
 	return r0, r1
 }
 
+// GetMuxCertificate provides a mock function with given fields: hostID, tenantID, publicKeyPEM
+func (_m *ClientInterface) GetMuxCertificate(hostID string, tenantID string, publicKeyPEM []byte) ([]byte, []byte, int64, error) {
+	ret := _m.Called(hostID, tenantID, publicKeyPEM)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(string, string, []byte) []byte); ok {
+		r0 = rf(hostID, tenantID, publicKeyPEM)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 []byte
+	if rf, ok := ret.Get(1).(func(string, string, []byte) []byte); ok {
+		r1 = rf(hostID, tenantID, publicKeyPEM)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]byte)
+		}
+	}
+
+	var r2 int64
+	if rf, ok := ret.Get(2).(func(string, string, []byte) int64); ok {
+		r2 = rf(hostID, tenantID, publicKeyPEM)
+	} else {
+		r2 = ret.Get(2).(int64)
+	}
+
+	var r3 error
+	if rf, ok := ret.Get(3).(func(string, string, []byte) error); ok {
+		r3 = rf(hostID, tenantID, publicKeyPEM)
+	} else {
+		r3 = ret.Error(3)
+	}
+
+	return r0, r1, r2, r3
+}
+
 // GetHostPublicKey provides a mock function with given fields: hostID
 func (_m *ClientInterface) GetHostPublicKey(hostID string) ([]byte, error) {
 	ret := _m.Called(hostID)
@@ -575,6 +795,52 @@ func (_m *ClientInterface) GetPoolIPs(poolID string) (*pool.PoolIPs, error) {
 	return r0, r1
 }
 
+// GetPublicEndpointCertificates provides a mock function with given fields:
+func (_m *ClientInterface) GetPublicEndpointCertificates() ([]certificate.Certificate, error) {
+	ret := _m.Called()
+
+	var r0 []certificate.Certificate
+	if rf, ok := ret.Get(0).(func() []certificate.Certificate); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]certificate.Certificate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRegistryReplicationStatus provides a mock function with given fields:
+func (_m *ClientInterface) GetRegistryReplicationStatus() ([]registry.ReplicaStatus, error) {
+	ret := _m.Called()
+
+	var r0 []registry.ReplicaStatus
+	if rf, ok := ret.Get(0).(func() []registry.ReplicaStatus); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]registry.ReplicaStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetResourcePool provides a mock function with given fields: poolID
 func (_m *ClientInterface) GetResourcePool(poolID string) (*pool.ResourcePool, error) {
 	ret := _m.Called(poolID)
@@ -598,6 +864,29 @@ func (_m *ClientInterface) GetResourcePool(poolID string) (*pool.ResourcePool, e
 	return r0, r1
 }
 
+// GetPoolMetricSinks provides a mock function with given fields: poolID
+func (_m *ClientInterface) GetPoolMetricSinks(poolID string) (pool.MetricSinks, error) {
+	ret := _m.Called(poolID)
+
+	var r0 pool.MetricSinks
+	if rf, ok := ret.Get(0).(func(string) pool.MetricSinks); ok {
+		r0 = rf(poolID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(pool.MetricSinks)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(poolID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetResourcePools provides a mock function with given fields:
 func (_m *ClientInterface) GetResourcePools() ([]pool.ResourcePool, error) {
 	ret := _m.Called()
@@ -759,6 +1048,29 @@ func (_m *ClientInterface) GetServicesHealth() (map[string]map[int]map[string]he
 	return r0, r1
 }
 
+// GetStorageQuotaStatus provides a mock function with given fields:
+func (_m *ClientInterface) GetStorageQuotaStatus() ([]volume.TenantQuotaStatus, error) {
+	ret := _m.Called()
+
+	var r0 []volume.TenantQuotaStatus
+	if rf, ok := ret.Get(0).(func() []volume.TenantQuotaStatus); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]volume.TenantQuotaStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetSystemUser provides a mock function with given fields:
 func (_m *ClientInterface) GetSystemUser() (user.User, error) {
 	ret := _m.Called()
@@ -884,6 +1196,20 @@ func (_m *ClientInterface) RemoveHost(hostID string) error {
 	return r0
 }
 
+// RemovePublicEndpointCertificate provides a mock function with given fields: serviceid, endpointName, kind, name
+func (_m *ClientInterface) RemovePublicEndpointCertificate(serviceid string, endpointName string, kind certificate.Kind, name string) error {
+	ret := _m.Called(serviceid, endpointName, kind, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, certificate.Kind, string) error); ok {
+		r0 = rf(serviceid, endpointName, kind, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // RemovePublicEndpointPort provides a mock function with given fields: serviceid, endpointName, portAddr
 func (_m *ClientInterface) RemovePublicEndpointPort(serviceid string, endpointName string, portAddr string) error {
 	ret := _m.Called(serviceid, endpointName, portAddr)
@@ -954,6 +1280,20 @@ func (_m *ClientInterface) RemoveVirtualIP(requestVirtualIP pool.VirtualIP) erro
 	return r0
 }
 
+// ReplicateRegistry provides a mock function with given fields:
+func (_m *ClientInterface) ReplicateRegistry() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ReportHealthStatus provides a mock function with given fields: key, value, expires
 func (_m *ClientInterface) ReportHealthStatus(key health.HealthStatusKey, value health.HealthStatus, expires time.Duration) error {
 	ret := _m.Called(key, value, expires)
@@ -1077,6 +1417,43 @@ func (_m *ClientInterface) ServiceUse(serviceID string, imageID string, registry
 	return r0, r1
 }
 
+// SetPublicEndpointCertificate provides a mock function with given fields: serviceid, endpointName, kind, name, certPEM, keyPEM
+func (_m *ClientInterface) SetPublicEndpointCertificate(serviceid string, endpointName string, kind certificate.Kind, name string, certPEM []byte, keyPEM []byte) (*certificate.Certificate, error) {
+	ret := _m.Called(serviceid, endpointName, kind, name, certPEM, keyPEM)
+
+	var r0 *certificate.Certificate
+	if rf, ok := ret.Get(0).(func(string, string, certificate.Kind, string, []byte, []byte) *certificate.Certificate); ok {
+		r0 = rf(serviceid, endpointName, kind, name, certPEM, keyPEM)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*certificate.Certificate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, certificate.Kind, string, []byte, []byte) error); ok {
+		r1 = rf(serviceid, endpointName, kind, name, certPEM, keyPEM)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetStorageQuota provides a mock function with given fields: tenantID, quota
+func (_m *ClientInterface) SetStorageQuota(tenantID string, quota volume.StorageQuota) error {
+	ret := _m.Called(tenantID, quota)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, volume.StorageQuota) error); ok {
+		r0 = rf(tenantID, quota)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // StopServiceInstance provides a mock function with given fields: serviceID, instanceID
 func (_m *ClientInterface) StopServiceInstance(serviceID string, instanceID int) error {
 	ret := _m.Called(serviceID, instanceID)
@@ -1207,3 +1584,63 @@ func (_m *ClientInterface) SetIPs(assignmentRequest addressassignment.Assignment
 }
 
 var _ master.ClientInterface = (*ClientInterface)(nil)
+
+// GetPrePullStatus provides a mock function with given fields:
+func (_m *ClientInterface) GetPrePullStatus() ([]zkdocker.PrePull, error) {
+	ret := _m.Called()
+
+	var r0 []zkdocker.PrePull
+	if rf, ok := ret.Get(0).(func() []zkdocker.PrePull); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]zkdocker.PrePull)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PrePullImages provides a mock function with given fields:
+func (_m *ClientInterface) PrePullImages() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnablePublicEndpointACME provides a mock function with given fields: serviceid, endpointName, vhost, domains
+func (_m *ClientInterface) EnablePublicEndpointACME(serviceid string, endpointName string, vhost string, domains []string) (*certificate.Certificate, error) {
+	ret := _m.Called(serviceid, endpointName, vhost, domains)
+
+	var r0 *certificate.Certificate
+	if rf, ok := ret.Get(0).(func(string, string, string, []string) *certificate.Certificate); ok {
+		r0 = rf(serviceid, endpointName, vhost, domains)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*certificate.Certificate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string, []string) error); ok {
+		r1 = rf(serviceid, endpointName, vhost, domains)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -45,6 +45,15 @@ func (c *Client) UpdateResourcePool(pool pool.ResourcePool) error {
 	return c.call("UpdateResourcePool", pool, nil)
 }
 
+// GetPoolMetricSinks returns the metric sinks of a ResourcePool
+func (c *Client) GetPoolMetricSinks(poolID string) (pool.MetricSinks, error) {
+	response := pool.MetricSinks{}
+	if err := c.call("GetPoolMetricSinks", poolID, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
 //RemoveResourcePool removes a ResourcePool
 func (c *Client) RemoveResourcePool(poolID string) error {
 	return c.call("RemoveResourcePool", poolID, nil)
@@ -53,6 +53,19 @@ func (s *Server) GetResourcePool(poolID string, reply *pool.ResourcePool) error
 	return nil
 }
 
+// GetPoolMetricSinks returns the metric sinks of the pool
+func (s *Server) GetPoolMetricSinks(poolID string, reply *pool.MetricSinks) error {
+	response, err := s.f.GetResourcePool(s.context(), poolID)
+	if err != nil {
+		return err
+	}
+	if response == nil {
+		return errors.New("pool not found")
+	}
+	*reply = response.MetricSinks
+	return nil
+}
+
 // RemoveResourcePool removes the pool
 func (s *Server) RemoveResourcePool(poolID string, _ *struct{}) error {
 	return s.f.RemoveResourcePool(s.context(), poolID)
@@ -14,13 +14,14 @@
 package master
 
 import (
+	"github.com/control-center/serviced/domain/certificate"
 	"github.com/control-center/serviced/domain/service"
 	"github.com/control-center/serviced/domain/servicedefinition"
 )
 
 // Adds a port public endpoint to a service.
 func (c *Client) AddPublicEndpointPort(serviceid, endpointName, portAddr string, usetls bool,
-	protocol string, isEnabled bool, restart bool) (*servicedefinition.Port, error) {
+	protocol string, isEnabled bool, access servicedefinition.AccessRules, restart bool) (*servicedefinition.Port, error) {
 	request := &PublicEndpointRequest{
 		Serviceid:    serviceid,
 		EndpointName: endpointName,
@@ -28,6 +29,7 @@ func (c *Client) AddPublicEndpointPort(serviceid, endpointName, portAddr string,
 		UseTLS:       usetls,
 		Protocol:     protocol,
 		IsEnabled:    isEnabled,
+		Access:       access,
 		Restart:      restart,
 	}
 	var result servicedefinition.Port
@@ -57,13 +59,14 @@ func (c *Client) EnablePublicEndpointPort(serviceid, endpointName, portAddr stri
 }
 
 // Adds a vhost public endpoint to a service.
-func (c *Client) AddPublicEndpointVHost(serviceid, endpointName, vhost string, isEnabled,
-	restart bool) (*servicedefinition.VHost, error) {
+func (c *Client) AddPublicEndpointVHost(serviceid, endpointName, vhost string, isEnabled bool,
+	access servicedefinition.AccessRules, restart bool) (*servicedefinition.VHost, error) {
 	request := &PublicEndpointRequest{
 		Serviceid:    serviceid,
 		EndpointName: endpointName,
 		Name:         vhost,
 		IsEnabled:    isEnabled,
+		Access:       access,
 		Restart:      restart,
 	}
 	var result servicedefinition.VHost
@@ -100,3 +103,54 @@ func (c *Client) GetAllPublicEndpoints() ([]service.PublicEndpoint, error) {
 	}
 	return response, nil
 }
+
+// Sets the TLS certificate of a vhost or port public endpoint.
+func (c *Client) SetPublicEndpointCertificate(serviceid, endpointName string, kind certificate.Kind, name string,
+	certPEM, keyPEM []byte) (*certificate.Certificate, error) {
+	request := &PublicEndpointCertificateRequest{
+		Serviceid:    serviceid,
+		EndpointName: endpointName,
+		Kind:         kind,
+		Name:         name,
+		CertPEM:      certPEM,
+		KeyPEM:       keyPEM,
+	}
+	var result certificate.Certificate
+	err := c.call("SetPublicEndpointCertificate", request, &result)
+	return &result, err
+}
+
+// Removes the TLS certificate of a vhost or port public endpoint.
+func (c *Client) RemovePublicEndpointCertificate(serviceid, endpointName string, kind certificate.Kind, name string) error {
+	request := &PublicEndpointCertificateRequest{
+		Serviceid:    serviceid,
+		EndpointName: endpointName,
+		Kind:         kind,
+		Name:         name,
+	}
+	return c.call("RemovePublicEndpointCertificate", request, nil)
+}
+
+// Has the TLS certificate of a vhost public endpoint obtained from ACME.
+func (c *Client) EnablePublicEndpointACME(serviceid, endpointName, vhost string, domains []string) (*certificate.Certificate, error) {
+	request := &PublicEndpointCertificateRequest{
+		Serviceid:    serviceid,
+		EndpointName: endpointName,
+		Kind:         certificate.VHost,
+		Name:         vhost,
+		ACMEDomains:  domains,
+	}
+	var result certificate.Certificate
+	err := c.call("EnablePublicEndpointACME", request, &result)
+	return &result, err
+}
+
+// GetPublicEndpointCertificates returns the certificates of all public
+// endpoints.
+func (c *Client) GetPublicEndpointCertificates() ([]certificate.Certificate, error) {
+	var response []certificate.Certificate
+	if err := c.call("GetPublicEndpointCertificates", empty, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
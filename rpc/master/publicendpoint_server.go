@@ -14,6 +14,7 @@
 package master
 
 import (
+	"github.com/control-center/serviced/domain/certificate"
 	"github.com/control-center/serviced/domain/service"
 	"github.com/control-center/serviced/domain/servicedefinition"
 )
@@ -26,13 +27,14 @@ type PublicEndpointRequest struct {
 	UseTLS       bool
 	Protocol     string
 	IsEnabled    bool
+	Access       servicedefinition.AccessRules
 	Restart      bool
 }
 
 // Adds a port public endpoint to a service.
 func (s *Server) AddPublicEndpointPort(request *PublicEndpointRequest, reply *servicedefinition.Port) error {
 	port, err := s.f.AddPublicEndpointPort(s.context(), request.Serviceid, request.EndpointName, request.Name,
-		request.UseTLS, request.Protocol, request.IsEnabled, request.Restart)
+		request.UseTLS, request.Protocol, request.IsEnabled, request.Access, request.Restart)
 	if err != nil {
 		return err
 	}
@@ -53,7 +55,7 @@ func (s *Server) EnablePublicEndpointPort(request *PublicEndpointRequest, _ *str
 // Adds a vhost public endpoint to a service.
 func (s *Server) AddPublicEndpointVHost(request *PublicEndpointRequest, reply *servicedefinition.VHost) error {
 	vhost, err := s.f.AddPublicEndpointVHost(s.context(), request.Serviceid, request.EndpointName, request.Name,
-		request.IsEnabled, request.Restart)
+		request.IsEnabled, request.Access, request.Restart)
 	if err != nil {
 		return err
 	}
@@ -80,3 +82,56 @@ func (s *Server) GetAllPublicEndpoints(empty struct{}, publicEndpoints *[]servic
 	*publicEndpoints = peps
 	return nil
 }
+
+// Defines a request to set or remove the certificate of a public endpoint
+type PublicEndpointCertificateRequest struct {
+	Serviceid    string
+	EndpointName string
+	Kind         certificate.Kind
+	Name         string
+	CertPEM      []byte
+	KeyPEM       []byte
+	ACMEDomains  []string
+}
+
+// Sets the TLS certificate of a vhost or port public endpoint.
+func (s *Server) SetPublicEndpointCertificate(request *PublicEndpointCertificateRequest, reply *certificate.Certificate) error {
+	cert, err := s.f.SetPublicEndpointCertificate(s.context(), request.Serviceid, request.EndpointName, request.Kind,
+		request.Name, request.CertPEM, request.KeyPEM)
+	if err != nil {
+		return err
+	}
+	*reply = *cert
+	reply.EncryptedKey = ""
+	return nil
+}
+
+// Removes the TLS certificate of a vhost or port public endpoint.
+func (s *Server) RemovePublicEndpointCertificate(request *PublicEndpointCertificateRequest, _ *struct{}) error {
+	return s.f.RemovePublicEndpointCertificate(s.context(), request.Serviceid, request.EndpointName, request.Kind, request.Name)
+}
+
+// Has the TLS certificate of a vhost public endpoint obtained from ACME.
+func (s *Server) EnablePublicEndpointACME(request *PublicEndpointCertificateRequest, reply *certificate.Certificate) error {
+	cert, err := s.f.EnablePublicEndpointACME(s.context(), request.Serviceid, request.EndpointName, request.Name, request.ACMEDomains)
+	if err != nil {
+		return err
+	}
+	*reply = *cert
+	reply.EncryptedKey = ""
+	return nil
+}
+
+// GetPublicEndpointCertificates returns the certificates of all public
+// endpoints, without their keys.
+func (s *Server) GetPublicEndpointCertificates(empty struct{}, reply *[]certificate.Certificate) error {
+	certs, err := s.f.GetCertificates(s.context())
+	if err != nil {
+		return err
+	}
+	for i := range certs {
+		certs[i].EncryptedKey = ""
+	}
+	*reply = certs
+	return nil
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/control-center/serviced/domain/service"
 	"github.com/Sirupsen/logrus"
 	"github.com/control-center/serviced/domain/addressassignment"
+	"github.com/control-center/serviced/slo"
 )
 
 // ServiceUse will use a new image for a given service - this will pull the image and tag it
@@ -114,6 +115,24 @@ func (c *Client) ClearEmergency(serviceID string) (int, error) {
 	return affected, err
 }
 
+// GetServiceSLOs reports each service level objective of a service against
+// its target
+func (c *Client) GetServiceSLOs(serviceID string) ([]slo.Status, error) {
+	statuses := []slo.Status{}
+	err := c.call("GetServiceSLOs", serviceID, &statuses)
+	return statuses, err
+}
+
+// GetResourceRecommendations recommends new commitments and limits for the
+// services of a tenant, or of all tenants, from their usage since the given
+// time
+func (c *Client) GetResourceRecommendations(tenantID string, since time.Time) ([]service.ResourceRecommendation, error) {
+	request := ResourceRecommendationRequest{TenantID: tenantID, Since: since}
+	recs := []service.ResourceRecommendation{}
+	err := c.call("GetResourceRecommendations", request, &recs)
+	return recs, err
+}
+
 // Remove the IP assignment of a service's endpoints
 func (c *Client) RemoveIPs(args []string) error {
 	return c.call("RemoveIPs", args, new(string))
@@ -18,6 +18,7 @@ import (
 
 	"github.com/control-center/serviced/domain/service"
 	"github.com/control-center/serviced/domain/addressassignment"
+	"github.com/control-center/serviced/slo"
 )
 
 type ServiceUseRequest struct {
@@ -35,6 +36,11 @@ type WaitServiceRequest struct {
 	Recursive  bool
 }
 
+type ResourceRecommendationRequest struct {
+	TenantID string
+	Since    time.Time
+}
+
 type EvaluateServiceRequest struct {
 	ServiceID  string
 	InstanceID int
@@ -154,6 +160,28 @@ func (s *Server) ClearEmergency(serviceID string, count *int) error {
 	return nil
 }
 
+// GetServiceSLOs reports each service level objective of a service against
+// its target
+func (s *Server) GetServiceSLOs(serviceID string, statuses *[]slo.Status) error {
+	result, err := s.f.GetServiceSLOs(s.context(), serviceID)
+	if err != nil {
+		return err
+	}
+	*statuses = result
+	return nil
+}
+
+// GetResourceRecommendations recommends new commitments and limits for the
+// services of a tenant, or of all tenants
+func (s *Server) GetResourceRecommendations(request ResourceRecommendationRequest, recs *[]service.ResourceRecommendation) error {
+	result, err := s.f.GetResourceRecommendations(s.context(), request.TenantID, request.Since)
+	if err != nil {
+		return err
+	}
+	*recs = result
+	return nil
+}
+
 func (s *Server) RemoveIPs(args []string, unused *string) error {
 	return s.f.RemoveIPs(s.context(), args)
 }
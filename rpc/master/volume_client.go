@@ -25,3 +25,19 @@ func (c *Client) GetVolumeStatus() (*volume.Statuses, error) {
 	}
 	return response, nil
 }
+
+// SetStorageQuota sets the storage quota for a tenant
+func (c *Client) SetStorageQuota(tenantID string, quota volume.StorageQuota) error {
+	request := StorageQuotaRequest{TenantID: tenantID, Quota: quota}
+	return c.call("SetStorageQuota", request, new(struct{}))
+}
+
+// GetStorageQuotaStatus reports the storage used by each tenant against its
+// quota
+func (c *Client) GetStorageQuotaStatus() ([]volume.TenantQuotaStatus, error) {
+	response := []volume.TenantQuotaStatus{}
+	if err := c.call("GetStorageQuotaStatus", empty, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
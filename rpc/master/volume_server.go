@@ -19,12 +19,39 @@ import (
 	"github.com/control-center/serviced/volume"
 )
 
+// StorageQuotaRequest sets the storage quota for a tenant
+type StorageQuotaRequest struct {
+	TenantID string
+	Quota    volume.StorageQuota
+}
+
 // GetVolumeStatus gets the volume status
 func (s *Server) GetVolumeStatus(empty struct{}, reply *volume.Statuses) error {
 	response := volume.GetStatus()
 	if response == nil {
 		return errors.New("volume_server.go GetStatus failed")
 	}
+	if quotas, err := s.f.GetStorageQuotas(s.context()); err != nil {
+		plog.WithError(err).Warn("Could not look up tenant storage quotas")
+	} else {
+		response.ApplyQuotas(quotas)
+	}
 	*reply = *response
 	return nil
 }
+
+// SetStorageQuota sets the storage quota for a tenant
+func (s *Server) SetStorageQuota(request StorageQuotaRequest, _ *struct{}) error {
+	return s.f.SetStorageQuota(s.context(), request.TenantID, request.Quota)
+}
+
+// GetStorageQuotaStatus reports the storage used by each tenant against its
+// quota
+func (s *Server) GetStorageQuotaStatus(empty struct{}, reply *[]volume.TenantQuotaStatus) error {
+	statuses, err := s.f.GetStorageQuotaStatus(s.context())
+	if err != nil {
+		return err
+	}
+	*reply = statuses
+	return nil
+}
@@ -33,12 +33,14 @@ var (
 		"Agent.BuildHost",
 		"ControlCenterAgent.Ping",
 		"Master.AddHostPrivate",
+		"Master.GetMuxCertificate",
 	}
 	// RPC calls that do not require admin access:
 	NonAdminRequiredCalls = map[string]struct{}{
 		"Master.GetHost":                         struct{}{},
 		"Master.GetHosts":                        struct{}{},
 		"Master.GetEvaluatedService":             struct{}{},
+		"Master.GetPoolMetricSinks":              struct{}{},
 		"Master.GetSystemUser":                   struct{}{},
 		"Master.ReportHealthStatus":              struct{}{},
 		"Master.ReportInstanceDead":              struct{}{},
@@ -46,6 +48,7 @@ var (
 		"ControlCenterAgent.GetEvaluatedService": struct{}{},
 		"ControlCenterAgent.GetHostID":           struct{}{},
 		"ControlCenterAgent.GetZkInfo":           struct{}{},
+		"ControlCenterAgent.GetMetricSinks":      struct{}{},
 		"ControlCenterAgent.GetISvcEndpoints":    struct{}{},
 		"ControlCenterAgent.ReportHealthStatus":  struct{}{},
 		"ControlCenterAgent.ReportInstanceDead":  struct{}{},
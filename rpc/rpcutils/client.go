@@ -96,6 +96,13 @@ type reconnectingClient struct {
 }
 
 func (rc *reconnectingClient) Call(serviceMethod string, args interface{}, reply interface{}, timeout time.Duration) error {
+	start := time.Now()
+	err := rc.call(serviceMethod, args, reply, timeout)
+	recordCall(serviceMethod, time.Since(start), err)
+	return err
+}
+
+func (rc *reconnectingClient) call(serviceMethod string, args interface{}, reply interface{}, timeout time.Duration) error {
 	if timeout <= 0 {
 		timeout = 365 * 24 * time.Hour
 	}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/control-center/serviced/auth"
 	"github.com/control-center/serviced/commons/pool"
+	"github.com/rcrowley/go-metrics"
 	. "gopkg.in/check.v1"
 )
 
@@ -226,6 +227,26 @@ func (s *MySuite) TestNonAuthenticatingCall(c *C) {
 	c.Assert(p, Equals, r)
 }
 
+func (s *MySuite) TestCallMetrics(c *C) {
+	auth.ClearKeys()
+	client, err := newClient("localhost:32111", 1, DiscardClientTimeout, connectRPC)
+	c.Assert(err, IsNil)
+	var r string
+	c.Assert(client.Call("RPCTestType.NonAuthenticatingCall", "x", &r, 10*time.Second), IsNil)
+	c.Assert(client.Call("RPCTestType.Missing", "x", &r, 10*time.Second), NotNil)
+
+	calls := make(map[string]int64)
+	errs := make(map[string]int64)
+	for _, tagged := range CallRegistries() {
+		method := tagged.Tags["rpc_method"]
+		calls[method] = metrics.GetOrRegisterCounter("rpc.calls", tagged.Registry).Count()
+		errs[method] = metrics.GetOrRegisterCounter("rpc.errors", tagged.Registry).Count()
+	}
+	c.Assert(calls["RPCTestType.NonAuthenticatingCall"] > 0, Equals, true)
+	c.Assert(errs["RPCTestType.NonAuthenticatingCall"], Equals, int64(0))
+	c.Assert(errs["RPCTestType.Missing"] > 0, Equals, true)
+}
+
 func (s *MySuite) TestUnauthenticatedClient(c *C) {
 	auth.ClearKeys()
 	// Attempt an RPC call without a token
@@ -0,0 +1,66 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcutils
+
+import (
+	"sync"
+	"time"
+
+	"github.com/control-center/serviced/stats"
+	"github.com/rcrowley/go-metrics"
+)
+
+var (
+	callMu         = &sync.Mutex{}
+	callRegistries = make(map[string]metrics.Registry)
+)
+
+// callRegistry returns the metrics registry of an RPC method, creating it if
+// it does not exist.
+func callRegistry(serviceMethod string) metrics.Registry {
+	callMu.Lock()
+	defer callMu.Unlock()
+	reg, ok := callRegistries[serviceMethod]
+	if !ok {
+		reg = metrics.NewRegistry()
+		callRegistries[serviceMethod] = reg
+	}
+	return reg
+}
+
+// recordCall updates the count, errors and latency in milliseconds of an RPC
+// method.
+func recordCall(serviceMethod string, elapsed time.Duration, err error) {
+	reg := callRegistry(serviceMethod)
+	metrics.GetOrRegisterCounter("rpc.calls", reg).Inc(1)
+	if err != nil {
+		metrics.GetOrRegisterCounter("rpc.errors", reg).Inc(1)
+	}
+	metrics.GetOrRegisterHistogram("rpc.latency.ms", reg, metrics.NewExpDecaySample(1028, 0.015)).Update(int64(elapsed / time.Millisecond))
+}
+
+// CallRegistries returns the metrics of the RPC calls made by this process,
+// tagged with the method.
+func CallRegistries() []stats.TaggedRegistry {
+	callMu.Lock()
+	defer callMu.Unlock()
+	result := make([]stats.TaggedRegistry, 0, len(callRegistries))
+	for method, reg := range callRegistries {
+		result = append(result, stats.TaggedRegistry{
+			Tags:     map[string]string{"rpc_method": method},
+			Registry: reg,
+		})
+	}
+	return result
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/control-center/serviced/facade"
 	"github.com/control-center/serviced/scheduler/strategy"
 	"github.com/control-center/serviced/zzk"
+	zkdocker "github.com/control-center/serviced/zzk/docker"
 	zkservice "github.com/control-center/serviced/zzk/service"
 )
 
@@ -152,6 +153,28 @@ func (l *leader) SelectHost(sn *zkservice.ServiceNode) (string, error) {
 		return "", err
 	}
 
-	return StrategySelectHost(sn, hosts, strat, l.facade)
+	return StrategySelectPreferredHost(sn, hosts, l.prePulledHosts(sn, hosts), strat, l.facade)
+}
+
+// prePulledHosts returns the hosts that have already pulled the service image.
+// The leader's connection is rooted at "/", so the pre-pull requests are
+// looked up under the pool.
+func (l *leader) prePulledHosts(sn *zkservice.ServiceNode, hosts []host.Host) map[string]struct{} {
+	preferred := make(map[string]struct{})
+	if sn.ImageID == "" {
+		return preferred
+	}
+	for _, h := range hosts {
+		ok, err := zkdocker.IsPrePulled(l.conn, l.poolID, h.ID, sn.ImageID)
+		if err != nil {
+			plog.WithFields(log.Fields{
+				"hostid":  h.ID,
+				"imageid": sn.ImageID,
+			}).WithError(err).Debug("Could not check if image was pre-pulled")
+		} else if ok {
+			preferred[h.ID] = struct{}{}
+		}
+	}
+	return preferred
 }
 
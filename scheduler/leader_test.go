@@ -0,0 +1,66 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package scheduler
+
+import (
+	"net/url"
+	"path"
+	"testing"
+
+	coordclient "github.com/control-center/serviced/coordinator/client"
+	"github.com/control-center/serviced/coordinator/client/mocks"
+	"github.com/control-center/serviced/domain/host"
+	"github.com/control-center/serviced/zzk"
+	zkdocker "github.com/control-center/serviced/zzk/docker"
+	zkservice "github.com/control-center/serviced/zzk/service"
+	"github.com/stretchr/testify/mock"
+)
+
+// prePullNodePath is where the agents of the pool record their pre-pulls
+func prePullNodePath(poolID, hostID, image string) string {
+	return path.Join(zzk.GeneratePoolPath(poolID), "/docker/prepull", hostID, url.QueryEscape(image))
+}
+
+func TestLeader_PrePulledHosts(t *testing.T) {
+	image := "localhost:5000/tenant/repo:latest"
+	conn := &mocks.Connection{}
+	conn.On("Get", prePullNodePath("default", "host1", image), mock.AnythingOfType("*docker.PrePull")).
+		Return(nil).
+		Run(func(a mock.Arguments) {
+			a.Get(1).(*zkdocker.PrePull).State = zkdocker.PrePullDone
+		})
+	conn.On("Get", prePullNodePath("default", "host2", image), mock.AnythingOfType("*docker.PrePull")).
+		Return(nil).
+		Run(func(a mock.Arguments) {
+			a.Get(1).(*zkdocker.PrePull).State = zkdocker.PrePullPulling
+		})
+	conn.On("Get", prePullNodePath("default", "host3", image), mock.AnythingOfType("*docker.PrePull")).
+		Return(coordclient.ErrNoNode)
+
+	// the leader is connected at the root, as in Lead
+	l := &leader{conn: conn, poolID: "default"}
+	sn := &zkservice.ServiceNode{ID: "svc1", ImageID: image}
+	hosts := []host.Host{{ID: "host1"}, {ID: "host2"}, {ID: "host3"}}
+
+	preferred := l.prePulledHosts(sn, hosts)
+	if len(preferred) != 1 {
+		t.Fatalf("Expected 1 pre-pulled host, got %v", preferred)
+	}
+	if _, ok := preferred["host1"]; !ok {
+		t.Errorf("Expected host1 to be preferred, got %v", preferred)
+	}
+	conn.AssertExpectations(t)
+}
@@ -54,12 +54,13 @@ type scheduler struct {
 	stopped       chan interface{}
 	storageServer *storage.Server
 	pushreg       *imgreg.RegistryListener
+	replicator    *imgreg.RegistryReplicator
 
 	conn coordclient.Connection
 }
 
 // NewScheduler creates a new scheduler master
-func NewScheduler(poolID string, instance_id string, storageServer *storage.Server, cpDao dao.ControlPlane, facade *facade.Facade, pushreg *imgreg.RegistryListener, snapshotTTL int) (*scheduler, error) {
+func NewScheduler(poolID string, instance_id string, storageServer *storage.Server, cpDao dao.ControlPlane, facade *facade.Facade, pushreg *imgreg.RegistryListener, replicator *imgreg.RegistryReplicator, snapshotTTL int) (*scheduler, error) {
 	s := &scheduler{
 		cpDao:         cpDao,
 		poolID:        poolID,
@@ -71,6 +72,7 @@ func NewScheduler(poolID string, instance_id string, storageServer *storage.Serv
 		snapshotTTL:   snapshotTTL,
 		storageServer: storageServer,
 		pushreg:       pushreg,
+		replicator:    replicator,
 	}
 	return s, nil
 }
@@ -187,7 +189,11 @@ func (s *scheduler) mainloop(conn coordclient.Connection) {
 	go func() {
 		defer glog.Infof("Stopping pool listeners")
 		defer wg.Done()
-		zzk.Start(_shutdown, conn, s, s.pushreg)
+		listeners := []zzk.Listener{s.pushreg}
+		if s.replicator != nil {
+			listeners = append(listeners, s.replicator)
+		}
+		zzk.Start(_shutdown, conn, s, listeners...)
 		stopped <- struct{}{}
 	}()
 
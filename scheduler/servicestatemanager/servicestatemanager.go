@@ -993,6 +993,39 @@ func (s *BatchServiceStateManager) findService(tenantID, serviceID string) (*Can
 	return nil, false
 }
 
+// QueueDepth is the number of batches and services waiting in one of a
+// tenant's queues, including the batch that is being processed
+type QueueDepth struct {
+	TenantID     string
+	DesiredState service.DesiredState
+	Batches      int
+	Services     int
+}
+
+// QueueDepths returns the depth of each tenant's queues
+func (s *BatchServiceStateManager) QueueDepths() []QueueDepth {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	depths := []QueueDepth{}
+	for tenantID, queues := range s.TenantQueues {
+		for desiredState, queue := range queues {
+			depth := QueueDepth{TenantID: tenantID, DesiredState: desiredState}
+			queue.lock.RLock()
+			for _, batch := range queue.BatchQueue {
+				depth.Batches++
+				depth.Services += len(batch.Services)
+			}
+			if len(queue.CurrentBatch.Services) > 0 {
+				depth.Batches++
+				depth.Services += len(queue.CurrentBatch.Services)
+			}
+			queue.lock.RUnlock()
+			depths = append(depths, depth)
+		}
+	}
+	return depths
+}
+
 func (s *BatchServiceStateManager) drainQueue(queue *ServiceStateQueue) {
 	for {
 		empty := func() bool {
@@ -1878,6 +1878,33 @@ func (s *ServiceStateManagerSuite) TestServiceStateManager_ScheduleServices_BadT
 	c.Assert(err, Equals, ssm.ErrBadTenantID)
 }
 
+func (s *ServiceStateManagerSuite) TestServiceStateManager_QueueDepths(c *C) {
+	tenantID := "tenant"
+	startQueue := ssm.NewServiceStateQueue(s.facade)
+	s.serviceStateManager.TenantQueues[tenantID] = map[service.DesiredState]*ssm.ServiceStateQueue{
+		service.SVCRun: startQueue,
+	}
+	svcs := getTestServicesABC()
+	startQueue.CurrentBatch = ssm.ServiceStateChangeBatch{
+		Services:     map[string]*ssm.CancellableService{"A": ssm.NewCancellableService(svcs[0])},
+		DesiredState: service.SVCRun,
+	}
+	startQueue.BatchQueue = []ssm.ServiceStateChangeBatch{
+		{
+			Services: map[string]*ssm.CancellableService{
+				"B": ssm.NewCancellableService(svcs[1]),
+				"C": ssm.NewCancellableService(svcs[2]),
+			},
+			DesiredState: service.SVCRun,
+		},
+	}
+
+	depths := s.serviceStateManager.QueueDepths()
+	c.Assert(depths, DeepEquals, []ssm.QueueDepth{
+		{TenantID: tenantID, DesiredState: service.SVCRun, Batches: 2, Services: 3},
+	})
+}
+
 func (s *ServiceStateManagerSuite) TestServiceStateManager_MergeBatches_UnmatchedStates(c *C) {
 	batches := []ssm.ServiceStateChangeBatch{ssm.ServiceStateChangeBatch{DesiredState: 0}, ssm.ServiceStateChangeBatch{DesiredState: 1}}
 
@@ -45,6 +45,12 @@ type StrategyService struct {
 }
 
 func StrategySelectHost(sn *zkservice.ServiceNode, hosts []host.Host, strat strategy.Strategy, facade *facade.Facade) (string, error) {
+	return StrategySelectPreferredHost(sn, hosts, nil, strat, facade)
+}
+
+// StrategySelectPreferredHost applies the strategy to the preferred hosts if
+// any of them can run the service, and to all of the hosts otherwise.
+func StrategySelectPreferredHost(sn *zkservice.ServiceNode, hosts []host.Host, preferred map[string]struct{}, strat strategy.Strategy, facade *facade.Facade) (string, error) {
 
 	glog.V(2).Infof("Applying %s strategy for service %s", strat.Name(), sn.ID)
 
@@ -75,6 +81,7 @@ func StrategySelectHost(sn *zkservice.ServiceNode, hosts []host.Host, strat stra
 		glog.V(2).Infof("Host %s is running %d service instances", h.HostID(), len(h.services))
 		shosts = append(shosts, h)
 	}
+	shosts = strategy.PreferHosts(&StrategyService{sn}, shosts, preferred)
 	if result, err := strat.SelectHost(&StrategyService{sn}, shosts); result == nil || err != nil {
 		return "", err
 	} else {
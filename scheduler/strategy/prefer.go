@@ -0,0 +1,45 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package strategy
+
+import (
+	"github.com/zenoss/glog"
+)
+
+// PreferHosts returns the preferred hosts (e.g. hosts that already have the
+// service image) if at least one of them has enough resources to handle the
+// service. Otherwise, all of the hosts are returned, so that a preference
+// never causes a host to be oversubscribed.
+func PreferHosts(service ServiceConfig, hosts []Host, preferred map[string]struct{}) []Host {
+	if len(preferred) == 0 {
+		return hosts
+	}
+
+	phosts := []Host{}
+	for _, host := range hosts {
+		if _, ok := preferred[host.HostID()]; ok {
+			phosts = append(phosts, host)
+		}
+	}
+	if len(phosts) == 0 || len(phosts) == len(hosts) {
+		return hosts
+	}
+
+	if under, _ := ScoreHosts(service, phosts); len(under) == 0 {
+		glog.V(2).Infof("No preferred host can run service %s", service.GetServiceID())
+		return hosts
+	}
+	glog.V(2).Infof("Preferring %d of %d hosts for service %s", len(phosts), len(hosts), service.GetServiceID())
+	return phosts
+}
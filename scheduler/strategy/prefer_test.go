@@ -0,0 +1,65 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package strategy_test
+
+import (
+	"github.com/control-center/serviced/scheduler/strategy"
+	. "gopkg.in/check.v1"
+)
+
+// Given a preferred host with enough resources, verify that only the preferred
+// hosts are returned
+func (s *StrategySuite) TestPreferHosts(c *C) {
+	hostA := newHost(2, 2)
+	hostB := newHost(2, 2)
+
+	svc := newService(1, 1)
+
+	hostA.On("RunningServices").Return([]strategy.ServiceConfig{})
+	hostB.On("RunningServices").Return([]strategy.ServiceConfig{})
+
+	preferred := map[string]struct{}{hostB.HostID(): struct{}{}}
+	hosts := strategy.PreferHosts(svc, []strategy.Host{hostA, hostB}, preferred)
+	c.Assert(hosts, DeepEquals, []strategy.Host{hostB})
+}
+
+// Given a preferred host without enough resources, verify that all of the
+// hosts are returned
+func (s *StrategySuite) TestPreferHostsOversubscribed(c *C) {
+	hostA := newHost(2, 2)
+	hostB := newHost(2, 2)
+
+	svc := newService(2, 2)
+	svc2 := newService(1, 1)
+
+	hostA.On("RunningServices").Return([]strategy.ServiceConfig{})
+	hostB.On("RunningServices").Return([]strategy.ServiceConfig{svc})
+
+	preferred := map[string]struct{}{hostB.HostID(): struct{}{}}
+	hosts := strategy.PreferHosts(svc2, []strategy.Host{hostA, hostB}, preferred)
+	c.Assert(hosts, DeepEquals, []strategy.Host{hostA, hostB})
+}
+
+// Given no preferred hosts, verify that all of the hosts are returned
+func (s *StrategySuite) TestPreferHostsNone(c *C) {
+	hostA := newHost(2, 2)
+	hostB := newHost(2, 2)
+
+	svc := newService(1, 1)
+
+	hosts := strategy.PreferHosts(svc, []strategy.Host{hostA, hostB}, nil)
+	c.Assert(hosts, DeepEquals, []strategy.Host{hostA, hostB})
+}
@@ -51,6 +51,8 @@ func main() {
 		cli.BoolFlag{"rpc-disable-tls", "disable TLS for RPC requests"},
 		cli.BoolTFlag{"autorestart", "restart process automatically when it finishes"},
 		cli.BoolFlag{"mux-disable-tls", "disable contacting the mux via TLS"},
+		cli.IntFlag{"mux-sessions", 0, "number of connections to each remote mux shared by multiplexed streams"},
+		cli.StringFlag{"mux-credentials", "", "directory of the certificate presented to remote muxes"},
 		cli.BoolFlag{"disable-metric-forwarding", "disable forwarding of metrics for this container"},
 		cli.StringFlag{"metric-forwarder-port", defaultMetricsForwarderPort, "the port the container processes send performance data to"},
 		cli.BoolTFlag{"logstash", "forward service logs via filebeat"},
@@ -27,6 +27,8 @@ type ControllerOptions struct {
 	MuxPort                 int      // the TCP port for the remote mux
 	Mux                     bool     // True if a remote mux is used
 	MUXDisableTLS           bool     // True if TLS should be disabled on the mux
+	MUXSessions             int      // Number of multiplexed connections to each remote mux, 0 disables
+	MUXCredentials          string   // Path to the certificate of the tenant for the mux, empty if not used
 	KeyPEMFile              string   // path to the KeyPEMfile
 	CertPEMFile             string   // path to the CertPEMfile
 	ServicedEndpoint        string
@@ -54,6 +56,8 @@ func (c ControllerOptions) toContainerControllerOptions() (options container.Con
 	options.Mux.Port = c.MuxPort
 	options.Mux.Enabled = c.Mux
 	options.Mux.DisableTLS = c.MUXDisableTLS
+	options.Mux.Sessions = c.MUXSessions
+	options.Mux.CredentialsPath = c.MUXCredentials
 	options.Mux.KeyPEMFile = c.KeyPEMFile
 	options.Mux.CertPEMFile = c.CertPEMFile
 	options.Logforwarder.Enabled = c.Logstash
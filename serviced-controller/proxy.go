@@ -42,6 +42,8 @@ func CmdServiceProxy(ctx *cli.Context) {
 	options := ControllerOptions{
 		MuxPort:                 ctx.GlobalInt("muxport"),
 		MUXDisableTLS:           ctx.GlobalBool("mux-disable-tls"),
+		MUXSessions:             ctx.GlobalInt("mux-sessions"),
+		MUXCredentials:          ctx.GlobalString("mux-credentials"),
 		KeyPEMFile:              ctx.GlobalString("keyfile"),
 		CertPEMFile:             ctx.GlobalString("certfile"),
 		RPCPort:                 ctx.GlobalInt("rpcport"),
@@ -0,0 +1,162 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slo
+
+import (
+	"time"
+
+	"github.com/control-center/serviced/domain/servicedefinition"
+)
+
+const (
+	// recentBucketSize is the period of the buckets that burn rates of up to
+	// a day are measured with
+	recentBucketSize = 5 * 60
+	// recentPeriod is how long the recent buckets are kept
+	recentPeriod = 24 * time.Hour
+	// hourlyBucketSize is the period of the buckets that compliance windows
+	// and longer burn rates are measured with
+	hourlyBucketSize = 60 * 60
+	// hourlyPeriod is how long the hourly buckets are kept
+	hourlyPeriod = servicedefinition.MaxSLOWindowDays * 24 * time.Hour
+)
+
+// bucket counts the measurements that fell within a period
+type bucket struct {
+	Start int64 // unix time of the start of the period
+	Good  int
+	Total int
+}
+
+// series is a time ordered list of buckets of the same size
+type series struct {
+	Size    int64 // seconds covered by each bucket
+	Buckets []bucket
+}
+
+// add counts a measurement.  Measurements are taken in order, so one that is
+// older than the last bucket is ignored.
+func (s *series) add(at time.Time, good bool) {
+	start := at.Unix() - at.Unix()%s.Size
+	n := len(s.Buckets)
+	if n == 0 || s.Buckets[n-1].Start < start {
+		s.Buckets = append(s.Buckets, bucket{Start: start})
+		n++
+	} else if s.Buckets[n-1].Start > start {
+		return
+	}
+	s.Buckets[n-1].Total++
+	if good {
+		s.Buckets[n-1].Good++
+	}
+}
+
+// count sums the measurements of the buckets that end after since
+func (s *series) count(since time.Time) (good, total int) {
+	for i := len(s.Buckets) - 1; i >= 0; i-- {
+		b := s.Buckets[i]
+		if b.Start+s.Size <= since.Unix() {
+			break
+		}
+		good += b.Good
+		total += b.Total
+	}
+	return good, total
+}
+
+// prune removes the buckets that ended before the given time
+func (s *series) prune(before time.Time) {
+	i := 0
+	for i < len(s.Buckets) && s.Buckets[i].Start+s.Size <= before.Unix() {
+		i++
+	}
+	if i > 0 {
+		s.Buckets = append([]bucket{}, s.Buckets[i:]...)
+	}
+}
+
+// history keeps the measurements of an SLO at two resolutions: fine buckets
+// for the last day, so that short burn rates react quickly, and hourly
+// buckets for the longest compliance window.
+type history struct {
+	Recent series
+	Hourly series
+}
+
+func newHistory() *history {
+	return &history{
+		Recent: series{Size: recentBucketSize},
+		Hourly: series{Size: hourlyBucketSize},
+	}
+}
+
+// add counts a measurement
+func (h *history) add(at time.Time, good bool) {
+	h.Recent.add(at, good)
+	h.Hourly.add(at, good)
+}
+
+// count sums the measurements taken over the period before now
+func (h *history) count(now time.Time, period time.Duration) (good, total int) {
+	since := now.Add(-period)
+	if period <= recentPeriod {
+		return h.Recent.count(since)
+	}
+	return h.Hourly.count(since)
+}
+
+// prune removes the buckets that are too old to be used
+func (h *history) prune(now time.Time) {
+	h.Recent.prune(now.Add(-recentPeriod))
+	h.Hourly.prune(now.Add(-hourlyPeriod))
+}
+
+// empty returns true if the history has no measurements
+func (h *history) empty() bool {
+	return len(h.Hourly.Buckets) == 0
+}
+
+// daily returns the compliance of each UTC day of the last days that has
+// measurements, oldest first.
+func (h *history) daily(now time.Time, days int) []DailyCompliance {
+	now = now.UTC()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1-days)
+	result := []DailyCompliance{}
+	for _, b := range h.Hourly.Buckets {
+		start := time.Unix(b.Start, 0).UTC()
+		if start.Before(since) || b.Total == 0 {
+			continue
+		}
+		date := start.Format("2006-01-02")
+		if n := len(result); n == 0 || result[n-1].Date != date {
+			result = append(result, DailyCompliance{Date: date})
+		}
+		day := &result[len(result)-1]
+		day.Good += b.Good
+		day.Total += b.Total
+	}
+	for i := range result {
+		result[i].Compliance = compliance(result[i].Good, result[i].Total)
+	}
+	return result
+}
+
+// compliance returns the percentage of good measurements, or 100 if there
+// are none.
+func compliance(good, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(good) / float64(total)
+}
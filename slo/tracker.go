@@ -0,0 +1,269 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package slo tracks the service level objectives of services.  The master
+// measures each SLO once a minute and keeps a rolling history, from which the
+// compliance, error budget and burn rates of the SLO are computed.
+package slo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/control-center/serviced/domain/servicedefinition"
+	"github.com/control-center/serviced/logging"
+	"github.com/control-center/serviced/stats"
+)
+
+// Interval is how often the master measures each SLO
+const Interval = time.Minute
+
+// FileName is the name of the file the master saves the SLO history to
+const FileName = "slo-history.json"
+
+// initialize the package logger
+var plog = logging.PackageLogger()
+
+// BurnRate is the rate at which the error budget of an SLO was spent over a
+// period, relative to the rate that would spend it exactly over the window.
+type BurnRate struct {
+	Hours     int
+	Rate      float64
+	Threshold float64 // the burn rate at which the alert is raised
+	Severity  int
+	Exceeded  bool
+}
+
+// DailyCompliance is the compliance of an SLO over a single day
+type DailyCompliance struct {
+	Date       string // yyyy-mm-dd, UTC
+	Good       int
+	Total      int
+	Compliance float64
+}
+
+// Status reports an SLO against its target
+type Status struct {
+	ServiceID   string
+	Name        string
+	Type        servicedefinition.SLOType
+	Target      float64
+	WindowDays  int
+	Good        int     // good minutes within the window
+	Total       int     // measured minutes within the window
+	Compliance  float64 // percentage of good minutes; 100 if none were measured
+	ErrorBudget float64 // percentage of the error budget left; negative once it is overspent
+	BurnRates   []BurnRate
+	History     []DailyCompliance
+}
+
+// Met returns true if the SLO is meeting its target
+func (s Status) Met() bool {
+	return s.Compliance >= s.Target
+}
+
+// ComplianceMetric is the name of the compliance metric of an SLO
+func ComplianceMetric(name string) string {
+	return fmt.Sprintf("slo.%s.compliance", name)
+}
+
+// ErrorBudgetMetric is the name of the error budget metric of an SLO
+func ErrorBudgetMetric(name string) string {
+	return fmt.Sprintf("slo.%s.errorbudget", name)
+}
+
+// BurnRateMetric is the name of a burn rate metric of an SLO
+func BurnRateMetric(name string, hours int) string {
+	return fmt.Sprintf("slo.%s.burnrate.%dh", name, hours)
+}
+
+// Samples returns the compliance, error budget and burn rates of the SLO as
+// stats of its service, which raise the burn rate threshold events.
+func (s Status) Samples(t time.Time) []stats.Sample {
+	tags := map[string]string{"controlplane_service_id": s.ServiceID}
+	sample := func(metric string, value float64) stats.Sample {
+		return stats.Sample{
+			Metric:    metric,
+			Value:     strconv.FormatFloat(value, 'f', -1, 64),
+			Timestamp: t.Unix(),
+			Tags:      tags,
+		}
+	}
+	samples := []stats.Sample{
+		sample(ComplianceMetric(s.Name), s.Compliance),
+		sample(ErrorBudgetMetric(s.Name), s.ErrorBudget),
+	}
+	for _, rate := range s.BurnRates {
+		samples = append(samples, sample(BurnRateMetric(s.Name, rate.Hours), rate.Rate))
+	}
+	return samples
+}
+
+// Tracker keeps the measurements of every SLO.  The history is saved to a
+// file, so that it survives a restart of the master.
+type Tracker struct {
+	mu        sync.Mutex
+	path      string
+	histories map[string]*history
+}
+
+// NewTracker creates a tracker that saves its history to the given path, and
+// loads the history that was saved there, if any.
+func NewTracker(path string) *Tracker {
+	t := &Tracker{
+		path:      path,
+		histories: make(map[string]*history),
+	}
+	if path == "" {
+		return t
+	}
+	logger := plog.WithField("path", path)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return t
+	} else if err != nil {
+		logger.WithError(err).Warn("Could not read the SLO history")
+		return t
+	}
+	if err := json.Unmarshal(data, &t.histories); err != nil {
+		logger.WithError(err).Warn("Could not parse the SLO history")
+		t.histories = make(map[string]*history)
+	}
+	return t
+}
+
+func historyKey(serviceID, name string) string {
+	return serviceID + "/" + name
+}
+
+// Record counts a minute of an SLO as good or bad
+func (t *Tracker) Record(serviceID, name string, at time.Time, good bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := historyKey(serviceID, name)
+	h, ok := t.histories[key]
+	if !ok {
+		h = newHistory()
+		t.histories[key] = h
+	}
+	h.add(at, good)
+}
+
+// Status reports an SLO of a service against its target
+func (t *Tracker) Status(serviceID string, obj servicedefinition.SLO, now time.Time) Status {
+	t.mu.Lock()
+	h, ok := t.histories[historyKey(serviceID, obj.Name)]
+	if !ok {
+		h = newHistory()
+	}
+	days := obj.GetWindowDays()
+	good, total := h.count(now, time.Duration(days)*24*time.Hour)
+	status := Status{
+		ServiceID:   serviceID,
+		Name:        obj.Name,
+		Type:        obj.Type,
+		Target:      obj.Target,
+		WindowDays:  days,
+		Good:        good,
+		Total:       total,
+		Compliance:  compliance(good, total),
+		ErrorBudget: errorBudget(obj.Target, good, total),
+		BurnRates:   make([]BurnRate, len(obj.Alerts)),
+		History:     h.daily(now, days),
+	}
+	for i, alert := range obj.Alerts {
+		good, total := h.count(now, time.Duration(alert.Hours)*time.Hour)
+		rate := burnRate(obj.Target, good, total)
+		status.BurnRates[i] = BurnRate{
+			Hours:     alert.Hours,
+			Rate:      rate,
+			Threshold: alert.BurnRate,
+			Severity:  alert.GetSeverity(),
+			Exceeded:  total > 0 && rate >= alert.BurnRate,
+		}
+	}
+	t.mu.Unlock()
+	return status
+}
+
+// errorBudget returns the percentage of the bad minutes allowed by the target
+// that have not been used.
+func errorBudget(target float64, good, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	allowed := (1 - target/100) * float64(total)
+	return 100 * (1 - float64(total-good)/allowed)
+}
+
+// burnRate returns the fraction of bad minutes over the fraction allowed by
+// the target.
+func burnRate(target float64, good, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	rate := (float64(total-good) / float64(total)) / (1 - target/100)
+	// keep two decimals, so that the rate reads well in events
+	return math.Floor(rate*100+0.5) / 100
+}
+
+// Retain drops the history of the SLOs that are no longer defined, and the
+// measurements that are older than the longest window.  The SLOs are keyed by
+// service id.
+func (t *Tracker) Retain(slos map[string]servicedefinition.SLOs, now time.Time) {
+	keep := make(map[string]struct{})
+	for serviceID, objs := range slos {
+		for _, obj := range objs {
+			keep[historyKey(serviceID, obj.Name)] = struct{}{}
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, h := range t.histories {
+		if _, ok := keep[key]; !ok {
+			delete(t.histories, key)
+			continue
+		}
+		if h.prune(now); h.empty() {
+			delete(t.histories, key)
+		}
+	}
+}
+
+// Save writes the history to the file of the tracker
+func (t *Tracker) Save() error {
+	if t.path == "" {
+		return nil
+	}
+	t.mu.Lock()
+	data, err := json.Marshal(t.histories)
+	t.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return err
+	}
+	tmp := t.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.path)
+}
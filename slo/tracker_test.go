@@ -0,0 +1,139 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package slo
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/control-center/serviced/domain/servicedefinition"
+)
+
+var testSLO = servicedefinition.SLO{
+	Name:   "availability",
+	Type:   servicedefinition.SLOAvailability,
+	Target: 99,
+	Alerts: []servicedefinition.BurnRateAlert{
+		{Hours: 1, BurnRate: 2},
+		{Hours: 1, BurnRate: 10, Severity: 5},
+	},
+}
+
+// recordHour records the hour before now, with the last bad minutes bad
+func recordHour(tracker *Tracker, now time.Time, bad int) {
+	for i := 59; i >= 0; i-- {
+		tracker.Record("svc1", testSLO.Name, now.Add(-time.Duration(i)*time.Minute), i >= bad)
+	}
+}
+
+func TestTracker_Status(t *testing.T) {
+	now := time.Date(2018, 3, 2, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker("")
+	recordHour(tracker, now, 3)
+
+	status := tracker.Status("svc1", testSLO, now)
+	if status.Good != 57 || status.Total != 60 {
+		t.Fatalf("Expected 57 of 60 good minutes, got %d of %d", status.Good, status.Total)
+	}
+	if status.Compliance != 95 {
+		t.Errorf("Expected 95%% compliance, got %v", status.Compliance)
+	}
+	if status.Met() {
+		t.Errorf("Expected the objective to be missed")
+	}
+	if math.Abs(status.ErrorBudget+400) > 1e-6 {
+		t.Errorf("Expected the error budget to be overspent 5 times, got %v", status.ErrorBudget)
+	}
+	if len(status.BurnRates) != 2 {
+		t.Fatalf("Expected 2 burn rates, got %d", len(status.BurnRates))
+	}
+	if rate := status.BurnRates[0]; rate.Rate != 5 || !rate.Exceeded || rate.Severity != servicedefinition.DefaultBurnRateSeverity {
+		t.Errorf("Expected an exceeded burn rate of 5, got %+v", rate)
+	}
+	if rate := status.BurnRates[1]; rate.Exceeded || rate.Severity != 5 {
+		t.Errorf("Expected the burn rate to be below the threshold, got %+v", rate)
+	}
+	if len(status.History) != 1 {
+		t.Fatalf("Expected 1 day of history, got %d", len(status.History))
+	}
+	if day := status.History[0]; day.Date != "2018-03-02" || day.Good != 57 || day.Total != 60 {
+		t.Errorf("Unexpected history %+v", day)
+	}
+	if samples := status.Samples(now); len(samples) != 4 {
+		t.Errorf("Expected 4 samples, got %d", len(samples))
+	} else if samples[2].Metric != "slo.availability.burnrate.1h" || samples[2].Value != "5" {
+		t.Errorf("Unexpected burn rate sample %+v", samples[2])
+	}
+}
+
+func TestTracker_StatusWithoutMeasurements(t *testing.T) {
+	status := NewTracker("").Status("svc1", testSLO, time.Now())
+	if status.Compliance != 100 || status.ErrorBudget != 100 {
+		t.Errorf("Expected a full error budget, got %+v", status)
+	}
+	for _, rate := range status.BurnRates {
+		if rate.Exceeded {
+			t.Errorf("Expected no burn rate to be exceeded, got %+v", rate)
+		}
+	}
+}
+
+func TestTracker_Retain(t *testing.T) {
+	now := time.Date(2018, 3, 2, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker("")
+	recordHour(tracker, now, 0)
+
+	tracker.Retain(map[string]servicedefinition.SLOs{"svc1": {testSLO}}, now)
+	if status := tracker.Status("svc1", testSLO, now); status.Total != 60 {
+		t.Errorf("Expected 60 minutes to be retained, got %d", status.Total)
+	}
+
+	tracker.Retain(map[string]servicedefinition.SLOs{"svc1": {testSLO}}, now.AddDate(0, 0, servicedefinition.MaxSLOWindowDays+1))
+	if status := tracker.Status("svc1", testSLO, now); status.Total != 0 {
+		t.Errorf("Expected expired minutes to be dropped, got %d", status.Total)
+	}
+
+	recordHour(tracker, now, 0)
+	tracker.Retain(map[string]servicedefinition.SLOs{}, now)
+	if status := tracker.Status("svc1", testSLO, now); status.Total != 0 {
+		t.Errorf("Expected the history of a removed objective to be dropped, got %d", status.Total)
+	}
+}
+
+func TestTracker_Save(t *testing.T) {
+	dir, err := ioutil.TempDir("", "slo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "isvcs", FileName)
+
+	now := time.Date(2018, 3, 2, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker(path)
+	recordHour(tracker, now, 3)
+	if err := tracker.Save(); err != nil {
+		t.Fatalf("Unexpected error saving history: %v", err)
+	}
+
+	status := NewTracker(path).Status("svc1", testSLO, now)
+	if status.Good != 57 || status.Total != 60 {
+		t.Errorf("Expected 57 of 60 good minutes after loading, got %d of %d", status.Good, status.Total)
+	}
+}
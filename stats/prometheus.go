@@ -0,0 +1,168 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PrometheusPrefix is prepended to the name of each metric served by the
+// Exporter.
+const PrometheusPrefix = "serviced_"
+
+// PrometheusContentType is the content type of the text exposition format.
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Exporter serves the samples of its sources in the Prometheus text
+// exposition format, so serviced can be scraped as well as posting its stats
+// to the TSDB.
+type Exporter struct {
+	sync.Mutex
+	sources []func() []Sample
+}
+
+// NewExporter creates an Exporter with no sources.
+func NewExporter() *Exporter {
+	return &Exporter{}
+}
+
+// AddSource adds a function that returns samples to serve.  It is called on
+// every scrape, so it should not do more than read values that are already
+// collected.
+func (e *Exporter) AddSource(source func() []Sample) {
+	e.Lock()
+	defer e.Unlock()
+	e.sources = append(e.sources, source)
+}
+
+// AddRegistrySource adds a function that returns registries whose metrics
+// are served with their tags.
+func (e *Exporter) AddRegistrySource(source func() []TaggedRegistry) {
+	e.AddSource(func() []Sample {
+		now := time.Now()
+		samples := []Sample{}
+		for _, tagged := range source() {
+			samples = append(samples, RegistrySamples(now, tagged.Tags, tagged.Registry)...)
+		}
+		return samples
+	})
+}
+
+// Gather returns the samples of all of the sources.
+func (e *Exporter) Gather() []Sample {
+	e.Lock()
+	sources := e.sources
+	e.Unlock()
+	samples := []Sample{}
+	for _, source := range sources {
+		samples = append(samples, source()...)
+	}
+	return samples
+}
+
+// ServeHTTP writes the samples of all of the sources.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", PrometheusContentType)
+	if err := WritePrometheus(w, e.Gather()); err != nil {
+		plog.WithError(err).Debug("Could not write metrics")
+	}
+}
+
+type prometheusLine struct {
+	name   string
+	labels string
+	value  string
+}
+
+type byNameAndLabels []prometheusLine
+
+func (l byNameAndLabels) Len() int      { return len(l) }
+func (l byNameAndLabels) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l byNameAndLabels) Less(i, j int) bool {
+	if l[i].name == l[j].name {
+		return l[i].labels < l[j].labels
+	}
+	return l[i].name < l[j].name
+}
+
+// WritePrometheus writes samples in the Prometheus text exposition format.
+// Metric and tag names are prefixed and stripped of the characters that
+// Prometheus does not allow, and samples are grouped by metric.  If more
+// than one sample has the same name and tags, only the first is written.
+func WritePrometheus(w io.Writer, samples []Sample) error {
+	lines := make([]prometheusLine, 0, len(samples))
+	for _, sample := range samples {
+		lines = append(lines, prometheusLine{
+			name:   PrometheusPrefix + prometheusName(sample.Metric),
+			labels: prometheusLabels(sample.Tags),
+			value:  sample.Value,
+		})
+	}
+	sort.Stable(byNameAndLabels(lines))
+
+	buf := bufio.NewWriter(w)
+	for i, line := range lines {
+		if i > 0 && lines[i-1].name == line.name && lines[i-1].labels == line.labels {
+			continue
+		}
+		if i == 0 || lines[i-1].name != line.name {
+			fmt.Fprintf(buf, "# TYPE %s untyped\n", line.name)
+		}
+		fmt.Fprintf(buf, "%s%s %s\n", line.name, line.labels, line.value)
+	}
+	return buf.Flush()
+}
+
+// prometheusName replaces the characters that are not allowed in metric and
+// label names with underscores.
+func prometheusName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// prometheusLabels formats tags as a label set, sorted by name.
+func prometheusLabels(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	labels := make([]string, len(names))
+	for i, name := range names {
+		label := prometheusName(name)
+		if label != "" && label[0] >= '0' && label[0] <= '9' {
+			label = "_" + label
+		}
+		labels[i] = fmt.Sprintf(`%s="%s"`, label, labelEscaper.Replace(tags[name]))
+	}
+	return "{" + strings.Join(labels, ",") + "}"
+}
@@ -0,0 +1,92 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package stats
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	samples := []Sample{
+		{Metric: "load.avg1m", Value: "0.5", Tags: map[string]string{"controlplane_host_id": "abc"}},
+		{Metric: "cgroup.memory.totalrss", Value: "2048", Tags: map[string]string{
+			"controlplane_service_id":  "svc1",
+			"controlplane_instance_id": "0",
+		}},
+		{Metric: "load.avg1m", Value: "0.7", Tags: map[string]string{"controlplane_host_id": "abc"}},
+		{Metric: "health", Value: "1", Tags: map[string]string{"1check": "a \"quoted\"\nvalue"}},
+		{Metric: "uptime", Value: "10"},
+	}
+	buf := &bytes.Buffer{}
+	if err := WritePrometheus(buf, samples); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := `# TYPE serviced_cgroup_memory_totalrss untyped
+serviced_cgroup_memory_totalrss{controlplane_instance_id="0",controlplane_service_id="svc1"} 2048
+# TYPE serviced_health untyped
+serviced_health{_1check="a \"quoted\"\nvalue"} 1
+# TYPE serviced_load_avg1m untyped
+serviced_load_avg1m{controlplane_host_id="abc"} 0.5
+# TYPE serviced_uptime untyped
+serviced_uptime 10
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestExporter(t *testing.T) {
+	registry := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("rpc.errors", registry).Inc(3)
+	e := NewExporter()
+	e.AddSource(func() []Sample {
+		return []Sample{{Metric: "zookeeper.session", Value: "1", Timestamp: time.Now().Unix()}}
+	})
+	e.AddRegistrySource(func() []TaggedRegistry {
+		return []TaggedRegistry{{Tags: map[string]string{"method": "Master.GetHost"}, Registry: registry}}
+	})
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := recorder.Header().Get("Content-Type"); ct != PrometheusContentType {
+		t.Errorf("Unexpected content type %s", ct)
+	}
+	expected := `# TYPE serviced_rpc_errors untyped
+serviced_rpc_errors{method="Master.GetHost"} 3
+# TYPE serviced_zookeeper_session untyped
+serviced_zookeeper_session 1
+`
+	if recorder.Body.String() != expected {
+		t.Errorf("Unexpected output:\n%s", recorder.Body.String())
+	}
+}
+
+func TestTagValue(t *testing.T) {
+	for name, expected := range map[string]string{
+		"zproxy":         "zproxy",
+		"app.domain.com": "app.domain.com",
+		":22222":         "_22222",
+		"10.0.0.1:443":   "10.0.0.1_443",
+	} {
+		if actual := TagValue(name); actual != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, name, actual)
+		}
+	}
+}
@@ -17,6 +17,7 @@ package stats
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/Sirupsen/logrus"
@@ -40,8 +41,37 @@ type ServicedStatsReporter struct {
 	conn                coordclient.Connection
 	containerRegistries map[registryKey]metrics.Registry
 	docker              docker.Docker
+	registrySources     []func() []TaggedRegistry
 }
 
+// TaggedRegistry is a metrics registry whose samples are reported with a set
+// of tags, for metrics that are collected outside of the stats reporter.
+type TaggedRegistry struct {
+	Tags     map[string]string
+	Registry metrics.Registry
+}
+
+// TagValue replaces the characters of a name that are not allowed in a metric
+// tag, such as the colon of a port address.
+func TagValue(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '-', r == '_', r == '.', r == '/':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// histogramPercentiles are the percentiles reported for each histogram, with
+// their metric name suffixes.
+var (
+	histogramPercentiles = []float64{0.5, 0.95, 0.99}
+	histogramSuffixes    = []string{".p50", ".p95", ".p99"}
+)
+
 type registryKey struct {
 	serviceID  string
 	instanceID int
@@ -77,6 +107,14 @@ func NewServicedStatsReporter(destination string, interval time.Duration, conn c
 	return &ssr, nil
 }
 
+// AddRegistrySource adds a function that returns registries whose metrics
+// are reported along with the host and container metrics.
+func (sr *ServicedStatsReporter) AddRegistrySource(source func() []TaggedRegistry) {
+	sr.Lock()
+	defer sr.Unlock()
+	sr.registrySources = append(sr.registrySources, source)
+}
+
 // getOrCreateContainerRegistry returns a registry for a given service id or creates it
 // if it doesn't exist.
 func (sr *ServicedStatsReporter) getOrCreateContainerRegistry(serviceID string, instanceID int) metrics.Registry {
@@ -164,6 +202,44 @@ func (sr *ServicedStatsReporter) gatherStats(t time.Time) []Sample {
 			}
 		})
 	}
+	// Handle the metrics of the other registry sources.
+	sr.Lock()
+	sources := sr.registrySources
+	sr.Unlock()
+	for _, source := range sources {
+		for _, tagged := range source() {
+			tagmap := map[string]string{
+				"controlplane_host_id": sr.hostID,
+			}
+			for k, v := range tagged.Tags {
+				tagmap[k] = v
+			}
+			stats = append(stats, RegistrySamples(t, tagmap, tagged.Registry)...)
+		}
+	}
+	return stats
+}
+
+// RegistrySamples returns a sample, with the given tags, of each counter,
+// gauge and histogram in the registry.  Histograms are reported by their
+// percentiles.
+func RegistrySamples(t time.Time, tags map[string]string, registry metrics.Registry) []Sample {
+	stats := []Sample{}
+	registry.Each(func(name string, i interface{}) {
+		switch metric := i.(type) {
+		case metrics.Counter:
+			stats = append(stats, Sample{name, strconv.FormatInt(metric.Count(), 10), t.Unix(), tags})
+		case metrics.Gauge:
+			stats = append(stats, Sample{name, strconv.FormatInt(metric.Value(), 10), t.Unix(), tags})
+		case metrics.GaugeFloat64:
+			stats = append(stats, Sample{name, strconv.FormatFloat(metric.Value(), 'f', -1, 32), t.Unix(), tags})
+		case metrics.Histogram:
+			ps := metric.Percentiles(histogramPercentiles)
+			for i, suffix := range histogramSuffixes {
+				stats = append(stats, Sample{name + suffix, strconv.FormatFloat(ps[i], 'f', -1, 32), t.Unix(), tags})
+			}
+		}
+	})
 	return stats
 }
 
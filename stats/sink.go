@@ -0,0 +1,416 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/domain/pool"
+	"github.com/rcrowley/go-metrics"
+)
+
+var (
+	// sinkRetryInterval is how often a sink that is down is retried
+	sinkRetryInterval = 10 * time.Second
+
+	// sinkClient posts samples to the http sinks
+	sinkClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+// statsdPacketSize keeps statsd packets under the usual network MTU
+const statsdPacketSize = 1432
+
+// sinkWriter sends a batch of samples to a sink
+type sinkWriter interface {
+	write(samples []Sample) error
+}
+
+func newSinkWriter(config pool.MetricSink) (sinkWriter, error) {
+	switch config.Type {
+	case pool.SinkStatsD:
+		return &statsdWriter{address: config.Address, prefix: config.Prefix}, nil
+	case pool.SinkInfluxDB:
+		return &influxWriter{url: config.Address, prefix: config.Prefix}, nil
+	case pool.SinkOpenTSDB:
+		return &openTSDBWriter{url: config.Address, prefix: config.Prefix}, nil
+	default:
+		return nil, fmt.Errorf("unsupported metric sink type %q", config.Type)
+	}
+}
+
+// sortedTags returns the names of the tags in order
+func sortedTags(tags map[string]string) []string {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sampleTime returns the time of a sample, or now if it has none
+func sampleTime(sample Sample) time.Time {
+	if sample.Timestamp <= 0 {
+		return time.Now()
+	}
+	return time.Unix(sample.Timestamp, 0)
+}
+
+// statsdWriter sends each sample as a gauge, with its tags in the DogStatsD
+// format.
+type statsdWriter struct {
+	address string
+	prefix  string
+}
+
+var statsdEscaper = strings.NewReplacer(":", "_", "|", "_", "@", "_", ",", "_", "#", "_", "\n", "_")
+
+func (w *statsdWriter) write(samples []Sample) error {
+	conn, err := net.Dial("udp", w.address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	packet := &bytes.Buffer{}
+	flush := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_, err := conn.Write(packet.Bytes())
+		packet.Reset()
+		return err
+	}
+	for _, sample := range samples {
+		line := &bytes.Buffer{}
+		fmt.Fprintf(line, "%s:%s|g", statsdEscaper.Replace(w.prefix+sample.Metric), sample.Value)
+		for i, name := range sortedTags(sample.Tags) {
+			if i == 0 {
+				line.WriteString("|#")
+			} else {
+				line.WriteString(",")
+			}
+			fmt.Fprintf(line, "%s:%s", statsdEscaper.Replace(name), statsdEscaper.Replace(sample.Tags[name]))
+		}
+		if packet.Len() > 0 && packet.Len()+1+line.Len() > statsdPacketSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if packet.Len() > 0 {
+			packet.WriteString("\n")
+		}
+		packet.Write(line.Bytes())
+	}
+	return flush()
+}
+
+// influxWriter posts samples in the InfluxDB line protocol, with the sample
+// value as the "value" field.
+type influxWriter struct {
+	url    string
+	prefix string
+}
+
+var (
+	influxNameEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", `\n`)
+	influxTagEscaper  = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`, "\n", `\n`)
+)
+
+func (w *influxWriter) write(samples []Sample) error {
+	body := &bytes.Buffer{}
+	for _, sample := range samples {
+		value, err := strconv.ParseFloat(sample.Value, 64)
+		if err != nil {
+			continue
+		}
+		body.WriteString(influxNameEscaper.Replace(w.prefix + sample.Metric))
+		for _, name := range sortedTags(sample.Tags) {
+			if sample.Tags[name] == "" {
+				continue
+			}
+			fmt.Fprintf(body, ",%s=%s", influxTagEscaper.Replace(name), influxTagEscaper.Replace(sample.Tags[name]))
+		}
+		fmt.Fprintf(body, " value=%s %d\n", strconv.FormatFloat(value, 'f', -1, 64), sampleTime(sample).UnixNano())
+	}
+	return postSamples(w.url, "text/plain; charset=utf-8", body)
+}
+
+// openTSDBWriter posts samples as json to an OpenTSDB compatible put api
+type openTSDBWriter struct {
+	url    string
+	prefix string
+}
+
+type openTSDBSample struct {
+	Metric    string            `json:"metric"`
+	Timestamp int64             `json:"timestamp"`
+	Value     float64           `json:"value"`
+	Tags      map[string]string `json:"tags"`
+}
+
+func (w *openTSDBWriter) write(samples []Sample) error {
+	points := make([]openTSDBSample, 0, len(samples))
+	for _, sample := range samples {
+		value, err := strconv.ParseFloat(sample.Value, 64)
+		if err != nil {
+			continue
+		}
+		tags := sample.Tags
+		if tags == nil {
+			tags = map[string]string{}
+		}
+		points = append(points, openTSDBSample{
+			Metric:    w.prefix + sample.Metric,
+			Timestamp: sampleTime(sample).Unix(),
+			Value:     value,
+			Tags:      tags,
+		})
+	}
+	data, err := json.Marshal(points)
+	if err != nil {
+		return err
+	}
+	return postSamples(w.url, "application/json", bytes.NewReader(data))
+}
+
+// postSamples posts a batch of samples to an http sink
+func postSamples(url, contentType string, body io.Reader) error {
+	req, err := http.NewRequest("POST", url, body)
+	if err != nil {
+		return err
+	}
+	req.Header["User-Agent"] = statsReqUserAgent
+	req.Header.Set("Content-Type", contentType)
+	resp, err := sinkClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("received response status %q", resp.Status)
+	}
+	return nil
+}
+
+// Sink sends samples to a metric sink in the background.  Samples are
+// buffered while the sink is down, and the oldest are dropped when the
+// buffer is full.
+type Sink struct {
+	config   pool.MetricSink
+	writer   sinkWriter
+	lock     sync.Mutex
+	buffer   []Sample
+	notify   chan struct{}
+	done     chan struct{}
+	retry    time.Duration
+	registry metrics.Registry
+}
+
+// NewSink starts sending samples to a metric sink
+func NewSink(config pool.MetricSink) (*Sink, error) {
+	writer, err := newSinkWriter(config)
+	if err != nil {
+		return nil, err
+	}
+	s := &Sink{
+		config:   config,
+		writer:   writer,
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		retry:    sinkRetryInterval,
+		registry: metrics.NewRegistry(),
+	}
+	go s.loop()
+	return s, nil
+}
+
+// Send queues samples to be sent to the sink
+func (s *Sink) Send(samples []Sample) {
+	if len(samples) == 0 {
+		return
+	}
+	s.lock.Lock()
+	s.buffer = append(s.buffer, samples...)
+	s.trim()
+	s.lock.Unlock()
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// trim drops the oldest samples that do not fit in the buffer.  The caller
+// must hold the lock.
+func (s *Sink) trim() {
+	if over := len(s.buffer) - s.config.GetBufferSize(); over > 0 {
+		s.buffer = append([]Sample{}, s.buffer[over:]...)
+		metrics.GetOrRegisterCounter("metricsink.dropped", s.registry).Inc(int64(over))
+	}
+	metrics.GetOrRegisterGauge("metricsink.buffered", s.registry).Update(int64(len(s.buffer)))
+}
+
+// Close stops sending samples to the sink
+func (s *Sink) Close() {
+	close(s.done)
+}
+
+func (s *Sink) loop() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.notify:
+		case <-time.After(s.retry):
+		}
+		s.flush()
+	}
+}
+
+// flush writes the buffered samples to the sink, and puts them back in the
+// buffer if the sink is down.
+func (s *Sink) flush() {
+	s.lock.Lock()
+	batch := s.buffer
+	s.buffer = nil
+	s.lock.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+	if err := s.writer.write(batch); err != nil {
+		plog.WithFields(logrus.Fields{
+			"sink":    s.config.Name,
+			"type":    s.config.Type,
+			"samples": len(batch),
+		}).WithError(err).Debug("Unable to send samples to metric sink")
+		metrics.GetOrRegisterCounter("metricsink.failures", s.registry).Inc(1)
+		s.lock.Lock()
+		s.buffer = append(batch, s.buffer...)
+		s.trim()
+		s.lock.Unlock()
+		return
+	}
+	metrics.GetOrRegisterCounter("metricsink.sent", s.registry).Inc(int64(len(batch)))
+	s.lock.Lock()
+	s.trim()
+	s.lock.Unlock()
+}
+
+// Sinks fans samples out to each of the metric sinks of a pool
+type Sinks struct {
+	lock  sync.Mutex
+	sinks map[string]*Sink
+}
+
+// NewSinks creates an empty set of metric sinks
+func NewSinks() *Sinks {
+	return &Sinks{sinks: make(map[string]*Sink)}
+}
+
+// Update starts sending to new and changed sinks, and stops sending to the
+// sinks that were removed.
+func (s *Sinks) Update(configs pool.MetricSinks) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	names := make(map[string]struct{})
+	for _, config := range configs {
+		names[config.Name] = struct{}{}
+		if sink, ok := s.sinks[config.Name]; ok {
+			if sink.config == config {
+				continue
+			}
+			sink.Close()
+			delete(s.sinks, config.Name)
+		}
+		sink, err := NewSink(config)
+		if err != nil {
+			plog.WithField("sink", config.Name).WithError(err).Warn("Unable to start metric sink")
+			continue
+		}
+		s.sinks[config.Name] = sink
+	}
+	for name, sink := range s.sinks {
+		if _, ok := names[name]; !ok {
+			sink.Close()
+			delete(s.sinks, name)
+		}
+	}
+}
+
+// Watch updates the sinks at each interval until shutdown is closed.
+func (s *Sinks) Watch(interval time.Duration, get func() (pool.MetricSinks, error), shutdown <-chan interface{}) {
+	for {
+		if configs, err := get(); err != nil {
+			plog.WithError(err).Debug("Unable to get metric sinks")
+		} else {
+			s.Update(configs)
+		}
+		select {
+		case <-shutdown:
+			s.Close()
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Send queues samples to be sent to every sink
+func (s *Sinks) Send(samples []Sample) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, sink := range s.sinks {
+		sink.Send(samples)
+	}
+}
+
+// Registries returns the counters of samples sent, dropped and buffered by
+// each sink, tagged with its name and type.
+func (s *Sinks) Registries() []TaggedRegistry {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	result := make([]TaggedRegistry, 0, len(s.sinks))
+	for name, sink := range s.sinks {
+		result = append(result, TaggedRegistry{
+			Tags: map[string]string{
+				"metricsink_name": name,
+				"metricsink_type": string(sink.config.Type),
+			},
+			Registry: sink.registry,
+		})
+	}
+	return result
+}
+
+// Close stops sending to all of the sinks
+func (s *Sinks) Close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for name, sink := range s.sinks {
+		sink.Close()
+		delete(s.sinks, name)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package stats
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/control-center/serviced/domain/pool"
+	"github.com/rcrowley/go-metrics"
+)
+
+var sinkSamples = []Sample{
+	{Metric: "cgroup.memory.totalrss", Value: "2048", Timestamp: 1500000000, Tags: map[string]string{
+		"controlplane_service_id": "svc 1",
+		"controlplane_host_id":    "abc",
+	}},
+	{Metric: "load.avg1m", Value: "0.5", Timestamp: 1500000000},
+}
+
+// sinkServer records the bodies posted to it, and fails while down is set
+type sinkServer struct {
+	sync.Mutex
+	down   bool
+	bodies []string
+}
+
+func (s *sinkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+	if s.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	s.bodies = append(s.bodies, string(body))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *sinkServer) received() []string {
+	s.Lock()
+	defer s.Unlock()
+	return append([]string{}, s.bodies...)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the sink")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStatsDWriter(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen: %s", err)
+	}
+	defer conn.Close()
+
+	w := &statsdWriter{address: conn.LocalAddr().String(), prefix: "cc."}
+	if err := w.write(sinkSamples); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	buf := make([]byte, statsdPacketSize)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Could not read packet: %s", err)
+	}
+	expected := "cc.cgroup.memory.totalrss:2048|g|#controlplane_host_id:abc,controlplane_service_id:svc 1\ncc.load.avg1m:0.5|g"
+	if string(buf[:n]) != expected {
+		t.Errorf("Unexpected packet %q", buf[:n])
+	}
+}
+
+func TestInfluxWriter(t *testing.T) {
+	server := &sinkServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	w := &influxWriter{url: ts.URL + "/write?db=cc"}
+	if err := w.write(sinkSamples); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := `cgroup.memory.totalrss,controlplane_host_id=abc,controlplane_service_id=svc\ 1 value=2048 1500000000000000000
+load.avg1m value=0.5 1500000000000000000
+`
+	if bodies := server.received(); len(bodies) != 1 || bodies[0] != expected {
+		t.Errorf("Unexpected body %q", bodies)
+	}
+}
+
+func TestOpenTSDBWriter(t *testing.T) {
+	server := &sinkServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	w := &openTSDBWriter{url: ts.URL + "/api/put", prefix: "cc."}
+	if err := w.write(sinkSamples[1:]); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := `[{"metric":"cc.load.avg1m","timestamp":1500000000,"value":0.5,"tags":{}}]`
+	if bodies := server.received(); len(bodies) != 1 || bodies[0] != expected {
+		t.Errorf("Unexpected body %q", bodies)
+	}
+
+	server.down = true
+	if err := w.write(sinkSamples); err == nil {
+		t.Errorf("Expected an error from a sink that is down")
+	}
+}
+
+func TestSinks_BufferWhileDown(t *testing.T) {
+	defer func(interval time.Duration) { sinkRetryInterval = interval }(sinkRetryInterval)
+	sinkRetryInterval = 20 * time.Millisecond
+
+	server := &sinkServer{down: true}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	sinks := NewSinks()
+	defer sinks.Close()
+	sinks.Update(pool.MetricSinks{{Name: "tsdb", Type: pool.SinkOpenTSDB, Address: ts.URL, BufferSize: 3}})
+	registries := sinks.Registries()
+	if len(registries) != 1 || registries[0].Tags["metricsink_name"] != "tsdb" {
+		t.Fatalf("Unexpected registries %+v", registries)
+	}
+	registry := registries[0].Registry
+	failures := metrics.GetOrRegisterCounter("metricsink.failures", registry)
+	dropped := metrics.GetOrRegisterCounter("metricsink.dropped", registry)
+	sent := metrics.GetOrRegisterCounter("metricsink.sent", registry)
+
+	sinks.Send(sinkSamples)
+	waitFor(t, func() bool { return failures.Count() > 0 })
+	sinks.Send(sinkSamples)
+	waitFor(t, func() bool { return dropped.Count() == 1 })
+
+	server.Lock()
+	server.down = false
+	server.Unlock()
+	waitFor(t, func() bool { return sent.Count() == 3 })
+	bodies := server.received()
+	if len(bodies) != 1 || strings.Count(bodies[0], `"metric"`) != 3 {
+		t.Errorf("Unexpected bodies %q", bodies)
+	}
+
+	sinks.Update(nil)
+	if len(sinks.Registries()) != 0 {
+		t.Errorf("Expected the sink to be removed")
+	}
+}
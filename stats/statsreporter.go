@@ -24,6 +24,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -49,6 +50,9 @@ type statsReporter struct {
 	closeChannel    chan struct{}
 	updateStatsFunc updateStatsFunc
 	gatherStatsFunc gatherStatsFunc
+	samplesLock     sync.Mutex
+	samples         []Sample
+	sinks           *Sinks
 }
 
 // Sample is a single metric measurement
@@ -72,6 +76,13 @@ func (sr *statsReporter) report(d time.Duration) {
 		case t := <-tc:
 			sr.updateStatsFunc()
 			stats := sr.gatherStatsFunc(t)
+			sr.samplesLock.Lock()
+			sr.samples = stats
+			sinks := sr.sinks
+			sr.samplesLock.Unlock()
+			if sinks != nil {
+				sinks.Send(stats)
+			}
 			err := Post(sr.destination, stats)
 			if err != nil {
 				plog.WithField("destination", sr.destination).
@@ -81,6 +92,21 @@ func (sr *statsReporter) report(d time.Duration) {
 	}
 }
 
+// Samples returns the stats that were gathered at the last interval.
+func (sr *statsReporter) Samples() []Sample {
+	sr.samplesLock.Lock()
+	defer sr.samplesLock.Unlock()
+	return sr.samples
+}
+
+// SetSinks sends the stats gathered at each interval to the metric sinks,
+// as well as the TSDB.
+func (sr *statsReporter) SetSinks(sinks *Sinks) {
+	sr.samplesLock.Lock()
+	defer sr.samplesLock.Unlock()
+	sr.sinks = sinks
+}
+
 // Close shuts down the reporting goroutine.
 func (sr *statsReporter) Close() {
 	close(sr.closeChannel)
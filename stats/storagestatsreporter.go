@@ -15,6 +15,7 @@
 package stats
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/Sirupsen/logrus"
@@ -74,7 +75,15 @@ func (sr *StorageStatsReporter) gatherStats(t time.Time) []Sample {
 	return stats
 }
 
-func (sr StorageStatsReporter) updateStats() {
+// UpdateTenantUsage records the bytes used by a tenant, as measured by the
+// storage quota checks, so that it is reported for every storage driver and
+// not only the ones that report the usage of their tenants.
+func (sr *StorageStatsReporter) UpdateTenantUsage(tenantID string, used uint64) {
+	metricName := fmt.Sprintf("storage.filesystem.used.%s", tenantID)
+	metrics.GetOrRegisterGauge(metricName, sr.storageRegistry).Update(int64(used))
+}
+
+func (sr *StorageStatsReporter) updateStats() {
 	volumeStatuses := volume.GetStatus()
 	if volumeStatuses == nil || len(volumeStatuses.GetAllStatuses()) == 0 {
 		plog.Error("Unexpected error getting volume status")
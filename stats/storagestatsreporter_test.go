@@ -0,0 +1,84 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package stats
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/control-center/serviced/volume"
+	_ "github.com/control-center/serviced/volume/rsync"
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestStorageStatsReporter_TenantUsage(t *testing.T) {
+	root, err := ioutil.TempDir("", "storagestats")
+	if err != nil {
+		t.Fatalf("Could not create temp dir: %s", err)
+	}
+	defer os.RemoveAll(root)
+
+	// rsync does not report the usage of its tenants
+	if err := volume.InitDriver(volume.DriverTypeRsync, root, nil); err != nil {
+		t.Fatalf("Could not initialize rsync driver: %s", err)
+	}
+	defer volume.ShutdownAll()
+	driver, err := volume.GetDriver(root)
+	if err != nil {
+		t.Fatalf("Could not get rsync driver: %s", err)
+	}
+	vol, err := driver.Create("tenant1")
+	if err != nil {
+		t.Fatalf("Could not create volume: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(vol.Path(), "data"), make([]byte, 4096), 0644); err != nil {
+		t.Fatalf("Could not write to volume: %s", err)
+	}
+
+	sr := &StorageStatsReporter{hostID: "abc", storageRegistry: metrics.NewRegistry()}
+	sr.updateStats()
+	for _, sample := range sr.gatherStats(time.Now()) {
+		if sample.Metric == "storage.filesystem.used.tenant1" {
+			t.Fatalf("Expected no usage to be reported by the driver, got %s", sample.Value)
+		}
+	}
+
+	// the usage is measured by the storage quota checks
+	used, err := volume.BytesUsed(vol)
+	if err != nil {
+		t.Fatalf("Could not measure volume: %s", err)
+	}
+	sr.UpdateTenantUsage("tenant1", used)
+	sr.updateStats()
+	found := false
+	for _, sample := range sr.gatherStats(time.Now()) {
+		if sample.Metric == "storage.filesystem.used.tenant1" {
+			found = true
+			if sample.Value != "4096" {
+				t.Errorf("Expected 4096 bytes used, got %s", sample.Value)
+			}
+			if sample.Tags["controlplane_host_id"] != "abc" {
+				t.Errorf("Expected the host id tag, got %v", sample.Tags)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected the usage of the tenant to be reported")
+	}
+}
@@ -43,6 +43,17 @@ func ExecDockerExec(containerID string, bashcmd []string) error {
 	return syscall.Exec(command[0], command[0:], os.Environ())
 }
 
+// DockerExecCommand returns the docker exec command that attaches to the
+// container, for callers that need to wait for it to exit
+func DockerExecCommand(containerID string, bashcmd []string) (*exec.Cmd, error) {
+	command, err := generateDockerExecCommand(containerID, bashcmd, false)
+	if err != nil {
+		return nil, err
+	}
+	glog.V(1).Infof("run command for container:%v command: %v\n", containerID, command)
+	return exec.Command(command[0], command[1:]...), nil
+}
+
 // RunDockerExec runs the command using docker exec
 func RunDockerExec(containerID string, bashcmd []string) ([]byte, error) {
 	oldStdin := os.Stdin
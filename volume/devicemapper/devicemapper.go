@@ -28,7 +28,7 @@ import (
 )
 
 var (
-	ErrNoShrinkage          = errors.New("you can't shrink a device")
+	ErrNoShrinkage          = volume.ErrNoShrinkage
 	ErrInvalidOption        = errors.New("invalid option")
 	ErrInvalidArg           = errors.New("invalid argument")
 	ErrIncompatibleSnapshot = errors.New("incompatible snapshot")
@@ -392,6 +392,27 @@ func (d *DeviceMapperDriver) Resize(volumeName string, size uint64) error {
 	return nil
 }
 
+// EnforceQuota implements volume.QuotaEnforcer.EnforceQuota.  The filesystem
+// cannot grow beyond the thin device, so a limit at or above the current
+// device size is already enforced, and the device is left alone rather than
+// grown to the limit.  Devices cannot be shrunk, so a limit smaller than the
+// current device size returns ErrNoShrinkage and must be held by accounting
+// instead.
+func (d *DeviceMapperDriver) EnforceQuota(volumeName string, limit uint64) error {
+	vol, err := d.getVolume(volumeName, false)
+	if err != nil {
+		return err
+	}
+	curSize, err := d.deviceSize(vol.deviceHash())
+	if err != nil {
+		return err
+	}
+	if limit < curSize {
+		return ErrNoShrinkage
+	}
+	return nil
+}
+
 func (d *DeviceMapperDriver) resize(deviceHash string, size uint64) error {
 
 	// Get the current size of the device
@@ -0,0 +1,160 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package volume
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// QuotaState describes how a tenant's storage usage compares to its quota.
+type QuotaState string
+
+const (
+	QuotaStateNone     QuotaState = ""         // no quota is configured
+	QuotaStateOK       QuotaState = "ok"       // usage is below the soft limit
+	QuotaStateWarning  QuotaState = "warning"  // usage has reached the soft limit
+	QuotaStateExceeded QuotaState = "exceeded" // usage has reached the hard limit
+)
+
+var (
+	ErrInvalidQuota     = errors.New("soft limit must not be greater than the hard limit")
+	ErrQuotaUnsupported = errors.New("driver does not support quota enforcement")
+	ErrNoShrinkage      = errors.New("you can't shrink a device")
+)
+
+// DirectoryUsageTTL is how long the measured size of a volume directory is
+// reused before the directory is walked again.
+var DirectoryUsageTTL = 5 * time.Minute
+
+type directoryUsage struct {
+	bytes    uint64
+	measured time.Time
+}
+
+var (
+	dirUsageMu sync.Mutex
+	dirUsage   = make(map[string]directoryUsage)
+)
+
+// StorageQuota limits the application storage that a tenant may consume.  A
+// limit of 0 is unlimited.
+type StorageQuota struct {
+	SoftLimit uint64 // Bytes in use at which a warning is raised
+	HardLimit uint64 // Bytes in use at which the tenant is emergency stopped
+}
+
+// IsSet returns true if either limit is configured.
+func (q StorageQuota) IsSet() bool {
+	return q.SoftLimit > 0 || q.HardLimit > 0
+}
+
+// Validate verifies that the limits are consistent with one another.
+func (q StorageQuota) Validate() error {
+	if q.SoftLimit > 0 && q.HardLimit > 0 && q.SoftLimit > q.HardLimit {
+		return ErrInvalidQuota
+	}
+	return nil
+}
+
+// Check returns the quota state for the given number of bytes in use.
+func (q StorageQuota) Check(used uint64) QuotaState {
+	switch {
+	case !q.IsSet():
+		return QuotaStateNone
+	case q.HardLimit > 0 && used >= q.HardLimit:
+		return QuotaStateExceeded
+	case q.SoftLimit > 0 && used >= q.SoftLimit:
+		return QuotaStateWarning
+	default:
+		return QuotaStateOK
+	}
+}
+
+// TenantQuotaStatus reports the storage usage of a tenant against its quota.
+type TenantQuotaStatus struct {
+	TenantID string
+	Quota    StorageQuota
+	Used     uint64
+	State    QuotaState
+}
+
+// QuotaEnforcer is implemented by drivers that are able to cap the size of a
+// tenant volume.  Drivers that do not implement it are held to their quota by
+// periodic accounting instead.
+type QuotaEnforcer interface {
+	// EnforceQuota limits the volume to at most the given number of bytes.
+	EnforceQuota(volumeName string, limit uint64) error
+}
+
+// EnforceQuota asks the driver to cap the volume at the given limit.  Returns
+// ErrQuotaUnsupported if the driver cannot do so.
+func EnforceQuota(driver Driver, volumeName string, limit uint64) error {
+	enforcer, ok := driver.(QuotaEnforcer)
+	if !ok {
+		return ErrQuotaUnsupported
+	}
+	return enforcer.EnforceQuota(volumeName, limit)
+}
+
+// BytesUsed returns the number of bytes consumed by the volume.  Devicemapper
+// volumes have their own filesystem, so usage is read from the filesystem;
+// for every other driver the volume directory is walked, at most once every
+// DirectoryUsageTTL.
+func BytesUsed(vol Volume) (uint64, error) {
+	if vol.Driver().DriverType() == DriverTypeDeviceMapper {
+		return FilesystemBytesUsed(vol.Path()), nil
+	}
+	return cachedDirectoryBytesUsed(vol.Path())
+}
+
+// cachedDirectoryBytesUsed returns the size of the directory from the last
+// walk, if it has not expired.
+func cachedDirectoryBytesUsed(path string) (uint64, error) {
+	now := time.Now()
+	dirUsageMu.Lock()
+	usage, ok := dirUsage[path]
+	dirUsageMu.Unlock()
+	if ok && now.Sub(usage.measured) < DirectoryUsageTTL {
+		return usage.bytes, nil
+	}
+
+	used, err := DirectoryBytesUsed(path)
+	if err != nil {
+		return 0, err
+	}
+	dirUsageMu.Lock()
+	dirUsage[path] = directoryUsage{bytes: used, measured: now}
+	dirUsageMu.Unlock()
+	return used, nil
+}
+
+// DirectoryBytesUsed returns the total size of the regular files beneath a
+// path.
+func DirectoryBytesUsed(path string) (uint64, error) {
+	var total uint64
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			total += uint64(info.Size())
+		}
+		return nil
+	})
+	return total, err
+}
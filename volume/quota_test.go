@@ -0,0 +1,123 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package volume_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/control-center/serviced/volume"
+	"github.com/control-center/serviced/volume/mocks"
+	. "gopkg.in/check.v1"
+)
+
+type QuotaSuite struct{}
+
+var _ = Suite(&QuotaSuite{})
+
+func (s *QuotaSuite) TestValidate(c *C) {
+	c.Assert(StorageQuota{}.Validate(), IsNil)
+	c.Assert(StorageQuota{SoftLimit: 10}.Validate(), IsNil)
+	c.Assert(StorageQuota{HardLimit: 10}.Validate(), IsNil)
+	c.Assert(StorageQuota{SoftLimit: 10, HardLimit: 10}.Validate(), IsNil)
+	c.Assert(StorageQuota{SoftLimit: 20, HardLimit: 10}.Validate(), Equals, ErrInvalidQuota)
+}
+
+func (s *QuotaSuite) TestCheck(c *C) {
+	c.Assert(StorageQuota{}.Check(100), Equals, QuotaStateNone)
+
+	quota := StorageQuota{SoftLimit: 50, HardLimit: 100}
+	c.Assert(quota.Check(0), Equals, QuotaStateOK)
+	c.Assert(quota.Check(49), Equals, QuotaStateOK)
+	c.Assert(quota.Check(50), Equals, QuotaStateWarning)
+	c.Assert(quota.Check(99), Equals, QuotaStateWarning)
+	c.Assert(quota.Check(100), Equals, QuotaStateExceeded)
+
+	// soft limit only
+	quota = StorageQuota{SoftLimit: 50}
+	c.Assert(quota.Check(1000), Equals, QuotaStateWarning)
+
+	// hard limit only
+	quota = StorageQuota{HardLimit: 100}
+	c.Assert(quota.Check(99), Equals, QuotaStateOK)
+	c.Assert(quota.Check(100), Equals, QuotaStateExceeded)
+}
+
+func (s *QuotaSuite) TestApplyQuotas(c *C) {
+	statuses := &Statuses{
+		DeviceMapperStatusMap: map[string]*DeviceMapperStatus{
+			"/dfs": &DeviceMapperStatus{
+				Tenants: []TenantStorageStats{
+					{TenantID: "tenant1", FilesystemUsed: 75},
+					{TenantID: "tenant2", FilesystemUsed: 75},
+				},
+			},
+		},
+	}
+	statuses.ApplyQuotas(map[string]StorageQuota{
+		"tenant1": StorageQuota{SoftLimit: 50, HardLimit: 100},
+	})
+	tenants := statuses.DeviceMapperStatusMap["/dfs"].Tenants
+	c.Assert(tenants[0].Quota, Equals, StorageQuota{SoftLimit: 50, HardLimit: 100})
+	c.Assert(tenants[0].QuotaState, Equals, QuotaStateWarning)
+	c.Assert(tenants[1].Quota.IsSet(), Equals, false)
+	c.Assert(tenants[1].QuotaState, Equals, QuotaStateNone)
+}
+
+func (s *QuotaSuite) TestDirectoryBytesUsed(c *C) {
+	root := c.MkDir()
+	os.MkdirAll(filepath.Join(root, "a", "b"), 0755)
+	ioutil.WriteFile(filepath.Join(root, "file1"), make([]byte, 10), 0644)
+	ioutil.WriteFile(filepath.Join(root, "a", "file2"), make([]byte, 20), 0644)
+	ioutil.WriteFile(filepath.Join(root, "a", "b", "file3"), make([]byte, 30), 0644)
+	os.Symlink(filepath.Join(root, "file1"), filepath.Join(root, "link"))
+
+	used, err := DirectoryBytesUsed(root)
+	c.Assert(err, IsNil)
+	c.Assert(used, Equals, uint64(60))
+
+	_, err = DirectoryBytesUsed(filepath.Join(root, "missing"))
+	c.Assert(err, NotNil)
+}
+
+func (s *QuotaSuite) TestBytesUsedCached(c *C) {
+	defer func(ttl time.Duration) { DirectoryUsageTTL = ttl }(DirectoryUsageTTL)
+	DirectoryUsageTTL = time.Hour
+
+	root := c.MkDir()
+	driver := &mocks.Driver{}
+	vol := &mocks.Volume{}
+	vol.On("Driver").Return(driver)
+	vol.On("Path").Return(root)
+
+	ioutil.WriteFile(filepath.Join(root, "file1"), make([]byte, 10), 0644)
+	used, err := BytesUsed(vol)
+	c.Assert(err, IsNil)
+	c.Assert(used, Equals, uint64(10))
+
+	// the directory is not walked again until the usage expires
+	ioutil.WriteFile(filepath.Join(root, "file2"), make([]byte, 20), 0644)
+	used, err = BytesUsed(vol)
+	c.Assert(err, IsNil)
+	c.Assert(used, Equals, uint64(10))
+
+	DirectoryUsageTTL = 0
+	used, err = BytesUsed(vol)
+	c.Assert(err, IsNil)
+	c.Assert(used, Equals, uint64(30))
+}
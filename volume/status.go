@@ -195,6 +195,19 @@ func (s *Statuses) GetAllStatuses() map[string]Status {
 	return result
 }
 
+// ApplyQuotas annotates the tenant storage stats with the given quotas,
+// keyed by tenant id.
+func (s *Statuses) ApplyQuotas(quotas map[string]StorageQuota) {
+	for _, status := range s.DeviceMapperStatusMap {
+		for i, tenant := range status.Tenants {
+			if quota, ok := quotas[tenant.TenantID]; ok {
+				status.Tenants[i].Quota = quota
+				status.Tenants[i].QuotaState = quota.Check(tenant.FilesystemUsed)
+			}
+		}
+	}
+}
+
 // GetStatus retrieves the status for the volumeNames passed in. If volumeNames is empty, it gets all statuses.
 func GetStatus() *Statuses {
 	result := &Statuses{}
@@ -268,6 +281,9 @@ type TenantStorageStats struct {
 
 	NumberSnapshots         int
 	SnapshotAllocatedBlocks uint64
+
+	Quota      StorageQuota
+	QuotaState QuotaState
 }
 
 type DeviceMapperStatus struct {
@@ -311,6 +327,8 @@ Data (total/used/avail):	{{bytes .PoolDataTotal}}	/ {{bytes .PoolDataUsed}}	({{p
 Volume Mount Point:	{{.VolumePath}}
 Filesystem (total/used/avail):	{{bytes .FilesystemTotal}} / {{bytes .FilesystemUsed}}	({{percent .FilesystemUsed .FilesystemTotal | noescape}}) / {{bytes .FilesystemAvailable}}	({{percent .FilesystemAvailable .FilesystemTotal | noescape}})
 Virtual device size:	{{blocksToBytes .DeviceTotalBlocks}}
+{{if .QuotaState}}Quota (soft/hard):	{{quota .Quota.SoftLimit}} / {{quota .Quota.HardLimit}}	({{.QuotaState}})
+{{end -}}
 {{range .Errors}}
 {{.}}
 {{end -}}
@@ -327,6 +345,14 @@ var funcMap = template.FuncMap{
 	"bytesToBlocks": BytesToBlocks,
 	"percent":       Percent,
 	"noescape":      Noescape,
+	"quota":         quotaLimit,
+}
+
+func quotaLimit(limit uint64) string {
+	if limit == 0 {
+		return "none"
+	}
+	return ToBytes(limit)
 }
 
 func (s DeviceMapperStatus) String() string {
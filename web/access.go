@@ -0,0 +1,222 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/control-center/serviced/domain/servicedefinition"
+	"github.com/control-center/serviced/stats"
+	"github.com/rcrowley/go-metrics"
+)
+
+var (
+	// ErrAccessDenied is returned when the client address is not allowed to
+	// reach the public endpoint.
+	ErrAccessDenied = errors.New("access denied")
+
+	// ErrRateLimited is returned when the public endpoint has exceeded its
+	// rate limit.
+	ErrRateLimited = errors.New("rate limit exceeded")
+)
+
+// endpointKey identifies the public endpoint of a metrics registry
+type endpointKey struct {
+	kind string
+	name string
+}
+
+var (
+	endpointMu         = &sync.Mutex{}
+	endpointRegistries = make(map[endpointKey]metrics.Registry)
+)
+
+// endpointRegistry returns the metrics registry of a public endpoint,
+// creating it if it does not exist.
+func endpointRegistry(kind, name string) metrics.Registry {
+	endpointMu.Lock()
+	defer endpointMu.Unlock()
+	key := endpointKey{kind, name}
+	reg, ok := endpointRegistries[key]
+	if !ok {
+		reg = metrics.NewRegistry()
+		endpointRegistries[key] = reg
+	}
+	return reg
+}
+
+// PublicEndpointRegistries returns the metrics of every public endpoint
+// served by this host, tagged with the endpoint type (vhost or port) and
+// name.
+func PublicEndpointRegistries() []stats.TaggedRegistry {
+	endpointMu.Lock()
+	defer endpointMu.Unlock()
+	result := make([]stats.TaggedRegistry, 0, len(endpointRegistries))
+	for key, reg := range endpointRegistries {
+		result = append(result, stats.TaggedRegistry{
+			Tags: map[string]string{
+				"publicendpoint_type": key.kind,
+				"publicendpoint_name": stats.TagValue(key.name),
+			},
+			Registry: reg,
+		})
+	}
+	return result
+}
+
+// rateLimiter is a token bucket that refills at rate tokens per second up to
+// burst tokens.
+type rateLimiter struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// allow takes a token from the bucket and returns false if it is empty.
+func (l *rateLimiter) allow(now time.Time) bool {
+	if elapsed := now.Sub(l.last).Seconds(); elapsed > 0 {
+		l.tokens = math.Min(l.burst, l.tokens+elapsed*l.rate)
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// AccessFilter enforces the access rules of a public endpoint.
+type AccessFilter struct {
+	mu      *sync.Mutex
+	allow   []*net.IPNet
+	deny    []*net.IPNet
+	limiter *rateLimiter
+	denied  metrics.Counter
+	limited metrics.Counter
+}
+
+// NewAccessFilter creates the access filter of a public endpoint.  Invalid
+// addresses are ignored, since the rules are validated when they are saved.
+func NewAccessFilter(kind, name string, rules servicedefinition.AccessRules) *AccessFilter {
+	reg := endpointRegistry(kind, name)
+	f := &AccessFilter{
+		mu:      &sync.Mutex{},
+		denied:  metrics.GetOrRegisterCounter("publicendpoint.denied", reg),
+		limited: metrics.GetOrRegisterCounter("publicendpoint.ratelimited", reg),
+	}
+	f.setRules(rules)
+	return f
+}
+
+// SetRules updates the rules of the filter.  The token bucket of the rate
+// limit is kept unless the rate limit itself changed, so that clients do not
+// get a full bucket whenever the endpoint is updated.
+func (f *AccessFilter) SetRules(rules servicedefinition.AccessRules) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.setRules(rules)
+}
+
+func (f *AccessFilter) setRules(rules servicedefinition.AccessRules) {
+	f.allow = parseNetworks(rules.Allow)
+	f.deny = parseNetworks(rules.Deny)
+	if rules.RateLimit <= 0 {
+		f.limiter = nil
+	} else if l := f.limiter; l == nil || l.rate != rules.RateLimit || l.burst != float64(rules.GetBurst()) {
+		f.limiter = newRateLimiter(rules.RateLimit, rules.GetBurst())
+	}
+}
+
+func parseNetworks(addresses []string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, address := range addresses {
+		if network, err := servicedefinition.ParseCIDR(address); err == nil {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns an error if a request or connection from the remote address
+// is not allowed.  Deny rules take precedence over allow rules; if there are
+// allow rules, the address must match one of them.
+func (f *AccessFilter) Check(remoteAddr string) error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.allow) > 0 || len(f.deny) > 0 {
+		host, _, err := net.SplitHostPort(remoteAddr)
+		if err != nil {
+			host = remoteAddr
+		}
+		ip := net.ParseIP(host)
+		if ip == nil || containsIP(f.deny, ip) || (len(f.allow) > 0 && !containsIP(f.allow, ip)) {
+			f.denied.Inc(1)
+			return ErrAccessDenied
+		}
+	}
+	if f.limiter != nil && !f.limiter.allow(time.Now()) {
+		f.limited.Inc(1)
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// accessListener closes the connections that are rejected by the access
+// filter of a public port.
+type accessListener struct {
+	net.Listener
+	filter func() *AccessFilter
+}
+
+// Accept waits for the next connection that passes the access filter
+func (l *accessListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		if err := l.filter().Check(conn.RemoteAddr().String()); err != nil {
+			plog.WithField("remoteaddr", conn.RemoteAddr().String()).WithError(err).Debug("Rejected connection to public port")
+			conn.Close()
+			continue
+		}
+		return conn, nil
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package web
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/control-center/serviced/domain/servicedefinition"
+	"github.com/control-center/serviced/zzk/registry"
+	"github.com/control-center/serviced/zzk/service"
+)
+
+func TestAccessFilter_Check(t *testing.T) {
+	f := NewAccessFilter("vhost", "check", servicedefinition.AccessRules{
+		Allow: []string{"10.0.0.0/8", "192.168.1.1"},
+		Deny:  []string{"10.1.0.0/16"},
+	})
+
+	for remoteAddr, expected := range map[string]error{
+		"10.0.0.1:5000":    nil,
+		"192.168.1.1:5000": nil,
+		"192.168.1.2:5000": ErrAccessDenied,
+		"10.1.2.3:5000":    ErrAccessDenied,
+		"172.17.0.1":       ErrAccessDenied,
+		"garbage":          ErrAccessDenied,
+	} {
+		if actual := f.Check(remoteAddr); actual != expected {
+			t.Errorf("Expected %v for %s, got %v", expected, remoteAddr, actual)
+		}
+	}
+	if count := f.denied.Count(); count != 4 {
+		t.Errorf("Expected 4 denied, got %d", count)
+	}
+
+	// a nil filter lets everything through
+	var open *AccessFilter
+	if err := open.Check("10.1.2.3:5000"); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	l := newRateLimiter(2, 3)
+	l.last = now
+
+	// the burst is available at once
+	for i := 0; i < 3; i++ {
+		if !l.allow(now) {
+			t.Fatalf("Expected token %d to be allowed", i)
+		}
+	}
+	if l.allow(now) {
+		t.Fatalf("Expected the bucket to be empty")
+	}
+
+	// refills at the rate
+	now = now.Add(500 * time.Millisecond)
+	if !l.allow(now) {
+		t.Errorf("Expected a token after half a second")
+	}
+	if l.allow(now) {
+		t.Errorf("Expected the bucket to be empty")
+	}
+
+	// never holds more than the burst
+	now = now.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		if !l.allow(now) {
+			t.Fatalf("Expected token %d to be allowed", i)
+		}
+	}
+	if l.allow(now) {
+		t.Errorf("Expected the bucket to be empty")
+	}
+}
+
+func TestVHostHandler_Access(t *testing.T) {
+	h := NewVHostHandler()
+	h.Enable()
+	h.SetAccess(NewAccessFilter("vhost", "access", servicedefinition.AccessRules{
+		Deny:      []string{"10.1.2.3"},
+		RateLimit: 1,
+	}))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.1.2.3:5000"
+	w := httptest.NewRecorder()
+	h.Handle(false, w, r)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected %d, got %d", http.StatusForbidden, w.Code)
+	}
+
+	// the first request uses the only token, and finds no exports
+	r.RemoteAddr = "10.0.0.1:5000"
+	w = httptest.NewRecorder()
+	h.Handle(false, w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.Handle(false, w, r)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected a Retry-After header")
+	}
+}
+
+func TestVHostManager_SetAccess(t *testing.T) {
+	m := NewVHostManager(false, nil)
+	rules := servicedefinition.AccessRules{RateLimit: 1}
+	m.SetAccess("myhost", rules)
+	filter := m.vhosts["myhost"].access
+	if err := filter.Check("10.0.0.1:5000"); err != nil {
+		t.Fatalf("Expected the only token to be allowed, got %v", err)
+	}
+
+	// the vhost is updated with the same rate limit, which keeps the bucket
+	rules.Deny = []string{"10.1.2.3"}
+	m.SetAccess("myhost", rules)
+	m.SetAccess("myhost", rules)
+	if access := m.vhosts["myhost"].access; access != filter {
+		t.Fatalf("Expected the access filter to be updated in place")
+	}
+	if err := filter.Check("10.0.0.1:5000"); err != ErrRateLimited {
+		t.Errorf("Expected %v, got %v", ErrRateLimited, err)
+	}
+	if err := filter.Check("10.1.2.3:5000"); err != ErrAccessDenied {
+		t.Errorf("Expected %v, got %v", ErrAccessDenied, err)
+	}
+
+	// a new rate limit starts with a full bucket
+	rules.RateLimit = 2
+	m.SetAccess("myhost", rules)
+	for i := 0; i < 2; i++ {
+		if err := filter.Check("10.0.0.1:5000"); err != nil {
+			t.Errorf("Expected token %d to be allowed, got %v", i, err)
+		}
+	}
+
+	// and no rate limit lets everything through
+	m.SetAccess("myhost", servicedefinition.AccessRules{})
+	if err := filter.Check("10.0.0.1:5000"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestAccessListener(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen: %s", err)
+	}
+	filter := NewAccessFilter("port", "listener", servicedefinition.AccessRules{Deny: []string{"127.0.0.1"}})
+	l := &accessListener{Listener: listener, filter: func() *AccessFilter { return filter }}
+	defer l.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := l.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Could not dial: %s", err)
+	}
+	defer conn.Close()
+
+	// the connection is closed by the listener
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Errorf("Expected the connection to be closed")
+	}
+	select {
+	case <-accepted:
+		t.Errorf("Expected the connection to be rejected")
+	default:
+	}
+	if count := filter.denied.Count(); count != 1 {
+		t.Errorf("Expected 1 denied, got %d", count)
+	}
+
+	found := false
+	for _, tagged := range PublicEndpointRegistries() {
+		if tagged.Tags["publicendpoint_type"] == "port" && tagged.Tags["publicendpoint_name"] == "listener" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the registry of the port")
+	}
+}
+
+func TestKeepAlive(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen: %s", err)
+	}
+	defer listener.Close()
+
+	cancel := make(chan struct{})
+	l := keepAlive(&accessListener{Listener: listener, filter: func() *AccessFilter { return nil }}, cancel)
+	filtered, ok := l.(*accessListener)
+	if !ok {
+		t.Fatalf("Expected the access listener to be kept, got %T", l)
+	}
+	if _, ok := filtered.Listener.(*TCPKeepAliveListener); !ok {
+		t.Errorf("Expected a keep alive listener underneath, got %T", filtered.Listener)
+	}
+}
+
+func TestServeUDP(t *testing.T) {
+	// the export must be on a local, non-loopback address to skip the mux
+	var hostIP string
+	for ip := range ipmap {
+		hostIP = ip
+		break
+	}
+	if hostIP == "" {
+		t.Skip("No local address to export from")
+	}
+	echo, err := net.ListenPacket("udp4", hostIP+":0")
+	if err != nil {
+		t.Fatalf("Could not listen: %s", err)
+	}
+	defer echo.Close()
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := echo.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			echo.WriteTo(buf[:n], addr)
+		}
+	}()
+
+	exports := NewBalancedExports([]registry.ExportDetails{
+		{
+			ExportBinding: service.ExportBinding{Application: "syslog", Protocol: "udp", PortNumber: uint16(echo.LocalAddr().(*net.UDPAddr).Port)},
+			HostIP:        hostIP,
+			PrivateIP:     hostIP,
+		},
+	})
+	var filter *AccessFilter
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen: %s", err)
+	}
+	cancel := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		ServeUDP(cancel, conn, exports, func() *AccessFilter { return filter })
+		close(done)
+	}()
+	defer func() {
+		close(cancel)
+		<-done
+	}()
+
+	client, err := net.Dial("udp4", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Could not dial: %s", err)
+	}
+	defer client.Close()
+	client.Write([]byte("<13>hello"))
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	n, err := client.Read(buf)
+	if err != nil {
+		t.Fatalf("Could not read the reply: %s", err)
+	}
+	if reply := string(buf[:n]); reply != "<13>hello" {
+		t.Errorf("Unexpected reply %q", reply)
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/control-center/serviced/zzk/registry"
+	"github.com/rcrowley/go-metrics"
+)
+
+// AccessLogType is the logstash type of the access log entries, so they can
+// be searched apart from the service logs.
+const AccessLogType = "serviced-access"
+
+const (
+	accessLogBuffer   = 1024
+	accessLogMaxDelay = 90 * time.Second
+)
+
+// AccessLogEntry describes a request served by a vhost or public port
+type AccessLogEntry struct {
+	Type        string    `json:"type"`
+	Timestamp   time.Time `json:"@timestamp"`
+	Endpoint    string    `json:"endpoint"`
+	Name        string    `json:"name"`
+	ClientIP    string    `json:"clientip"`
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`
+	Status      int       `json:"status"`
+	Bytes       int64     `json:"bytes"`
+	Latency     float64   `json:"latency_ms"`
+	Application string    `json:"application,omitempty"`
+	HostIP      string    `json:"hostip,omitempty"`
+	PrivateIP   string    `json:"privateip,omitempty"`
+	InstanceID  int       `json:"instanceid"`
+}
+
+// AccessLogger ships access log entries to logstash as json lines.  Entries
+// are dropped rather than blocking requests when logstash is not keeping up.
+type AccessLogger struct {
+	address string
+	entries chan AccessLogEntry
+}
+
+// NewAccessLogger creates a new access logger that ships entries to the
+// logstash tcp input at address.
+func NewAccessLogger(address string) *AccessLogger {
+	return &AccessLogger{
+		address: address,
+		entries: make(chan AccessLogEntry, accessLogBuffer),
+	}
+}
+
+// Log queues an entry to be shipped
+func (l *AccessLogger) Log(entry AccessLogEntry) {
+	if l == nil {
+		return
+	}
+	select {
+	case l.entries <- entry:
+	default:
+		plog.Debug("Access log buffer is full, dropping entry")
+	}
+}
+
+// Run ships the queued entries until shutdown, reconnecting to logstash with
+// a backoff when the connection fails.
+func (l *AccessLogger) Run(shutdown <-chan interface{}) {
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	delay := time.Second
+	for {
+		var entry AccessLogEntry
+		select {
+		case entry = <-l.entries:
+		case <-shutdown:
+			return
+		}
+
+		if conn == nil {
+			var err error
+			if conn, err = net.DialTimeout("tcp", l.address, time.Second); err != nil {
+				plog.WithField("address", l.address).WithError(err).Debug("Could not connect to logstash, dropping access log entry")
+				conn = nil
+				select {
+				case <-time.After(delay):
+				case <-shutdown:
+					return
+				}
+				if delay *= 2; delay > accessLogMaxDelay {
+					delay = accessLogMaxDelay
+				}
+				continue
+			}
+			delay = time.Second
+		}
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			plog.WithError(err).Debug("Could not marshal access log entry")
+			continue
+		}
+		if _, err := fmt.Fprintln(conn, string(data)); err != nil {
+			plog.WithError(err).Debug("Could not write to logstash")
+			conn.Close()
+			conn = nil
+		}
+	}
+}
+
+// accessLogWriter records the status and size of a response, and the export
+// that served it.
+type accessLogWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+	export *registry.ExportDetails
+}
+
+// newAccessLogWriter wraps the response writer of a public endpoint request
+func newAccessLogWriter(w http.ResponseWriter) *accessLogWriter {
+	return &accessLogWriter{ResponseWriter: w}
+}
+
+// setAccessExport records the export that serves the request, if the
+// response is being logged.
+func setAccessExport(w http.ResponseWriter, export *registry.ExportDetails) {
+	if aw, ok := w.(*accessLogWriter); ok {
+		aw.export = export
+	}
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *accessLogWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements http.ResponseWriter
+func (w *accessLogWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher
+func (w *accessLogWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, for websockets
+func (w *accessLogWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		if w.status == 0 {
+			w.status = http.StatusSwitchingProtocols
+		}
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("response writer does not support hijacking")
+}
+
+// recordAccess updates the request metrics of a public endpoint and ships
+// the access log entry.  The path is passed in because the proxy may strip
+// the prefix of a route from the request.
+func recordAccess(l *AccessLogger, kind, name string, w *accessLogWriter, r *http.Request, path string, start time.Time) {
+	latency := float64(time.Since(start)) / float64(time.Millisecond)
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	reg := endpointRegistry(kind, name)
+	metrics.GetOrRegisterCounter(fmt.Sprintf("publicendpoint.requests.%dxx", status/100), reg).Inc(1)
+	metrics.GetOrRegisterCounter("publicendpoint.bytes", reg).Inc(w.bytes)
+	metrics.GetOrRegisterHistogram("publicendpoint.latency", reg, metrics.NewExpDecaySample(1028, 0.015)).Update(int64(latency))
+
+	clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		clientIP = r.RemoteAddr
+	}
+	entry := AccessLogEntry{
+		Type:      AccessLogType,
+		Timestamp: start.UTC(),
+		Endpoint:  kind,
+		Name:      name,
+		ClientIP:  clientIP,
+		Method:    r.Method,
+		Path:      path,
+		Status:    status,
+		Bytes:     w.bytes,
+		Latency:   latency,
+	}
+	if w.export != nil {
+		entry.Application = w.export.Application
+		entry.HostIP = w.export.HostIP
+		entry.PrivateIP = w.export.PrivateIP
+		entry.InstanceID = w.export.InstanceID
+	}
+	l.Log(entry)
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package web
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/domain/servicedefinition"
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestAccessLogger_Run(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen: %s", err)
+	}
+	defer listener.Close()
+
+	shutdown := make(chan interface{})
+	defer close(shutdown)
+	l := NewAccessLogger(listener.Addr().String())
+	go l.Run(shutdown)
+	l.Log(AccessLogEntry{Type: AccessLogType, Name: "zproxy", Status: http.StatusOK})
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("Could not accept: %s", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("Could not read the entry: %s", err)
+	}
+
+	var entry AccessLogEntry
+	if err := json.Unmarshal(line, &entry); err != nil {
+		t.Fatalf("Could not unmarshal %q: %s", line, err)
+	}
+	if entry.Type != AccessLogType || entry.Name != "zproxy" || entry.Status != http.StatusOK {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+}
+
+func TestAccessLogger_Full(t *testing.T) {
+	l := NewAccessLogger("127.0.0.1:0")
+	for i := 0; i < accessLogBuffer+1; i++ {
+		l.Log(AccessLogEntry{})
+	}
+	if len(l.entries) != accessLogBuffer {
+		t.Errorf("Expected %d entries, got %d", accessLogBuffer, len(l.entries))
+	}
+
+	// a nil logger drops everything
+	var none *AccessLogger
+	none.Log(AccessLogEntry{})
+}
+
+func TestVHostManager_AccessLog(t *testing.T) {
+	l := NewAccessLogger("127.0.0.1:0")
+	m := NewVHostManager(false, l)
+	m.Enable("logged")
+	m.SetRules("logged", servicedefinition.VHostRules{
+		Routes: []servicedefinition.VHostRoute{{PathPrefix: "/api", Application: "api", StripPrefix: true}},
+	})
+
+	r := httptest.NewRequest("GET", "/api/things", nil)
+	r.RemoteAddr = "10.0.0.1:5000"
+	w := httptest.NewRecorder()
+	if !m.Handle("logged", w, r) {
+		t.Fatalf("Expected the request to be handled")
+	}
+
+	select {
+	case entry := <-l.entries:
+		if entry.Endpoint != "vhost" || entry.Name != "logged" {
+			t.Errorf("Unexpected endpoint %s %s", entry.Endpoint, entry.Name)
+		}
+		if entry.ClientIP != "10.0.0.1" || entry.Method != "GET" || entry.Path != "/api/things" {
+			t.Errorf("Unexpected request %s %s %s", entry.ClientIP, entry.Method, entry.Path)
+		}
+		if entry.Status != http.StatusNotFound || entry.Bytes == 0 {
+			t.Errorf("Unexpected response %d %d", entry.Status, entry.Bytes)
+		}
+	default:
+		t.Fatalf("Expected an access log entry")
+	}
+
+	reg := endpointRegistry("vhost", "logged")
+	if count := metrics.GetOrRegisterCounter("publicendpoint.requests.4xx", reg).Count(); count != 1 {
+		t.Errorf("Expected 1 4xx response, got %d", count)
+	}
+	if count := metrics.GetOrRegisterHistogram("publicendpoint.latency", reg, metrics.NewUniformSample(1)).Count(); count != 1 {
+		t.Errorf("Expected 1 latency sample, got %d", count)
+	}
+}
+
+func TestGetPublicEndpointGraphConfigs(t *testing.T) {
+	svc := &service.Service{}
+	if graphs := getPublicEndpointGraphConfigs(svc); len(graphs) != 0 {
+		t.Errorf("Expected no graphs, got %d", len(graphs))
+	}
+
+	svc.Endpoints = []service.ServiceEndpoint{
+		{
+			VHostList: []servicedefinition.VHost{{Name: "zproxy"}},
+			PortList:  []servicedefinition.Port{{PortAddr: ":22222"}},
+		},
+	}
+	graphs := getPublicEndpointGraphConfigs(svc)
+	if len(graphs) != 2 {
+		t.Fatalf("Expected 2 graphs, got %d", len(graphs))
+	}
+	names := graphs[0].Tags["publicendpoint_name"]
+	if len(names) != 2 || names[0] != "zproxy" || names[1] != "_22222" {
+		t.Errorf("Unexpected tags %v", names)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/control-center/serviced/domain/auditlog"
+	"github.com/zenoss/go-json-rest"
+)
+
+// getAuditEntries returns a page of entries from the audit trail, newest
+// first.  The optional query parameters are user, entity, since (in
+// milliseconds before now), offset and limit.
+func getAuditEntries(w *rest.ResponseWriter, r *rest.Request, ctx *requestContext) {
+	values := r.URL.Query()
+	query := auditlog.Query{
+		User:   values.Get("user"),
+		Entity: values.Get("entity"),
+	}
+
+	if since := values.Get("since"); since != "" {
+		tint, err := strconv.ParseInt(since, 10, 64)
+		if err != nil {
+			writeJSON(w, "since must be a number of milliseconds", http.StatusBadRequest)
+			return
+		}
+		query.Since = time.Now().Add(-time.Duration(tint) * time.Millisecond)
+	}
+	if offset := values.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil {
+			writeJSON(w, "offset must be a number", http.StatusBadRequest)
+			return
+		}
+		query.Offset = n
+	}
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			writeJSON(w, "limit must be a number", http.StatusBadRequest)
+			return
+		}
+		query.Limit = n
+	}
+
+	entries, err := ctx.getFacade().GetAuditEntries(ctx.getDatastoreContext(), query)
+	if err != nil {
+		restServerError(w, err)
+		return
+	}
+	w.WriteJson(entries)
+}
@@ -0,0 +1,47 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package web
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/control-center/serviced/domain/auditlog"
+	"github.com/stretchr/testify/mock"
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestWebSuite) TestGetAuditEntriesShouldReturnStatusOK(c *C) {
+	request := s.buildRequest("GET", "http://www.example.com/audit?user=admin&entity=service&since=3600000&offset=10&limit=5", "")
+	matcher := func(query auditlog.Query) bool {
+		return query.User == "admin" && query.Entity == "service" && query.Offset == 10 && query.Limit == 5 &&
+			time.Since(query.Since) >= time.Hour
+	}
+	s.mockFacade.
+		On("GetAuditEntries", s.ctx.getDatastoreContext(), mock.MatchedBy(matcher)).
+		Return([]auditlog.Entry{{Seq: 11, User: "admin", EntityType: "service"}}, nil)
+
+	getAuditEntries(&(s.writer), &request, s.ctx)
+
+	c.Assert(s.recorder.Code, Equals, http.StatusOK)
+	s.mockFacade.AssertExpectations(c)
+}
+
+func (s *TestWebSuite) TestGetAuditEntriesShouldReturnBadRequestForInvalidLimit(c *C) {
+	request := s.buildRequest("GET", "http://www.example.com/audit?limit=lots", "")
+	getAuditEntries(&(s.writer), &request, s.ctx)
+	c.Assert(s.recorder.Code, Equals, http.StatusBadRequest)
+}
@@ -0,0 +1,92 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/control-center/serviced/domain"
+	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/slo"
+	"github.com/zenoss/go-json-rest"
+)
+
+// restGetServiceSLOs reports each service level objective of a service
+// against its target.
+func restGetServiceSLOs(w *rest.ResponseWriter, r *rest.Request, ctx *requestContext) {
+	serviceID, err := url.QueryUnescape(r.PathParam("serviceId"))
+	if err != nil {
+		writeJSON(w, err, http.StatusBadRequest)
+		return
+	} else if serviceID == "" {
+		writeJSON(w, "serviceId must be specified", http.StatusBadRequest)
+		return
+	}
+
+	statuses, err := ctx.getFacade().GetServiceSLOs(ctx.getDatastoreContext(), serviceID)
+	if err != nil {
+		plog.WithError(err).WithField("serviceid", serviceID).Error("Could not get service level objectives")
+		restServerError(w, err)
+		return
+	}
+	w.WriteJson(statuses)
+}
+
+// addSLOThresholds adds a metric config for the service level objectives of a
+// service and a threshold for each burn rate alert, which fires when the error
+// budget of the objective burns too quickly.
+func addSLOThresholds(profile *domain.MonitorProfile, svc *service.Service) {
+	if len(svc.SLOs) == 0 {
+		return
+	}
+	metricConfig := domain.MetricConfig{
+		ID:          "slo",
+		Name:        "Service level objectives",
+		Description: "Compliance, error budget and burn rates of service level objectives",
+	}
+	for _, obj := range svc.SLOs {
+		metricConfig.Metrics = append(metricConfig.Metrics,
+			domain.Metric{ID: slo.ComplianceMetric(obj.Name), Name: obj.Name + " compliance", Unit: "percent"},
+			domain.Metric{ID: slo.ErrorBudgetMetric(obj.Name), Name: obj.Name + " error budget", Unit: "percent"},
+		)
+		for _, alert := range obj.Alerts {
+			metricID := slo.BurnRateMetric(obj.Name, alert.Hours)
+			metricConfig.Metrics = append(metricConfig.Metrics, domain.Metric{
+				ID:   metricID,
+				Name: fmt.Sprintf("%s burn rate (%dh)", obj.Name, alert.Hours),
+				Unit: "rate",
+			})
+			profile.ThresholdConfigs = append(profile.ThresholdConfigs, domain.ThresholdConfig{
+				ID:           fmt.Sprintf("%s.%s", svc.ID, metricID),
+				Name:         "Service level objective burn rate",
+				Description:  fmt.Sprintf("The error budget of %s is burning too quickly", obj.Name),
+				MetricSource: metricConfig.ID,
+				DataPoints:   []string{metricID},
+				Type:         "MinMax",
+				Threshold:    domain.MinMaxThreshold{Min: "", Max: strconv.FormatFloat(alert.BurnRate, 'f', -1, 64)},
+				EventTags: map[string]interface{}{
+					"Severity":    alert.GetSeverity(),
+					"Resolution":  "Investigate the failing health checks or slow responses of the service",
+					"Explanation": fmt.Sprintf("Over the last %d hours, the error budget of %s was spent at more than %g times the sustainable rate", alert.Hours, obj.Name, alert.BurnRate),
+					"EventClass":  "/Status/SLO",
+					"ServiceID":   svc.ID,
+				},
+			})
+		}
+	}
+	profile.MetricConfigs = append(profile.MetricConfigs, metricConfig)
+}
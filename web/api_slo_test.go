@@ -0,0 +1,72 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package web
+
+import (
+	"net/http"
+
+	"github.com/control-center/serviced/domain"
+	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/domain/servicedefinition"
+	"github.com/control-center/serviced/slo"
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestWebSuite) TestGetServiceSLOsShouldReturnStatusOK(c *C) {
+	request := s.buildRequest("GET", "http://www.example.com/services/svc1/slos", "")
+	request.PathParams["serviceId"] = "svc1"
+	s.mockFacade.
+		On("GetServiceSLOs", s.ctx.getDatastoreContext(), "svc1").
+		Return([]slo.Status{{ServiceID: "svc1", Name: "availability", Compliance: 100}}, nil)
+
+	restGetServiceSLOs(&(s.writer), &request, s.ctx)
+
+	c.Assert(s.recorder.Code, Equals, http.StatusOK)
+	s.mockFacade.AssertExpectations(c)
+}
+
+func (s *TestWebSuite) TestGetServiceSLOsShouldReturnBadRequestWithoutServiceID(c *C) {
+	request := s.buildRequest("GET", "http://www.example.com/services/slos", "")
+	request.PathParams["serviceId"] = ""
+	restGetServiceSLOs(&(s.writer), &request, s.ctx)
+	c.Assert(s.recorder.Code, Equals, http.StatusBadRequest)
+}
+
+func (s *TestWebSuite) TestAddSLOThresholds(c *C) {
+	profile := &domain.MonitorProfile{}
+	addSLOThresholds(profile, &service.Service{ID: "svc1"})
+	c.Assert(profile.MetricConfigs, HasLen, 0)
+
+	svc := &service.Service{
+		ID: "svc1",
+		SLOs: servicedefinition.SLOs{
+			{
+				Name:   "availability",
+				Type:   servicedefinition.SLOAvailability,
+				Target: 99.9,
+				Alerts: []servicedefinition.BurnRateAlert{{Hours: 1, BurnRate: 14.4}, {Hours: 6, BurnRate: 6, Severity: 4}},
+			},
+		},
+	}
+	addSLOThresholds(profile, svc)
+	c.Assert(profile.MetricConfigs, HasLen, 1)
+	c.Assert(profile.MetricConfigs[0].Metrics, HasLen, 4)
+	c.Assert(profile.ThresholdConfigs, HasLen, 2)
+	threshold := profile.ThresholdConfigs[1]
+	c.Assert(threshold.DataPoints, DeepEquals, []string{"slo.availability.burnrate.6h"})
+	c.Assert(threshold.Threshold, DeepEquals, domain.MinMaxThreshold{Min: "", Max: "6"})
+	c.Assert(threshold.EventTags["Severity"], Equals, 4)
+}
@@ -0,0 +1,172 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"crypto/tls"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/domain/certificate"
+)
+
+const (
+	// certExpiryWarning is how long before a certificate expires that
+	// warnings are logged
+	certExpiryWarning = 30 * 24 * time.Hour
+
+	// certCheckInterval is how often the certificates are reloaded and their
+	// expiry is checked
+	certCheckInterval = 24 * time.Hour
+)
+
+// ErrNoCertificate is returned when there is no certificate to serve
+var ErrNoCertificate = errors.New("no certificate available")
+
+// CertificateManager holds the certificates of the vhosts and public ports,
+// and selects which one to serve for each TLS connection.
+type CertificateManager struct {
+	mu       *sync.RWMutex
+	defaults *tls.Certificate
+	vhosts   map[string]*tls.Certificate
+	ports    map[string]*tls.Certificate
+	certs    []certificate.Certificate
+}
+
+// NewCertificateManager creates a new certificate manager
+func NewCertificateManager() *CertificateManager {
+	return &CertificateManager{
+		mu:     &sync.RWMutex{},
+		vhosts: make(map[string]*tls.Certificate),
+		ports:  make(map[string]*tls.Certificate),
+	}
+}
+
+// SetDefault loads the certificate that is served when a vhost or public
+// port has none of its own.
+func (m *CertificateManager) SetDefault(certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.defaults = &cert
+	return nil
+}
+
+// Load replaces the certificates of the vhosts and public ports.  The private
+// keys are decrypted with secret; certificates that cannot be decrypted are
+// skipped.
+func (m *CertificateManager) Load(certs []certificate.Certificate, secret []byte) {
+	vhosts := make(map[string]*tls.Certificate)
+	ports := make(map[string]*tls.Certificate)
+	for _, cert := range certs {
+		logger := plog.WithFields(log.Fields{
+			"kind": cert.Kind,
+			"name": cert.Name,
+		})
+		if cert.IsPending() {
+			logger.Debug("Certificate has not been issued yet")
+			continue
+		}
+		pair, err := cert.KeyPair(secret)
+		if err != nil {
+			logger.WithError(err).Error("Could not load certificate")
+			continue
+		}
+		switch cert.Kind {
+		case certificate.VHost:
+			vhosts[strings.ToLower(cert.Name)] = &pair
+		case certificate.Port:
+			ports[cert.Name] = &pair
+		}
+	}
+
+	m.mu.Lock()
+	m.vhosts, m.ports, m.certs = vhosts, ports, certs
+	m.mu.Unlock()
+
+	plog.WithFields(log.Fields{
+		"vhosts": len(vhosts),
+		"ports":  len(ports),
+	}).Debug("Loaded certificates")
+	m.CheckExpiry()
+}
+
+// CheckExpiry logs a warning for each certificate that expires soon
+func (m *CertificateManager) CheckExpiry() {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, cert := range m.certs {
+		if cert.IsPending() || !cert.ExpiresWithin(certExpiryWarning) {
+			continue
+		}
+		logger := plog.WithFields(log.Fields{
+			"kind":     cert.Kind,
+			"name":     cert.Name,
+			"notafter": cert.NotAfter,
+		})
+		if cert.ExpiresWithin(0) {
+			logger.Error("Certificate has expired")
+		} else {
+			logger.Warn("Certificate expires soon")
+		}
+	}
+}
+
+// GetVHostCertificate selects the certificate of the vhost that the client
+// asked for, by server name (SNI).  For "xyz.domain.com", the certificate of
+// either the "xyz.domain.com" or the "xyz" vhost is served.  Returns nil if
+// the vhost has no certificate, so that the server's own certificate is used.
+func (m *CertificateManager) GetVHostCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.getVHostCertificate(hello.ServerName), nil
+}
+
+func (m *CertificateManager) getVHostCertificate(serverName string) *tls.Certificate {
+	if serverName == "" {
+		return nil
+	}
+	serverName = strings.ToLower(serverName)
+	if cert, ok := m.vhosts[serverName]; ok {
+		return cert
+	}
+	return m.vhosts[strings.Split(serverName, ".")[0]]
+}
+
+// GetPortCertificate returns the function that selects the certificate of a
+// public port, for its TLS config.  If the port has no certificate, the
+// certificate of the vhost asked for by SNI, or else the default certificate,
+// is served.
+func (m *CertificateManager) GetPortCertificate(portAddr string) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		if cert, ok := m.ports[portAddr]; ok {
+			return cert, nil
+		}
+		if cert := m.getVHostCertificate(hello.ServerName); cert != nil {
+			return cert, nil
+		}
+		if m.defaults != nil {
+			return m.defaults, nil
+		}
+		return nil, ErrNoCertificate
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package web
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/control-center/serviced/domain/certificate"
+)
+
+var testCertSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func testCertificate(t *testing.T, kind certificate.Kind, name string) certificate.Certificate {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Could not generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Could not create certificate: %s", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	cert, err := certificate.New(kind, name, certPEM, keyPEM, testCertSecret)
+	if err != nil {
+		t.Fatalf("Could not load certificate: %s", err)
+	}
+	return *cert
+}
+
+func commonName(t *testing.T, cert *tls.Certificate) string {
+	if cert == nil {
+		return ""
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("Could not parse certificate: %s", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertificateManager_VHost(t *testing.T) {
+	m := NewCertificateManager()
+	m.Load([]certificate.Certificate{
+		testCertificate(t, certificate.VHost, "myapp"),
+		testCertificate(t, certificate.VHost, "other.example.com"),
+	}, testCertSecret)
+
+	for serverName, expected := range map[string]string{
+		"myapp":               "myapp",
+		"MyApp.example.com":   "myapp",
+		"other.example.com":   "other.example.com",
+		"other.example.org":   "",
+		"unknown.example.com": "",
+		"":                    "",
+	} {
+		cert, err := m.GetVHostCertificate(&tls.ClientHelloInfo{ServerName: serverName})
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %s", serverName, err)
+		}
+		if actual := commonName(t, cert); actual != expected {
+			t.Errorf("Server name %q: expected %q, got %q", serverName, expected, actual)
+		}
+	}
+}
+
+func TestCertificateManager_Port(t *testing.T) {
+	m := NewCertificateManager()
+	getCert := m.GetPortCertificate(":8443")
+
+	// no certificates at all
+	if _, err := getCert(&tls.ClientHelloInfo{}); err != ErrNoCertificate {
+		t.Errorf("Expected %s, got %v", ErrNoCertificate, err)
+	}
+
+	m.Load([]certificate.Certificate{
+		testCertificate(t, certificate.VHost, "myapp"),
+		testCertificate(t, certificate.Port, ":9443"),
+	}, testCertSecret)
+	m.defaults = &tls.Certificate{}
+
+	// the vhost asked for by SNI
+	cert, err := getCert(&tls.ClientHelloInfo{ServerName: "myapp.example.com"})
+	if err != nil || commonName(t, cert) != "myapp" {
+		t.Errorf("Expected the vhost certificate, got %v (%v)", cert, err)
+	}
+
+	// the default certificate
+	cert, err = getCert(&tls.ClientHelloInfo{})
+	if err != nil || cert != m.defaults {
+		t.Errorf("Expected the default certificate, got %v (%v)", cert, err)
+	}
+
+	// the port's own certificate
+	m.Load([]certificate.Certificate{testCertificate(t, certificate.Port, ":8443")}, testCertSecret)
+	cert, err = getCert(&tls.ClientHelloInfo{ServerName: "myapp.example.com"})
+	if err != nil || commonName(t, cert) != ":8443" {
+		t.Errorf("Expected the port certificate, got %v (%v)", cert, err)
+	}
+}
+
+func TestCertificateManager_BadSecret(t *testing.T) {
+	m := NewCertificateManager()
+	m.Load([]certificate.Certificate{testCertificate(t, certificate.VHost, "myapp")}, []byte("fedcba9876543210fedcba9876543210"))
+	if cert, _ := m.GetVHostCertificate(&tls.ClientHelloInfo{ServerName: "myapp"}); cert != nil {
+		t.Errorf("Expected the certificate to be skipped")
+	}
+}
@@ -24,11 +24,17 @@ import (
 	"os/exec"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/acme"
+	"github.com/control-center/serviced/auth"
 	"github.com/control-center/serviced/config"
+	"github.com/control-center/serviced/coordinator/client"
 	daoclient "github.com/control-center/serviced/dao/client"
 	"github.com/control-center/serviced/datastore"
+	"github.com/control-center/serviced/domain/certificate"
+	"github.com/control-center/serviced/domain/logfilter"
 	"github.com/control-center/serviced/facade"
 	"github.com/control-center/serviced/node"
 	"github.com/control-center/serviced/rpc/master"
@@ -42,6 +48,7 @@ import (
 // that the UI may care about
 type UIConfig struct {
 	PollFrequency int
+	LogRetention  *logfilter.RetentionPolicy `json:",omitempty"`
 }
 
 // ServiceConfig is the ui/rest handler for control center
@@ -58,6 +65,8 @@ type ServiceConfig struct {
 	uiConfig    UIConfig
 	facade      facade.FacadeInterface
 	vhostmgr    *VHostManager
+	certs       *CertificateManager
+	accessLog   *AccessLogger
 }
 
 // Auth0Config contains configuration values pertaining to Auth0
@@ -97,6 +106,7 @@ func NewServiceConfig(bindPort string, agentPort string, stats bool, hostaliases
 		keyPEMFile:  keyPEMFile,
 		uiConfig:    uiCfg,
 		facade:      facade,
+		certs:       NewCertificateManager(),
 	}
 
 	hostAddrs, err := utils.GetIPv4Addresses()
@@ -139,6 +149,21 @@ func (sc *ServiceConfig) Serve(shutdown <-chan (interface{})) {
 	logger := plog.WithField("bindport", sc.bindPort)
 	logger.Debug("Starting vhost synching")
 
+	// load the default certificate and watch for vhost and public port
+	// certificates
+	// FIXME: bubble up these errors to the caller
+	certFile, keyFile := GetCertFiles(sc.certPEMFile, sc.keyPEMFile)
+	if err := sc.certs.SetDefault(certFile, keyFile); err != nil {
+		logger.WithError(err).Error("Could not load default certificate")
+	}
+	sc.startCertificateListener(shutdown)
+
+	// ship the requests to vhosts and public ports to logstash
+	if logstashURL := config.GetOptions().LogstashURL; logstashURL != "" {
+		sc.accessLog = NewAccessLogger(logstashURL)
+		go sc.accessLog.Run(shutdown)
+	}
+
 	// start public port listener
 	sc.startPublicPortListener(shutdown)
 
@@ -183,6 +208,10 @@ func (sc *ServiceConfig) Serve(shutdown <-chan (interface{})) {
 
 		httphost := strings.Split(r.Host, ":")[0]
 		logger.WithField("httphost", httphost).Debug("In httphandler")
+		if strings.HasPrefix(r.URL.Path, acme.ChallengePath) {
+			acme.ServeChallenge(w, r, sc.facade.GetACMEChallenge)
+			return
+		}
 		if strings.Contains(httphost, ".") {
 			if sc.vhostmgr.Handle(httphost, w, r) {
 				return
@@ -215,11 +244,13 @@ func (sc *ServiceConfig) Serve(shutdown <-chan (interface{})) {
 	defaultHostAlias = sc.hostaliases[0]
 	uiConfig = sc.uiConfig
 
-	// FIXME: bubble up these errors to the caller
-	certFile, keyFile := GetCertFiles(sc.certPEMFile, sc.keyPEMFile)
-
 	go func() {
 		redirect := func(w http.ResponseWriter, req *http.Request) {
+			// ACME servers validate HTTP-01 challenges on port 80
+			if strings.HasPrefix(req.URL.Path, acme.ChallengePath) {
+				acme.ServeChallenge(w, req, sc.facade.GetACMEChallenge)
+				return
+			}
 			// bindPort has already been validated, so the Split/access below won't break.
 			http.Redirect(w, req, fmt.Sprintf("https://%s:%s%s", req.Host, strings.Split(sc.bindPort, ":")[1], req.URL), http.StatusMovedPermanently)
 		}
@@ -236,6 +267,7 @@ func (sc *ServiceConfig) Serve(shutdown <-chan (interface{})) {
 			MinVersion:               utils.MinTLS("http"),
 			PreferServerCipherSuites: true,
 			CipherSuites:             utils.CipherSuites("http"),
+			GetCertificate:           sc.certs.GetVHostCertificate,
 		}
 		server := &http.Server{Addr: sc.bindPort, TLSConfig: config, Handler: http.HandlerFunc(httphandler)}
 		logger.WithField("ciphersuite", utils.CipherSuitesByName(config)).Info("Creating HTTP server")
@@ -435,7 +467,7 @@ type getRoutes func(sc *ServiceConfig) []rest.Route
 // changes in state
 func (sc *ServiceConfig) startPublicPortListener(shutdown <-chan interface{}) {
 	// set up the public port manager
-	pubmgr := NewPublicPortManager("", sc.certPEMFile, sc.keyPEMFile, func(portAddress string, err error) {
+	pubmgr := NewPublicPortManager("", sc.certs, sc.accessLog, func(portAddress string, err error) {
 		logger := plog.WithField("portaddress", portAddress).WithError(err)
 
 		// connect to zookeeper
@@ -488,10 +520,79 @@ func (sc *ServiceConfig) startPublicPortListener(shutdown <-chan interface{}) {
 	}()
 }
 
+// startCertificateListener loads the certificates of the vhosts and public
+// ports, and reloads them whenever they change.
+func (sc *ServiceConfig) startCertificateListener(shutdown <-chan interface{}) {
+	go func() {
+		for {
+			select {
+			case conn := <-zzk.Connect("/", zzk.GetLocalConnection):
+				if conn != nil {
+					sc.watchCertificates(shutdown, conn)
+					select {
+					case <-shutdown:
+						return
+					default:
+					}
+				}
+			case <-shutdown:
+				return
+			}
+		}
+	}()
+}
+
+// watchCertificates reloads the certificates when they change, and at least
+// once a day to check their expiry.
+func (sc *ServiceConfig) watchCertificates(shutdown <-chan interface{}, conn client.Connection) {
+	ticker := time.NewTicker(certCheckInterval)
+	defer ticker.Stop()
+
+	done := make(chan struct{})
+	defer func() { close(done) }()
+	for {
+		evt, err := registry.WatchCertificates(conn, done)
+		if err != nil {
+			plog.WithError(err).Error("Could not watch certificates")
+			select {
+			case <-time.After(5 * time.Second):
+			case <-shutdown:
+			}
+			return
+		}
+		sc.loadCertificates()
+
+		select {
+		case <-evt:
+		case <-ticker.C:
+		case <-shutdown:
+			return
+		}
+
+		close(done)
+		done = make(chan struct{})
+	}
+}
+
+// loadCertificates loads the certificates of the vhosts and public ports
+func (sc *ServiceConfig) loadCertificates() {
+	certs, err := sc.facade.GetCertificates(datastore.Get())
+	if err != nil {
+		plog.WithError(err).Error("Could not look up certificates")
+		return
+	}
+	secret, err := auth.MasterSecret(certificate.SecretPurpose)
+	if err != nil {
+		plog.WithError(err).Error("Could not get the secret for decrypting certificates")
+		return
+	}
+	sc.certs.Load(certs, secret)
+}
+
 // startVHostListener manages proxies for all vhosts
 func (sc *ServiceConfig) startVHostListener(shutdown <-chan interface{}) {
 	// set up the vhost manager
-	sc.vhostmgr = NewVHostManager(sc.muxTLS)
+	sc.vhostmgr = NewVHostManager(sc.muxTLS, sc.accessLog)
 
 	// set up the vhost listener
 	listener := registry.NewVHostListener("master", sc.vhostmgr)
@@ -14,10 +14,14 @@
 package web
 
 import (
+	"fmt"
+	"hash/fnv"
 	"math/rand"
+	"net"
 	"sync"
 	"time"
 
+	"github.com/control-center/serviced/proxy"
 	"github.com/control-center/serviced/zzk/registry"
 )
 
@@ -25,59 +29,148 @@ func init() {
 	rand.Seed(time.Now().Unix())
 }
 
+// exportHealth tracks the failures to connect to exports, which are shared
+// by all of the vhosts and public ports.
+var exportHealth = proxy.NewHealthTracker()
+
+// exportKey uniquely identifies an export
+func exportKey(export *registry.ExportDetails) string {
+	return fmt.Sprintf("%s/%s:%d", export.HostIP, export.PrivateIP, export.PortNumber)
+}
+
+// exportAffinity is an opaque id of an export, which can be handed to clients
+// without revealing the addresses of the export.
+func exportAffinity(export *registry.ExportDetails) string {
+	h := fnv.New64a()
+	h.Write([]byte(exportKey(export)))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
 // Exports manage a list of available exports
 type Exports interface {
 	Set(data []registry.ExportDetails)
 	Next() *registry.ExportDetails
+	// Pick returns the export for a connection from clientAddr, which must be
+	// released when the connection closes.
+	Pick(clientAddr string) *registry.ExportDetails
+	Release(export *registry.ExportDetails)
+	// Acquire returns the export with the given affinity id, which must be
+	// released when the connection closes, or nil if it is not available.
+	Acquire(affinity string) *registry.ExportDetails
+	// Track returns conn, which is closed once the export leaves the list and
+	// has had its drain period to finish.
+	Track(export *registry.ExportDetails, conn net.Conn) net.Conn
+	// Close empties the list, draining the connections to its exports, and
+	// releases the reverse proxies to them.
+	Close()
 }
 
-// RoundRobinExports returns the next export in a round-robin manner
-type RoundRobinExports struct {
-	mu   *sync.Mutex
-	xid  int
-	data []registry.ExportDetails
+// BalancedExports returns exports according to the load balancing policy of
+// the exported endpoint (round-robin by default).
+type BalancedExports struct {
+	mu       *sync.Mutex
+	balancer *proxy.Balancer
+	data     map[string]registry.ExportDetails
+	affinity map[string]string // export keys by affinity id
+	drainer  *proxy.Drainer
 }
 
-// NewRoundRobinExports creates a new round robin list of exports
-func NewRoundRobinExports(data []registry.ExportDetails) *RoundRobinExports {
-	e := &RoundRobinExports{
-		mu: &sync.Mutex{},
+// NewBalancedExports creates a new load balanced list of exports
+func NewBalancedExports(data []registry.ExportDetails) *BalancedExports {
+	e := &BalancedExports{
+		mu:       &sync.Mutex{},
+		balancer: proxy.NewBalancer(exportHealth),
+		drainer:  proxy.NewDrainer(),
 	}
 	e.set(data)
 	return e
 }
 
 // Set updates the list of exports.
-func (e *RoundRobinExports) Set(data []registry.ExportDetails) {
+func (e *BalancedExports) Set(data []registry.ExportDetails) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	e.set(data)
 }
 
-// set updates the export list, but first randomizes the order and resets the
-// counter.
-func (e *RoundRobinExports) set(data []registry.ExportDetails) {
-
-	// reset the counter
-	e.xid = 0
-
-	// randomize the exports
-	e.data = make([]registry.ExportDetails, len(data))
+// set updates the export list, but first randomizes the order.
+func (e *BalancedExports) set(data []registry.ExportDetails) {
+	removed := e.data
+	e.data = make(map[string]registry.ExportDetails)
+	e.affinity = make(map[string]string)
+	backends := make([]proxy.Backend, len(data))
 	for i, j := range rand.Perm(len(data)) {
-		e.data[i] = data[j]
+		key := exportKey(&data[j])
+		e.data[key] = data[j]
+		e.affinity[exportAffinity(&data[j])] = key
+		backends[i] = proxy.Backend{Key: key, InstanceID: data[j].InstanceID}
+		e.drainer.Restore(key)
+	}
+	if len(data) > 0 {
+		e.balancer.Configure(data[0].LoadBalancer)
+	}
+	e.balancer.Set(backends)
+
+	// give the open connections to the exports that went away time to finish
+	for key, export := range removed {
+		if _, ok := e.data[key]; !ok {
+			e.drainer.Drain(key, export.GetDrainPeriod())
+		}
 	}
 }
 
 // Next returns the next available export
-func (e *RoundRobinExports) Next() *registry.ExportDetails {
+func (e *BalancedExports) Next() *registry.ExportDetails {
+	export := e.Pick("")
+	if export != nil {
+		e.Release(export)
+	}
+	return export
+}
+
+// Pick returns the export for a connection from clientAddr
+func (e *BalancedExports) Pick(clientAddr string) *registry.ExportDetails {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	// make sure there is data to submit
-	if size := len(e.data); size > 0 {
-		dat := e.data[e.xid]
-		e.xid = (e.xid + 1) % size
-		return &dat
+	backend, ok := e.balancer.Pick(clientAddr)
+	if !ok {
+		return nil
 	}
-	return nil
+	dat := e.data[backend.Key]
+	return &dat
+}
+
+// Release is called when a connection to an export that was picked closes
+func (e *BalancedExports) Release(export *registry.ExportDetails) {
+	e.balancer.Release(exportKey(export))
+}
+
+// Acquire returns the export with the given affinity id
+func (e *BalancedExports) Acquire(affinity string) *registry.ExportDetails {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key, ok := e.affinity[affinity]
+	if !ok || !e.balancer.Acquire(key) {
+		return nil
+	}
+	dat := e.data[key]
+	return &dat
+}
+
+// Track closes the connection to an export that went away, once it has had
+// the drain period of the export to finish.  Only the exports that leave
+// this list are drained, so other vhosts and ports that still route to the
+// export keep their connections.
+func (e *BalancedExports) Track(export *registry.ExportDetails, conn net.Conn) net.Conn {
+	return e.drainer.Track(exportKey(export), conn)
+}
+
+// Close empties the list, which gives the open connections to its exports
+// their drain period to finish, and evicts the reverse proxies of the list
+// from the cache.
+func (e *BalancedExports) Close() {
+	e.Set(nil)
+	rpcache.Evict(e)
 }
@@ -161,6 +161,8 @@ func restGetServiceMonitoringProfile(w *rest.ResponseWriter, r *rest.Request, ct
 		return
 	} else if svc.Instances > 0 {
 		mp.GraphConfigs = append(mp.GraphConfigs, getInternalGraphConfigs(serviceID)...)
+		mp.GraphConfigs = append(mp.GraphConfigs, getPublicEndpointGraphConfigs(svc)...)
+		addSLOThresholds(mp, svc)
 	}
 
 	// we want to try to include monitoring data for the tenant, as well
@@ -14,7 +14,10 @@
 package web
 
 import (
+	"fmt"
+
 	"github.com/control-center/serviced/domain"
+	"github.com/control-center/serviced/volume"
 )
 
 //profile defines meta-data for the host/pool resource's metrics and graphs
@@ -166,6 +169,61 @@ var (
 	}
 )
 
+// addStorageQuotaThresholds adds a metric config for the storage used by each
+// tenant with a quota and thresholds that fire when the soft and hard limits
+// are reached
+func addStorageQuotaThresholds(profile *domain.MonitorProfile, quotas []volume.TenantQuotaStatus) {
+	if len(quotas) == 0 {
+		return
+	}
+	metricConfig := domain.MetricConfig{
+		ID:          "storage.quota",
+		Name:        "storage quota",
+		Description: "Application storage usage against quota",
+	}
+	for _, quota := range quotas {
+		metricID := fmt.Sprintf("storage.filesystem.used.%s", quota.TenantID)
+		metricConfig.Metrics = append(metricConfig.Metrics, domain.Metric{ID: metricID, Name: quota.TenantID + " used", Unit: "bytes"})
+		if quota.Quota.SoftLimit > 0 {
+			profile.ThresholdConfigs = append(profile.ThresholdConfigs, domain.ThresholdConfig{
+				ID:           fmt.Sprintf("storage.quota.soft.%s", quota.TenantID),
+				Name:         "Application storage quota warning",
+				Description:  "Application storage has reached its soft quota",
+				MetricSource: metricConfig.ID,
+				DataPoints:   []string{metricID},
+				Type:         "MinMax",
+				Threshold:    domain.MinMaxThreshold{Min: "", Max: fmt.Sprintf("%d", quota.Quota.SoftLimit)},
+				EventTags: map[string]interface{}{
+					"Severity":    3,
+					"Resolution":  "Remove application data or raise the storage quota",
+					"Explanation": "Application storage is approaching its quota",
+					"EventClass":  "/Storage/Quota",
+					"TenantID":    quota.TenantID,
+				},
+			})
+		}
+		if quota.Quota.HardLimit > 0 {
+			profile.ThresholdConfigs = append(profile.ThresholdConfigs, domain.ThresholdConfig{
+				ID:           fmt.Sprintf("storage.quota.hard.%s", quota.TenantID),
+				Name:         "Application storage quota exceeded",
+				Description:  "Application storage has reached its hard quota",
+				MetricSource: metricConfig.ID,
+				DataPoints:   []string{metricID},
+				Type:         "MinMax",
+				Threshold:    domain.MinMaxThreshold{Min: "", Max: fmt.Sprintf("%d", quota.Quota.HardLimit)},
+				EventTags: map[string]interface{}{
+					"Severity":    5,
+					"Resolution":  "Remove application data or raise the storage quota, then clear the emergency shutdown",
+					"Explanation": "Application storage has exceeded its quota and the application is being stopped",
+					"EventClass":  "/Storage/Quota",
+					"TenantID":    quota.TenantID,
+				},
+			})
+		}
+	}
+	profile.MetricConfigs = append(profile.MetricConfigs, metricConfig)
+}
+
 //Open File Descriptors
 func newOpenFileDescriptorsGraph(tags map[string][]string) domain.GraphConfig {
 	return domain.GraphConfig{
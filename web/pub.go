@@ -20,6 +20,8 @@ import (
 	"sync"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/commons"
+	"github.com/control-center/serviced/domain/servicedefinition"
 	"github.com/control-center/serviced/utils"
 	"github.com/control-center/serviced/zzk/registry"
 )
@@ -29,19 +31,20 @@ var ErrPortServerRunning = errors.New("port server is already running")
 // PublicPortManager manages all the port servers for a particular host id
 type PublicPortManager struct {
 	hostID    string
-	certFile  string
-	keyFile   string
+	certs     *CertificateManager
+	accessLog *AccessLogger
 	onFailure func(portNumber string, err error)
 	mu        *sync.RWMutex
 	ports     map[string]*PublicPortHandler
 }
 
-// NewPublicPortManager creates a new public port manager for a host id
-func NewPublicPortManager(hostID, certFile, keyFile string, onFailure func(portAddr string, err error)) *PublicPortManager {
+// NewPublicPortManager creates a new public port manager for a host id, which
+// ships the http requests it serves to the access log, if there is one.
+func NewPublicPortManager(hostID string, certs *CertificateManager, accessLog *AccessLogger, onFailure func(portAddr string, err error)) *PublicPortManager {
 	return &PublicPortManager{
 		hostID:    hostID,
-		certFile:  certFile,
-		keyFile:   keyFile,
+		certs:     certs,
+		accessLog: accessLog,
 		onFailure: onFailure,
 		mu:        &sync.RWMutex{},
 		ports:     make(map[string]*PublicPortHandler),
@@ -61,7 +64,7 @@ func (m *PublicPortManager) Enable(portAddr, protocol string, useTLS bool) {
 	}
 
 	// start the port server
-	if err := h.Serve(protocol, useTLS, m.certFile, m.keyFile); err != nil {
+	if err := h.Serve(protocol, useTLS, m.certs, m.accessLog); err != nil {
 		m.onFailure(portAddr, err)
 	}
 }
@@ -92,10 +95,29 @@ func (m *PublicPortManager) Set(portAddr string, data []registry.ExportDetails)
 	}
 }
 
+// SetAccess updates the access rules of a particular port handler
+func (m *PublicPortManager) SetAccess(portAddr string, rules servicedefinition.AccessRules) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.ports[portAddr]
+	if !ok {
+		h = NewPublicPortHandler(portAddr)
+		m.ports[portAddr] = h
+	}
+	if access := h.getAccess(); access != nil {
+		access.SetRules(rules)
+	} else {
+		h.SetAccess(NewAccessFilter("port", portAddr, rules))
+	}
+}
+
 // PublicPortHandler manages the port server at a specific port address
 type PublicPortHandler struct {
 	portAddr string
 	exports  Exports
+	access   *AccessFilter
+	accessMu *sync.RWMutex
 	cancel   chan struct{}
 	wg       *sync.WaitGroup
 }
@@ -107,14 +129,15 @@ func NewPublicPortHandler(portAddr string, data ...registry.ExportDetails) *Publ
 
 	return &PublicPortHandler{
 		portAddr: portAddr,
-		exports:  NewRoundRobinExports(data), // round-robin is the default
+		exports:  NewBalancedExports(data),
+		accessMu: &sync.RWMutex{},
 		cancel:   cancel,
 		wg:       &sync.WaitGroup{},
 	}
 }
 
 // Serve starts the port server at address
-func (h *PublicPortHandler) Serve(protocol string, useTLS bool, certFile, keyFile string) error {
+func (h *PublicPortHandler) Serve(protocol string, useTLS bool, certs *CertificateManager, accessLog *AccessLogger) error {
 	logger := plog.WithFields(log.Fields{
 		"portaddress": h.portAddr,
 		"protocol":    protocol,
@@ -130,17 +153,28 @@ func (h *PublicPortHandler) Serve(protocol string, useTLS bool, certFile, keyFil
 		return ErrPortServerRunning
 	}
 
-	var tlsConfig *tls.Config
-	if useTLS {
-
-		// get the certificate
-		certFile, keyFile = GetCertFiles(certFile, keyFile)
-		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	// udp ports forward datagrams, without tls
+	if protocol == commons.UDP {
+		conn, err := net.ListenPacket("udp", h.portAddr)
 		if err != nil {
-			logger.WithError(err).Debug("Could not set up certificate")
+			logger.WithError(err).Debug("Could not start UDP listener")
 			return err
 		}
 
+		h.wg.Add(1)
+		go func() {
+			logger.Info("Starting port server")
+			defer logger.Debug("Port server exited")
+			ServeUDP(h.cancel, conn, h.exports, h.getAccess)
+			h.wg.Done()
+		}()
+
+		return nil
+	}
+
+	var tlsConfig *tls.Config
+	if useTLS {
+
 		// cipher suites and tls min version change may not be needed with
 		// golang 1.5:
 		// https://github.com/golang/go/issues/10094
@@ -149,7 +183,7 @@ func (h *PublicPortHandler) Serve(protocol string, useTLS bool, certFile, keyFil
 			MinVersion:               utils.MinTLS("http"),
 			PreferServerCipherSuites: true,
 			CipherSuites:             utils.CipherSuites("http"),
-			Certificates:             []tls.Certificate{cert},
+			GetCertificate:           certs.GetPortCertificate(h.portAddr),
 		}
 
 		logger.Debug("Set up tls certificate")
@@ -162,13 +196,16 @@ func (h *PublicPortHandler) Serve(protocol string, useTLS bool, certFile, keyFil
 		return err
 	}
 
+	// close the connections that are rejected by the access rules
+	listener = &accessListener{Listener: listener, filter: h.getAccess}
+
 	h.wg.Add(1)
 	go func() {
 		logger.Info("Starting port server")
 		defer logger.Debug("Port server exited")
 
 		if protocol == "http" || protocol == "https" {
-			ServeHTTP(h.cancel, h.portAddr, protocol, listener, tlsConfig, h.exports)
+			ServeHTTP(h.cancel, h.portAddr, protocol, listener, tlsConfig, h.exports, accessLog)
 		} else {
 			ServeTCP(h.cancel, listener, tlsConfig, h.exports)
 		}
@@ -192,3 +229,16 @@ func (h *PublicPortHandler) Stop() {
 func (h *PublicPortHandler) SetExports(data []registry.ExportDetails) {
 	h.exports.Set(data)
 }
+
+// SetAccess updates the access filter for the port handler
+func (h *PublicPortHandler) SetAccess(access *AccessFilter) {
+	h.accessMu.Lock()
+	defer h.accessMu.Unlock()
+	h.access = access
+}
+
+func (h *PublicPortHandler) getAccess() *AccessFilter {
+	h.accessMu.RLock()
+	defer h.accessMu.RUnlock()
+	return h.access
+}
@@ -17,6 +17,7 @@
 package web
 
 import (
+	"github.com/control-center/serviced/domain/servicedefinition"
 	"github.com/zenoss/glog"
 	"github.com/zenoss/go-json-rest"
 
@@ -38,6 +39,7 @@ type endpointRequest struct {
 	UseTLS      bool
 	Protocol    string
 	IsEnabled   bool
+	Access      servicedefinition.AccessRules
 }
 
 // restAddVirtualHost parses payload, adds the vhost to the service, then updates the service
@@ -67,7 +69,7 @@ func restAddVirtualHost(w *rest.ResponseWriter, r *rest.Request, ctx *requestCon
 	facade := ctx.getFacade()
 	dataCtx := ctx.getDatastoreContext()
 
-	_, err = facade.AddPublicEndpointVHost(dataCtx, serviceid, application, vhostname, true, true)
+	_, err = facade.AddPublicEndpointVHost(dataCtx, serviceid, application, vhostname, true, request.Access, true)
 	if err != nil {
 		glog.Errorf("Error adding vhost to service (%s): %v", request.ServiceName, err)
 		restServerError(w, err)
@@ -212,7 +214,7 @@ func restAddPort(w *rest.ResponseWriter, r *rest.Request, ctx *requestContext) {
 	dataCtx := ctx.getDatastoreContext()
 
 	_, err = facade.AddPublicEndpointPort(dataCtx, serviceid, application,
-		port, request.UseTLS, request.Protocol, true, true)
+		port, request.UseTLS, request.Protocol, true, request.Access, true)
 	if err != nil {
 		glog.Errorf("Error adding port to service (%s): %v", request.ServiceName, err)
 		restServerError(w, err)
@@ -170,6 +170,7 @@ func restGetAllServices(w *rest.ResponseWriter, r *rest.Request, ctx *requestCon
 			if len(svc.Startup) > 2 {
 				result[ii].MonitoringProfile.MetricConfigs = append(result[ii].MonitoringProfile.MetricConfigs, *config)
 				result[ii].MonitoringProfile.GraphConfigs = append(result[ii].MonitoringProfile.GraphConfigs, getInternalGraphConfigs(result[ii].ID)...)
+				result[ii].MonitoringProfile.GraphConfigs = append(result[ii].MonitoringProfile.GraphConfigs, getPublicEndpointGraphConfigs(&result[ii])...)
 			}
 		}
 		w.WriteJson(&result)
@@ -187,6 +188,7 @@ func restGetAllServices(w *rest.ResponseWriter, r *rest.Request, ctx *requestCon
 			if len(svc.Startup) > 2 {
 				result[ii].MonitoringProfile.MetricConfigs = append(result[ii].MonitoringProfile.MetricConfigs, *config)
 				result[ii].MonitoringProfile.GraphConfigs = append(result[ii].MonitoringProfile.GraphConfigs, getInternalGraphConfigs(result[ii].ID)...)
+				result[ii].MonitoringProfile.GraphConfigs = append(result[ii].MonitoringProfile.GraphConfigs, getPublicEndpointGraphConfigs(&result[ii])...)
 			}
 		}
 		w.WriteJson(&result)
@@ -231,6 +233,7 @@ func restGetAllServices(w *rest.ResponseWriter, r *rest.Request, ctx *requestCon
 		if len(svc.Startup) > 2 {
 			result[ii].MonitoringProfile.MetricConfigs = append(result[ii].MonitoringProfile.MetricConfigs, *config)
 			result[ii].MonitoringProfile.GraphConfigs = append(result[ii].MonitoringProfile.GraphConfigs, getInternalGraphConfigs(result[ii].ID)...)
+			result[ii].MonitoringProfile.GraphConfigs = append(result[ii].MonitoringProfile.GraphConfigs, getPublicEndpointGraphConfigs(&result[ii])...)
 		}
 	}
 	w.WriteJson(&result)
@@ -401,6 +404,7 @@ func restGetService(w *rest.ResponseWriter, r *rest.Request, client *daoclient.C
 	if svc.ID == serviceID {
 		svc.MonitoringProfile.MetricConfigs = append(svc.MonitoringProfile.MetricConfigs, *config)
 		svc.MonitoringProfile.GraphConfigs = append(svc.MonitoringProfile.GraphConfigs, getInternalGraphConfigs(svc.ID)...)
+		svc.MonitoringProfile.GraphConfigs = append(svc.MonitoringProfile.GraphConfigs, getPublicEndpointGraphConfigs(&svc)...)
 		w.WriteJson(&svc)
 		return
 	}
@@ -848,7 +852,7 @@ func restGetServicedVersion(w *rest.ResponseWriter, r *rest.Request) {
 	w.WriteJson(servicedversion.GetVersion())
 }
 
-func restGetStorage(w *rest.ResponseWriter, r *rest.Request, client *daoclient.ControlClient) {
+func restGetStorage(w *rest.ResponseWriter, r *rest.Request, ctx *requestContext) {
 	volumeStatuses := volume.GetStatus()
 	if volumeStatuses == nil || len(volumeStatuses.GetAllStatuses()) == 0 {
 		err := fmt.Errorf("Unexpected error getting volume status")
@@ -857,9 +861,21 @@ func restGetStorage(w *rest.ResponseWriter, r *rest.Request, client *daoclient.C
 		return
 	}
 
+	quotas, err := ctx.getFacade().GetStorageQuotaStatus(ctx.getDatastoreContext())
+	if err != nil {
+		plog.WithError(err).Warn("Could not get storage quota status")
+		quotas = []volume.TenantQuotaStatus{}
+	}
+	quotaMap := make(map[string]volume.StorageQuota)
+	for _, quota := range quotas {
+		quotaMap[quota.TenantID] = quota.Quota
+	}
+	volumeStatuses.ApplyQuotas(quotaMap)
+
 	type VolumeInfo struct {
 		Name              string
 		Status            volume.Status
+		Quotas            []volume.TenantQuotaStatus
 		MonitoringProfile domain.MonitorProfile
 	}
 
@@ -870,7 +886,7 @@ func restGetStorage(w *rest.ResponseWriter, r *rest.Request, client *daoclient.C
 		vLogger := plog.WithFields(logrus.Fields{
 			"volumename": volumeName,
 		})
-		volumeInfo := VolumeInfo{Name: volumeName, Status: volumeStatus}
+		volumeInfo := VolumeInfo{Name: volumeName, Status: volumeStatus, Quotas: quotas}
 		tags := map[string][]string{}
 		profile, err := volumeProfile.ReBuild("1h-ago", tags)
 		if err != nil {
@@ -883,6 +899,8 @@ func restGetStorage(w *rest.ResponseWriter, r *rest.Request, client *daoclient.C
 			newThinPoolDataUsageGraph(tags),
 			newThinPoolMetadataUsageGraph(tags),
 		}
+		//add quota thresholds to profile
+		addStorageQuotaThresholds(profile, quotas)
 
 		volumeInfo.MonitoringProfile = *profile
 		storageInfo = append(storageInfo, volumeInfo)
@@ -891,8 +909,15 @@ func restGetStorage(w *rest.ResponseWriter, r *rest.Request, client *daoclient.C
 	w.WriteJson(storageInfo)
 }
 
-func restGetUIConfig(w *rest.ResponseWriter, r *rest.Request, client *daoclient.ControlClient) {
-	w.WriteJson(uiConfig)
+func restGetUIConfig(w *rest.ResponseWriter, r *rest.Request, ctx *requestContext) {
+	cfg := uiConfig
+	policy, err := ctx.getFacade().GetLogRetentionPolicy(ctx.getDatastoreContext())
+	if err != nil {
+		plog.WithError(err).Warn("Could not get log retention policy")
+	} else {
+		cfg.LogRetention = policy
+	}
+	w.WriteJson(cfg)
 }
 
 func RestBackupCheck(w *rest.ResponseWriter, r *rest.Request, client *daoclient.ControlClient) {
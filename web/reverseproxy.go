@@ -36,6 +36,7 @@ type ReverseProxyKey struct {
 	HostAddress string
 	PrivateAddress string
 	UseTLS  bool
+	Exports Exports
 }
 
 // ReverseProxyCache keeps track of all available reverse proxies
@@ -45,33 +46,52 @@ type ReverseProxyCache struct {
 }
 
 // Get retrieves a reverse proxy from the cache
-func (cache *ReverseProxyCache) Get(hostAddress, privateAddress string, useTLS bool) (*httputil.ReverseProxy, bool) {
+func (cache *ReverseProxyCache) Get(hostAddress, privateAddress string, useTLS bool, exports Exports) (*httputil.ReverseProxy, bool) {
 	cache.mu.RLock()
 	defer cache.mu.RUnlock()
 	key := ReverseProxyKey{
 		HostAddress: hostAddress,
 		PrivateAddress: privateAddress,
 		UseTLS:  useTLS,
+		Exports: exports,
 	}
 	rp, ok := cache.data[key]
 	return rp, ok
 }
 
 // Set sets an instantiated reverse proxy
-func (cache *ReverseProxyCache) Set(hostAddress, privateAddress string, useTLS bool, rp *httputil.ReverseProxy) {
+func (cache *ReverseProxyCache) Set(hostAddress, privateAddress string, useTLS bool, exports Exports, rp *httputil.ReverseProxy) {
 	cache.mu.Lock()
 	defer cache.mu.Unlock()
 	key := ReverseProxyKey{
 		HostAddress: hostAddress,
 		PrivateAddress: privateAddress,
 		UseTLS:  useTLS,
+		Exports: exports,
 	}
 	cache.data[key] = rp
 }
 
+// Evict removes the reverse proxies of the exports from the cache, and closes
+// their idle connections.
+func (cache *ReverseProxyCache) Evict(exports Exports) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	for key, rp := range cache.data {
+		if key.Exports != exports {
+			continue
+		}
+		if transport, ok := rp.Transport.(*http.Transport); ok {
+			transport.CloseIdleConnections()
+		}
+		delete(cache.data, key)
+	}
+}
+
 // GetReverseProxy acquires a reverse proxy from the cache if it exists or
-// creates it if it is not found.
-func GetReverseProxy(useTLS bool, export *registry.ExportDetails) *httputil.ReverseProxy {
+// creates it if it is not found.  The connections of the proxy are drained
+// when the export leaves the given exports.
+func GetReverseProxy(useTLS bool, exports Exports, export *registry.ExportDetails) *httputil.ReverseProxy {
 	remoteAddress := ""
 	hostAddress := fmt.Sprintf("%s:%d", export.HostIP, export.MuxPort)
 	privateAddress := fmt.Sprintf("%s:%d", export.PrivateIP, export.PortNumber)
@@ -86,7 +106,7 @@ func GetReverseProxy(useTLS bool, export *registry.ExportDetails) *httputil.Reve
 	}
 
 	// Look up the reverse proxy in the cache and return it if it exists.
-	rp, ok := rpcache.Get(hostAddress, privateAddress, useTLS)
+	rp, ok := rpcache.Get(hostAddress, privateAddress, useTLS, exports)
 	if ok {
 		return rp
 	}
@@ -95,11 +115,15 @@ func GetReverseProxy(useTLS bool, export *registry.ExportDetails) *httputil.Reve
 	rpurl := url.URL{Scheme: "http", Host: remoteAddress}
 	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
 	transport.Dial = func(network, addr string) (net.Conn, error) {
-		return GetRemoteConnection(useTLS, export)
+		remote, err := GetRemoteConnection(useTLS, export)
+		if err != nil {
+			return nil, err
+		}
+		return exports.Track(export, remote), nil
 	}
 	rp = httputil.NewSingleHostReverseProxy(&rpurl)
 	rp.Transport = transport
 	rp.FlushInterval = time.Millisecond * 10
-	rpcache.Set(hostAddress, privateAddress, useTLS, rp)
+	rpcache.Set(hostAddress, privateAddress, useTLS, exports, rp)
 	return rp
 }
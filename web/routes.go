@@ -106,7 +106,7 @@ func (sc *ServiceConfig) getRoutes() []rest.Route {
 
 		// "Misc" stuff
 		rest.Route{"GET", "/top/services", gz(sc.checkAuth(restGetTopServices))},
-		rest.Route{"GET", "/config", gz(sc.authorizedClient(restGetUIConfig))},
+		rest.Route{"GET", "/config", gz(sc.checkAuth(restGetUIConfig))},
 		rest.Route{"GET", "/servicestatus", gz(sc.checkAuth(restGetConciseServiceStatus))},
 
 		// Generic static data
@@ -119,7 +119,7 @@ func (sc *ServiceConfig) getRoutes() []rest.Route {
 		rest.Route{"GET", "/dockerIsLoggedIn", gz(sc.authorizedClient(restDockerIsLoggedIn))},
 		rest.Route{"GET", "/stats", gz(sc.isCollectingStats())},
 		rest.Route{"GET", "/version", gz(restGetServicedVersion)},
-		rest.Route{"GET", "/storage", gz(sc.authorizedClient(restGetStorage))},
+		rest.Route{"GET", "/storage", gz(sc.checkAuth(restGetStorage))},
 
 		// V2 API
 		rest.Route{"GET", "/api/v2/pools", gz(sc.checkAuth(getPools))},
@@ -136,6 +136,7 @@ func (sc *ServiceConfig) getRoutes() []rest.Route {
 		rest.Route{"GET", "/api/v2/services/:serviceId/services", gz(sc.checkAuth(getChildServiceDetails))},
 		rest.Route{"GET", "/api/v2/services/:serviceId/instances", gz(sc.checkAuth(restGetServiceInstances))},
 		rest.Route{"GET", "/api/v2/services/:serviceId/monitoringprofile", gz(sc.checkAuth(restGetServiceMonitoringProfile))},
+		rest.Route{"GET", "/api/v2/services/:serviceId/slos", gz(sc.checkAuth(restGetServiceSLOs))},
 		rest.Route{"GET", "/api/v2/services/:serviceId/publicendpoints", gz(sc.checkAuth(restGetServicePublicEndpoints))},
 		rest.Route{"GET", "/api/v2/services/:serviceId/ipassignments", gz(sc.checkAuth(restGetServiceIPAssignments))},
 		rest.Route{"GET", "/api/v2/services/:serviceId/exportendpoints", gz(sc.checkAuth(restGetServiceExportedEndpoints))},
@@ -144,6 +145,7 @@ func (sc *ServiceConfig) getRoutes() []rest.Route {
 		rest.Route{"PUT", "/api/v2/services/:serviceId/context", gz(sc.checkAuth(putServiceContext))},
 		rest.Route{"GET", "/api/v2/statuses", gz(sc.checkAuth(restGetAggregateServices))},
 		rest.Route{"GET", "/api/v2/hoststatuses", gz(sc.checkAuth(getHostStatuses))},
+		rest.Route{"GET", "/api/v2/audit", gz(sc.checkAuth(getAuditEntries))},
 
 		rest.Route{"GET", "/api/v2/services/:serviceId/serviceconfigs", gz(sc.checkAuth(restGetServiceConfigFiles))},
 		rest.Route{"POST", "/api/v2/services/:serviceId/serviceconfigs", gz(sc.checkAuth(restAddServiceConfigFile))},
@@ -15,13 +15,16 @@ package web
 
 import (
 	"crypto/tls"
+	"errors"
 	"net"
 	"net/http"
 	"sync"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/control-center/serviced/config"
 	"github.com/control-center/serviced/proxy"
+	"github.com/control-center/serviced/zzk/registry"
 )
 
 // If RawPath is given, Golang's url object has canonized the original URL.  We
@@ -38,6 +41,34 @@ func RouteOriginalURL(r *http.Request) {
 	}
 }
 
+// ServeUDP forwards the datagrams received on conn to the exports, with a
+// session for each client address that is allowed through the access filter.
+func ServeUDP(cancel <-chan struct{}, conn net.PacketConn, exports Exports, filter func() *AccessFilter) {
+	udp := proxy.NewUDPProxy(conn, func(client net.Addr) (net.Conn, func(), error) {
+		if err := filter().Check(client.String()); err != nil {
+			return nil, nil, err
+		}
+
+		export := exports.Pick(client.String())
+		if export == nil {
+			// This happens if the endpoint is accessed and the containers
+			// have died or not come up yet.
+			return nil, nil, errors.New("could not retrieve endpoint")
+		}
+
+		remote, err := GetRemoteDatagramConnection(config.MuxTLSIsEnabled(), export)
+		if err != nil {
+			exports.Release(export)
+			return nil, nil, err
+		}
+		return exports.Track(export, remote), func() { exports.Release(export) }, nil
+	}, proxy.DefaultUDPTimeout)
+
+	go udp.Serve()
+	<-cancel
+	udp.Close()
+}
+
 // ServeTCP sets up a tcp based server connection given a set of exports.
 func ServeTCP(cancel <-chan struct{}, listener net.Listener, tlsConfig *tls.Config, exports Exports) {
 	stopChan := make(chan bool)
@@ -55,7 +86,7 @@ func ServeTCP(cancel <-chan struct{}, listener net.Listener, tlsConfig *tls.Conf
 				local = tls.Server(local, tlsConfig)
 			}
 
-			export := exports.Next()
+			export := exports.Pick(local.RemoteAddr().String())
 			if export == nil {
 				// This happens if the endpoint is accessed and the containers
 				// have died or not come up yet.
@@ -79,16 +110,19 @@ func ServeTCP(cancel <-chan struct{}, listener net.Listener, tlsConfig *tls.Conf
 			remote, err := GetRemoteConnection(config.MuxTLSIsEnabled(), export)
 			if err != nil {
 				logger.WithError(err).Error("Could not get remote connection for endpoint")
+				exports.Release(export)
 				continue
 			}
+			remote = exports.Track(export, remote)
 
 			logger.WithField("remoteaddress", remote.RemoteAddr()).Debug("Established remote connection")
 
 			wg.Add(1)
-			go func() {
+			go func(export *registry.ExportDetails) {
 				proxy.ProxyLoop(local, remote, stopChan)
+				exports.Release(export)
 				wg.Done()
-			}()
+			}(export)
 		}
 	}()
 
@@ -99,7 +133,7 @@ func ServeTCP(cancel <-chan struct{}, listener net.Listener, tlsConfig *tls.Conf
 }
 
 // ServeHTTP sets up an http server for handling a collection of endpoints
-func ServeHTTP(cancel <-chan struct{}, address, protocol string, listener net.Listener, tlsConfig *tls.Config, exports Exports) {
+func ServeHTTP(cancel <-chan struct{}, address, protocol string, listener net.Listener, tlsConfig *tls.Config, exports Exports, accessLog *AccessLogger) {
 	logger := plog.WithFields(log.Fields{
 		"portaddress": address,
 		"protocol":    protocol,
@@ -113,6 +147,10 @@ func ServeHTTP(cancel <-chan struct{}, address, protocol string, listener net.Li
 	httphandler := func(w http.ResponseWriter, r *http.Request) {
 		RouteOriginalURL(r)
 
+		aw := newAccessLogWriter(w)
+		defer recordAccess(accessLog, "port", address, aw, r, r.URL.Path, time.Now())
+		w = aw
+
 		// Notify any active connections that the endpoint is not available if
 		// they refresh the browser.
 		select {
@@ -137,13 +175,15 @@ func ServeHTTP(cancel <-chan struct{}, address, protocol string, listener net.Li
 
 		logger.WithField("handlerrequest", r).Debug("Handler handling (port) request")
 
-		export := exports.Next()
+		export := exports.Pick(r.RemoteAddr)
 		if export == nil {
 			http.Error(w, "endpoint not available", http.StatusNotFound)
 			return
 		}
+		defer exports.Release(export)
+		setAccessExport(w, export)
 
-		rp := GetReverseProxy(config.MuxTLSIsEnabled(), export)
+		rp := GetReverseProxy(config.MuxTLSIsEnabled(), exports, export)
 
 		logger.WithFields(log.Fields{
 			"application": export.Application,
@@ -171,11 +211,7 @@ func ServeHTTP(cancel <-chan struct{}, address, protocol string, listener net.Li
 	server := &http.Server{Addr: address, Handler: http.HandlerFunc(httphandler)}
 
 	if tlsConfig != nil {
-		keepAliveListener := &TCPKeepAliveListener{
-			TCPListener: listener.(*net.TCPListener),
-			cancel:      cancel,
-		}
-		listener = tls.NewListener(keepAliveListener, tlsConfig)
+		listener = tls.NewListener(keepAlive(listener, cancel), tlsConfig)
 	}
 
 	wg := &sync.WaitGroup{}
@@ -17,6 +17,8 @@ import (
 	"fmt"
 
 	"github.com/control-center/serviced/domain"
+	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/stats"
 )
 
 var internalCounterStats = []string{
@@ -28,10 +30,14 @@ var internalCounterStats = []string{
 	"net.tx_dropped", "net.tx_errors", "net.tx_fifo_errors",
 	"net.tx_heartbeat_errors", "net.tx_packets", "net.tx_window_errors",
 	"cgroup.memory.pgmajfault",
+	"publicendpoint.requests.1xx", "publicendpoint.requests.2xx", "publicendpoint.requests.3xx",
+	"publicendpoint.requests.4xx", "publicendpoint.requests.5xx", "publicendpoint.bytes",
+	"publicendpoint.denied", "publicendpoint.ratelimited",
 }
 var internalGaugeStats = []string{
 	"cgroup.memory.totalrss", "cgroup.memory.cache", "net.open_connections.tcp", "net.open_connections.udp",
 	"net.open_connections.raw", "docker.usageinkernelmode", "docker.usageinusermode",
+	"publicendpoint.latency.p50", "publicendpoint.latency.p95", "publicendpoint.latency.p99",
 }
 var internalTenantStats = []string{
 	"storage.filesystem.available.%s", "storage.filesystem.used.%s",
@@ -337,3 +343,98 @@ func getTenantGraphConfigs(tenantID string) []domain.GraphConfig {
 		},
 	}
 }
+
+// getPublicEndpointGraphConfigs returns the request graphs of the vhosts and
+// public ports of a service, or nothing if the service has none.
+func getPublicEndpointGraphConfigs(svc *service.Service) []domain.GraphConfig {
+	names := []string{}
+	for _, ep := range svc.Endpoints {
+		for _, vhost := range ep.VHostList {
+			names = append(names, stats.TagValue(vhost.Name))
+		}
+		for _, port := range ep.PortList {
+			names = append(names, stats.TagValue(port.PortAddr))
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	tags := map[string][]string{
+		"publicendpoint_name": names,
+	}
+	tRange := domain.GraphConfigRange{
+		Start: "1h-ago",
+		End:   "0s-ago",
+	}
+	zero := 0
+
+	requests := []domain.DataPoint{}
+	for _, class := range []string{"2xx", "3xx", "4xx", "5xx"} {
+		metric := "publicendpoint.requests." + class
+		requests = append(requests, domain.DataPoint{
+			Aggregator:   "sum",
+			Format:       "%4.2f",
+			Legend:       class,
+			Metric:       metric,
+			MetricSource: "metrics",
+			ID:           metric,
+			Name:         class + " Responses",
+			Rate:         true,
+			RateOptions: &domain.DataPointRateOptions{
+				Counter:        true,
+				ResetThreshold: 1,
+			},
+			Type: "area",
+		})
+	}
+
+	latency := []domain.DataPoint{}
+	for _, p := range []string{"p50", "p95", "p99"} {
+		metric := "publicendpoint.latency." + p
+		latency = append(latency, domain.DataPoint{
+			Aggregator:   "max",
+			Format:       "%4.2f",
+			Legend:       p,
+			Metric:       metric,
+			MetricSource: "metrics",
+			ID:           metric,
+			Name:         p + " Latency",
+			Rate:         false,
+			Type:         "line",
+		})
+	}
+
+	return []domain.GraphConfig{
+		{
+			// public endpoint request rate graph
+			ID:          "internalPublicEndpointRequests",
+			Name:        "Public Endpoint Requests",
+			BuiltIn:     true,
+			Format:      "%4.2f",
+			ReturnSet:   "EXACT",
+			Type:        "area",
+			Tags:        tags,
+			YAxisLabel:  "requests/s",
+			Description: "Requests per second by response status",
+			MinY:        &zero,
+			Range:       &tRange,
+			Units:       "Requests per second",
+			DataPoints:  requests,
+		}, {
+			// public endpoint latency graph
+			ID:          "internalPublicEndpointLatency",
+			Name:        "Public Endpoint Latency",
+			BuiltIn:     true,
+			Format:      "%4.2f",
+			ReturnSet:   "EXACT",
+			Type:        "line",
+			Tags:        tags,
+			YAxisLabel:  "ms",
+			Description: "Response time percentiles",
+			MinY:        &zero,
+			Range:       &tRange,
+			Units:       "Milliseconds",
+			DataPoints:  latency,
+		},
+	}
+}
@@ -18,9 +18,11 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/control-center/serviced/auth"
+	"github.com/control-center/serviced/config"
 	"github.com/control-center/serviced/logging"
 	"github.com/control-center/serviced/proxy"
 	"github.com/control-center/serviced/utils"
@@ -33,6 +35,19 @@ var plog = logging.PackageLogger()
 // ipmap keeps track of all ipv4 addresses on this host
 var ipmap = make(map[string]struct{})
 
+// muxSessions are the sessions to remote muxes, with and without TLS
+var (
+	muxSessionsLock sync.Mutex
+	muxSessions     = make(map[bool]*proxy.SessionPool)
+)
+
+// muxCredentials authenticate this host to remote muxes, if they require
+// mutual TLS
+var (
+	muxCredentialsLock sync.RWMutex
+	muxCredentials     *auth.MuxCredentials
+)
+
 func init() {
 	// set up the ipmap
 	ips, err := utils.GetIPv4Addresses()
@@ -101,28 +116,88 @@ func newTlsDialer(config *tls.Config) dialerInterface {
 	return &tlsDialer{config: config}
 }
 
+// SetMuxCredentials sets the certificate that is presented to remote muxes.
+func SetMuxCredentials(creds *auth.MuxCredentials) {
+	muxCredentialsLock.Lock()
+	defer muxCredentialsLock.Unlock()
+	muxCredentials = creds
+}
+
+// muxTLSConfig returns the TLS configuration for connecting to the mux of a
+// remote host.
+func muxTLSConfig(hostIP string) (*tls.Config, error) {
+	muxCredentialsLock.RLock()
+	creds := muxCredentials
+	muxCredentialsLock.RUnlock()
+	if creds == nil {
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+	return creds.ClientConfig(hostIP)
+}
+
 // GetRemoteConnection returns a connection to a remote address
 func GetRemoteConnection(useTLS bool, export *registry.ExportDetails) (remote net.Conn, err error) {
+	return dialRemote(useTLS, export, getRemoteConnection)
+}
+
+// GetRemoteDatagramConnection returns a connection that carries udp datagrams
+// to a remote address
+func GetRemoteDatagramConnection(useTLS bool, export *registry.ExportDetails) (remote net.Conn, err error) {
+	return dialRemote(useTLS, export, getRemoteDatagramConnection)
+}
+
+func dialRemote(useTLS bool, export *registry.ExportDetails, dial func(*registry.ExportDetails, dialerInterface) (net.Conn, error)) (remote net.Conn, err error) {
 	var dialer dialerInterface
 	if useTLS && !IsLocalAddress(export.HostIP) {
-		config := tls.Config{InsecureSkipVerify: true}
-		dialer = newTlsDialer(&config)
+		config, err := muxTLSConfig(export.HostIP)
+		if err != nil {
+			return nil, err
+		}
+		dialer = newTlsDialer(config)
 	} else {
 		dialer = newNetDialer()
 	}
-	return getRemoteConnection(export, dialer)
+
+	// keep track of failures so that unreachable exports can be ejected
+	remote, err = dial(export, dialer)
+	if err != nil {
+		exportHealth.Failed(exportKey(export))
+		return nil, err
+	}
+	exportHealth.Succeeded(exportKey(export))
+	return remote, nil
 }
 
 func getRemoteConnection(export *registry.ExportDetails, dialer dialerInterface) (net.Conn, error) {
+	return dialExport(export, dialer, false)
+}
+
+func getRemoteDatagramConnection(export *registry.ExportDetails, dialer dialerInterface) (net.Conn, error) {
+	return dialExport(export, dialer, true)
+}
+
+func dialExport(export *registry.ExportDetails, dialer dialerInterface, udp bool) (net.Conn, error) {
 	// If the exported endpoint is on this Host, we don't go through the mux.
 	if IsLocalAddress(export.HostIP) {
 		// if the address is local return a connection directly to the container
 		address := fmt.Sprintf("%s:%d", export.PrivateIP, export.PortNumber)
+		if udp {
+			return dialer.Dial("udp4", address)
+		}
 		return dialer.Dial("tcp4", address)
 	}
 
 	// Set up the remote address for the mux
 	remoteAddress := fmt.Sprintf("%s:%d", export.HostIP, export.MuxPort)
+
+	// Streams share the connections to the mux when it supports sessions
+	if sessions := getSessionPool(dialer); sessions != nil {
+		remote, err := dialSession(sessions, remoteAddress, export, udp)
+		if err != proxy.ErrSessionUnsupported {
+			return remote, err
+		}
+	}
+
 	remote, err := dialer.Dial("tcp4", remoteAddress)
 
 	// Prevent a panic if we couldn't connect to the mux.
@@ -137,18 +212,21 @@ func getRemoteConnection(export *registry.ExportDetails, dialer dialerInterface)
 		return nil, err
 	}
 
-	var (
-		token        string
-		tokenTimeout = 30 * time.Second
-	)
-
-	select {
-	case token = <-auth.AuthToken(nil):
-	case <-time.After(tokenTimeout):
-		plog.WithField("timeout", "30s").Error("Unable to retrieve authentication token within the timeout")
+	token, err := getAuthToken()
+	if err != nil {
 		return nil, err
 	}
 
+	if udp {
+		if err := auth.AddSignedUDPMuxHeader(remote, muxAddr, token); err != nil {
+			plog.WithError(err).Error("Unable to send authenticated mux header")
+			return nil, err
+		}
+
+		// datagrams are framed to cross the mux
+		return proxy.NewDatagramConn(remote), nil
+	}
+
 	if err := auth.AddSignedMuxHeader(remote, muxAddr, token); err != nil {
 		plog.WithError(err).Error("Unable to send authenticated mux header")
 		return nil, err
@@ -157,6 +235,83 @@ func getRemoteConnection(export *registry.ExportDetails, dialer dialerInterface)
 	return remote, nil
 }
 
+// dialSession opens a stream to the export on a session to its mux.
+func dialSession(sessions *proxy.SessionPool, remoteAddress string, export *registry.ExportDetails, udp bool) (net.Conn, error) {
+	muxAddr, err := utils.PackTCPAddress(export.PrivateIP, export.PortNumber)
+	if err != nil {
+		return nil, err
+	}
+	token, err := getAuthToken()
+	if err != nil {
+		return nil, err
+	}
+	remote, err := sessions.Dial(remoteAddress, muxAddr, udp, token)
+	if err != nil {
+		return nil, err
+	}
+	if udp {
+		return proxy.NewDatagramConn(remote), nil
+	}
+	return remote, nil
+}
+
+// getSessionPool returns the sessions to remote muxes that are dialed like
+// dialer, or nil if sessions are disabled.
+func getSessionPool(dialer dialerInterface) *proxy.SessionPool {
+	size := config.GetOptions().MuxSessions
+	if size <= 0 {
+		return nil
+	}
+	_, useTLS := dialer.(*tlsDialer)
+
+	muxSessionsLock.Lock()
+	defer muxSessionsLock.Unlock()
+	sessions, ok := muxSessions[useTLS]
+	if !ok {
+		sessions = proxy.NewSessionPool(size, func(address string) (net.Conn, error) {
+			if !useTLS {
+				return dialer.Dial("tcp4", address)
+			}
+			// each mux has its own certificate
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return nil, err
+			}
+			config, err := muxTLSConfig(host)
+			if err != nil {
+				return nil, err
+			}
+			return newTlsDialer(config).Dial("tcp4", address)
+		})
+		muxSessions[useTLS] = sessions
+	}
+	return sessions
+}
+
+// getAuthToken waits for the token that authenticates us to the mux.
+func getAuthToken() (string, error) {
+	tokenTimeout := 30 * time.Second
+	select {
+	case token := <-auth.AuthToken(nil):
+		return token, nil
+	case <-time.After(tokenTimeout):
+		plog.WithField("timeout", "30s").Error("Unable to retrieve authentication token within the timeout")
+		return "", errors.New("timed out waiting for an authentication token")
+	}
+}
+
+// keepAlive wraps the tcp listener underneath any filtering listeners to keep
+// its connections alive.
+func keepAlive(listener net.Listener, cancel <-chan struct{}) net.Listener {
+	switch l := listener.(type) {
+	case *net.TCPListener:
+		return &TCPKeepAliveListener{TCPListener: l, cancel: cancel}
+	case *accessListener:
+		return &accessListener{Listener: keepAlive(l.Listener, cancel), filter: l.filter}
+	}
+	return listener
+}
+
 // TCPKeepAliveListener keeps a listener connection alive for the duration
 // of a cancellable
 type TCPKeepAliveListener struct {
@@ -14,27 +14,42 @@
 package web
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
 	"net/http"
+	"strings"
 	"sync"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/control-center/serviced/domain/servicedefinition"
 	"github.com/control-center/serviced/zzk/registry"
-	"strings"
 )
 
+// affinityCookie is the name of the cookie that pins a client to an export
+// when sticky sessions are enabled.
+const affinityCookie = "ZCP-AFFINITY"
+
 // VHostManager manages all vhosts on a host
 type VHostManager struct {
-	useTLS bool
-	mu     *sync.RWMutex
-	vhosts map[string]*VHostHandler
+	useTLS    bool
+	accessLog *AccessLogger
+	mu        *sync.RWMutex
+	vhosts    map[string]*VHostHandler
 }
 
-// NewVHostManager creates a new vhost manager for a host
-func NewVHostManager(useTLS bool) *VHostManager {
+// NewVHostManager creates a new vhost manager for a host, which ships the
+// requests it serves to the access log, if there is one.
+func NewVHostManager(useTLS bool, accessLog *AccessLogger) *VHostManager {
 	return &VHostManager{
-		useTLS: useTLS,
-		mu:     &sync.RWMutex{},
-		vhosts: make(map[string]*VHostHandler),
+		useTLS:    useTLS,
+		accessLog: accessLog,
+		mu:        &sync.RWMutex{},
+		vhosts:    make(map[string]*VHostHandler),
 	}
 }
 
@@ -76,6 +91,49 @@ func (m *VHostManager) Set(name string, data []registry.ExportDetails) {
 	}
 }
 
+// SetRules updates the rules for proxying requests to the vhost
+func (m *VHostManager) SetRules(name string, rules servicedefinition.VHostRules) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.vhosts[name]
+	if !ok {
+		h = NewVHostHandler()
+		m.vhosts[name] = h
+	}
+	h.SetRules(rules)
+}
+
+// SetAccess updates the access rules of the vhost
+func (m *VHostManager) SetAccess(name string, rules servicedefinition.AccessRules) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.vhosts[name]
+	if !ok {
+		h = NewVHostHandler()
+		m.vhosts[name] = h
+	}
+	if access := h.getAccess(); access != nil {
+		access.SetRules(rules)
+	} else {
+		h.SetAccess(NewAccessFilter("vhost", name, rules))
+	}
+}
+
+// SetRoute updates the endpoints of a path prefix route of the vhost
+func (m *VHostManager) SetRoute(name, pathPrefix string, data []registry.ExportDetails) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.vhosts[name]
+	if !ok {
+		h = NewVHostHandler()
+		m.vhosts[name] = h
+	}
+	h.SetRoute(pathPrefix, data)
+}
+
 // Handle manages a vhost request and returns true if the vhost is enabled
 func (m *VHostManager) Handle(httphost string, w http.ResponseWriter, r *http.Request) bool {
 	m.mu.RLock()
@@ -91,7 +149,12 @@ func (m *VHostManager) handle(name string, w http.ResponseWriter, r *http.Reques
 	h, ok := m.vhosts[name]
 	if ok {
 		plog.WithField("name", name).Debug("Found VHost handler")
-		return h.Handle(m.useTLS, w, r)
+		start, path := time.Now(), r.URL.Path
+		aw := newAccessLogWriter(w)
+		if h.Handle(m.useTLS, aw, r) {
+			recordAccess(m.accessLog, "vhost", name, aw, r, path, start)
+			return true
+		}
 	}
 	return false
 }
@@ -99,6 +162,9 @@ func (m *VHostManager) handle(name string, w http.ResponseWriter, r *http.Reques
 // VHostHandler manages a vhost endpoint
 type VHostHandler struct {
 	exports Exports
+	routes  map[string]Exports
+	rules   servicedefinition.VHostRules
+	access  *AccessFilter
 	mu      *sync.RWMutex
 	enabled bool
 }
@@ -106,7 +172,8 @@ type VHostHandler struct {
 // NewVHostHandler instantiates a new vhost handler
 func NewVHostHandler(data ...registry.ExportDetails) *VHostHandler {
 	return &VHostHandler{
-		exports: NewRoundRobinExports(data), // default to round-robin
+		exports: NewBalancedExports(data),
+		routes:  make(map[string]Exports),
 		mu:      &sync.RWMutex{},
 		enabled: false,
 	}
@@ -131,6 +198,65 @@ func (h *VHostHandler) SetExports(data []registry.ExportDetails) {
 	h.exports.Set(data)
 }
 
+// SetRules updates the rules of a vhost endpoint, and closes the routes that
+// are no longer configured.
+func (h *VHostHandler) SetRules(rules servicedefinition.VHostRules) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rules = rules
+
+	prefixes := make(map[string]struct{})
+	for _, route := range rules.Routes {
+		prefixes[route.PathPrefix] = struct{}{}
+	}
+	for prefix, exports := range h.routes {
+		if _, ok := prefixes[prefix]; !ok {
+			exports.Close()
+			delete(h.routes, prefix)
+		}
+	}
+}
+
+// SetAccess updates the access filter of a vhost endpoint
+func (h *VHostHandler) SetAccess(access *AccessFilter) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.access = access
+}
+
+func (h *VHostHandler) getAccess() *AccessFilter {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.access
+}
+
+// SetRoute updates the exports of a path prefix route
+func (h *VHostHandler) SetRoute(pathPrefix string, data []registry.ExportDetails) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if exports, ok := h.routes[pathPrefix]; ok {
+		exports.Set(data)
+	} else {
+		h.routes[pathPrefix] = NewBalancedExports(data)
+	}
+}
+
+// route returns the route with the longest prefix that matches the path, or
+// false if the request goes to the vhost's own endpoint.
+func (h *VHostHandler) route(urlPath string) (servicedefinition.VHostRoute, bool) {
+	var match servicedefinition.VHostRoute
+	found := false
+	for _, route := range h.rules.Routes {
+		if !matchPrefix(urlPath, route.PathPrefix) {
+			continue
+		}
+		if !found || len(route.PathPrefix) > len(match.PathPrefix) {
+			match, found = route, true
+		}
+	}
+	return match, found
+}
+
 // Handle is the vhost handler, returns true if the vhost is enabled
 func (h *VHostHandler) Handle(useTLS bool, w http.ResponseWriter, r *http.Request) bool {
 	h.mu.RLock()
@@ -141,13 +267,57 @@ func (h *VHostHandler) Handle(useTLS bool, w http.ResponseWriter, r *http.Reques
 		return false
 	}
 
-	// get the next available export
-	export := h.exports.Next()
-	if export == nil {
-		http.Error(w, "endpoint not available", http.StatusNotFound)
+	// reject requests that are denied or over the rate limit
+	switch err := h.access.Check(r.RemoteAddr); err {
+	case nil:
+	case ErrRateLimited:
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
 		return true
+	default:
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return true
+	}
+
+	// find the exports that serve the path
+	exports, cookieName := h.exports, affinityCookie
+	route, routed := h.route(r.URL.Path)
+	if routed {
+		if exports, routed = h.routes[route.PathPrefix]; !routed {
+			http.Error(w, "endpoint not available", http.StatusNotFound)
+			return true
+		}
+		cookieName = routeAffinityCookie(route.PathPrefix)
 	}
 
+	// return to the export the client was pinned to, if it is still
+	// available, otherwise get the next available export
+	var export *registry.ExportDetails
+	if h.rules.StickySessions {
+		if cookie, err := r.Cookie(cookieName); err == nil {
+			export = exports.Acquire(cookie.Value)
+		}
+	}
+	if export == nil {
+		if export = exports.Pick(r.RemoteAddr); export == nil {
+			http.Error(w, "endpoint not available", http.StatusNotFound)
+			return true
+		}
+		if h.rules.StickySessions {
+			http.SetCookie(w, &http.Cookie{
+				Name:     cookieName,
+				Value:    exportAffinity(export),
+				Path:     "/",
+				HttpOnly: true,
+			})
+		}
+	}
+	defer exports.Release(export)
+	setAccessExport(w, export)
+
+	if routed && route.StripPrefix {
+		stripPrefix(r, route.PathPrefix)
+	}
 	RouteOriginalURL(r)
 
 	logger := plog.WithFields(log.Fields{
@@ -160,16 +330,122 @@ func (h *VHostHandler) Handle(useTLS bool, w http.ResponseWriter, r *http.Reques
 	logger.Debug("Proxying endpoint")
 
 	// get the reverse proxy for the export
-	rp := GetReverseProxy(useTLS, export)
+	rp := GetReverseProxy(useTLS, exports, export)
 
 	// Set up the X-Forwarded-Proto header so that downstream servers know
 	// the request originated as HTTPS.
 	if _, found := r.Header["X-Forwarded-Proto"]; !found {
 		r.Header.Set("X-Forwarded-Proto", "https")
 	}
+	applyHeaderRules(r.Header, h.rules.Headers, false)
+
+	if timeout := h.rules.GetTimeout(); timeout > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+	}
 
 	w.Header().Add("Strict-Transport-Security", "max-age=31536000")
-	rp.ServeHTTP(w, r)
+	rp.ServeHTTP(newHeaderRuleWriter(w, h.rules.Headers), r)
 
 	return true
 }
+
+// routeAffinityCookie returns the name of the affinity cookie of a route, so
+// that a client can be pinned to an export of each endpoint behind the vhost.
+func routeAffinityCookie(pathPrefix string) string {
+	h := fnv.New32a()
+	h.Write([]byte(pathPrefix))
+	return fmt.Sprintf("%s-%08x", affinityCookie, h.Sum32())
+}
+
+// matchPrefix returns true if the path is the prefix, or is beneath it.
+func matchPrefix(urlPath, prefix string) bool {
+	if !strings.HasPrefix(urlPath, prefix) {
+		return false
+	}
+	return len(urlPath) == len(prefix) || strings.HasSuffix(prefix, "/") || urlPath[len(prefix)] == '/'
+}
+
+// stripPrefix removes the route prefix from the path of a request.
+func stripPrefix(r *http.Request, prefix string) {
+	trim := func(p string) string {
+		p = strings.TrimPrefix(p, strings.TrimSuffix(prefix, "/"))
+		if !strings.HasPrefix(p, "/") {
+			p = "/" + p
+		}
+		return p
+	}
+	r.URL.Path = trim(r.URL.Path)
+	if r.URL.RawPath != "" {
+		r.URL.RawPath = trim(r.URL.RawPath)
+	}
+}
+
+// applyHeaderRules rewrites the headers with the request (or response) rules.
+func applyHeaderRules(header http.Header, rules []servicedefinition.HeaderRule, response bool) {
+	for _, rule := range rules {
+		if rule.Response != response {
+			continue
+		}
+		switch rule.Action {
+		case servicedefinition.HeaderSet:
+			header.Set(rule.Name, rule.Value)
+		case servicedefinition.HeaderAdd:
+			header.Add(rule.Name, rule.Value)
+		case servicedefinition.HeaderRemove:
+			header.Del(rule.Name)
+		}
+	}
+}
+
+// headerRuleWriter applies the response header rules before the response
+// header is written.
+type headerRuleWriter struct {
+	http.ResponseWriter
+	rules       []servicedefinition.HeaderRule
+	wroteHeader bool
+}
+
+// newHeaderRuleWriter wraps the response writer if there are any response
+// header rules.
+func newHeaderRuleWriter(w http.ResponseWriter, rules []servicedefinition.HeaderRule) http.ResponseWriter {
+	for _, rule := range rules {
+		if rule.Response {
+			return &headerRuleWriter{ResponseWriter: w, rules: rules}
+		}
+	}
+	return w
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *headerRuleWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		applyHeaderRules(w.Header(), w.rules, true)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements http.ResponseWriter
+func (w *headerRuleWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher
+func (w *headerRuleWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, for websockets
+func (w *headerRuleWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("response writer does not support hijacking")
+}
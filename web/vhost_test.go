@@ -0,0 +1,253 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build unit
+
+package web
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/control-center/serviced/domain/servicedefinition"
+	"github.com/control-center/serviced/zzk/registry"
+	"github.com/control-center/serviced/zzk/service"
+)
+
+func TestVHostHandler_Route(t *testing.T) {
+	h := NewVHostHandler()
+	h.SetRules(servicedefinition.VHostRules{
+		Routes: []servicedefinition.VHostRoute{
+			{PathPrefix: "/api", Application: "api"},
+			{PathPrefix: "/api/v2/", Application: "apiv2"},
+		},
+	})
+
+	for urlPath, expected := range map[string]string{
+		"/":           "",
+		"/apis":       "",
+		"/api":        "api",
+		"/api/v1/foo": "api",
+		"/api/v2":     "api",
+		"/api/v2/foo": "apiv2",
+	} {
+		route, ok := h.route(urlPath)
+		if expected == "" && ok {
+			t.Errorf("Path %s: expected no route, got %s", urlPath, route.Application)
+		} else if expected != "" && route.Application != expected {
+			t.Errorf("Path %s: expected route %s, got %s", urlPath, expected, route.Application)
+		}
+	}
+}
+
+func TestStripPrefix(t *testing.T) {
+	for _, tc := range []struct{ prefix, path, expected string }{
+		{"/api", "/api", "/"},
+		{"/api", "/api/foo", "/foo"},
+		{"/api/", "/api/foo", "/foo"},
+	} {
+		r, _ := http.NewRequest("GET", tc.path, nil)
+		stripPrefix(r, tc.prefix)
+		if r.URL.Path != tc.expected {
+			t.Errorf("Strip %s from %s: expected %s, got %s", tc.prefix, tc.path, tc.expected, r.URL.Path)
+		}
+	}
+}
+
+func TestApplyHeaderRules(t *testing.T) {
+	rules := []servicedefinition.HeaderRule{
+		{Action: servicedefinition.HeaderSet, Name: "X-Set", Value: "new"},
+		{Action: servicedefinition.HeaderAdd, Name: "X-Add", Value: "two"},
+		{Action: servicedefinition.HeaderRemove, Name: "X-Remove"},
+		{Action: servicedefinition.HeaderSet, Name: "X-Response", Value: "yes", Response: true},
+	}
+	header := http.Header{}
+	header.Set("X-Set", "old")
+	header.Set("X-Add", "one")
+	header.Set("X-Remove", "gone")
+	applyHeaderRules(header, rules, false)
+
+	if v := header.Get("X-Set"); v != "new" {
+		t.Errorf("Expected X-Set to be replaced, got %s", v)
+	}
+	if v := header["X-Add"]; len(v) != 2 {
+		t.Errorf("Expected X-Add to have 2 values, got %v", v)
+	}
+	if _, ok := header["X-Remove"]; ok {
+		t.Errorf("Expected X-Remove to be deleted")
+	}
+	if _, ok := header["X-Response"]; ok {
+		t.Errorf("Expected response rule to be skipped")
+	}
+
+	// the response rules are applied when the header is written
+	rec := httptest.NewRecorder()
+	w := newHeaderRuleWriter(rec, rules)
+	w.Write([]byte("ok"))
+	if v := rec.Header().Get("X-Response"); v != "yes" {
+		t.Errorf("Expected response header to be set, got %s", v)
+	}
+}
+
+func TestBalancedExports_Acquire(t *testing.T) {
+	data := []registry.ExportDetails{
+		{ExportBinding: service.ExportBinding{PortNumber: 8080}, HostIP: "10.0.0.1", PrivateIP: "172.17.0.2"},
+		{ExportBinding: service.ExportBinding{PortNumber: 8080}, HostIP: "10.0.0.2", PrivateIP: "172.17.0.3"},
+	}
+	exports := NewBalancedExports(data)
+
+	export := exports.Pick("")
+	affinity := exportAffinity(export)
+	exports.Release(export)
+
+	for i := 0; i < 3; i++ {
+		pinned := exports.Acquire(affinity)
+		if pinned == nil || exportKey(pinned) != exportKey(export) {
+			t.Fatalf("Expected the pinned export %s, got %v", exportKey(export), pinned)
+		}
+		exports.Release(pinned)
+	}
+
+	// the export goes away
+	exports.Set(data[:0])
+	if pinned := exports.Acquire(affinity); pinned != nil {
+		t.Errorf("Expected no export, got %s", exportKey(pinned))
+	}
+}
+
+func TestBalancedExports_Drain(t *testing.T) {
+	data := []registry.ExportDetails{
+		{ExportBinding: service.ExportBinding{PortNumber: 8080, DrainPeriod: 1}, HostIP: "10.0.0.3", PrivateIP: "172.17.0.4"},
+		{ExportBinding: service.ExportBinding{PortNumber: 8080, DrainPeriod: 1}, HostIP: "10.0.0.4", PrivateIP: "172.17.0.5"},
+	}
+	exports := NewBalancedExports(data)
+	other := NewBalancedExports(data)
+
+	conn, peer := net.Pipe()
+	defer peer.Close()
+	tracked := exports.Track(&data[0], conn)
+	defer tracked.Close()
+
+	// a connection through another handler that still routes to the export
+	otherConn, otherPeer := net.Pipe()
+	defer otherPeer.Close()
+	otherTracked := other.Track(&data[0], otherConn)
+	defer otherTracked.Close()
+	otherClosed := make(chan struct{})
+	go func() {
+		otherPeer.Read(make([]byte, 1))
+		close(otherClosed)
+	}()
+
+	// the connection gets the drain period to finish once its export is gone
+	exports.Set(data[1:])
+	closed := make(chan struct{})
+	go func() {
+		peer.Read(make([]byte, 1))
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatalf("Connection was closed before the drain period")
+	case <-time.After(500 * time.Millisecond):
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Connection was not closed after the drain period")
+	}
+	select {
+	case <-otherClosed:
+		t.Errorf("Connection of other exports was closed")
+	default:
+	}
+}
+
+func TestVHostHandler_RemoveRoute(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+	_, port, err := net.SplitHostPort(strings.TrimPrefix(backend.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Could not parse backend address: %s", err)
+	}
+	portNumber, _ := strconv.Atoi(port)
+
+	// connect to the backend directly, as if it were a local container
+	ipmap["127.0.0.1"] = struct{}{}
+	defer delete(ipmap, "127.0.0.1")
+	export := registry.ExportDetails{
+		ExportBinding: service.ExportBinding{PortNumber: uint16(portNumber), DrainPeriod: 1},
+		HostIP:        "127.0.0.1",
+		PrivateIP:     "127.0.0.1",
+	}
+
+	h := NewVHostHandler()
+	h.SetRules(servicedefinition.VHostRules{
+		Routes: []servicedefinition.VHostRoute{{PathPrefix: "/api", Application: "api"}},
+	})
+	h.SetRoute("/api", []registry.ExportDetails{export})
+	exports := h.routes["/api"].(*BalancedExports)
+	key := exportKey(&export)
+
+	// leave an idle connection in the transport of the reverse proxy
+	rec := httptest.NewRecorder()
+	GetReverseProxy(false, exports, &export).ServeHTTP(rec, httptest.NewRequest("GET", "/api", nil))
+	if rec.Body.String() != "ok" {
+		t.Fatalf("Expected a response from the backend, got %q", rec.Body.String())
+	}
+	if open := exports.drainer.Open(key); open != 1 {
+		t.Fatalf("Expected 1 open connection, got %d", open)
+	}
+	hostAddress := fmt.Sprintf("%s:%d", export.HostIP, export.MuxPort)
+	if _, ok := rpcache.Get(hostAddress, backend.Listener.Addr().String(), false, exports); !ok {
+		t.Fatalf("Expected the reverse proxy of the route to be cached")
+	}
+
+	// and a connection that is still in use
+	conn, peer := net.Pipe()
+	defer peer.Close()
+	tracked := exports.Track(&export, conn)
+	defer tracked.Close()
+	closed := make(chan struct{})
+	go func() {
+		peer.Read(make([]byte, 1))
+		close(closed)
+	}()
+
+	h.SetRules(servicedefinition.VHostRules{})
+	if _, ok := h.routes["/api"]; ok {
+		t.Errorf("Expected the route to be removed")
+	}
+	if _, ok := rpcache.Get(hostAddress, backend.Listener.Addr().String(), false, exports); ok {
+		t.Errorf("Expected the reverse proxy of the route to be evicted")
+	}
+	if open := exports.drainer.Open(key); open != 1 {
+		t.Errorf("Expected the idle connection to be closed, got %d open connections", open)
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Connection was not closed after the drain period")
+	}
+	if open := exports.drainer.Open(key); open != 0 {
+		t.Errorf("Expected no open connections, got %d", open)
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/control-center/serviced/coordinator/client"
+	"github.com/zenoss/glog"
+)
+
+const (
+	zkPrePull = "/docker/prepull"
+)
+
+// PrePullState describes the progress of an image pre-pull on a host
+type PrePullState string
+
+const (
+	// PrePullPending means the host has not started pulling the image
+	PrePullPending PrePullState = "pending"
+	// PrePullPulling means the host is pulling the image
+	PrePullPulling PrePullState = "pulling"
+	// PrePullDone means the image is available on the host
+	PrePullDone PrePullState = "done"
+	// PrePullFailed means the host could not pull the image
+	PrePullFailed PrePullState = "failed"
+)
+
+func prePullPath(nodes ...string) string {
+	p := []string{zkPrePull}
+	p = append(p, nodes...)
+	return path.Join(p...)
+}
+
+// prePullKey returns the node name of an image, which may contain slashes
+func prePullKey(image string) string {
+	return url.QueryEscape(image)
+}
+
+// PrePull is the request node for pulling an image onto a host before a
+// service instance is scheduled there.
+type PrePull struct {
+	HostID    string
+	Image     string
+	UUID      string // id of the image in the registry when it was requested
+	State     PrePullState
+	Requested time.Time
+	Started   time.Time
+	Finished  time.Time
+	Error     string
+	version   interface{}
+}
+
+// Version is an implementation of client.Node
+func (p *PrePull) Version() interface{} { return p.version }
+
+// SetVersion is an implementation of client.Node
+func (p *PrePull) SetVersion(version interface{}) { p.version = version }
+
+// PrePullHandler handles all non-zookeeper interactions required by the
+// PrePull
+type PrePullHandler interface {
+	PullImage(cancel <-chan time.Time, image string) error
+}
+
+// PrePullListener is the listener object for /docker/prepull
+type PrePullListener struct {
+	conn    client.Connection
+	handler PrePullHandler
+	hostID  string
+	timeout time.Duration
+}
+
+// NewPrePullListener instantiates a new pre-pull listener for /docker/prepull
+func NewPrePullListener(handler PrePullHandler, hostID string, timeout time.Duration) *PrePullListener {
+	return &PrePullListener{handler: handler, hostID: hostID, timeout: timeout}
+}
+
+// SetConnection implements zzk.Listener
+func (l *PrePullListener) SetConnection(conn client.Connection) { l.conn = conn }
+
+// GetPath implements zzk.Listener
+func (l *PrePullListener) GetPath(nodes ...string) string {
+	return prePullPath(append([]string{l.hostID}, nodes...)...)
+}
+
+// Ready implements zzk.Listener
+func (l *PrePullListener) Ready() (err error) { return }
+
+// Done implements zzk.Listener
+func (l *PrePullListener) Done() { return }
+
+// PostProcess implements zzk.Listener
+func (l *PrePullListener) PostProcess(p map[string]struct{}) {}
+
+// Spawn pulls the requested image whenever its request is pending
+func (l *PrePullListener) Spawn(shutdown <-chan interface{}, key string) {
+	done := make(chan struct{})
+	defer func(channel *chan struct{}) { close(*channel) }(&done)
+	for {
+		var req PrePull
+		evt, err := l.conn.GetW(l.GetPath(key), &req, done)
+		if err == client.ErrNoNode {
+			return
+		} else if err != nil {
+			glog.Errorf("Could not look up pre-pull request %s: %s", l.GetPath(key), err)
+			return
+		}
+
+		if req.State == PrePullPending {
+			l.pull(&req, key)
+		} else {
+			select {
+			case <-evt:
+			case <-shutdown:
+				return
+			}
+		}
+
+		close(done)
+		done = make(chan struct{})
+	}
+}
+
+// pull pulls the image of a pending request and records the outcome
+func (l *PrePullListener) pull(req *PrePull, key string) {
+	req.State = PrePullPulling
+	req.Started = time.Now().UTC()
+	req.Error = ""
+	if err := l.conn.Set(l.GetPath(key), req); err != nil {
+		// the request was updated; look it up again
+		glog.V(1).Infof("Could not start pre-pull %s: %s", l.GetPath(key), err)
+		return
+	}
+
+	glog.Infof("Pre-pulling image %s", req.Image)
+	err := l.handler.PullImage(time.After(l.timeout), req.Image)
+	req.Finished = time.Now().UTC()
+	if err != nil {
+		glog.Warningf("Could not pre-pull image %s: %s", req.Image, err)
+		req.State = PrePullFailed
+		req.Error = err.Error()
+	} else {
+		glog.Infof("Pre-pulled image %s", req.Image)
+		req.State = PrePullDone
+	}
+
+	var current PrePull
+	if err := l.conn.Get(l.GetPath(key), &current); err != nil {
+		glog.Errorf("Could not look up pre-pull request %s: %s", l.GetPath(key), err)
+		return
+	} else if current.UUID != req.UUID || current.State != PrePullPulling {
+		// a newer request came in while pulling
+		return
+	}
+	req.SetVersion(current.Version())
+	if err := l.conn.Set(l.GetPath(key), req); err != nil {
+		glog.Errorf("Could not update pre-pull request %s: %s", l.GetPath(key), err)
+	}
+}
+
+// RequestPrePull asks a host to pull an image.  If the host already has (or is
+// pulling) the same version of the image, the request is left alone.
+func RequestPrePull(conn client.Connection, hostID, image, uuid string) error {
+	node := prePullPath(hostID, prePullKey(image))
+	req := &PrePull{
+		HostID:    hostID,
+		Image:     image,
+		UUID:      uuid,
+		State:     PrePullPending,
+		Requested: time.Now().UTC(),
+	}
+
+	var current PrePull
+	if err := conn.Get(node, &current); err == client.ErrNoNode {
+		return conn.Create(node, req)
+	} else if err != nil {
+		return err
+	}
+	if current.UUID == uuid && current.State != PrePullFailed {
+		return nil
+	}
+	req.SetVersion(current.Version())
+	return conn.Set(node, req)
+}
+
+// IsPrePulled returns true if a host has finished pulling an image.  The
+// request is looked up under the pool if a pool is given, for connections
+// that are not rooted at the pool.
+func IsPrePulled(conn client.Connection, poolID, hostID, image string) (bool, error) {
+	basepth := "/"
+	if poolID != "" {
+		basepth = path.Join("/pools", poolID)
+	}
+	var req PrePull
+	if err := conn.Get(path.Join(basepth, prePullPath(hostID, prePullKey(image))), &req); err == client.ErrNoNode {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return req.State == PrePullDone, nil
+}
+
+// GetPrePulls returns the pre-pull requests of every host
+func GetPrePulls(conn client.Connection) ([]PrePull, error) {
+	hostIDs, err := conn.Children(zkPrePull)
+	if err == client.ErrNoNode {
+		return []PrePull{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	reqs := []PrePull{}
+	for _, hostID := range hostIDs {
+		keys, err := conn.Children(prePullPath(hostID))
+		if err == client.ErrNoNode {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			var req PrePull
+			if err := conn.Get(prePullPath(hostID, key), &req); err == client.ErrNoNode {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			reqs = append(reqs, req)
+		}
+	}
+	return reqs, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build integration,!quick
+
+package docker
+
+import (
+	"errors"
+	"time"
+
+	"github.com/control-center/serviced/zzk"
+	. "gopkg.in/check.v1"
+)
+
+type TestPrePullHandler struct {
+	Errs map[string]error
+}
+
+func (handler *TestPrePullHandler) PullImage(cancel <-chan time.Time, image string) error {
+	return handler.Errs[image]
+}
+
+func (t *ZZKTest) TestPrePullListener_Spawn(c *C) {
+	conn, err := zzk.GetLocalConnection("/")
+	c.Assert(err, IsNil)
+
+	handler := &TestPrePullHandler{
+		Errs: map[string]error{"tenant/bad:latest": errors.New("pull failed")},
+	}
+	listener := NewPrePullListener(handler, "test-host-1", time.Minute)
+	listener.SetConnection(conn)
+	c.Assert(conn.CreateDir(listener.GetPath()), IsNil)
+
+	shutdown := make(chan interface{})
+	defer close(shutdown)
+
+	// successful pull
+	c.Assert(RequestPrePull(conn, "test-host-1", "tenant/good:latest", "uuid1"), IsNil)
+	go listener.Spawn(shutdown, prePullKey("tenant/good:latest"))
+
+	// failed pull
+	c.Assert(RequestPrePull(conn, "test-host-1", "tenant/bad:latest", "uuid2"), IsNil)
+	go listener.Spawn(shutdown, prePullKey("tenant/bad:latest"))
+
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+	for {
+		good, err := IsPrePulled(conn, "", "test-host-1", "tenant/good:latest")
+		c.Assert(err, IsNil)
+		reqs, err := GetPrePulls(conn)
+		c.Assert(err, IsNil)
+		failed := false
+		for _, req := range reqs {
+			if req.Image == "tenant/bad:latest" && req.State == PrePullFailed {
+				c.Assert(req.Error, Equals, "pull failed")
+				failed = true
+			}
+		}
+		if good && failed {
+			break
+		}
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-timer.C:
+			c.Fatalf("Timed out waiting for pre-pulls")
+		}
+	}
+
+	// requesting the same image again is a no-op, but a new uuid is pulled
+	c.Assert(RequestPrePull(conn, "test-host-1", "tenant/good:latest", "uuid1"), IsNil)
+	ok, err := IsPrePulled(conn, "", "test-host-1", "tenant/good:latest")
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	c.Assert(RequestPrePull(conn, "test-host-1", "tenant/good:latest", "uuid3"), IsNil)
+	var req PrePull
+	c.Assert(conn.Get(prePullPath("test-host-1", prePullKey("tenant/good:latest")), &req), IsNil)
+	c.Assert(req.UUID, Equals, "uuid3")
+}
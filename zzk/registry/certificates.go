@@ -0,0 +1,62 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"time"
+
+	"github.com/control-center/serviced/coordinator/client"
+)
+
+const zkCertificates = "/net/certificates"
+
+// CertificateSync is updated whenever a vhost or public port certificate
+// changes, so that the hosts serving vhosts and public ports reload them.
+type CertificateSync struct {
+	Updated time.Time
+	version interface{}
+}
+
+// Version implements client.Node
+func (node *CertificateSync) Version() interface{} {
+	return node.version
+}
+
+// SetVersion implements client.Node
+func (node *CertificateSync) SetVersion(version interface{}) {
+	node.version = version
+}
+
+// NotifyCertificates tells the hosts that the certificates have changed
+func NotifyCertificates(conn client.Connection) error {
+	node := &CertificateSync{}
+	if err := conn.Get(zkCertificates, node); err == client.ErrNoNode {
+		node.Updated = time.Now().UTC()
+		return conn.Create(zkCertificates, node)
+	} else if err != nil {
+		return err
+	}
+	node.Updated = time.Now().UTC()
+	return conn.Set(zkCertificates, node)
+}
+
+// WatchCertificates returns an event that fires when the certificates change
+func WatchCertificates(conn client.Connection, done <-chan struct{}) (<-chan client.Event, error) {
+	node := &CertificateSync{}
+	evt, err := conn.GetW(zkCertificates, node, done)
+	if err == client.ErrNoNode {
+		_, evt, err = conn.ExistsW(zkCertificates, done)
+	}
+	return evt, err
+}
@@ -0,0 +1,47 @@
+// Copyright 2018 The Serviced Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build integration,!quick
+
+package registry_test
+
+import (
+	"time"
+
+	"github.com/control-center/serviced/zzk"
+	. "github.com/control-center/serviced/zzk/registry"
+	. "gopkg.in/check.v1"
+)
+
+func (t *ZZKTest) TestWatchCertificates(c *C) {
+	conn, err := zzk.GetLocalConnection("/")
+	c.Assert(err, IsNil)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	// the node does not exist yet
+	for i := 0; i < 2; i++ {
+		evt, err := WatchCertificates(conn, done)
+		c.Assert(err, IsNil)
+
+		err = NotifyCertificates(conn)
+		c.Assert(err, IsNil)
+
+		select {
+		case <-evt:
+		case <-time.After(5 * time.Second):
+			c.Fatalf("Timed out waiting for certificate event %d", i)
+		}
+	}
+}
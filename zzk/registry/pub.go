@@ -18,6 +18,7 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/control-center/serviced/coordinator/client"
+	"github.com/control-center/serviced/domain/servicedefinition"
 )
 
 // PublicPort describes a public endpoint
@@ -27,6 +28,7 @@ type PublicPort struct {
 	ServiceID   string // TODO: search by tenant and application
 	Protocol    string
 	UseTLS      bool
+	Access      servicedefinition.AccessRules
 	version     interface{}
 }
 
@@ -45,6 +47,7 @@ type PublicPortHandler interface {
 	Enable(port string, protocol string, useTLS bool)
 	Disable(port string)
 	Set(port string, exports []ExportDetails)
+	SetAccess(port string, access servicedefinition.AccessRules)
 }
 
 // PublicPortListener listens to ports for a provided ip
@@ -100,6 +103,9 @@ func (l *PublicPortListener) Spawn(shutdown <-chan interface{}, portAddr string)
 	// looked up.
 	exportMap := make(map[string]ExportDetails)
 
+	// the access rules are sent whenever the port changes
+	sendAccess := true
+
 	isEnabled := false
 	defer func() {
 		if isEnabled {
@@ -123,6 +129,11 @@ func (l *PublicPortListener) Spawn(shutdown <-chan interface{}, portAddr string)
 			return
 		}
 
+		if sendAccess {
+			l.handler.SetAccess(portAddr, dat.Access)
+			sendAccess = false
+		}
+
 		// track the exports
 		exLogger := logger.WithFields(log.Fields{
 			"tenantid":    dat.TenantID,
@@ -198,6 +209,7 @@ func (l *PublicPortListener) Spawn(shutdown <-chan interface{}, portAddr string)
 
 		select {
 		case <-evt:
+			sendAccess = true
 		case <-exevt:
 		case <-shutdown:
 			return
@@ -18,6 +18,7 @@ package registry_test
 import (
 	"time"
 
+	"github.com/control-center/serviced/domain/servicedefinition"
 	"github.com/control-center/serviced/zzk"
 	. "github.com/control-center/serviced/zzk/registry"
 	"github.com/control-center/serviced/zzk/registry/mocks"
@@ -35,12 +36,15 @@ func (t *ZZKTest) TestPublicPortListener(c *C) {
 	listener := NewPublicPortListener("master", handler)
 	listener.SetConnection(conn)
 
+	access := servicedefinition.AccessRules{Allow: []string{"10.0.0.0/8"}, RateLimit: 5}
+	handler.On("SetAccess", "10.187.22.151:2181", access).Return().Once()
 	handler.On("Enable", "10.187.22.151:2181", "proto", true).Return().Once()
 	publicPort := &PublicPort{
 		TenantID:    "tenantid",
 		Application: "app",
 		Protocol:    "proto",
 		UseTLS:      true,
+		Access:      access,
 	}
 	err = conn.Create("/net/pub/master/10.187.22.151:2181", publicPort)
 	c.Assert(err, IsNil)
@@ -18,6 +18,7 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/control-center/serviced/coordinator/client"
+	"github.com/control-center/serviced/domain/servicedefinition"
 )
 
 // VHost describes a vhost endpoint
@@ -25,6 +26,8 @@ type VHost struct {
 	TenantID    string
 	ServiceID   string
 	Application string
+	Rules       servicedefinition.VHostRules
+	Access      servicedefinition.AccessRules
 	version     interface{}
 }
 
@@ -43,6 +46,9 @@ type VHostHandler interface {
 	Enable(name string)
 	Disable(name string)
 	Set(name string, exports []ExportDetails)
+	SetRules(name string, rules servicedefinition.VHostRules)
+	SetAccess(name string, access servicedefinition.AccessRules)
+	SetRoute(name, pathPrefix string, exports []ExportDetails)
 }
 
 // VHostListener listens for vhosts on a host
@@ -95,6 +101,18 @@ func (l *VHostListener) Spawn(shutdown <-chan interface{}, subdomain string) {
 	// looked up.
 	exportMap := make(map[string]ExportDetails)
 
+	// keep a watch on the exports of each route, by path prefix
+	routes := make(map[string]*routeWatch)
+	routevt := make(chan *routeWatch)
+	defer func() {
+		for _, w := range routes {
+			close(w.cancel)
+		}
+	}()
+
+	// the rules and access rules are sent whenever the vhost changes
+	sendRules := true
+
 	// keep track of the on/off state of the export
 	isEnabled := false
 	defer func() {
@@ -120,72 +138,80 @@ func (l *VHostListener) Spawn(shutdown <-chan interface{}, subdomain string) {
 			return
 		}
 
+		if sendRules {
+			l.handler.SetRules(subdomain, dat.Rules)
+			l.handler.SetAccess(subdomain, dat.Access)
+			sendRules = false
+		}
+
 		// track the exports
 		exLogger := logger.WithFields(log.Fields{
 			"tenantid":    dat.TenantID,
 			"application": dat.Application,
 		})
 
-		var exevt <-chan client.Event
-		var ch []string
-
 		expth := path.Join("/net/export", dat.TenantID, dat.Application)
+		exports, chMap, sendUpdate, exevt, err := l.watchExports(expth, exportMap, done)
+		if err != nil {
+			exLogger.WithError(err).Error("Could not track exports for endpoint")
+			return
+		}
+		exportMap = chMap
 
-		// keep checking until we have an event or an error
-		for {
-			var ok bool
-			var err error
+		// only send an update if the exports have changed
+		if sendUpdate {
+			l.handler.Set(subdomain, exports)
+		}
 
-			ok, exevt, err = l.conn.ExistsW(expth, done)
-			if err != nil {
-				exLogger.WithError(err).Error("Could not check exports for endpoint")
-				return
+		// track the exports of the routes.  A route is only watched again
+		// once its watch fires or its exports move, so the watches do not
+		// pile up while the exports of the vhost change.
+		prefixes := make(map[string]struct{})
+		for _, route := range dat.Rules.Routes {
+			prefixes[route.PathPrefix] = struct{}{}
+			rtpth := path.Join("/net/export", dat.TenantID, route.Application)
+			w, ok := routes[route.PathPrefix]
+			if ok && w.path == rtpth && !w.fired {
+				continue
 			}
-
+			rtLogger := exLogger.WithFields(log.Fields{
+				"pathprefix":       route.PathPrefix,
+				"routeapplication": route.Application,
+			})
+			var cache map[string]ExportDetails
 			if ok {
-				ch, exevt, err = l.conn.ChildrenW(expth, done)
-				if err == client.ErrNoNode {
-					logger.Debug("VHost suddenly deleted, retrying")
-					close(done)
-					done = make(chan struct{})
-
-					// we need an event, so try again
-					continue
-				} else if err != nil {
-					exLogger.WithFields(log.Fields{
-						"Error": err,
-					}).Error("Could not track exports for endpoint")
-					return
+				close(w.cancel)
+				if w.path == rtpth {
+					cache = w.exports
 				}
 			}
-			break
-		}
-
-		exports := []ExportDetails{}
-
-		// get the exports and update the cache
-		sendUpdate := len(ch) != len(exportMap)
-		chMap := make(map[string]ExportDetails)
-		for _, name := range ch {
-			export, ok := exportMap[name]
-			if !ok {
-				sendUpdate = true
-				if err := l.conn.Get(path.Join(expth, name), &export); err == client.ErrNoNode {
-					continue
-				} else if err != nil {
-					exLogger.WithField("exportkey", name).WithError(err).Error("Could not look up export")
-					return
-				}
+			w = &routeWatch{path: rtpth, cancel: make(chan struct{})}
+			routes[route.PathPrefix] = w
+			rtexports, rtMap, rtUpdate, rtevt, err := l.watchExports(rtpth, cache, w.cancel)
+			if err != nil {
+				rtLogger.WithError(err).Error("Could not track exports for route")
+				return
+			}
+			w.exports = rtMap
+			if rtUpdate || cache == nil {
+				l.handler.SetRoute(subdomain, route.PathPrefix, rtexports)
 			}
-			chMap[name] = export
-			exports = append(exports, export)
+			go func(w *routeWatch, rtevt <-chan client.Event) {
+				select {
+				case <-rtevt:
+					select {
+					case routevt <- w:
+					case <-w.cancel:
+					}
+				case <-w.cancel:
+				}
+			}(w, rtevt)
 		}
-
-		exportMap = chMap
-
-		// only send an update if the exports have changed
-		if sendUpdate {
-			l.handler.Set(subdomain, exports)
+		for prefix, w := range routes {
+			if _, ok := prefixes[prefix]; !ok {
+				close(w.cancel)
+				delete(routes, prefix)
+			}
 		}
 
 		// do something if the state of the vhost has changed
@@ -197,7 +223,10 @@ func (l *VHostListener) Spawn(shutdown <-chan interface{}, subdomain string) {
 
 		select {
 		case <-evt:
+			sendRules = true
 		case <-exevt:
+		case w := <-routevt:
+			w.fired = true
 		case <-shutdown:
 			return
 		}
@@ -206,3 +235,64 @@ func (l *VHostListener) Spawn(shutdown <-chan interface{}, subdomain string) {
 		done = make(chan struct{})
 	}
 }
+
+// routeWatch is the watch on the exports of a vhost route
+type routeWatch struct {
+	path    string
+	exports map[string]ExportDetails
+	cancel  chan struct{}
+	fired   bool
+}
+
+// watchExports looks up the exports at expth, and returns them along with an
+// updated cache, whether they differ from the cache, and an event that fires
+// when they change.
+func (l *VHostListener) watchExports(expth string, cache map[string]ExportDetails, done <-chan struct{}) ([]ExportDetails, map[string]ExportDetails, bool, <-chan client.Event, error) {
+	var exevt <-chan client.Event
+	var ch []string
+
+	// keep checking until we have an event or an error
+	for {
+		var ok bool
+		var err error
+
+		ok, exevt, err = l.conn.ExistsW(expth, done)
+		if err != nil {
+			return nil, nil, false, nil, err
+		}
+
+		if ok {
+			ch, exevt, err = l.conn.ChildrenW(expth, done)
+			if err == client.ErrNoNode {
+				plog.WithField("zkpath", expth).Debug("Exports suddenly deleted, retrying")
+
+				// we need an event, so try again
+				continue
+			} else if err != nil {
+				return nil, nil, false, nil, err
+			}
+		}
+		break
+	}
+
+	exports := []ExportDetails{}
+
+	// get the exports and update the cache
+	sendUpdate := len(ch) != len(cache)
+	chMap := make(map[string]ExportDetails)
+	for _, name := range ch {
+		export, ok := cache[name]
+		if !ok {
+			sendUpdate = true
+			if err := l.conn.Get(path.Join(expth, name), &export); err == client.ErrNoNode {
+				continue
+			} else if err != nil {
+				plog.WithField("exportkey", name).WithError(err).Error("Could not look up export")
+				return nil, nil, false, nil, err
+			}
+		}
+		chMap[name] = export
+		exports = append(exports, export)
+	}
+	return exports, chMap, sendUpdate, exevt, nil
+}
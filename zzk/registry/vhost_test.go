@@ -18,6 +18,7 @@ package registry_test
 import (
 	"time"
 
+	"github.com/control-center/serviced/domain/servicedefinition"
 	"github.com/control-center/serviced/zzk"
 	. "github.com/control-center/serviced/zzk/registry"
 	"github.com/control-center/serviced/zzk/registry/mocks"
@@ -35,6 +36,8 @@ func (t *ZZKTest) TestVHostListener(c *C) {
 	listener := NewVHostListener("master", handler)
 	listener.SetConnection(conn)
 
+	handler.On("SetRules", "myhost", servicedefinition.VHostRules{}).Return().Once()
+	handler.On("SetAccess", "myhost", servicedefinition.AccessRules{}).Return().Once()
 	handler.On("Enable", "myhost").Return().Once()
 	vhost := &VHost{
 		TenantID:    "tenantid",
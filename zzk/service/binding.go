@@ -18,6 +18,9 @@ import (
 	"fmt"
 	"strconv"
 	"text/template"
+	"time"
+
+	"github.com/control-center/serviced/domain/servicedefinition"
 )
 
 // set up template function definitions
@@ -39,6 +42,7 @@ func (err TemplateError) Error() string {
 type ImportBinding struct {
 	Application    string
 	Purpose        string // import or import_all
+	Protocol       string // tcp or udp; defaults to the protocol of the export
 	PortNumber     uint16
 	PortTemplate   string
 	VirtualAddress string
@@ -100,4 +104,12 @@ type ExportBinding struct {
 	Protocol           string
 	PortNumber         uint16
 	AssignedPortNumber uint16
+	LoadBalancer       servicedefinition.LoadBalancer
+	DrainPeriod        uint // seconds
+}
+
+// GetDrainPeriod returns how long the open connections to the export get to
+// finish when it goes away.
+func (e ExportBinding) GetDrainPeriod() time.Duration {
+	return time.Duration(e.DrainPeriod) * time.Second
 }
@@ -48,6 +48,7 @@ type ServiceNode struct {
 	ChangeOptions               []servicedefinition.ChangeOption
 	AddressAssignment           addressassignment.AddressAssignment
 	ShouldHaveAddressAssignment bool
+	ImageID                     string
 	//non-service fields
 	Locked  bool
 	version interface{}
@@ -63,6 +64,7 @@ func NewServiceNodeFromService(s *service.Service) (*ServiceNode, error) {
 		RAMCommitment: s.RAMCommitment,
 		ChangeOptions: s.ChangeOptions,
 		HostPolicy:    s.HostPolicy,
+		ImageID:       s.ImageID,
 	}
 
 	// Copy address assignment if it exists. Note whether assignment is expected, so the scheduler can verify it later.
@@ -25,6 +25,7 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/control-center/serviced/coordinator/client"
 	"github.com/control-center/serviced/domain/service"
+	"github.com/control-center/serviced/domain/servicedefinition"
 )
 
 var ErrInstanceNotFound = errors.New("instance is not scheduled to a host")
@@ -67,8 +68,9 @@ type ServiceState struct {
 }
 
 type CurrentStateContainer struct {
-	Status  service.InstanceCurrentState
-	version interface{}
+	Status        service.InstanceCurrentState
+	SnapshotHooks []servicedefinition.SnapshotHookResult // results of the hooks run for the last snapshot
+	version       interface{}
 }
 
 func (s *CurrentStateContainer) Version() interface{} {